@@ -0,0 +1,199 @@
+package v1
+
+import "time"
+
+type ApplicationTagItem struct {
+	Key   string `json:"key" binding:"required" example:"team"`
+	Value string `json:"value" binding:"required" example:"dns"`
+}
+type ApplicationDataItem struct {
+	ID              uint                   `json:"id"`
+	AppID           string                 `json:"appId" example:"dns-app-001"`
+	Name            string                 `json:"name" example:"DNS服务-01"`
+	TypeID          uint                   `json:"typeId" example:"1"`
+	TypeName        string                 `json:"typeName" example:"dns_server"`
+	Version         string                 `json:"version" example:"9.18.1"`
+	Status          string                 `json:"status" example:"running"`
+	ResourceID      string                 `json:"resourceId" example:"server-001"`
+	DeploymentType  string                 `json:"deploymentType" example:"binary"`
+	WorkingDir      string                 `json:"workingDir" example:"/opt/dns"`
+	ExecutablePath  string                 `json:"executablePath" example:"/opt/dns/bin/named"`
+	ListenPorts     map[string]interface{} `json:"listenPorts"`
+	NetworkConfig   map[string]interface{} `json:"networkConfig"`
+	ResourceUsage   map[string]interface{} `json:"resourceUsage"`
+	ResourceLimits  map[string]interface{} `json:"resourceLimits"`
+	Environment     string                 `json:"environment" example:"prod"`
+	TenantID        string                 `json:"tenantId" example:"tenant-001"`
+	ProcessID       int                    `json:"processId" example:"12345"`
+	StartTime       string                 `json:"startTime"`
+	LastHeartbeat   string                 `json:"lastHeartbeat"`
+	HealthStatus    string                 `json:"healthStatus" example:"healthy"`
+	LastHealthCheck string                 `json:"lastHealthCheck"`
+	Description     string                 `json:"description"`
+	Tags            []ApplicationTagItem   `json:"tags"`
+	UpdatedAt       string                 `json:"updatedAt"`
+	CreatedAt       string                 `json:"createdAt"`
+}
+type GetApplicationsRequest struct {
+	Page         int    `form:"page" binding:"required" example:"1"`
+	PageSize     int    `form:"pageSize" binding:"required" example:"10"`
+	Name         string `form:"name" binding:"" example:"DNS"`
+	TypeName     string `form:"typeName" binding:"" example:"dns_server"`
+	Status       string `form:"status" binding:"omitempty,oneof=running stopped starting stopping failed maintenance upgrading" example:"running"`
+	ResourceID   string `form:"resourceId" binding:"" example:"server-001"`
+	Environment  string `form:"environment" binding:"" example:"prod"`
+	TenantID     string `form:"tenantId" binding:"" example:"tenant-001"`
+	HealthStatus string `form:"healthStatus" binding:"" example:"healthy"`
+}
+type GetApplicationsResponseData struct {
+	List  []ApplicationDataItem `json:"list"`
+	Total int64                 `json:"total"`
+}
+type GetApplicationsResponse struct {
+	Response
+	Data GetApplicationsResponseData
+}
+type GetApplicationRequest struct {
+	AppID string `form:"appId" binding:"required" example:"dns-app-001"`
+}
+type GetApplicationResponse struct {
+	Response
+	Data ApplicationDataItem
+}
+
+// ApplicationCreateRequest 登记应用实例，status为登记时的实际状态(默认stopped)，之后只能通过生命周期操作修改
+type ApplicationCreateRequest struct {
+	AppID          string                 `json:"appId" binding:"" example:"dns-app-001"`
+	Name           string                 `json:"name" binding:"required" example:"DNS服务-01"`
+	TypeName       string                 `json:"typeName" binding:"required" example:"dns_server"`
+	Version        string                 `json:"version" binding:"" example:"9.18.1"`
+	Status         string                 `json:"status" binding:"omitempty,oneof=running stopped starting stopping failed maintenance upgrading" example:"stopped"`
+	ResourceID     string                 `json:"resourceId" binding:"required" example:"server-001"`
+	DeploymentType string                 `json:"deploymentType" binding:"" example:"binary"`
+	WorkingDir     string                 `json:"workingDir" binding:"" example:"/opt/dns"`
+	ExecutablePath string                 `json:"executablePath" binding:"" example:"/opt/dns/bin/named"`
+	ListenPorts    map[string]interface{} `json:"listenPorts"`
+	NetworkConfig  map[string]interface{} `json:"networkConfig"`
+	ResourceLimits map[string]interface{} `json:"resourceLimits"`
+	Environment    string                 `json:"environment" binding:"" example:"prod"`
+	TenantID       string                 `json:"tenantId" binding:"" example:"tenant-001"`
+	Description    string                 `json:"description" binding:"" example:"生产环境DNS服务"`
+	Tags           []ApplicationTagItem   `json:"tags" binding:"dive"`
+}
+
+// ApplicationUpdateRequest 修改应用实例的描述信息，状态通过生命周期操作修改
+type ApplicationUpdateRequest struct {
+	AppID          string                 `json:"appId" binding:"required" example:"dns-app-001"`
+	Name           string                 `json:"name" binding:"required" example:"DNS服务-01"`
+	Version        string                 `json:"version" binding:"" example:"9.18.1"`
+	ResourceID     string                 `json:"resourceId" binding:"required" example:"server-001"`
+	DeploymentType string                 `json:"deploymentType" binding:"" example:"binary"`
+	WorkingDir     string                 `json:"workingDir" binding:"" example:"/opt/dns"`
+	ExecutablePath string                 `json:"executablePath" binding:"" example:"/opt/dns/bin/named"`
+	ListenPorts    map[string]interface{} `json:"listenPorts"`
+	NetworkConfig  map[string]interface{} `json:"networkConfig"`
+	ResourceLimits map[string]interface{} `json:"resourceLimits"`
+	Environment    string                 `json:"environment" binding:"" example:"prod"`
+	TenantID       string                 `json:"tenantId" binding:"" example:"tenant-001"`
+	Description    string                 `json:"description" binding:"" example:"生产环境DNS服务"`
+	Tags           []ApplicationTagItem   `json:"tags" binding:"dive"`
+}
+type ApplicationDeleteRequest struct {
+	AppID string `form:"appId" binding:"required" example:"dns-app-001"`
+}
+
+// ApplicationLifecycleRequest 执行生命周期操作，version仅用于finish_upgrade，为空时保留原版本
+type ApplicationLifecycleRequest struct {
+	AppID   string `json:"appId" binding:"required" example:"dns-app-001"`
+	Action  string `json:"action" binding:"required,oneof=start mark_running stop mark_stopped mark_failed begin_upgrade finish_upgrade enter_maintenance leave_maintenance" example:"begin_upgrade"`
+	Version string `json:"version" binding:"" example:"9.18.2"`
+	Reason  string `json:"reason" binding:"" example:"升级到9.18.2"`
+}
+type ApplicationLifecycleResponseData struct {
+	AppID      string `json:"appId" example:"dns-app-001"`
+	FromStatus string `json:"fromStatus" example:"running"`
+	Status     string `json:"status" example:"upgrading"`
+}
+type ApplicationLifecycleResponse struct {
+	Response
+	Data ApplicationLifecycleResponseData
+}
+
+// ApplicationHeartbeatRequest Agent上报的心跳，healthStatus为空时不修改健康状态(心跳超时后恢复上报时置为healthy)
+type ApplicationHeartbeatRequest struct {
+	AppID         string                 `json:"appId" binding:"required" example:"dns-app-001"`
+	ProcessID     int                    `json:"processId" binding:"min=0" example:"12345"`
+	Version       string                 `json:"version" binding:"" example:"9.18.1"`
+	ResourceUsage map[string]interface{} `json:"resourceUsage"`
+	ListenPorts   map[string]interface{} `json:"listenPorts"`
+	HealthStatus  string                 `json:"healthStatus" binding:"omitempty,oneof=healthy unhealthy unknown" example:"healthy"`
+}
+type ApplicationHeartbeatResponseData struct {
+	AppID         string `json:"appId" example:"dns-app-001"`
+	Status        string `json:"status" example:"running"`
+	HealthStatus  string `json:"healthStatus" example:"healthy"`
+	LastHeartbeat string `json:"lastHeartbeat"`
+}
+type ApplicationHeartbeatResponse struct {
+	Response
+	Data ApplicationHeartbeatResponseData
+}
+
+// ApplicationHeartbeatBatchRequest 批量心跳，每条单独处理，部分失败不影响其他实例
+type ApplicationHeartbeatBatchRequest struct {
+	Heartbeats []ApplicationHeartbeatRequest `json:"heartbeats" binding:"required,min=1,max=500,dive"`
+}
+type ApplicationHeartbeatBatchItem struct {
+	AppID        string `json:"appId" example:"dns-app-001"`
+	Success      bool   `json:"success"`
+	Status       string `json:"status" example:"running"`
+	HealthStatus string `json:"healthStatus" example:"healthy"`
+	Message      string `json:"message"`
+}
+type ApplicationHeartbeatBatchResponseData struct {
+	Total   int                             `json:"total"`
+	Success int                             `json:"success"`
+	Failed  int                             `json:"failed"`
+	Results []ApplicationHeartbeatBatchItem `json:"results"`
+}
+type ApplicationHeartbeatBatchResponse struct {
+	Response
+	Data ApplicationHeartbeatBatchResponseData
+}
+
+type GetApplicationProbeResultsRequest struct {
+	Page      int       `form:"page" binding:"required" example:"1"`
+	PageSize  int       `form:"pageSize" binding:"required" example:"10"`
+	AppID     string    `form:"appId" binding:"required" example:"dns-app-001"`
+	Success   *bool     `form:"success" example:"false"`
+	StartTime time.Time `form:"startTime" time_format:"2006-01-02T15:04:05Z07:00" example:"2026-09-01T00:00:00Z"`
+	EndTime   time.Time `form:"endTime" time_format:"2006-01-02T15:04:05Z07:00" example:"2026-09-02T00:00:00Z"`
+}
+type ApplicationProbeResultItem struct {
+	ID           uint   `json:"id"`
+	AppID        string `json:"appId" example:"dns-app-001"`
+	Method       string `json:"method" example:"dns_query"`
+	Target       string `json:"target" example:"192.168.1.10:53"`
+	Success      bool   `json:"success"`
+	LatencyMs    int64  `json:"latencyMs" example:"3"`
+	Message      string `json:"message" example:"no answer"`
+	HealthStatus string `json:"healthStatus" example:"healthy"`
+	CheckedAt    string `json:"checkedAt"`
+}
+type GetApplicationProbeResultsResponseData struct {
+	List  []ApplicationProbeResultItem `json:"list"`
+	Total int64                        `json:"total"`
+}
+type GetApplicationProbeResultsResponse struct {
+	Response
+	Data GetApplicationProbeResultsResponseData
+}
+
+// ApplicationHealthCheckRequest 立即对实例执行一次健康检查，不受检查间隔限制
+type ApplicationHealthCheckRequest struct {
+	AppID string `json:"appId" binding:"required" example:"dns-app-001"`
+}
+type ApplicationHealthCheckResponse struct {
+	Response
+	Data ApplicationProbeResultItem
+}
@@ -0,0 +1,75 @@
+package v1
+
+type ConfigTemplateDataItem struct {
+	ID            uint                   `json:"id"`
+	TemplateID    string                 `json:"templateId" example:"tpl-dns-business"`
+	Name          string                 `json:"name" example:"DNS业务配置模板"`
+	AppTypeID     uint                   `json:"appTypeId" example:"1"`
+	TemplateData  map[string]interface{} `json:"templateData"`
+	Variables     map[string]interface{} `json:"variables"`
+	BusinessTypes []string               `json:"businessTypes"`
+	Environments  []string               `json:"environments"`
+	Version       string                 `json:"version" example:"1.0.0"`
+	Category      string                 `json:"category" example:"business"`
+	IsDefault     bool                   `json:"isDefault" example:"false"`
+	IsActive      bool                   `json:"isActive" example:"true"`
+	CreatedBy     string                 `json:"createdBy" example:"admin"`
+	UpdatedBy     string                 `json:"updatedBy" example:"admin"`
+	Description   string                 `json:"description"`
+	UpdatedAt     string                 `json:"updatedAt"`
+	CreatedAt     string                 `json:"createdAt"`
+}
+type GetConfigTemplatesRequest struct {
+	Page      int    `form:"page" binding:"required" example:"1"`
+	PageSize  int    `form:"pageSize" binding:"required" example:"10"`
+	Name      string `form:"name" binding:"" example:"DNS"`
+	AppTypeID uint   `form:"appTypeId" binding:"" example:"1"`
+	Category  string `form:"category" binding:"" example:"business"`
+}
+type GetConfigTemplatesResponseData struct {
+	List  []ConfigTemplateDataItem `json:"list"`
+	Total int64                    `json:"total"`
+}
+type GetConfigTemplatesResponse struct {
+	Response
+	Data GetConfigTemplatesResponseData
+}
+type GetConfigTemplateRequest struct {
+	TemplateID string `form:"templateId" binding:"required" example:"tpl-dns-business"`
+}
+type GetConfigTemplateResponse struct {
+	Response
+	Data ConfigTemplateDataItem
+}
+
+// ConfigTemplateCreateRequest templateData中的字符串可以用${变量名}引用变量，整个字符串只有一个引用时保留变量值的类型；
+// variables是变量的JSON-Schema定义(properties/required)，变量默认值可以写在属性的default中
+type ConfigTemplateCreateRequest struct {
+	TemplateID    string                 `json:"templateId" binding:"" example:"tpl-dns-business"`
+	Name          string                 `json:"name" binding:"required" example:"DNS业务配置模板"`
+	AppTypeID     uint                   `json:"appTypeId" binding:"required" example:"1"`
+	TemplateData  map[string]interface{} `json:"templateData" binding:"required"`
+	Variables     map[string]interface{} `json:"variables"`
+	BusinessTypes []string               `json:"businessTypes"`
+	Environments  []string               `json:"environments"`
+	Version       string                 `json:"version" binding:"required" example:"1.0.0"`
+	Category      string                 `json:"category" binding:"" example:"business"`
+	IsDefault     bool                   `json:"isDefault" example:"false"`
+	Description   string                 `json:"description" binding:"" example:""`
+}
+type ConfigTemplateUpdateRequest struct {
+	TemplateID    string                 `json:"templateId" binding:"required" example:"tpl-dns-business"`
+	Name          string                 `json:"name" binding:"required" example:"DNS业务配置模板"`
+	TemplateData  map[string]interface{} `json:"templateData" binding:"required"`
+	Variables     map[string]interface{} `json:"variables"`
+	BusinessTypes []string               `json:"businessTypes"`
+	Environments  []string               `json:"environments"`
+	Version       string                 `json:"version" binding:"required" example:"1.1.0"`
+	Category      string                 `json:"category" binding:"" example:"business"`
+	IsDefault     bool                   `json:"isDefault" example:"false"`
+	IsActive      bool                   `json:"isActive" example:"true"`
+	Description   string                 `json:"description" binding:"" example:""`
+}
+type ConfigTemplateDeleteRequest struct {
+	TemplateID string `form:"templateId" binding:"required" example:"tpl-dns-business"`
+}
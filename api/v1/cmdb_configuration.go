@@ -0,0 +1,317 @@
+package v1
+
+import "time"
+
+type ConfigurationTagItem struct {
+	Key   string `json:"key" binding:"required" example:"team"`
+	Value string `json:"value" binding:"required" example:"dns"`
+}
+type ConfigurationDataItem struct {
+	ID            uint                   `json:"id"`
+	ConfigID      string                 `json:"configId" example:"dns-config-web-001"`
+	Name          string                 `json:"name" example:"DNS-Web业务配置"`
+	ApplicationID uint                   `json:"applicationId" example:"1"`
+	BusinessID    string                 `json:"businessId" example:"web-service"`
+	ServiceID     string                 `json:"serviceId" example:"web-service-001"`
+	TenantID      string                 `json:"tenantId" example:"tenant-001"`
+	ConfigType    string                 `json:"configType" example:"business"`
+	ConfigData    map[string]interface{} `json:"configData"`
+	ConfigFormat  string                 `json:"configFormat" example:"json"`
+	Source        string                 `json:"source" example:"manual"`
+	TemplateID    string                 `json:"templateId" example:""`
+	Priority      int                    `json:"priority" example:"1"`
+	ConfigGroup   string                 `json:"configGroup" example:"web-business"`
+	Status        string                 `json:"status" example:"active"`
+	IsEncrypted   bool                   `json:"isEncrypted" example:"false"`
+	Version       string                 `json:"version" example:"3"`
+	CreatedBy     string                 `json:"createdBy" example:"admin"`
+	UpdatedBy     string                 `json:"updatedBy" example:"admin"`
+	AppliedAt     string                 `json:"appliedAt"`
+	EffectiveTime string                 `json:"effectiveTime"`
+	ExpireTime    string                 `json:"expireTime"`
+	Description   string                 `json:"description"`
+	Tags          []ConfigurationTagItem `json:"tags"`
+	UpdatedAt     string                 `json:"updatedAt"`
+	CreatedAt     string                 `json:"createdAt"`
+}
+type GetConfigurationsRequest struct {
+	Page          int    `form:"page" binding:"required" example:"1"`
+	PageSize      int    `form:"pageSize" binding:"required" example:"10"`
+	Name          string `form:"name" binding:"" example:"DNS"`
+	ApplicationID uint   `form:"applicationId" binding:"" example:"1"`
+	BusinessID    string `form:"businessId" binding:"" example:"web-service"`
+	ServiceID     string `form:"serviceId" binding:"" example:"web-service-001"`
+	TenantID      string `form:"tenantId" binding:"" example:"tenant-001"`
+	ConfigType    string `form:"configType" binding:"" example:"business"`
+	ConfigGroup   string `form:"configGroup" binding:"" example:"web-business"`
+	Status        string `form:"status" binding:"omitempty,oneof=active inactive pending" example:"active"`
+	Reveal        bool   `form:"reveal" example:"false"`
+}
+type GetConfigurationsResponseData struct {
+	List  []ConfigurationDataItem `json:"list"`
+	Total int64                   `json:"total"`
+}
+type GetConfigurationsResponse struct {
+	Response
+	Data GetConfigurationsResponseData
+}
+type GetConfigurationRequest struct {
+	ConfigID string `form:"configId" binding:"required" example:"dns-config-web-001"`
+	Reveal   bool   `form:"reveal" example:"false"`
+}
+type GetConfigurationResponse struct {
+	Response
+	Data ConfigurationDataItem
+}
+
+// ConfigurationCreateRequest 创建配置并以configData生成第一个草稿版本，发布前配置处于pending状态
+type ConfigurationCreateRequest struct {
+	ConfigID      string                 `json:"configId" binding:"" example:"dns-config-web-001"`
+	Name          string                 `json:"name" binding:"required" example:"DNS-Web业务配置"`
+	ApplicationID uint                   `json:"applicationId" binding:"required" example:"1"`
+	BusinessID    string                 `json:"businessId" binding:"" example:"web-service"`
+	ServiceID     string                 `json:"serviceId" binding:"" example:"web-service-001"`
+	TenantID      string                 `json:"tenantId" binding:"" example:"tenant-001"`
+	ConfigType    string                 `json:"configType" binding:"required" example:"business"`
+	ConfigData    map[string]interface{} `json:"configData" binding:"required"`
+	ConfigFormat  string                 `json:"configFormat" binding:"" example:"json"`
+	Source        string                 `json:"source" binding:"" example:"manual"`
+	TemplateID    string                 `json:"templateId" binding:"" example:""`
+	Priority      int                    `json:"priority" binding:"" example:"1"`
+	ConfigGroup   string                 `json:"configGroup" binding:"" example:"web-business"`
+	IsEncrypted   bool                   `json:"isEncrypted" example:"false"`
+	EffectiveTime *time.Time             `json:"effectiveTime" example:"2026-09-01T00:00:00Z"`
+	ExpireTime    *time.Time             `json:"expireTime" example:"2027-09-01T00:00:00Z"`
+	Comment       string                 `json:"comment" binding:"" example:"初始配置"`
+	Description   string                 `json:"description" binding:"" example:"Web业务的DNS配置"`
+	Tags          []ConfigurationTagItem `json:"tags" binding:"dive"`
+}
+type ConfigurationCreateResponseData struct {
+	ConfigID string `json:"configId" example:"dns-config-web-001"`
+	Revision int    `json:"revision" example:"1"`
+}
+type ConfigurationCreateResponse struct {
+	Response
+	Data ConfigurationCreateResponseData
+}
+
+// ConfigurationUpdateRequest 只修改配置的描述信息，配置数据通过版本修改
+type ConfigurationUpdateRequest struct {
+	ConfigID    string                 `json:"configId" binding:"required" example:"dns-config-web-001"`
+	Name        string                 `json:"name" binding:"required" example:"DNS-Web业务配置"`
+	BusinessID  string                 `json:"businessId" binding:"" example:"web-service"`
+	ServiceID   string                 `json:"serviceId" binding:"" example:"web-service-001"`
+	TenantID    string                 `json:"tenantId" binding:"" example:"tenant-001"`
+	Priority    int                    `json:"priority" binding:"" example:"1"`
+	ConfigGroup string                 `json:"configGroup" binding:"" example:"web-business"`
+	Status      string                 `json:"status" binding:"omitempty,oneof=active inactive" example:"active"`
+	Description string                 `json:"description" binding:"" example:"Web业务的DNS配置"`
+	Tags        []ConfigurationTagItem `json:"tags" binding:"dive"`
+}
+type ConfigurationDeleteRequest struct {
+	ConfigID string `form:"configId" binding:"required" example:"dns-config-web-001"`
+}
+
+type ConfigRevisionDataItem struct {
+	ID             uint                   `json:"id"`
+	ConfigID       string                 `json:"configId" example:"dns-config-web-001"`
+	Revision       int                    `json:"revision" example:"3"`
+	Status         string                 `json:"status" example:"published"`
+	ConfigData     map[string]interface{} `json:"configData"`
+	ConfigFormat   string                 `json:"configFormat" example:"json"`
+	IsEncrypted    bool                   `json:"isEncrypted" example:"false"`
+	BaseRevision   int                    `json:"baseRevision" example:"2"`
+	SourceRevision int                    `json:"sourceRevision" example:"0"`
+	Comment        string                 `json:"comment" example:"调整缓存大小"`
+	EffectiveTime  string                 `json:"effectiveTime"`
+	ExpireTime     string                 `json:"expireTime"`
+	CreatedBy      string                 `json:"createdBy" example:"admin"`
+	SubmittedBy    string                 `json:"submittedBy" example:"admin"`
+	SubmittedAt    string                 `json:"submittedAt"`
+	ApprovedBy     string                 `json:"approvedBy" example:"admin"`
+	ApprovedAt     string                 `json:"approvedAt"`
+	PublishedAt    string                 `json:"publishedAt"`
+	UpdatedAt      string                 `json:"updatedAt"`
+	CreatedAt      string                 `json:"createdAt"`
+}
+type GetConfigRevisionsRequest struct {
+	Page     int    `form:"page" binding:"required" example:"1"`
+	PageSize int    `form:"pageSize" binding:"required" example:"10"`
+	ConfigID string `form:"configId" binding:"required" example:"dns-config-web-001"`
+	Status   string `form:"status" binding:"omitempty,oneof=draft pending scheduled published superseded" example:"published"`
+	Reveal   bool   `form:"reveal" example:"false"`
+}
+type GetConfigRevisionsResponseData struct {
+	List  []ConfigRevisionDataItem `json:"list"`
+	Total int64                    `json:"total"`
+}
+type GetConfigRevisionsResponse struct {
+	Response
+	Data GetConfigRevisionsResponseData
+}
+type GetConfigRevisionRequest struct {
+	ConfigID string `form:"configId" binding:"required" example:"dns-config-web-001"`
+	Revision int    `form:"revision" binding:"required,min=1" example:"3"`
+	Reveal   bool   `form:"reveal" example:"false"`
+}
+type GetConfigRevisionResponse struct {
+	Response
+	Data ConfigRevisionDataItem
+}
+
+// ConfigRevisionCreateRequest 基于baseRevision创建草稿，未指定时基于当前生效的版本；敏感字段传回脱敏占位符表示沿用基础版本的值
+type ConfigRevisionCreateRequest struct {
+	ConfigID      string                 `json:"configId" binding:"required" example:"dns-config-web-001"`
+	BaseRevision  int                    `json:"baseRevision" binding:"min=0" example:"2"`
+	ConfigData    map[string]interface{} `json:"configData" binding:"required"`
+	EffectiveTime *time.Time             `json:"effectiveTime" example:"2026-09-01T00:00:00Z"`
+	ExpireTime    *time.Time             `json:"expireTime" example:"2027-09-01T00:00:00Z"`
+	Comment       string                 `json:"comment" binding:"" example:"调整缓存大小"`
+}
+type ConfigRevisionCreateResponseData struct {
+	Revision int `json:"revision" example:"3"`
+}
+type ConfigRevisionCreateResponse struct {
+	Response
+	Data ConfigRevisionCreateResponseData
+}
+
+// ConfigRevisionUpdateRequest 只能修改草稿
+type ConfigRevisionUpdateRequest struct {
+	ConfigID      string                 `json:"configId" binding:"required" example:"dns-config-web-001"`
+	Revision      int                    `json:"revision" binding:"required,min=1" example:"3"`
+	ConfigData    map[string]interface{} `json:"configData" binding:"required"`
+	EffectiveTime *time.Time             `json:"effectiveTime" example:"2026-09-01T00:00:00Z"`
+	ExpireTime    *time.Time             `json:"expireTime" example:"2027-09-01T00:00:00Z"`
+	Comment       string                 `json:"comment" binding:"" example:"调整缓存大小"`
+}
+
+type ConfigRevisionDeleteRequest struct {
+	ConfigID string `form:"configId" binding:"required" example:"dns-config-web-001"`
+	Revision int    `form:"revision" binding:"required,min=1" example:"3"`
+}
+
+// ConfigRevisionActionRequest 提交、驳回和发布共用
+type ConfigRevisionActionRequest struct {
+	ConfigID string `json:"configId" binding:"required" example:"dns-config-web-001"`
+	Revision int    `json:"revision" binding:"required,min=1" example:"3"`
+}
+type ConfigRevisionPublishResponseData struct {
+	Revision int    `json:"revision" example:"3"`
+	Status   string `json:"status" example:"published"`
+}
+type ConfigRevisionPublishResponse struct {
+	Response
+	Data ConfigRevisionPublishResponseData
+}
+
+// ConfigRollbackRequest 复制一个曾经发布过的版本为新版本并立即发布
+type ConfigRollbackRequest struct {
+	ConfigID string `json:"configId" binding:"required" example:"dns-config-web-001"`
+	Revision int    `json:"revision" binding:"required,min=1" example:"2"`
+	Comment  string `json:"comment" binding:"" example:"缓存调整导致命中率下降"`
+}
+type ConfigRollbackResponseData struct {
+	Revision int `json:"revision" example:"4"`
+}
+type ConfigRollbackResponse struct {
+	Response
+	Data ConfigRollbackResponseData
+}
+
+// ConfigRevisionDiffRequest from为0时与to的基础版本比较
+type ConfigRevisionDiffRequest struct {
+	ConfigID string `form:"configId" binding:"required" example:"dns-config-web-001"`
+	From     int    `form:"from" binding:"min=0" example:"2"`
+	To       int    `form:"to" binding:"required,min=1" example:"3"`
+	Reveal   bool   `form:"reveal" example:"false"`
+}
+type ConfigRevisionDiffResponseData struct {
+	ConfigID      string                 `json:"configId" example:"dns-config-web-001"`
+	From          int                    `json:"from" example:"2"`
+	To            int                    `json:"to" example:"3"`
+	ChangedFields map[string]interface{} `json:"changedFields"`
+}
+type ConfigRevisionDiffResponse struct {
+	Response
+	Data ConfigRevisionDiffResponseData
+}
+
+// ConfigurationRenderRequest 用模板渲染新配置，templateId为空时使用应用类型的默认模板。
+// 变量取值优先级: variables > 模板变量定义的default > 应用类型的DefaultConfig
+type ConfigurationRenderRequest struct {
+	TemplateID    string                 `json:"templateId" binding:"" example:"tpl-dns-business"`
+	ApplicationID uint                   `json:"applicationId" binding:"required" example:"1"`
+	ConfigID      string                 `json:"configId" binding:"" example:"dns-config-web-002"`
+	Name          string                 `json:"name" binding:"required" example:"DNS-Web业务配置"`
+	BusinessID    string                 `json:"businessId" binding:"" example:"web-service"`
+	ServiceID     string                 `json:"serviceId" binding:"" example:"web-service-001"`
+	TenantID      string                 `json:"tenantId" binding:"" example:"tenant-001"`
+	ConfigType    string                 `json:"configType" binding:"required" example:"business"`
+	ConfigFormat  string                 `json:"configFormat" binding:"" example:"json"`
+	Priority      int                    `json:"priority" binding:"" example:"1"`
+	ConfigGroup   string                 `json:"configGroup" binding:"" example:"web-business"`
+	IsEncrypted   bool                   `json:"isEncrypted" example:"false"`
+	Variables     map[string]interface{} `json:"variables"`
+	Comment       string                 `json:"comment" binding:"" example:""`
+	Description   string                 `json:"description" binding:"" example:""`
+	Tags          []ConfigurationTagItem `json:"tags"`
+}
+type ConfigurationRenderResponseData struct {
+	ConfigID        string `json:"configId" example:"dns-config-web-002"`
+	Revision        int    `json:"revision" example:"1"`
+	TemplateID      string `json:"templateId" example:"tpl-dns-business"`
+	TemplateVersion string `json:"templateVersion" example:"1.0.0"`
+}
+type ConfigurationRenderResponse struct {
+	Response
+	Data ConfigurationRenderResponseData
+}
+
+// ConfigurationRerenderRequest 用模板当前版本和渲染时的变量重新渲染派生的配置，生成待审核的草稿版本。
+// 发布的内容与上次渲染结果不一致(被手工修改过)的配置只报告差异，force为true时覆盖
+type ConfigurationRerenderRequest struct {
+	TemplateID string   `json:"templateId" binding:"required" example:"tpl-dns-business"`
+	ConfigIDs  []string `json:"configIds"`
+	Force      bool     `json:"force" example:"false"`
+	DryRun     bool     `json:"dryRun" example:"true"`
+}
+type ConfigurationRerenderItem struct {
+	ConfigID        string   `json:"configId" example:"dns-config-web-002"`
+	PreviousVersion string   `json:"previousVersion" example:"1.0.0"`
+	Status          string   `json:"status" example:"rendered"` // rendered/unchanged/drifted/pending/failed
+	Revision        int      `json:"revision" example:"3"`
+	DriftedFields   []string `json:"driftedFields"`
+	Message         string   `json:"message"`
+}
+type ConfigurationRerenderResponseData struct {
+	TemplateID      string                      `json:"templateId" example:"tpl-dns-business"`
+	TemplateVersion string                      `json:"templateVersion" example:"1.1.0"`
+	Items           []ConfigurationRerenderItem `json:"items"`
+}
+type ConfigurationRerenderResponse struct {
+	Response
+	Data ConfigurationRerenderResponseData
+}
+
+// ConfigurationLintRequest 按应用类型当前的ConfigSchema检查该类型全部配置已发布的内容和未发布的版本
+type ConfigurationLintRequest struct {
+	TypeName string `form:"typeName" binding:"required" example:"dns_server"`
+}
+type ConfigurationLintItem struct {
+	ConfigID   string       `json:"configId" example:"dns-config-web-001"`
+	Name       string       `json:"name" example:"DNS-Web业务配置"`
+	Revision   int          `json:"revision" example:"0"`       // 0表示配置当前发布的内容
+	Status     string       `json:"status" example:"published"` // published/draft/pending/scheduled
+	Violations []FieldError `json:"violations"`
+}
+type ConfigurationLintResponseData struct {
+	TypeName string                  `json:"typeName" example:"dns_server"`
+	Total    int                     `json:"total" example:"10"`
+	Checked  int                     `json:"checked" example:"12"`
+	Invalid  []ConfigurationLintItem `json:"invalid"`
+}
+type ConfigurationLintResponse struct {
+	Response
+	Data ConfigurationLintResponseData
+}
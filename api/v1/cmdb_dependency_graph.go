@@ -0,0 +1,78 @@
+package v1
+
+type DependencyGraphKey struct {
+	GraphType   string `json:"graphType" form:"graphType" binding:"required,oneof=dependency topology data_flow deployment network_flow" example:"dependency"`
+	RootType    string `json:"rootType" form:"rootType" binding:"required" example:"business"`
+	RootID      string `json:"rootId" form:"rootId" binding:"required" example:"biz-001"`
+	Environment string `json:"environment" form:"environment" binding:"" example:"prod"`
+	TenantID    string `json:"tenantId" form:"tenantId" binding:"" example:"tenant-001"`
+}
+type DependencyGraphComputeRequest struct {
+	DependencyGraphKey
+	Depth int `json:"depth" binding:"omitempty,min=1,max=10" example:"5"`
+}
+type GetDependencyGraphRequest struct {
+	DependencyGraphKey
+	Version int64 `form:"version" binding:"omitempty,min=1" example:"1"`
+}
+type GetDependencyGraphVersionsRequest struct {
+	DependencyGraphKey
+}
+type DependencyGraphDiffRequest struct {
+	DependencyGraphKey
+	FromVersion int64 `form:"fromVersion" binding:"required,min=1" example:"1"`
+	ToVersion   int64 `form:"toVersion" binding:"required,min=1" example:"2"`
+}
+type DependencyGraphVersionItem struct {
+	GraphID      string `json:"graphId" example:"1a2b3c"`
+	GraphType    string `json:"graphType" example:"dependency"`
+	RootType     string `json:"rootType" example:"business"`
+	RootID       string `json:"rootId" example:"biz-001"`
+	Environment  string `json:"environment" example:"prod"`
+	TenantID     string `json:"tenantId" example:"tenant-001"`
+	Version      int64  `json:"version" example:"2"`
+	NodeCount    int    `json:"nodeCount" example:"12"`
+	EdgeCount    int    `json:"edgeCount" example:"14"`
+	MaxDepth     int    `json:"maxDepth" example:"3"`
+	CalcTime     string `json:"calcTime" example:"2006-01-02 15:04:05"`
+	CalcDuration int64  `json:"calcDuration" example:"8"`
+	ExpiresAt    string `json:"expiresAt" example:"2006-01-02 15:04:05"`
+	AccessCount  int    `json:"accessCount" example:"3"`
+}
+type DependencyGraphDataItem struct {
+	DependencyGraphVersionItem
+	Nodes     []GraphNode `json:"nodes"`
+	Edges     []GraphEdge `json:"edges"`
+	Truncated bool        `json:"truncated" example:"false"`
+}
+type DependencyGraphComputeResponseData struct {
+	DependencyGraphVersionItem
+	Changed bool `json:"changed" example:"true"`
+}
+type DependencyGraphComputeResponse struct {
+	Response
+	Data DependencyGraphComputeResponseData
+}
+type GetDependencyGraphResponse struct {
+	Response
+	Data DependencyGraphDataItem
+}
+type GetDependencyGraphVersionsResponseData struct {
+	List []DependencyGraphVersionItem `json:"list"`
+}
+type GetDependencyGraphVersionsResponse struct {
+	Response
+	Data GetDependencyGraphVersionsResponseData
+}
+type DependencyGraphDiffResponseData struct {
+	FromVersion  int64       `json:"fromVersion" example:"1"`
+	ToVersion    int64       `json:"toVersion" example:"2"`
+	NodesAdded   []GraphNode `json:"nodesAdded"`
+	NodesRemoved []GraphNode `json:"nodesRemoved"`
+	EdgesAdded   []GraphEdge `json:"edgesAdded"`
+	EdgesRemoved []GraphEdge `json:"edgesRemoved"`
+}
+type DependencyGraphDiffResponse struct {
+	Response
+	Data DependencyGraphDiffResponseData
+}
@@ -0,0 +1,41 @@
+package v1
+
+import "time"
+
+type GraphExpandRequest struct {
+	Type            string    `form:"type" binding:"required" example:"resource"`
+	ID              string    `form:"id" binding:"required" example:"server-001"`
+	Depth           int       `form:"depth" binding:"omitempty,min=1,max=5" example:"2"`
+	Direction       string    `form:"direction" binding:"omitempty,oneof=out in both" example:"both"`
+	RelationTypes   []string  `form:"relationTypes" binding:"" example:"hosts,runs_on"`
+	Environment     string    `form:"environment" binding:"" example:"prod"`
+	TenantID        string    `form:"tenantId" binding:"" example:"tenant-001"`
+	IncludeInactive bool      `form:"includeInactive" binding:"" example:"false"`
+	AsOf            time.Time `form:"as_of" time_format:"2006-01-02T15:04:05Z07:00" example:"2026-09-01T00:00:00Z"`
+}
+type GraphNode struct {
+	ID       string `json:"id" example:"resource:server-001"`
+	Type     string `json:"type" example:"resource"`
+	ObjectID string `json:"objectId" example:"server-001"`
+	Name     string `json:"name" example:"Web服务器-01"`
+	SubType  string `json:"subType" example:"server"`
+	Depth    int    `json:"depth" example:"0"`
+}
+type GraphEdge struct {
+	ID           string                 `json:"id" example:"rel-resource-app-dns-001"`
+	Source       string                 `json:"source" example:"resource:server-001"`
+	Target       string                 `json:"target" example:"application:dns-app-001"`
+	RelationType string                 `json:"relationType" example:"hosts"`
+	Direction    string                 `json:"direction" example:"forward"`
+	Weight       float64                `json:"weight" example:"1"`
+	Properties   map[string]interface{} `json:"properties"`
+}
+type GraphExpandResponseData struct {
+	Nodes     []GraphNode `json:"nodes"`
+	Edges     []GraphEdge `json:"edges"`
+	Truncated bool        `json:"truncated" example:"false"`
+}
+type GraphExpandResponse struct {
+	Response
+	Data GraphExpandResponseData
+}
@@ -0,0 +1,96 @@
+package v1
+
+type ServiceHealthRuleData struct {
+	ServiceID            string   `json:"serviceId" example:"web-service-001"`
+	Enabled              bool     `json:"enabled" example:"true"`
+	DownBelowMembers     int      `json:"downBelowMembers" example:"1"`
+	MinHealthyMembers    int      `json:"minHealthyMembers" example:"2"`
+	MinHealthyRatio      float64  `json:"minHealthyRatio" example:"0.5"`
+	CriticalRoles        []string `json:"criticalRoles" example:"primary"`
+	CriticalDependencies []string `json:"criticalDependencies" example:"db-service-001"`
+	DegradeOnDependency  bool     `json:"degradeOnDependency" example:"true"`
+	Description          string   `json:"description"`
+	// IsDefault 服务没有配置规则，返回的是默认规则
+	IsDefault bool `json:"isDefault"`
+}
+type GetServiceHealthRuleRequest struct {
+	ServiceID string `form:"serviceId" binding:"required" example:"web-service-001"`
+}
+type GetServiceHealthRuleResponse struct {
+	Response
+	Data ServiceHealthRuleData
+}
+
+// ServiceHealthRuleUpdateRequest 创建或替换服务的健康汇总规则，enabled为空时默认启用
+type ServiceHealthRuleUpdateRequest struct {
+	ServiceID            string   `json:"serviceId" binding:"required" example:"web-service-001"`
+	Enabled              *bool    `json:"enabled" example:"true"`
+	DownBelowMembers     int      `json:"downBelowMembers" binding:"min=0" example:"1"`
+	MinHealthyMembers    int      `json:"minHealthyMembers" binding:"min=0" example:"2"`
+	MinHealthyRatio      float64  `json:"minHealthyRatio" binding:"min=0,max=1" example:"0.5"`
+	CriticalRoles        []string `json:"criticalRoles" binding:"dive,required" example:"primary"`
+	CriticalDependencies []string `json:"criticalDependencies" binding:"dive,required" example:"db-service-001"`
+	DegradeOnDependency  bool     `json:"degradeOnDependency" example:"true"`
+	Description          string   `json:"description" example:"至少2个健康实例"`
+}
+
+// ServiceHealthRuleDeleteRequest 删除规则后恢复使用默认规则
+type ServiceHealthRuleDeleteRequest struct {
+	ServiceID string `form:"serviceId" binding:"required" example:"web-service-001"`
+}
+
+type GetServiceHealthRequest struct {
+	ServiceID string `form:"serviceId" binding:"required" example:"web-service-001"`
+}
+type ServiceHealthMemberItem struct {
+	AppID        string `json:"appId" example:"dns-app-001"`
+	Name         string `json:"name" example:"DNS服务-01"`
+	Role         string `json:"role" example:"primary"`
+	Status       string `json:"status" example:"running"`
+	HealthStatus string `json:"healthStatus" example:"healthy"`
+	Healthy      bool   `json:"healthy"`
+}
+type ServiceHealthDependencyItem struct {
+	ServiceID    string `json:"serviceId" example:"db-service-001"`
+	HealthStatus string `json:"healthStatus" example:"healthy"`
+}
+
+// ServiceHealthResponseData 服务当前保存的健康状态和按规则重新计算的结果
+type ServiceHealthResponseData struct {
+	ServiceID       string                        `json:"serviceId" example:"web-service-001"`
+	HealthStatus    string                        `json:"healthStatus" example:"healthy"`
+	EvaluatedStatus string                        `json:"evaluatedStatus" example:"degraded"`
+	Reasons         []string                      `json:"reasons"`
+	Total           int                           `json:"total" example:"3"`
+	Healthy         int                           `json:"healthy" example:"2"`
+	Members         []ServiceHealthMemberItem     `json:"members"`
+	Dependencies    []ServiceHealthDependencyItem `json:"dependencies"`
+	Rule            ServiceHealthRuleData         `json:"rule"`
+}
+type GetServiceHealthResponse struct {
+	Response
+	Data ServiceHealthResponseData
+}
+
+// HealthRollupRequest 立即汇总服务和业务的健康状态，dryRun时只返回将发生的变化
+type HealthRollupRequest struct {
+	DryRun bool `json:"dryRun" example:"false"`
+}
+type HealthRollupChangeItem struct {
+	ObjectType string `json:"objectType" example:"service"`
+	ObjectID   string `json:"objectId" example:"web-service-001"`
+	Name       string `json:"name" example:"Web服务"`
+	FromStatus string `json:"fromStatus" example:"healthy"`
+	ToStatus   string `json:"toStatus" example:"degraded"`
+	Reason     string `json:"reason" example:"健康成员1个，少于2个"`
+}
+type HealthRollupResponseData struct {
+	DryRun     bool                     `json:"dryRun"`
+	Services   int                      `json:"services"`
+	Businesses int                      `json:"businesses"`
+	Changes    []HealthRollupChangeItem `json:"changes"`
+}
+type HealthRollupResponse struct {
+	Response
+	Data HealthRollupResponseData
+}
@@ -0,0 +1,50 @@
+package v1
+
+type GetHistoriesRequest struct {
+	Page       int    `form:"page" binding:"required" example:"1"`
+	PageSize   int    `form:"pageSize" binding:"required" example:"10"`
+	ObjectType string `form:"objectType" binding:"required,oneof=resource service business resource_relation universal_relation application" example:"resource"`
+	ObjectID   string `form:"objectId" binding:"required" example:"server-001"`
+	ChangeType string `form:"changeType" binding:"omitempty,oneof=create update delete sync" example:"update"`
+	Reveal     bool   `form:"reveal" example:"false"`
+}
+type HistoryDataItem struct {
+	ID            uint                   `json:"id" example:"1"`
+	ObjectType    string                 `json:"objectType" example:"resource"`
+	ObjectID      string                 `json:"objectId" example:"server-001"`
+	ChangeType    string                 `json:"changeType" example:"update"`
+	ChangeSource  string                 `json:"changeSource" example:"api"`
+	ChangeTime    string                 `json:"changeTime" example:"2006-01-02 15:04:05"`
+	OperatorID    string                 `json:"operatorId" example:"1"`
+	OperatorName  string                 `json:"operatorName" example:"admin"`
+	OperatorIP    string                 `json:"operatorIp" example:"10.0.0.1"`
+	BeforeData    map[string]interface{} `json:"beforeData"`
+	AfterData     map[string]interface{} `json:"afterData"`
+	ChangedFields map[string]interface{} `json:"changedFields"`
+	ChangeReason  string                 `json:"changeReason" example:"扩容"`
+	Comment       string                 `json:"comment" example:""`
+	Version       int64                  `json:"version" example:"3"`
+}
+type GetHistoriesResponseData struct {
+	List  []HistoryDataItem `json:"list"`
+	Total int64             `json:"total"`
+}
+type GetHistoriesResponse struct {
+	Response
+	Data GetHistoriesResponseData
+}
+type HistoryRevertRequest struct {
+	ObjectType     string `json:"objectType" binding:"required,oneof=resource service business" example:"resource"`
+	ObjectID       string `json:"objectId" binding:"required" example:"server-001"`
+	Version        int64  `json:"version" binding:"required,min=1" example:"2"`
+	CurrentVersion int64  `json:"currentVersion" binding:"required,min=1" example:"5"`
+	Reason         string `json:"reason" binding:"required" example:"误操作批量修改"`
+}
+type HistoryRevertResponseData struct {
+	Version int64 `json:"version" example:"6"`
+	Changed bool  `json:"changed" example:"true"`
+}
+type HistoryRevertResponse struct {
+	Response
+	Data HistoryRevertResponseData
+}
@@ -0,0 +1,36 @@
+package v1
+
+type GetImpactRequest struct {
+	ResourceID string `form:"resourceId" binding:"required" example:"server-001"`
+	MaxDepth   int    `form:"maxDepth" binding:"omitempty,min=1,max=20" example:"10"`
+}
+type ImpactPathStep struct {
+	ObjectType string `json:"objectType" example:"application"`
+	ObjectID   string `json:"objectId" example:"dns-app-001"`
+	Name       string `json:"name" example:"DNS服务-01"`
+	Relation   string `json:"relation" example:"hosts"`
+	Reverse    bool   `json:"reverse" example:"false"`
+}
+type ImpactItem struct {
+	ObjectType  string           `json:"objectType" example:"application"`
+	ObjectID    string           `json:"objectId" example:"dns-app-001"`
+	Name        string           `json:"name" example:"DNS服务-01"`
+	SubType     string           `json:"subType" example:"dns_server"`
+	Impact      string           `json:"impact" example:"down"`
+	Depth       int              `json:"depth" example:"1"`
+	Criticality string           `json:"criticality" example:"critical"`
+	Priority    int              `json:"priority" example:"1"`
+	Path        []ImpactPathStep `json:"path"`
+	Explanation string           `json:"explanation" example:"Web服务器-01 -[hosts]-> DNS服务-01"`
+}
+type GetImpactResponseData struct {
+	ObjectType string         `json:"objectType" example:"resource"`
+	ObjectID   string         `json:"objectId" example:"server-001"`
+	Name       string         `json:"name" example:"Web服务器-01"`
+	Summary    map[string]int `json:"summary"`
+	Affected   []ImpactItem   `json:"affected"`
+}
+type GetImpactResponse struct {
+	Response
+	Data GetImpactResponseData
+}
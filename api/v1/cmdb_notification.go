@@ -0,0 +1,39 @@
+package v1
+
+type GetNotificationDeliveriesRequest struct {
+	Page       int    `form:"page" binding:"required" example:"1"`
+	PageSize   int    `form:"pageSize" binding:"required" example:"10"`
+	Channel    string `form:"channel" binding:"omitempty,oneof=email webhook" example:"webhook"`
+	Status     string `form:"status" binding:"omitempty,oneof=pending sent failed" example:"failed"`
+	ObjectType string `form:"objectType" binding:"" example:"resource"`
+	ObjectID   string `form:"objectId" binding:"" example:"server-001"`
+}
+type NotificationDeliveryDataItem struct {
+	ID             uint                   `json:"id"`
+	ObjectType     string                 `json:"objectType" example:"resource"`
+	ObjectID       string                 `json:"objectId" example:"server-001"`
+	ChangeType     string                 `json:"changeType" example:"update"`
+	ChangeTime     string                 `json:"changeTime" example:"2006-01-02 15:04:05"`
+	HistoryVersion int64                  `json:"historyVersion" example:"3"`
+	AuditConfigID  uint                   `json:"auditConfigId" example:"1"`
+	Channel        string                 `json:"channel" example:"webhook"`
+	Target         string                 `json:"target" example:"https://hooks.example.com/cmdb"`
+	Payload        map[string]interface{} `json:"payload"`
+	Status         string                 `json:"status" example:"sent"`
+	Attempts       int                    `json:"attempts" example:"1"`
+	NextRetryAt    string                 `json:"nextRetryAt" example:"2006-01-02 15:04:05"`
+	LastError      string                 `json:"lastError" example:""`
+	SentAt         string                 `json:"sentAt" example:"2006-01-02 15:04:05"`
+	CreatedAt      string                 `json:"createdAt"`
+}
+type GetNotificationDeliveriesResponseData struct {
+	List  []NotificationDeliveryDataItem `json:"list"`
+	Total int64                          `json:"total"`
+}
+type GetNotificationDeliveriesResponse struct {
+	Response
+	Data GetNotificationDeliveriesResponseData
+}
+type NotificationRetryRequest struct {
+	ID uint `json:"id" binding:"required" example:"1"`
+}
@@ -0,0 +1,38 @@
+package v1
+
+type GetRelationPathsRequest struct {
+	StartType string `form:"startType" binding:"required" example:"resource"`
+	StartID   string `form:"startId" binding:"required" example:"server-001"`
+	EndType   string `form:"endType" binding:"required" example:"business"`
+	EndID     string `form:"endId" binding:"required" example:"biz-001"`
+	Mode      string `form:"mode" binding:"omitempty,oneof=shortest all" example:"shortest"`
+	MaxDepth  int    `form:"maxDepth" binding:"omitempty,min=1,max=6" example:"4"`
+	Direction string `form:"direction" binding:"omitempty,oneof=out both" example:"out"`
+	Refresh   bool   `form:"refresh" binding:"" example:"false"`
+}
+type RelationPathStep struct {
+	Relation     string  `json:"relation" example:"universal:rel-resource-app-dns-001"`
+	RelationType string  `json:"relationType" example:"hosts"`
+	From         string  `json:"from" example:"resource:server-001"`
+	To           string  `json:"to" example:"application:dns-app-001"`
+	Weight       float64 `json:"weight" example:"1"`
+}
+type RelationPathItem struct {
+	Nodes         []string           `json:"nodes"`
+	Steps         []RelationPathStep `json:"steps"`
+	Length        int                `json:"length" example:"2"`
+	Weight        float64            `json:"weight" example:"2"`
+	RelationChain string             `json:"relationChain" example:"hosts->serves"`
+}
+type GetRelationPathsResponseData struct {
+	Mode        string             `json:"mode" example:"shortest"`
+	Paths       []RelationPathItem `json:"paths"`
+	Cached      bool               `json:"cached" example:"true"`
+	CacheTime   string             `json:"cacheTime" example:"2006-01-02 15:04:05"`
+	ExpiresAt   string             `json:"expiresAt" example:"2006-01-02 15:04:05"`
+	AccessCount int                `json:"accessCount" example:"3"`
+}
+type GetRelationPathsResponse struct {
+	Response
+	Data GetRelationPathsResponseData
+}
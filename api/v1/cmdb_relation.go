@@ -0,0 +1,120 @@
+package v1
+
+type UniversalRelationDataItem struct {
+	ID           uint                   `json:"id"`
+	RelationID   string                 `json:"relationId" example:"rel-resource-app-dns-001"`
+	SourceType   string                 `json:"sourceType" example:"resource"`
+	SourceID     string                 `json:"sourceId" example:"server-001"`
+	SourceName   string                 `json:"sourceName" example:"Web服务器-01"`
+	TargetType   string                 `json:"targetType" example:"application"`
+	TargetID     string                 `json:"targetId" example:"dns-app-001"`
+	TargetName   string                 `json:"targetName" example:"DNS服务-01"`
+	RelationType string                 `json:"relationType" example:"hosts"`
+	Direction    string                 `json:"direction" example:"forward"`
+	Weight       float64                `json:"weight" example:"1"`
+	Priority     int                    `json:"priority" example:"1"`
+	Properties   map[string]interface{} `json:"properties"`
+	Environment  string                 `json:"environment" example:"prod"`
+	TenantID     string                 `json:"tenantId" example:"tenant-001"`
+	Status       string                 `json:"status" example:"active"`
+	Description  string                 `json:"description"`
+	UpdatedAt    string                 `json:"updatedAt"`
+	CreatedAt    string                 `json:"createdAt"`
+}
+type GetUniversalRelationsRequest struct {
+	Page         int    `form:"page" binding:"required" example:"1"`
+	PageSize     int    `form:"pageSize" binding:"required" example:"10"`
+	SourceType   string `form:"sourceType" binding:"" example:"resource"`
+	SourceID     string `form:"sourceId" binding:"" example:"server-001"`
+	TargetType   string `form:"targetType" binding:"" example:"application"`
+	TargetID     string `form:"targetId" binding:"" example:"dns-app-001"`
+	RelationType string `form:"relationType" binding:"" example:"hosts"`
+}
+type GetUniversalRelationsResponseData struct {
+	List  []UniversalRelationDataItem `json:"list"`
+	Total int64                       `json:"total"`
+}
+type GetUniversalRelationsResponse struct {
+	Response
+	Data GetUniversalRelationsResponseData
+}
+type GetUniversalRelationRequest struct {
+	RelationID string `form:"relationId" binding:"required" example:"rel-resource-app-dns-001"`
+}
+type GetUniversalRelationResponse struct {
+	Response
+	Data UniversalRelationDataItem
+}
+type UniversalRelationCreateRequest struct {
+	RelationID   string                 `json:"relationId" binding:"" example:"rel-resource-app-dns-001"`
+	SourceType   string                 `json:"sourceType" binding:"required" example:"resource"`
+	SourceID     string                 `json:"sourceId" binding:"required" example:"server-001"`
+	TargetType   string                 `json:"targetType" binding:"required" example:"application"`
+	TargetID     string                 `json:"targetId" binding:"required" example:"dns-app-001"`
+	RelationType string                 `json:"relationType" binding:"required" example:"hosts"`
+	Direction    string                 `json:"direction" binding:"omitempty,oneof=forward backward bidirectional" example:"forward"`
+	Weight       float64                `json:"weight" binding:"" example:"1"`
+	Priority     int                    `json:"priority" binding:"" example:"1"`
+	Properties   map[string]interface{} `json:"properties"`
+	Environment  string                 `json:"environment" binding:"" example:"prod"`
+	TenantID     string                 `json:"tenantId" binding:"" example:"tenant-001"`
+	Description  string                 `json:"description" binding:"" example:"服务器托管DNS应用"`
+}
+type UniversalRelationUpdateRequest struct {
+	RelationID   string                 `json:"relationId" binding:"required" example:"rel-resource-app-dns-001"`
+	RelationType string                 `json:"relationType" binding:"required" example:"hosts"`
+	Direction    string                 `json:"direction" binding:"required,oneof=forward backward bidirectional" example:"forward"`
+	Weight       float64                `json:"weight" binding:"" example:"1"`
+	Priority     int                    `json:"priority" binding:"" example:"1"`
+	Properties   map[string]interface{} `json:"properties"`
+	Status       string                 `json:"status" binding:"required" example:"active"`
+	Description  string                 `json:"description" binding:"" example:"服务器托管DNS应用"`
+}
+type UniversalRelationDeleteRequest struct {
+	RelationID string `form:"relationId" binding:"required" example:"rel-resource-app-dns-001"`
+}
+
+type ResourceRelationDataItem struct {
+	ID               uint                   `json:"id"`
+	SourceResourceID string                 `json:"sourceResourceId" example:"cdn-node-001"`
+	SourceName       string                 `json:"sourceName" example:"CDN节点-01"`
+	TargetResourceID string                 `json:"targetResourceId" example:"server-001"`
+	TargetName       string                 `json:"targetName" example:"Web服务器-01"`
+	RelationType     string                 `json:"relationType" example:"runs_on"`
+	Direction        string                 `json:"direction" example:"forward"`
+	Weight           int                    `json:"weight" example:"1"`
+	Properties       map[string]interface{} `json:"properties"`
+	Description      string                 `json:"description"`
+	UpdatedAt        string                 `json:"updatedAt"`
+	CreatedAt        string                 `json:"createdAt"`
+}
+type GetResourceRelationsRequest struct {
+	ResourceID string `form:"resourceId" binding:"required" example:"server-001"`
+}
+type GetResourceRelationsResponseData struct {
+	List []ResourceRelationDataItem `json:"list"`
+}
+type GetResourceRelationsResponse struct {
+	Response
+	Data GetResourceRelationsResponseData
+}
+type ResourceRelationCreateRequest struct {
+	SourceResourceID string                 `json:"sourceResourceId" binding:"required" example:"cdn-node-001"`
+	TargetResourceID string                 `json:"targetResourceId" binding:"required" example:"server-001"`
+	RelationType     string                 `json:"relationType" binding:"required" example:"runs_on"`
+	Direction        string                 `json:"direction" binding:"omitempty,oneof=forward backward bidirectional" example:"forward"`
+	Weight           int                    `json:"weight" binding:"" example:"1"`
+	Properties       map[string]interface{} `json:"properties"`
+	Description      string                 `json:"description" binding:"" example:"CDN节点运行在物理服务器上"`
+}
+type ResourceRelationUpdateRequest struct {
+	ID           uint                   `json:"id" binding:"required" example:"1"`
+	RelationType string                 `json:"relationType" binding:"required" example:"runs_on"`
+	Direction    string                 `json:"direction" binding:"required,oneof=forward backward bidirectional" example:"forward"`
+	Weight       int                    `json:"weight" binding:"" example:"1"`
+	Properties   map[string]interface{} `json:"properties"`
+	Description  string                 `json:"description" binding:"" example:"CDN节点运行在物理服务器上"`
+}
+type ResourceRelationDeleteRequest struct {
+	ID uint `form:"id" binding:"required" example:"1"`
+}
@@ -0,0 +1,79 @@
+package v1
+
+type RelationRuleDataItem struct {
+	ID               uint                   `json:"id"`
+	RuleID           string                 `json:"ruleId" example:"rule-cdn-node-server"`
+	RuleName         string                 `json:"ruleName" example:"CDN节点必须运行在服务器上"`
+	SourceType       string                 `json:"sourceType" example:"cdn_node"`
+	TargetType       string                 `json:"targetType" example:"server"`
+	AllowedRelations []string               `json:"allowedRelations"`
+	IsRequired       bool                   `json:"isRequired" example:"true"`
+	MaxConnections   int                    `json:"maxConnections" example:"1"`
+	MinConnections   int                    `json:"minConnections" example:"1"`
+	ValidationRules  map[string]interface{} `json:"validationRules"`
+	Priority         int                    `json:"priority" example:"1"`
+	IsActive         bool                   `json:"isActive" example:"true"`
+	Description      string                 `json:"description"`
+	UpdatedAt        string                 `json:"updatedAt"`
+	CreatedAt        string                 `json:"createdAt"`
+}
+type GetRelationRulesRequest struct {
+	SourceType string `form:"sourceType" binding:"" example:"cdn_node"`
+	TargetType string `form:"targetType" binding:"" example:"server"`
+}
+type GetRelationRulesResponseData struct {
+	List []RelationRuleDataItem `json:"list"`
+}
+type GetRelationRulesResponse struct {
+	Response
+	Data GetRelationRulesResponseData
+}
+type RelationRuleCreateRequest struct {
+	RuleID           string                 `json:"ruleId" binding:"" example:"rule-cdn-node-server"`
+	RuleName         string                 `json:"ruleName" binding:"required" example:"CDN节点必须运行在服务器上"`
+	SourceType       string                 `json:"sourceType" binding:"required" example:"cdn_node"`
+	TargetType       string                 `json:"targetType" binding:"required" example:"server"`
+	AllowedRelations []string               `json:"allowedRelations" binding:"required,min=1"`
+	IsRequired       bool                   `json:"isRequired" example:"true"`
+	MaxConnections   int                    `json:"maxConnections" binding:"min=0" example:"1"`
+	MinConnections   int                    `json:"minConnections" binding:"min=0" example:"1"`
+	ValidationRules  map[string]interface{} `json:"validationRules"`
+	Priority         int                    `json:"priority" binding:"" example:"1"`
+	Description      string                 `json:"description" binding:"" example:""`
+}
+type RelationRuleUpdateRequest struct {
+	RuleID           string                 `json:"ruleId" binding:"required" example:"rule-cdn-node-server"`
+	RuleName         string                 `json:"ruleName" binding:"required" example:"CDN节点必须运行在服务器上"`
+	SourceType       string                 `json:"sourceType" binding:"required" example:"cdn_node"`
+	TargetType       string                 `json:"targetType" binding:"required" example:"server"`
+	AllowedRelations []string               `json:"allowedRelations" binding:"required,min=1"`
+	IsRequired       bool                   `json:"isRequired" example:"true"`
+	MaxConnections   int                    `json:"maxConnections" binding:"min=0" example:"1"`
+	MinConnections   int                    `json:"minConnections" binding:"min=0" example:"1"`
+	ValidationRules  map[string]interface{} `json:"validationRules"`
+	Priority         int                    `json:"priority" binding:"" example:"1"`
+	IsActive         bool                   `json:"isActive" example:"true"`
+	Description      string                 `json:"description" binding:"" example:""`
+}
+type RelationRuleDeleteRequest struct {
+	RuleID string `form:"ruleId" binding:"required" example:"rule-cdn-node-server"`
+}
+type RelationComplianceItem struct {
+	RuleID         string `json:"ruleId" example:"rule-cdn-node-server"`
+	RuleName       string `json:"ruleName" example:"CDN节点必须运行在服务器上"`
+	ObjectType     string `json:"objectType" example:"resource"`
+	ObjectID       string `json:"objectId" example:"cdn-node-002"`
+	ObjectName     string `json:"objectName" example:"CDN节点-02"`
+	Connections    int    `json:"connections" example:"0"`
+	MinConnections int    `json:"minConnections" example:"1"`
+	MaxConnections int    `json:"maxConnections" example:"1"`
+	Message        string `json:"message" example:"连接数少于最小连接数"`
+}
+type GetRelationComplianceResponseData struct {
+	Checked    int                      `json:"checked" example:"10"`
+	Violations []RelationComplianceItem `json:"violations"`
+}
+type GetRelationComplianceResponse struct {
+	Response
+	Data GetRelationComplianceResponseData
+}
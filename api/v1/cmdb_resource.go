@@ -0,0 +1,92 @@
+package v1
+
+import "time"
+
+type ResourceTagItem struct {
+	Key   string `json:"key" binding:"required" example:"team"`
+	Value string `json:"value" binding:"required" example:"backend"`
+}
+type ResourceDataItem struct {
+	ID           uint                   `json:"id"`
+	ResourceID   string                 `json:"resourceId" example:"server-001"`
+	Name         string                 `json:"name" example:"Web服务器-01"`
+	Type         string                 `json:"type" example:"server"`
+	Status       string                 `json:"status" example:"active"`
+	Provider     string                 `json:"provider" example:"self_built"`
+	Region       string                 `json:"region" example:"beijing"`
+	Zone         string                 `json:"zone" example:"beijing-a"`
+	TenantID     string                 `json:"tenantId" example:"tenant-001"`
+	BusinessID   string                 `json:"businessId" example:"web-service"`
+	Environment  string                 `json:"environment" example:"prod"`
+	Attributes   map[string]interface{} `json:"attributes"`
+	Description  string                 `json:"description"`
+	LastSyncTime string                 `json:"lastSyncTime"`
+	Tags         []ResourceTagItem      `json:"tags"`
+	UpdatedAt    string                 `json:"updatedAt"`
+	CreatedAt    string                 `json:"createdAt"`
+}
+type GetResourcesRequest struct {
+	Page        int       `form:"page" binding:"required" example:"1"`
+	PageSize    int       `form:"pageSize" binding:"required" example:"10"`
+	Name        string    `form:"name" binding:"" example:"Web服务器"`
+	Type        string    `form:"type" binding:"" example:"server"`
+	Status      string    `form:"status" binding:"" example:"active"`
+	Provider    string    `form:"provider" binding:"" example:"self_built"`
+	Region      string    `form:"region" binding:"" example:"beijing"`
+	Zone        string    `form:"zone" binding:"" example:"beijing-a"`
+	TenantID    string    `form:"tenantId" binding:"" example:"tenant-001"`
+	BusinessID  string    `form:"businessId" binding:"" example:"web-service"`
+	Environment string    `form:"environment" binding:"" example:"prod"`
+	AsOf        time.Time `form:"as_of" time_format:"2006-01-02T15:04:05Z07:00" example:"2026-09-01T00:00:00Z"`
+	Reveal      bool      `form:"reveal" example:"false"`
+}
+type GetResourcesResponseData struct {
+	List  []ResourceDataItem `json:"list"`
+	Total int64              `json:"total"`
+}
+type GetResourcesResponse struct {
+	Response
+	Data GetResourcesResponseData
+}
+type GetResourceRequest struct {
+	ResourceID string    `form:"resourceId" binding:"required" example:"server-001"`
+	AsOf       time.Time `form:"as_of" time_format:"2006-01-02T15:04:05Z07:00" example:"2026-09-01T00:00:00Z"`
+	Reveal     bool      `form:"reveal" example:"false"`
+}
+type GetResourceResponse struct {
+	Response
+	Data ResourceDataItem
+}
+type ResourceCreateRequest struct {
+	ResourceID  string                 `json:"resourceId" binding:"" example:"server-001"`
+	Name        string                 `json:"name" binding:"required" example:"Web服务器-01"`
+	Type        string                 `json:"type" binding:"required" example:"server"`
+	Status      string                 `json:"status" binding:"" example:"active"`
+	Provider    string                 `json:"provider" binding:"" example:"self_built"`
+	Region      string                 `json:"region" binding:"" example:"beijing"`
+	Zone        string                 `json:"zone" binding:"" example:"beijing-a"`
+	TenantID    string                 `json:"tenantId" binding:"" example:"tenant-001"`
+	BusinessID  string                 `json:"businessId" binding:"" example:"web-service"`
+	Environment string                 `json:"environment" binding:"" example:"prod"`
+	Attributes  map[string]interface{} `json:"attributes"`
+	Description string                 `json:"description" binding:"" example:"生产环境Web服务器"`
+	Tags        []ResourceTagItem      `json:"tags" binding:"dive"`
+}
+type ResourceUpdateRequest struct {
+	ResourceID  string                 `json:"resourceId" binding:"required" example:"server-001"`
+	Name        string                 `json:"name" binding:"required" example:"Web服务器-01"`
+	Type        string                 `json:"type" binding:"required" example:"server"`
+	Status      string                 `json:"status" binding:"required" example:"active"`
+	Provider    string                 `json:"provider" binding:"" example:"self_built"`
+	Region      string                 `json:"region" binding:"" example:"beijing"`
+	Zone        string                 `json:"zone" binding:"" example:"beijing-a"`
+	TenantID    string                 `json:"tenantId" binding:"" example:"tenant-001"`
+	BusinessID  string                 `json:"businessId" binding:"" example:"web-service"`
+	Environment string                 `json:"environment" binding:"" example:"prod"`
+	Attributes  map[string]interface{} `json:"attributes"`
+	Description string                 `json:"description" binding:"" example:"生产环境Web服务器"`
+	Tags        []ResourceTagItem      `json:"tags" binding:"dive"`
+}
+type ResourceDeleteRequest struct {
+	ResourceID string `form:"resourceId" binding:"required" example:"server-001"`
+}
@@ -0,0 +1,131 @@
+package v1
+
+type ResourceTypeDataItem struct {
+	ID               uint                   `json:"id"`
+	TypeName         string                 `json:"typeName" example:"server"`
+	DisplayName      string                 `json:"displayName" example:"物理服务器"`
+	Category         string                 `json:"category" example:"infrastructure"`
+	Icon             string                 `json:"icon" example:"server"`
+	Color            string                 `json:"color" example:"#1890ff"`
+	AttributeSchema  map[string]interface{} `json:"attributeSchema"`
+	SchemaVersion    int                    `json:"schemaVersion" example:"1"`
+	AllowedRelations []string               `json:"allowedRelations"`
+	Description      string                 `json:"description"`
+	IsActive         bool                   `json:"isActive" example:"true"`
+	UpdatedAt        string                 `json:"updatedAt"`
+	CreatedAt        string                 `json:"createdAt"`
+}
+type GetResourceTypesRequest struct {
+	Category        string `form:"category" binding:"" example:"infrastructure"`
+	IncludeInactive bool   `form:"includeInactive" binding:"" example:"false"`
+}
+type GetResourceTypesResponseData struct {
+	List []ResourceTypeDataItem `json:"list"`
+}
+type GetResourceTypesResponse struct {
+	Response
+	Data GetResourceTypesResponseData
+}
+type ResourceTypeCreateRequest struct {
+	TypeName         string                 `json:"typeName" binding:"required" example:"gpu_server"`
+	DisplayName      string                 `json:"displayName" binding:"required" example:"GPU服务器"`
+	Category         string                 `json:"category" binding:"required" example:"infrastructure"`
+	Icon             string                 `json:"icon" binding:"" example:"server"`
+	Color            string                 `json:"color" binding:"" example:"#1890ff"`
+	AttributeSchema  map[string]interface{} `json:"attributeSchema"`
+	AllowedRelations []string               `json:"allowedRelations"`
+	Description      string                 `json:"description" binding:"" example:"带GPU加速卡的物理服务器"`
+}
+type ResourceTypeUpdateRequest struct {
+	TypeName         string                 `json:"typeName" binding:"required" example:"gpu_server"`
+	DisplayName      string                 `json:"displayName" binding:"required" example:"GPU服务器"`
+	Category         string                 `json:"category" binding:"required" example:"infrastructure"`
+	Icon             string                 `json:"icon" binding:"" example:"server"`
+	Color            string                 `json:"color" binding:"" example:"#1890ff"`
+	AttributeSchema  map[string]interface{} `json:"attributeSchema"`
+	AllowedRelations []string               `json:"allowedRelations"`
+	Description      string                 `json:"description" binding:"" example:"带GPU加速卡的物理服务器"`
+}
+type SchemaChangeItem struct {
+	Field      string `json:"field" example:"cpu_cores"`
+	Message    string `json:"message" example:"新增必填字段"`
+	Compatible bool   `json:"compatible" example:"false"`
+}
+type NonCompliantResourceItem struct {
+	ResourceID string       `json:"resourceId" example:"server-001"`
+	Name       string       `json:"name" example:"Web服务器-01"`
+	Violations []FieldError `json:"violations"`
+}
+type ResourceTypeUpdateResponseData struct {
+	SchemaVersion int                        `json:"schemaVersion" example:"2"`
+	SchemaChanged bool                       `json:"schemaChanged" example:"true"`
+	Compatible    bool                       `json:"compatible" example:"false"`
+	Changes       []SchemaChangeItem         `json:"changes"`
+	NonCompliant  []NonCompliantResourceItem `json:"nonCompliant"`
+}
+type ResourceTypeUpdateResponse struct {
+	Response
+	Data ResourceTypeUpdateResponseData
+}
+type ResourceTypeStatusRequest struct {
+	TypeName string `json:"typeName" binding:"required" example:"gpu_server"`
+	IsActive bool   `json:"isActive" example:"false"`
+}
+type GetResourceTypeSchemasRequest struct {
+	TypeName string `form:"typeName" binding:"required" example:"server"`
+}
+type ResourceTypeSchemaDataItem struct {
+	Version         int                    `json:"version" example:"1"`
+	AttributeSchema map[string]interface{} `json:"attributeSchema"`
+	Compatible      bool                   `json:"compatible" example:"true"`
+	Changes         []SchemaChangeItem     `json:"changes"`
+	CreatedAt       string                 `json:"createdAt"`
+}
+type GetResourceTypeSchemasResponseData struct {
+	List []ResourceTypeSchemaDataItem `json:"list"`
+}
+type GetResourceTypeSchemasResponse struct {
+	Response
+	Data GetResourceTypeSchemasResponseData
+}
+type GetResourceTypeComplianceRequest struct {
+	TypeName string `form:"typeName" binding:"required" example:"server"`
+}
+type GetResourceTypeComplianceResponseData struct {
+	SchemaVersion int                        `json:"schemaVersion" example:"2"`
+	Total         int                        `json:"total" example:"10"`
+	NonCompliant  []NonCompliantResourceItem `json:"nonCompliant"`
+}
+type GetResourceTypeComplianceResponse struct {
+	Response
+	Data GetResourceTypeComplianceResponseData
+}
+type AttributeMigrationOperation struct {
+	Op    string      `json:"op" binding:"required,oneof=rename default drop" example:"rename"`
+	Field string      `json:"field" binding:"required" example:"memory"`
+	To    string      `json:"to" binding:"" example:"memory_gb"`
+	Value interface{} `json:"value"`
+}
+type ResourceTypeMigrateRequest struct {
+	TypeName   string                        `json:"typeName" binding:"required" example:"server"`
+	DryRun     bool                          `json:"dryRun" example:"true"`
+	Operations []AttributeMigrationOperation `json:"operations" binding:"required,min=1,dive"`
+}
+
+// AttributeMigrationConflictItem 资源已有rename的目标属性，重命名会覆盖或丢弃其中一个值
+type AttributeMigrationConflictItem struct {
+	ResourceID string `json:"resourceId" example:"server-001"`
+	Field      string `json:"field" example:"memory"`
+	To         string `json:"to" example:"memory_gb"`
+}
+type ResourceTypeMigrateResponseData struct {
+	DryRun       bool                             `json:"dryRun" example:"true"`
+	Total        int                              `json:"total" example:"10"`
+	Affected     []string                         `json:"affected"`
+	Conflicts    []AttributeMigrationConflictItem `json:"conflicts"`
+	NonCompliant []NonCompliantResourceItem       `json:"nonCompliant"`
+}
+type ResourceTypeMigrateResponse struct {
+	Response
+	Data ResourceTypeMigrateResponseData
+}
@@ -0,0 +1,32 @@
+package v1
+
+type SensitiveRevealRequest struct {
+	ObjectType string `form:"objectType" binding:"required,oneof=resource configuration" example:"resource"`
+	ObjectID   string `form:"objectId" binding:"required" example:"server-001"`
+}
+type SensitiveRevealResponseData struct {
+	ObjectType string `json:"objectType" example:"resource"`
+	ObjectID   string `json:"objectId" example:"server-001"`
+	// Fields 敏感字段路径 -> 明文
+	Fields map[string]interface{} `json:"fields"`
+}
+type SensitiveRevealResponse struct {
+	Response
+	Data SensitiveRevealResponseData
+}
+type SensitiveRotateItem struct {
+	ObjectType string `json:"objectType" example:"resource"`
+	// Scanned 本次处理的仍需轮换的记录数，已完成轮换的记录不会再被处理
+	Scanned int `json:"scanned" example:"120"`
+	Updated int `json:"updated" example:"12"`
+	// Failed 无法解密或保存失败的记录数，这些记录保持原样，可以修复后重新执行轮换
+	Failed int `json:"failed" example:"0"`
+}
+type SensitiveRotateResponseData struct {
+	CurrentKey string                `json:"currentKey" example:"k2"`
+	Items      []SensitiveRotateItem `json:"items"`
+}
+type SensitiveRotateResponse struct {
+	Response
+	Data SensitiveRotateResponseData
+}
@@ -0,0 +1,55 @@
+package v1
+
+import "time"
+
+// SLAReportRequest date为窗口内任意一天，为空时为当前窗口；周从周一开始
+type SLAReportRequest struct {
+	Period          string `form:"period" binding:"required,oneof=day week month" example:"month"`
+	Date            string `form:"date" binding:"omitempty,datetime=2006-01-02" example:"2026-10-01"`
+	ServiceID       string `form:"serviceId" example:"web-service-001"`
+	BelowTargetOnly bool   `form:"belowTargetOnly" example:"false"`
+}
+
+// SLACalculateRequest 立即计算并保存指定窗口的SLA统计
+type SLACalculateRequest struct {
+	Period string `json:"period" binding:"required,oneof=day week month" example:"day"`
+	Date   string `json:"date" binding:"omitempty,datetime=2006-01-02" example:"2026-10-17"`
+}
+
+// SLAOutageItem 服务处于down状态的时段，ongoing表示统计时仍未恢复
+type SLAOutageItem struct {
+	Start           time.Time `json:"start"`
+	End             time.Time `json:"end"`
+	DurationSeconds int64     `json:"durationSeconds" example:"300"`
+	Reason          string    `json:"reason" example:"健康成员0个，少于1个"`
+	Ongoing         bool      `json:"ongoing"`
+}
+
+// SLAReportItem 可用率=正常时长/(正常时长+不可用时长)，degraded计入正常时长，
+// 状态未知的时长不参与计算；没有可用数据时availability为空
+type SLAReportItem struct {
+	ServiceID       string          `json:"serviceId" example:"web-service-001"`
+	Name            string          `json:"name" example:"Web服务"`
+	SLATarget       float64         `json:"slaTarget" example:"0.999"`
+	Availability    *float64        `json:"availability" example:"0.9986"`
+	BelowTarget     bool            `json:"belowTarget"`
+	UptimeSeconds   int64           `json:"uptimeSeconds" example:"2588400"`
+	DowntimeSeconds int64           `json:"downtimeSeconds" example:"3600"`
+	DegradedSeconds int64           `json:"degradedSeconds" example:"600"`
+	UnknownSeconds  int64           `json:"unknownSeconds" example:"0"`
+	Outages         []SLAOutageItem `json:"outages"`
+	CalcTime        time.Time       `json:"calcTime"`
+}
+type SLAReportResponseData struct {
+	Period      string          `json:"period" example:"month"`
+	Date        string          `json:"date" example:"2026-10"`
+	WindowStart time.Time       `json:"windowStart"`
+	WindowEnd   time.Time       `json:"windowEnd"`
+	Total       int             `json:"total" example:"12"`
+	BelowTarget int             `json:"belowTarget" example:"1"`
+	Items       []SLAReportItem `json:"items"`
+}
+type SLAReportResponse struct {
+	Response
+	Data SLAReportResponseData
+}
@@ -0,0 +1,107 @@
+package v1
+
+type SnapshotCreateRequest struct {
+	SnapshotType  string `json:"snapshotType" binding:"required,oneof=full incremental" example:"full"`
+	TenantID      string `json:"tenantId" binding:"" example:"tenant-001"`
+	BusinessID    string `json:"businessId" binding:"" example:"web-service"`
+	RetentionDays int    `json:"retentionDays" binding:"omitempty,min=1" example:"30"`
+	Description   string `json:"description" binding:"" example:"版本发布前备份"`
+}
+type SnapshotDataItem struct {
+	ID             uint   `json:"id"`
+	SnapshotID     string `json:"snapshotId" example:"snap-1234567890"`
+	SnapshotTime   string `json:"snapshotTime" example:"2006-01-02 15:04:05"`
+	SnapshotType   string `json:"snapshotType" example:"full"`
+	BaseSnapshotID string `json:"baseSnapshotId" example:""`
+	TenantID       string `json:"tenantId" example:"tenant-001"`
+	BusinessID     string `json:"businessId" example:"web-service"`
+	ResourceCount  int    `json:"resourceCount" example:"120"`
+	ServiceCount   int    `json:"serviceCount" example:"30"`
+	BusinessCount  int    `json:"businessCount" example:"5"`
+	RelationCount  int    `json:"relationCount" example:"260"`
+	Status         string `json:"status" example:"completed"`
+	StoragePath    string `json:"storagePath" example:"storage/snapshots/snap-1234567890.json.gz"`
+	FileSize       int64  `json:"fileSize" example:"10240"`
+	Checksum       string `json:"checksum" example:"sha256"`
+	CreatorID      string `json:"creatorId" example:"1"`
+	CreatorName    string `json:"creatorName" example:"admin"`
+	ExpiresAt      string `json:"expiresAt" example:"2006-01-02 15:04:05"`
+	Description    string `json:"description" example:"版本发布前备份"`
+	CreatedAt      string `json:"createdAt"`
+}
+type SnapshotCreateResponse struct {
+	Response
+	Data SnapshotDataItem
+}
+type GetSnapshotsRequest struct {
+	Page         int    `form:"page" binding:"required" example:"1"`
+	PageSize     int    `form:"pageSize" binding:"required" example:"10"`
+	SnapshotType string `form:"snapshotType" binding:"omitempty,oneof=full incremental" example:"full"`
+	TenantID     string `form:"tenantId" binding:"" example:"tenant-001"`
+	BusinessID   string `form:"businessId" binding:"" example:"web-service"`
+	Status       string `form:"status" binding:"omitempty,oneof=creating completed failed" example:"completed"`
+}
+type GetSnapshotsResponseData struct {
+	List  []SnapshotDataItem `json:"list"`
+	Total int64              `json:"total"`
+}
+type GetSnapshotsResponse struct {
+	Response
+	Data GetSnapshotsResponseData
+}
+type SnapshotVerifyRequest struct {
+	SnapshotID string `form:"snapshotId" binding:"required" example:"snap-1234567890"`
+}
+type SnapshotVerifyResponseData struct {
+	Valid    bool   `json:"valid" example:"true"`
+	Checksum string `json:"checksum" example:"sha256"`
+	FileSize int64  `json:"fileSize" example:"10240"`
+}
+type SnapshotVerifyResponse struct {
+	Response
+	Data SnapshotVerifyResponseData
+}
+type SnapshotDiffRequest struct {
+	FromSnapshotID string `form:"fromSnapshotId" binding:"required" example:"snap-1234567890"`
+	ToSnapshotID   string `form:"toSnapshotId" binding:"" example:"snap-1234567891"`
+	Reveal         bool   `form:"reveal" example:"false"`
+}
+type SnapshotDiffItem struct {
+	ObjectType    string                 `json:"objectType" example:"resource"`
+	Key           string                 `json:"key" example:"server-001"`
+	Before        map[string]interface{} `json:"before"`
+	After         map[string]interface{} `json:"after"`
+	ChangedFields map[string]interface{} `json:"changedFields"`
+}
+type SnapshotDiffGroup struct {
+	Added   []SnapshotDiffItem `json:"added"`
+	Removed []SnapshotDiffItem `json:"removed"`
+	Changed []SnapshotDiffItem `json:"changed"`
+}
+type SnapshotDiffResponseData struct {
+	FromSnapshotID string            `json:"fromSnapshotId" example:"snap-1234567890"`
+	ToSnapshotID   string            `json:"toSnapshotId" example:"snap-1234567891"`
+	Objects        SnapshotDiffGroup `json:"objects"`
+	Edges          SnapshotDiffGroup `json:"edges"`
+}
+type SnapshotDiffResponse struct {
+	Response
+	Data SnapshotDiffResponseData
+}
+type SnapshotRestoreRequest struct {
+	SnapshotID  string   `json:"snapshotId" binding:"required" example:"snap-1234567890"`
+	TenantID    string   `json:"tenantId" binding:"" example:"tenant-001"`
+	BusinessID  string   `json:"businessId" binding:"" example:"web-service"`
+	ObjectTypes []string `json:"objectTypes" binding:"dive,oneof=resource service business resource_relation universal_relation" example:"resource,resource_relation"`
+	Reason      string   `json:"reason" binding:"required" example:"误删除恢复"`
+}
+type SnapshotRestoreResponseData struct {
+	Created int `json:"created" example:"3"`
+	Updated int `json:"updated" example:"10"`
+	Deleted int `json:"deleted" example:"1"`
+	Skipped int `json:"skipped" example:"0"`
+}
+type SnapshotRestoreResponse struct {
+	Response
+	Data SnapshotRestoreResponseData
+}
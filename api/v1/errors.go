@@ -11,4 +11,52 @@ var (
 
 	// more biz errors
 	ErrUsernameAlreadyUse = newError(1001, "The username is already in use.")
+
+	// cmdb errors
+	ErrResourceIDAlreadyUse       = newError(2001, "资源ID已存在")
+	ErrResourceTypeNotFound       = newError(2002, "资源类型不存在")
+	ErrResourceTypeInactive       = newError(2003, "资源类型未启用")
+	ErrResourceAttributesInvalid  = newError(2004, "资源属性校验失败")
+	ErrResourceTypeAlreadyUse     = newError(2005, "资源类型已存在")
+	ErrResourceMigrationInvalid   = newError(2006, "属性迁移操作不合法")
+	ErrRelationObjectNotFound     = newError(2007, "关系对象不存在")
+	ErrRelationNotAllowed         = newError(2008, "不允许的关系类型")
+	ErrRelationMaxConnections     = newError(2009, "超过关系规则的最大连接数")
+	ErrRelationAlreadyExists      = newError(2010, "关系已存在")
+	ErrRelationPropertiesInvalid  = newError(2011, "关系属性校验失败")
+	ErrRelationRuleIDAlreadyUse   = newError(2012, "关系规则ID已存在")
+	ErrHistoryVersionConflict     = newError(2013, "对象已被修改，请刷新后重试")
+	ErrHistoryVersionInvalid      = newError(2014, "该历史版本没有可恢复的数据")
+	ErrSnapshotBaseNotFound       = newError(2015, "没有可用的全量快照，请先创建全量快照")
+	ErrSnapshotNotCompleted       = newError(2016, "快照未生成完成")
+	ErrSnapshotChecksumMismatch   = newError(2017, "快照文件校验失败")
+	ErrSnapshotScopeMismatch      = newError(2018, "恢复范围不在快照范围内")
+	ErrNotificationNotRetryable   = newError(2019, "只能重试发送失败的通知")
+	ErrSensitiveDecryptFailed     = newError(2020, "敏感字段解密失败，请检查加密主密钥配置")
+	ErrSensitiveKeyNotConfigured  = newError(2021, "未配置加密主密钥")
+	ErrConfigIDAlreadyUse         = newError(2022, "配置ID已存在")
+	ErrApplicationNotFound        = newError(2023, "应用实例不存在")
+	ErrConfigRevisionStatus       = newError(2024, "配置版本当前状态不允许该操作")
+	ErrConfigRevisionRollback     = newError(2025, "只能回滚到曾经发布过的版本")
+	ErrConfigTimeRangeInvalid     = newError(2026, "过期时间必须晚于生效时间")
+	ErrConfigTemplateIDAlreadyUse = newError(2027, "配置模板ID已存在")
+	ErrConfigTemplateNotFound     = newError(2028, "配置模板不存在")
+	ErrConfigTemplateInactive     = newError(2029, "配置模板未启用")
+	ErrConfigTemplateMismatch     = newError(2030, "配置模板不适用于该应用的类型、环境或业务")
+	ErrConfigTemplateInvalid      = newError(2031, "配置模板定义不合法")
+	ErrConfigVariablesInvalid     = newError(2032, "模板变量校验失败")
+	ErrConfigTemplateInUse        = newError(2033, "配置模板已被配置使用，不能删除")
+	ErrApplicationTypeNotFound    = newError(2034, "应用类型不存在")
+	ErrConfigDataInvalid          = newError(2035, "配置数据校验失败")
+	ErrAppIDAlreadyUse            = newError(2036, "应用实例ID已存在")
+	ErrApplicationTransition      = newError(2037, "应用当前状态不允许该操作")
+	ErrApplicationNotStopped      = newError(2038, "应用实例未停止，不能删除")
+	ErrApplicationInUse           = newError(2039, "应用实例仍有配置，不能删除")
+	ErrResourceNotFound           = newError(2040, "资源不存在")
+	ErrApplicationTypeInactive    = newError(2041, "应用类型未启用")
+	ErrHealthCheckNotConfigured   = newError(2042, "应用类型未配置健康检查")
+	ErrHealthCheckTarget          = newError(2043, "无法确定健康检查的探测目标")
+	ErrHealthRuleDependency       = newError(2044, "关键依赖服务不存在或为服务自身")
+	ErrSLAWindowNotStarted        = newError(2045, "SLA统计窗口尚未开始")
+	ErrResourceMigrationConflict  = newError(2046, "重命名的目标属性已存在，迁移会丢失数据")
 )
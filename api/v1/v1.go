@@ -4,6 +4,7 @@ import (
 	"errors"
 	"github.com/gin-gonic/gin"
 	"net/http"
+	"reflect"
 )
 
 type Response struct {
@@ -27,13 +28,52 @@ func HandleError(ctx *gin.Context, httpCode int, err error, data interface{}) {
 	if data == nil {
 		data = map[string]string{}
 	}
-	resp := Response{Code: errorCodeMap[err], Message: err.Error(), Data: data}
-	if _, ok := errorCodeMap[err]; !ok {
-		resp = Response{Code: 500, Message: "unknown error", Data: data}
+	resp := Response{Code: 500, Message: "unknown error", Data: data}
+	if known, ok := KnownError(err); ok {
+		resp = Response{Code: errorCodeMap[known], Message: known.Error(), Data: data}
 	}
 	ctx.JSON(httpCode, resp)
 }
 
+// IsKnownError 判断err是否为已注册错误码的业务错误，或用fmt.Errorf("%w")包装了这样的错误
+func IsKnownError(err error) bool {
+	_, ok := KnownError(err)
+	return ok
+}
+
+// KnownError 沿Unwrap链返回第一个已注册错误码的业务错误
+func KnownError(err error) (error, bool) {
+	for ; err != nil; err = errors.Unwrap(err) {
+		// 不可比较的错误类型不能作为map的键查找
+		if !reflect.TypeOf(err).Comparable() {
+			continue
+		}
+		if _, ok := errorCodeMap[err]; ok {
+			return err, true
+		}
+	}
+	return nil, false
+}
+
+// FieldError 字段级校验错误明细
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError 携带字段级错误明细的业务错误，Err必须是已注册错误码的错误
+type ValidationError struct {
+	Err    error
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	return e.Err.Error()
+}
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
 type Error struct {
 	Code    int
 	Message string
@@ -0,0 +1,48 @@
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestKnownError(t *testing.T) {
+	wrapped := fmt.Errorf("%w: %v", ErrSensitiveDecryptFailed, errors.New("cipher: message authentication failed"))
+	tests := []struct {
+		err   error
+		known error
+	}{
+		{ErrSensitiveDecryptFailed, ErrSensitiveDecryptFailed},
+		{wrapped, ErrSensitiveDecryptFailed},
+		{fmt.Errorf("batch: %w", wrapped), ErrSensitiveDecryptFailed},
+		{&ValidationError{Err: ErrBadRequest}, ErrBadRequest},
+		{errors.New("boom"), nil},
+		{nil, nil},
+	}
+	for _, tt := range tests {
+		known, ok := KnownError(tt.err)
+		if known != tt.known || ok != (tt.known != nil) || IsKnownError(tt.err) != ok {
+			t.Errorf("KnownError(%v) = %v, %v; want %v", tt.err, known, ok, tt.known)
+		}
+	}
+}
+
+func TestHandleErrorWrapped(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(w)
+	HandleError(ctx, http.StatusBadRequest, fmt.Errorf("%w: %v", ErrSensitiveDecryptFailed, errors.New("cipher")), nil)
+
+	var resp Response
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if resp.Code != 2020 || resp.Message != ErrSensitiveDecryptFailed.Error() {
+		t.Errorf("response = %d %q, want 2020 %q", resp.Code, resp.Message, ErrSensitiveDecryptFailed.Error())
+	}
+}
@@ -3,19 +3,36 @@ package main
 import (
 	"context"
 	"flag"
+	"fmt"
 	"nunu-layout-admin/cmd/migration/wire"
+	"nunu-layout-admin/internal/server"
 	"nunu-layout-admin/pkg/config"
 	"nunu-layout-admin/pkg/log"
+	"os"
 )
 
 func main() {
 	var envConf = flag.String("conf", "config/local.yml", "config path, eg: -conf ./config/local.yml")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [-conf config/local.yml] [status|up|down|redo]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
 	flag.Parse()
+	command := server.MigrateCommandUp
+	if flag.NArg() > 0 {
+		command = server.MigrateCommand(flag.Arg(0))
+	}
+	switch command {
+	case server.MigrateCommandStatus, server.MigrateCommandUp, server.MigrateCommandDown, server.MigrateCommandRedo:
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
 	conf := config.NewConfig(*envConf)
 
 	logger := log.NewLog(conf)
 
-	app, cleanup, err := wire.NewWire(conf, logger)
+	app, cleanup, err := wire.NewWire(conf, logger, command)
 	defer cleanup()
 	if err != nil {
 		panic(err)
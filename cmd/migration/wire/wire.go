@@ -33,7 +33,7 @@ func newApp(
 	)
 }
 
-func NewWire(*viper.Viper, *log.Logger) (*app.App, func(), error) {
+func NewWire(*viper.Viper, *log.Logger, server.MigrateCommand) (*app.App, func(), error) {
 	panic(wire.Build(
 		repositorySet,
 		serverSet,
@@ -18,11 +18,11 @@ import (
 
 // Injectors from wire.go:
 
-func NewWire(viperViper *viper.Viper, logger *log.Logger) (*app.App, func(), error) {
+func NewWire(viperViper *viper.Viper, logger *log.Logger, migrateCommand server.MigrateCommand) (*app.App, func(), error) {
 	db := repository.NewDB(viperViper, logger)
 	sidSid := sid.NewSid()
 	syncedEnforcer := repository.NewCasbinEnforcer(viperViper, logger, db)
-	migrateServer := server.NewMigrateServer(db, logger, sidSid, syncedEnforcer)
+	migrateServer := server.NewMigrateServer(db, logger, sidSid, syncedEnforcer, migrateCommand)
 	appApp := newApp(migrateServer)
 	return appApp, func() {
 	}, nil
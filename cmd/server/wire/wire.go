@@ -12,6 +12,7 @@ import (
 	"nunu-layout-admin/internal/server"
 	"nunu-layout-admin/internal/service"
 	"nunu-layout-admin/pkg/app"
+	"nunu-layout-admin/pkg/envelope"
 	"nunu-layout-admin/pkg/jwt"
 	"nunu-layout-admin/pkg/log"
 	"nunu-layout-admin/pkg/server/http"
@@ -26,18 +27,73 @@ var repositorySet = wire.NewSet(
 	repository.NewUserRepository,
 	repository.NewCasbinEnforcer,
 	repository.NewAdminRepository,
+	repository.NewResourceRepository,
+	repository.NewResourceTypeRepository,
+	repository.NewRelationRepository,
+	repository.NewRelationRuleRepository,
+	repository.NewImpactRepository,
+	repository.NewPathRepository,
+	repository.NewDependencyGraphRepository,
+	repository.NewHistoryRepository,
+	repository.NewServiceRepository,
+	repository.NewBusinessRepository,
+	repository.NewSnapshotRepository,
+	repository.NewNotificationRepository,
+	repository.NewSensitiveRepository,
+	repository.NewConfigurationRepository,
+	repository.NewConfigTemplateRepository,
+	repository.NewApplicationRepository,
+	repository.NewHealthCheckRepository,
+	repository.NewHealthRollupRepository,
+	repository.NewSLARepository,
 )
 
 var serviceSet = wire.NewSet(
 	service.NewService,
 	service.NewUserService,
 	service.NewAdminService,
+	service.NewResourceService,
+	service.NewResourceTypeService,
+	service.NewRelationService,
+	service.NewRelationRuleService,
+	service.NewGraphService,
+	service.NewImpactService,
+	service.NewPathService,
+	service.NewDependencyGraphService,
+	service.NewHistoryService,
+	service.NewSnapshotService,
+	service.NewNotificationService,
+	service.NewSensitiveService,
+	service.NewConfigurationService,
+	service.NewConfigTemplateService,
+	service.NewApplicationService,
+	service.NewHealthCheckService,
+	service.NewHealthRollupService,
+	service.NewSLAService,
 )
 
 var handlerSet = wire.NewSet(
 	handler.NewHandler,
 	handler.NewUserHandler,
 	handler.NewAdminHandler,
+	handler.NewResourceHandler,
+	handler.NewResourceTypeHandler,
+	handler.NewRelationHandler,
+	handler.NewRelationRuleHandler,
+	handler.NewGraphHandler,
+	handler.NewImpactHandler,
+	handler.NewPathHandler,
+	handler.NewDependencyGraphHandler,
+	handler.NewHistoryHandler,
+	handler.NewSnapshotHandler,
+	handler.NewNotificationHandler,
+	handler.NewSensitiveHandler,
+	handler.NewConfigurationHandler,
+	handler.NewConfigTemplateHandler,
+	handler.NewApplicationHandler,
+	handler.NewHealthCheckHandler,
+	handler.NewHealthRollupHandler,
+	handler.NewSLAHandler,
 )
 
 var jobSet = wire.NewSet(
@@ -70,6 +126,7 @@ func NewWire(*viper.Viper, *log.Logger) (*app.App, func(), error) {
 		serverSet,
 		sid.NewSid,
 		jwt.NewJwt,
+		envelope.NewKeyring,
 		newApp,
 	))
 }
@@ -15,6 +15,7 @@ import (
 	"nunu-layout-admin/internal/server"
 	"nunu-layout-admin/internal/service"
 	"nunu-layout-admin/pkg/app"
+	"nunu-layout-admin/pkg/envelope"
 	"nunu-layout-admin/pkg/jwt"
 	"nunu-layout-admin/pkg/log"
 	"nunu-layout-admin/pkg/server/http"
@@ -38,7 +39,63 @@ func NewWire(viperViper *viper.Viper, logger *log.Logger) (*app.App, func(), err
 	userRepository := repository.NewUserRepository(repositoryRepository)
 	userService := service.NewUserService(serviceService, userRepository)
 	userHandler := handler.NewUserHandler(handlerHandler, userService)
-	httpServer := server.NewHTTPServer(logger, viperViper, jwtJWT, syncedEnforcer, adminHandler, userHandler)
+	keyring := envelope.NewKeyring(viperViper)
+	resourceRepository := repository.NewResourceRepository(repositoryRepository)
+	resourceTypeRepository := repository.NewResourceTypeRepository(repositoryRepository)
+	historyRepository := repository.NewHistoryRepository(repositoryRepository)
+	sensitiveRepository := repository.NewSensitiveRepository(repositoryRepository)
+	resourceService := service.NewResourceService(serviceService, keyring, resourceRepository, resourceTypeRepository, historyRepository, sensitiveRepository)
+	resourceHandler := handler.NewResourceHandler(handlerHandler, resourceService)
+	resourceTypeService := service.NewResourceTypeService(serviceService, keyring, resourceTypeRepository, resourceRepository, historyRepository)
+	resourceTypeHandler := handler.NewResourceTypeHandler(handlerHandler, resourceTypeService)
+	relationRepository := repository.NewRelationRepository(repositoryRepository)
+	relationRuleRepository := repository.NewRelationRuleRepository(repositoryRepository)
+	pathRepository := repository.NewPathRepository(repositoryRepository)
+	relationService := service.NewRelationService(serviceService, relationRepository, relationRuleRepository, resourceRepository, resourceTypeRepository, pathRepository, historyRepository)
+	relationHandler := handler.NewRelationHandler(handlerHandler, relationService)
+	relationRuleService := service.NewRelationRuleService(serviceService, relationRuleRepository, relationRepository)
+	relationRuleHandler := handler.NewRelationRuleHandler(handlerHandler, relationRuleService)
+	graphService := service.NewGraphService(serviceService, relationRepository, resourceRepository, historyRepository)
+	graphHandler := handler.NewGraphHandler(handlerHandler, graphService)
+	impactRepository := repository.NewImpactRepository(repositoryRepository)
+	impactService := service.NewImpactService(serviceService, impactRepository, relationRepository)
+	impactHandler := handler.NewImpactHandler(handlerHandler, impactService)
+	pathService := service.NewPathService(serviceService, pathRepository, relationRepository, resourceRepository)
+	pathHandler := handler.NewPathHandler(handlerHandler, pathService)
+	dependencyGraphRepository := repository.NewDependencyGraphRepository(repositoryRepository)
+	dependencyGraphService := service.NewDependencyGraphService(serviceService, dependencyGraphRepository, relationRepository, impactRepository)
+	dependencyGraphHandler := handler.NewDependencyGraphHandler(handlerHandler, dependencyGraphService)
+	serviceRepository := repository.NewServiceRepository(repositoryRepository)
+	businessRepository := repository.NewBusinessRepository(repositoryRepository)
+	historyService := service.NewHistoryService(serviceService, keyring, historyRepository, resourceRepository, resourceTypeRepository, serviceRepository, businessRepository, sensitiveRepository)
+	historyHandler := handler.NewHistoryHandler(handlerHandler, historyService)
+	snapshotRepository := repository.NewSnapshotRepository(repositoryRepository)
+	snapshotService := service.NewSnapshotService(serviceService, viperViper, keyring, snapshotRepository, historyRepository, resourceRepository, serviceRepository, businessRepository, relationRepository, pathRepository, sensitiveRepository)
+	snapshotHandler := handler.NewSnapshotHandler(handlerHandler, snapshotService)
+	notificationRepository := repository.NewNotificationRepository(repositoryRepository)
+	notificationService := service.NewNotificationService(serviceService, viperViper, notificationRepository)
+	notificationHandler := handler.NewNotificationHandler(handlerHandler, notificationService)
+	sensitiveService := service.NewSensitiveService(serviceService, keyring, sensitiveRepository, resourceRepository, historyRepository)
+	sensitiveHandler := handler.NewSensitiveHandler(handlerHandler, sensitiveService)
+	configurationRepository := repository.NewConfigurationRepository(repositoryRepository)
+	configTemplateRepository := repository.NewConfigTemplateRepository(repositoryRepository)
+	configurationService := service.NewConfigurationService(serviceService, keyring, configurationRepository, configTemplateRepository, historyRepository, sensitiveRepository)
+	configurationHandler := handler.NewConfigurationHandler(handlerHandler, configurationService)
+	configTemplateService := service.NewConfigTemplateService(serviceService, configTemplateRepository, historyRepository)
+	configTemplateHandler := handler.NewConfigTemplateHandler(handlerHandler, configTemplateService)
+	applicationRepository := repository.NewApplicationRepository(repositoryRepository)
+	applicationService := service.NewApplicationService(serviceService, viperViper, applicationRepository, historyRepository)
+	applicationHandler := handler.NewApplicationHandler(handlerHandler, applicationService)
+	healthCheckRepository := repository.NewHealthCheckRepository(repositoryRepository)
+	healthCheckService := service.NewHealthCheckService(serviceService, viperViper, keyring, healthCheckRepository, applicationRepository, historyRepository)
+	healthCheckHandler := handler.NewHealthCheckHandler(handlerHandler, healthCheckService)
+	healthRollupRepository := repository.NewHealthRollupRepository(repositoryRepository)
+	healthRollupService := service.NewHealthRollupService(serviceService, healthRollupRepository, serviceRepository, historyRepository)
+	healthRollupHandler := handler.NewHealthRollupHandler(handlerHandler, healthRollupService)
+	slaRepository := repository.NewSLARepository(repositoryRepository)
+	slaService := service.NewSLAService(serviceService, viperViper, slaRepository)
+	slaHandler := handler.NewSLAHandler(handlerHandler, slaService)
+	httpServer := server.NewHTTPServer(logger, viperViper, jwtJWT, syncedEnforcer, adminHandler, userHandler, resourceHandler, resourceTypeHandler, relationHandler, relationRuleHandler, graphHandler, impactHandler, pathHandler, dependencyGraphHandler, historyHandler, snapshotHandler, notificationHandler, sensitiveHandler, configurationHandler, configTemplateHandler, applicationHandler, healthCheckHandler, healthRollupHandler, slaHandler)
 	jobJob := job.NewJob(transaction, logger, sidSid)
 	userJob := job.NewUserJob(jobJob, userRepository)
 	jobServer := server.NewJobServer(logger, userJob)
@@ -49,11 +106,11 @@ func NewWire(viperViper *viper.Viper, logger *log.Logger) (*app.App, func(), err
 
 // wire.go:
 
-var repositorySet = wire.NewSet(repository.NewDB, repository.NewRepository, repository.NewTransaction, repository.NewUserRepository, repository.NewCasbinEnforcer, repository.NewAdminRepository)
+var repositorySet = wire.NewSet(repository.NewDB, repository.NewRepository, repository.NewTransaction, repository.NewUserRepository, repository.NewCasbinEnforcer, repository.NewAdminRepository, repository.NewResourceRepository, repository.NewResourceTypeRepository, repository.NewRelationRepository, repository.NewRelationRuleRepository, repository.NewImpactRepository, repository.NewPathRepository, repository.NewDependencyGraphRepository, repository.NewHistoryRepository, repository.NewServiceRepository, repository.NewBusinessRepository, repository.NewSnapshotRepository, repository.NewNotificationRepository, repository.NewSensitiveRepository, repository.NewConfigurationRepository, repository.NewConfigTemplateRepository)
 
-var serviceSet = wire.NewSet(service.NewService, service.NewUserService, service.NewAdminService)
+var serviceSet = wire.NewSet(service.NewService, service.NewUserService, service.NewAdminService, service.NewResourceService, service.NewResourceTypeService, service.NewRelationService, service.NewRelationRuleService, service.NewGraphService, service.NewImpactService, service.NewPathService, service.NewDependencyGraphService, service.NewHistoryService, service.NewSnapshotService, service.NewNotificationService, service.NewSensitiveService, service.NewConfigurationService, service.NewConfigTemplateService)
 
-var handlerSet = wire.NewSet(handler.NewHandler, handler.NewUserHandler, handler.NewAdminHandler)
+var handlerSet = wire.NewSet(handler.NewHandler, handler.NewUserHandler, handler.NewAdminHandler, handler.NewResourceHandler, handler.NewResourceTypeHandler, handler.NewRelationHandler, handler.NewRelationRuleHandler, handler.NewGraphHandler, handler.NewImpactHandler, handler.NewPathHandler, handler.NewDependencyGraphHandler, handler.NewHistoryHandler, handler.NewSnapshotHandler, handler.NewNotificationHandler, handler.NewSensitiveHandler, handler.NewConfigurationHandler, handler.NewConfigTemplateHandler)
 
 var jobSet = wire.NewSet(job.NewJob, job.NewUserJob)
 
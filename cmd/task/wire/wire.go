@@ -8,8 +8,11 @@ import (
 	"github.com/spf13/viper"
 	"nunu-layout-admin/internal/repository"
 	"nunu-layout-admin/internal/server"
+	"nunu-layout-admin/internal/service"
 	"nunu-layout-admin/internal/task"
 	"nunu-layout-admin/pkg/app"
+	"nunu-layout-admin/pkg/envelope"
+	"nunu-layout-admin/pkg/jwt"
 	"nunu-layout-admin/pkg/log"
 	"nunu-layout-admin/pkg/sid"
 )
@@ -21,11 +24,51 @@ var repositorySet = wire.NewSet(
 	repository.NewTransaction,
 	repository.NewUserRepository,
 	repository.NewCasbinEnforcer,
+	repository.NewPathRepository,
+	repository.NewRelationRepository,
+	repository.NewImpactRepository,
+	repository.NewDependencyGraphRepository,
+	repository.NewSnapshotRepository,
+	repository.NewHistoryRepository,
+	repository.NewResourceRepository,
+	repository.NewServiceRepository,
+	repository.NewBusinessRepository,
+	repository.NewNotificationRepository,
+	repository.NewSensitiveRepository,
+	repository.NewConfigurationRepository,
+	repository.NewConfigTemplateRepository,
+	repository.NewApplicationRepository,
+	repository.NewHealthCheckRepository,
+	repository.NewHealthRollupRepository,
+	repository.NewSLARepository,
 )
 
 var taskSet = wire.NewSet(
 	task.NewTask,
 	task.NewUserTask,
+	task.NewPathTask,
+	task.NewDependencyGraphTask,
+	task.NewSnapshotTask,
+	task.NewAuditTask,
+	task.NewNotificationTask,
+	task.NewConfigurationTask,
+	task.NewApplicationTask,
+	task.NewHealthCheckTask,
+	task.NewHealthRollupTask,
+	task.NewSLATask,
+)
+
+var serviceSet = wire.NewSet(
+	service.NewService,
+	service.NewDependencyGraphService,
+	service.NewSnapshotService,
+	service.NewAuditService,
+	service.NewNotificationService,
+	service.NewConfigurationService,
+	service.NewApplicationService,
+	service.NewHealthCheckService,
+	service.NewHealthRollupService,
+	service.NewSLAService,
 )
 var serverSet = wire.NewSet(
 	server.NewTaskServer,
@@ -44,9 +87,12 @@ func newApp(
 func NewWire(*viper.Viper, *log.Logger) (*app.App, func(), error) {
 	panic(wire.Build(
 		repositorySet,
+		serviceSet,
 		taskSet,
 		serverSet,
 		newApp,
 		sid.NewSid,
+		jwt.NewJwt,
+		envelope.NewKeyring,
 	))
 }
@@ -11,8 +11,11 @@ import (
 	"github.com/spf13/viper"
 	"nunu-layout-admin/internal/repository"
 	"nunu-layout-admin/internal/server"
+	"nunu-layout-admin/internal/service"
 	"nunu-layout-admin/internal/task"
 	"nunu-layout-admin/pkg/app"
+	"nunu-layout-admin/pkg/envelope"
+	"nunu-layout-admin/pkg/jwt"
 	"nunu-layout-admin/pkg/log"
 	"nunu-layout-admin/pkg/sid"
 )
@@ -28,7 +31,46 @@ func NewWire(viperViper *viper.Viper, logger *log.Logger) (*app.App, func(), err
 	taskTask := task.NewTask(transaction, logger, sidSid)
 	userRepository := repository.NewUserRepository(repositoryRepository)
 	userTask := task.NewUserTask(taskTask, userRepository)
-	taskServer := server.NewTaskServer(logger, userTask)
+	pathRepository := repository.NewPathRepository(repositoryRepository)
+	pathTask := task.NewPathTask(taskTask, pathRepository)
+	jwtJWT := jwt.NewJwt(viperViper)
+	serviceService := service.NewService(transaction, logger, sidSid, jwtJWT)
+	dependencyGraphRepository := repository.NewDependencyGraphRepository(repositoryRepository)
+	relationRepository := repository.NewRelationRepository(repositoryRepository)
+	impactRepository := repository.NewImpactRepository(repositoryRepository)
+	dependencyGraphService := service.NewDependencyGraphService(serviceService, dependencyGraphRepository, relationRepository, impactRepository)
+	dependencyGraphTask := task.NewDependencyGraphTask(taskTask, viperViper, dependencyGraphService)
+	keyring := envelope.NewKeyring(viperViper)
+	snapshotRepository := repository.NewSnapshotRepository(repositoryRepository)
+	historyRepository := repository.NewHistoryRepository(repositoryRepository)
+	resourceRepository := repository.NewResourceRepository(repositoryRepository)
+	serviceRepository := repository.NewServiceRepository(repositoryRepository)
+	businessRepository := repository.NewBusinessRepository(repositoryRepository)
+	sensitiveRepository := repository.NewSensitiveRepository(repositoryRepository)
+	snapshotService := service.NewSnapshotService(serviceService, viperViper, keyring, snapshotRepository, historyRepository, resourceRepository, serviceRepository, businessRepository, relationRepository, pathRepository, sensitiveRepository)
+	snapshotTask := task.NewSnapshotTask(taskTask, snapshotService)
+	auditService := service.NewAuditService(serviceService, viperViper, historyRepository)
+	auditTask := task.NewAuditTask(taskTask, auditService)
+	notificationRepository := repository.NewNotificationRepository(repositoryRepository)
+	notificationService := service.NewNotificationService(serviceService, viperViper, notificationRepository)
+	notificationTask := task.NewNotificationTask(taskTask, notificationService)
+	configurationRepository := repository.NewConfigurationRepository(repositoryRepository)
+	configTemplateRepository := repository.NewConfigTemplateRepository(repositoryRepository)
+	configurationService := service.NewConfigurationService(serviceService, keyring, configurationRepository, configTemplateRepository, historyRepository, sensitiveRepository)
+	configurationTask := task.NewConfigurationTask(taskTask, configurationService)
+	applicationRepository := repository.NewApplicationRepository(repositoryRepository)
+	applicationService := service.NewApplicationService(serviceService, viperViper, applicationRepository, historyRepository)
+	applicationTask := task.NewApplicationTask(taskTask, applicationService)
+	healthCheckRepository := repository.NewHealthCheckRepository(repositoryRepository)
+	healthCheckService := service.NewHealthCheckService(serviceService, viperViper, keyring, healthCheckRepository, applicationRepository, historyRepository)
+	healthCheckTask := task.NewHealthCheckTask(taskTask, healthCheckService)
+	healthRollupRepository := repository.NewHealthRollupRepository(repositoryRepository)
+	healthRollupService := service.NewHealthRollupService(serviceService, healthRollupRepository, serviceRepository, historyRepository)
+	healthRollupTask := task.NewHealthRollupTask(taskTask, healthRollupService)
+	slaRepository := repository.NewSLARepository(repositoryRepository)
+	slaService := service.NewSLAService(serviceService, viperViper, slaRepository)
+	slaTask := task.NewSLATask(taskTask, slaService)
+	taskServer := server.NewTaskServer(logger, userTask, pathTask, dependencyGraphTask, snapshotTask, auditTask, notificationTask, configurationTask, applicationTask, healthCheckTask, healthRollupTask, slaTask)
 	appApp := newApp(taskServer)
 	return appApp, func() {
 	}, nil
@@ -36,9 +78,11 @@ func NewWire(viperViper *viper.Viper, logger *log.Logger) (*app.App, func(), err
 
 // wire.go:
 
-var repositorySet = wire.NewSet(repository.NewDB, repository.NewRepository, repository.NewTransaction, repository.NewUserRepository, repository.NewCasbinEnforcer)
+var repositorySet = wire.NewSet(repository.NewDB, repository.NewRepository, repository.NewTransaction, repository.NewUserRepository, repository.NewCasbinEnforcer, repository.NewPathRepository, repository.NewRelationRepository, repository.NewImpactRepository, repository.NewDependencyGraphRepository, repository.NewSnapshotRepository, repository.NewHistoryRepository, repository.NewResourceRepository, repository.NewServiceRepository, repository.NewBusinessRepository, repository.NewNotificationRepository, repository.NewSensitiveRepository, repository.NewConfigurationRepository, repository.NewConfigTemplateRepository, repository.NewApplicationRepository, repository.NewHealthCheckRepository, repository.NewHealthRollupRepository, repository.NewSLARepository)
 
-var taskSet = wire.NewSet(task.NewTask, task.NewUserTask)
+var taskSet = wire.NewSet(task.NewTask, task.NewUserTask, task.NewPathTask, task.NewDependencyGraphTask, task.NewSnapshotTask, task.NewAuditTask, task.NewNotificationTask, task.NewConfigurationTask, task.NewApplicationTask, task.NewHealthCheckTask, task.NewHealthRollupTask, task.NewSLATask)
+
+var serviceSet = wire.NewSet(service.NewService, service.NewDependencyGraphService, service.NewSnapshotService, service.NewAuditService, service.NewNotificationService, service.NewConfigurationService, service.NewApplicationService, service.NewHealthCheckService, service.NewHealthRollupService, service.NewSLAService)
 
 var serverSet = wire.NewSet(server.NewTaskServer)
 
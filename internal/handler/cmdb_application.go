@@ -0,0 +1,220 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	v1 "nunu-layout-admin/api/v1"
+	"nunu-layout-admin/internal/service"
+)
+
+type ApplicationHandler struct {
+	*Handler
+	applicationService service.ApplicationService
+}
+
+func NewApplicationHandler(
+	handler *Handler,
+	applicationService service.ApplicationService,
+) *ApplicationHandler {
+	return &ApplicationHandler{
+		Handler:            handler,
+		applicationService: applicationService,
+	}
+}
+
+// GetApplications godoc
+// @Summary 获取应用实例列表
+// @Schemes
+// @Description 分页获取应用实例，支持按名称、应用类型、状态、资源、环境、租户和健康状态过滤
+// @Tags CMDB应用模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request query v1.GetApplicationsRequest true "params"
+// @Success 200 {object} v1.GetApplicationsResponse
+// @Router /v1/cmdb/applications [get]
+func (h *ApplicationHandler) GetApplications(ctx *gin.Context) {
+	var req v1.GetApplicationsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	data, err := h.applicationService.GetApplications(ctx, &req)
+	if err != nil {
+		h.handleServiceError(ctx, err)
+		return
+	}
+	v1.HandleSuccess(ctx, data)
+}
+
+// GetApplication godoc
+// @Summary 获取应用实例详情
+// @Schemes
+// @Description 获取应用实例详情
+// @Tags CMDB应用模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param appId query string true "应用实例唯一标识"
+// @Success 200 {object} v1.GetApplicationResponse
+// @Router /v1/cmdb/application [get]
+func (h *ApplicationHandler) GetApplication(ctx *gin.Context) {
+	var req v1.GetApplicationRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	data, err := h.applicationService.GetApplication(ctx, req.AppID)
+	if err != nil {
+		h.handleServiceError(ctx, err)
+		return
+	}
+	v1.HandleSuccess(ctx, data)
+}
+
+// ApplicationCreate godoc
+// @Summary 登记应用实例
+// @Schemes
+// @Description 登记部署在资源上的应用实例，status为登记时的实际状态，默认stopped
+// @Tags CMDB应用模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.ApplicationCreateRequest true "params"
+// @Success 200 {object} v1.Response
+// @Router /v1/cmdb/application [post]
+func (h *ApplicationHandler) ApplicationCreate(ctx *gin.Context) {
+	var req v1.ApplicationCreateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	if err := h.applicationService.ApplicationCreate(ctx, &req); err != nil {
+		h.handleServiceError(ctx, err)
+		return
+	}
+	v1.HandleSuccess(ctx, nil)
+}
+
+// ApplicationUpdate godoc
+// @Summary 更新应用实例
+// @Schemes
+// @Description 更新应用实例的部署和描述信息，状态只能通过生命周期操作修改
+// @Tags CMDB应用模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.ApplicationUpdateRequest true "params"
+// @Success 200 {object} v1.Response
+// @Router /v1/cmdb/application [put]
+func (h *ApplicationHandler) ApplicationUpdate(ctx *gin.Context) {
+	var req v1.ApplicationUpdateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	if err := h.applicationService.ApplicationUpdate(ctx, &req); err != nil {
+		h.handleServiceError(ctx, err)
+		return
+	}
+	v1.HandleSuccess(ctx, nil)
+}
+
+// ApplicationDelete godoc
+// @Summary 删除应用实例
+// @Schemes
+// @Description 只能删除已停止或故障且没有配置的应用实例
+// @Tags CMDB应用模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param appId query string true "应用实例唯一标识"
+// @Success 200 {object} v1.Response
+// @Router /v1/cmdb/application [delete]
+func (h *ApplicationHandler) ApplicationDelete(ctx *gin.Context) {
+	var req v1.ApplicationDeleteRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	if err := h.applicationService.ApplicationDelete(ctx, req.AppID); err != nil {
+		h.handleServiceError(ctx, err)
+		return
+	}
+	v1.HandleSuccess(ctx, nil)
+}
+
+// ApplicationLifecycle godoc
+// @Summary 应用生命周期操作
+// @Schemes
+// @Description 执行start/mark_running/stop/mark_stopped/mark_failed/begin_upgrade/finish_upgrade/enter_maintenance/leave_maintenance，当前状态不允许该操作时返回允许的起始状态
+// @Tags CMDB应用模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.ApplicationLifecycleRequest true "params"
+// @Success 200 {object} v1.ApplicationLifecycleResponse
+// @Router /v1/cmdb/application/lifecycle [post]
+func (h *ApplicationHandler) ApplicationLifecycle(ctx *gin.Context) {
+	var req v1.ApplicationLifecycleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	data, err := h.applicationService.ApplicationLifecycle(ctx, &req)
+	if err != nil {
+		h.handleServiceError(ctx, err)
+		return
+	}
+	v1.HandleSuccess(ctx, data)
+}
+
+// ApplicationHeartbeat godoc
+// @Summary 应用心跳上报
+// @Schemes
+// @Description Agent上报进程ID、版本、资源使用和监听端口，启动中的实例收到心跳后变为running。使用Agent令牌认证(security.agent.tokens)，不使用管理员登录令牌
+// @Tags CMDB应用模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.ApplicationHeartbeatRequest true "params"
+// @Success 200 {object} v1.ApplicationHeartbeatResponse
+// @Router /v1/cmdb/application/heartbeat [post]
+func (h *ApplicationHandler) ApplicationHeartbeat(ctx *gin.Context) {
+	var req v1.ApplicationHeartbeatRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	data, err := h.applicationService.ApplicationHeartbeat(ctx, &req)
+	if err != nil {
+		h.handleServiceError(ctx, err)
+		return
+	}
+	v1.HandleSuccess(ctx, data)
+}
+
+// ApplicationHeartbeatBatch godoc
+// @Summary 批量应用心跳上报
+// @Schemes
+// @Description 一个Agent管理多个实例时批量上报心跳，每条单独处理并返回结果。使用Agent令牌认证(security.agent.tokens)
+// @Tags CMDB应用模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.ApplicationHeartbeatBatchRequest true "params"
+// @Success 200 {object} v1.ApplicationHeartbeatBatchResponse
+// @Router /v1/cmdb/applications/heartbeat [post]
+func (h *ApplicationHandler) ApplicationHeartbeatBatch(ctx *gin.Context) {
+	var req v1.ApplicationHeartbeatBatchRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	data, err := h.applicationService.ApplicationHeartbeatBatch(ctx, &req)
+	if err != nil {
+		h.handleServiceError(ctx, err)
+		return
+	}
+	v1.HandleSuccess(ctx, data)
+}
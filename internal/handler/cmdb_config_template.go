@@ -0,0 +1,145 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	v1 "nunu-layout-admin/api/v1"
+	"nunu-layout-admin/internal/service"
+)
+
+type ConfigTemplateHandler struct {
+	*Handler
+	configTemplateService service.ConfigTemplateService
+}
+
+func NewConfigTemplateHandler(
+	handler *Handler,
+	configTemplateService service.ConfigTemplateService,
+) *ConfigTemplateHandler {
+	return &ConfigTemplateHandler{
+		Handler:               handler,
+		configTemplateService: configTemplateService,
+	}
+}
+
+// GetConfigTemplates godoc
+// @Summary 获取配置模板列表
+// @Schemes
+// @Description 分页获取配置模板，支持按名称、应用类型和分类过滤
+// @Tags CMDB配置模板模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request query v1.GetConfigTemplatesRequest true "params"
+// @Success 200 {object} v1.GetConfigTemplatesResponse
+// @Router /v1/cmdb/config-templates [get]
+func (h *ConfigTemplateHandler) GetConfigTemplates(ctx *gin.Context) {
+	var req v1.GetConfigTemplatesRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	data, err := h.configTemplateService.GetConfigTemplates(ctx, &req)
+	if err != nil {
+		h.handleServiceError(ctx, err)
+		return
+	}
+	v1.HandleSuccess(ctx, data)
+}
+
+// GetConfigTemplate godoc
+// @Summary 获取配置模板详情
+// @Schemes
+// @Description 获取配置模板详情
+// @Tags CMDB配置模板模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param templateId query string true "模板唯一标识"
+// @Success 200 {object} v1.GetConfigTemplateResponse
+// @Router /v1/cmdb/config-template [get]
+func (h *ConfigTemplateHandler) GetConfigTemplate(ctx *gin.Context) {
+	var req v1.GetConfigTemplateRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	data, err := h.configTemplateService.GetConfigTemplate(ctx, req.TemplateID)
+	if err != nil {
+		h.handleServiceError(ctx, err)
+		return
+	}
+	v1.HandleSuccess(ctx, data)
+}
+
+// ConfigTemplateCreate godoc
+// @Summary 创建配置模板
+// @Schemes
+// @Description 模板数据中用${变量名}引用变量，variables为变量的JSON-Schema定义；设为默认模板时取消同一应用类型其他模板的默认标记
+// @Tags CMDB配置模板模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.ConfigTemplateCreateRequest true "params"
+// @Success 200 {object} v1.Response
+// @Router /v1/cmdb/config-template [post]
+func (h *ConfigTemplateHandler) ConfigTemplateCreate(ctx *gin.Context) {
+	var req v1.ConfigTemplateCreateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	if err := h.configTemplateService.ConfigTemplateCreate(ctx, &req); err != nil {
+		h.handleServiceError(ctx, err)
+		return
+	}
+	v1.HandleSuccess(ctx, nil)
+}
+
+// ConfigTemplateUpdate godoc
+// @Summary 更新配置模板
+// @Schemes
+// @Description 更新模板不会修改已渲染的配置，升级version后通过重新渲染接口更新派生的配置
+// @Tags CMDB配置模板模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.ConfigTemplateUpdateRequest true "params"
+// @Success 200 {object} v1.Response
+// @Router /v1/cmdb/config-template [put]
+func (h *ConfigTemplateHandler) ConfigTemplateUpdate(ctx *gin.Context) {
+	var req v1.ConfigTemplateUpdateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	if err := h.configTemplateService.ConfigTemplateUpdate(ctx, &req); err != nil {
+		h.handleServiceError(ctx, err)
+		return
+	}
+	v1.HandleSuccess(ctx, nil)
+}
+
+// ConfigTemplateDelete godoc
+// @Summary 删除配置模板
+// @Schemes
+// @Description 已被配置使用的模板不能删除
+// @Tags CMDB配置模板模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param templateId query string true "模板唯一标识"
+// @Success 200 {object} v1.Response
+// @Router /v1/cmdb/config-template [delete]
+func (h *ConfigTemplateHandler) ConfigTemplateDelete(ctx *gin.Context) {
+	var req v1.ConfigTemplateDeleteRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	if err := h.configTemplateService.ConfigTemplateDelete(ctx, req.TemplateID); err != nil {
+		h.handleServiceError(ctx, err)
+		return
+	}
+	v1.HandleSuccess(ctx, nil)
+}
@@ -0,0 +1,467 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	v1 "nunu-layout-admin/api/v1"
+	"nunu-layout-admin/internal/service"
+)
+
+type ConfigurationHandler struct {
+	*Handler
+	configurationService service.ConfigurationService
+}
+
+func NewConfigurationHandler(
+	handler *Handler,
+	configurationService service.ConfigurationService,
+) *ConfigurationHandler {
+	return &ConfigurationHandler{
+		Handler:              handler,
+		configurationService: configurationService,
+	}
+}
+
+// GetConfigurations godoc
+// @Summary 配置列表
+// @Schemes
+// @Description 分页查询配置实例，配置数据为当前生效版本的内容，敏感字段默认脱敏
+// @Tags CMDB配置模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request query v1.GetConfigurationsRequest true "params"
+// @Success 200 {object} v1.GetConfigurationsResponse
+// @Router /v1/cmdb/configurations [get]
+func (h *ConfigurationHandler) GetConfigurations(ctx *gin.Context) {
+	var req v1.GetConfigurationsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	data, err := h.configurationService.GetConfigurations(ctx, &req)
+	if err != nil {
+		h.handleServiceError(ctx, err)
+		return
+	}
+	v1.HandleSuccess(ctx, data)
+}
+
+// GetConfiguration godoc
+// @Summary 获取配置详情
+// @Schemes
+// @Description 按配置ID获取配置实例
+// @Tags CMDB配置模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request query v1.GetConfigurationRequest true "params"
+// @Success 200 {object} v1.GetConfigurationResponse
+// @Router /v1/cmdb/configuration [get]
+func (h *ConfigurationHandler) GetConfiguration(ctx *gin.Context) {
+	var req v1.GetConfigurationRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	data, err := h.configurationService.GetConfiguration(ctx, &req)
+	if err != nil {
+		h.handleServiceError(ctx, err)
+		return
+	}
+	v1.HandleSuccess(ctx, data)
+}
+
+// ConfigurationCreate godoc
+// @Summary 创建配置
+// @Schemes
+// @Description 创建配置实例，配置数据保存为第一个草稿版本，发布后生效
+// @Tags CMDB配置模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.ConfigurationCreateRequest true "params"
+// @Success 200 {object} v1.ConfigurationCreateResponse
+// @Router /v1/cmdb/configuration [post]
+func (h *ConfigurationHandler) ConfigurationCreate(ctx *gin.Context) {
+	var req v1.ConfigurationCreateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	data, err := h.configurationService.ConfigurationCreate(ctx, &req)
+	if err != nil {
+		h.handleServiceError(ctx, err)
+		return
+	}
+	v1.HandleSuccess(ctx, data)
+}
+
+// ConfigurationUpdate godoc
+// @Summary 更新配置信息
+// @Schemes
+// @Description 更新配置的名称、归属、状态和标签，配置数据需要通过版本修改
+// @Tags CMDB配置模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.ConfigurationUpdateRequest true "params"
+// @Success 200 {object} v1.Response
+// @Router /v1/cmdb/configuration [put]
+func (h *ConfigurationHandler) ConfigurationUpdate(ctx *gin.Context) {
+	var req v1.ConfigurationUpdateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	if err := h.configurationService.ConfigurationUpdate(ctx, &req); err != nil {
+		h.handleServiceError(ctx, err)
+		return
+	}
+	v1.HandleSuccess(ctx, nil)
+}
+
+// ConfigurationDelete godoc
+// @Summary 删除配置
+// @Schemes
+// @Description 删除配置实例及其全部版本
+// @Tags CMDB配置模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request query v1.ConfigurationDeleteRequest true "params"
+// @Success 200 {object} v1.Response
+// @Router /v1/cmdb/configuration [delete]
+func (h *ConfigurationHandler) ConfigurationDelete(ctx *gin.Context) {
+	var req v1.ConfigurationDeleteRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	if err := h.configurationService.ConfigurationDelete(ctx, req.ConfigID); err != nil {
+		h.handleServiceError(ctx, err)
+		return
+	}
+	v1.HandleSuccess(ctx, nil)
+}
+
+// GetRevisions godoc
+// @Summary 配置版本列表
+// @Schemes
+// @Description 按版本号倒序分页查询配置的版本
+// @Tags CMDB配置模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request query v1.GetConfigRevisionsRequest true "params"
+// @Success 200 {object} v1.GetConfigRevisionsResponse
+// @Router /v1/cmdb/configuration/revisions [get]
+func (h *ConfigurationHandler) GetRevisions(ctx *gin.Context) {
+	var req v1.GetConfigRevisionsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	data, err := h.configurationService.GetRevisions(ctx, &req)
+	if err != nil {
+		h.handleServiceError(ctx, err)
+		return
+	}
+	v1.HandleSuccess(ctx, data)
+}
+
+// GetRevision godoc
+// @Summary 获取配置版本
+// @Schemes
+// @Description 获取配置的指定版本
+// @Tags CMDB配置模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request query v1.GetConfigRevisionRequest true "params"
+// @Success 200 {object} v1.GetConfigRevisionResponse
+// @Router /v1/cmdb/configuration/revision [get]
+func (h *ConfigurationHandler) GetRevision(ctx *gin.Context) {
+	var req v1.GetConfigRevisionRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	data, err := h.configurationService.GetRevision(ctx, &req)
+	if err != nil {
+		h.handleServiceError(ctx, err)
+		return
+	}
+	v1.HandleSuccess(ctx, data)
+}
+
+// RevisionCreate godoc
+// @Summary 创建配置草稿
+// @Schemes
+// @Description 基于指定版本(默认当前生效版本)创建草稿，敏感字段提交脱敏占位符表示沿用基础版本的值
+// @Tags CMDB配置模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.ConfigRevisionCreateRequest true "params"
+// @Success 200 {object} v1.ConfigRevisionCreateResponse
+// @Router /v1/cmdb/configuration/revision [post]
+func (h *ConfigurationHandler) RevisionCreate(ctx *gin.Context) {
+	var req v1.ConfigRevisionCreateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	data, err := h.configurationService.RevisionCreate(ctx, &req)
+	if err != nil {
+		h.handleServiceError(ctx, err)
+		return
+	}
+	v1.HandleSuccess(ctx, data)
+}
+
+// RevisionUpdate godoc
+// @Summary 修改配置草稿
+// @Schemes
+// @Description 只能修改草稿状态的版本
+// @Tags CMDB配置模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.ConfigRevisionUpdateRequest true "params"
+// @Success 200 {object} v1.Response
+// @Router /v1/cmdb/configuration/revision [put]
+func (h *ConfigurationHandler) RevisionUpdate(ctx *gin.Context) {
+	var req v1.ConfigRevisionUpdateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	if err := h.configurationService.RevisionUpdate(ctx, &req); err != nil {
+		h.handleServiceError(ctx, err)
+		return
+	}
+	v1.HandleSuccess(ctx, nil)
+}
+
+// RevisionDelete godoc
+// @Summary 删除配置草稿
+// @Schemes
+// @Description 只能删除草稿状态的版本，版本号不会复用
+// @Tags CMDB配置模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request query v1.ConfigRevisionDeleteRequest true "params"
+// @Success 200 {object} v1.Response
+// @Router /v1/cmdb/configuration/revision [delete]
+func (h *ConfigurationHandler) RevisionDelete(ctx *gin.Context) {
+	var req v1.ConfigRevisionDeleteRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	if err := h.configurationService.RevisionDelete(ctx, &req); err != nil {
+		h.handleServiceError(ctx, err)
+		return
+	}
+	v1.HandleSuccess(ctx, nil)
+}
+
+// RevisionSubmit godoc
+// @Summary 提交配置版本
+// @Schemes
+// @Description 草稿提交为待发布，提交后内容不可再修改
+// @Tags CMDB配置模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.ConfigRevisionActionRequest true "params"
+// @Success 200 {object} v1.Response
+// @Router /v1/cmdb/configuration/revision/submit [post]
+func (h *ConfigurationHandler) RevisionSubmit(ctx *gin.Context) {
+	var req v1.ConfigRevisionActionRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	if err := h.configurationService.RevisionSubmit(ctx, &req); err != nil {
+		h.handleServiceError(ctx, err)
+		return
+	}
+	v1.HandleSuccess(ctx, nil)
+}
+
+// RevisionReject godoc
+// @Summary 驳回配置版本
+// @Schemes
+// @Description 待发布或等待生效的版本退回为草稿
+// @Tags CMDB配置模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.ConfigRevisionActionRequest true "params"
+// @Success 200 {object} v1.Response
+// @Router /v1/cmdb/configuration/revision/reject [post]
+func (h *ConfigurationHandler) RevisionReject(ctx *gin.Context) {
+	var req v1.ConfigRevisionActionRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	if err := h.configurationService.RevisionReject(ctx, &req); err != nil {
+		h.handleServiceError(ctx, err)
+		return
+	}
+	v1.HandleSuccess(ctx, nil)
+}
+
+// RevisionPublish godoc
+// @Summary 发布配置版本
+// @Schemes
+// @Description 批准待发布的版本。没有生效时间或生效时间已到时立即生效，否则到生效时间后由定时任务发布
+// @Tags CMDB配置模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.ConfigRevisionActionRequest true "params"
+// @Success 200 {object} v1.ConfigRevisionPublishResponse
+// @Router /v1/cmdb/configuration/revision/publish [post]
+func (h *ConfigurationHandler) RevisionPublish(ctx *gin.Context) {
+	var req v1.ConfigRevisionActionRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	data, err := h.configurationService.RevisionPublish(ctx, &req)
+	if err != nil {
+		h.handleServiceError(ctx, err)
+		return
+	}
+	v1.HandleSuccess(ctx, data)
+}
+
+// Rollback godoc
+// @Summary 回滚配置
+// @Schemes
+// @Description 把曾经发布过的版本复制为新版本并立即发布
+// @Tags CMDB配置模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.ConfigRollbackRequest true "params"
+// @Success 200 {object} v1.ConfigRollbackResponse
+// @Router /v1/cmdb/configuration/rollback [post]
+func (h *ConfigurationHandler) Rollback(ctx *gin.Context) {
+	var req v1.ConfigRollbackRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	data, err := h.configurationService.Rollback(ctx, &req)
+	if err != nil {
+		h.handleServiceError(ctx, err)
+		return
+	}
+	v1.HandleSuccess(ctx, data)
+}
+
+// DiffRevisions godoc
+// @Summary 比较配置版本
+// @Schemes
+// @Description 字段级比较两个版本的配置数据，未指定from时与to的基础版本比较
+// @Tags CMDB配置模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request query v1.ConfigRevisionDiffRequest true "params"
+// @Success 200 {object} v1.ConfigRevisionDiffResponse
+// @Router /v1/cmdb/configuration/revision/diff [get]
+func (h *ConfigurationHandler) DiffRevisions(ctx *gin.Context) {
+	var req v1.ConfigRevisionDiffRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	data, err := h.configurationService.DiffRevisions(ctx, &req)
+	if err != nil {
+		h.handleServiceError(ctx, err)
+		return
+	}
+	v1.HandleSuccess(ctx, data)
+}
+
+// ConfigurationRender godoc
+// @Summary 用模板渲染配置
+// @Schemes
+// @Description 按模板和变量渲染新配置并生成第一个草稿版本，未指定模板时使用应用类型的默认模板
+// @Tags CMDB配置模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.ConfigurationRenderRequest true "params"
+// @Success 200 {object} v1.ConfigurationRenderResponse
+// @Router /v1/cmdb/configuration/render [post]
+func (h *ConfigurationHandler) ConfigurationRender(ctx *gin.Context) {
+	var req v1.ConfigurationRenderRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	data, err := h.configurationService.ConfigurationRender(ctx, &req)
+	if err != nil {
+		h.handleServiceError(ctx, err)
+		return
+	}
+	v1.HandleSuccess(ctx, data)
+}
+
+// ConfigurationRerender godoc
+// @Summary 重新渲染模板派生的配置
+// @Schemes
+// @Description 模板升级后用新版本重新渲染派生的配置并生成草稿版本，报告发布后被手工修改过的配置；dryRun只报告不修改
+// @Tags CMDB配置模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.ConfigurationRerenderRequest true "params"
+// @Success 200 {object} v1.ConfigurationRerenderResponse
+// @Router /v1/cmdb/configuration/rerender [post]
+func (h *ConfigurationHandler) ConfigurationRerender(ctx *gin.Context) {
+	var req v1.ConfigurationRerenderRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	data, err := h.configurationService.ConfigurationRerender(ctx, &req)
+	if err != nil {
+		h.handleServiceError(ctx, err)
+		return
+	}
+	v1.HandleSuccess(ctx, data)
+}
+
+// ConfigurationLint godoc
+// @Summary 配置数据合规检查
+// @Schemes
+// @Description 按应用类型当前的ConfigSchema检查该类型全部配置已发布的内容和未发布的版本，返回不合规的配置及字段
+// @Tags CMDB配置模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param typeName query string true "应用类型名称"
+// @Success 200 {object} v1.ConfigurationLintResponse
+// @Router /v1/cmdb/configurations/lint [get]
+func (h *ConfigurationHandler) ConfigurationLint(ctx *gin.Context) {
+	var req v1.ConfigurationLintRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	data, err := h.configurationService.ConfigurationLint(ctx, req.TypeName)
+	if err != nil {
+		h.handleServiceError(ctx, err)
+		return
+	}
+	v1.HandleSuccess(ctx, data)
+}
@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	v1 "nunu-layout-admin/api/v1"
+	"nunu-layout-admin/internal/service"
+)
+
+type DependencyGraphHandler struct {
+	*Handler
+	dependencyGraphService service.DependencyGraphService
+}
+
+func NewDependencyGraphHandler(
+	handler *Handler,
+	dependencyGraphService service.DependencyGraphService,
+) *DependencyGraphHandler {
+	return &DependencyGraphHandler{
+		Handler:                handler,
+		dependencyGraphService: dependencyGraphService,
+	}
+}
+
+// Compute godoc
+// @Summary 计算依赖图
+// @Schemes
+// @Description 立即为根对象计算依赖图，与最新版本相比有变化时保存为新版本
+// @Tags CMDB关系图模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.DependencyGraphComputeRequest true "参数"
+// @Success 200 {object} v1.DependencyGraphComputeResponse
+// @Router /v1/cmdb/dependency-graph/compute [post]
+func (h *DependencyGraphHandler) Compute(ctx *gin.Context) {
+	var req v1.DependencyGraphComputeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	data, err := h.dependencyGraphService.Compute(ctx, &req)
+	if err != nil {
+		h.handleServiceError(ctx, err)
+		return
+	}
+	v1.HandleSuccess(ctx, data)
+}
+
+// GetDependencyGraph godoc
+// @Summary 获取依赖图
+// @Schemes
+// @Description 获取根对象依赖图的指定版本，不传version时返回最新版本
+// @Tags CMDB关系图模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request query v1.GetDependencyGraphRequest true "参数"
+// @Success 200 {object} v1.GetDependencyGraphResponse
+// @Router /v1/cmdb/dependency-graph [get]
+func (h *DependencyGraphHandler) GetDependencyGraph(ctx *gin.Context) {
+	var req v1.GetDependencyGraphRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	data, err := h.dependencyGraphService.GetDependencyGraph(ctx, &req)
+	if err != nil {
+		h.handleServiceError(ctx, err)
+		return
+	}
+	v1.HandleSuccess(ctx, data)
+}
+
+// GetDependencyGraphVersions godoc
+// @Summary 依赖图版本列表
+// @Schemes
+// @Description 按版本倒序列出根对象依赖图的全部版本，不含图数据
+// @Tags CMDB关系图模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request query v1.GetDependencyGraphVersionsRequest true "参数"
+// @Success 200 {object} v1.GetDependencyGraphVersionsResponse
+// @Router /v1/cmdb/dependency-graph/versions [get]
+func (h *DependencyGraphHandler) GetDependencyGraphVersions(ctx *gin.Context) {
+	var req v1.GetDependencyGraphVersionsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	data, err := h.dependencyGraphService.GetDependencyGraphVersions(ctx, &req)
+	if err != nil {
+		h.handleServiceError(ctx, err)
+		return
+	}
+	v1.HandleSuccess(ctx, data)
+}
+
+// Diff godoc
+// @Summary 依赖图版本对比
+// @Schemes
+// @Description 对比两个版本依赖图新增、删除的节点和边
+// @Tags CMDB关系图模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request query v1.DependencyGraphDiffRequest true "参数"
+// @Success 200 {object} v1.DependencyGraphDiffResponse
+// @Router /v1/cmdb/dependency-graph/diff [get]
+func (h *DependencyGraphHandler) Diff(ctx *gin.Context) {
+	var req v1.DependencyGraphDiffRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	data, err := h.dependencyGraphService.Diff(ctx, &req)
+	if err != nil {
+		h.handleServiceError(ctx, err)
+		return
+	}
+	v1.HandleSuccess(ctx, data)
+}
@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	v1 "nunu-layout-admin/api/v1"
+	"nunu-layout-admin/internal/service"
+)
+
+type GraphHandler struct {
+	*Handler
+	graphService service.GraphService
+}
+
+func NewGraphHandler(
+	handler *Handler,
+	graphService service.GraphService,
+) *GraphHandler {
+	return &GraphHandler{
+		Handler:      handler,
+		graphService: graphService,
+	}
+}
+
+// Expand godoc
+// @Summary 关系图展开
+// @Schemes
+// @Description 从任意对象出发，按方向、关系类型、环境、租户过滤，返回N跳以内生效的节点和边
+// @Tags CMDB关系图模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param type query string true "对象类型(resource/application/configuration/service/business)"
+// @Param id query string true "对象ID"
+// @Param depth query int false "展开跳数(1-5)，默认1"
+// @Param direction query string false "方向(out/in/both)，默认both"
+// @Param relationTypes query []string false "关系类型，可重复或逗号分隔"
+// @Param environment query string false "环境"
+// @Param tenantId query string false "租户ID"
+// @Param includeInactive query bool false "是否包含未启用的关系"
+// @Param as_of query string false "时间点(RFC3339)，返回该时刻的关系图"
+// @Success 200 {object} v1.GraphExpandResponse
+// @Router /v1/cmdb/graph/expand [get]
+func (h *GraphHandler) Expand(ctx *gin.Context) {
+	var req v1.GraphExpandRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	data, err := h.graphService.Expand(ctx, &req)
+	if err != nil {
+		h.handleServiceError(ctx, err)
+		return
+	}
+	v1.HandleSuccess(ctx, data)
+}
@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	v1 "nunu-layout-admin/api/v1"
+	"nunu-layout-admin/internal/service"
+)
+
+type HealthCheckHandler struct {
+	*Handler
+	healthCheckService service.HealthCheckService
+}
+
+func NewHealthCheckHandler(
+	handler *Handler,
+	healthCheckService service.HealthCheckService,
+) *HealthCheckHandler {
+	return &HealthCheckHandler{
+		Handler:            handler,
+		healthCheckService: healthCheckService,
+	}
+}
+
+// GetProbeResults godoc
+// @Summary 获取应用健康检查探测结果
+// @Schemes
+// @Description 分页获取应用实例的主动探测结果，新的在前
+// @Tags CMDB应用模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request query v1.GetApplicationProbeResultsRequest true "params"
+// @Success 200 {object} v1.GetApplicationProbeResultsResponse
+// @Router /v1/cmdb/application/probe-results [get]
+func (h *HealthCheckHandler) GetProbeResults(ctx *gin.Context) {
+	var req v1.GetApplicationProbeResultsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	data, err := h.healthCheckService.GetProbeResults(ctx, &req)
+	if err != nil {
+		h.handleServiceError(ctx, err)
+		return
+	}
+	v1.HandleSuccess(ctx, data)
+}
+
+// ApplicationHealthCheck godoc
+// @Summary 立即执行健康检查
+// @Schemes
+// @Description 按应用类型的健康检查配置立即探测一次，结果计入探测历史并按阈值更新健康状态
+// @Tags CMDB应用模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.ApplicationHealthCheckRequest true "params"
+// @Success 200 {object} v1.ApplicationHealthCheckResponse
+// @Router /v1/cmdb/application/health-check [post]
+func (h *HealthCheckHandler) ApplicationHealthCheck(ctx *gin.Context) {
+	var req v1.ApplicationHealthCheckRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	data, err := h.healthCheckService.ApplicationHealthCheck(ctx, req.AppID)
+	if err != nil {
+		h.handleServiceError(ctx, err)
+		return
+	}
+	v1.HandleSuccess(ctx, data)
+}
@@ -0,0 +1,146 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	v1 "nunu-layout-admin/api/v1"
+	"nunu-layout-admin/internal/service"
+)
+
+type HealthRollupHandler struct {
+	*Handler
+	healthRollupService service.HealthRollupService
+}
+
+func NewHealthRollupHandler(
+	handler *Handler,
+	healthRollupService service.HealthRollupService,
+) *HealthRollupHandler {
+	return &HealthRollupHandler{
+		Handler:             handler,
+		healthRollupService: healthRollupService,
+	}
+}
+
+// GetServiceHealth godoc
+// @Summary 获取服务健康状态
+// @Schemes
+// @Description 返回服务保存的健康状态，以及按当前成员、关键依赖和汇总规则重新计算的结果
+// @Tags CMDB健康汇总模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request query v1.GetServiceHealthRequest true "params"
+// @Success 200 {object} v1.GetServiceHealthResponse
+// @Router /v1/cmdb/service/health [get]
+func (h *HealthRollupHandler) GetServiceHealth(ctx *gin.Context) {
+	var req v1.GetServiceHealthRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	data, err := h.healthRollupService.GetServiceHealth(ctx, req.ServiceID)
+	if err != nil {
+		h.handleServiceError(ctx, err)
+		return
+	}
+	v1.HandleSuccess(ctx, data)
+}
+
+// GetServiceHealthRule godoc
+// @Summary 获取服务健康汇总规则
+// @Schemes
+// @Description 服务没有配置规则时返回默认规则
+// @Tags CMDB健康汇总模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request query v1.GetServiceHealthRuleRequest true "params"
+// @Success 200 {object} v1.GetServiceHealthRuleResponse
+// @Router /v1/cmdb/service/health-rule [get]
+func (h *HealthRollupHandler) GetServiceHealthRule(ctx *gin.Context) {
+	var req v1.GetServiceHealthRuleRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	data, err := h.healthRollupService.GetServiceHealthRule(ctx, req.ServiceID)
+	if err != nil {
+		h.handleServiceError(ctx, err)
+		return
+	}
+	v1.HandleSuccess(ctx, data)
+}
+
+// ServiceHealthRuleUpdate godoc
+// @Summary 设置服务健康汇总规则
+// @Schemes
+// @Description 创建或替换服务的健康汇总规则
+// @Tags CMDB健康汇总模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.ServiceHealthRuleUpdateRequest true "params"
+// @Success 200 {object} v1.Response
+// @Router /v1/cmdb/service/health-rule [put]
+func (h *HealthRollupHandler) ServiceHealthRuleUpdate(ctx *gin.Context) {
+	var req v1.ServiceHealthRuleUpdateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	if err := h.healthRollupService.ServiceHealthRuleUpdate(ctx, &req); err != nil {
+		h.handleServiceError(ctx, err)
+		return
+	}
+	v1.HandleSuccess(ctx, nil)
+}
+
+// ServiceHealthRuleDelete godoc
+// @Summary 删除服务健康汇总规则
+// @Schemes
+// @Description 删除后服务使用默认规则
+// @Tags CMDB健康汇总模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request query v1.ServiceHealthRuleDeleteRequest true "params"
+// @Success 200 {object} v1.Response
+// @Router /v1/cmdb/service/health-rule [delete]
+func (h *HealthRollupHandler) ServiceHealthRuleDelete(ctx *gin.Context) {
+	var req v1.ServiceHealthRuleDeleteRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	if err := h.healthRollupService.ServiceHealthRuleDelete(ctx, req.ServiceID); err != nil {
+		h.handleServiceError(ctx, err)
+		return
+	}
+	v1.HandleSuccess(ctx, nil)
+}
+
+// RollupHealth godoc
+// @Summary 立即汇总健康状态
+// @Schemes
+// @Description 由应用实例健康状态汇总服务和业务的健康状态，状态变化记入变更历史；dryRun时只返回将发生的变化
+// @Tags CMDB健康汇总模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.HealthRollupRequest true "params"
+// @Success 200 {object} v1.HealthRollupResponse
+// @Router /v1/cmdb/health/rollup [post]
+func (h *HealthRollupHandler) RollupHealth(ctx *gin.Context) {
+	var req v1.HealthRollupRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	data, err := h.healthRollupService.RollupHealth(ctx, req.DryRun)
+	if err != nil {
+		h.handleServiceError(ctx, err)
+		return
+	}
+	v1.HandleSuccess(ctx, data)
+}
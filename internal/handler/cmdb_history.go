@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	v1 "nunu-layout-admin/api/v1"
+	"nunu-layout-admin/internal/service"
+)
+
+type HistoryHandler struct {
+	*Handler
+	historyService service.HistoryService
+}
+
+func NewHistoryHandler(
+	handler *Handler,
+	historyService service.HistoryService,
+) *HistoryHandler {
+	return &HistoryHandler{
+		Handler:        handler,
+		historyService: historyService,
+	}
+}
+
+// GetHistories godoc
+// @Summary 变更历史列表
+// @Schemes
+// @Description 按版本倒序查询资源、服务、业务或关系的变更历史，包含字段级差异和操作人
+// @Tags CMDB变更历史模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request query v1.GetHistoriesRequest true "params"
+// @Success 200 {object} v1.GetHistoriesResponse
+// @Router /v1/cmdb/histories [get]
+func (h *HistoryHandler) GetHistories(ctx *gin.Context) {
+	var req v1.GetHistoriesRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	data, err := h.historyService.GetHistories(ctx, &req)
+	if err != nil {
+		h.handleServiceError(ctx, err)
+		return
+	}
+	v1.HandleSuccess(ctx, data)
+}
+
+// Revert godoc
+// @Summary 回滚到历史版本
+// @Schemes
+// @Description 把资源、服务或业务的字段和标签恢复为指定历史版本，回滚本身记录为一条更新历史；currentVersion与对象最新版本不一致时拒绝执行
+// @Tags CMDB变更历史模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.HistoryRevertRequest true "params"
+// @Success 200 {object} v1.HistoryRevertResponse
+// @Router /v1/cmdb/history/revert [post]
+func (h *HistoryHandler) Revert(ctx *gin.Context) {
+	var req v1.HistoryRevertRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	data, err := h.historyService.Revert(ctx, &req)
+	if err != nil {
+		h.handleServiceError(ctx, err)
+		return
+	}
+	v1.HandleSuccess(ctx, data)
+}
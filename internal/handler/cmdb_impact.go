@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	v1 "nunu-layout-admin/api/v1"
+	"nunu-layout-admin/internal/service"
+)
+
+type ImpactHandler struct {
+	*Handler
+	impactService service.ImpactService
+}
+
+func NewImpactHandler(
+	handler *Handler,
+	impactService service.ImpactService,
+) *ImpactHandler {
+	return &ImpactHandler{
+		Handler:       handler,
+		impactService: impactService,
+	}
+}
+
+// GetImpact godoc
+// @Summary 资源故障影响分析
+// @Schemes
+// @Description 沿hosts/runs_on/depends_on/serves关系、应用部署与依赖、服务资源、业务服务关联，列出资源故障时受影响的资源、应用、配置、服务和业务及传播路径
+// @Tags CMDB关系图模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param resourceId query string true "资源唯一标识"
+// @Param maxDepth query int false "最大传播深度(1-20)，默认10"
+// @Success 200 {object} v1.GetImpactResponse
+// @Router /v1/cmdb/impact [get]
+func (h *ImpactHandler) GetImpact(ctx *gin.Context) {
+	var req v1.GetImpactRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	data, err := h.impactService.GetImpact(ctx, &req)
+	if err != nil {
+		h.handleServiceError(ctx, err)
+		return
+	}
+	v1.HandleSuccess(ctx, data)
+}
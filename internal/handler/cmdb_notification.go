@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	v1 "nunu-layout-admin/api/v1"
+	"nunu-layout-admin/internal/service"
+)
+
+type NotificationHandler struct {
+	*Handler
+	notificationService service.NotificationService
+}
+
+func NewNotificationHandler(
+	handler *Handler,
+	notificationService service.NotificationService,
+) *NotificationHandler {
+	return &NotificationHandler{
+		Handler:             handler,
+		notificationService: notificationService,
+	}
+}
+
+// GetDeliveries godoc
+// @Summary 变更通知投递记录
+// @Schemes
+// @Description 分页查询变更通知的投递状态、尝试次数和失败原因
+// @Tags CMDB变更通知模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request query v1.GetNotificationDeliveriesRequest true "params"
+// @Success 200 {object} v1.GetNotificationDeliveriesResponse
+// @Router /v1/cmdb/notification/deliveries [get]
+func (h *NotificationHandler) GetDeliveries(ctx *gin.Context) {
+	var req v1.GetNotificationDeliveriesRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	data, err := h.notificationService.GetDeliveries(ctx, &req)
+	if err != nil {
+		h.handleServiceError(ctx, err)
+		return
+	}
+	v1.HandleSuccess(ctx, data)
+}
+
+// RetryDelivery godoc
+// @Summary 重新发送通知
+// @Schemes
+// @Description 把重试次数已用尽的通知重新放回待发送队列
+// @Tags CMDB变更通知模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.NotificationRetryRequest true "params"
+// @Success 200 {object} v1.Response
+// @Router /v1/cmdb/notification/delivery/retry [post]
+func (h *NotificationHandler) RetryDelivery(ctx *gin.Context) {
+	var req v1.NotificationRetryRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	if err := h.notificationService.RetryDelivery(ctx, &req); err != nil {
+		h.handleServiceError(ctx, err)
+		return
+	}
+	v1.HandleSuccess(ctx, nil)
+}
@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	v1 "nunu-layout-admin/api/v1"
+	"nunu-layout-admin/internal/service"
+)
+
+type PathHandler struct {
+	*Handler
+	pathService service.PathService
+}
+
+func NewPathHandler(
+	handler *Handler,
+	pathService service.PathService,
+) *PathHandler {
+	return &PathHandler{
+		Handler:     handler,
+		pathService: pathService,
+	}
+}
+
+// GetRelationPaths godoc
+// @Summary 对象间关系路径
+// @Schemes
+// @Description 计算两个对象之间的关系路径：shortest按关系权重返回最短路径，all返回maxDepth跳以内的全部简单路径，结果会被缓存
+// @Tags CMDB关系图模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param startType query string true "起点对象类型"
+// @Param startId query string true "起点对象ID"
+// @Param endType query string true "终点对象类型"
+// @Param endId query string true "终点对象ID"
+// @Param mode query string false "路径模式(shortest/all)，默认shortest"
+// @Param maxDepth query int false "all模式的最大跳数(1-6)，默认4"
+// @Param direction query string false "方向(out/both)，默认out"
+// @Param refresh query bool false "是否忽略缓存重新计算"
+// @Success 200 {object} v1.GetRelationPathsResponse
+// @Router /v1/cmdb/paths [get]
+func (h *PathHandler) GetRelationPaths(ctx *gin.Context) {
+	var req v1.GetRelationPathsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	data, err := h.pathService.GetRelationPaths(ctx, &req)
+	if err != nil {
+		h.handleServiceError(ctx, err)
+		return
+	}
+	v1.HandleSuccess(ctx, data)
+}
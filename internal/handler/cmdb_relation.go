@@ -0,0 +1,248 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	v1 "nunu-layout-admin/api/v1"
+	"nunu-layout-admin/internal/service"
+)
+
+type RelationHandler struct {
+	*Handler
+	relationService service.RelationService
+}
+
+func NewRelationHandler(
+	handler *Handler,
+	relationService service.RelationService,
+) *RelationHandler {
+	return &RelationHandler{
+		Handler:         handler,
+		relationService: relationService,
+	}
+}
+
+// GetUniversalRelations godoc
+// @Summary 获取通用关系列表
+// @Schemes
+// @Description 分页获取通用关系，支持按源对象、目标对象、关系类型过滤
+// @Tags CMDB关系模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param page query int true "页码"
+// @Param pageSize query int true "每页数量"
+// @Param sourceType query string false "源对象类型"
+// @Param sourceId query string false "源对象ID"
+// @Param targetType query string false "目标对象类型"
+// @Param targetId query string false "目标对象ID"
+// @Param relationType query string false "关系类型"
+// @Success 200 {object} v1.GetUniversalRelationsResponse
+// @Router /v1/cmdb/universal-relations [get]
+func (h *RelationHandler) GetUniversalRelations(ctx *gin.Context) {
+	var req v1.GetUniversalRelationsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	data, err := h.relationService.GetUniversalRelations(ctx, &req)
+	if err != nil {
+		h.handleServiceError(ctx, err)
+		return
+	}
+	v1.HandleSuccess(ctx, data)
+}
+
+// GetUniversalRelation godoc
+// @Summary 获取通用关系详情
+// @Schemes
+// @Description 根据关系唯一标识获取通用关系
+// @Tags CMDB关系模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param relationId query string true "关系唯一标识"
+// @Success 200 {object} v1.GetUniversalRelationResponse
+// @Router /v1/cmdb/universal-relation [get]
+func (h *RelationHandler) GetUniversalRelation(ctx *gin.Context) {
+	var req v1.GetUniversalRelationRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	data, err := h.relationService.GetUniversalRelation(ctx, req.RelationID)
+	if err != nil {
+		h.handleServiceError(ctx, err)
+		return
+	}
+	v1.HandleSuccess(ctx, data)
+}
+
+// UniversalRelationCreate godoc
+// @Summary 创建通用关系
+// @Schemes
+// @Description 创建任意对象之间的关系，需满足资源类型允许的关系及关系规则约束
+// @Tags CMDB关系模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.UniversalRelationCreateRequest true "参数"
+// @Success 200 {object} v1.Response
+// @Router /v1/cmdb/universal-relation [post]
+func (h *RelationHandler) UniversalRelationCreate(ctx *gin.Context) {
+	var req v1.UniversalRelationCreateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	if err := h.relationService.UniversalRelationCreate(ctx, &req); err != nil {
+		h.handleServiceError(ctx, err)
+		return
+	}
+	v1.HandleSuccess(ctx, nil)
+}
+
+// UniversalRelationUpdate godoc
+// @Summary 更新通用关系
+// @Schemes
+// @Description 更新关系类型及属性，源和目标对象不可修改
+// @Tags CMDB关系模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.UniversalRelationUpdateRequest true "参数"
+// @Success 200 {object} v1.Response
+// @Router /v1/cmdb/universal-relation [put]
+func (h *RelationHandler) UniversalRelationUpdate(ctx *gin.Context) {
+	var req v1.UniversalRelationUpdateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	if err := h.relationService.UniversalRelationUpdate(ctx, &req); err != nil {
+		h.handleServiceError(ctx, err)
+		return
+	}
+	v1.HandleSuccess(ctx, nil)
+}
+
+// UniversalRelationDelete godoc
+// @Summary 删除通用关系
+// @Schemes
+// @Description 删除通用关系及其标签
+// @Tags CMDB关系模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param relationId query string true "关系唯一标识"
+// @Success 200 {object} v1.Response
+// @Router /v1/cmdb/universal-relation [delete]
+func (h *RelationHandler) UniversalRelationDelete(ctx *gin.Context) {
+	var req v1.UniversalRelationDeleteRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	if err := h.relationService.UniversalRelationDelete(ctx, req.RelationID); err != nil {
+		h.handleServiceError(ctx, err)
+		return
+	}
+	v1.HandleSuccess(ctx, nil)
+}
+
+// GetResourceRelations godoc
+// @Summary 获取资源关系列表
+// @Schemes
+// @Description 获取以该资源为源或目标的全部资源关系
+// @Tags CMDB关系模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param resourceId query string true "资源唯一标识"
+// @Success 200 {object} v1.GetResourceRelationsResponse
+// @Router /v1/cmdb/resource-relations [get]
+func (h *RelationHandler) GetResourceRelations(ctx *gin.Context) {
+	var req v1.GetResourceRelationsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	data, err := h.relationService.GetResourceRelations(ctx, req.ResourceID)
+	if err != nil {
+		h.handleServiceError(ctx, err)
+		return
+	}
+	v1.HandleSuccess(ctx, data)
+}
+
+// ResourceRelationCreate godoc
+// @Summary 创建资源关系
+// @Schemes
+// @Description 创建资源之间的关系，需满足资源类型允许的关系及关系规则约束
+// @Tags CMDB关系模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.ResourceRelationCreateRequest true "参数"
+// @Success 200 {object} v1.Response
+// @Router /v1/cmdb/resource-relation [post]
+func (h *RelationHandler) ResourceRelationCreate(ctx *gin.Context) {
+	var req v1.ResourceRelationCreateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	if err := h.relationService.ResourceRelationCreate(ctx, &req); err != nil {
+		h.handleServiceError(ctx, err)
+		return
+	}
+	v1.HandleSuccess(ctx, nil)
+}
+
+// ResourceRelationUpdate godoc
+// @Summary 更新资源关系
+// @Schemes
+// @Description 更新资源关系类型及属性
+// @Tags CMDB关系模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.ResourceRelationUpdateRequest true "参数"
+// @Success 200 {object} v1.Response
+// @Router /v1/cmdb/resource-relation [put]
+func (h *RelationHandler) ResourceRelationUpdate(ctx *gin.Context) {
+	var req v1.ResourceRelationUpdateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	if err := h.relationService.ResourceRelationUpdate(ctx, &req); err != nil {
+		h.handleServiceError(ctx, err)
+		return
+	}
+	v1.HandleSuccess(ctx, nil)
+}
+
+// ResourceRelationDelete godoc
+// @Summary 删除资源关系
+// @Schemes
+// @Description 删除资源关系
+// @Tags CMDB关系模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id query int true "关系ID"
+// @Success 200 {object} v1.Response
+// @Router /v1/cmdb/resource-relation [delete]
+func (h *RelationHandler) ResourceRelationDelete(ctx *gin.Context) {
+	var req v1.ResourceRelationDeleteRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	if err := h.relationService.ResourceRelationDelete(ctx, req.ID); err != nil {
+		h.handleServiceError(ctx, err)
+		return
+	}
+	v1.HandleSuccess(ctx, nil)
+}
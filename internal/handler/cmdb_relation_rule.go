@@ -0,0 +1,140 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	v1 "nunu-layout-admin/api/v1"
+	"nunu-layout-admin/internal/service"
+)
+
+type RelationRuleHandler struct {
+	*Handler
+	relationRuleService service.RelationRuleService
+}
+
+func NewRelationRuleHandler(
+	handler *Handler,
+	relationRuleService service.RelationRuleService,
+) *RelationRuleHandler {
+	return &RelationRuleHandler{
+		Handler:             handler,
+		relationRuleService: relationRuleService,
+	}
+}
+
+// GetRelationRules godoc
+// @Summary 获取关系规则列表
+// @Schemes
+// @Description 获取关系规则，支持按源类型和目标类型过滤
+// @Tags CMDB关系规则模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param sourceType query string false "源对象类型"
+// @Param targetType query string false "目标对象类型"
+// @Success 200 {object} v1.GetRelationRulesResponse
+// @Router /v1/cmdb/relation-rules [get]
+func (h *RelationRuleHandler) GetRelationRules(ctx *gin.Context) {
+	var req v1.GetRelationRulesRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	data, err := h.relationRuleService.GetRelationRules(ctx, &req)
+	if err != nil {
+		h.handleServiceError(ctx, err)
+		return
+	}
+	v1.HandleSuccess(ctx, data)
+}
+
+// RelationRuleCreate godoc
+// @Summary 创建关系规则
+// @Schemes
+// @Description 源/目标类型可以是对象类型(resource/application等)、细分类型(server/cdn_node等)或*
+// @Tags CMDB关系规则模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.RelationRuleCreateRequest true "参数"
+// @Success 200 {object} v1.Response
+// @Router /v1/cmdb/relation-rule [post]
+func (h *RelationRuleHandler) RelationRuleCreate(ctx *gin.Context) {
+	var req v1.RelationRuleCreateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	if err := h.relationRuleService.RelationRuleCreate(ctx, &req); err != nil {
+		h.handleServiceError(ctx, err)
+		return
+	}
+	v1.HandleSuccess(ctx, nil)
+}
+
+// RelationRuleUpdate godoc
+// @Summary 更新关系规则
+// @Schemes
+// @Description 更新关系规则
+// @Tags CMDB关系规则模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.RelationRuleUpdateRequest true "参数"
+// @Success 200 {object} v1.Response
+// @Router /v1/cmdb/relation-rule [put]
+func (h *RelationRuleHandler) RelationRuleUpdate(ctx *gin.Context) {
+	var req v1.RelationRuleUpdateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	if err := h.relationRuleService.RelationRuleUpdate(ctx, &req); err != nil {
+		h.handleServiceError(ctx, err)
+		return
+	}
+	v1.HandleSuccess(ctx, nil)
+}
+
+// RelationRuleDelete godoc
+// @Summary 删除关系规则
+// @Schemes
+// @Description 删除关系规则
+// @Tags CMDB关系规则模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param ruleId query string true "规则唯一标识"
+// @Success 200 {object} v1.Response
+// @Router /v1/cmdb/relation-rule [delete]
+func (h *RelationRuleHandler) RelationRuleDelete(ctx *gin.Context) {
+	var req v1.RelationRuleDeleteRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	if err := h.relationRuleService.RelationRuleDelete(ctx, req.RuleID); err != nil {
+		h.handleServiceError(ctx, err)
+		return
+	}
+	v1.HandleSuccess(ctx, nil)
+}
+
+// GetRelationCompliance godoc
+// @Summary 关系规则合规报告
+// @Schemes
+// @Description 按启用的关系规则检查全部对象，列出违反最小连接数、必需关系或最大连接数的对象
+// @Tags CMDB关系规则模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} v1.GetRelationComplianceResponse
+// @Router /v1/cmdb/relation-rules/compliance [get]
+func (h *RelationRuleHandler) GetRelationCompliance(ctx *gin.Context) {
+	data, err := h.relationRuleService.GetRelationCompliance(ctx)
+	if err != nil {
+		h.handleServiceError(ctx, err)
+		return
+	}
+	v1.HandleSuccess(ctx, data)
+}
@@ -0,0 +1,157 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	v1 "nunu-layout-admin/api/v1"
+	"nunu-layout-admin/internal/service"
+)
+
+type ResourceHandler struct {
+	*Handler
+	resourceService service.ResourceService
+}
+
+func NewResourceHandler(
+	handler *Handler,
+	resourceService service.ResourceService,
+) *ResourceHandler {
+	return &ResourceHandler{
+		Handler:         handler,
+		resourceService: resourceService,
+	}
+}
+
+// GetResources godoc
+// @Summary 获取资源列表
+// @Schemes
+// @Description 分页获取CMDB资源列表，支持按类型、状态、云提供商、区域、可用区、租户、业务、环境过滤
+// @Tags CMDB资源模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param page query int true "页码"
+// @Param pageSize query int true "每页数量"
+// @Param name query string false "资源名称"
+// @Param type query string false "资源类型"
+// @Param status query string false "资源状态"
+// @Param provider query string false "云提供商"
+// @Param region query string false "区域"
+// @Param zone query string false "可用区"
+// @Param tenantId query string false "租户ID"
+// @Param businessId query string false "业务ID"
+// @Param environment query string false "环境"
+// @Param as_of query string false "时间点(RFC3339)，返回该时刻的资源状态"
+// @Success 200 {object} v1.GetResourcesResponse
+// @Router /v1/cmdb/resources [get]
+func (h *ResourceHandler) GetResources(ctx *gin.Context) {
+	var req v1.GetResourcesRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	data, err := h.resourceService.GetResources(ctx, &req)
+	if err != nil {
+		h.handleServiceError(ctx, err)
+		return
+	}
+	v1.HandleSuccess(ctx, data)
+}
+
+// GetResource godoc
+// @Summary 获取资源详情
+// @Schemes
+// @Description 根据资源唯一标识获取资源详情及标签
+// @Tags CMDB资源模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param resourceId query string true "资源唯一标识"
+// @Param as_of query string false "时间点(RFC3339)，返回该时刻的资源状态"
+// @Success 200 {object} v1.GetResourceResponse
+// @Router /v1/cmdb/resource [get]
+func (h *ResourceHandler) GetResource(ctx *gin.Context) {
+	var req v1.GetResourceRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	data, err := h.resourceService.GetResource(ctx, &req)
+	if err != nil {
+		h.handleServiceError(ctx, err)
+		return
+	}
+	v1.HandleSuccess(ctx, data)
+}
+
+// ResourceCreate godoc
+// @Summary 创建资源
+// @Schemes
+// @Description 创建资源，同时写入资源标签
+// @Tags CMDB资源模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.ResourceCreateRequest true "参数"
+// @Success 200 {object} v1.Response
+// @Router /v1/cmdb/resource [post]
+func (h *ResourceHandler) ResourceCreate(ctx *gin.Context) {
+	var req v1.ResourceCreateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	if err := h.resourceService.ResourceCreate(ctx, &req); err != nil {
+		h.handleServiceError(ctx, err)
+		return
+	}
+	v1.HandleSuccess(ctx, nil)
+}
+
+// ResourceUpdate godoc
+// @Summary 更新资源
+// @Schemes
+// @Description 更新资源信息，标签以请求中的列表整体替换
+// @Tags CMDB资源模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.ResourceUpdateRequest true "参数"
+// @Success 200 {object} v1.Response
+// @Router /v1/cmdb/resource [put]
+func (h *ResourceHandler) ResourceUpdate(ctx *gin.Context) {
+	var req v1.ResourceUpdateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	if err := h.resourceService.ResourceUpdate(ctx, &req); err != nil {
+		h.handleServiceError(ctx, err)
+		return
+	}
+	v1.HandleSuccess(ctx, nil)
+}
+
+// ResourceDelete godoc
+// @Summary 删除资源
+// @Schemes
+// @Description 删除资源及其标签
+// @Tags CMDB资源模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param resourceId query string true "资源唯一标识"
+// @Success 200 {object} v1.Response
+// @Router /v1/cmdb/resource [delete]
+func (h *ResourceHandler) ResourceDelete(ctx *gin.Context) {
+	var req v1.ResourceDeleteRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	if err := h.resourceService.ResourceDelete(ctx, req.ResourceID); err != nil {
+		h.handleServiceError(ctx, err)
+		return
+	}
+	v1.HandleSuccess(ctx, nil)
+}
@@ -0,0 +1,197 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	v1 "nunu-layout-admin/api/v1"
+	"nunu-layout-admin/internal/service"
+)
+
+type ResourceTypeHandler struct {
+	*Handler
+	resourceTypeService service.ResourceTypeService
+}
+
+func NewResourceTypeHandler(
+	handler *Handler,
+	resourceTypeService service.ResourceTypeService,
+) *ResourceTypeHandler {
+	return &ResourceTypeHandler{
+		Handler:             handler,
+		resourceTypeService: resourceTypeService,
+	}
+}
+
+// GetResourceTypes godoc
+// @Summary 获取资源类型列表
+// @Schemes
+// @Description 按分类获取资源类型，默认只返回已启用的类型
+// @Tags CMDB资源类型模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param category query string false "资源分类"
+// @Param includeInactive query bool false "是否包含已停用类型"
+// @Success 200 {object} v1.GetResourceTypesResponse
+// @Router /v1/cmdb/resource-types [get]
+func (h *ResourceTypeHandler) GetResourceTypes(ctx *gin.Context) {
+	var req v1.GetResourceTypesRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	data, err := h.resourceTypeService.GetResourceTypes(ctx, &req)
+	if err != nil {
+		h.handleServiceError(ctx, err)
+		return
+	}
+	v1.HandleSuccess(ctx, data)
+}
+
+// ResourceTypeCreate godoc
+// @Summary 创建资源类型
+// @Schemes
+// @Description 注册新的资源类型，属性schema记为版本1
+// @Tags CMDB资源类型模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.ResourceTypeCreateRequest true "参数"
+// @Success 200 {object} v1.Response
+// @Router /v1/cmdb/resource-type [post]
+func (h *ResourceTypeHandler) ResourceTypeCreate(ctx *gin.Context) {
+	var req v1.ResourceTypeCreateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	if err := h.resourceTypeService.ResourceTypeCreate(ctx, &req); err != nil {
+		h.handleServiceError(ctx, err)
+		return
+	}
+	v1.HandleSuccess(ctx, nil)
+}
+
+// ResourceTypeUpdate godoc
+// @Summary 更新资源类型
+// @Schemes
+// @Description 更新资源类型，属性schema变化时生成新版本，并返回兼容性分析及不合规的存量资源
+// @Tags CMDB资源类型模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.ResourceTypeUpdateRequest true "参数"
+// @Success 200 {object} v1.ResourceTypeUpdateResponse
+// @Router /v1/cmdb/resource-type [put]
+func (h *ResourceTypeHandler) ResourceTypeUpdate(ctx *gin.Context) {
+	var req v1.ResourceTypeUpdateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	data, err := h.resourceTypeService.ResourceTypeUpdate(ctx, &req)
+	if err != nil {
+		h.handleServiceError(ctx, err)
+		return
+	}
+	v1.HandleSuccess(ctx, data)
+}
+
+// UpdateResourceTypeStatus godoc
+// @Summary 启用/停用资源类型
+// @Schemes
+// @Description 停用后不能再创建或更新该类型的资源
+// @Tags CMDB资源类型模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.ResourceTypeStatusRequest true "参数"
+// @Success 200 {object} v1.Response
+// @Router /v1/cmdb/resource-type/status [put]
+func (h *ResourceTypeHandler) UpdateResourceTypeStatus(ctx *gin.Context) {
+	var req v1.ResourceTypeStatusRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	if err := h.resourceTypeService.UpdateResourceTypeStatus(ctx, &req); err != nil {
+		h.handleServiceError(ctx, err)
+		return
+	}
+	v1.HandleSuccess(ctx, nil)
+}
+
+// GetResourceTypeSchemas godoc
+// @Summary 获取资源类型schema版本
+// @Schemes
+// @Description 获取资源类型的全部历史schema版本及每个版本的变更明细
+// @Tags CMDB资源类型模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param typeName query string true "资源类型名称"
+// @Success 200 {object} v1.GetResourceTypeSchemasResponse
+// @Router /v1/cmdb/resource-type/schemas [get]
+func (h *ResourceTypeHandler) GetResourceTypeSchemas(ctx *gin.Context) {
+	var req v1.GetResourceTypeSchemasRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	data, err := h.resourceTypeService.GetResourceTypeSchemas(ctx, req.TypeName)
+	if err != nil {
+		h.handleServiceError(ctx, err)
+		return
+	}
+	v1.HandleSuccess(ctx, data)
+}
+
+// GetResourceTypeCompliance godoc
+// @Summary 资源属性合规检查
+// @Schemes
+// @Description 按当前schema校验该类型下的全部资源，返回不合规的资源及字段
+// @Tags CMDB资源类型模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param typeName query string true "资源类型名称"
+// @Success 200 {object} v1.GetResourceTypeComplianceResponse
+// @Router /v1/cmdb/resource-type/compliance [get]
+func (h *ResourceTypeHandler) GetResourceTypeCompliance(ctx *gin.Context) {
+	var req v1.GetResourceTypeComplianceRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	data, err := h.resourceTypeService.GetResourceTypeCompliance(ctx, req.TypeName)
+	if err != nil {
+		h.handleServiceError(ctx, err)
+		return
+	}
+	v1.HandleSuccess(ctx, data)
+}
+
+// ResourceTypeMigrate godoc
+// @Summary 迁移资源属性
+// @Schemes
+// @Description 对该类型下的资源属性执行rename/default/drop操作，dryRun为true时只返回预览结果
+// @Tags CMDB资源类型模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.ResourceTypeMigrateRequest true "参数"
+// @Success 200 {object} v1.ResourceTypeMigrateResponse
+// @Router /v1/cmdb/resource-type/migrate [post]
+func (h *ResourceTypeHandler) ResourceTypeMigrate(ctx *gin.Context) {
+	var req v1.ResourceTypeMigrateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	data, err := h.resourceTypeService.ResourceTypeMigrate(ctx, &req)
+	if err != nil {
+		h.handleServiceError(ctx, err)
+		return
+	}
+	v1.HandleSuccess(ctx, data)
+}
@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	v1 "nunu-layout-admin/api/v1"
+	"nunu-layout-admin/internal/service"
+)
+
+type SensitiveHandler struct {
+	*Handler
+	sensitiveService service.SensitiveService
+}
+
+func NewSensitiveHandler(
+	handler *Handler,
+	sensitiveService service.SensitiveService,
+) *SensitiveHandler {
+	return &SensitiveHandler{
+		Handler:          handler,
+		sensitiveService: sensitiveService,
+	}
+}
+
+// Reveal godoc
+// @Summary 查看敏感字段明文
+// @Schemes
+// @Description 返回资源扩展属性或配置数据中敏感字段的明文。拥有该接口权限的用户也可以在资源、变更历史和快照对比接口中传reveal=true查看明文
+// @Tags CMDB敏感数据模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request query v1.SensitiveRevealRequest true "params"
+// @Success 200 {object} v1.SensitiveRevealResponse
+// @Router /v1/cmdb/sensitive/reveal [get]
+func (h *SensitiveHandler) Reveal(ctx *gin.Context) {
+	var req v1.SensitiveRevealRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	data, err := h.sensitiveService.Reveal(ctx, &req)
+	if err != nil {
+		h.handleServiceError(ctx, err)
+		return
+	}
+	v1.HandleSuccess(ctx, data)
+}
+
+// RotateKeys godoc
+// @Summary 轮换加密主密钥
+// @Schemes
+// @Description 用配置中的当前主密钥重新加密资源、配置和变更历史中已有密文的数据密钥，并加密尚未加密的敏感字段。执行完成后才能从配置中删除旧主密钥
+// @Tags CMDB敏感数据模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} v1.SensitiveRotateResponse
+// @Router /v1/cmdb/sensitive/rotate [post]
+func (h *SensitiveHandler) RotateKeys(ctx *gin.Context) {
+	data, err := h.sensitiveService.RotateKeys(ctx)
+	if err != nil {
+		h.handleServiceError(ctx, err)
+		return
+	}
+	v1.HandleSuccess(ctx, data)
+}
@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	v1 "nunu-layout-admin/api/v1"
+	"nunu-layout-admin/internal/service"
+)
+
+type SLAHandler struct {
+	*Handler
+	slaService service.SLAService
+}
+
+func NewSLAHandler(
+	handler *Handler,
+	slaService service.SLAService,
+) *SLAHandler {
+	return &SLAHandler{
+		Handler:    handler,
+		slaService: slaService,
+	}
+}
+
+// GetSLAReport godoc
+// @Summary 获取服务SLA报告
+// @Schemes
+// @Description 按日、周或月窗口返回各服务的可用率，标记低于SLA目标的服务并列出造成不可用的时段
+// @Tags CMDB健康汇总模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request query v1.SLAReportRequest true "params"
+// @Success 200 {object} v1.SLAReportResponse
+// @Router /v1/cmdb/sla/report [get]
+func (h *SLAHandler) GetSLAReport(ctx *gin.Context) {
+	var req v1.SLAReportRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	data, err := h.slaService.GetSLAReport(ctx, &req)
+	if err != nil {
+		h.handleServiceError(ctx, err)
+		return
+	}
+	v1.HandleSuccess(ctx, data)
+}
+
+// CalculateSLA godoc
+// @Summary 立即计算服务SLA
+// @Schemes
+// @Description 按服务健康状态的变化记录计算指定窗口的可用率并保存，返回计算结果
+// @Tags CMDB健康汇总模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.SLACalculateRequest true "params"
+// @Success 200 {object} v1.SLAReportResponse
+// @Router /v1/cmdb/sla/calculate [post]
+func (h *SLAHandler) CalculateSLA(ctx *gin.Context) {
+	var req v1.SLACalculateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	data, err := h.slaService.CalculateSLA(ctx, &req)
+	if err != nil {
+		h.handleServiceError(ctx, err)
+		return
+	}
+	v1.HandleSuccess(ctx, data)
+}
@@ -0,0 +1,148 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	v1 "nunu-layout-admin/api/v1"
+	"nunu-layout-admin/internal/service"
+)
+
+type SnapshotHandler struct {
+	*Handler
+	snapshotService service.SnapshotService
+}
+
+func NewSnapshotHandler(
+	handler *Handler,
+	snapshotService service.SnapshotService,
+) *SnapshotHandler {
+	return &SnapshotHandler{
+		Handler:         handler,
+		snapshotService: snapshotService,
+	}
+}
+
+// CreateSnapshot godoc
+// @Summary 创建快照
+// @Schemes
+// @Description 把资源、服务、业务和关系导出为压缩文件，可按租户/业务限定范围；增量快照保存最近一次全量快照之后的变更历史
+// @Tags CMDB快照模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.SnapshotCreateRequest true "params"
+// @Success 200 {object} v1.SnapshotCreateResponse
+// @Router /v1/cmdb/snapshot [post]
+func (h *SnapshotHandler) CreateSnapshot(ctx *gin.Context) {
+	var req v1.SnapshotCreateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	data, err := h.snapshotService.CreateSnapshot(ctx, &req)
+	if err != nil {
+		h.handleServiceError(ctx, err)
+		return
+	}
+	v1.HandleSuccess(ctx, data)
+}
+
+// GetSnapshots godoc
+// @Summary 快照列表
+// @Schemes
+// @Description 分页查询快照记录
+// @Tags CMDB快照模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request query v1.GetSnapshotsRequest true "params"
+// @Success 200 {object} v1.GetSnapshotsResponse
+// @Router /v1/cmdb/snapshots [get]
+func (h *SnapshotHandler) GetSnapshots(ctx *gin.Context) {
+	var req v1.GetSnapshotsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	data, err := h.snapshotService.GetSnapshots(ctx, &req)
+	if err != nil {
+		h.handleServiceError(ctx, err)
+		return
+	}
+	v1.HandleSuccess(ctx, data)
+}
+
+// VerifySnapshot godoc
+// @Summary 校验快照文件
+// @Schemes
+// @Description 重新计算快照文件的SHA-256并与记录的校验和比对
+// @Tags CMDB快照模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request query v1.SnapshotVerifyRequest true "params"
+// @Success 200 {object} v1.SnapshotVerifyResponse
+// @Router /v1/cmdb/snapshot/verify [get]
+func (h *SnapshotHandler) VerifySnapshot(ctx *gin.Context) {
+	var req v1.SnapshotVerifyRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	data, err := h.snapshotService.VerifySnapshot(ctx, &req)
+	if err != nil {
+		h.handleServiceError(ctx, err)
+		return
+	}
+	v1.HandleSuccess(ctx, data)
+}
+
+// DiffSnapshots godoc
+// @Summary 快照对比
+// @Schemes
+// @Description 对比两个快照(增量快照会在基准全量快照上还原)，未指定toSnapshotId时与当前数据对比，分别列出新增、删除、变更的对象和关系
+// @Tags CMDB快照模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request query v1.SnapshotDiffRequest true "params"
+// @Success 200 {object} v1.SnapshotDiffResponse
+// @Router /v1/cmdb/snapshot/diff [get]
+func (h *SnapshotHandler) DiffSnapshots(ctx *gin.Context) {
+	var req v1.SnapshotDiffRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	data, err := h.snapshotService.DiffSnapshots(ctx, &req)
+	if err != nil {
+		h.handleServiceError(ctx, err)
+		return
+	}
+	v1.HandleSuccess(ctx, data)
+}
+
+// RestoreSnapshot godoc
+// @Summary 从快照恢复
+// @Schemes
+// @Description 在一个事务中把快照重放到数据库，可按租户/业务/对象类型选择性恢复；范围内当前有而快照中没有的对象会被删除，变更历史来源记为import
+// @Tags CMDB快照模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.SnapshotRestoreRequest true "params"
+// @Success 200 {object} v1.SnapshotRestoreResponse
+// @Router /v1/cmdb/snapshot/restore [post]
+func (h *SnapshotHandler) RestoreSnapshot(ctx *gin.Context) {
+	var req v1.SnapshotRestoreRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	data, err := h.snapshotService.RestoreSnapshot(ctx, &req)
+	if err != nil {
+		h.handleServiceError(ctx, err)
+		return
+	}
+	v1.HandleSuccess(ctx, data)
+}
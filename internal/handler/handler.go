@@ -1,7 +1,11 @@
 package handler
 
 import (
+	"errors"
 	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"net/http"
+	v1 "nunu-layout-admin/api/v1"
 	"nunu-layout-admin/pkg/jwt"
 	"nunu-layout-admin/pkg/log"
 )
@@ -24,3 +28,25 @@ func GetUserIdFromCtx(ctx *gin.Context) uint {
 	}
 	return v.(*jwt.MyCustomClaims).UserId
 }
+
+// handleServiceError 将service层返回的错误转换为对应的HTTP响应
+func (h *Handler) handleServiceError(ctx *gin.Context, err error) {
+	var verr *v1.ValidationError
+	switch {
+	case errors.As(err, &verr):
+		v1.HandleError(ctx, http.StatusBadRequest, verr.Err, verr.Fields)
+	case errors.Is(err, v1.ErrNotFound):
+		v1.HandleError(ctx, http.StatusNotFound, v1.ErrNotFound, nil)
+	case errors.Is(err, v1.ErrForbidden):
+		v1.HandleError(ctx, http.StatusForbidden, v1.ErrForbidden, nil)
+	case errors.Is(err, v1.ErrInternalServerError) || !v1.IsKnownError(err):
+		h.logger.WithContext(ctx).Error("service error", zap.Error(err))
+		v1.HandleError(ctx, http.StatusInternalServerError, v1.ErrInternalServerError, nil)
+	default:
+		// 包装过的业务错误按其错误码返回，包装的原因只记录日志
+		if known, _ := v1.KnownError(err); known != err {
+			h.logger.WithContext(ctx).Warn("service error", zap.Error(err))
+		}
+		v1.HandleError(ctx, http.StatusBadRequest, err, nil)
+	}
+}
@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+	"net/http"
+	"nunu-layout-admin/api/v1"
+	"nunu-layout-admin/pkg/log"
+	"os"
+	"strings"
+)
+
+// AgentTokenEnvPrefix Agent令牌的环境变量前缀，配置文件中令牌为空时从 AgentTokenEnvPrefix+大写的Agent ID 读取
+const AgentTokenEnvPrefix = "CMDB_AGENT_TOKEN_"
+
+// AgentAuth 校验Agent上报接口的令牌(Authorization: Bearer <token>)，令牌在 security.agent.tokens 中按Agent ID配置。
+// 这些接口不使用管理员JWT和casbin权限，没有配置令牌时拒绝所有请求
+func AgentAuth(conf *viper.Viper, logger *log.Logger) gin.HandlerFunc {
+	tokens := make(map[string]string)
+	for id, token := range conf.GetStringMapString("security.agent.tokens") {
+		if token == "" {
+			token = os.Getenv(AgentTokenEnvPrefix + strings.ToUpper(id))
+		}
+		if token != "" {
+			tokens[id] = token
+		}
+	}
+	if len(tokens) == 0 {
+		logger.Warn("agent token is not configured, agent endpoints will reject all requests")
+	}
+	return func(ctx *gin.Context) {
+		token := strings.TrimSpace(strings.TrimPrefix(ctx.Request.Header.Get("Authorization"), "Bearer "))
+		agent := ""
+		if token != "" {
+			for id, value := range tokens {
+				if subtle.ConstantTimeCompare([]byte(token), []byte(value)) == 1 {
+					agent = id
+					break
+				}
+			}
+		}
+		if agent == "" {
+			logger.WithContext(ctx).Warn("invalid agent token", zap.String("url", ctx.Request.URL.String()),
+				zap.String("ip", ctx.ClientIP()))
+			v1.HandleError(ctx, http.StatusUnauthorized, v1.ErrUnauthorized, nil)
+			ctx.Abort()
+			return
+		}
+		ctx.Set("agent", agent)
+		ctx.Next()
+	}
+}
@@ -2,16 +2,28 @@ package middleware
 
 import (
 	"bytes"
+	"encoding/json"
 	"github.com/duke-git/lancet/v2/cryptor"
 	"github.com/duke-git/lancet/v2/random"
 	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
 	"go.uber.org/zap"
 	"io"
+	"net/http"
+	"net/url"
+	"nunu-layout-admin/pkg/envelope"
 	"nunu-layout-admin/pkg/log"
+	"strings"
 	"time"
 )
 
-func RequestLogMiddleware(logger *log.Logger) gin.HandlerFunc {
+const redactedValue = "******"
+
+// defaultRedactFields 未配置log.redact_fields时日志中脱敏的字段
+var defaultRedactFields = []string{"password", "secret", "token", "accessToken", "ip_address"}
+
+func RequestLogMiddleware(logger *log.Logger, conf *viper.Viper) gin.HandlerFunc {
+	r := newLogRedactor(conf)
 	return func(ctx *gin.Context) {
 		// The configuration is initialized once per request
 		uuid, err := random.UUIdV4()
@@ -21,25 +33,26 @@ func RequestLogMiddleware(logger *log.Logger) gin.HandlerFunc {
 		trace := cryptor.Md5String(uuid)
 		logger.WithValue(ctx, zap.String("trace", trace))
 		logger.WithValue(ctx, zap.String("request_method", ctx.Request.Method))
-		logger.WithValue(ctx, zap.Any("request_headers", ctx.Request.Header))
-		logger.WithValue(ctx, zap.String("request_url", ctx.Request.URL.String()))
+		logger.WithValue(ctx, zap.Any("request_headers", r.headers(ctx.Request.Header)))
+		logger.WithValue(ctx, zap.String("request_url", r.url(ctx.Request.URL)))
 		if ctx.Request.Body != nil {
 			bodyBytes, _ := ctx.GetRawData()
 			ctx.Request.Body = io.NopCloser(bytes.NewBuffer(bodyBytes)) // 关键点
-			logger.WithValue(ctx, zap.String("request_params", string(bodyBytes)))
+			logger.WithValue(ctx, zap.String("request_params", r.body(bodyBytes)))
 		}
 		logger.WithContext(ctx).Info("Request")
 		ctx.Next()
 	}
 }
-func ResponseLogMiddleware(logger *log.Logger) gin.HandlerFunc {
+func ResponseLogMiddleware(logger *log.Logger, conf *viper.Viper) gin.HandlerFunc {
+	r := newLogRedactor(conf)
 	return func(ctx *gin.Context) {
 		blw := &bodyLogWriter{body: bytes.NewBufferString(""), ResponseWriter: ctx.Writer}
 		ctx.Writer = blw
 		startTime := time.Now()
 		ctx.Next()
 		duration := time.Since(startTime).String()
-		logger.WithContext(ctx).Info("Response", zap.Any("response_body", blw.body.String()), zap.Any("time", duration))
+		logger.WithContext(ctx).Info("Response", zap.Any("response_body", r.body(blw.body.Bytes())), zap.Any("time", duration))
 	}
 }
 
@@ -52,3 +65,101 @@ func (w bodyLogWriter) Write(b []byte) (int, error) {
 	w.body.Write(b)
 	return w.ResponseWriter.Write(b)
 }
+
+// logRedactor 脱敏请求和响应日志中的敏感字段和密文。字段名忽略大小写和下划线，
+// 因此ip_address同时匹配ipAddress，字段差异中的路径(attributes.ip_address)按最后一段匹配
+type logRedactor struct {
+	fields map[string]struct{}
+}
+
+func newLogRedactor(conf *viper.Viper) *logRedactor {
+	fields := conf.GetStringSlice("log.redact_fields")
+	if len(fields) == 0 {
+		fields = defaultRedactFields
+	}
+	r := &logRedactor{fields: make(map[string]struct{}, len(fields))}
+	for _, field := range fields {
+		r.fields[normalizeLogField(field)] = struct{}{}
+	}
+	return r
+}
+
+func normalizeLogField(field string) string {
+	if i := strings.LastIndex(field, "."); i >= 0 {
+		field = field[i+1:]
+	}
+	return strings.ToLower(strings.NewReplacer("_", "", "-", "").Replace(field))
+}
+
+func (r *logRedactor) sensitive(field string) bool {
+	_, ok := r.fields[normalizeLogField(field)]
+	return ok
+}
+
+func (r *logRedactor) headers(header http.Header) http.Header {
+	out := header.Clone()
+	for _, key := range []string{"Authorization", "Cookie", "Set-Cookie"} {
+		if out.Get(key) != "" {
+			out.Set(key, redactedValue)
+		}
+	}
+	return out
+}
+
+func (r *logRedactor) url(u *url.URL) string {
+	query := u.Query()
+	redacted := false
+	for key := range query {
+		if r.sensitive(key) {
+			query.Set(key, redactedValue)
+			redacted = true
+		}
+	}
+	if !redacted {
+		return u.String()
+	}
+	out := *u
+	out.RawQuery = query.Encode()
+	return out.String()
+}
+
+// body 脱敏JSON内容，非JSON内容原样返回
+func (r *logRedactor) body(data []byte) string {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var v interface{}
+	if err := decoder.Decode(&v); err != nil {
+		return string(data)
+	}
+	out, err := json.Marshal(r.redact(v, false))
+	if err != nil {
+		return string(data)
+	}
+	return string(out)
+}
+
+func (r *logRedactor) redact(v interface{}, sensitive bool) interface{} {
+	switch value := v.(type) {
+	case nil:
+		return nil
+	case map[string]interface{}:
+		for k, item := range value {
+			value[k] = r.redact(item, sensitive || r.sensitive(k))
+		}
+		return value
+	case []interface{}:
+		for i, item := range value {
+			value[i] = r.redact(item, sensitive)
+		}
+		return value
+	case string:
+		if sensitive || envelope.IsSealed(value) {
+			return redactedValue
+		}
+		return value
+	}
+	if sensitive {
+		return redactedValue
+	}
+	return v
+}
@@ -8,6 +8,8 @@ const (
 	MenuResourcePrefix = "menu:"
 	ApiResourcePrefix  = "api:"
 	PermSep            = ","
+	// SensitiveRevealApi 拥有该接口权限的用户可以查看敏感字段明文
+	SensitiveRevealApi = "/v1/cmdb/sensitive/reveal"
 )
 
 type AdminUser struct {
@@ -30,6 +30,53 @@ const (
 	AppStatusUpgrading   = "upgrading"   // 升级中
 )
 
+// 健康状态枚举，unhealthy用于应用实例，degraded和down用于服务和业务的汇总健康状态
+const (
+	HealthStatusHealthy   = "healthy"   // 健康
+	HealthStatusUnhealthy = "unhealthy" // 不健康
+	HealthStatusUnknown   = "unknown"   // 未知
+	HealthStatusDegraded  = "degraded"  // 降级
+	HealthStatusDown      = "down"      // 不可用
+)
+
+// 心跳超时处理方式
+const (
+	HeartbeatTimeoutFailed    = "failed"    // 标记故障(mark_failed)
+	HeartbeatTimeoutUnhealthy = "unhealthy" // 只标记为不健康
+)
+
+// 应用生命周期操作，状态只能通过这些操作按ApplicationTransitions流转
+const (
+	AppActionStart            = "start"             // 开始启动
+	AppActionMarkRunning      = "mark_running"      // 启动完成
+	AppActionStop             = "stop"              // 开始停止
+	AppActionMarkStopped      = "mark_stopped"      // 停止完成
+	AppActionMarkFailed       = "mark_failed"       // 标记故障
+	AppActionBeginUpgrade     = "begin_upgrade"     // 开始升级
+	AppActionFinishUpgrade    = "finish_upgrade"    // 升级完成
+	AppActionEnterMaintenance = "enter_maintenance" // 进入维护
+	AppActionLeaveMaintenance = "leave_maintenance" // 结束维护
+)
+
+// AppTransition 生命周期操作允许的起始状态和操作后的状态
+type AppTransition struct {
+	From []string
+	To   string
+}
+
+// ApplicationTransitions 应用状态机，不在From中的状态执行该操作视为非法流转
+var ApplicationTransitions = map[string]AppTransition{
+	AppActionStart:            {From: []string{AppStatusStopped, AppStatusFailed}, To: AppStatusStarting},
+	AppActionMarkRunning:      {From: []string{AppStatusStarting}, To: AppStatusRunning},
+	AppActionStop:             {From: []string{AppStatusRunning, AppStatusStarting, AppStatusMaintenance, AppStatusFailed}, To: AppStatusStopping},
+	AppActionMarkStopped:      {From: []string{AppStatusStopping}, To: AppStatusStopped},
+	AppActionMarkFailed:       {From: []string{AppStatusRunning, AppStatusStarting, AppStatusStopping, AppStatusUpgrading, AppStatusMaintenance}, To: AppStatusFailed},
+	AppActionBeginUpgrade:     {From: []string{AppStatusRunning, AppStatusMaintenance}, To: AppStatusUpgrading},
+	AppActionFinishUpgrade:    {From: []string{AppStatusUpgrading}, To: AppStatusRunning},
+	AppActionEnterMaintenance: {From: []string{AppStatusRunning, AppStatusFailed}, To: AppStatusMaintenance},
+	AppActionLeaveMaintenance: {From: []string{AppStatusMaintenance}, To: AppStatusRunning},
+}
+
 // 1. 应用定义表 (应用类型的元数据)
 type ApplicationType struct {
 	gorm.Model
@@ -55,8 +102,8 @@ type ApplicationType struct {
 	MonitoringConfig  JSONMap `json:"monitoring_config" gorm:"type:jsonb;comment:'监控配置'"`
 
 	// 部署信息
-	DeploymentMethods []string `json:"deployment_methods" gorm:"type:json;comment:'支持的部署方式(docker/binary/k8s等)'"`
-	SupportedOS       []string `json:"supported_os" gorm:"type:json;comment:'支持的操作系统'"`
+	DeploymentMethods []string `json:"deployment_methods" gorm:"type:json;serializer:json;comment:'支持的部署方式(docker/binary/k8s等)'"`
+	SupportedOS       []string `json:"supported_os" gorm:"type:json;serializer:json;comment:'支持的操作系统'"`
 
 	Description string `json:"description" gorm:"type:text;comment:'应用描述'"`
 	IsActive    bool   `json:"is_active" gorm:"default:true;comment:'是否启用'"`
@@ -211,8 +258,8 @@ type ConfigurationTemplate struct {
 	Variables    JSONMap `json:"variables" gorm:"type:jsonb;comment:'模板变量定义'"`
 
 	// 适用范围
-	BusinessTypes []string `json:"business_types" gorm:"type:json;comment:'适用的业务类型'"`
-	Environments  []string `json:"environments" gorm:"type:json;comment:'适用的环境'"`
+	BusinessTypes []string `json:"business_types" gorm:"type:json;serializer:json;comment:'适用的业务类型'"`
+	Environments  []string `json:"environments" gorm:"type:json;serializer:json;comment:'适用的环境'"`
 
 	// 模板属性
 	Version   string `json:"version" gorm:"type:varchar(50);comment:'模板版本'"`
@@ -323,3 +370,21 @@ type ApplicationGroupMember struct {
 func (m *ApplicationGroupMember) TableName() string {
 	return "cmdb_application_group_members"
 }
+
+// 10. 应用健康检查探测结果表
+type ApplicationProbeResult struct {
+	gorm.Model
+	ApplicationID uint      `json:"application_id" gorm:"index:idx_probe_result_app;not null;comment:'应用ID'"`
+	AppID         string    `json:"app_id" gorm:"type:varchar(100);not null;comment:'应用实例唯一标识'"`
+	Method        string    `json:"method" gorm:"type:varchar(50);not null;comment:'探测方式'"`
+	Target        string    `json:"target" gorm:"type:varchar(300);comment:'探测目标(host:port)'"`
+	Success       bool      `json:"success" gorm:"not null;comment:'是否成功'"`
+	LatencyMs     int64     `json:"latency_ms" gorm:"comment:'耗时(毫秒)'"`
+	Message       string    `json:"message" gorm:"type:varchar(500);comment:'结果说明或错误信息'"`
+	HealthStatus  string    `json:"health_status" gorm:"type:varchar(50);comment:'本次探测后的健康状态'"`
+	CheckedAt     time.Time `json:"checked_at" gorm:"index:idx_probe_result_app;not null;comment:'探测时间'"`
+}
+
+func (m *ApplicationProbeResult) TableName() string {
+	return "cmdb_application_probe_results"
+}
@@ -0,0 +1,93 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 配置状态
+const (
+	ConfigStatusActive   = "active"   // 已有发布版本
+	ConfigStatusInactive = "inactive" // 停用
+	ConfigStatusPending  = "pending"  // 尚未发布任何版本
+)
+
+// 配置版本状态: draft -> pending -> published，发布时生效时间未到则为scheduled，到期后由定时任务发布
+const (
+	ConfigRevisionDraft      = "draft"      // 草稿，可修改
+	ConfigRevisionPending    = "pending"    // 待发布(已提交审核)
+	ConfigRevisionScheduled  = "scheduled"  // 已批准，等待生效时间
+	ConfigRevisionPublished  = "published"  // 当前生效的版本
+	ConfigRevisionSuperseded = "superseded" // 曾经生效，已被之后发布的版本替换
+)
+
+// ConfigurationRevision 配置的不可变版本。草稿提交后内容不再修改，发布时把内容写回配置实例
+type ConfigurationRevision struct {
+	gorm.Model
+	ConfigurationID uint   `json:"configuration_id" gorm:"not null;uniqueIndex:idx_config_revision;comment:'配置ID'"`
+	ConfigID        string `json:"config_id" gorm:"type:varchar(100);not null;index;comment:'配置唯一标识'"`
+	Revision        int    `json:"revision" gorm:"not null;uniqueIndex:idx_config_revision;comment:'版本号，从1递增'"`
+	Status          string `json:"status" gorm:"type:varchar(20);not null;index;comment:'版本状态(draft/pending/scheduled/published/superseded)'"`
+
+	// 版本内容
+	ConfigData   JSONMap `json:"config_data" gorm:"type:jsonb;not null;comment:'配置数据'"`
+	ConfigFormat string  `json:"config_format" gorm:"type:varchar(20);comment:'配置格式'"`
+	IsEncrypted  bool    `json:"is_encrypted" gorm:"comment:'配置数据是否整体加密'"`
+
+	// 版本来源
+	BaseRevision   int    `json:"base_revision" gorm:"comment:'基于的版本号，0表示没有'"`
+	SourceRevision int    `json:"source_revision" gorm:"comment:'回滚时复制的版本号'"`
+	Comment        string `json:"comment" gorm:"type:text;comment:'变更说明'"`
+
+	// 生效条件
+	EffectiveTime *time.Time `json:"effective_time" gorm:"index;comment:'生效时间，为空表示发布后立即生效'"`
+	ExpireTime    *time.Time `json:"expire_time" gorm:"comment:'过期时间'"`
+
+	// 流转记录
+	CreatedBy   string     `json:"created_by" gorm:"type:varchar(100);comment:'创建人'"`
+	SubmittedBy string     `json:"submitted_by" gorm:"type:varchar(100);comment:'提交人'"`
+	SubmittedAt *time.Time `json:"submitted_at" gorm:"comment:'提交时间'"`
+	ApprovedBy  string     `json:"approved_by" gorm:"type:varchar(100);comment:'批准发布人'"`
+	ApprovedAt  *time.Time `json:"approved_at" gorm:"comment:'批准发布时间'"`
+	PublishedAt *time.Time `json:"published_at" gorm:"comment:'实际生效时间'"`
+}
+
+func (m *ConfigurationRevision) TableName() string {
+	return "cmdb_configuration_revisions"
+}
+
+// 配置来源
+const (
+	ConfigSourceManual   = "manual"   // 手工创建
+	ConfigSourceTemplate = "template" // 由模板渲染
+)
+
+// 重新渲染结果
+const (
+	ConfigRenderRendered  = "rendered"  // 已生成新的草稿版本
+	ConfigRenderUnchanged = "unchanged" // 渲染结果与当前内容一致
+	ConfigRenderDrifted   = "drifted"   // 发布的内容被手工修改过，未重新渲染
+	ConfigRenderPending   = "pending"   // 上次渲染的版本等待发布，未重新渲染
+	ConfigRenderFailed    = "failed"    // 变量校验失败或模板不再适用
+)
+
+// ConfigurationRender 配置最近一次由模板渲染的记录，用于模板升级后重新渲染和检测手工修改
+type ConfigurationRender struct {
+	gorm.Model
+	ConfigurationID uint   `json:"configuration_id" gorm:"uniqueIndex;not null;comment:'配置ID'"`
+	ConfigID        string `json:"config_id" gorm:"type:varchar(100);not null;comment:'配置唯一标识'"`
+	TemplateID      string `json:"template_id" gorm:"type:varchar(100);not null;index;comment:'模板唯一标识'"`
+	TemplateVersion string `json:"template_version" gorm:"type:varchar(50);comment:'渲染时的模板版本'"`
+
+	// 渲染输入和输出
+	Variables JSONMap `json:"variables" gorm:"type:jsonb;comment:'调用方提供的变量值，敏感变量加密保存'"`
+	Revision  int     `json:"revision" gorm:"comment:'渲染生成的配置版本号'"`
+
+	RenderedBy string     `json:"rendered_by" gorm:"type:varchar(100);comment:'渲染人'"`
+	RenderedAt *time.Time `json:"rendered_at" gorm:"comment:'渲染时间'"`
+}
+
+func (m *ConfigurationRender) TableName() string {
+	return "cmdb_configuration_renders"
+}
@@ -139,9 +139,9 @@ func (m *ResourceTag) TableName() string {
 // 3. 资源关系表 (图结构建模)
 type ResourceRelation struct {
 	gorm.Model
-	SourceID     uint   `json:"source_id" gorm:"index;not null;comment:'源资源ID'"`
-	TargetID     uint   `json:"target_id" gorm:"index;not null;comment:'目标资源ID'"`
-	RelationType string `json:"relation_type" gorm:"type:varchar(50);not null;index;comment:'关系类型'"`
+	SourceID     uint   `json:"source_id" gorm:"index;uniqueIndex:idx_resource_relation_edge,priority:1,where:deleted_at IS NULL;not null;comment:'源资源ID'"`
+	TargetID     uint   `json:"target_id" gorm:"index;uniqueIndex:idx_resource_relation_edge,priority:2;not null;comment:'目标资源ID'"`
+	RelationType string `json:"relation_type" gorm:"type:varchar(50);not null;index;uniqueIndex:idx_resource_relation_edge,priority:3;comment:'关系类型'"`
 	Direction    string `json:"direction" gorm:"type:varchar(20);not null;default:'forward';comment:'关系方向(forward/backward/bidirectional)'"`
 	Weight       int    `json:"weight" gorm:"type:int;default:1;comment:'关系权重'"`
 
@@ -186,9 +186,10 @@ type ResourceType struct {
 
 	// 属性定义 (JSON Schema)
 	AttributeSchema JSONMap `json:"attribute_schema" gorm:"type:jsonb;comment:'属性schema定义'"`
+	SchemaVersion   int     `json:"schema_version" gorm:"type:int;not null;default:1;comment:'属性schema版本号'"`
 
 	// 允许的关系类型
-	AllowedRelations []string `json:"allowed_relations" gorm:"type:json;comment:'允许的关系类型列表'"`
+	AllowedRelations []string `json:"allowed_relations" gorm:"type:json;serializer:json;comment:'允许的关系类型列表'"`
 
 	Description string `json:"description" gorm:"type:text;comment:'类型描述'"`
 	IsActive    bool   `json:"is_active" gorm:"default:true;comment:'是否启用'"`
@@ -198,6 +199,27 @@ func (m *ResourceType) TableName() string {
 	return "cmdb_resource_types"
 }
 
+// 资源类型属性schema版本表 (保留每个版本的schema，用于不兼容变更的追溯)
+type ResourceTypeSchema struct {
+	gorm.Model
+	TypeName        string               `json:"type_name" gorm:"type:varchar(50);not null;uniqueIndex:idx_resource_type_schema_version;comment:'资源类型名称'"`
+	Version         int                  `json:"version" gorm:"type:int;not null;uniqueIndex:idx_resource_type_schema_version;comment:'schema版本号'"`
+	AttributeSchema JSONMap              `json:"attribute_schema" gorm:"type:jsonb;comment:'属性schema定义'"`
+	Compatible      bool                 `json:"compatible" gorm:"comment:'是否与上一版本兼容'"`
+	Changes         []SchemaChangeRecord `json:"changes" gorm:"type:json;serializer:json;comment:'相对上一版本的变更明细'"`
+}
+
+// schema变更明细
+type SchemaChangeRecord struct {
+	Field      string `json:"field"`
+	Message    string `json:"message"`
+	Compatible bool   `json:"compatible"`
+}
+
+func (m *ResourceTypeSchema) TableName() string {
+	return "cmdb_resource_type_schemas"
+}
+
 // 5. 服务层定义
 type Service struct {
 	gorm.Model
@@ -246,6 +268,48 @@ func (m *ServiceResource) TableName() string {
 	return "cmdb_service_resources"
 }
 
+// ServiceHealthRule 服务健康状态的汇总规则，服务没有规则时使用默认规则(没有健康成员为down，有成员不健康为degraded)。
+// 各列的0值都有意义，不设置数据库默认值
+type ServiceHealthRule struct {
+	gorm.Model
+	ServiceID uint `json:"service_id" gorm:"uniqueIndex;not null;comment:'服务ID'"`
+	Enabled   bool `json:"enabled" gorm:"comment:'是否自动汇总，关闭时保留手工设置的健康状态'"`
+
+	// 成员(服务资源上部署的应用实例和服务的应用组成员)
+	DownBelowMembers  int      `json:"down_below_members" gorm:"type:int;comment:'健康成员少于该数时为down'"`
+	MinHealthyMembers int      `json:"min_healthy_members" gorm:"type:int;comment:'健康成员少于该数时为degraded'"`
+	MinHealthyRatio   float64  `json:"min_healthy_ratio" gorm:"type:decimal(5,4);comment:'健康成员占比低于该值时为degraded'"`
+	CriticalRoles     []string `json:"critical_roles" gorm:"type:json;serializer:json;comment:'关键角色，该角色的成员不健康时为down'"`
+
+	// 依赖的服务
+	CriticalDependencies []string `json:"critical_dependencies" gorm:"type:json;serializer:json;comment:'关键依赖服务ID，任一为down时为down'"`
+	DegradeOnDependency  bool     `json:"degrade_on_dependency" gorm:"comment:'关键依赖服务degraded时是否降级'"`
+
+	Description string `json:"description" gorm:"type:text;comment:'规则描述'"`
+
+	Service Service `json:"service" gorm:"foreignKey:ServiceID"`
+}
+
+func (m *ServiceHealthRule) TableName() string {
+	return "cmdb_service_health_rules"
+}
+
+// ServiceHealthTransition 服务健康状态的变化记录，用于计算SLA。
+// 与变更历史不同，不受审计配置的影响，也不随过期历史一起清理
+type ServiceHealthTransition struct {
+	gorm.Model
+	ServiceID   uint      `json:"service_id" gorm:"index;not null;comment:'服务ID'"`
+	ServiceUUID string    `json:"service_uuid" gorm:"type:varchar(100);index;not null;comment:'服务唯一标识'"`
+	FromStatus  string    `json:"from_status" gorm:"type:varchar(50);comment:'变化前的健康状态'"`
+	ToStatus    string    `json:"to_status" gorm:"type:varchar(50);comment:'变化后的健康状态，服务删除时为空'"`
+	ChangeTime  time.Time `json:"change_time" gorm:"not null;index;comment:'变化时间'"`
+	Reason      string    `json:"reason" gorm:"type:text;comment:'变化原因'"`
+}
+
+func (m *ServiceHealthTransition) TableName() string {
+	return "cmdb_service_health_transitions"
+}
+
 // 服务标签表
 type ServiceTag struct {
 	gorm.Model
@@ -278,6 +342,9 @@ type Business struct {
 	CostCenter string  `json:"cost_center" gorm:"type:varchar(100);comment:'成本中心'"`
 	Budget     float64 `json:"budget" gorm:"type:decimal(15,2);comment:'预算'"`
 
+	// 由服务健康状态汇总
+	HealthStatus string `json:"health_status" gorm:"type:varchar(50);comment:'健康状态'"`
+
 	Description string `json:"description" gorm:"type:text;comment:'业务描述'"`
 
 	// 关联
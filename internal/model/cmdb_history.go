@@ -22,6 +22,22 @@ const (
 	ChangeSourceScheduled = "scheduled" // 定时任务
 )
 
+// 变更历史对象类型，资源/服务/业务沿用ObjectType常量
+const (
+	HistoryObjectResourceRelation  = "resource_relation"  // 资源关系
+	HistoryObjectUniversalRelation = "universal_relation" // 通用关系
+)
+
+// 快照类型与状态
+const (
+	SnapshotTypeFull        = "full"        // 全量快照
+	SnapshotTypeIncremental = "incremental" // 增量快照(基于最近一次全量快照之后的变更历史)
+
+	SnapshotStatusCreating  = "creating"
+	SnapshotStatusCompleted = "completed"
+	SnapshotStatusFailed    = "failed"
+)
+
 // 1. 资源变更历史记录表
 type ResourceHistory struct {
 	gorm.Model
@@ -181,6 +197,7 @@ type ResourceSnapshot struct {
 	SnapshotID   string    `json:"snapshot_id" gorm:"type:varchar(100);uniqueIndex;not null;comment:'快照唯一标识'"`
 	SnapshotTime time.Time `json:"snapshot_time" gorm:"not null;index;comment:'快照时间'"`
 	SnapshotType string    `json:"snapshot_type" gorm:"type:varchar(50);not null;index;comment:'快照类型(full/incremental)'"`
+	BaseSnapshotID string `json:"base_snapshot_id" gorm:"type:varchar(100);index;comment:'增量快照所基于的全量快照ID'"`
 	
 	// 快照范围
 	TenantID     string `json:"tenant_id" gorm:"type:varchar(100);index;comment:'租户ID(为空表示全局快照)'"`
@@ -283,3 +300,75 @@ type SyncLog struct {
 func (m *SyncLog) TableName() string {
 	return "cmdb_sync_logs"
 }
+
+// 8. 通用关系变更历史记录表 (RelationHistory仅关联资源关系)
+type UniversalRelationHistory struct {
+	gorm.Model
+	// 关联信息
+	RelationID   string `json:"relation_id" gorm:"type:varchar(100);index;not null;comment:'通用关系唯一标识'"`
+	SourceType   string `json:"source_type" gorm:"type:varchar(50);not null;comment:'源对象类型'"`
+	SourceID     string `json:"source_id" gorm:"type:varchar(100);not null;index;comment:'源对象ID'"`
+	TargetType   string `json:"target_type" gorm:"type:varchar(50);not null;comment:'目标对象类型'"`
+	TargetID     string `json:"target_id" gorm:"type:varchar(100);not null;index;comment:'目标对象ID'"`
+	RelationType string `json:"relation_type" gorm:"type:varchar(50);not null;index;comment:'关系类型'"`
+
+	// 变更信息
+	ChangeType   string    `json:"change_type" gorm:"type:varchar(20);not null;index;comment:'变更类型'"`
+	ChangeSource string    `json:"change_source" gorm:"type:varchar(50);not null;index;comment:'变更来源'"`
+	ChangeTime   time.Time `json:"change_time" gorm:"not null;index;comment:'变更时间'"`
+
+	// 操作人信息
+	OperatorID   string `json:"operator_id" gorm:"type:varchar(100);index;comment:'操作人ID'"`
+	OperatorName string `json:"operator_name" gorm:"type:varchar(100);comment:'操作人姓名'"`
+	OperatorIP   string `json:"operator_ip" gorm:"type:varchar(50);comment:'操作人IP'"`
+
+	// 变更内容
+	BeforeData    JSONMap `json:"before_data" gorm:"type:jsonb;comment:'变更前数据快照'"`
+	AfterData     JSONMap `json:"after_data" gorm:"type:jsonb;comment:'变更后数据快照'"`
+	ChangedFields JSONMap `json:"changed_fields" gorm:"type:jsonb;comment:'变更字段详情'"`
+
+	// 附加信息
+	ChangeReason string `json:"change_reason" gorm:"type:text;comment:'变更原因'"`
+	Comment      string `json:"comment" gorm:"type:text;comment:'变更说明'"`
+
+	// 版本信息
+	Version int64 `json:"version" gorm:"not null;index;comment:'版本号'"`
+}
+
+func (m *UniversalRelationHistory) TableName() string {
+	return "cmdb_universal_relation_history"
+}
+
+// 9. 应用实例变更历史记录表
+type ApplicationHistory struct {
+	gorm.Model
+	// 关联信息
+	ApplicationID   uint   `json:"application_id" gorm:"index;not null;comment:'应用实例ID'"`
+	ApplicationUUID string `json:"application_uuid" gorm:"type:varchar(100);index;not null;comment:'应用实例唯一标识'"`
+
+	// 变更信息
+	ChangeType   string    `json:"change_type" gorm:"type:varchar(20);not null;index;comment:'变更类型'"`
+	ChangeSource string    `json:"change_source" gorm:"type:varchar(50);not null;index;comment:'变更来源'"`
+	ChangeTime   time.Time `json:"change_time" gorm:"not null;index;comment:'变更时间'"`
+
+	// 操作人信息
+	OperatorID   string `json:"operator_id" gorm:"type:varchar(100);index;comment:'操作人ID'"`
+	OperatorName string `json:"operator_name" gorm:"type:varchar(100);comment:'操作人姓名'"`
+	OperatorIP   string `json:"operator_ip" gorm:"type:varchar(50);comment:'操作人IP'"`
+
+	// 变更内容
+	BeforeData    JSONMap `json:"before_data" gorm:"type:jsonb;comment:'变更前数据快照'"`
+	AfterData     JSONMap `json:"after_data" gorm:"type:jsonb;comment:'变更后数据快照'"`
+	ChangedFields JSONMap `json:"changed_fields" gorm:"type:jsonb;comment:'变更字段详情'"`
+
+	// 附加信息
+	ChangeReason string `json:"change_reason" gorm:"type:text;comment:'变更原因'"`
+	Comment      string `json:"comment" gorm:"type:text;comment:'变更说明'"`
+
+	// 版本信息
+	Version int64 `json:"version" gorm:"not null;index;comment:'版本号'"`
+}
+
+func (m *ApplicationHistory) TableName() string {
+	return "cmdb_application_history"
+}
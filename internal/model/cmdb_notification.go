@@ -0,0 +1,44 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	NotificationChannelEmail   = "email"   // 邮件
+	NotificationChannelWebhook = "webhook" // Webhook
+
+	NotificationStatusPending = "pending" // 待发送(含等待重试)
+	NotificationStatusSent    = "sent"    // 已发送
+	NotificationStatusFailed  = "failed"  // 重试次数用尽
+)
+
+// NotificationDelivery 变更通知的待发送记录及投递日志，与变更历史在同一事务中写入
+type NotificationDelivery struct {
+	gorm.Model
+	// 触发通知的变更
+	ObjectType     string    `json:"object_type" gorm:"type:varchar(50);not null;index:idx_notification_object;comment:'对象类型'"`
+	ObjectID       string    `json:"object_id" gorm:"type:varchar(100);not null;index:idx_notification_object;comment:'对象标识'"`
+	ChangeType     string    `json:"change_type" gorm:"type:varchar(20);not null;comment:'变更类型'"`
+	ChangeTime     time.Time `json:"change_time" gorm:"not null;comment:'变更时间'"`
+	HistoryVersion int64     `json:"history_version" gorm:"comment:'对应的历史版本号'"`
+	AuditConfigID  uint      `json:"audit_config_id" gorm:"index;comment:'匹配的审计配置ID'"`
+
+	// 投递信息
+	Channel string  `json:"channel" gorm:"type:varchar(20);not null;index;comment:'通知渠道(email/webhook)'"`
+	Target  string  `json:"target" gorm:"type:text;not null;comment:'收件人(逗号分隔)或Webhook地址'"`
+	Payload JSONMap `json:"payload" gorm:"type:jsonb;comment:'通知内容'"`
+
+	// 投递状态
+	Status      string     `json:"status" gorm:"type:varchar(20);not null;index:idx_notification_due;comment:'状态(pending/sent/failed)'"`
+	Attempts    int        `json:"attempts" gorm:"not null;default:0;comment:'已尝试次数'"`
+	NextRetryAt *time.Time `json:"next_retry_at" gorm:"index:idx_notification_due;comment:'下次尝试时间'"`
+	LastError   string     `json:"last_error" gorm:"type:text;comment:'最近一次失败原因'"`
+	SentAt      *time.Time `json:"sent_at" gorm:"comment:'发送成功时间'"`
+}
+
+func (m *NotificationDelivery) TableName() string {
+	return "cmdb_notification_deliveries"
+}
@@ -59,17 +59,17 @@ type UniversalRelation struct {
 	RelationID   string `json:"relation_id" gorm:"type:varchar(100);uniqueIndex;not null;comment:'关系唯一标识'"`
 	
 	// 源对象
-	SourceType   string `json:"source_type" gorm:"type:varchar(50);not null;index;comment:'源对象类型'"`
-	SourceID     string `json:"source_id" gorm:"type:varchar(100);not null;index;comment:'源对象ID'"`
+	SourceType   string `json:"source_type" gorm:"type:varchar(50);not null;index;uniqueIndex:idx_universal_relation_edge,priority:1,where:deleted_at IS NULL;comment:'源对象类型'"`
+	SourceID     string `json:"source_id" gorm:"type:varchar(100);not null;index;uniqueIndex:idx_universal_relation_edge,priority:2;comment:'源对象ID'"`
 	SourceName   string `json:"source_name" gorm:"type:varchar(200);comment:'源对象名称(冗余字段)'"`
 	
 	// 目标对象
-	TargetType   string `json:"target_type" gorm:"type:varchar(50);not null;index;comment:'目标对象类型'"`
-	TargetID     string `json:"target_id" gorm:"type:varchar(100);not null;index;comment:'目标对象ID'"`
+	TargetType   string `json:"target_type" gorm:"type:varchar(50);not null;index;uniqueIndex:idx_universal_relation_edge,priority:3;comment:'目标对象类型'"`
+	TargetID     string `json:"target_id" gorm:"type:varchar(100);not null;index;uniqueIndex:idx_universal_relation_edge,priority:4;comment:'目标对象ID'"`
 	TargetName   string `json:"target_name" gorm:"type:varchar(200);comment:'目标对象名称(冗余字段)'"`
 	
 	// 关系属性
-	RelationType string  `json:"relation_type" gorm:"type:varchar(50);not null;index;comment:'关系类型'"`
+	RelationType string  `json:"relation_type" gorm:"type:varchar(50);not null;index;uniqueIndex:idx_universal_relation_edge,priority:5;comment:'关系类型'"`
 	Direction    string  `json:"direction" gorm:"type:varchar(20);not null;default:'forward';comment:'关系方向'"`
 	Weight       float64 `json:"weight" gorm:"type:decimal(10,4);default:1.0;comment:'关系权重'"`
 	Priority     int     `json:"priority" gorm:"type:int;default:1;comment:'关系优先级'"`
@@ -270,7 +270,7 @@ type RelationRule struct {
 	TargetType   string `json:"target_type" gorm:"type:varchar(50);not null;index;comment:'目标对象类型'"`
 	
 	// 允许的关系
-	AllowedRelations []string `json:"allowed_relations" gorm:"type:json;not null;comment:'允许的关系类型列表'"`
+	AllowedRelations []string `json:"allowed_relations" gorm:"type:json;serializer:json;not null;comment:'允许的关系类型列表'"`
 	
 	// 约束条件
 	Constraints      JSONMap `json:"constraints" gorm:"type:jsonb;comment:'约束条件'"`
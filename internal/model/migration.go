@@ -0,0 +1,14 @@
+package model
+
+import "time"
+
+// SchemaMigration 已执行的数据库迁移版本
+type SchemaMigration struct {
+	Version   uint      `gorm:"primaryKey;autoIncrement:false;comment:'迁移版本号'"`
+	Name      string    `gorm:"type:varchar(255);not null;comment:'迁移名称'"`
+	AppliedAt time.Time `gorm:"not null;comment:'执行时间'"`
+}
+
+func (m *SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
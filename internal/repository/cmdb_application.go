@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"context"
+	v1 "nunu-layout-admin/api/v1"
+	"nunu-layout-admin/internal/model"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ApplicationRepository interface {
+	GetApplications(ctx context.Context, req *v1.GetApplicationsRequest) ([]model.Application, int64, error)
+	// GetApplication 按应用实例唯一标识获取，同时加载标签、应用类型和部署的资源
+	GetApplication(ctx context.Context, appID string) (model.Application, error)
+	GetApplicationTypeByName(ctx context.Context, typeName string) (model.ApplicationType, error)
+	GetResource(ctx context.Context, resourceID string) (model.Resource, error)
+	CountApplicationConfigurations(ctx context.Context, id uint) (int64, error)
+	ApplicationCreate(ctx context.Context, m *model.Application) error
+	ApplicationUpdate(ctx context.Context, m *model.Application) error
+	// ApplicationStatusUpdate 只在状态仍为from时更新状态及运行时字段，返回是否更新成功
+	ApplicationStatusUpdate(ctx context.Context, m *model.Application, from string) (bool, error)
+	// ApplicationRuntimeUpdate 只在状态仍为from时更新心跳上报的运行时字段、状态和健康状态，返回是否更新成功
+	ApplicationRuntimeUpdate(ctx context.Context, m *model.Application, from string) (bool, error)
+	// GetStaleApplications 获取运行中或启动中、上报过心跳但在deadline之后没有心跳也没有其他变更的实例
+	GetStaleApplications(ctx context.Context, deadline time.Time) ([]model.Application, error)
+	ApplicationDelete(ctx context.Context, id uint) error
+	ReplaceApplicationTags(ctx context.Context, id uint, tags []model.ApplicationTag) error
+}
+
+func NewApplicationRepository(
+	repository *Repository,
+) ApplicationRepository {
+	return &applicationRepository{
+		Repository: repository,
+	}
+}
+
+type applicationRepository struct {
+	*Repository
+}
+
+func (r *applicationRepository) GetApplications(ctx context.Context, req *v1.GetApplicationsRequest) ([]model.Application, int64, error) {
+	var list []model.Application
+	var total int64
+	scope := r.DB(ctx).Model(&model.Application{})
+	if req.Name != "" {
+		scope = scope.Where("name LIKE ?", "%"+req.Name+"%")
+	}
+	if req.TypeName != "" {
+		scope = scope.Where("type_id IN (?)", r.DB(ctx).Model(&model.ApplicationType{}).Select("id").Where("type_name = ?", req.TypeName))
+	}
+	if req.Status != "" {
+		scope = scope.Where("status = ?", req.Status)
+	}
+	if req.ResourceID != "" {
+		scope = scope.Where("resource_id IN (?)", r.DB(ctx).Model(&model.Resource{}).Select("id").Where("resource_id = ?", req.ResourceID))
+	}
+	if req.Environment != "" {
+		scope = scope.Where("environment = ?", req.Environment)
+	}
+	if req.TenantID != "" {
+		scope = scope.Where("tenant_id = ?", req.TenantID)
+	}
+	if req.HealthStatus != "" {
+		scope = scope.Where("health_status = ?", req.HealthStatus)
+	}
+	if err := scope.Count(&total).Error; err != nil {
+		return nil, total, err
+	}
+	if err := preloadApplication(scope).Offset((req.Page - 1) * req.PageSize).Limit(req.PageSize).Order("id DESC").Find(&list).Error; err != nil {
+		return nil, total, err
+	}
+	return list, total, nil
+}
+
+func (r *applicationRepository) GetApplication(ctx context.Context, appID string) (model.Application, error) {
+	m := model.Application{}
+	return m, preloadApplication(r.DB(ctx)).Where("app_id = ?", appID).First(&m).Error
+}
+
+func (r *applicationRepository) GetApplicationTypeByName(ctx context.Context, typeName string) (model.ApplicationType, error) {
+	m := model.ApplicationType{}
+	return m, r.DB(ctx).Where("type_name = ?", typeName).First(&m).Error
+}
+
+func (r *applicationRepository) GetResource(ctx context.Context, resourceID string) (model.Resource, error) {
+	m := model.Resource{}
+	return m, r.DB(ctx).Where("resource_id = ?", resourceID).First(&m).Error
+}
+
+func (r *applicationRepository) CountApplicationConfigurations(ctx context.Context, id uint) (int64, error) {
+	var count int64
+	return count, r.DB(ctx).Model(&model.Configuration{}).Where("application_id = ?", id).Count(&count).Error
+}
+
+func (r *applicationRepository) ApplicationCreate(ctx context.Context, m *model.Application) error {
+	return r.DB(ctx).Omit("ApplicationType", "Resource", "Configurations").Create(m).Error
+}
+
+func (r *applicationRepository) ApplicationUpdate(ctx context.Context, m *model.Application) error {
+	// 显式指定列，允许将可选字段更新为空值；状态和运行时字段不在此更新
+	return r.DB(ctx).Model(&model.Application{}).Where("id = ?", m.ID).
+		Select("name", "version", "resource_id", "deployment_type", "working_dir", "executable_path",
+			"listen_ports", "network_config", "resource_limits", "environment", "tenant_id", "description").
+		Updates(m).Error
+}
+
+func (r *applicationRepository) ApplicationStatusUpdate(ctx context.Context, m *model.Application, from string) (bool, error) {
+	result := r.DB(ctx).Model(&model.Application{}).Where("id = ? AND status = ?", m.ID, from).
+		Select("status", "version", "start_time", "process_id").
+		Updates(m)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *applicationRepository) ApplicationRuntimeUpdate(ctx context.Context, m *model.Application, from string) (bool, error) {
+	result := r.DB(ctx).Model(&model.Application{}).Where("id = ? AND status = ?", m.ID, from).
+		Select("status", "version", "start_time", "process_id", "resource_usage", "listen_ports", "last_heartbeat", "health_status").
+		Updates(m)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *applicationRepository) GetStaleApplications(ctx context.Context, deadline time.Time) ([]model.Application, error) {
+	var list []model.Application
+	// updated_at随心跳和生命周期操作更新，刚执行start等操作的实例在下一个超时周期内不会被判定超时
+	return list, preloadApplication(r.DB(ctx)).
+		Where("status IN ?", []string{model.AppStatusRunning, model.AppStatusStarting}).
+		Where("last_heartbeat IS NOT NULL AND last_heartbeat < ? AND updated_at < ?", deadline, deadline).
+		Order("id").Find(&list).Error
+}
+
+func (r *applicationRepository) ApplicationDelete(ctx context.Context, id uint) error {
+	if err := r.DB(ctx).Where("application_id = ?", id).Delete(&model.ApplicationTag{}).Error; err != nil {
+		return err
+	}
+	return r.DB(ctx).Where("id = ?", id).Delete(&model.Application{}).Error
+}
+
+func (r *applicationRepository) ReplaceApplicationTags(ctx context.Context, id uint, tags []model.ApplicationTag) error {
+	if err := r.DB(ctx).Where("application_id = ?", id).Delete(&model.ApplicationTag{}).Error; err != nil {
+		return err
+	}
+	if len(tags) == 0 {
+		return nil
+	}
+	for i := range tags {
+		tags[i].ApplicationID = id
+	}
+	return r.DB(ctx).Omit(clause.Associations).Create(&tags).Error
+}
+
+func preloadApplication(db *gorm.DB) *gorm.DB {
+	return db.Preload("Tags").Preload("ApplicationType").Preload("Resource")
+}
@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+	"nunu-layout-admin/internal/model"
+
+	"gorm.io/gorm/clause"
+)
+
+type BusinessRepository interface {
+	GetBusiness(ctx context.Context, businessID string) (model.Business, error)
+	BusinessCreate(ctx context.Context, m *model.Business) error
+	BusinessUpdate(ctx context.Context, m *model.Business) error
+	BusinessDelete(ctx context.Context, id uint) error
+	ReplaceBusinessTags(ctx context.Context, id uint, tags []model.BusinessTag) error
+}
+
+func NewBusinessRepository(
+	repository *Repository,
+) BusinessRepository {
+	return &businessRepository{
+		Repository: repository,
+	}
+}
+
+type businessRepository struct {
+	*Repository
+}
+
+func (r *businessRepository) GetBusiness(ctx context.Context, businessID string) (model.Business, error) {
+	m := model.Business{}
+	return m, r.DB(ctx).Preload("Tags").Where("business_id = ?", businessID).First(&m).Error
+}
+
+func (r *businessRepository) BusinessCreate(ctx context.Context, m *model.Business) error {
+	return r.DB(ctx).Omit(clause.Associations).Create(m).Error
+}
+
+func (r *businessRepository) BusinessUpdate(ctx context.Context, m *model.Business) error {
+	return r.DB(ctx).Model(&model.Business{}).Where("id = ?", m.ID).
+		Select("name", "type", "status", "tenant_id", "owner_id", "team_id", "priority", "cost_center",
+			"budget", "health_status", "description").
+		Updates(m).Error
+}
+
+func (r *businessRepository) BusinessDelete(ctx context.Context, id uint) error {
+	if err := r.DB(ctx).Where("business_id = ?", id).Delete(&model.BusinessTag{}).Error; err != nil {
+		return err
+	}
+	return r.DB(ctx).Where("id = ?", id).Delete(&model.Business{}).Error
+}
+
+func (r *businessRepository) ReplaceBusinessTags(ctx context.Context, id uint, tags []model.BusinessTag) error {
+	if err := r.DB(ctx).Where("business_id = ?", id).Delete(&model.BusinessTag{}).Error; err != nil {
+		return err
+	}
+	if len(tags) == 0 {
+		return nil
+	}
+	for i := range tags {
+		tags[i].BusinessID = id
+	}
+	return r.DB(ctx).Omit(clause.Associations).Create(&tags).Error
+}
@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+	v1 "nunu-layout-admin/api/v1"
+	"nunu-layout-admin/internal/model"
+)
+
+type ConfigTemplateRepository interface {
+	GetConfigTemplates(ctx context.Context, req *v1.GetConfigTemplatesRequest) ([]model.ConfigurationTemplate, int64, error)
+	GetConfigTemplate(ctx context.Context, templateID string) (model.ConfigurationTemplate, error)
+	// GetDefaultConfigTemplate 应用类型启用的默认模板
+	GetDefaultConfigTemplate(ctx context.Context, appTypeID uint) (model.ConfigurationTemplate, error)
+	GetApplicationType(ctx context.Context, id uint) (model.ApplicationType, error)
+	GetApplicationTypeByName(ctx context.Context, typeName string) (model.ApplicationType, error)
+	ConfigTemplateCreate(ctx context.Context, m *model.ConfigurationTemplate) error
+	ConfigTemplateUpdate(ctx context.Context, m *model.ConfigurationTemplate) error
+	ConfigTemplateDelete(ctx context.Context, id uint) error
+	// ClearDefaultConfigTemplates 取消应用类型下除exceptID以外模板的默认标记
+	ClearDefaultConfigTemplates(ctx context.Context, appTypeID, exceptID uint) error
+	// CountTemplateConfigurations 使用模板的配置数量
+	CountTemplateConfigurations(ctx context.Context, templateID string) (int64, error)
+}
+
+func NewConfigTemplateRepository(
+	repository *Repository,
+) ConfigTemplateRepository {
+	return &configTemplateRepository{
+		Repository: repository,
+	}
+}
+
+type configTemplateRepository struct {
+	*Repository
+}
+
+func (r *configTemplateRepository) GetConfigTemplates(ctx context.Context, req *v1.GetConfigTemplatesRequest) ([]model.ConfigurationTemplate, int64, error) {
+	var list []model.ConfigurationTemplate
+	var total int64
+	scope := r.DB(ctx).Model(&model.ConfigurationTemplate{})
+	if req.Name != "" {
+		scope = scope.Where("name LIKE ?", "%"+req.Name+"%")
+	}
+	if req.AppTypeID != 0 {
+		scope = scope.Where("app_type_id = ?", req.AppTypeID)
+	}
+	if req.Category != "" {
+		scope = scope.Where("category = ?", req.Category)
+	}
+	if err := scope.Count(&total).Error; err != nil {
+		return nil, total, err
+	}
+	if err := scope.Offset((req.Page - 1) * req.PageSize).Limit(req.PageSize).Order("id DESC").Find(&list).Error; err != nil {
+		return nil, total, err
+	}
+	return list, total, nil
+}
+
+func (r *configTemplateRepository) GetConfigTemplate(ctx context.Context, templateID string) (model.ConfigurationTemplate, error) {
+	m := model.ConfigurationTemplate{}
+	return m, r.DB(ctx).Where("template_id = ?", templateID).First(&m).Error
+}
+
+func (r *configTemplateRepository) GetDefaultConfigTemplate(ctx context.Context, appTypeID uint) (model.ConfigurationTemplate, error) {
+	m := model.ConfigurationTemplate{}
+	return m, r.DB(ctx).Where("app_type_id = ? AND is_default = ? AND is_active = ?", appTypeID, true, true).
+		Order("id DESC").First(&m).Error
+}
+
+func (r *configTemplateRepository) GetApplicationType(ctx context.Context, id uint) (model.ApplicationType, error) {
+	m := model.ApplicationType{}
+	return m, r.DB(ctx).Where("id = ?", id).First(&m).Error
+}
+
+func (r *configTemplateRepository) GetApplicationTypeByName(ctx context.Context, typeName string) (model.ApplicationType, error) {
+	m := model.ApplicationType{}
+	return m, r.DB(ctx).Where("type_name = ?", typeName).First(&m).Error
+}
+
+func (r *configTemplateRepository) ConfigTemplateCreate(ctx context.Context, m *model.ConfigurationTemplate) error {
+	return r.DB(ctx).Create(m).Error
+}
+
+func (r *configTemplateRepository) ConfigTemplateUpdate(ctx context.Context, m *model.ConfigurationTemplate) error {
+	return r.DB(ctx).Model(&model.ConfigurationTemplate{}).Where("id = ?", m.ID).
+		Select("name", "template_data", "variables", "business_types", "environments", "version", "category",
+			"is_default", "is_active", "updated_by", "description").
+		Updates(m).Error
+}
+
+func (r *configTemplateRepository) ConfigTemplateDelete(ctx context.Context, id uint) error {
+	return r.DB(ctx).Where("id = ?", id).Delete(&model.ConfigurationTemplate{}).Error
+}
+
+func (r *configTemplateRepository) ClearDefaultConfigTemplates(ctx context.Context, appTypeID, exceptID uint) error {
+	return r.DB(ctx).Model(&model.ConfigurationTemplate{}).
+		Where("app_type_id = ? AND id <> ? AND is_default = ?", appTypeID, exceptID, true).
+		Update("is_default", false).Error
+}
+
+func (r *configTemplateRepository) CountTemplateConfigurations(ctx context.Context, templateID string) (int64, error) {
+	var total int64
+	return total, r.DB(ctx).Model(&model.Configuration{}).Where("template_id = ?", templateID).Count(&total).Error
+}
@@ -0,0 +1,251 @@
+package repository
+
+import (
+	"context"
+	v1 "nunu-layout-admin/api/v1"
+	"nunu-layout-admin/internal/model"
+	"time"
+
+	"gorm.io/gorm/clause"
+)
+
+type ConfigurationRepository interface {
+	GetConfigurations(ctx context.Context, req *v1.GetConfigurationsRequest) ([]model.Configuration, int64, error)
+	GetConfiguration(ctx context.Context, configID string) (model.Configuration, error)
+	// GetConfigurationsByAppType 该应用类型的全部应用实例上的配置
+	GetConfigurationsByAppType(ctx context.Context, appTypeID uint) ([]model.Configuration, error)
+	GetApplication(ctx context.Context, id uint) (model.Application, error)
+	GetBusiness(ctx context.Context, businessID string) (model.Business, error)
+	ConfigurationCreate(ctx context.Context, m *model.Configuration) error
+	ConfigurationUpdate(ctx context.Context, m *model.Configuration) error
+	// ConfigurationPublish 把发布的版本内容写回配置实例
+	ConfigurationPublish(ctx context.Context, m *model.Configuration) error
+	ConfigurationDelete(ctx context.Context, id uint) error
+	ReplaceConfigurationTags(ctx context.Context, id uint, tags []model.ConfigurationTag) error
+
+	GetConfigRevisions(ctx context.Context, configurationID uint, status string, page, pageSize int) ([]model.ConfigurationRevision, int64, error)
+	GetConfigRevision(ctx context.Context, configurationID uint, revision int) (model.ConfigurationRevision, error)
+	GetPublishedConfigRevision(ctx context.Context, configurationID uint) (model.ConfigurationRevision, error)
+	// GetOpenConfigRevisions 尚未发布的版本(draft/pending/scheduled)，按配置和版本号排序
+	GetOpenConfigRevisions(ctx context.Context, configurationIDs []uint) ([]model.ConfigurationRevision, error)
+	// GetMaxConfigRevision 最大的版本号(含已删除的草稿)，没有版本时返回0
+	GetMaxConfigRevision(ctx context.Context, configurationID uint) (int, error)
+	// GetDueConfigRevisions 生效时间已到、等待发布的版本，按生效时间和版本号排序
+	GetDueConfigRevisions(ctx context.Context, now time.Time) ([]model.ConfigurationRevision, error)
+	ConfigRevisionCreate(ctx context.Context, m *model.ConfigurationRevision) error
+	ConfigRevisionUpdate(ctx context.Context, m *model.ConfigurationRevision) error
+	ConfigRevisionDelete(ctx context.Context, id uint) error
+	// SupersedeConfigRevisions 把配置当前发布的版本标记为已替换
+	SupersedeConfigRevisions(ctx context.Context, configurationID uint) error
+
+	// GetConfigRenders 由模板渲染的配置的渲染记录，configIDs为空时返回全部
+	GetConfigRenders(ctx context.Context, templateID string, configIDs []string) ([]model.ConfigurationRender, error)
+	ConfigRenderCreate(ctx context.Context, m *model.ConfigurationRender) error
+	ConfigRenderUpdate(ctx context.Context, m *model.ConfigurationRender) error
+}
+
+func NewConfigurationRepository(
+	repository *Repository,
+) ConfigurationRepository {
+	return &configurationRepository{
+		Repository: repository,
+	}
+}
+
+type configurationRepository struct {
+	*Repository
+}
+
+func (r *configurationRepository) GetConfigurations(ctx context.Context, req *v1.GetConfigurationsRequest) ([]model.Configuration, int64, error) {
+	var list []model.Configuration
+	var total int64
+	scope := r.DB(ctx).Model(&model.Configuration{})
+	if req.Name != "" {
+		scope = scope.Where("name LIKE ?", "%"+req.Name+"%")
+	}
+	if req.ApplicationID != 0 {
+		scope = scope.Where("application_id = ?", req.ApplicationID)
+	}
+	if req.BusinessID != "" {
+		scope = scope.Where("business_id = ?", req.BusinessID)
+	}
+	if req.ServiceID != "" {
+		scope = scope.Where("service_id = ?", req.ServiceID)
+	}
+	if req.TenantID != "" {
+		scope = scope.Where("tenant_id = ?", req.TenantID)
+	}
+	if req.ConfigType != "" {
+		scope = scope.Where("config_type = ?", req.ConfigType)
+	}
+	if req.ConfigGroup != "" {
+		scope = scope.Where("config_group = ?", req.ConfigGroup)
+	}
+	if req.Status != "" {
+		scope = scope.Where("status = ?", req.Status)
+	}
+	if err := scope.Count(&total).Error; err != nil {
+		return nil, total, err
+	}
+	if err := scope.Preload("Tags").Offset((req.Page - 1) * req.PageSize).Limit(req.PageSize).Order("id DESC").Find(&list).Error; err != nil {
+		return nil, total, err
+	}
+	return list, total, nil
+}
+
+func (r *configurationRepository) GetConfiguration(ctx context.Context, configID string) (model.Configuration, error) {
+	m := model.Configuration{}
+	return m, r.DB(ctx).Preload("Tags").Where("config_id = ?", configID).First(&m).Error
+}
+
+func (r *configurationRepository) GetConfigurationsByAppType(ctx context.Context, appTypeID uint) ([]model.Configuration, error) {
+	var list []model.Configuration
+	return list, r.DB(ctx).
+		Where("application_id IN (?)", r.DB(ctx).Model(&model.Application{}).Select("id").Where("type_id = ?", appTypeID)).
+		Order("id ASC").Find(&list).Error
+}
+
+func (r *configurationRepository) GetApplication(ctx context.Context, id uint) (model.Application, error) {
+	m := model.Application{}
+	return m, r.DB(ctx).Where("id = ?", id).First(&m).Error
+}
+
+func (r *configurationRepository) GetBusiness(ctx context.Context, businessID string) (model.Business, error) {
+	m := model.Business{}
+	return m, r.DB(ctx).Where("business_id = ?", businessID).First(&m).Error
+}
+
+func (r *configurationRepository) ConfigurationCreate(ctx context.Context, m *model.Configuration) error {
+	return r.DB(ctx).Create(m).Error
+}
+
+func (r *configurationRepository) ConfigurationUpdate(ctx context.Context, m *model.Configuration) error {
+	// 显式指定列，允许将可选字段更新为空值
+	return r.DB(ctx).Model(&model.Configuration{}).Where("id = ?", m.ID).
+		Select("name", "business_id", "service_id", "tenant_id", "priority", "config_group", "status",
+			"description", "updated_by").
+		Updates(m).Error
+}
+
+func (r *configurationRepository) ConfigurationPublish(ctx context.Context, m *model.Configuration) error {
+	return r.DB(ctx).Model(&model.Configuration{}).Where("id = ?", m.ID).
+		Select("config_data", "config_format", "is_encrypted", "status", "version", "updated_by",
+			"applied_at", "effective_time", "expire_time").
+		Updates(m).Error
+}
+
+func (r *configurationRepository) ConfigurationDelete(ctx context.Context, id uint) error {
+	if err := r.DB(ctx).Where("configuration_id = ?", id).Delete(&model.ConfigurationTag{}).Error; err != nil {
+		return err
+	}
+	if err := r.DB(ctx).Where("configuration_id = ?", id).Delete(&model.ConfigurationRevision{}).Error; err != nil {
+		return err
+	}
+	if err := r.DB(ctx).Where("configuration_id = ?", id).Delete(&model.ConfigurationRender{}).Error; err != nil {
+		return err
+	}
+	return r.DB(ctx).Where("id = ?", id).Delete(&model.Configuration{}).Error
+}
+
+func (r *configurationRepository) ReplaceConfigurationTags(ctx context.Context, id uint, tags []model.ConfigurationTag) error {
+	if err := r.DB(ctx).Where("configuration_id = ?", id).Delete(&model.ConfigurationTag{}).Error; err != nil {
+		return err
+	}
+	if len(tags) == 0 {
+		return nil
+	}
+	for i := range tags {
+		tags[i].ConfigurationID = id
+	}
+	return r.DB(ctx).Omit(clause.Associations).Create(&tags).Error
+}
+
+func (r *configurationRepository) GetConfigRevisions(ctx context.Context, configurationID uint, status string, page, pageSize int) ([]model.ConfigurationRevision, int64, error) {
+	var list []model.ConfigurationRevision
+	var total int64
+	scope := r.DB(ctx).Model(&model.ConfigurationRevision{}).Where("configuration_id = ?", configurationID)
+	if status != "" {
+		scope = scope.Where("status = ?", status)
+	}
+	if err := scope.Count(&total).Error; err != nil {
+		return nil, total, err
+	}
+	if err := scope.Offset((page - 1) * pageSize).Limit(pageSize).Order("revision DESC").Find(&list).Error; err != nil {
+		return nil, total, err
+	}
+	return list, total, nil
+}
+
+func (r *configurationRepository) GetConfigRevision(ctx context.Context, configurationID uint, revision int) (model.ConfigurationRevision, error) {
+	m := model.ConfigurationRevision{}
+	return m, r.DB(ctx).Where("configuration_id = ? AND revision = ?", configurationID, revision).First(&m).Error
+}
+
+func (r *configurationRepository) GetPublishedConfigRevision(ctx context.Context, configurationID uint) (model.ConfigurationRevision, error) {
+	m := model.ConfigurationRevision{}
+	return m, r.DB(ctx).Where("configuration_id = ? AND status = ?", configurationID, model.ConfigRevisionPublished).First(&m).Error
+}
+
+func (r *configurationRepository) GetOpenConfigRevisions(ctx context.Context, configurationIDs []uint) ([]model.ConfigurationRevision, error) {
+	var list []model.ConfigurationRevision
+	if len(configurationIDs) == 0 {
+		return list, nil
+	}
+	return list, r.DB(ctx).
+		Where("configuration_id IN ? AND status IN ?", configurationIDs,
+			[]string{model.ConfigRevisionDraft, model.ConfigRevisionPending, model.ConfigRevisionScheduled}).
+		Order("configuration_id ASC, revision ASC").Find(&list).Error
+}
+
+func (r *configurationRepository) GetMaxConfigRevision(ctx context.Context, configurationID uint) (int, error) {
+	var revision int
+	err := r.DB(ctx).Unscoped().Model(&model.ConfigurationRevision{}).Where("configuration_id = ?", configurationID).
+		Select("COALESCE(MAX(revision), 0)").Scan(&revision).Error
+	return revision, err
+}
+
+func (r *configurationRepository) GetDueConfigRevisions(ctx context.Context, now time.Time) ([]model.ConfigurationRevision, error) {
+	var list []model.ConfigurationRevision
+	return list, r.DB(ctx).Where("status = ? AND effective_time <= ?", model.ConfigRevisionScheduled, now).
+		Order("effective_time ASC").Order("revision ASC").Find(&list).Error
+}
+
+func (r *configurationRepository) ConfigRevisionCreate(ctx context.Context, m *model.ConfigurationRevision) error {
+	return r.DB(ctx).Create(m).Error
+}
+
+func (r *configurationRepository) ConfigRevisionUpdate(ctx context.Context, m *model.ConfigurationRevision) error {
+	return r.DB(ctx).Model(&model.ConfigurationRevision{}).Where("id = ?", m.ID).
+		Select("status", "config_data", "is_encrypted", "comment", "effective_time", "expire_time",
+			"submitted_by", "submitted_at", "approved_by", "approved_at", "published_at").
+		Updates(m).Error
+}
+
+func (r *configurationRepository) ConfigRevisionDelete(ctx context.Context, id uint) error {
+	return r.DB(ctx).Where("id = ?", id).Delete(&model.ConfigurationRevision{}).Error
+}
+
+func (r *configurationRepository) SupersedeConfigRevisions(ctx context.Context, configurationID uint) error {
+	return r.DB(ctx).Model(&model.ConfigurationRevision{}).
+		Where("configuration_id = ? AND status = ?", configurationID, model.ConfigRevisionPublished).
+		Update("status", model.ConfigRevisionSuperseded).Error
+}
+
+func (r *configurationRepository) GetConfigRenders(ctx context.Context, templateID string, configIDs []string) ([]model.ConfigurationRender, error) {
+	var list []model.ConfigurationRender
+	scope := r.DB(ctx).Where("template_id = ?", templateID)
+	if len(configIDs) > 0 {
+		scope = scope.Where("config_id IN ?", configIDs)
+	}
+	return list, scope.Order("id ASC").Find(&list).Error
+}
+
+func (r *configurationRepository) ConfigRenderCreate(ctx context.Context, m *model.ConfigurationRender) error {
+	return r.DB(ctx).Create(m).Error
+}
+
+func (r *configurationRepository) ConfigRenderUpdate(ctx context.Context, m *model.ConfigurationRender) error {
+	return r.DB(ctx).Model(&model.ConfigurationRender{}).Where("id = ?", m.ID).
+		Select("template_version", "variables", "revision", "rendered_by", "rendered_at").
+		Updates(m).Error
+}
@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	v1 "nunu-layout-admin/api/v1"
+	"nunu-layout-admin/internal/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type DependencyGraphRepository interface {
+	// GetDependencyGraph 获取指定版本的依赖图，version为0时返回最新版本
+	GetDependencyGraph(ctx context.Context, key *v1.DependencyGraphKey, version int64) (model.DependencyGraph, error)
+	GetDependencyGraphVersions(ctx context.Context, key *v1.DependencyGraphKey) ([]model.DependencyGraph, error)
+	DependencyGraphCreate(ctx context.Context, m *model.DependencyGraph) error
+	DependencyGraphRefresh(ctx context.Context, id uint, calcTime, expiresAt time.Time, calcDuration int64) error
+	DependencyGraphTouch(ctx context.Context, id uint, now time.Time) error
+}
+
+func NewDependencyGraphRepository(
+	repository *Repository,
+) DependencyGraphRepository {
+	return &dependencyGraphRepository{
+		Repository: repository,
+	}
+}
+
+type dependencyGraphRepository struct {
+	*Repository
+}
+
+func (r *dependencyGraphRepository) scope(ctx context.Context, key *v1.DependencyGraphKey) *gorm.DB {
+	return r.DB(ctx).Model(&model.DependencyGraph{}).
+		Where("graph_type = ? AND root_type = ? AND root_id = ?", key.GraphType, key.RootType, key.RootID).
+		Where("environment = ? AND tenant_id = ?", key.Environment, key.TenantID)
+}
+
+func (r *dependencyGraphRepository) GetDependencyGraph(ctx context.Context, key *v1.DependencyGraphKey, version int64) (model.DependencyGraph, error) {
+	m := model.DependencyGraph{}
+	scope := r.scope(ctx, key)
+	if version > 0 {
+		scope = scope.Where("version = ?", version)
+	}
+	return m, scope.Order("version DESC").First(&m).Error
+}
+
+func (r *dependencyGraphRepository) GetDependencyGraphVersions(ctx context.Context, key *v1.DependencyGraphKey) ([]model.DependencyGraph, error) {
+	var list []model.DependencyGraph
+	return list, r.scope(ctx, key).Omit("graph_data").Order("version DESC").Find(&list).Error
+}
+
+func (r *dependencyGraphRepository) DependencyGraphCreate(ctx context.Context, m *model.DependencyGraph) error {
+	return r.DB(ctx).Create(m).Error
+}
+
+func (r *dependencyGraphRepository) DependencyGraphRefresh(ctx context.Context, id uint, calcTime, expiresAt time.Time, calcDuration int64) error {
+	return r.DB(ctx).Model(&model.DependencyGraph{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"calc_time":     calcTime,
+		"expires_at":    expiresAt,
+		"calc_duration": calcDuration,
+	}).Error
+}
+
+func (r *dependencyGraphRepository) DependencyGraphTouch(ctx context.Context, id uint, now time.Time) error {
+	return r.DB(ctx).Model(&model.DependencyGraph{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"access_count": gorm.Expr("access_count + ?", 1),
+		"last_access":  now,
+	}).Error
+}
@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	v1 "nunu-layout-admin/api/v1"
+	"nunu-layout-admin/internal/model"
+	"time"
+)
+
+type HealthCheckRepository interface {
+	// GetProbeApplications 获取需要主动探测的运行中实例，同时加载应用类型和部署的资源
+	GetProbeApplications(ctx context.Context) ([]model.Application, error)
+	// GetHealthCheckDisabledApplicationIDs 返回关闭了健康检查的实例ID：作为依赖目标，且指向它的依赖关系都关闭了health_check_enabled
+	GetHealthCheckDisabledApplicationIDs(ctx context.Context) ([]uint, error)
+	// GetRecentProbeResults 获取实例最近的limit条探测结果，新的在前
+	GetRecentProbeResults(ctx context.Context, applicationID uint, limit int) ([]model.ApplicationProbeResult, error)
+	GetProbeResults(ctx context.Context, applicationID uint, req *v1.GetApplicationProbeResultsRequest) ([]model.ApplicationProbeResult, int64, error)
+	ProbeResultCreate(ctx context.Context, m *model.ApplicationProbeResult) error
+	// ClaimHealthCheck 最后健康检查时间仍为last时改为now，返回是否成功，用于在探测前认领实例，避免重复探测
+	ClaimHealthCheck(ctx context.Context, id uint, last *time.Time, now time.Time) (bool, error)
+	// ApplicationHealthUpdate 只更新健康状态和最后健康检查时间，不更新updated_at
+	ApplicationHealthUpdate(ctx context.Context, m *model.Application) error
+	// PurgeProbeResults 删除before之前的探测结果，返回删除条数
+	PurgeProbeResults(ctx context.Context, before time.Time) (int64, error)
+}
+
+func NewHealthCheckRepository(
+	repository *Repository,
+) HealthCheckRepository {
+	return &healthCheckRepository{
+		Repository: repository,
+	}
+}
+
+type healthCheckRepository struct {
+	*Repository
+}
+
+func (r *healthCheckRepository) GetProbeApplications(ctx context.Context) ([]model.Application, error) {
+	var list []model.Application
+	return list, r.DB(ctx).Preload("ApplicationType").Preload("Resource").
+		Where("status = ?", model.AppStatusRunning).Order("id").Find(&list).Error
+}
+
+func (r *healthCheckRepository) GetHealthCheckDisabledApplicationIDs(ctx context.Context) ([]uint, error) {
+	var ids []uint
+	return ids, r.DB(ctx).Model(&model.ApplicationDependency{}).
+		Group("target_app_id").
+		Having("SUM(CASE WHEN health_check_enabled THEN 1 ELSE 0 END) = 0").
+		Pluck("target_app_id", &ids).Error
+}
+
+func (r *healthCheckRepository) GetRecentProbeResults(ctx context.Context, applicationID uint, limit int) ([]model.ApplicationProbeResult, error) {
+	var list []model.ApplicationProbeResult
+	return list, r.DB(ctx).Where("application_id = ?", applicationID).
+		Order("checked_at DESC").Order("id DESC").Limit(limit).Find(&list).Error
+}
+
+func (r *healthCheckRepository) GetProbeResults(ctx context.Context, applicationID uint, req *v1.GetApplicationProbeResultsRequest) ([]model.ApplicationProbeResult, int64, error) {
+	var list []model.ApplicationProbeResult
+	var total int64
+	scope := r.DB(ctx).Model(&model.ApplicationProbeResult{}).Where("application_id = ?", applicationID)
+	if req.Success != nil {
+		scope = scope.Where("success = ?", *req.Success)
+	}
+	if !req.StartTime.IsZero() {
+		scope = scope.Where("checked_at >= ?", req.StartTime)
+	}
+	if !req.EndTime.IsZero() {
+		scope = scope.Where("checked_at <= ?", req.EndTime)
+	}
+	if err := scope.Count(&total).Error; err != nil {
+		return nil, total, err
+	}
+	if err := scope.Offset((req.Page - 1) * req.PageSize).Limit(req.PageSize).Order("checked_at DESC").Order("id DESC").Find(&list).Error; err != nil {
+		return nil, total, err
+	}
+	return list, total, nil
+}
+
+func (r *healthCheckRepository) ProbeResultCreate(ctx context.Context, m *model.ApplicationProbeResult) error {
+	return r.DB(ctx).Create(m).Error
+}
+
+func (r *healthCheckRepository) ClaimHealthCheck(ctx context.Context, id uint, last *time.Time, now time.Time) (bool, error) {
+	scope := r.DB(ctx).Model(&model.Application{}).Where("id = ?", id)
+	if last == nil {
+		scope = scope.Where("last_health_check IS NULL")
+	} else {
+		scope = scope.Where("last_health_check = ?", *last)
+	}
+	result := scope.UpdateColumn("last_health_check", now)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *healthCheckRepository) ApplicationHealthUpdate(ctx context.Context, m *model.Application) error {
+	// 不更新updated_at，心跳超时判断依赖它
+	return r.DB(ctx).Model(&model.Application{}).Where("id = ?", m.ID).
+		UpdateColumns(map[string]interface{}{
+			"health_status":     m.HealthStatus,
+			"last_health_check": m.LastHealthCheck,
+		}).Error
+}
+
+func (r *healthCheckRepository) PurgeProbeResults(ctx context.Context, before time.Time) (int64, error) {
+	result := r.DB(ctx).Unscoped().Where("checked_at < ?", before).Delete(&model.ApplicationProbeResult{})
+	return result.RowsAffected, result.Error
+}
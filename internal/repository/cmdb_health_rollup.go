@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"context"
+	"nunu-layout-admin/internal/model"
+)
+
+// HealthMember 参与服务健康汇总的应用实例，ServiceID为服务的业务唯一标识
+type HealthMember struct {
+	ServiceID    string
+	AppID        string
+	Name         string
+	Role         string
+	Status       string
+	HealthStatus string
+}
+
+type HealthRollupRepository interface {
+	// GetRollupServices 获取全部服务及标签
+	GetRollupServices(ctx context.Context) ([]model.Service, error)
+	// GetRollupBusinesses 获取全部业务及标签
+	GetRollupBusinesses(ctx context.Context) ([]model.Business, error)
+	GetServiceHealthRules(ctx context.Context) ([]model.ServiceHealthRule, error)
+	// GetResourceMembers 服务资源上部署的应用实例，角色为资源在服务中的角色
+	GetResourceMembers(ctx context.Context) ([]HealthMember, error)
+	// GetGroupMembers 服务的应用组中启用的成员，角色为成员在组中的角色
+	GetGroupMembers(ctx context.Context) ([]HealthMember, error)
+	// GetBusinessServices 业务(Source)包含服务(Target)及服务的重要性级别
+	GetBusinessServices(ctx context.Context) ([]ObjectLink, error)
+	GetServiceHealthRule(ctx context.Context, serviceID uint) (model.ServiceHealthRule, error)
+	ServiceHealthRuleCreate(ctx context.Context, m *model.ServiceHealthRule) error
+	ServiceHealthRuleUpdate(ctx context.Context, m *model.ServiceHealthRule) error
+	ServiceHealthRuleDelete(ctx context.Context, id uint) error
+	// ServiceHealthUpdate 只更新服务的健康状态
+	ServiceHealthUpdate(ctx context.Context, m *model.Service) error
+	// BusinessHealthUpdate 只更新业务的健康状态
+	BusinessHealthUpdate(ctx context.Context, m *model.Business) error
+}
+
+func NewHealthRollupRepository(
+	repository *Repository,
+) HealthRollupRepository {
+	return &healthRollupRepository{
+		Repository: repository,
+	}
+}
+
+type healthRollupRepository struct {
+	*Repository
+}
+
+func (r *healthRollupRepository) GetRollupServices(ctx context.Context) ([]model.Service, error) {
+	var list []model.Service
+	return list, r.DB(ctx).Preload("Tags").Order("id").Find(&list).Error
+}
+
+func (r *healthRollupRepository) GetRollupBusinesses(ctx context.Context) ([]model.Business, error) {
+	var list []model.Business
+	return list, r.DB(ctx).Preload("Tags").Order("id").Find(&list).Error
+}
+
+func (r *healthRollupRepository) GetServiceHealthRules(ctx context.Context) ([]model.ServiceHealthRule, error) {
+	var list []model.ServiceHealthRule
+	return list, r.DB(ctx).Find(&list).Error
+}
+
+func (r *healthRollupRepository) GetResourceMembers(ctx context.Context) ([]HealthMember, error) {
+	var list []HealthMember
+	return list, r.DB(ctx).Table("cmdb_service_resources AS sr").
+		Select("s.service_id, a.app_id, a.name, sr.role, a.status, a.health_status").
+		Joins("JOIN cmdb_services AS s ON s.id = sr.service_id AND s.deleted_at IS NULL").
+		Joins("JOIN cmdb_applications AS a ON a.resource_id = sr.resource_id AND a.deleted_at IS NULL").
+		Where("sr.deleted_at IS NULL").
+		Order("a.id").
+		Scan(&list).Error
+}
+
+func (r *healthRollupRepository) GetGroupMembers(ctx context.Context) ([]HealthMember, error) {
+	var list []HealthMember
+	return list, r.DB(ctx).Table("cmdb_application_group_members AS m").
+		Select("g.service_id, a.app_id, a.name, m.role, a.status, a.health_status").
+		Joins("JOIN cmdb_application_groups AS g ON g.id = m.group_id AND g.deleted_at IS NULL").
+		Joins("JOIN cmdb_applications AS a ON a.id = m.application_id AND a.deleted_at IS NULL").
+		Where("m.deleted_at IS NULL AND m.is_active = ? AND g.service_id <> ''", true).
+		Order("a.id").
+		Scan(&list).Error
+}
+
+func (r *healthRollupRepository) GetBusinessServices(ctx context.Context) ([]ObjectLink, error) {
+	var list []ObjectLink
+	return list, r.DB(ctx).Table("cmdb_business_services AS bs").
+		Select("b.business_id AS source_id, s.service_id AS target_id, bs.role AS kind, bs.criticality").
+		Joins("JOIN cmdb_businesses AS b ON b.id = bs.business_id AND b.deleted_at IS NULL").
+		Joins("JOIN cmdb_services AS s ON s.id = bs.service_id AND s.deleted_at IS NULL").
+		Where("bs.deleted_at IS NULL").
+		Scan(&list).Error
+}
+
+func (r *healthRollupRepository) GetServiceHealthRule(ctx context.Context, serviceID uint) (model.ServiceHealthRule, error) {
+	m := model.ServiceHealthRule{}
+	return m, r.DB(ctx).Where("service_id = ?", serviceID).First(&m).Error
+}
+
+func (r *healthRollupRepository) ServiceHealthRuleCreate(ctx context.Context, m *model.ServiceHealthRule) error {
+	return r.DB(ctx).Omit("Service").Create(m).Error
+}
+
+func (r *healthRollupRepository) ServiceHealthRuleUpdate(ctx context.Context, m *model.ServiceHealthRule) error {
+	return r.DB(ctx).Model(&model.ServiceHealthRule{}).Where("id = ?", m.ID).
+		Select("enabled", "down_below_members", "min_healthy_members", "min_healthy_ratio", "critical_roles",
+			"critical_dependencies", "degrade_on_dependency", "description").
+		Updates(m).Error
+}
+
+func (r *healthRollupRepository) ServiceHealthRuleDelete(ctx context.Context, id uint) error {
+	return r.DB(ctx).Unscoped().Where("id = ?", id).Delete(&model.ServiceHealthRule{}).Error
+}
+
+func (r *healthRollupRepository) ServiceHealthUpdate(ctx context.Context, m *model.Service) error {
+	return r.DB(ctx).Model(&model.Service{}).Where("id = ?", m.ID).
+		Select("health_status").
+		Updates(m).Error
+}
+
+func (r *healthRollupRepository) BusinessHealthUpdate(ctx context.Context, m *model.Business) error {
+	return r.DB(ctx).Model(&model.Business{}).Where("id = ?", m.ID).
+		Select("health_status").
+		Updates(m).Error
+}
@@ -0,0 +1,257 @@
+package repository
+
+import (
+	"context"
+	v1 "nunu-layout-admin/api/v1"
+	"nunu-layout-admin/internal/model"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// HistoryRecord 各变更历史表的公共列，ObjectID为对象标识列的别名
+type HistoryRecord struct {
+	ID            uint
+	ObjectID      string
+	ChangeType    string
+	ChangeSource  string
+	ChangeTime    time.Time
+	OperatorID    string
+	OperatorName  string
+	OperatorIP    string
+	BeforeData    model.JSONMap `gorm:"type:jsonb"`
+	AfterData     model.JSONMap `gorm:"type:jsonb"`
+	ChangedFields model.JSONMap `gorm:"type:jsonb"`
+	ChangeReason  string
+	Comment       string
+	Version       int64
+}
+
+type HistoryRepository interface {
+	GetHistories(ctx context.Context, req *v1.GetHistoriesRequest) ([]HistoryRecord, int64, error)
+	// GetHistory 获取对象指定版本的历史，version为0时返回最新版本
+	GetHistory(ctx context.Context, objectType, objectID string, version int64) (HistoryRecord, error)
+	// GetLatestHistoryVersion 返回对象当前最大的历史版本号，没有历史时为0
+	GetLatestHistoryVersion(ctx context.Context, objectType, objectID string) (int64, error)
+	// GetHistoriesAround 返回每个对象在asOf及之前的最后一条历史和asOf之后的第一条历史，objectIDs为空时查询该类型全部对象
+	GetHistoriesAround(ctx context.Context, objectType string, objectIDs []string, asOf time.Time) ([]HistoryRecord, []HistoryRecord, error)
+	// GetHistoriesBetween 返回该类型全部对象在(since, until]之间的历史，按写入顺序排列
+	GetHistoriesBetween(ctx context.Context, objectType string, since, until time.Time) ([]HistoryRecord, error)
+	// GetExpiredHistories 按ID顺序分批返回该类型在before之前的历史，不包含每个对象的最新一条
+	GetExpiredHistories(ctx context.Context, objectType string, before time.Time, afterID uint, limit int) ([]HistoryRecord, error)
+	// HistoryCreate 写入一条变更历史，m为各类*model.XxxHistory
+	HistoryCreate(ctx context.Context, m interface{}) error
+	HistoryDelete(ctx context.Context, objectType string, ids []uint) error
+	// GetAuditConfigs 返回全部启用的审计配置
+	GetAuditConfigs(ctx context.Context) ([]model.AuditConfig, error)
+	// NotificationCreate 写入变更通知的待发送记录，与变更历史在同一事务中
+	NotificationCreate(ctx context.Context, list []model.NotificationDelivery) error
+	// HealthTransitionCreate 写入服务健康状态的变化记录，与变更历史在同一事务中
+	HealthTransitionCreate(ctx context.Context, m *model.ServiceHealthTransition) error
+	GetOperatorName(ctx context.Context, uid uint) (string, error)
+}
+
+func NewHistoryRepository(
+	repository *Repository,
+) HistoryRepository {
+	return &historyRepository{
+		Repository: repository,
+	}
+}
+
+type historyRepository struct {
+	*Repository
+}
+
+func (r *historyRepository) GetHistories(ctx context.Context, req *v1.GetHistoriesRequest) ([]HistoryRecord, int64, error) {
+	var list []HistoryRecord
+	var total int64
+	scope, ok := r.historyScope(ctx, req.ObjectType, req.ObjectID)
+	if !ok {
+		return list, total, nil
+	}
+	if req.ChangeType != "" {
+		scope = scope.Where("change_type = ?", req.ChangeType)
+	}
+	if err := scope.Count(&total).Error; err != nil {
+		return nil, total, err
+	}
+	if err := scope.Select(historyColumns(req.ObjectType)).Offset((req.Page - 1) * req.PageSize).Limit(req.PageSize).
+		Order("version DESC").Order("id DESC").Find(&list).Error; err != nil {
+		return nil, total, err
+	}
+	return list, total, nil
+}
+
+func (r *historyRepository) GetHistory(ctx context.Context, objectType, objectID string, version int64) (HistoryRecord, error) {
+	m := HistoryRecord{}
+	scope, ok := r.historyScope(ctx, objectType, objectID)
+	if !ok {
+		return m, gorm.ErrRecordNotFound
+	}
+	if version > 0 {
+		scope = scope.Where("version = ?", version)
+	}
+	return m, scope.Select(historyColumns(objectType)).Order("version DESC").Order("id DESC").First(&m).Error
+}
+
+func (r *historyRepository) GetLatestHistoryVersion(ctx context.Context, objectType, objectID string) (int64, error) {
+	var version int64
+	scope, ok := r.historyScope(ctx, objectType, objectID)
+	if !ok {
+		return 0, nil
+	}
+	return version, scope.Select("COALESCE(MAX(version), 0)").Scan(&version).Error
+}
+
+func (r *historyRepository) GetHistoriesAround(ctx context.Context, objectType string, objectIDs []string, asOf time.Time) ([]HistoryRecord, []HistoryRecord, error) {
+	table, keyColumn, ok := historyTable(objectType)
+	if !ok {
+		return nil, nil, nil
+	}
+	keys, ok := historyKeys(objectType, objectIDs)
+	if !ok {
+		return nil, nil, nil
+	}
+	find := func(aggregate, timeCondition string) ([]HistoryRecord, error) {
+		var list []HistoryRecord
+		sub := r.DB(ctx).Model(table).Select(aggregate).Where(timeCondition, asOf).Group(keyColumn)
+		if keys != nil {
+			sub = sub.Where(keyColumn+" IN ?", keys)
+		}
+		return list, r.DB(ctx).Model(table).Select(historyColumns(objectType)).
+			Where("id IN (?)", sub).Find(&list).Error
+	}
+	last, err := find("MAX(id)", "change_time <= ?")
+	if err != nil {
+		return nil, nil, err
+	}
+	next, err := find("MIN(id)", "change_time > ?")
+	if err != nil {
+		return nil, nil, err
+	}
+	return last, next, nil
+}
+
+func (r *historyRepository) GetHistoriesBetween(ctx context.Context, objectType string, since, until time.Time) ([]HistoryRecord, error) {
+	var list []HistoryRecord
+	table, _, ok := historyTable(objectType)
+	if !ok {
+		return list, nil
+	}
+	return list, r.DB(ctx).Model(table).Select(historyColumns(objectType)).
+		Where("change_time > ? AND change_time <= ?", since, until).Order("id ASC").Find(&list).Error
+}
+
+func (r *historyRepository) GetExpiredHistories(ctx context.Context, objectType string, before time.Time, afterID uint, limit int) ([]HistoryRecord, error) {
+	var list []HistoryRecord
+	table, keyColumn, ok := historyTable(objectType)
+	if !ok {
+		return list, nil
+	}
+	// 保留每个对象的最新一条历史，版本号和按时间点查询都依赖它
+	latest := r.DB(ctx).Model(table).Select("MAX(id)").Group(keyColumn)
+	return list, r.DB(ctx).Model(table).Select(historyColumns(objectType)).
+		Where("change_time < ? AND id > ?", before, afterID).Where("id NOT IN (?)", latest).
+		Order("id ASC").Limit(limit).Find(&list).Error
+}
+
+func (r *historyRepository) HistoryCreate(ctx context.Context, m interface{}) error {
+	return r.DB(ctx).Omit(clause.Associations).Create(m).Error
+}
+
+func (r *historyRepository) HistoryDelete(ctx context.Context, objectType string, ids []uint) error {
+	table, _, ok := historyTable(objectType)
+	if !ok || len(ids) == 0 {
+		return nil
+	}
+	// 过期历史直接物理删除，不保留软删除记录
+	return r.DB(ctx).Unscoped().Where("id IN ?", ids).Delete(table).Error
+}
+
+func (r *historyRepository) NotificationCreate(ctx context.Context, list []model.NotificationDelivery) error {
+	if len(list) == 0 {
+		return nil
+	}
+	return r.DB(ctx).Create(&list).Error
+}
+
+func (r *historyRepository) HealthTransitionCreate(ctx context.Context, m *model.ServiceHealthTransition) error {
+	return r.DB(ctx).Create(m).Error
+}
+
+func (r *historyRepository) GetAuditConfigs(ctx context.Context) ([]model.AuditConfig, error) {
+	var list []model.AuditConfig
+	return list, r.DB(ctx).Where("is_active = ?", true).Order("id ASC").Find(&list).Error
+}
+
+func (r *historyRepository) GetOperatorName(ctx context.Context, uid uint) (string, error) {
+	m := model.AdminUser{}
+	if err := r.DB(ctx).Select("username", "nickname").Where("id = ?", uid).First(&m).Error; err != nil {
+		return "", err
+	}
+	if m.Nickname != "" {
+		return m.Nickname, nil
+	}
+	return m.Username, nil
+}
+
+// historyScope 返回对象类型对应的历史表中单个对象的查询
+func (r *historyRepository) historyScope(ctx context.Context, objectType, objectID string) (*gorm.DB, bool) {
+	table, keyColumn, ok := historyTable(objectType)
+	if !ok {
+		return nil, false
+	}
+	keys, ok := historyKeys(objectType, []string{objectID})
+	if !ok {
+		return nil, false
+	}
+	return r.DB(ctx).Model(table).Where(keyColumn+" IN ?", keys), true
+}
+
+// historyTable 返回对象类型对应的历史表及对象标识列，资源/服务/业务/应用实例按业务唯一标识，
+// 资源关系按关系自增ID，通用关系按RelationID
+func historyTable(objectType string) (interface{}, string, bool) {
+	switch objectType {
+	case model.ObjectTypeResource:
+		return &model.ResourceHistory{}, "resource_uuid", true
+	case model.ObjectTypeService:
+		return &model.ServiceHistory{}, "service_uuid", true
+	case model.ObjectTypeBusiness:
+		return &model.BusinessHistory{}, "business_uuid", true
+	case model.HistoryObjectResourceRelation:
+		return &model.RelationHistory{}, "relation_id", true
+	case model.HistoryObjectUniversalRelation:
+		return &model.UniversalRelationHistory{}, "relation_id", true
+	case model.ObjectTypeApplication:
+		return &model.ApplicationHistory{}, "application_uuid", true
+	}
+	return nil, "", false
+}
+
+// historyColumns 查询HistoryRecord时的列，把对象标识列映射为object_id
+func historyColumns(objectType string) string {
+	_, keyColumn, _ := historyTable(objectType)
+	return "*, " + keyColumn + " AS object_id"
+}
+
+// historyKeys 把对象标识转换为标识列的取值，资源关系的标识列为数字
+func historyKeys(objectType string, objectIDs []string) (interface{}, bool) {
+	if len(objectIDs) == 0 {
+		return nil, true
+	}
+	if objectType != model.HistoryObjectResourceRelation {
+		return objectIDs, true
+	}
+	ids := make([]uint64, 0, len(objectIDs))
+	for _, objectID := range objectIDs {
+		id, err := strconv.ParseUint(objectID, 10, 64)
+		if err != nil {
+			return nil, false
+		}
+		ids = append(ids, id)
+	}
+	return ids, true
+}
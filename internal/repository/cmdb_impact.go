@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"context"
+	"nunu-layout-admin/internal/model"
+)
+
+// ObjectLink 两个对象之间的一条关联，ID均为对象的业务唯一标识
+type ObjectLink struct {
+	SourceID    string
+	TargetID    string
+	Kind        string
+	IsRequired  bool
+	Criticality string
+}
+
+type ImpactRepository interface {
+	// GetApplicationDeployments 资源(Source) -> 部署在其上的应用(Target)
+	GetApplicationDeployments(ctx context.Context) ([]ObjectLink, error)
+	// GetApplicationDependencies 应用(Source)依赖应用(Target)
+	GetApplicationDependencies(ctx context.Context) ([]ObjectLink, error)
+	// GetServiceResources 服务(Source)使用资源(Target)
+	GetServiceResources(ctx context.Context) ([]ObjectLink, error)
+	// GetBusinessServices 业务(Source)包含服务(Target)
+	GetBusinessServices(ctx context.Context) ([]ObjectLink, error)
+	// GetApplicationConfigurations 配置(Source)属于应用(Target)
+	GetApplicationConfigurations(ctx context.Context) ([]ObjectLink, error)
+	GetBusinesses(ctx context.Context) ([]model.Business, error)
+}
+
+func NewImpactRepository(
+	repository *Repository,
+) ImpactRepository {
+	return &impactRepository{
+		Repository: repository,
+	}
+}
+
+type impactRepository struct {
+	*Repository
+}
+
+func (r *impactRepository) GetApplicationDeployments(ctx context.Context) ([]ObjectLink, error) {
+	var list []ObjectLink
+	return list, r.DB(ctx).Table("cmdb_applications AS a").
+		Select("r.resource_id AS source_id, a.app_id AS target_id").
+		Joins("JOIN cmdb_resources AS r ON r.id = a.resource_id AND r.deleted_at IS NULL").
+		Where("a.deleted_at IS NULL").
+		Scan(&list).Error
+}
+
+func (r *impactRepository) GetApplicationDependencies(ctx context.Context) ([]ObjectLink, error) {
+	var list []ObjectLink
+	return list, r.DB(ctx).Table("cmdb_application_dependencies AS d").
+		Select("s.app_id AS source_id, t.app_id AS target_id, d.dependency_type AS kind, d.is_required").
+		Joins("JOIN cmdb_applications AS s ON s.id = d.source_app_id AND s.deleted_at IS NULL").
+		Joins("JOIN cmdb_applications AS t ON t.id = d.target_app_id AND t.deleted_at IS NULL").
+		Where("d.deleted_at IS NULL").
+		Scan(&list).Error
+}
+
+func (r *impactRepository) GetServiceResources(ctx context.Context) ([]ObjectLink, error) {
+	var list []ObjectLink
+	return list, r.DB(ctx).Table("cmdb_service_resources AS sr").
+		Select("s.service_id AS source_id, r.resource_id AS target_id, sr.role AS kind").
+		Joins("JOIN cmdb_services AS s ON s.id = sr.service_id AND s.deleted_at IS NULL").
+		Joins("JOIN cmdb_resources AS r ON r.id = sr.resource_id AND r.deleted_at IS NULL").
+		Where("sr.deleted_at IS NULL").
+		Scan(&list).Error
+}
+
+func (r *impactRepository) GetBusinessServices(ctx context.Context) ([]ObjectLink, error) {
+	var list []ObjectLink
+	return list, r.DB(ctx).Table("cmdb_business_services AS bs").
+		Select("b.business_id AS source_id, s.service_id AS target_id, bs.role AS kind, bs.criticality").
+		Joins("JOIN cmdb_businesses AS b ON b.id = bs.business_id AND b.deleted_at IS NULL").
+		Joins("JOIN cmdb_services AS s ON s.id = bs.service_id AND s.deleted_at IS NULL").
+		Where("bs.deleted_at IS NULL").
+		Scan(&list).Error
+}
+
+func (r *impactRepository) GetApplicationConfigurations(ctx context.Context) ([]ObjectLink, error) {
+	var list []ObjectLink
+	return list, r.DB(ctx).Table("cmdb_configurations AS c").
+		Select("c.config_id AS source_id, a.app_id AS target_id, c.config_type AS kind").
+		Joins("JOIN cmdb_applications AS a ON a.id = c.application_id AND a.deleted_at IS NULL").
+		Where("c.deleted_at IS NULL").
+		Scan(&list).Error
+}
+
+func (r *impactRepository) GetBusinesses(ctx context.Context) ([]model.Business, error) {
+	var list []model.Business
+	return list, r.DB(ctx).Select("id", "business_id", "name", "priority").Find(&list).Error
+}
@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	v1 "nunu-layout-admin/api/v1"
+	"nunu-layout-admin/internal/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type NotificationRepository interface {
+	GetDeliveries(ctx context.Context, req *v1.GetNotificationDeliveriesRequest) ([]model.NotificationDelivery, int64, error)
+	GetDelivery(ctx context.Context, id uint) (model.NotificationDelivery, error)
+	// GetDueDeliveries 返回到期待发送的通知，按写入顺序排列
+	GetDueDeliveries(ctx context.Context, now time.Time, limit int) ([]model.NotificationDelivery, error)
+	// DeliveryClaim 以尝试次数做乐观锁占用一条待发送通知，同时把下次尝试时间推迟到leaseUntil，
+	// 发送进程中途退出时到期后会被重新发送。返回false表示已被其他进程占用
+	DeliveryClaim(ctx context.Context, id uint, attempts int, leaseUntil time.Time) (bool, error)
+	DeliveryUpdate(ctx context.Context, m *model.NotificationDelivery) error
+	GetAuditConfig(ctx context.Context, id uint) (model.AuditConfig, error)
+}
+
+func NewNotificationRepository(
+	repository *Repository,
+) NotificationRepository {
+	return &notificationRepository{
+		Repository: repository,
+	}
+}
+
+type notificationRepository struct {
+	*Repository
+}
+
+func (r *notificationRepository) GetDeliveries(ctx context.Context, req *v1.GetNotificationDeliveriesRequest) ([]model.NotificationDelivery, int64, error) {
+	var list []model.NotificationDelivery
+	var total int64
+	scope := r.DB(ctx).Model(&model.NotificationDelivery{})
+	if req.Channel != "" {
+		scope = scope.Where("channel = ?", req.Channel)
+	}
+	if req.Status != "" {
+		scope = scope.Where("status = ?", req.Status)
+	}
+	if req.ObjectType != "" {
+		scope = scope.Where("object_type = ?", req.ObjectType)
+	}
+	if req.ObjectID != "" {
+		scope = scope.Where("object_id = ?", req.ObjectID)
+	}
+	if err := scope.Count(&total).Error; err != nil {
+		return nil, total, err
+	}
+	if err := scope.Offset((req.Page - 1) * req.PageSize).Limit(req.PageSize).Order("id DESC").Find(&list).Error; err != nil {
+		return nil, total, err
+	}
+	return list, total, nil
+}
+
+func (r *notificationRepository) GetDelivery(ctx context.Context, id uint) (model.NotificationDelivery, error) {
+	m := model.NotificationDelivery{}
+	return m, r.DB(ctx).Where("id = ?", id).First(&m).Error
+}
+
+func (r *notificationRepository) GetDueDeliveries(ctx context.Context, now time.Time, limit int) ([]model.NotificationDelivery, error) {
+	var list []model.NotificationDelivery
+	return list, r.DB(ctx).Where("status = ?", model.NotificationStatusPending).
+		Where("next_retry_at IS NULL OR next_retry_at <= ?", now).
+		Order("id ASC").Limit(limit).Find(&list).Error
+}
+
+func (r *notificationRepository) DeliveryClaim(ctx context.Context, id uint, attempts int, leaseUntil time.Time) (bool, error) {
+	result := r.DB(ctx).Model(&model.NotificationDelivery{}).
+		Where("id = ? AND status = ? AND attempts = ?", id, model.NotificationStatusPending, attempts).
+		Updates(map[string]interface{}{
+			"attempts":      gorm.Expr("attempts + 1"),
+			"next_retry_at": leaseUntil,
+		})
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *notificationRepository) DeliveryUpdate(ctx context.Context, m *model.NotificationDelivery) error {
+	return r.DB(ctx).Model(&model.NotificationDelivery{}).Where("id = ?", m.ID).
+		Select("status", "attempts", "next_retry_at", "last_error", "sent_at").
+		Updates(m).Error
+}
+
+func (r *notificationRepository) GetAuditConfig(ctx context.Context, id uint) (model.AuditConfig, error) {
+	m := model.AuditConfig{}
+	return m, r.DB(ctx).Where("id = ?", id).First(&m).Error
+}
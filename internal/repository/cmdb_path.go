@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"context"
+	"nunu-layout-admin/internal/model"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PathRepository interface {
+	GetRelationPath(ctx context.Context, pathID string) (model.RelationPath, error)
+	GetRelationPaths(ctx context.Context) ([]model.RelationPath, error)
+	RelationPathSave(ctx context.Context, m *model.RelationPath) error
+	RelationPathTouch(ctx context.Context, id uint, now time.Time) error
+	RelationPathsDelete(ctx context.Context, ids []uint) error
+
+	GetRelationCache(ctx context.Context, sourceID, targetID uint) (model.RelationCache, error)
+	GetRelationCaches(ctx context.Context) ([]model.RelationCache, error)
+	RelationCacheSave(ctx context.Context, m *model.RelationCache) error
+	RelationCacheTouch(ctx context.Context, id uint, now time.Time) error
+	RelationCachesDelete(ctx context.Context, ids []uint) error
+
+	// PathsDeleteAll 清空全部路径缓存
+	PathsDeleteAll(ctx context.Context) error
+	// PathsDeleteExpired 删除now之前过期的路径缓存，返回删除条数
+	PathsDeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
+
+func NewPathRepository(
+	repository *Repository,
+) PathRepository {
+	return &pathRepository{
+		Repository: repository,
+	}
+}
+
+type pathRepository struct {
+	*Repository
+}
+
+func (r *pathRepository) GetRelationPath(ctx context.Context, pathID string) (model.RelationPath, error) {
+	m := model.RelationPath{}
+	return m, r.DB(ctx).Where("path_id = ?", pathID).First(&m).Error
+}
+
+func (r *pathRepository) GetRelationPaths(ctx context.Context) ([]model.RelationPath, error) {
+	var list []model.RelationPath
+	return list, r.DB(ctx).Select("id", "path_id", "path_data").Order("id ASC").Find(&list).Error
+}
+
+// RelationPathSave 写入路径缓存，同一PathID的旧记录会被物理删除以避开唯一索引
+func (r *pathRepository) RelationPathSave(ctx context.Context, m *model.RelationPath) error {
+	if err := r.DB(ctx).Unscoped().Where("path_id = ?", m.PathID).Delete(&model.RelationPath{}).Error; err != nil {
+		return err
+	}
+	return r.DB(ctx).Create(m).Error
+}
+
+func (r *pathRepository) RelationPathTouch(ctx context.Context, id uint, now time.Time) error {
+	return r.DB(ctx).Model(&model.RelationPath{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"access_count": gorm.Expr("access_count + ?", 1),
+		"last_access":  now,
+	}).Error
+}
+
+func (r *pathRepository) RelationPathsDelete(ctx context.Context, ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return r.DB(ctx).Unscoped().Where("id IN ?", ids).Delete(&model.RelationPath{}).Error
+}
+
+func (r *pathRepository) GetRelationCache(ctx context.Context, sourceID, targetID uint) (model.RelationCache, error) {
+	m := model.RelationCache{}
+	return m, r.DB(ctx).Where("source_id = ? AND target_id = ?", sourceID, targetID).First(&m).Error
+}
+
+func (r *pathRepository) GetRelationCaches(ctx context.Context) ([]model.RelationCache, error) {
+	var list []model.RelationCache
+	return list, r.DB(ctx).Select("id", "source_id", "target_id", "path").Order("id ASC").Find(&list).Error
+}
+
+func (r *pathRepository) RelationCacheSave(ctx context.Context, m *model.RelationCache) error {
+	if err := r.DB(ctx).Unscoped().Where("source_id = ? AND target_id = ?", m.SourceID, m.TargetID).
+		Delete(&model.RelationCache{}).Error; err != nil {
+		return err
+	}
+	return r.DB(ctx).Omit(clause.Associations).Create(m).Error
+}
+
+func (r *pathRepository) RelationCacheTouch(ctx context.Context, id uint, now time.Time) error {
+	return r.DB(ctx).Model(&model.RelationCache{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"access_count": gorm.Expr("access_count + ?", 1),
+		"last_access":  now,
+	}).Error
+}
+
+func (r *pathRepository) RelationCachesDelete(ctx context.Context, ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return r.DB(ctx).Unscoped().Where("id IN ?", ids).Delete(&model.RelationCache{}).Error
+}
+
+func (r *pathRepository) PathsDeleteAll(ctx context.Context) error {
+	if err := r.DB(ctx).Unscoped().Where("1 = 1").Delete(&model.RelationPath{}).Error; err != nil {
+		return err
+	}
+	return r.DB(ctx).Unscoped().Where("1 = 1").Delete(&model.RelationCache{}).Error
+}
+
+func (r *pathRepository) PathsDeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	result := r.DB(ctx).Unscoped().Where("expires_at < ?", now).Delete(&model.RelationPath{})
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	total := result.RowsAffected
+	result = r.DB(ctx).Unscoped().Where("expires_at < ?", now).Delete(&model.RelationCache{})
+	if result.Error != nil {
+		return total, result.Error
+	}
+	return total + result.RowsAffected, nil
+}
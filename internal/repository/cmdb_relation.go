@@ -0,0 +1,281 @@
+package repository
+
+import (
+	"context"
+	v1 "nunu-layout-admin/api/v1"
+	"nunu-layout-admin/internal/model"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RelationObject 关系两端对象的统一描述，SubType为对象的细分类型(资源类型/应用类型/服务类型等)
+type RelationObject struct {
+	ObjectType string
+	ObjectID   string
+	Name       string
+	SubType    string
+}
+
+type RelationRepository interface {
+	GetUniversalRelations(ctx context.Context, req *v1.GetUniversalRelationsRequest) ([]model.UniversalRelation, int64, error)
+	GetUniversalRelation(ctx context.Context, relationID string) (model.UniversalRelation, error)
+	GetUniversalRelationsBySource(ctx context.Context, sourceType, sourceID string) ([]model.UniversalRelation, error)
+	GetActiveUniversalRelations(ctx context.Context) ([]model.UniversalRelation, error)
+	// GetUniversalRelationsCreatedBefore 返回before之前创建的通用关系(含已删除)
+	GetUniversalRelationsCreatedBefore(ctx context.Context, before time.Time) ([]model.UniversalRelation, error)
+	UniversalRelationCreate(ctx context.Context, m *model.UniversalRelation) error
+	UniversalRelationUpdate(ctx context.Context, m *model.UniversalRelation) error
+	// UniversalRelationUpdateAll 覆盖端点、生效时间等全部字段，用于从快照恢复
+	UniversalRelationUpdateAll(ctx context.Context, m *model.UniversalRelation) error
+	UniversalRelationDelete(ctx context.Context, id uint) error
+
+	GetResourceRelations(ctx context.Context, resourceID uint) ([]model.ResourceRelation, error)
+	GetResourceRelation(ctx context.Context, id uint) (model.ResourceRelation, error)
+	GetResourceRelationsBySource(ctx context.Context, sourceID uint) ([]model.ResourceRelation, error)
+	GetAllResourceRelations(ctx context.Context) ([]model.ResourceRelation, error)
+	ResourceRelationCreate(ctx context.Context, m *model.ResourceRelation) error
+	ResourceRelationUpdate(ctx context.Context, m *model.ResourceRelation) error
+	ResourceRelationDelete(ctx context.Context, id uint) error
+
+	GetGraphRelations(ctx context.Context, req *v1.GraphExpandRequest, objects map[string][]string, now time.Time) ([]model.UniversalRelation, error)
+
+	GetObject(ctx context.Context, objectType, objectID string) (RelationObject, error)
+	// LockObject 在当前事务中以SELECT ... FOR UPDATE锁定对象所在的行，没有对应表的对象类型直接返回
+	LockObject(ctx context.Context, objectType, objectID string) error
+	GetObjects(ctx context.Context, objectType string) ([]RelationObject, error)
+	GetObjectsByIDs(ctx context.Context, objectType string, objectIDs []string) ([]RelationObject, error)
+}
+
+func NewRelationRepository(
+	repository *Repository,
+) RelationRepository {
+	return &relationRepository{
+		Repository: repository,
+	}
+}
+
+type relationRepository struct {
+	*Repository
+}
+
+func (r *relationRepository) GetUniversalRelations(ctx context.Context, req *v1.GetUniversalRelationsRequest) ([]model.UniversalRelation, int64, error) {
+	var list []model.UniversalRelation
+	var total int64
+	scope := r.DB(ctx).Model(&model.UniversalRelation{})
+	if req.SourceType != "" {
+		scope = scope.Where("source_type = ?", req.SourceType)
+	}
+	if req.SourceID != "" {
+		scope = scope.Where("source_id = ?", req.SourceID)
+	}
+	if req.TargetType != "" {
+		scope = scope.Where("target_type = ?", req.TargetType)
+	}
+	if req.TargetID != "" {
+		scope = scope.Where("target_id = ?", req.TargetID)
+	}
+	if req.RelationType != "" {
+		scope = scope.Where("relation_type = ?", req.RelationType)
+	}
+	if err := scope.Count(&total).Error; err != nil {
+		return nil, total, err
+	}
+	if err := scope.Offset((req.Page - 1) * req.PageSize).Limit(req.PageSize).Order("id DESC").Find(&list).Error; err != nil {
+		return nil, total, err
+	}
+	return list, total, nil
+}
+
+func (r *relationRepository) GetUniversalRelation(ctx context.Context, relationID string) (model.UniversalRelation, error) {
+	m := model.UniversalRelation{}
+	return m, r.DB(ctx).Where("relation_id = ?", relationID).First(&m).Error
+}
+
+func (r *relationRepository) GetUniversalRelationsCreatedBefore(ctx context.Context, before time.Time) ([]model.UniversalRelation, error) {
+	var list []model.UniversalRelation
+	return list, r.DB(ctx).Unscoped().Where("created_at <= ?", before).Order("id ASC").Find(&list).Error
+}
+
+func (r *relationRepository) GetUniversalRelationsBySource(ctx context.Context, sourceType, sourceID string) ([]model.UniversalRelation, error) {
+	var list []model.UniversalRelation
+	return list, r.DB(ctx).Where("source_type = ? AND source_id = ? AND is_active = ?", sourceType, sourceID, true).
+		Find(&list).Error
+}
+
+func (r *relationRepository) GetActiveUniversalRelations(ctx context.Context) ([]model.UniversalRelation, error) {
+	var list []model.UniversalRelation
+	return list, r.DB(ctx).Where("is_active = ?", true).Order("id ASC").Find(&list).Error
+}
+
+func (r *relationRepository) UniversalRelationCreate(ctx context.Context, m *model.UniversalRelation) error {
+	return r.DB(ctx).Omit(clause.Associations).Create(m).Error
+}
+
+func (r *relationRepository) UniversalRelationUpdate(ctx context.Context, m *model.UniversalRelation) error {
+	return r.DB(ctx).Model(&model.UniversalRelation{}).Where("id = ?", m.ID).
+		Select("relation_type", "direction", "weight", "priority", "properties", "status", "description").
+		Updates(m).Error
+}
+
+func (r *relationRepository) UniversalRelationUpdateAll(ctx context.Context, m *model.UniversalRelation) error {
+	return r.DB(ctx).Model(&model.UniversalRelation{}).Where("id = ?", m.ID).
+		Select("source_type", "source_id", "source_name", "target_type", "target_id", "target_name", "relation_type",
+			"direction", "weight", "priority", "properties", "effective_time", "expire_time", "environment", "tenant_id",
+			"status", "is_active", "description").
+		Updates(m).Error
+}
+
+func (r *relationRepository) UniversalRelationDelete(ctx context.Context, id uint) error {
+	if err := r.DB(ctx).Where("relation_id = ?", id).Delete(&model.UniversalRelationTag{}).Error; err != nil {
+		return err
+	}
+	return r.DB(ctx).Where("id = ?", id).Delete(&model.UniversalRelation{}).Error
+}
+
+func (r *relationRepository) GetResourceRelations(ctx context.Context, resourceID uint) ([]model.ResourceRelation, error) {
+	var list []model.ResourceRelation
+	return list, r.DB(ctx).Preload("Source").Preload("Target").
+		Where("source_id = ? OR target_id = ?", resourceID, resourceID).Order("id ASC").Find(&list).Error
+}
+
+func (r *relationRepository) GetResourceRelation(ctx context.Context, id uint) (model.ResourceRelation, error) {
+	m := model.ResourceRelation{}
+	return m, r.DB(ctx).Preload("Source").Preload("Target").Where("id = ?", id).First(&m).Error
+}
+
+func (r *relationRepository) GetResourceRelationsBySource(ctx context.Context, sourceID uint) ([]model.ResourceRelation, error) {
+	var list []model.ResourceRelation
+	return list, r.DB(ctx).Preload("Target").Where("source_id = ?", sourceID).Find(&list).Error
+}
+
+func (r *relationRepository) GetAllResourceRelations(ctx context.Context) ([]model.ResourceRelation, error) {
+	var list []model.ResourceRelation
+	return list, r.DB(ctx).Preload("Source").Preload("Target").Order("id ASC").Find(&list).Error
+}
+
+func (r *relationRepository) ResourceRelationCreate(ctx context.Context, m *model.ResourceRelation) error {
+	return r.DB(ctx).Omit(clause.Associations).Create(m).Error
+}
+
+func (r *relationRepository) ResourceRelationUpdate(ctx context.Context, m *model.ResourceRelation) error {
+	return r.DB(ctx).Model(&model.ResourceRelation{}).Where("id = ?", m.ID).
+		Select("relation_type", "direction", "weight", "properties", "description").
+		Updates(m).Error
+}
+
+func (r *relationRepository) ResourceRelationDelete(ctx context.Context, id uint) error {
+	return r.DB(ctx).Where("id = ?", id).Delete(&model.ResourceRelation{}).Error
+}
+
+// GetGraphRelations 查询与objects(对象类型 -> 对象ID列表)相邻且在now时刻生效的通用关系
+func (r *relationRepository) GetGraphRelations(ctx context.Context, req *v1.GraphExpandRequest, objects map[string][]string, now time.Time) ([]model.UniversalRelation, error) {
+	var list []model.UniversalRelation
+	db := r.DB(ctx)
+	endpoints := db.Where("1 = 0")
+	for objectType, ids := range objects {
+		if req.Direction != "in" {
+			endpoints = endpoints.Or("source_type = ? AND source_id IN ?", objectType, ids)
+		}
+		if req.Direction != "out" {
+			endpoints = endpoints.Or("target_type = ? AND target_id IN ?", objectType, ids)
+		}
+	}
+	scope := db.Model(&model.UniversalRelation{}).Where(endpoints).
+		Where("effective_time IS NULL OR effective_time <= ?", now).
+		Where("expire_time IS NULL OR expire_time > ?", now)
+	if len(req.RelationTypes) > 0 {
+		scope = scope.Where("relation_type IN ?", req.RelationTypes)
+	}
+	if req.Environment != "" {
+		scope = scope.Where("environment = ?", req.Environment)
+	}
+	if req.TenantID != "" {
+		scope = scope.Where("tenant_id = ?", req.TenantID)
+	}
+	if !req.IncludeInactive {
+		scope = scope.Where("is_active = ?", true)
+	}
+	return list, scope.Order("priority DESC, id ASC").Find(&list).Error
+}
+
+// GetObject 按对象类型查找关系端点，项目/租户等没有对应表的对象类型直接返回
+func (r *relationRepository) GetObject(ctx context.Context, objectType, objectID string) (RelationObject, error) {
+	obj := RelationObject{ObjectType: objectType, ObjectID: objectID}
+	scope, idColumn, ok := r.objectScope(ctx, objectType)
+	if !ok {
+		return obj, nil
+	}
+	var found []RelationObject
+	if err := scope.Where(idColumn+" = ?", objectID).Limit(1).Find(&found).Error; err != nil {
+		return obj, err
+	}
+	if len(found) == 0 {
+		return obj, gorm.ErrRecordNotFound
+	}
+	found[0].ObjectType = objectType
+	return found[0], nil
+}
+
+func (r *relationRepository) LockObject(ctx context.Context, objectType, objectID string) error {
+	scope, idColumn, ok := r.objectScope(ctx, objectType)
+	if !ok {
+		return nil
+	}
+	// 应用实例的查询带有LEFT JOIN，只锁对象自身的表
+	var found []RelationObject
+	return scope.Clauses(clause.Locking{Strength: "UPDATE", Table: clause.Table{Name: clause.CurrentTable}}).
+		Where(idColumn+" = ?", objectID).Limit(1).Find(&found).Error
+}
+
+func (r *relationRepository) GetObjects(ctx context.Context, objectType string) ([]RelationObject, error) {
+	scope, idColumn, ok := r.objectScope(ctx, objectType)
+	if !ok {
+		return nil, nil
+	}
+	var list []RelationObject
+	if err := scope.Order(idColumn + " ASC").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	for i := range list {
+		list[i].ObjectType = objectType
+	}
+	return list, nil
+}
+
+func (r *relationRepository) GetObjectsByIDs(ctx context.Context, objectType string, objectIDs []string) ([]RelationObject, error) {
+	scope, idColumn, ok := r.objectScope(ctx, objectType)
+	if !ok || len(objectIDs) == 0 {
+		return nil, nil
+	}
+	var list []RelationObject
+	if err := scope.Where(idColumn+" IN ?", objectIDs).Find(&list).Error; err != nil {
+		return nil, err
+	}
+	for i := range list {
+		list[i].ObjectType = objectType
+	}
+	return list, nil
+}
+
+// objectScope 返回把各对象表映射为(object_id, name, sub_type)的查询，以及用于过滤的对象ID列
+func (r *relationRepository) objectScope(ctx context.Context, objectType string) (*gorm.DB, string, bool) {
+	db := r.DB(ctx)
+	switch objectType {
+	case model.ObjectTypeResource:
+		return db.Model(&model.Resource{}).Select("resource_id AS object_id, name, type AS sub_type"), "resource_id", true
+	case model.ObjectTypeService:
+		return db.Model(&model.Service{}).Select("service_id AS object_id, name, type AS sub_type"), "service_id", true
+	case model.ObjectTypeBusiness:
+		return db.Model(&model.Business{}).Select("business_id AS object_id, name, type AS sub_type"), "business_id", true
+	case model.ObjectTypeConfiguration:
+		return db.Model(&model.Configuration{}).Select("config_id AS object_id, name, config_type AS sub_type"), "config_id", true
+	case model.ObjectTypeApplication:
+		return db.Table("cmdb_applications").
+			Select("cmdb_applications.app_id AS object_id, cmdb_applications.name, cmdb_application_types.type_name AS sub_type").
+			Joins("LEFT JOIN cmdb_application_types ON cmdb_application_types.id = cmdb_applications.type_id").
+			Where("cmdb_applications.deleted_at IS NULL"), "cmdb_applications.app_id", true
+	}
+	return nil, "", false
+}
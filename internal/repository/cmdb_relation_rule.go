@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+	"nunu-layout-admin/internal/model"
+)
+
+type RelationRuleRepository interface {
+	GetRelationRules(ctx context.Context, sourceType, targetType string) ([]model.RelationRule, error)
+	GetActiveRelationRules(ctx context.Context) ([]model.RelationRule, error)
+	GetRelationRule(ctx context.Context, ruleID string) (model.RelationRule, error)
+	RelationRuleCreate(ctx context.Context, m *model.RelationRule) error
+	RelationRuleUpdate(ctx context.Context, m *model.RelationRule) error
+	RelationRuleDelete(ctx context.Context, id uint) error
+}
+
+func NewRelationRuleRepository(
+	repository *Repository,
+) RelationRuleRepository {
+	return &relationRuleRepository{
+		Repository: repository,
+	}
+}
+
+type relationRuleRepository struct {
+	*Repository
+}
+
+func (r *relationRuleRepository) GetRelationRules(ctx context.Context, sourceType, targetType string) ([]model.RelationRule, error) {
+	var list []model.RelationRule
+	scope := r.DB(ctx).Model(&model.RelationRule{})
+	if sourceType != "" {
+		scope = scope.Where("source_type = ?", sourceType)
+	}
+	if targetType != "" {
+		scope = scope.Where("target_type = ?", targetType)
+	}
+	return list, scope.Order("priority DESC, id ASC").Find(&list).Error
+}
+
+func (r *relationRuleRepository) GetActiveRelationRules(ctx context.Context) ([]model.RelationRule, error) {
+	var list []model.RelationRule
+	return list, r.DB(ctx).Where("is_active = ?", true).Order("priority DESC, id ASC").Find(&list).Error
+}
+
+func (r *relationRuleRepository) GetRelationRule(ctx context.Context, ruleID string) (model.RelationRule, error) {
+	m := model.RelationRule{}
+	return m, r.DB(ctx).Where("rule_id = ?", ruleID).First(&m).Error
+}
+
+func (r *relationRuleRepository) RelationRuleCreate(ctx context.Context, m *model.RelationRule) error {
+	return r.DB(ctx).Create(m).Error
+}
+
+func (r *relationRuleRepository) RelationRuleUpdate(ctx context.Context, m *model.RelationRule) error {
+	return r.DB(ctx).Model(&model.RelationRule{}).Where("id = ?", m.ID).
+		Select("rule_name", "source_type", "target_type", "allowed_relations", "is_required", "max_connections",
+			"min_connections", "validation_rules", "priority", "is_active", "description").
+		Updates(m).Error
+}
+
+func (r *relationRuleRepository) RelationRuleDelete(ctx context.Context, id uint) error {
+	return r.DB(ctx).Where("id = ?", id).Delete(&model.RelationRule{}).Error
+}
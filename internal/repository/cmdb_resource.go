@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"context"
+	v1 "nunu-layout-admin/api/v1"
+	"nunu-layout-admin/internal/model"
+	"time"
+
+	"gorm.io/gorm/clause"
+)
+
+type ResourceRepository interface {
+	GetResources(ctx context.Context, req *v1.GetResourcesRequest) ([]model.Resource, int64, error)
+	GetResource(ctx context.Context, resourceID string) (model.Resource, error)
+	// ResourceIDExists 判断资源标识是否已被使用，包含已删除的资源：软删除的记录仍占用resource_id唯一索引
+	ResourceIDExists(ctx context.Context, resourceID string) (bool, error)
+	// GetResourcesCreatedBefore 返回before之前创建的资源(含已删除)，resourceIDs为空时不按标识过滤
+	GetResourcesCreatedBefore(ctx context.Context, before time.Time, resourceIDs []string) ([]model.Resource, error)
+	GetResourcesByType(ctx context.Context, typeName string) ([]model.Resource, error)
+	ResourceCreate(ctx context.Context, m *model.Resource) error
+	ResourceUpdate(ctx context.Context, m *model.Resource) error
+	ResourceAttributesUpdate(ctx context.Context, id uint, attributes model.JSONMap) error
+	ResourceDelete(ctx context.Context, id uint) error
+	ReplaceResourceTags(ctx context.Context, id uint, tags []model.ResourceTag) error
+}
+
+func NewResourceRepository(
+	repository *Repository,
+) ResourceRepository {
+	return &resourceRepository{
+		Repository: repository,
+	}
+}
+
+type resourceRepository struct {
+	*Repository
+}
+
+func (r *resourceRepository) GetResources(ctx context.Context, req *v1.GetResourcesRequest) ([]model.Resource, int64, error) {
+	var list []model.Resource
+	var total int64
+	scope := r.DB(ctx).Model(&model.Resource{})
+	if req.Name != "" {
+		scope = scope.Where("name LIKE ?", "%"+req.Name+"%")
+	}
+	if req.Type != "" {
+		scope = scope.Where("type = ?", req.Type)
+	}
+	if req.Status != "" {
+		scope = scope.Where("status = ?", req.Status)
+	}
+	if req.Provider != "" {
+		scope = scope.Where("provider = ?", req.Provider)
+	}
+	if req.Region != "" {
+		scope = scope.Where("region = ?", req.Region)
+	}
+	if req.Zone != "" {
+		scope = scope.Where("zone = ?", req.Zone)
+	}
+	if req.TenantID != "" {
+		scope = scope.Where("tenant_id = ?", req.TenantID)
+	}
+	if req.BusinessID != "" {
+		scope = scope.Where("business_id = ?", req.BusinessID)
+	}
+	if req.Environment != "" {
+		scope = scope.Where("environment = ?", req.Environment)
+	}
+	if err := scope.Count(&total).Error; err != nil {
+		return nil, total, err
+	}
+	if err := scope.Preload("Tags").Offset((req.Page - 1) * req.PageSize).Limit(req.PageSize).Order("id DESC").Find(&list).Error; err != nil {
+		return nil, total, err
+	}
+	return list, total, nil
+}
+
+func (r *resourceRepository) GetResource(ctx context.Context, resourceID string) (model.Resource, error) {
+	m := model.Resource{}
+	return m, r.DB(ctx).Preload("Tags").Where("resource_id = ?", resourceID).First(&m).Error
+}
+
+func (r *resourceRepository) ResourceIDExists(ctx context.Context, resourceID string) (bool, error) {
+	var count int64
+	err := r.DB(ctx).Unscoped().Model(&model.Resource{}).Where("resource_id = ?", resourceID).Count(&count).Error
+	return count > 0, err
+}
+
+func (r *resourceRepository) GetResourcesCreatedBefore(ctx context.Context, before time.Time, resourceIDs []string) ([]model.Resource, error) {
+	var list []model.Resource
+	scope := r.DB(ctx).Unscoped().Preload("Tags").Where("created_at <= ?", before)
+	if len(resourceIDs) > 0 {
+		scope = scope.Where("resource_id IN ?", resourceIDs)
+	}
+	return list, scope.Order("id ASC").Find(&list).Error
+}
+
+func (r *resourceRepository) GetResourcesByType(ctx context.Context, typeName string) ([]model.Resource, error) {
+	var list []model.Resource
+	return list, r.DB(ctx).Preload("Tags").Where("type = ?", typeName).Order("id ASC").Find(&list).Error
+}
+
+func (r *resourceRepository) ResourceCreate(ctx context.Context, m *model.Resource) error {
+	return r.DB(ctx).Create(m).Error
+}
+
+func (r *resourceRepository) ResourceUpdate(ctx context.Context, m *model.Resource) error {
+	// 显式指定列，允许将可选字段更新为空值
+	return r.DB(ctx).Model(&model.Resource{}).Where("id = ?", m.ID).
+		Select("name", "type", "status", "provider", "region", "zone", "tenant_id", "business_id",
+			"environment", "attributes", "description", "last_sync_time").
+		Updates(m).Error
+}
+
+func (r *resourceRepository) ResourceAttributesUpdate(ctx context.Context, id uint, attributes model.JSONMap) error {
+	return r.DB(ctx).Model(&model.Resource{}).Where("id = ?", id).Update("attributes", attributes).Error
+}
+
+func (r *resourceRepository) ResourceDelete(ctx context.Context, id uint) error {
+	if err := r.DB(ctx).Where("resource_id = ?", id).Delete(&model.ResourceTag{}).Error; err != nil {
+		return err
+	}
+	return r.DB(ctx).Where("id = ?", id).Delete(&model.Resource{}).Error
+}
+
+func (r *resourceRepository) ReplaceResourceTags(ctx context.Context, id uint, tags []model.ResourceTag) error {
+	if err := r.DB(ctx).Where("resource_id = ?", id).Delete(&model.ResourceTag{}).Error; err != nil {
+		return err
+	}
+	if len(tags) == 0 {
+		return nil
+	}
+	for i := range tags {
+		tags[i].ResourceID = id
+	}
+	return r.DB(ctx).Omit(clause.Associations).Create(&tags).Error
+}
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+	"nunu-layout-admin/internal/model"
+)
+
+type ResourceTypeRepository interface {
+	GetResourceTypes(ctx context.Context, category string, includeInactive bool) ([]model.ResourceType, error)
+	GetResourceType(ctx context.Context, typeName string) (model.ResourceType, error)
+	ResourceTypeCreate(ctx context.Context, m *model.ResourceType) error
+	ResourceTypeUpdate(ctx context.Context, m *model.ResourceType) error
+	UpdateResourceTypeStatus(ctx context.Context, typeName string, isActive bool) error
+	GetResourceTypeSchemas(ctx context.Context, typeName string) ([]model.ResourceTypeSchema, error)
+	ResourceTypeSchemaCreate(ctx context.Context, m *model.ResourceTypeSchema) error
+}
+
+func NewResourceTypeRepository(
+	repository *Repository,
+) ResourceTypeRepository {
+	return &resourceTypeRepository{
+		Repository: repository,
+	}
+}
+
+type resourceTypeRepository struct {
+	*Repository
+}
+
+func (r *resourceTypeRepository) GetResourceTypes(ctx context.Context, category string, includeInactive bool) ([]model.ResourceType, error) {
+	var list []model.ResourceType
+	scope := r.DB(ctx).Model(&model.ResourceType{})
+	if category != "" {
+		scope = scope.Where("category = ?", category)
+	}
+	if !includeInactive {
+		scope = scope.Where("is_active = ?", true)
+	}
+	return list, scope.Order("category ASC, type_name ASC").Find(&list).Error
+}
+
+func (r *resourceTypeRepository) GetResourceType(ctx context.Context, typeName string) (model.ResourceType, error) {
+	m := model.ResourceType{}
+	return m, r.DB(ctx).Where("type_name = ?", typeName).First(&m).Error
+}
+
+func (r *resourceTypeRepository) ResourceTypeCreate(ctx context.Context, m *model.ResourceType) error {
+	return r.DB(ctx).Create(m).Error
+}
+
+func (r *resourceTypeRepository) ResourceTypeUpdate(ctx context.Context, m *model.ResourceType) error {
+	return r.DB(ctx).Model(&model.ResourceType{}).Where("id = ?", m.ID).
+		Select("display_name", "category", "icon", "color", "attribute_schema", "schema_version",
+			"allowed_relations", "description").
+		Updates(m).Error
+}
+
+func (r *resourceTypeRepository) UpdateResourceTypeStatus(ctx context.Context, typeName string, isActive bool) error {
+	return r.DB(ctx).Model(&model.ResourceType{}).Where("type_name = ?", typeName).Update("is_active", isActive).Error
+}
+
+func (r *resourceTypeRepository) GetResourceTypeSchemas(ctx context.Context, typeName string) ([]model.ResourceTypeSchema, error) {
+	var list []model.ResourceTypeSchema
+	return list, r.DB(ctx).Where("type_name = ?", typeName).Order("version DESC").Find(&list).Error
+}
+
+func (r *resourceTypeRepository) ResourceTypeSchemaCreate(ctx context.Context, m *model.ResourceTypeSchema) error {
+	return r.DB(ctx).Create(m).Error
+}
@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"context"
+	"net/http"
+	"nunu-layout-admin/internal/model"
+	"nunu-layout-admin/pkg/envelope"
+	"strconv"
+
+	"gorm.io/gorm"
+)
+
+type SensitiveRepository interface {
+	// CanReveal 用户是否有查看敏感字段明文的权限，超管始终拥有该权限
+	CanReveal(ctx context.Context, uid uint) (bool, error)
+	GetConfiguration(ctx context.Context, configID string) (model.Configuration, error)
+
+	// 以下按ID顺序分批读取包含已删除且仍需轮换的记录，供主密钥轮换使用
+	GetResourcesAfter(ctx context.Context, filter SensitiveRotateFilter, afterID uint, limit int) ([]model.Resource, error)
+	GetConfigurationsAfter(ctx context.Context, filter SensitiveRotateFilter, afterID uint, limit int) ([]model.Configuration, error)
+	GetConfigRevisionsAfter(ctx context.Context, filter SensitiveRotateFilter, afterID uint, limit int) ([]model.ConfigurationRevision, error)
+	GetConfigRendersAfter(ctx context.Context, filter SensitiveRotateFilter, afterID uint, limit int) ([]model.ConfigurationRender, error)
+	GetHistoriesAfter(ctx context.Context, filter SensitiveRotateFilter, objectType string, afterID uint, limit int) ([]HistoryRecord, error)
+	// GetConfigurationsByIDs 读取配置版本和渲染记录所属的配置，包含已删除的配置
+	GetConfigurationsByIDs(ctx context.Context, ids []uint) ([]model.Configuration, error)
+	ResourceAttributesUpdate(ctx context.Context, id uint, attributes model.JSONMap) error
+	ConfigurationDataUpdate(ctx context.Context, id uint, data model.JSONMap) error
+	ConfigRevisionDataUpdate(ctx context.Context, id uint, data model.JSONMap) error
+	ConfigRenderVariablesUpdate(ctx context.Context, id uint, variables model.JSONMap) error
+	HistoryDataUpdate(ctx context.Context, objectType string, m *HistoryRecord) error
+}
+
+// SensitiveRotateFilter 主密钥轮换时筛选仍需处理的记录：不含当前主密钥的密文(包括尚未加密的记录)，
+// 或者仍含其他主密钥的密文。已经完成轮换的记录不会再被读取，轮换中断后可以直接重新执行
+type SensitiveRotateFilter struct {
+	CurrentKey  string
+	RetiredKeys []string
+}
+
+// scope 在column(JSON字段的文本表示)上按密文中的主密钥ID筛选
+func (f SensitiveRotateFilter) scope(db *gorm.DB, column string) *gorm.DB {
+	query := column + " NOT LIKE ?"
+	args := []interface{}{sealedKeyPattern(f.CurrentKey)}
+	for _, id := range f.RetiredKeys {
+		query += " OR " + column + " LIKE ?"
+		args = append(args, sealedKeyPattern(id))
+	}
+	return db.Where("("+query+")", args...)
+}
+
+// sealedKeyPattern 匹配由主密钥id加密的密文
+func sealedKeyPattern(id string) string {
+	return "%" + envelope.Prefix + id + ":%"
+}
+
+func NewSensitiveRepository(
+	repository *Repository,
+) SensitiveRepository {
+	return &sensitiveRepository{
+		Repository: repository,
+	}
+}
+
+type sensitiveRepository struct {
+	*Repository
+}
+
+func (r *sensitiveRepository) CanReveal(ctx context.Context, uid uint) (bool, error) {
+	if uid == 0 {
+		return false, nil
+	}
+	sub := strconv.FormatUint(uint64(uid), 10)
+	if sub == model.AdminUserID {
+		return true, nil
+	}
+	return r.e.Enforce(sub, model.ApiResourcePrefix+model.SensitiveRevealApi, http.MethodGet)
+}
+
+func (r *sensitiveRepository) GetConfiguration(ctx context.Context, configID string) (model.Configuration, error) {
+	m := model.Configuration{}
+	return m, r.DB(ctx).Where("config_id = ?", configID).First(&m).Error
+}
+
+func (r *sensitiveRepository) GetResourcesAfter(ctx context.Context, filter SensitiveRotateFilter, afterID uint, limit int) ([]model.Resource, error) {
+	var list []model.Resource
+	return list, filter.scope(r.DB(ctx).Unscoped(), "CAST(attributes AS TEXT)").
+		Where("id > ?", afterID).Order("id ASC").Limit(limit).Find(&list).Error
+}
+
+func (r *sensitiveRepository) GetConfigurationsAfter(ctx context.Context, filter SensitiveRotateFilter, afterID uint, limit int) ([]model.Configuration, error) {
+	var list []model.Configuration
+	return list, filter.scope(r.DB(ctx).Unscoped(), "CAST(config_data AS TEXT)").
+		Where("id > ?", afterID).Order("id ASC").Limit(limit).Find(&list).Error
+}
+
+func (r *sensitiveRepository) GetConfigRevisionsAfter(ctx context.Context, filter SensitiveRotateFilter, afterID uint, limit int) ([]model.ConfigurationRevision, error) {
+	var list []model.ConfigurationRevision
+	return list, filter.scope(r.DB(ctx).Unscoped(), "CAST(config_data AS TEXT)").
+		Where("id > ?", afterID).Order("id ASC").Limit(limit).Find(&list).Error
+}
+
+func (r *sensitiveRepository) GetConfigRendersAfter(ctx context.Context, filter SensitiveRotateFilter, afterID uint, limit int) ([]model.ConfigurationRender, error) {
+	var list []model.ConfigurationRender
+	return list, filter.scope(r.DB(ctx).Unscoped(), "CAST(variables AS TEXT)").
+		Where("id > ?", afterID).Order("id ASC").Limit(limit).Find(&list).Error
+}
+
+func (r *sensitiveRepository) GetHistoriesAfter(ctx context.Context, filter SensitiveRotateFilter, objectType string, afterID uint, limit int) ([]HistoryRecord, error) {
+	var list []HistoryRecord
+	table, _, ok := historyTable(objectType)
+	if !ok {
+		return list, nil
+	}
+	// 前后快照和字段差异作为一个整体筛选
+	column := "(COALESCE(CAST(before_data AS TEXT), '') || COALESCE(CAST(after_data AS TEXT), '') || " +
+		"COALESCE(CAST(changed_fields AS TEXT), ''))"
+	return list, filter.scope(r.DB(ctx).Unscoped().Model(table), column).Select(historyColumns(objectType)).
+		Where("id > ?", afterID).Order("id ASC").Limit(limit).Find(&list).Error
+}
+
+func (r *sensitiveRepository) GetConfigurationsByIDs(ctx context.Context, ids []uint) ([]model.Configuration, error) {
+	var list []model.Configuration
+	if len(ids) == 0 {
+		return list, nil
+	}
+	return list, r.DB(ctx).Unscoped().Where("id IN ?", ids).Find(&list).Error
+}
+
+func (r *sensitiveRepository) ResourceAttributesUpdate(ctx context.Context, id uint, attributes model.JSONMap) error {
+	return r.DB(ctx).Unscoped().Model(&model.Resource{}).Where("id = ?", id).
+		UpdateColumn("attributes", attributes).Error
+}
+
+func (r *sensitiveRepository) ConfigurationDataUpdate(ctx context.Context, id uint, data model.JSONMap) error {
+	return r.DB(ctx).Unscoped().Model(&model.Configuration{}).Where("id = ?", id).
+		UpdateColumn("config_data", data).Error
+}
+
+func (r *sensitiveRepository) ConfigRevisionDataUpdate(ctx context.Context, id uint, data model.JSONMap) error {
+	return r.DB(ctx).Unscoped().Model(&model.ConfigurationRevision{}).Where("id = ?", id).
+		UpdateColumn("config_data", data).Error
+}
+
+func (r *sensitiveRepository) ConfigRenderVariablesUpdate(ctx context.Context, id uint, variables model.JSONMap) error {
+	return r.DB(ctx).Unscoped().Model(&model.ConfigurationRender{}).Where("id = ?", id).
+		UpdateColumn("variables", variables).Error
+}
+
+func (r *sensitiveRepository) HistoryDataUpdate(ctx context.Context, objectType string, m *HistoryRecord) error {
+	table, _, ok := historyTable(objectType)
+	if !ok {
+		return gorm.ErrRecordNotFound
+	}
+	return r.DB(ctx).Unscoped().Model(table).Where("id = ?", m.ID).UpdateColumns(map[string]interface{}{
+		"before_data":    m.BeforeData,
+		"after_data":     m.AfterData,
+		"changed_fields": m.ChangedFields,
+	}).Error
+}
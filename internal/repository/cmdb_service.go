@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+	"nunu-layout-admin/internal/model"
+
+	"gorm.io/gorm/clause"
+)
+
+type ServiceRepository interface {
+	GetService(ctx context.Context, serviceID string) (model.Service, error)
+	ServiceCreate(ctx context.Context, m *model.Service) error
+	ServiceUpdate(ctx context.Context, m *model.Service) error
+	ServiceDelete(ctx context.Context, id uint) error
+	ReplaceServiceTags(ctx context.Context, id uint, tags []model.ServiceTag) error
+}
+
+func NewServiceRepository(
+	repository *Repository,
+) ServiceRepository {
+	return &serviceRepository{
+		Repository: repository,
+	}
+}
+
+type serviceRepository struct {
+	*Repository
+}
+
+func (r *serviceRepository) GetService(ctx context.Context, serviceID string) (model.Service, error) {
+	m := model.Service{}
+	return m, r.DB(ctx).Preload("Tags").Where("service_id = ?", serviceID).First(&m).Error
+}
+
+func (r *serviceRepository) ServiceCreate(ctx context.Context, m *model.Service) error {
+	return r.DB(ctx).Omit(clause.Associations).Create(m).Error
+}
+
+func (r *serviceRepository) ServiceUpdate(ctx context.Context, m *model.Service) error {
+	return r.DB(ctx).Model(&model.Service{}).Where("id = ?", m.ID).
+		Select("name", "type", "status", "tenant_id", "business_id", "environment", "configuration",
+			"endpoints", "health_status", "sla_target", "description").
+		Updates(m).Error
+}
+
+func (r *serviceRepository) ServiceDelete(ctx context.Context, id uint) error {
+	if err := r.DB(ctx).Where("service_id = ?", id).Delete(&model.ServiceTag{}).Error; err != nil {
+		return err
+	}
+	return r.DB(ctx).Where("id = ?", id).Delete(&model.Service{}).Error
+}
+
+func (r *serviceRepository) ReplaceServiceTags(ctx context.Context, id uint, tags []model.ServiceTag) error {
+	if err := r.DB(ctx).Where("service_id = ?", id).Delete(&model.ServiceTag{}).Error; err != nil {
+		return err
+	}
+	if len(tags) == 0 {
+		return nil
+	}
+	for i := range tags {
+		tags[i].ServiceID = id
+	}
+	return r.DB(ctx).Omit(clause.Associations).Create(&tags).Error
+}
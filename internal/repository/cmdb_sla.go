@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"nunu-layout-admin/internal/model"
+	"time"
+)
+
+// SLA统计在DataStatistics中的维度前缀，维度为前缀加服务唯一标识
+const SLADimensionPrefix = "sla:"
+
+type SLARepository interface {
+	GetSLAServices(ctx context.Context) ([]model.Service, error)
+	// GetLastHealthTransitions 返回每个服务在asOf及之前的最后一次健康状态变化
+	GetLastHealthTransitions(ctx context.Context, asOf time.Time) ([]model.ServiceHealthTransition, error)
+	// GetHealthTransitions 返回全部服务在(since, until]之间的健康状态变化，按写入顺序排列
+	GetHealthTransitions(ctx context.Context, since, until time.Time) ([]model.ServiceHealthTransition, error)
+	// GetSLAStatistics 获取指定周期窗口内各服务最新的SLA统计，serviceID为空时返回全部服务
+	GetSLAStatistics(ctx context.Context, period, date, serviceID string) ([]model.DataStatistics, error)
+	StatisticsCreate(ctx context.Context, m *model.DataStatistics) error
+	StatisticsUpdate(ctx context.Context, m *model.DataStatistics) error
+}
+
+func NewSLARepository(
+	repository *Repository,
+) SLARepository {
+	return &slaRepository{
+		Repository: repository,
+	}
+}
+
+type slaRepository struct {
+	*Repository
+}
+
+func (r *slaRepository) GetSLAServices(ctx context.Context) ([]model.Service, error) {
+	var list []model.Service
+	return list, r.DB(ctx).Order("id").Find(&list).Error
+}
+
+func (r *slaRepository) GetLastHealthTransitions(ctx context.Context, asOf time.Time) ([]model.ServiceHealthTransition, error) {
+	var list []model.ServiceHealthTransition
+	sub := r.DB(ctx).Model(&model.ServiceHealthTransition{}).Select("MAX(id)").Where("change_time <= ?", asOf).Group("service_uuid")
+	return list, r.DB(ctx).Where("id IN (?)", sub).Find(&list).Error
+}
+
+func (r *slaRepository) GetHealthTransitions(ctx context.Context, since, until time.Time) ([]model.ServiceHealthTransition, error) {
+	var list []model.ServiceHealthTransition
+	return list, r.DB(ctx).Where("change_time > ? AND change_time <= ?", since, until).Order("id ASC").Find(&list).Error
+}
+
+func (r *slaRepository) GetSLAStatistics(ctx context.Context, period, date, serviceID string) ([]model.DataStatistics, error) {
+	var list []model.DataStatistics
+	db := r.DB(ctx).Where("stat_type = ? AND period = ? AND date = ? AND is_latest = ?", model.StatTypePerformance, period, date, true)
+	if serviceID != "" {
+		db = db.Where("dimension = ?", SLADimensionPrefix+serviceID)
+	} else {
+		db = db.Where("dimension LIKE ?", SLADimensionPrefix+"%")
+	}
+	return list, db.Order("dimension").Find(&list).Error
+}
+
+func (r *slaRepository) StatisticsCreate(ctx context.Context, m *model.DataStatistics) error {
+	return r.DB(ctx).Create(m).Error
+}
+
+func (r *slaRepository) StatisticsUpdate(ctx context.Context, m *model.DataStatistics) error {
+	return r.DB(ctx).Model(&model.DataStatistics{}).Where("id = ?", m.ID).
+		Select("tenant_id", "business_id", "total_count", "active_count", "inactive_count", "statistics", "calc_time", "calc_duration",
+			"data_source", "version", "description").
+		Updates(m).Error
+}
@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"context"
+	v1 "nunu-layout-admin/api/v1"
+	"nunu-layout-admin/internal/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type SnapshotRepository interface {
+	GetSnapshots(ctx context.Context, req *v1.GetSnapshotsRequest) ([]model.ResourceSnapshot, int64, error)
+	GetSnapshot(ctx context.Context, snapshotID string) (model.ResourceSnapshot, error)
+	// GetLatestFullSnapshot 返回同一范围内最近一次成功的全量快照
+	GetLatestFullSnapshot(ctx context.Context, tenantID, businessID string) (model.ResourceSnapshot, error)
+	GetExpiredSnapshots(ctx context.Context, now time.Time) ([]model.ResourceSnapshot, error)
+	SnapshotCreate(ctx context.Context, m *model.ResourceSnapshot) error
+	SnapshotUpdate(ctx context.Context, m *model.ResourceSnapshot) error
+	SnapshotDelete(ctx context.Context, id uint) error
+
+	// 以下按租户/业务范围读取快照内容，范围为空时不过滤
+	GetScopedResources(ctx context.Context, tenantID, businessID string) ([]model.Resource, error)
+	GetScopedServices(ctx context.Context, tenantID, businessID string) ([]model.Service, error)
+	GetScopedBusinesses(ctx context.Context, tenantID, businessID string) ([]model.Business, error)
+	// GetScopedResourceRelations 返回一端在resourceIDs中的资源关系，resourceIDs为nil时返回全部
+	GetScopedResourceRelations(ctx context.Context, resourceIDs []uint) ([]model.ResourceRelation, error)
+	// GetScopedUniversalRelations 返回一端在objects(对象类型->标识)中的通用关系，objects为nil时返回全部
+	GetScopedUniversalRelations(ctx context.Context, objects map[string][]string) ([]model.UniversalRelation, error)
+	// UndeleteByKey 按唯一标识列恢复一条软删除的记录并返回其ID，没有已删除记录时返回0。
+	// 从快照恢复已删除的对象时复用原记录，避免与唯一索引冲突
+	UndeleteByKey(ctx context.Context, m interface{}, keyColumn, key string) (uint, error)
+}
+
+func NewSnapshotRepository(
+	repository *Repository,
+) SnapshotRepository {
+	return &snapshotRepository{
+		Repository: repository,
+	}
+}
+
+type snapshotRepository struct {
+	*Repository
+}
+
+func (r *snapshotRepository) GetSnapshots(ctx context.Context, req *v1.GetSnapshotsRequest) ([]model.ResourceSnapshot, int64, error) {
+	var list []model.ResourceSnapshot
+	var total int64
+	scope := r.DB(ctx).Model(&model.ResourceSnapshot{})
+	if req.SnapshotType != "" {
+		scope = scope.Where("snapshot_type = ?", req.SnapshotType)
+	}
+	if req.TenantID != "" {
+		scope = scope.Where("tenant_id = ?", req.TenantID)
+	}
+	if req.BusinessID != "" {
+		scope = scope.Where("business_id = ?", req.BusinessID)
+	}
+	if req.Status != "" {
+		scope = scope.Where("status = ?", req.Status)
+	}
+	if err := scope.Count(&total).Error; err != nil {
+		return nil, total, err
+	}
+	if err := scope.Offset((req.Page - 1) * req.PageSize).Limit(req.PageSize).Order("id DESC").Find(&list).Error; err != nil {
+		return nil, total, err
+	}
+	return list, total, nil
+}
+
+func (r *snapshotRepository) GetSnapshot(ctx context.Context, snapshotID string) (model.ResourceSnapshot, error) {
+	m := model.ResourceSnapshot{}
+	return m, r.DB(ctx).Where("snapshot_id = ?", snapshotID).First(&m).Error
+}
+
+func (r *snapshotRepository) GetLatestFullSnapshot(ctx context.Context, tenantID, businessID string) (model.ResourceSnapshot, error) {
+	m := model.ResourceSnapshot{}
+	return m, r.DB(ctx).Where("snapshot_type = ? AND status = ?", model.SnapshotTypeFull, model.SnapshotStatusCompleted).
+		Where("tenant_id = ? AND business_id = ?", tenantID, businessID).
+		Order("snapshot_time DESC").Order("id DESC").First(&m).Error
+}
+
+func (r *snapshotRepository) GetExpiredSnapshots(ctx context.Context, now time.Time) ([]model.ResourceSnapshot, error) {
+	var list []model.ResourceSnapshot
+	return list, r.DB(ctx).Where("expires_at IS NOT NULL AND expires_at <= ?", now).Order("id ASC").Find(&list).Error
+}
+
+func (r *snapshotRepository) SnapshotCreate(ctx context.Context, m *model.ResourceSnapshot) error {
+	return r.DB(ctx).Create(m).Error
+}
+
+func (r *snapshotRepository) SnapshotUpdate(ctx context.Context, m *model.ResourceSnapshot) error {
+	return r.DB(ctx).Model(&model.ResourceSnapshot{}).Where("id = ?", m.ID).
+		Select("resource_count", "service_count", "business_count", "relation_count", "status",
+			"storage_path", "file_size", "checksum", "description").
+		Updates(m).Error
+}
+
+func (r *snapshotRepository) SnapshotDelete(ctx context.Context, id uint) error {
+	return r.DB(ctx).Where("id = ?", id).Delete(&model.ResourceSnapshot{}).Error
+}
+
+func (r *snapshotRepository) GetScopedResources(ctx context.Context, tenantID, businessID string) ([]model.Resource, error) {
+	var list []model.Resource
+	return list, snapshotScope(r.DB(ctx), tenantID, businessID).Preload("Tags").Order("id ASC").Find(&list).Error
+}
+
+func (r *snapshotRepository) GetScopedServices(ctx context.Context, tenantID, businessID string) ([]model.Service, error) {
+	var list []model.Service
+	return list, snapshotScope(r.DB(ctx), tenantID, businessID).Preload("Tags").Order("id ASC").Find(&list).Error
+}
+
+func (r *snapshotRepository) GetScopedBusinesses(ctx context.Context, tenantID, businessID string) ([]model.Business, error) {
+	var list []model.Business
+	return list, snapshotScope(r.DB(ctx), tenantID, businessID).Preload("Tags").Order("id ASC").Find(&list).Error
+}
+
+func (r *snapshotRepository) GetScopedResourceRelations(ctx context.Context, resourceIDs []uint) ([]model.ResourceRelation, error) {
+	var list []model.ResourceRelation
+	// 端点资源被删除后关系仍然保留，按原资源标识导出
+	unscoped := func(db *gorm.DB) *gorm.DB { return db.Unscoped() }
+	scope := r.DB(ctx).Preload("Source", unscoped).Preload("Target", unscoped)
+	if resourceIDs != nil {
+		scope = scope.Where("source_id IN ? OR target_id IN ?", resourceIDs, resourceIDs)
+	}
+	return list, scope.Order("id ASC").Find(&list).Error
+}
+
+func (r *snapshotRepository) GetScopedUniversalRelations(ctx context.Context, objects map[string][]string) ([]model.UniversalRelation, error) {
+	var list []model.UniversalRelation
+	db := r.DB(ctx)
+	scope := db.Model(&model.UniversalRelation{})
+	if objects != nil {
+		endpoints := db.Where("1 = 0")
+		for objectType, ids := range objects {
+			endpoints = endpoints.Or("source_type = ? AND source_id IN ?", objectType, ids).
+				Or("target_type = ? AND target_id IN ?", objectType, ids)
+		}
+		scope = scope.Where(endpoints)
+	}
+	return list, scope.Order("id ASC").Find(&list).Error
+}
+
+func (r *snapshotRepository) UndeleteByKey(ctx context.Context, m interface{}, keyColumn, key string) (uint, error) {
+	var ids []uint
+	if err := r.DB(ctx).Unscoped().Model(m).Where(keyColumn+" = ? AND deleted_at IS NOT NULL", key).
+		Order("id DESC").Limit(1).Pluck("id", &ids).Error; err != nil {
+		return 0, err
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	return ids[0], r.DB(ctx).Unscoped().Model(m).Where("id = ?", ids[0]).Update("deleted_at", nil).Error
+}
+
+// snapshotScope 资源、服务、业务表都有tenant_id和business_id列
+func snapshotScope(db *gorm.DB, tenantID, businessID string) *gorm.DB {
+	if tenantID != "" {
+		db = db.Where("tenant_id = ?", tenantID)
+	}
+	if businessID != "" {
+		db = db.Where("business_id = ?", businessID)
+	}
+	return db
+}
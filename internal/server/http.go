@@ -24,6 +24,24 @@ func NewHTTPServer(
 	e *casbin.SyncedEnforcer,
 	adminHandler *handler.AdminHandler,
 	userHandler *handler.UserHandler,
+	resourceHandler *handler.ResourceHandler,
+	resourceTypeHandler *handler.ResourceTypeHandler,
+	relationHandler *handler.RelationHandler,
+	relationRuleHandler *handler.RelationRuleHandler,
+	graphHandler *handler.GraphHandler,
+	impactHandler *handler.ImpactHandler,
+	pathHandler *handler.PathHandler,
+	dependencyGraphHandler *handler.DependencyGraphHandler,
+	historyHandler *handler.HistoryHandler,
+	snapshotHandler *handler.SnapshotHandler,
+	notificationHandler *handler.NotificationHandler,
+	sensitiveHandler *handler.SensitiveHandler,
+	configurationHandler *handler.ConfigurationHandler,
+	configTemplateHandler *handler.ConfigTemplateHandler,
+	applicationHandler *handler.ApplicationHandler,
+	healthCheckHandler *handler.HealthCheckHandler,
+	healthRollupHandler *handler.HealthRollupHandler,
+	slaHandler *handler.SLAHandler,
 ) *http.Server {
 	gin.SetMode(gin.DebugMode)
 	s := http.NewServer(
@@ -53,8 +71,8 @@ func NewHTTPServer(
 
 	s.Use(
 		middleware.CORSMiddleware(),
-		middleware.ResponseLogMiddleware(logger),
-		middleware.RequestLogMiddleware(logger),
+		middleware.ResponseLogMiddleware(logger, conf),
+		middleware.RequestLogMiddleware(logger, conf),
 		//middleware.SignMiddleware(log),
 	)
 
@@ -66,6 +84,13 @@ func NewHTTPServer(
 			noAuthRouter.POST("/login", adminHandler.Login)
 		}
 
+		// Agent上报接口使用单独的Agent令牌，不经过管理员JWT和casbin
+		agentAuthRouter := v1.Group("/").Use(middleware.AgentAuth(conf, logger))
+		{
+			agentAuthRouter.POST("/cmdb/application/heartbeat", applicationHandler.ApplicationHeartbeat)
+			agentAuthRouter.POST("/cmdb/applications/heartbeat", applicationHandler.ApplicationHeartbeatBatch)
+		}
+
 		// Strict permission routing group
 		strictAuthRouter := v1.Group("/").Use(middleware.StrictAuth(jwt, logger), middleware.AuthMiddleware(e))
 		{
@@ -95,6 +120,97 @@ func NewHTTPServer(
 			strictAuthRouter.PUT("/admin/api", adminHandler.ApiUpdate)
 			strictAuthRouter.DELETE("/admin/api", adminHandler.ApiDelete)
 
+			// CMDB 资源
+			strictAuthRouter.GET("/cmdb/resources", resourceHandler.GetResources)
+			strictAuthRouter.GET("/cmdb/resource", resourceHandler.GetResource)
+			strictAuthRouter.POST("/cmdb/resource", resourceHandler.ResourceCreate)
+			strictAuthRouter.PUT("/cmdb/resource", resourceHandler.ResourceUpdate)
+			strictAuthRouter.DELETE("/cmdb/resource", resourceHandler.ResourceDelete)
+
+			strictAuthRouter.GET("/cmdb/resource-types", resourceTypeHandler.GetResourceTypes)
+			strictAuthRouter.POST("/cmdb/resource-type", resourceTypeHandler.ResourceTypeCreate)
+			strictAuthRouter.PUT("/cmdb/resource-type", resourceTypeHandler.ResourceTypeUpdate)
+			strictAuthRouter.PUT("/cmdb/resource-type/status", resourceTypeHandler.UpdateResourceTypeStatus)
+			strictAuthRouter.GET("/cmdb/resource-type/schemas", resourceTypeHandler.GetResourceTypeSchemas)
+			strictAuthRouter.GET("/cmdb/resource-type/compliance", resourceTypeHandler.GetResourceTypeCompliance)
+			strictAuthRouter.POST("/cmdb/resource-type/migrate", resourceTypeHandler.ResourceTypeMigrate)
+
+			strictAuthRouter.GET("/cmdb/universal-relations", relationHandler.GetUniversalRelations)
+			strictAuthRouter.GET("/cmdb/universal-relation", relationHandler.GetUniversalRelation)
+			strictAuthRouter.POST("/cmdb/universal-relation", relationHandler.UniversalRelationCreate)
+			strictAuthRouter.PUT("/cmdb/universal-relation", relationHandler.UniversalRelationUpdate)
+			strictAuthRouter.DELETE("/cmdb/universal-relation", relationHandler.UniversalRelationDelete)
+			strictAuthRouter.GET("/cmdb/resource-relations", relationHandler.GetResourceRelations)
+			strictAuthRouter.POST("/cmdb/resource-relation", relationHandler.ResourceRelationCreate)
+			strictAuthRouter.PUT("/cmdb/resource-relation", relationHandler.ResourceRelationUpdate)
+			strictAuthRouter.DELETE("/cmdb/resource-relation", relationHandler.ResourceRelationDelete)
+
+			strictAuthRouter.GET("/cmdb/relation-rules", relationRuleHandler.GetRelationRules)
+			strictAuthRouter.POST("/cmdb/relation-rule", relationRuleHandler.RelationRuleCreate)
+			strictAuthRouter.PUT("/cmdb/relation-rule", relationRuleHandler.RelationRuleUpdate)
+			strictAuthRouter.DELETE("/cmdb/relation-rule", relationRuleHandler.RelationRuleDelete)
+			strictAuthRouter.GET("/cmdb/relation-rules/compliance", relationRuleHandler.GetRelationCompliance)
+
+			strictAuthRouter.GET("/cmdb/graph/expand", graphHandler.Expand)
+			strictAuthRouter.GET("/cmdb/impact", impactHandler.GetImpact)
+			strictAuthRouter.GET("/cmdb/paths", pathHandler.GetRelationPaths)
+			strictAuthRouter.POST("/cmdb/dependency-graph/compute", dependencyGraphHandler.Compute)
+			strictAuthRouter.GET("/cmdb/dependency-graph", dependencyGraphHandler.GetDependencyGraph)
+			strictAuthRouter.GET("/cmdb/dependency-graph/versions", dependencyGraphHandler.GetDependencyGraphVersions)
+			strictAuthRouter.GET("/cmdb/dependency-graph/diff", dependencyGraphHandler.Diff)
+			strictAuthRouter.GET("/cmdb/histories", historyHandler.GetHistories)
+			strictAuthRouter.POST("/cmdb/history/revert", historyHandler.Revert)
+			strictAuthRouter.POST("/cmdb/snapshot", snapshotHandler.CreateSnapshot)
+			strictAuthRouter.GET("/cmdb/snapshots", snapshotHandler.GetSnapshots)
+			strictAuthRouter.GET("/cmdb/snapshot/verify", snapshotHandler.VerifySnapshot)
+			strictAuthRouter.GET("/cmdb/snapshot/diff", snapshotHandler.DiffSnapshots)
+			strictAuthRouter.POST("/cmdb/snapshot/restore", snapshotHandler.RestoreSnapshot)
+			strictAuthRouter.GET("/cmdb/notification/deliveries", notificationHandler.GetDeliveries)
+			strictAuthRouter.POST("/cmdb/notification/delivery/retry", notificationHandler.RetryDelivery)
+			strictAuthRouter.GET("/cmdb/sensitive/reveal", sensitiveHandler.Reveal)
+			strictAuthRouter.POST("/cmdb/sensitive/rotate", sensitiveHandler.RotateKeys)
+
+			strictAuthRouter.GET("/cmdb/configurations", configurationHandler.GetConfigurations)
+			strictAuthRouter.GET("/cmdb/configuration", configurationHandler.GetConfiguration)
+			strictAuthRouter.POST("/cmdb/configuration", configurationHandler.ConfigurationCreate)
+			strictAuthRouter.PUT("/cmdb/configuration", configurationHandler.ConfigurationUpdate)
+			strictAuthRouter.DELETE("/cmdb/configuration", configurationHandler.ConfigurationDelete)
+			strictAuthRouter.GET("/cmdb/configuration/revisions", configurationHandler.GetRevisions)
+			strictAuthRouter.GET("/cmdb/configuration/revision", configurationHandler.GetRevision)
+			strictAuthRouter.POST("/cmdb/configuration/revision", configurationHandler.RevisionCreate)
+			strictAuthRouter.PUT("/cmdb/configuration/revision", configurationHandler.RevisionUpdate)
+			strictAuthRouter.DELETE("/cmdb/configuration/revision", configurationHandler.RevisionDelete)
+			strictAuthRouter.POST("/cmdb/configuration/revision/submit", configurationHandler.RevisionSubmit)
+			strictAuthRouter.POST("/cmdb/configuration/revision/reject", configurationHandler.RevisionReject)
+			strictAuthRouter.POST("/cmdb/configuration/revision/publish", configurationHandler.RevisionPublish)
+			strictAuthRouter.GET("/cmdb/configuration/revision/diff", configurationHandler.DiffRevisions)
+			strictAuthRouter.POST("/cmdb/configuration/rollback", configurationHandler.Rollback)
+			strictAuthRouter.POST("/cmdb/configuration/render", configurationHandler.ConfigurationRender)
+			strictAuthRouter.POST("/cmdb/configuration/rerender", configurationHandler.ConfigurationRerender)
+			strictAuthRouter.GET("/cmdb/configurations/lint", configurationHandler.ConfigurationLint)
+
+			strictAuthRouter.GET("/cmdb/config-templates", configTemplateHandler.GetConfigTemplates)
+			strictAuthRouter.GET("/cmdb/config-template", configTemplateHandler.GetConfigTemplate)
+			strictAuthRouter.POST("/cmdb/config-template", configTemplateHandler.ConfigTemplateCreate)
+			strictAuthRouter.PUT("/cmdb/config-template", configTemplateHandler.ConfigTemplateUpdate)
+			strictAuthRouter.DELETE("/cmdb/config-template", configTemplateHandler.ConfigTemplateDelete)
+
+			strictAuthRouter.GET("/cmdb/applications", applicationHandler.GetApplications)
+			strictAuthRouter.GET("/cmdb/application", applicationHandler.GetApplication)
+			strictAuthRouter.POST("/cmdb/application", applicationHandler.ApplicationCreate)
+			strictAuthRouter.PUT("/cmdb/application", applicationHandler.ApplicationUpdate)
+			strictAuthRouter.DELETE("/cmdb/application", applicationHandler.ApplicationDelete)
+			strictAuthRouter.POST("/cmdb/application/lifecycle", applicationHandler.ApplicationLifecycle)
+			strictAuthRouter.GET("/cmdb/application/probe-results", healthCheckHandler.GetProbeResults)
+			strictAuthRouter.POST("/cmdb/application/health-check", healthCheckHandler.ApplicationHealthCheck)
+
+			strictAuthRouter.GET("/cmdb/service/health", healthRollupHandler.GetServiceHealth)
+			strictAuthRouter.GET("/cmdb/service/health-rule", healthRollupHandler.GetServiceHealthRule)
+			strictAuthRouter.PUT("/cmdb/service/health-rule", healthRollupHandler.ServiceHealthRuleUpdate)
+			strictAuthRouter.DELETE("/cmdb/service/health-rule", healthRollupHandler.ServiceHealthRuleDelete)
+			strictAuthRouter.POST("/cmdb/health/rollup", healthRollupHandler.RollupHealth)
+			strictAuthRouter.GET("/cmdb/sla/report", slaHandler.GetSLAReport)
+			strictAuthRouter.POST("/cmdb/sla/calculate", slaHandler.CalculateSLA)
 		}
 	}
 	return s
@@ -3,6 +3,7 @@ package server
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	v1 "nunu-layout-admin/api/v1"
@@ -10,6 +11,7 @@ import (
 	"nunu-layout-admin/pkg/log"
 	"nunu-layout-admin/pkg/sid"
 	"os"
+	"time"
 
 	"github.com/casbin/casbin/v2"
 	"go.uber.org/zap"
@@ -17,11 +19,22 @@ import (
 	"gorm.io/gorm"
 )
 
+// MigrateCommand 迁移子命令
+type MigrateCommand string
+
+const (
+	MigrateCommandStatus MigrateCommand = "status" // 查看各版本执行状态
+	MigrateCommandUp     MigrateCommand = "up"     // 执行全部未执行的迁移并补齐初始化数据
+	MigrateCommandDown   MigrateCommand = "down"   // 回滚最近一次执行的迁移
+	MigrateCommandRedo   MigrateCommand = "redo"   // 回滚最近一次执行的迁移后重新执行
+)
+
 type MigrateServer struct {
-	db  *gorm.DB
-	log *log.Logger
-	sid *sid.Sid
-	e   *casbin.SyncedEnforcer
+	db      *gorm.DB
+	log     *log.Logger
+	sid     *sid.Sid
+	e       *casbin.SyncedEnforcer
+	command MigrateCommand
 }
 
 func NewMigrateServer(
@@ -29,211 +42,269 @@ func NewMigrateServer(
 	log *log.Logger,
 	sid *sid.Sid,
 	e *casbin.SyncedEnforcer,
+	command MigrateCommand,
 ) *MigrateServer {
 	return &MigrateServer{
-		e:   e,
-		db:  db,
-		log: log,
-		sid: sid,
+		e:       e,
+		db:      db,
+		log:     log,
+		sid:     sid,
+		command: command,
 	}
 }
 func (m *MigrateServer) Start(ctx context.Context) error {
-	// 删除现有表
-	m.db.Migrator().DropTable(
-		&model.AdminUser{},
-		&model.Menu{},
-		&model.Role{},
-		&model.Api{},
-		// CMDB 核心表
-		&model.Resource{},
-		&model.ResourceTag{},
-		&model.ResourceRelation{},
-		&model.ResourceType{},
-		&model.Service{},
-		&model.ServiceResource{},
-		&model.ServiceTag{},
-		&model.Business{},
-		&model.BusinessService{},
-		&model.BusinessTag{},
-		// CMDB 历史表
-		&model.ResourceHistory{},
-		&model.ServiceHistory{},
-		&model.BusinessHistory{},
-		&model.RelationHistory{},
-		&model.ResourceSnapshot{},
-		&model.AuditConfig{},
-		&model.SyncLog{},
-		// CMDB 缓存表
-		&model.RelationCache{},
-		&model.ResourceView{},
-		&model.SearchIndex{},
-		&model.QueryPerformance{},
-		&model.CacheManager{},
-		&model.DataStatistics{},
-		// CMDB 应用层表
-		&model.ApplicationType{},
-		&model.Application{},
-		&model.Configuration{},
-		&model.ApplicationTag{},
-		&model.ConfigurationTag{},
-		&model.ConfigurationTemplate{},
-		&model.ApplicationDependency{},
-		&model.ApplicationGroup{},
-		&model.ApplicationGroupMember{},
-		// CMDB 关系表
-		&model.UniversalRelation{},
-		&model.UniversalRelationTag{},
-		&model.MultiDimensionAssociation{},
-		&model.RelationPath{},
-		&model.DependencyGraph{},
-		&model.RelationRule{},
-	)
+	if err := m.run(ctx); err != nil {
+		m.log.Error("migrate error", zap.String("command", string(m.command)), zap.Error(err))
+		os.Exit(1)
+	}
+	m.log.Info("migrate success", zap.String("command", string(m.command)))
+	os.Exit(0)
+	return nil
+}
+func (m *MigrateServer) Stop(ctx context.Context) error {
+	m.log.Info("AutoMigrate stop")
+	return nil
+}
+func (m *MigrateServer) run(ctx context.Context) error {
+	if err := m.db.AutoMigrate(&model.SchemaMigration{}); err != nil {
+		return err
+	}
+	switch m.command {
+	case MigrateCommandStatus:
+		return m.status(ctx)
+	case MigrateCommandUp:
+		if err := m.up(ctx); err != nil {
+			return err
+		}
+		return m.seed(ctx)
+	case MigrateCommandDown:
+		_, err := m.down(ctx)
+		return err
+	case MigrateCommandRedo:
+		mg, err := m.down(ctx)
+		if err != nil {
+			return err
+		}
+		if err := m.apply(ctx, mg); err != nil {
+			return err
+		}
+		return m.seed(ctx)
+	}
+	return fmt.Errorf("unknown migrate command %q", m.command)
+}
+
+// appliedMigrations 返回已执行的迁移，按版本号排列
+func (m *MigrateServer) appliedMigrations(ctx context.Context) ([]model.SchemaMigration, error) {
+	var list []model.SchemaMigration
+	return list, m.db.WithContext(ctx).Order("version ASC").Find(&list).Error
+}
 
-	// 创建新表
-	if err := m.db.AutoMigrate(
-		&model.AdminUser{},
-		&model.Menu{},
-		&model.Role{},
-		&model.Api{},
-		// CMDB 核心表
-		&model.Resource{},
-		&model.ResourceTag{},
-		&model.ResourceRelation{},
-		&model.ResourceType{},
-		&model.Service{},
-		&model.ServiceResource{},
-		&model.ServiceTag{},
-		&model.Business{},
-		&model.BusinessService{},
-		&model.BusinessTag{},
-		// CMDB 历史表
-		&model.ResourceHistory{},
-		&model.ServiceHistory{},
-		&model.BusinessHistory{},
-		&model.RelationHistory{},
-		&model.ResourceSnapshot{},
-		&model.AuditConfig{},
-		&model.SyncLog{},
-		// CMDB 缓存表
-		&model.RelationCache{},
-		&model.ResourceView{},
-		&model.SearchIndex{},
-		&model.QueryPerformance{},
-		&model.CacheManager{},
-		&model.DataStatistics{},
-		// CMDB 应用层表
-		&model.ApplicationType{},
-		&model.Application{},
-		&model.Configuration{},
-		&model.ApplicationTag{},
-		&model.ConfigurationTag{},
-		&model.ConfigurationTemplate{},
-		&model.ApplicationDependency{},
-		&model.ApplicationGroup{},
-		&model.ApplicationGroupMember{},
-		// CMDB 关系表
-		&model.UniversalRelation{},
-		&model.UniversalRelationTag{},
-		&model.MultiDimensionAssociation{},
-		&model.RelationPath{},
-		&model.DependencyGraph{},
-		&model.RelationRule{},
-	); err != nil {
-		m.log.Error("user migrate error", zap.Error(err))
+func (m *MigrateServer) status(ctx context.Context) error {
+	applied, err := m.appliedMigrations(ctx)
+	if err != nil {
 		return err
 	}
+	appliedAt := make(map[uint]time.Time, len(applied))
+	for _, item := range applied {
+		appliedAt[item.Version] = item.AppliedAt
+	}
+	for _, mg := range migrations {
+		state := "pending"
+		if t, ok := appliedAt[mg.Version]; ok {
+			state = "applied at " + t.Format("2006-01-02 15:04:05")
+			delete(appliedAt, mg.Version)
+		}
+		fmt.Printf("%04d %-40s %s\n", mg.Version, mg.Name, state)
+	}
+	// 数据库中存在而代码中没有的版本，通常是用更新版本的程序执行过迁移
+	for _, item := range applied {
+		if _, ok := appliedAt[item.Version]; ok {
+			fmt.Printf("%04d %-40s applied at %s (unknown)\n", item.Version, item.Name, item.AppliedAt.Format("2006-01-02 15:04:05"))
+		}
+	}
+	return nil
+}
+
+// up 按版本号顺序执行所有未执行的迁移
+func (m *MigrateServer) up(ctx context.Context) error {
+	applied, err := m.appliedMigrations(ctx)
+	if err != nil {
+		return err
+	}
+	done := make(map[uint]bool, len(applied))
+	for _, item := range applied {
+		done[item.Version] = true
+	}
+	for _, mg := range migrations {
+		if done[mg.Version] {
+			continue
+		}
+		if err := m.apply(ctx, mg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// down 回滚最近一次执行的迁移并返回该迁移
+func (m *MigrateServer) down(ctx context.Context) (migration, error) {
+	last := model.SchemaMigration{}
+	err := m.db.WithContext(ctx).Order("version DESC").First(&last).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return migration{}, errors.New("no applied migration to roll back")
+		}
+		return migration{}, err
+	}
+	for _, mg := range migrations {
+		if mg.Version != last.Version {
+			continue
+		}
+		err := m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			if err := mg.Down(tx); err != nil {
+				return err
+			}
+			return tx.Where("version = ?", mg.Version).Delete(&model.SchemaMigration{}).Error
+		})
+		if err != nil {
+			return migration{}, fmt.Errorf("migration %04d_%s down: %w", mg.Version, mg.Name, err)
+		}
+		m.log.Info("migration rolled back", zap.Uint("version", mg.Version), zap.String("name", mg.Name))
+		return mg, nil
+	}
+	return migration{}, fmt.Errorf("migration %04d_%s is not defined in this build", last.Version, last.Name)
+}
+
+// apply 执行一个迁移并记录到schema_migrations，二者在同一个事务中
+func (m *MigrateServer) apply(ctx context.Context, mg migration) error {
+	err := m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := mg.Up(tx); err != nil {
+			return err
+		}
+		return tx.Create(&model.SchemaMigration{
+			Version:   mg.Version,
+			Name:      mg.Name,
+			AppliedAt: time.Now(),
+		}).Error
+	})
+	if err != nil {
+		return fmt.Errorf("migration %04d_%s up: %w", mg.Version, mg.Name, err)
+	}
+	m.log.Info("migration applied", zap.Uint("version", mg.Version), zap.String("name", mg.Name))
+	return nil
+}
+
+// seed 补齐缺失的初始化数据，已存在(包括已软删除)的记录不会被覆盖或恢复，可重复执行
+func (m *MigrateServer) seed(ctx context.Context) error {
 	err := m.initialAdminUser(ctx)
 	if err != nil {
 		m.log.Error("initialAdminUser error", zap.Error(err))
+		return err
 	}
 
-	err = m.initialMenuData(ctx)
+	menus, err := m.initialMenuData(ctx)
 	if err != nil {
 		m.log.Error("initialMenuData error", zap.Error(err))
+		return err
 	}
 
-	err = m.initialApisData(ctx)
+	apis, err := m.initialApisData(ctx)
 	if err != nil {
 		m.log.Error("initialApisData error", zap.Error(err))
+		return err
 	}
 
-	err = m.initialRBAC(ctx)
+	err = m.initialRBAC(ctx, menus, apis)
 	if err != nil {
 		m.log.Error("initialRBAC error", zap.Error(err))
+		return err
 	}
 
 	err = m.initialCMDBData(ctx)
 	if err != nil {
 		m.log.Error("initialCMDBData error", zap.Error(err))
+		return err
 	}
-
-	m.log.Info("AutoMigrate success")
-	os.Exit(0)
 	return nil
 }
-func (m *MigrateServer) Stop(ctx context.Context) error {
-	m.log.Info("AutoMigrate stop")
-	return nil
+
+// createMissing 逐条插入list中按keys字段查不到的记录(含软删除)，返回实际插入的记录
+func createMissing[T any](db *gorm.DB, list []T, keys ...string) ([]T, error) {
+	fields := make([]interface{}, 0, len(keys))
+	for _, key := range keys {
+		fields = append(fields, key)
+	}
+	created := make([]T, 0, len(list))
+	for i := range list {
+		var count int64
+		if err := db.Unscoped().Model(new(T)).Where(&list[i], fields...).Count(&count).Error; err != nil {
+			return nil, err
+		}
+		if count > 0 {
+			continue
+		}
+		if err := db.Create(&list[i]).Error; err != nil {
+			return nil, err
+		}
+		created = append(created, list[i])
+	}
+	return created, nil
 }
+
 func (m *MigrateServer) initialAdminUser(ctx context.Context) error {
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte("123456"), bcrypt.DefaultCost)
 	if err != nil {
 		return err
 	}
-	err = m.db.Create(&model.AdminUser{
-		Model:    gorm.Model{ID: 1},
-		Username: "admin",
-		Password: string(hashedPassword),
-		Nickname: "Admin",
-	}).Error
-	return m.db.Create(&model.AdminUser{
-		Model:    gorm.Model{ID: 2},
-		Username: "user",
-		Password: string(hashedPassword),
-		Nickname: "运营人员",
-	}).Error
-
+	_, err = createMissing(m.db, []model.AdminUser{
+		{
+			Model:    gorm.Model{ID: 1},
+			Username: "admin",
+			Password: string(hashedPassword),
+			Nickname: "Admin",
+		},
+		{
+			Model:    gorm.Model{ID: 2},
+			Username: "user",
+			Password: string(hashedPassword),
+			Nickname: "运营人员",
+		},
+	}, "Username")
+	return err
 }
-func (m *MigrateServer) initialRBAC(ctx context.Context) error {
-	roles := []model.Role{
+
+// initialRBAC 只为本次新增的角色、菜单和API授权，不会恢复已被手动回收的权限
+func (m *MigrateServer) initialRBAC(ctx context.Context, menus []model.Menu, apis []model.Api) error {
+	roles, err := createMissing(m.db, []model.Role{
 		{Sid: model.AdminRole, Name: "超级管理员"},
 		{Sid: "1000", Name: "运营人员"},
 		{Sid: "1001", Name: "访客"},
-	}
-	if err := m.db.Create(&roles).Error; err != nil {
-		return err
-	}
-	m.e.ClearPolicy()
-	err := m.e.SavePolicy()
+	}, "Sid")
 	if err != nil {
-		m.log.Error("m.e.SavePolicy error", zap.Error(err))
 		return err
 	}
-	_, err = m.e.AddRoleForUser(model.AdminUserID, model.AdminRole)
-	if err != nil {
-		m.log.Error("m.e.AddRoleForUser error", zap.Error(err))
-		return err
+	createdRoles := make(map[string]bool, len(roles))
+	for _, role := range roles {
+		createdRoles[role.Sid] = true
 	}
-	menuList := make([]v1.MenuDataItem, 0)
-	err = json.Unmarshal([]byte(menuData), &menuList)
-	if err != nil {
-		m.log.Error("json.Unmarshal error", zap.Error(err))
-		return err
+	if createdRoles[model.AdminRole] {
+		_, err = m.e.AddRoleForUser(model.AdminUserID, model.AdminRole)
+		if err != nil {
+			m.log.Error("m.e.AddRoleForUser error", zap.Error(err))
+			return err
+		}
 	}
-	for _, item := range menuList {
-		m.addPermissionForRole(model.AdminRole, model.MenuResourcePrefix+item.Path, "read")
+	for _, menu := range menus {
+		m.addPermissionForRole(model.AdminRole, model.MenuResourcePrefix+menu.Path, "read")
 	}
-	apiList := make([]model.Api, 0)
-	err = m.db.Find(&apiList).Error
-	if err != nil {
-		m.log.Error("m.db.Find(&apiList).Error error", zap.Error(err))
-		return err
-	}
-	for _, api := range apiList {
+	for _, api := range apis {
 		m.addPermissionForRole(model.AdminRole, model.ApiResourcePrefix+api.Path, api.Method)
 	}
 
 	// 添加运营人员权限
+	if !createdRoles["1000"] {
+		return nil
+	}
 	_, err = m.e.AddRoleForUser("2", "1000")
 	if err != nil {
 		m.log.Error("m.e.AddRoleForUser error", zap.Error(err))
@@ -254,14 +325,16 @@ func (m *MigrateServer) initialRBAC(ctx context.Context) error {
 	return nil
 }
 func (m *MigrateServer) addPermissionForRole(role, resource, action string) {
-	_, err := m.e.AddPermissionForUser(role, resource, action)
+	added, err := m.e.AddPermissionForUser(role, resource, action)
 	if err != nil {
 		m.log.Sugar().Info("为角色 %s 添加权限 %s:%s 失败: %v", role, resource, action, err)
 		return
 	}
-	fmt.Printf("为角色 %s 添加权限: %s %s\n", role, resource, action)
+	if added {
+		fmt.Printf("为角色 %s 添加权限: %s %s\n", role, resource, action)
+	}
 }
-func (m *MigrateServer) initialApisData(ctx context.Context) error {
+func (m *MigrateServer) initialApisData(ctx context.Context) ([]model.Api, error) {
 	initialApis := []model.Api{
 
 		{Group: "基础API", Name: "获取用户菜单列表", Path: "/v1/menus", Method: http.MethodGet},
@@ -289,16 +362,103 @@ func (m *MigrateServer) initialApisData(ctx context.Context) error {
 		{Group: "权限模块", Name: "创建API", Path: "/v1/admin/api", Method: http.MethodPost},
 		{Group: "权限模块", Name: "更新API", Path: "/v1/admin/api", Method: http.MethodPut},
 		{Group: "权限模块", Name: "删除API", Path: "/v1/admin/api", Method: http.MethodDelete},
+
+		{Group: "CMDB资源", Name: "获取资源列表", Path: "/v1/cmdb/resources", Method: http.MethodGet},
+		{Group: "CMDB资源", Name: "获取资源详情", Path: "/v1/cmdb/resource", Method: http.MethodGet},
+		{Group: "CMDB资源", Name: "创建资源", Path: "/v1/cmdb/resource", Method: http.MethodPost},
+		{Group: "CMDB资源", Name: "更新资源", Path: "/v1/cmdb/resource", Method: http.MethodPut},
+		{Group: "CMDB资源", Name: "删除资源", Path: "/v1/cmdb/resource", Method: http.MethodDelete},
+
+		{Group: "CMDB资源类型", Name: "获取资源类型列表", Path: "/v1/cmdb/resource-types", Method: http.MethodGet},
+		{Group: "CMDB资源类型", Name: "创建资源类型", Path: "/v1/cmdb/resource-type", Method: http.MethodPost},
+		{Group: "CMDB资源类型", Name: "更新资源类型", Path: "/v1/cmdb/resource-type", Method: http.MethodPut},
+		{Group: "CMDB资源类型", Name: "启用/停用资源类型", Path: "/v1/cmdb/resource-type/status", Method: http.MethodPut},
+		{Group: "CMDB资源类型", Name: "获取资源类型schema版本", Path: "/v1/cmdb/resource-type/schemas", Method: http.MethodGet},
+		{Group: "CMDB资源类型", Name: "资源属性合规检查", Path: "/v1/cmdb/resource-type/compliance", Method: http.MethodGet},
+		{Group: "CMDB资源类型", Name: "迁移资源属性", Path: "/v1/cmdb/resource-type/migrate", Method: http.MethodPost},
+
+		{Group: "CMDB关系", Name: "获取通用关系列表", Path: "/v1/cmdb/universal-relations", Method: http.MethodGet},
+		{Group: "CMDB关系", Name: "获取通用关系详情", Path: "/v1/cmdb/universal-relation", Method: http.MethodGet},
+		{Group: "CMDB关系", Name: "创建通用关系", Path: "/v1/cmdb/universal-relation", Method: http.MethodPost},
+		{Group: "CMDB关系", Name: "更新通用关系", Path: "/v1/cmdb/universal-relation", Method: http.MethodPut},
+		{Group: "CMDB关系", Name: "删除通用关系", Path: "/v1/cmdb/universal-relation", Method: http.MethodDelete},
+		{Group: "CMDB关系", Name: "获取资源关系列表", Path: "/v1/cmdb/resource-relations", Method: http.MethodGet},
+		{Group: "CMDB关系", Name: "创建资源关系", Path: "/v1/cmdb/resource-relation", Method: http.MethodPost},
+		{Group: "CMDB关系", Name: "更新资源关系", Path: "/v1/cmdb/resource-relation", Method: http.MethodPut},
+		{Group: "CMDB关系", Name: "删除资源关系", Path: "/v1/cmdb/resource-relation", Method: http.MethodDelete},
+
+		{Group: "CMDB关系规则", Name: "获取关系规则列表", Path: "/v1/cmdb/relation-rules", Method: http.MethodGet},
+		{Group: "CMDB关系规则", Name: "创建关系规则", Path: "/v1/cmdb/relation-rule", Method: http.MethodPost},
+		{Group: "CMDB关系规则", Name: "更新关系规则", Path: "/v1/cmdb/relation-rule", Method: http.MethodPut},
+		{Group: "CMDB关系规则", Name: "删除关系规则", Path: "/v1/cmdb/relation-rule", Method: http.MethodDelete},
+		{Group: "CMDB关系规则", Name: "关系规则合规报告", Path: "/v1/cmdb/relation-rules/compliance", Method: http.MethodGet},
+
+		{Group: "CMDB关系图", Name: "关系图展开", Path: "/v1/cmdb/graph/expand", Method: http.MethodGet},
+		{Group: "CMDB关系图", Name: "资源故障影响分析", Path: "/v1/cmdb/impact", Method: http.MethodGet},
+		{Group: "CMDB关系图", Name: "对象间关系路径", Path: "/v1/cmdb/paths", Method: http.MethodGet},
+		{Group: "CMDB关系图", Name: "计算依赖图", Path: "/v1/cmdb/dependency-graph/compute", Method: http.MethodPost},
+		{Group: "CMDB关系图", Name: "获取依赖图", Path: "/v1/cmdb/dependency-graph", Method: http.MethodGet},
+		{Group: "CMDB关系图", Name: "依赖图版本列表", Path: "/v1/cmdb/dependency-graph/versions", Method: http.MethodGet},
+		{Group: "CMDB关系图", Name: "依赖图版本对比", Path: "/v1/cmdb/dependency-graph/diff", Method: http.MethodGet},
+		{Group: "CMDB变更历史", Name: "变更历史列表", Path: "/v1/cmdb/histories", Method: http.MethodGet},
+		{Group: "CMDB变更历史", Name: "回滚到历史版本", Path: "/v1/cmdb/history/revert", Method: http.MethodPost},
+		{Group: "CMDB快照", Name: "创建快照", Path: "/v1/cmdb/snapshot", Method: http.MethodPost},
+		{Group: "CMDB快照", Name: "快照列表", Path: "/v1/cmdb/snapshots", Method: http.MethodGet},
+		{Group: "CMDB快照", Name: "校验快照文件", Path: "/v1/cmdb/snapshot/verify", Method: http.MethodGet},
+		{Group: "CMDB快照", Name: "快照对比", Path: "/v1/cmdb/snapshot/diff", Method: http.MethodGet},
+		{Group: "CMDB快照", Name: "从快照恢复", Path: "/v1/cmdb/snapshot/restore", Method: http.MethodPost},
+		{Group: "CMDB变更通知", Name: "通知投递记录", Path: "/v1/cmdb/notification/deliveries", Method: http.MethodGet},
+		{Group: "CMDB变更通知", Name: "重新发送通知", Path: "/v1/cmdb/notification/delivery/retry", Method: http.MethodPost},
+		{Group: "CMDB敏感数据", Name: "查看敏感字段明文", Path: model.SensitiveRevealApi, Method: http.MethodGet},
+		{Group: "CMDB敏感数据", Name: "轮换加密主密钥", Path: "/v1/cmdb/sensitive/rotate", Method: http.MethodPost},
+		{Group: "CMDB配置", Name: "获取配置列表", Path: "/v1/cmdb/configurations", Method: http.MethodGet},
+		{Group: "CMDB配置", Name: "获取配置详情", Path: "/v1/cmdb/configuration", Method: http.MethodGet},
+		{Group: "CMDB配置", Name: "创建配置", Path: "/v1/cmdb/configuration", Method: http.MethodPost},
+		{Group: "CMDB配置", Name: "更新配置信息", Path: "/v1/cmdb/configuration", Method: http.MethodPut},
+		{Group: "CMDB配置", Name: "删除配置", Path: "/v1/cmdb/configuration", Method: http.MethodDelete},
+		{Group: "CMDB配置", Name: "获取配置版本列表", Path: "/v1/cmdb/configuration/revisions", Method: http.MethodGet},
+		{Group: "CMDB配置", Name: "获取配置版本", Path: "/v1/cmdb/configuration/revision", Method: http.MethodGet},
+		{Group: "CMDB配置", Name: "创建配置草稿", Path: "/v1/cmdb/configuration/revision", Method: http.MethodPost},
+		{Group: "CMDB配置", Name: "修改配置草稿", Path: "/v1/cmdb/configuration/revision", Method: http.MethodPut},
+		{Group: "CMDB配置", Name: "删除配置草稿", Path: "/v1/cmdb/configuration/revision", Method: http.MethodDelete},
+		{Group: "CMDB配置", Name: "提交配置版本", Path: "/v1/cmdb/configuration/revision/submit", Method: http.MethodPost},
+		{Group: "CMDB配置", Name: "驳回配置版本", Path: "/v1/cmdb/configuration/revision/reject", Method: http.MethodPost},
+		{Group: "CMDB配置", Name: "发布配置版本", Path: "/v1/cmdb/configuration/revision/publish", Method: http.MethodPost},
+		{Group: "CMDB配置", Name: "比较配置版本", Path: "/v1/cmdb/configuration/revision/diff", Method: http.MethodGet},
+		{Group: "CMDB配置", Name: "回滚配置", Path: "/v1/cmdb/configuration/rollback", Method: http.MethodPost},
+		{Group: "CMDB配置", Name: "用模板渲染配置", Path: "/v1/cmdb/configuration/render", Method: http.MethodPost},
+		{Group: "CMDB配置", Name: "重新渲染模板派生的配置", Path: "/v1/cmdb/configuration/rerender", Method: http.MethodPost},
+		{Group: "CMDB配置", Name: "配置数据合规检查", Path: "/v1/cmdb/configurations/lint", Method: http.MethodGet},
+		{Group: "CMDB配置模板", Name: "获取配置模板列表", Path: "/v1/cmdb/config-templates", Method: http.MethodGet},
+		{Group: "CMDB配置模板", Name: "获取配置模板详情", Path: "/v1/cmdb/config-template", Method: http.MethodGet},
+		{Group: "CMDB配置模板", Name: "创建配置模板", Path: "/v1/cmdb/config-template", Method: http.MethodPost},
+		{Group: "CMDB配置模板", Name: "更新配置模板", Path: "/v1/cmdb/config-template", Method: http.MethodPut},
+		{Group: "CMDB配置模板", Name: "删除配置模板", Path: "/v1/cmdb/config-template", Method: http.MethodDelete},
+		{Group: "CMDB应用", Name: "获取应用实例列表", Path: "/v1/cmdb/applications", Method: http.MethodGet},
+		{Group: "CMDB应用", Name: "获取应用实例详情", Path: "/v1/cmdb/application", Method: http.MethodGet},
+		{Group: "CMDB应用", Name: "登记应用实例", Path: "/v1/cmdb/application", Method: http.MethodPost},
+		{Group: "CMDB应用", Name: "更新应用实例", Path: "/v1/cmdb/application", Method: http.MethodPut},
+		{Group: "CMDB应用", Name: "删除应用实例", Path: "/v1/cmdb/application", Method: http.MethodDelete},
+		{Group: "CMDB应用", Name: "应用生命周期操作", Path: "/v1/cmdb/application/lifecycle", Method: http.MethodPost},
+		{Group: "CMDB应用", Name: "获取健康检查探测结果", Path: "/v1/cmdb/application/probe-results", Method: http.MethodGet},
+		{Group: "CMDB应用", Name: "立即执行健康检查", Path: "/v1/cmdb/application/health-check", Method: http.MethodPost},
+		{Group: "CMDB健康汇总", Name: "获取服务健康状态", Path: "/v1/cmdb/service/health", Method: http.MethodGet},
+		{Group: "CMDB健康汇总", Name: "获取服务健康汇总规则", Path: "/v1/cmdb/service/health-rule", Method: http.MethodGet},
+		{Group: "CMDB健康汇总", Name: "设置服务健康汇总规则", Path: "/v1/cmdb/service/health-rule", Method: http.MethodPut},
+		{Group: "CMDB健康汇总", Name: "删除服务健康汇总规则", Path: "/v1/cmdb/service/health-rule", Method: http.MethodDelete},
+		{Group: "CMDB健康汇总", Name: "立即汇总健康状态", Path: "/v1/cmdb/health/rollup", Method: http.MethodPost},
+		{Group: "CMDB健康汇总", Name: "获取服务SLA报告", Path: "/v1/cmdb/sla/report", Method: http.MethodGet},
+		{Group: "CMDB健康汇总", Name: "立即计算服务SLA", Path: "/v1/cmdb/sla/calculate", Method: http.MethodPost},
 	}
 
-	return m.db.Create(&initialApis).Error
+	return createMissing(m.db, initialApis, "Path", "Method")
 }
-func (m *MigrateServer) initialMenuData(ctx context.Context) error {
+func (m *MigrateServer) initialMenuData(ctx context.Context) ([]model.Menu, error) {
 	menuList := make([]v1.MenuDataItem, 0)
 	err := json.Unmarshal([]byte(menuData), &menuList)
 	if err != nil {
 		m.log.Error("json.Unmarshal error", zap.Error(err))
-		return err
+		return nil, err
 	}
 	menuListDb := make([]model.Menu, 0)
 	for _, item := range menuList {
@@ -320,10 +480,10 @@ func (m *MigrateServer) initialMenuData(ctx context.Context) error {
 			HideInMenu: item.HideInMenu,
 		})
 	}
-	return m.db.Create(&menuListDb).Error
+	return createMissing(m.db, menuListDb, "ID")
 }
 
-// 初始化CMDB基础数据
+// 初始化CMDB基础数据，资源类型、审计配置等参考数据按唯一标识补齐
 func (m *MigrateServer) initialCMDBData(ctx context.Context) error {
 	m.log.Info("开始初始化CMDB基础数据...")
 
@@ -391,10 +551,25 @@ func (m *MigrateServer) initialCMDBData(ctx context.Context) error {
 		},
 	}
 
-	if err := m.db.Create(&resourceTypes).Error; err != nil {
+	// 只为新增的资源类型创建初始schema版本
+	createdTypes, err := createMissing(m.db, resourceTypes, "TypeName")
+	if err != nil {
 		m.log.Error("创建资源类型失败", zap.Error(err))
 		return err
 	}
+	resourceTypeSchemas := make([]model.ResourceTypeSchema, 0, len(createdTypes))
+	for _, t := range createdTypes {
+		resourceTypeSchemas = append(resourceTypeSchemas, model.ResourceTypeSchema{
+			TypeName:        t.TypeName,
+			Version:         1,
+			AttributeSchema: t.AttributeSchema,
+			Compatible:      true,
+		})
+	}
+	if _, err := createMissing(m.db, resourceTypeSchemas, "TypeName", "Version"); err != nil {
+		m.log.Error("创建资源类型schema版本失败", zap.Error(err))
+		return err
+	}
 
 	// 2. 初始化审计配置
 	auditConfigs := []model.AuditConfig{
@@ -422,12 +597,115 @@ func (m *MigrateServer) initialCMDBData(ctx context.Context) error {
 		},
 	}
 
-	if err := m.db.Create(&auditConfigs).Error; err != nil {
+	if _, err := createMissing(m.db, auditConfigs, "ResourceType", "TenantID"); err != nil {
 		m.log.Error("创建审计配置失败", zap.Error(err))
 		return err
 	}
 
-	// 3. 创建示例资源数据
+	// 3. 初始化应用类型定义
+	applicationTypes := []model.ApplicationType{
+		{
+			TypeName:    model.AppTypeDNSServer,
+			DisplayName: "DNS服务器",
+			Category:    "network",
+			Version:     "1.0.0",
+			Icon:        "dns",
+			Color:       "#13c2c2",
+			ResourceRequirements: model.JSONMap{
+				"min_cpu":    0.5,
+				"min_memory": 512,
+				"min_disk":   1024,
+			},
+			ConfigSchema: model.JSONMap{
+				"properties": map[string]interface{}{
+					"listen_port":      map[string]interface{}{"type": "integer", "default": 53},
+					"upstream_dns":     map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+					"cache_size":       map[string]interface{}{"type": "integer", "default": 1000},
+					"log_level":        map[string]interface{}{"type": "string", "default": "info"},
+					"enable_recursion": map[string]interface{}{"type": "boolean", "default": true},
+				},
+			},
+			DefaultConfig: model.JSONMap{
+				"listen_port":      53,
+				"upstream_dns":     []string{"8.8.8.8", "8.8.4.4"},
+				"cache_size":       1000,
+				"log_level":        "info",
+				"enable_recursion": true,
+			},
+			HealthCheckConfig: model.JSONMap{
+				"method":   "dns_query",
+				"query":    "health.check",
+				"timeout":  5,
+				"interval": 30,
+			},
+			DeploymentMethods: []string{"binary", "docker"},
+			SupportedOS:       []string{"linux", "windows"},
+			Description:       "DNS服务器应用类型",
+			IsActive:          true,
+		},
+		{
+			TypeName:    model.AppTypeCacheService,
+			DisplayName: "缓存服务",
+			Category:    "cache",
+			Version:     "1.0.0",
+			Icon:        "cache",
+			Color:       "#f5222d",
+			ResourceRequirements: model.JSONMap{
+				"min_cpu":    1.0,
+				"min_memory": 1024,
+				"min_disk":   2048,
+			},
+			ConfigSchema: model.JSONMap{
+				"properties": map[string]interface{}{
+					"port":        map[string]interface{}{"type": "integer", "default": 6379},
+					"max_memory":  map[string]interface{}{"type": "string", "default": "1gb"},
+					"persistence": map[string]interface{}{"type": "boolean", "default": true},
+					"max_clients": map[string]interface{}{"type": "integer", "default": 10000},
+					"timeout":     map[string]interface{}{"type": "integer", "default": 300},
+				},
+			},
+			DefaultConfig: model.JSONMap{
+				"port":        6379,
+				"max_memory":  "1gb",
+				"persistence": true,
+				"max_clients": 10000,
+				"timeout":     300,
+			},
+			HealthCheckConfig: model.JSONMap{
+				"method":   "tcp_connect",
+				"timeout":  3,
+				"interval": 15,
+			},
+			DeploymentMethods: []string{"binary", "docker", "k8s"},
+			SupportedOS:       []string{"linux", "windows", "macos"},
+			Description:       "缓存服务应用类型",
+			IsActive:          true,
+		},
+	}
+
+	if _, err := createMissing(m.db, applicationTypes, "TypeName"); err != nil {
+		m.log.Error("创建应用类型失败", zap.Error(err))
+		return err
+	}
+
+	// 示例数据只在没有任何资源(包括已删除)的新库中创建，避免写入已有的生产数据
+	var resourceCount int64
+	if err := m.db.Unscoped().Model(&model.Resource{}).Count(&resourceCount).Error; err != nil {
+		return err
+	}
+	if resourceCount == 0 {
+		if err := m.initialCMDBSampleData(ctx); err != nil {
+			return err
+		}
+	}
+
+	m.log.Info("CMDB基础数据初始化完成")
+	return nil
+}
+
+// 初始化CMDB示例数据
+func (m *MigrateServer) initialCMDBSampleData(ctx context.Context) error {
+	// 1. 创建示例资源数据
 	resources := []model.Resource{
 		{
 			ResourceID:  "server-001",
@@ -479,7 +757,7 @@ func (m *MigrateServer) initialCMDBData(ctx context.Context) error {
 		return err
 	}
 
-	// 4. 创建资源标签
+	// 2. 创建资源标签
 	var serverResource, cdnResource model.Resource
 	m.db.Where("resource_id = ?", "server-001").First(&serverResource)
 	m.db.Where("resource_id = ?", "cdn-node-001").First(&cdnResource)
@@ -498,7 +776,7 @@ func (m *MigrateServer) initialCMDBData(ctx context.Context) error {
 		return err
 	}
 
-	// 5. 创建服务定义
+	// 3. 创建服务定义
 	services := []model.Service{
 		{
 			ServiceID:   "web-service-001",
@@ -529,7 +807,7 @@ func (m *MigrateServer) initialCMDBData(ctx context.Context) error {
 		return err
 	}
 
-	// 6. 创建业务定义
+	// 4. 创建业务定义
 	businesses := []model.Business{
 		{
 			BusinessID:  "web-service",
@@ -551,93 +829,7 @@ func (m *MigrateServer) initialCMDBData(ctx context.Context) error {
 		return err
 	}
 
-	// 7. 创建应用类型定义
-	applicationTypes := []model.ApplicationType{
-		{
-			TypeName:    model.AppTypeDNSServer,
-			DisplayName: "DNS服务器",
-			Category:    "network",
-			Version:     "1.0.0",
-			Icon:        "dns",
-			Color:       "#13c2c2",
-			ResourceRequirements: model.JSONMap{
-				"min_cpu":    0.5,
-				"min_memory": 512,
-				"min_disk":   1024,
-			},
-			ConfigSchema: model.JSONMap{
-				"properties": map[string]interface{}{
-					"listen_port":      map[string]interface{}{"type": "integer", "default": 53},
-					"upstream_dns":     map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
-					"cache_size":       map[string]interface{}{"type": "integer", "default": 1000},
-					"log_level":        map[string]interface{}{"type": "string", "default": "info"},
-					"enable_recursion": map[string]interface{}{"type": "boolean", "default": true},
-				},
-			},
-			DefaultConfig: model.JSONMap{
-				"listen_port":      53,
-				"upstream_dns":     []string{"8.8.8.8", "8.8.4.4"},
-				"cache_size":       1000,
-				"log_level":        "info",
-				"enable_recursion": true,
-			},
-			HealthCheckConfig: model.JSONMap{
-				"method":   "dns_query",
-				"query":    "health.check",
-				"timeout":  5,
-				"interval": 30,
-			},
-			DeploymentMethods: []string{"binary", "docker"},
-			SupportedOS:       []string{"linux", "windows"},
-			Description:       "DNS服务器应用类型",
-			IsActive:          true,
-		},
-		{
-			TypeName:    model.AppTypeCacheService,
-			DisplayName: "缓存服务",
-			Category:    "cache",
-			Version:     "1.0.0",
-			Icon:        "cache",
-			Color:       "#f5222d",
-			ResourceRequirements: model.JSONMap{
-				"min_cpu":    1.0,
-				"min_memory": 1024,
-				"min_disk":   2048,
-			},
-			ConfigSchema: model.JSONMap{
-				"properties": map[string]interface{}{
-					"port":        map[string]interface{}{"type": "integer", "default": 6379},
-					"max_memory":  map[string]interface{}{"type": "string", "default": "1gb"},
-					"persistence": map[string]interface{}{"type": "boolean", "default": true},
-					"max_clients": map[string]interface{}{"type": "integer", "default": 10000},
-					"timeout":     map[string]interface{}{"type": "integer", "default": 300},
-				},
-			},
-			DefaultConfig: model.JSONMap{
-				"port":        6379,
-				"max_memory":  "1gb",
-				"persistence": true,
-				"max_clients": 10000,
-				"timeout":     300,
-			},
-			HealthCheckConfig: model.JSONMap{
-				"method":   "tcp_connect",
-				"timeout":  3,
-				"interval": 15,
-			},
-			DeploymentMethods: []string{"binary", "docker", "k8s"},
-			SupportedOS:       []string{"linux", "windows", "macos"},
-			Description:       "缓存服务应用类型",
-			IsActive:          true,
-		},
-	}
-
-	if err := m.db.Create(&applicationTypes).Error; err != nil {
-		m.log.Error("创建应用类型失败", zap.Error(err))
-		return err
-	}
-
-	// 8. 创建应用实例
+	// 5. 创建应用实例
 	var dnsAppType, cacheAppType model.ApplicationType
 	m.db.Where("type_name = ?", model.AppTypeDNSServer).First(&dnsAppType)
 	m.db.Where("type_name = ?", model.AppTypeCacheService).First(&cacheAppType)
@@ -698,7 +890,7 @@ func (m *MigrateServer) initialCMDBData(ctx context.Context) error {
 		return err
 	}
 
-	// 9. 创建配置实例
+	// 6. 创建配置实例
 	var dnsApp, cacheApp model.Application
 	m.db.Where("app_id = ?", "dns-app-001").First(&dnsApp)
 	m.db.Where("app_id = ?", "cache-app-001").First(&cacheApp)
@@ -776,7 +968,7 @@ func (m *MigrateServer) initialCMDBData(ctx context.Context) error {
 		return err
 	}
 
-	// 10. 创建多维度关联关系
+	// 7. 创建多维度关联关系
 	associations := []model.MultiDimensionAssociation{
 		{
 			AssocID:        "deploy-web-dns-001",
@@ -834,7 +1026,7 @@ func (m *MigrateServer) initialCMDBData(ctx context.Context) error {
 		return err
 	}
 
-	// 11. 创建通用关系
+	// 8. 创建通用关系
 	universalRelations := []model.UniversalRelation{
 		{
 			RelationID:   "rel-resource-app-dns-001",
@@ -895,7 +1087,6 @@ func (m *MigrateServer) initialCMDBData(ctx context.Context) error {
 		return err
 	}
 
-	m.log.Info("CMDB基础数据初始化完成")
 	return nil
 }
 
@@ -0,0 +1,267 @@
+package server
+
+import (
+	"fmt"
+	"nunu-layout-admin/internal/model"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// migration 一个版本化的数据库迁移，Up和Down与版本记录在同一个事务中执行
+type migration struct {
+	Version uint
+	Name    string
+	Up      func(tx *gorm.DB) error
+	Down    func(tx *gorm.DB) error
+}
+
+// migrations 按版本号递增排列。已发布的版本不要再修改，表结构变更请追加新的版本。
+// 初始版本使用AutoMigrate，对旧的无版本记录的数据库执行时只会补齐缺失的表和列
+var migrations = []migration{
+	{Version: 1, Name: "create_admin_tables", Up: createTables(adminTables), Down: dropTables(adminTables)},
+	{Version: 2, Name: "create_cmdb_core_tables", Up: createTables(cmdbCoreTables), Down: dropTables(cmdbCoreTables)},
+	{Version: 3, Name: "create_cmdb_history_tables", Up: createTables(cmdbHistoryTables), Down: dropTables(cmdbHistoryTables)},
+	{Version: 4, Name: "create_cmdb_cache_tables", Up: createTables(cmdbCacheTables), Down: dropTables(cmdbCacheTables)},
+	{Version: 5, Name: "create_cmdb_application_tables", Up: createTables(cmdbApplicationTables), Down: dropTables(cmdbApplicationTables)},
+	{Version: 6, Name: "create_cmdb_relation_tables", Up: createTables(cmdbRelationTables), Down: dropTables(cmdbRelationTables)},
+	{Version: 7, Name: "create_cmdb_notification_deliveries", Up: createTables(cmdbNotificationTables), Down: dropTables(cmdbNotificationTables)},
+	{Version: 8, Name: "create_cmdb_configuration_revisions", Up: createTables(cmdbConfigurationTables), Down: dropTables(cmdbConfigurationTables)},
+	{Version: 9, Name: "create_cmdb_configuration_renders", Up: createTables(cmdbConfigurationRenderTables), Down: dropTables(cmdbConfigurationRenderTables)},
+	{Version: 10, Name: "create_cmdb_application_history", Up: createTables(cmdbApplicationHistoryTables), Down: dropTables(cmdbApplicationHistoryTables)},
+	{Version: 11, Name: "create_cmdb_application_probe_results", Up: createTables(cmdbApplicationProbeTables), Down: dropTables(cmdbApplicationProbeTables)},
+	{Version: 12, Name: "add_cmdb_business_health_status", Up: addColumns(&model.Business{}, "HealthStatus"), Down: dropColumns(&model.Business{}, "HealthStatus")},
+	{Version: 13, Name: "create_cmdb_service_health_rules", Up: createTables(cmdbServiceHealthTables), Down: dropTables(cmdbServiceHealthTables)},
+	{Version: 14, Name: "create_cmdb_service_health_transitions", Up: createServiceHealthTransitions, Down: dropTables(cmdbServiceHealthTransitionTables)},
+	{Version: 15, Name: "add_cmdb_relation_edge_indexes", Up: createRelationEdgeIndexes, Down: dropRelationEdgeIndexes},
+	{Version: 16, Name: "remove_cmdb_agent_api_permissions", Up: removeAgentApiPermissions, Down: noop},
+}
+
+var (
+	adminTables = []interface{}{
+		&model.AdminUser{},
+		&model.Menu{},
+		&model.Role{},
+		&model.Api{},
+	}
+	// CMDB 核心表
+	cmdbCoreTables = []interface{}{
+		&model.Resource{},
+		&model.ResourceTag{},
+		&model.ResourceRelation{},
+		&model.ResourceType{},
+		&model.ResourceTypeSchema{},
+		&model.Service{},
+		&model.ServiceResource{},
+		&model.ServiceTag{},
+		&model.Business{},
+		&model.BusinessService{},
+		&model.BusinessTag{},
+	}
+	// CMDB 历史表
+	cmdbHistoryTables = []interface{}{
+		&model.ResourceHistory{},
+		&model.ServiceHistory{},
+		&model.BusinessHistory{},
+		&model.RelationHistory{},
+		&model.UniversalRelationHistory{},
+		&model.ResourceSnapshot{},
+		&model.AuditConfig{},
+		&model.SyncLog{},
+	}
+	// CMDB 缓存表
+	cmdbCacheTables = []interface{}{
+		&model.RelationCache{},
+		&model.ResourceView{},
+		&model.SearchIndex{},
+		&model.QueryPerformance{},
+		&model.CacheManager{},
+		&model.DataStatistics{},
+	}
+	// CMDB 应用层表
+	cmdbApplicationTables = []interface{}{
+		&model.ApplicationType{},
+		&model.Application{},
+		&model.Configuration{},
+		&model.ApplicationTag{},
+		&model.ConfigurationTag{},
+		&model.ConfigurationTemplate{},
+		&model.ApplicationDependency{},
+		&model.ApplicationGroup{},
+		&model.ApplicationGroupMember{},
+	}
+	// CMDB 关系表
+	cmdbRelationTables = []interface{}{
+		&model.UniversalRelation{},
+		&model.UniversalRelationTag{},
+		&model.MultiDimensionAssociation{},
+		&model.RelationPath{},
+		&model.DependencyGraph{},
+		&model.RelationRule{},
+	}
+	// CMDB 变更通知表
+	cmdbNotificationTables = []interface{}{
+		&model.NotificationDelivery{},
+	}
+	// CMDB 配置版本表
+	cmdbConfigurationTables = []interface{}{
+		&model.ConfigurationRevision{},
+	}
+	// CMDB 配置模板渲染记录表
+	cmdbConfigurationRenderTables = []interface{}{
+		&model.ConfigurationRender{},
+	}
+	// CMDB 应用实例变更历史表
+	cmdbApplicationHistoryTables = []interface{}{
+		&model.ApplicationHistory{},
+	}
+	// CMDB 应用健康检查探测结果表
+	cmdbApplicationProbeTables = []interface{}{
+		&model.ApplicationProbeResult{},
+	}
+	// CMDB 服务健康汇总规则表
+	cmdbServiceHealthTables = []interface{}{
+		&model.ServiceHealthRule{},
+	}
+	cmdbServiceHealthTransitionTables = []interface{}{
+		&model.ServiceHealthTransition{},
+	}
+)
+
+func createTables(tables []interface{}) func(tx *gorm.DB) error {
+	return func(tx *gorm.DB) error {
+		return tx.AutoMigrate(tables...)
+	}
+}
+
+// createServiceHealthTransitions 创建健康状态变化表，并以迁移时各服务的健康状态作为初始记录
+func createServiceHealthTransitions(tx *gorm.DB) error {
+	if err := tx.AutoMigrate(cmdbServiceHealthTransitionTables...); err != nil {
+		return err
+	}
+	var services []model.Service
+	if err := tx.Where("health_status <> ''").Find(&services).Error; err != nil {
+		return err
+	}
+	now := time.Now()
+	for _, service := range services {
+		if err := tx.Create(&model.ServiceHealthTransition{
+			ServiceID:   service.ID,
+			ServiceUUID: service.ServiceID,
+			ToStatus:    service.HealthStatus,
+			ChangeTime:  now,
+			Reason:      "迁移时的健康状态",
+		}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// relationEdgeIndexes 同一源、目标和关系类型只能有一条未删除的关系
+var relationEdgeIndexes = []struct {
+	table   interface{}
+	name    string
+	columns []string
+}{
+	{&model.UniversalRelation{}, "idx_universal_relation_edge", []string{"source_type", "source_id", "target_type", "target_id", "relation_type"}},
+	{&model.ResourceRelation{}, "idx_resource_relation_edge", []string{"source_id", "target_id", "relation_type"}},
+}
+
+// createRelationEdgeIndexes 为已有的关系表补充唯一索引。已存在重复关系时迁移失败，需要先删除重复的关系
+func createRelationEdgeIndexes(tx *gorm.DB) error {
+	for _, index := range relationEdgeIndexes {
+		if tx.Migrator().HasIndex(index.table, index.name) {
+			continue
+		}
+		var duplicates []map[string]interface{}
+		if err := tx.Model(index.table).Select(index.columns).Group(strings.Join(index.columns, ", ")).
+			Having("COUNT(*) > 1").Limit(10).Find(&duplicates).Error; err != nil {
+			return err
+		}
+		if len(duplicates) > 0 {
+			return fmt.Errorf("duplicate relations block unique index %s, remove them first: %v", index.name, duplicates)
+		}
+		if err := tx.Migrator().CreateIndex(index.table, index.name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func dropRelationEdgeIndexes(tx *gorm.DB) error {
+	for _, index := range relationEdgeIndexes {
+		if !tx.Migrator().HasIndex(index.table, index.name) {
+			continue
+		}
+		if err := tx.Migrator().DropIndex(index.table, index.name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// agentApiPaths 改用Agent令牌认证的接口，不再出现在接口列表和角色权限中
+var agentApiPaths = []string{"/v1/cmdb/application/heartbeat", "/v1/cmdb/applications/heartbeat"}
+
+// removeAgentApiPermissions 删除旧版本为Agent上报接口初始化的接口记录和casbin权限
+func removeAgentApiPermissions(tx *gorm.DB) error {
+	if err := tx.Unscoped().Where("path IN ?", agentApiPaths).Delete(&model.Api{}).Error; err != nil {
+		return err
+	}
+	if !tx.Migrator().HasTable("casbin_rule") {
+		return nil
+	}
+	resources := make([]string, 0, len(agentApiPaths))
+	for _, path := range agentApiPaths {
+		resources = append(resources, model.ApiResourcePrefix+path)
+	}
+	return tx.Table("casbin_rule").Where("ptype = ? AND v1 IN ?", "p", resources).Delete(nil).Error
+}
+
+// noop 不需要回滚的迁移
+func noop(tx *gorm.DB) error {
+	return nil
+}
+
+// addColumns 为已有的表补充列。新库在创建表时已包含这些列，此时跳过
+func addColumns(table interface{}, fields ...string) func(tx *gorm.DB) error {
+	return func(tx *gorm.DB) error {
+		for _, field := range fields {
+			if tx.Migrator().HasColumn(table, field) {
+				continue
+			}
+			if err := tx.Migrator().AddColumn(table, field); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func dropColumns(table interface{}, fields ...string) func(tx *gorm.DB) error {
+	return func(tx *gorm.DB) error {
+		for _, field := range fields {
+			if !tx.Migrator().HasColumn(table, field) {
+				continue
+			}
+			if err := tx.Migrator().DropColumn(table, field); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// dropTables 按创建的逆序删除表
+func dropTables(tables []interface{}) func(tx *gorm.DB) error {
+	return func(tx *gorm.DB) error {
+		for i := len(tables) - 1; i >= 0; i-- {
+			if err := tx.Migrator().DropTable(tables[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
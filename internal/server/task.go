@@ -10,18 +10,48 @@ import (
 )
 
 type TaskServer struct {
-	log       *log.Logger
-	scheduler *gocron.Scheduler
-	userTask  task.UserTask
+	log          *log.Logger
+	scheduler    *gocron.Scheduler
+	userTask     task.UserTask
+	pathTask     task.PathTask
+	graphTask    task.DependencyGraphTask
+	snapshotTask task.SnapshotTask
+	auditTask    task.AuditTask
+	notifyTask   task.NotificationTask
+	configTask   task.ConfigurationTask
+	appTask      task.ApplicationTask
+	healthTask   task.HealthCheckTask
+	rollupTask   task.HealthRollupTask
+	slaTask      task.SLATask
 }
 
 func NewTaskServer(
 	log *log.Logger,
 	userTask task.UserTask,
+	pathTask task.PathTask,
+	graphTask task.DependencyGraphTask,
+	snapshotTask task.SnapshotTask,
+	auditTask task.AuditTask,
+	notifyTask task.NotificationTask,
+	configTask task.ConfigurationTask,
+	appTask task.ApplicationTask,
+	healthTask task.HealthCheckTask,
+	rollupTask task.HealthRollupTask,
+	slaTask task.SLATask,
 ) *TaskServer {
 	return &TaskServer{
-		log:      log,
-		userTask: userTask,
+		log:          log,
+		userTask:     userTask,
+		pathTask:     pathTask,
+		graphTask:    graphTask,
+		snapshotTask: snapshotTask,
+		auditTask:    auditTask,
+		notifyTask:   notifyTask,
+		configTask:   configTask,
+		appTask:      appTask,
+		healthTask:   healthTask,
+		rollupTask:   rollupTask,
+		slaTask:      slaTask,
 	}
 }
 func (t *TaskServer) Start(ctx context.Context) error {
@@ -45,6 +75,127 @@ func (t *TaskServer) Start(ctx context.Context) error {
 		t.log.Error("CheckUser error", zap.Error(err))
 	}
 
+	// 每10分钟清理过期的关系路径缓存
+	_, err = t.scheduler.CronWithSeconds("0 */10 * * * *").Do(func() {
+		err := t.pathTask.PurgeExpiredPaths(ctx)
+		if err != nil {
+			t.log.Error("PurgeExpiredPaths error", zap.Error(err))
+		}
+	})
+	if err != nil {
+		t.log.Error("PurgeExpiredPaths error", zap.Error(err))
+	}
+
+	// 每小时重新计算一次依赖图，无变化时不产生新版本
+	_, err = t.scheduler.CronWithSeconds("0 0 * * * *").Do(func() {
+		err := t.graphTask.ComputeDependencyGraphs(ctx)
+		if err != nil {
+			t.log.Error("ComputeDependencyGraphs error", zap.Error(err))
+		}
+	})
+	if err != nil {
+		t.log.Error("ComputeDependencyGraphs error", zap.Error(err))
+	}
+
+	// 每天凌晨清理过期的快照文件
+	_, err = t.scheduler.CronWithSeconds("0 30 3 * * *").Do(func() {
+		err := t.snapshotTask.PurgeExpiredSnapshots(ctx)
+		if err != nil {
+			t.log.Error("PurgeExpiredSnapshots error", zap.Error(err))
+		}
+	})
+	if err != nil {
+		t.log.Error("PurgeExpiredSnapshots error", zap.Error(err))
+	}
+
+	// 每天凌晨按审计配置清理过期的变更历史
+	_, err = t.scheduler.CronWithSeconds("0 0 4 * * *").Do(func() {
+		err := t.auditTask.PurgeExpiredHistories(ctx)
+		if err != nil {
+			t.log.Error("PurgeExpiredHistories error", zap.Error(err))
+		}
+	})
+	if err != nil {
+		t.log.Error("PurgeExpiredHistories error", zap.Error(err))
+	}
+
+	// 每15秒发送到期的变更通知
+	_, err = t.scheduler.CronWithSeconds("0/15 * * * * *").Do(func() {
+		err := t.notifyTask.DispatchNotifications(ctx)
+		if err != nil {
+			t.log.Error("DispatchNotifications error", zap.Error(err))
+		}
+	})
+	if err != nil {
+		t.log.Error("DispatchNotifications error", zap.Error(err))
+	}
+
+	// 每分钟发布生效时间已到的配置版本
+	_, err = t.scheduler.CronWithSeconds("0 * * * * *").Do(func() {
+		err := t.configTask.PublishDueRevisions(ctx)
+		if err != nil {
+			t.log.Error("PublishDueRevisions error", zap.Error(err))
+		}
+	})
+	if err != nil {
+		t.log.Error("PublishDueRevisions error", zap.Error(err))
+	}
+
+	// 每30秒检查心跳超时的应用实例
+	_, err = t.scheduler.CronWithSeconds("0/30 * * * * *").Do(func() {
+		err := t.appTask.CheckHeartbeats(ctx)
+		if err != nil {
+			t.log.Error("CheckHeartbeats error", zap.Error(err))
+		}
+	})
+	if err != nil {
+		t.log.Error("CheckHeartbeats error", zap.Error(err))
+	}
+
+	// 每5秒探测到达检查间隔(HealthCheckConfig.interval)的应用实例，上一轮未结束时跳过本轮
+	_, err = t.scheduler.CronWithSeconds("0/5 * * * * *").SingletonMode().Do(func() {
+		err := t.healthTask.RunHealthChecks(ctx)
+		if err != nil {
+			t.log.Error("RunHealthChecks error", zap.Error(err))
+		}
+	})
+	if err != nil {
+		t.log.Error("RunHealthChecks error", zap.Error(err))
+	}
+
+	// 每30秒由应用实例健康状态汇总服务和业务的健康状态
+	_, err = t.scheduler.CronWithSeconds("15/30 * * * * *").Do(func() {
+		err := t.rollupTask.RollupHealth(ctx)
+		if err != nil {
+			t.log.Error("RollupHealth error", zap.Error(err))
+		}
+	})
+	if err != nil {
+		t.log.Error("RollupHealth error", zap.Error(err))
+	}
+
+	// 每10分钟计算当前日、周、月窗口的服务SLA，跨越窗口时补算上一个窗口
+	_, err = t.scheduler.CronWithSeconds("0 */10 * * * *").Do(func() {
+		err := t.slaTask.CalculateSLA(ctx)
+		if err != nil {
+			t.log.Error("CalculateSLA error", zap.Error(err))
+		}
+	})
+	if err != nil {
+		t.log.Error("CalculateSLA error", zap.Error(err))
+	}
+
+	// 每天凌晨清理过期的健康检查探测结果
+	_, err = t.scheduler.CronWithSeconds("0 30 4 * * *").Do(func() {
+		err := t.healthTask.PurgeProbeResults(ctx)
+		if err != nil {
+			t.log.Error("PurgeProbeResults error", zap.Error(err))
+		}
+	})
+	if err != nil {
+		t.log.Error("PurgeProbeResults error", zap.Error(err))
+	}
+
 	t.scheduler.StartBlocking()
 	return nil
 }
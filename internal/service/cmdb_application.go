@@ -0,0 +1,479 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	v1 "nunu-layout-admin/api/v1"
+	"nunu-layout-admin/internal/model"
+	"nunu-layout-admin/internal/repository"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+)
+
+type ApplicationService interface {
+	GetApplications(ctx context.Context, req *v1.GetApplicationsRequest) (*v1.GetApplicationsResponseData, error)
+	GetApplication(ctx context.Context, appID string) (*v1.ApplicationDataItem, error)
+	ApplicationCreate(ctx context.Context, req *v1.ApplicationCreateRequest) error
+	ApplicationUpdate(ctx context.Context, req *v1.ApplicationUpdateRequest) error
+	ApplicationDelete(ctx context.Context, appID string) error
+	// ApplicationLifecycle 按状态机执行生命周期操作并记录历史
+	ApplicationLifecycle(ctx context.Context, req *v1.ApplicationLifecycleRequest) (*v1.ApplicationLifecycleResponseData, error)
+	// ApplicationHeartbeat 处理Agent心跳，更新运行时信息，启动中的实例收到心跳后视为启动完成
+	ApplicationHeartbeat(ctx context.Context, req *v1.ApplicationHeartbeatRequest) (*v1.ApplicationHeartbeatResponseData, error)
+	ApplicationHeartbeatBatch(ctx context.Context, req *v1.ApplicationHeartbeatBatchRequest) (*v1.ApplicationHeartbeatBatchResponseData, error)
+	// CheckHeartbeats 按cmdb.heartbeat配置把心跳超时的实例标记为故障或不健康，返回处理的实例数
+	CheckHeartbeats(ctx context.Context) (int, error)
+}
+
+func NewApplicationService(
+	service *Service,
+	conf *viper.Viper,
+	applicationRepository repository.ApplicationRepository,
+	historyRepository repository.HistoryRepository,
+) ApplicationService {
+	return &applicationService{
+		Service:               service,
+		conf:                  conf,
+		applicationRepository: applicationRepository,
+		historyRepository:     historyRepository,
+	}
+}
+
+type applicationService struct {
+	*Service
+	conf                  *viper.Viper
+	applicationRepository repository.ApplicationRepository
+	historyRepository     repository.HistoryRepository
+}
+
+func (s *applicationService) GetApplications(ctx context.Context, req *v1.GetApplicationsRequest) (*v1.GetApplicationsResponseData, error) {
+	list, total, err := s.applicationRepository.GetApplications(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	data := &v1.GetApplicationsResponseData{
+		List:  make([]v1.ApplicationDataItem, 0, len(list)),
+		Total: total,
+	}
+	for _, m := range list {
+		data.List = append(data.List, toApplicationDataItem(m))
+	}
+	return data, nil
+}
+
+func (s *applicationService) GetApplication(ctx context.Context, appID string) (*v1.ApplicationDataItem, error) {
+	m, err := s.getApplication(ctx, appID)
+	if err != nil {
+		return nil, err
+	}
+	item := toApplicationDataItem(m)
+	return &item, nil
+}
+
+func (s *applicationService) ApplicationCreate(ctx context.Context, req *v1.ApplicationCreateRequest) error {
+	if req.AppID == "" {
+		id, err := s.sid.GenString()
+		if err != nil {
+			return err
+		}
+		req.AppID = id
+	}
+	if req.Status == "" {
+		req.Status = model.AppStatusStopped
+	}
+	_, err := s.applicationRepository.GetApplication(ctx, req.AppID)
+	if err == nil {
+		return v1.ErrAppIDAlreadyUse
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	appType, err := s.applicationRepository.GetApplicationTypeByName(ctx, req.TypeName)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return v1.ErrApplicationTypeNotFound
+		}
+		return err
+	}
+	if !appType.IsActive {
+		return v1.ErrApplicationTypeInactive
+	}
+	resource, err := s.getResource(ctx, req.ResourceID)
+	if err != nil {
+		return err
+	}
+	m := &model.Application{
+		AppID:          req.AppID,
+		Name:           req.Name,
+		TypeID:         appType.ID,
+		Version:        req.Version,
+		Status:         req.Status,
+		ResourceID:     resource.ID,
+		DeploymentType: req.DeploymentType,
+		WorkingDir:     req.WorkingDir,
+		ExecutablePath: req.ExecutablePath,
+		ListenPorts:    req.ListenPorts,
+		NetworkConfig:  req.NetworkConfig,
+		ResourceLimits: req.ResourceLimits,
+		Environment:    req.Environment,
+		TenantID:       req.TenantID,
+		Description:    req.Description,
+		Tags:           toApplicationTags(req.Tags),
+	}
+	// 登记已在运行的实例时以登记时间作为启动时间
+	if m.Status == model.AppStatusRunning {
+		now := time.Now()
+		m.StartTime = &now
+	}
+	return s.tm.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.applicationRepository.ApplicationCreate(ctx, m); err != nil {
+			return err
+		}
+		m.ApplicationType = appType
+		m.Resource = resource
+		return recordApplicationHistory(ctx, s.historyRepository, model.ChangeTypeCreate, nil, m, "", "")
+	})
+}
+
+func (s *applicationService) ApplicationUpdate(ctx context.Context, req *v1.ApplicationUpdateRequest) error {
+	old, err := s.getApplication(ctx, req.AppID)
+	if err != nil {
+		return err
+	}
+	resource, err := s.getResource(ctx, req.ResourceID)
+	if err != nil {
+		return err
+	}
+	return s.tm.Transaction(ctx, func(ctx context.Context) error {
+		err := s.applicationRepository.ApplicationUpdate(ctx, &model.Application{
+			Model:          gorm.Model{ID: old.ID},
+			Name:           req.Name,
+			Version:        req.Version,
+			ResourceID:     resource.ID,
+			DeploymentType: req.DeploymentType,
+			WorkingDir:     req.WorkingDir,
+			ExecutablePath: req.ExecutablePath,
+			ListenPorts:    req.ListenPorts,
+			NetworkConfig:  req.NetworkConfig,
+			ResourceLimits: req.ResourceLimits,
+			Environment:    req.Environment,
+			TenantID:       req.TenantID,
+			Description:    req.Description,
+		})
+		if err != nil {
+			return err
+		}
+		if err := s.applicationRepository.ReplaceApplicationTags(ctx, old.ID, toApplicationTags(req.Tags)); err != nil {
+			return err
+		}
+		m, err := s.applicationRepository.GetApplication(ctx, old.AppID)
+		if err != nil {
+			return err
+		}
+		return recordApplicationHistory(ctx, s.historyRepository, model.ChangeTypeUpdate, &old, &m, "", "")
+	})
+}
+
+func (s *applicationService) ApplicationDelete(ctx context.Context, appID string) error {
+	old, err := s.getApplication(ctx, appID)
+	if err != nil {
+		return err
+	}
+	if old.Status != model.AppStatusStopped && old.Status != model.AppStatusFailed {
+		return v1.ErrApplicationNotStopped
+	}
+	count, err := s.applicationRepository.CountApplicationConfigurations(ctx, old.ID)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return v1.ErrApplicationInUse
+	}
+	return s.tm.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.applicationRepository.ApplicationDelete(ctx, old.ID); err != nil {
+			return err
+		}
+		return recordApplicationHistory(ctx, s.historyRepository, model.ChangeTypeDelete, &old, nil, "", "")
+	})
+}
+
+func (s *applicationService) ApplicationLifecycle(ctx context.Context, req *v1.ApplicationLifecycleRequest) (*v1.ApplicationLifecycleResponseData, error) {
+	data := &v1.ApplicationLifecycleResponseData{AppID: req.AppID}
+	err := s.tm.Transaction(ctx, func(ctx context.Context) error {
+		old, err := s.getApplication(ctx, req.AppID)
+		if err != nil {
+			return err
+		}
+		m, err := applyAppTransition(old, req.Action, req.Version, time.Now())
+		if err != nil {
+			return err
+		}
+		// 读取后状态已被并发修改(如心跳或其他操作)时返回冲突，由调用方刷新后重试
+		ok, err := s.applicationRepository.ApplicationStatusUpdate(ctx, &m, old.Status)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return v1.ErrHistoryVersionConflict
+		}
+		data.FromStatus = old.Status
+		data.Status = m.Status
+		return recordApplicationHistory(ctx, s.historyRepository, model.ChangeTypeUpdate, &old, &m, req.Reason, req.Action)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func (s *applicationService) ApplicationHeartbeat(ctx context.Context, req *v1.ApplicationHeartbeatRequest) (*v1.ApplicationHeartbeatResponseData, error) {
+	m, err := s.heartbeat(ctx, req, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return &v1.ApplicationHeartbeatResponseData{
+		AppID:         m.AppID,
+		Status:        m.Status,
+		HealthStatus:  m.HealthStatus,
+		LastHeartbeat: m.LastHeartbeat.Format("2006-01-02 15:04:05"),
+	}, nil
+}
+
+func (s *applicationService) ApplicationHeartbeatBatch(ctx context.Context, req *v1.ApplicationHeartbeatBatchRequest) (*v1.ApplicationHeartbeatBatchResponseData, error) {
+	data := &v1.ApplicationHeartbeatBatchResponseData{
+		Total:   len(req.Heartbeats),
+		Results: make([]v1.ApplicationHeartbeatBatchItem, 0, len(req.Heartbeats)),
+	}
+	now := time.Now()
+	for i := range req.Heartbeats {
+		item := v1.ApplicationHeartbeatBatchItem{AppID: req.Heartbeats[i].AppID}
+		m, err := s.heartbeat(ctx, &req.Heartbeats[i], now)
+		switch {
+		case err == nil:
+			item.Success = true
+			item.Status = m.Status
+			item.HealthStatus = m.HealthStatus
+			data.Success++
+		case v1.IsKnownError(err):
+			item.Message = err.Error()
+			data.Failed++
+		default:
+			return nil, fmt.Errorf("application %s: %w", item.AppID, err)
+		}
+		data.Results = append(data.Results, item)
+	}
+	return data, nil
+}
+
+// heartbeat 在事务中处理一条心跳。上报的healthStatus为空时保持原健康状态，
+// 但因心跳超时被标记为不健康的实例恢复上报后置为healthy；故障实例需要先执行start才会在心跳后恢复running
+func (s *applicationService) heartbeat(ctx context.Context, req *v1.ApplicationHeartbeatRequest, now time.Time) (model.Application, error) {
+	var m model.Application
+	err := s.tm.Transaction(ctx, func(ctx context.Context) error {
+		old, err := s.getApplication(ctx, req.AppID)
+		if err != nil {
+			return err
+		}
+		m = old
+		reason := ""
+		if old.Status == model.AppStatusStarting {
+			if m, err = applyAppTransition(old, model.AppActionMarkRunning, "", now); err != nil {
+				return err
+			}
+			reason = "收到心跳，启动完成"
+		}
+		m.ProcessID = req.ProcessID
+		if req.Version != "" {
+			m.Version = req.Version
+		}
+		if req.ResourceUsage != nil {
+			m.ResourceUsage = req.ResourceUsage
+		}
+		if req.ListenPorts != nil {
+			m.ListenPorts = req.ListenPorts
+		}
+		switch {
+		case req.HealthStatus != "":
+			m.HealthStatus = req.HealthStatus
+		case old.HealthStatus == model.HealthStatusUnhealthy && s.heartbeatLost(old, now):
+			m.HealthStatus = model.HealthStatusHealthy
+			reason = "心跳恢复"
+		}
+		m.LastHeartbeat = &now
+		ok, err := s.applicationRepository.ApplicationRuntimeUpdate(ctx, &m, old.Status)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return v1.ErrHistoryVersionConflict
+		}
+		return recordApplicationHistory(ctx, s.historyRepository, model.ChangeTypeUpdate, &old, &m, reason, "heartbeat")
+	})
+	return m, err
+}
+
+func (s *applicationService) CheckHeartbeats(ctx context.Context) (int, error) {
+	ctx = WithChangeSource(ctx, model.ChangeSourceScheduled)
+	now := time.Now()
+	list, err := s.applicationRepository.GetStaleApplications(ctx, now.Add(-s.heartbeatTimeout()))
+	if err != nil {
+		return 0, err
+	}
+	action := s.heartbeatTimeoutAction()
+	marked := 0
+	for _, old := range list {
+		if action == model.HeartbeatTimeoutUnhealthy && old.HealthStatus == model.HealthStatusUnhealthy {
+			continue
+		}
+		err := s.tm.Transaction(ctx, func(ctx context.Context) error {
+			m := old
+			comment := model.AppActionMarkFailed
+			if action == model.HeartbeatTimeoutUnhealthy {
+				m.HealthStatus = model.HealthStatusUnhealthy
+				comment = "heartbeat"
+			} else {
+				var err error
+				if m, err = applyAppTransition(old, model.AppActionMarkFailed, "", now); err != nil {
+					return err
+				}
+			}
+			ok, err := s.applicationRepository.ApplicationRuntimeUpdate(ctx, &m, old.Status)
+			if err != nil || !ok {
+				// 扫描期间收到心跳或状态被修改，跳过
+				return err
+			}
+			marked++
+			reason := fmt.Sprintf("心跳超时，最后心跳时间%s", old.LastHeartbeat.Format("2006-01-02 15:04:05"))
+			return recordApplicationHistory(ctx, s.historyRepository, model.ChangeTypeUpdate, &old, &m, reason, comment)
+		})
+		if err != nil {
+			return marked, fmt.Errorf("application %s: %w", old.AppID, err)
+		}
+	}
+	return marked, nil
+}
+
+// heartbeatLost 距上次心跳是否已超过超时时间
+func (s *applicationService) heartbeatLost(m model.Application, now time.Time) bool {
+	return m.LastHeartbeat == nil || now.Sub(*m.LastHeartbeat) > s.heartbeatTimeout()
+}
+
+func (s *applicationService) heartbeatTimeout() time.Duration {
+	if timeout := s.conf.GetDuration("cmdb.heartbeat.timeout"); timeout > 0 {
+		return timeout
+	}
+	return 3 * time.Minute
+}
+
+func (s *applicationService) heartbeatTimeoutAction() string {
+	if s.conf.GetString("cmdb.heartbeat.timeout_action") == model.HeartbeatTimeoutUnhealthy {
+		return model.HeartbeatTimeoutUnhealthy
+	}
+	return model.HeartbeatTimeoutFailed
+}
+
+func (s *applicationService) getApplication(ctx context.Context, appID string) (model.Application, error) {
+	m, err := s.applicationRepository.GetApplication(ctx, appID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return m, v1.ErrNotFound
+		}
+		return m, err
+	}
+	return m, nil
+}
+
+func (s *applicationService) getResource(ctx context.Context, resourceID string) (model.Resource, error) {
+	m, err := s.applicationRepository.GetResource(ctx, resourceID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return m, v1.ErrResourceNotFound
+		}
+		return m, err
+	}
+	return m, nil
+}
+
+// applyAppTransition 按ApplicationTransitions检查操作是否允许，返回操作后的应用实例。
+// 进入running时(启动完成、升级完成)重新记录启动时间，停止完成后清空进程ID
+func applyAppTransition(m model.Application, action, version string, now time.Time) (model.Application, error) {
+	transition, ok := model.ApplicationTransitions[action]
+	if !ok {
+		return m, v1.ErrBadRequest
+	}
+	if !containsString(transition.From, m.Status) {
+		return m, &v1.ValidationError{
+			Err: v1.ErrApplicationTransition,
+			Fields: []v1.FieldError{{
+				Field:   "action",
+				Message: fmt.Sprintf("应用状态为%s，不能执行%s，允许的起始状态: %s", m.Status, action, strings.Join(transition.From, ", ")),
+			}},
+		}
+	}
+	m.Status = transition.To
+	switch action {
+	case model.AppActionMarkRunning:
+		m.StartTime = &now
+	case model.AppActionFinishUpgrade:
+		m.StartTime = &now
+		if version != "" {
+			m.Version = version
+		}
+	case model.AppActionMarkStopped:
+		m.ProcessID = 0
+	}
+	return m, nil
+}
+
+func toApplicationTags(items []v1.ApplicationTagItem) []model.ApplicationTag {
+	tags := make([]model.ApplicationTag, 0, len(items))
+	for _, item := range items {
+		tags = append(tags, model.ApplicationTag{Key: item.Key, Value: item.Value})
+	}
+	return tags
+}
+
+func toApplicationDataItem(m model.Application) v1.ApplicationDataItem {
+	item := v1.ApplicationDataItem{
+		ID:             m.ID,
+		AppID:          m.AppID,
+		Name:           m.Name,
+		TypeID:         m.TypeID,
+		TypeName:       m.ApplicationType.TypeName,
+		Version:        m.Version,
+		Status:         m.Status,
+		ResourceID:     m.Resource.ResourceID,
+		DeploymentType: m.DeploymentType,
+		WorkingDir:     m.WorkingDir,
+		ExecutablePath: m.ExecutablePath,
+		ListenPorts:    m.ListenPorts,
+		NetworkConfig:  m.NetworkConfig,
+		ResourceUsage:  m.ResourceUsage,
+		ResourceLimits: m.ResourceLimits,
+		Environment:    m.Environment,
+		TenantID:       m.TenantID,
+		ProcessID:      m.ProcessID,
+		HealthStatus:   m.HealthStatus,
+		Description:    m.Description,
+		Tags:           make([]v1.ApplicationTagItem, 0, len(m.Tags)),
+		CreatedAt:      m.CreatedAt.Format("2006-01-02 15:04:05"),
+		UpdatedAt:      m.UpdatedAt.Format("2006-01-02 15:04:05"),
+	}
+	if m.StartTime != nil {
+		item.StartTime = m.StartTime.Format("2006-01-02 15:04:05")
+	}
+	if m.LastHeartbeat != nil {
+		item.LastHeartbeat = m.LastHeartbeat.Format("2006-01-02 15:04:05")
+	}
+	if m.LastHealthCheck != nil {
+		item.LastHealthCheck = m.LastHealthCheck.Format("2006-01-02 15:04:05")
+	}
+	for _, tag := range m.Tags {
+		item.Tags = append(item.Tags, v1.ApplicationTagItem{Key: tag.Key, Value: tag.Value})
+	}
+	return item
+}
@@ -0,0 +1,310 @@
+package service
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"nunu-layout-admin/internal/model"
+	"nunu-layout-admin/internal/repository"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+const (
+	AuditPurgeModeArchive = "archive" // 压缩归档后删除
+	AuditPurgeModeDelete  = "delete"  // 直接删除
+
+	auditPurgeBatchSize = 500
+)
+
+// auditObjectTypes 受审计配置管理的历史对象类型
+var auditObjectTypes = []string{
+	model.ObjectTypeResource,
+	model.ObjectTypeService,
+	model.ObjectTypeBusiness,
+	model.HistoryObjectResourceRelation,
+	model.HistoryObjectUniversalRelation,
+	model.ObjectTypeApplication,
+}
+
+type AuditService interface {
+	// PurgeExpiredHistories 按审计配置的保留天数清理过期的变更历史，返回清理的条数
+	PurgeExpiredHistories(ctx context.Context) (int, error)
+}
+
+func NewAuditService(
+	service *Service,
+	conf *viper.Viper,
+	historyRepository repository.HistoryRepository,
+) AuditService {
+	return &auditService{
+		Service:           service,
+		conf:              conf,
+		historyRepository: historyRepository,
+	}
+}
+
+type auditService struct {
+	*Service
+	conf              *viper.Viper
+	historyRepository repository.HistoryRepository
+}
+
+// auditArchiveItem 归档文件中的一行
+type auditArchiveItem struct {
+	ObjectType    string        `json:"object_type"`
+	ID            uint          `json:"id"`
+	ObjectID      string        `json:"object_id"`
+	ChangeType    string        `json:"change_type"`
+	ChangeSource  string        `json:"change_source"`
+	ChangeTime    time.Time     `json:"change_time"`
+	OperatorID    string        `json:"operator_id"`
+	OperatorName  string        `json:"operator_name"`
+	OperatorIP    string        `json:"operator_ip"`
+	BeforeData    model.JSONMap `json:"before_data"`
+	AfterData     model.JSONMap `json:"after_data"`
+	ChangedFields model.JSONMap `json:"changed_fields"`
+	ChangeReason  string        `json:"change_reason"`
+	Comment       string        `json:"comment"`
+	Version       int64         `json:"version"`
+}
+
+func (s *auditService) PurgeExpiredHistories(ctx context.Context) (int, error) {
+	configs, err := s.historyRepository.GetAuditConfigs(ctx)
+	if err != nil {
+		return 0, err
+	}
+	// 先按最短的保留天数取候选记录，再按每条记录匹配到的配置判断是否过期
+	minDays := 0
+	for _, config := range configs {
+		if config.RetentionDays > 0 && (minDays == 0 || config.RetentionDays < minDays) {
+			minDays = config.RetentionDays
+		}
+	}
+	if minDays == 0 {
+		return 0, nil
+	}
+	now := time.Now()
+	total := 0
+	for _, objectType := range auditObjectTypes {
+		count, err := s.purgeHistories(ctx, objectType, configs, now, now.AddDate(0, 0, -minDays))
+		total += count
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+// purgeHistories 清理一种对象的过期历史，归档模式下归档文件写入成功后才删除记录
+func (s *auditService) purgeHistories(ctx context.Context, objectType string, configs []model.AuditConfig, now, before time.Time) (int, error) {
+	var archive *auditArchiveWriter
+	if s.purgeMode() == AuditPurgeModeArchive {
+		path := filepath.Join(s.archiveDir(), fmt.Sprintf("%s-%s.jsonl.gz", objectType, now.Format("20060102150405")))
+		archive = &auditArchiveWriter{path: path}
+		defer archive.abort()
+	}
+	var ids []uint
+	var afterID uint
+	for {
+		list, err := s.historyRepository.GetExpiredHistories(ctx, objectType, before, afterID, auditPurgeBatchSize)
+		if err != nil {
+			return 0, err
+		}
+		for _, m := range list {
+			afterID = m.ID
+			data := m.AfterData
+			if data == nil {
+				data = m.BeforeData
+			}
+			config := matchAuditConfig(configs, objectType, data)
+			if config == nil || config.RetentionDays <= 0 || !m.ChangeTime.Before(now.AddDate(0, 0, -config.RetentionDays)) {
+				continue
+			}
+			if archive != nil {
+				if err := archive.write(toAuditArchiveItem(objectType, m)); err != nil {
+					return 0, err
+				}
+			}
+			ids = append(ids, m.ID)
+		}
+		if len(list) < auditPurgeBatchSize {
+			break
+		}
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	if archive != nil {
+		if err := archive.close(); err != nil {
+			return 0, err
+		}
+		s.logger.WithContext(ctx).Info("archive expired histories", zap.String("objectType", objectType),
+			zap.String("path", archive.path), zap.Int("count", len(ids)))
+	}
+	for start := 0; start < len(ids); start += auditPurgeBatchSize {
+		end := start + auditPurgeBatchSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+		if err := s.historyRepository.HistoryDelete(ctx, objectType, ids[start:end]); err != nil {
+			return start, err
+		}
+	}
+	return len(ids), nil
+}
+
+func (s *auditService) purgeMode() string {
+	if mode := s.conf.GetString("cmdb.audit.purge_mode"); mode != "" {
+		return mode
+	}
+	return AuditPurgeModeArchive
+}
+
+func (s *auditService) archiveDir() string {
+	if dir := s.conf.GetString("cmdb.audit.archive_dir"); dir != "" {
+		return dir
+	}
+	return "storage/audit-archive"
+}
+
+// auditArchiveWriter 按行写入gzip压缩的JSON，先写临时文件，close时重命名为正式文件
+type auditArchiveWriter struct {
+	path string
+	f    *os.File
+	gz   *gzip.Writer
+	enc  *json.Encoder
+}
+
+func (w *auditArchiveWriter) write(item auditArchiveItem) error {
+	if w.f == nil {
+		if err := os.MkdirAll(filepath.Dir(w.path), 0755); err != nil {
+			return err
+		}
+		f, err := os.Create(w.path + ".tmp")
+		if err != nil {
+			return err
+		}
+		w.f = f
+		w.gz = gzip.NewWriter(f)
+		w.enc = json.NewEncoder(w.gz)
+	}
+	return w.enc.Encode(item)
+}
+
+func (w *auditArchiveWriter) close() error {
+	if w.f == nil {
+		return nil
+	}
+	if err := w.gz.Close(); err != nil {
+		return err
+	}
+	if err := w.f.Close(); err != nil {
+		return err
+	}
+	w.f = nil
+	return os.Rename(w.path+".tmp", w.path)
+}
+
+// abort 清理未完成的临时文件，close成功后调用无影响
+func (w *auditArchiveWriter) abort() {
+	if w.f == nil {
+		return
+	}
+	w.f.Close()
+	os.Remove(w.path + ".tmp")
+}
+
+func toAuditArchiveItem(objectType string, m repository.HistoryRecord) auditArchiveItem {
+	return auditArchiveItem{
+		ObjectType:    objectType,
+		ID:            m.ID,
+		ObjectID:      m.ObjectID,
+		ChangeType:    m.ChangeType,
+		ChangeSource:  m.ChangeSource,
+		ChangeTime:    m.ChangeTime,
+		OperatorID:    m.OperatorID,
+		OperatorName:  m.OperatorName,
+		OperatorIP:    m.OperatorIP,
+		BeforeData:    m.BeforeData,
+		AfterData:     m.AfterData,
+		ChangedFields: m.ChangedFields,
+		ChangeReason:  m.ChangeReason,
+		Comment:       m.Comment,
+		Version:       m.Version,
+	}
+}
+
+// matchAuditConfig 按类型和租户选择最具体的审计配置：类型+租户 > 类型 > *+租户 > *，没有匹配时返回nil。
+// 资源按资源类型匹配，其他对象按对象类型(service、business等)匹配，类型和租户从历史快照中取
+func matchAuditConfig(configs []model.AuditConfig, objectType string, data model.JSONMap) *model.AuditConfig {
+	auditType := objectType
+	if objectType == model.ObjectTypeResource {
+		auditType, _ = data["type"].(string)
+	}
+	tenantID, _ := data["tenant_id"].(string)
+	var matched *model.AuditConfig
+	best := -1
+	for i := range configs {
+		config := &configs[i]
+		score := 0
+		switch config.ResourceType {
+		case auditType:
+			score += 2
+		case "*":
+		default:
+			continue
+		}
+		switch config.TenantID {
+		case tenantID:
+			if tenantID != "" {
+				score++
+			}
+		case "":
+		default:
+			continue
+		}
+		// 同样具体的配置以后创建的为准
+		if score >= best {
+			matched, best = config, score
+		}
+	}
+	return matched
+}
+
+// filterMonitoredFields 只保留监控字段的变化。monitored为 分组 -> 字段列表，字段匹配自身、
+// 其下的嵌套字段(attributes匹配attributes.cpu)以及同名的嵌套字段(ip_address匹配attributes.ip_address)；
+// 没有配置监控字段时不过滤
+func filterMonitoredFields(changed, monitored model.JSONMap) model.JSONMap {
+	fields := make([]string, 0)
+	for _, group := range monitored {
+		list, ok := group.([]interface{})
+		if !ok {
+			continue
+		}
+		for _, field := range list {
+			if name, ok := field.(string); ok && name != "" {
+				fields = append(fields, name)
+			}
+		}
+	}
+	if len(fields) == 0 {
+		return changed
+	}
+	filtered := model.JSONMap{}
+	for path, value := range changed {
+		for _, field := range fields {
+			if path == field || strings.HasPrefix(path, field+".") || strings.HasSuffix(path, "."+field) {
+				filtered[path] = value
+				break
+			}
+		}
+	}
+	return filtered
+}
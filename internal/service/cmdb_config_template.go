@@ -0,0 +1,439 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	v1 "nunu-layout-admin/api/v1"
+	"nunu-layout-admin/internal/model"
+	"nunu-layout-admin/internal/repository"
+	"nunu-layout-admin/pkg/schema"
+	"regexp"
+	"sort"
+
+	"gorm.io/gorm"
+)
+
+type ConfigTemplateService interface {
+	GetConfigTemplates(ctx context.Context, req *v1.GetConfigTemplatesRequest) (*v1.GetConfigTemplatesResponseData, error)
+	GetConfigTemplate(ctx context.Context, templateID string) (*v1.ConfigTemplateDataItem, error)
+	ConfigTemplateCreate(ctx context.Context, req *v1.ConfigTemplateCreateRequest) error
+	ConfigTemplateUpdate(ctx context.Context, req *v1.ConfigTemplateUpdateRequest) error
+	ConfigTemplateDelete(ctx context.Context, templateID string) error
+}
+
+func NewConfigTemplateService(
+	service *Service,
+	configTemplateRepository repository.ConfigTemplateRepository,
+	historyRepository repository.HistoryRepository,
+) ConfigTemplateService {
+	return &configTemplateService{
+		Service:                  service,
+		configTemplateRepository: configTemplateRepository,
+		historyRepository:        historyRepository,
+	}
+}
+
+type configTemplateService struct {
+	*Service
+	configTemplateRepository repository.ConfigTemplateRepository
+	historyRepository        repository.HistoryRepository
+}
+
+func (s *configTemplateService) GetConfigTemplates(ctx context.Context, req *v1.GetConfigTemplatesRequest) (*v1.GetConfigTemplatesResponseData, error) {
+	list, total, err := s.configTemplateRepository.GetConfigTemplates(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	data := &v1.GetConfigTemplatesResponseData{
+		List:  make([]v1.ConfigTemplateDataItem, 0, len(list)),
+		Total: total,
+	}
+	for _, m := range list {
+		data.List = append(data.List, toConfigTemplateDataItem(m))
+	}
+	return data, nil
+}
+
+func (s *configTemplateService) GetConfigTemplate(ctx context.Context, templateID string) (*v1.ConfigTemplateDataItem, error) {
+	m, err := s.configTemplateRepository.GetConfigTemplate(ctx, templateID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, v1.ErrNotFound
+		}
+		return nil, err
+	}
+	item := toConfigTemplateDataItem(m)
+	return &item, nil
+}
+
+func (s *configTemplateService) ConfigTemplateCreate(ctx context.Context, req *v1.ConfigTemplateCreateRequest) error {
+	if req.TemplateID == "" {
+		id, err := s.sid.GenString()
+		if err != nil {
+			return err
+		}
+		req.TemplateID = id
+	}
+	_, err := s.configTemplateRepository.GetConfigTemplate(ctx, req.TemplateID)
+	if err == nil {
+		return v1.ErrConfigTemplateIDAlreadyUse
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	appType, err := s.configTemplateRepository.GetApplicationType(ctx, req.AppTypeID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return v1.ErrApplicationTypeNotFound
+		}
+		return err
+	}
+	operator := requestOperatorName(ctx, s.historyRepository)
+	m := &model.ConfigurationTemplate{
+		TemplateID:    req.TemplateID,
+		Name:          req.Name,
+		AppTypeID:     req.AppTypeID,
+		TemplateData:  req.TemplateData,
+		Variables:     req.Variables,
+		BusinessTypes: req.BusinessTypes,
+		Environments:  req.Environments,
+		Version:       req.Version,
+		Category:      req.Category,
+		IsDefault:     req.IsDefault,
+		IsActive:      true,
+		CreatedBy:     operator,
+		UpdatedBy:     operator,
+		Description:   req.Description,
+	}
+	if err := validateConfigTemplate(*m, appType); err != nil {
+		return err
+	}
+	return s.tm.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.configTemplateRepository.ConfigTemplateCreate(ctx, m); err != nil {
+			return err
+		}
+		if !m.IsDefault {
+			return nil
+		}
+		return s.configTemplateRepository.ClearDefaultConfigTemplates(ctx, m.AppTypeID, m.ID)
+	})
+}
+
+func (s *configTemplateService) ConfigTemplateUpdate(ctx context.Context, req *v1.ConfigTemplateUpdateRequest) error {
+	old, err := s.configTemplateRepository.GetConfigTemplate(ctx, req.TemplateID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return v1.ErrNotFound
+		}
+		return err
+	}
+	appType, err := s.configTemplateRepository.GetApplicationType(ctx, old.AppTypeID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return v1.ErrApplicationTypeNotFound
+		}
+		return err
+	}
+	m := &model.ConfigurationTemplate{
+		Model:         gorm.Model{ID: old.ID},
+		AppTypeID:     old.AppTypeID,
+		Name:          req.Name,
+		TemplateData:  req.TemplateData,
+		Variables:     req.Variables,
+		BusinessTypes: req.BusinessTypes,
+		Environments:  req.Environments,
+		Version:       req.Version,
+		Category:      req.Category,
+		IsDefault:     req.IsDefault,
+		IsActive:      req.IsActive,
+		UpdatedBy:     requestOperatorName(ctx, s.historyRepository),
+		Description:   req.Description,
+	}
+	if err := validateConfigTemplate(*m, appType); err != nil {
+		return err
+	}
+	return s.tm.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.configTemplateRepository.ConfigTemplateUpdate(ctx, m); err != nil {
+			return err
+		}
+		if !m.IsDefault {
+			return nil
+		}
+		return s.configTemplateRepository.ClearDefaultConfigTemplates(ctx, m.AppTypeID, m.ID)
+	})
+}
+
+func (s *configTemplateService) ConfigTemplateDelete(ctx context.Context, templateID string) error {
+	old, err := s.configTemplateRepository.GetConfigTemplate(ctx, templateID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return v1.ErrNotFound
+		}
+		return err
+	}
+	count, err := s.configTemplateRepository.CountTemplateConfigurations(ctx, old.TemplateID)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return v1.ErrConfigTemplateInUse
+	}
+	return s.configTemplateRepository.ConfigTemplateDelete(ctx, old.ID)
+}
+
+// templateVariablePattern 模板中的变量引用${name}
+var templateVariablePattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// templateReference 模板数据中一处变量引用，path为引用所在字段的路径
+type templateReference struct {
+	path string
+	name string
+}
+
+// validateConfigTemplate 校验变量定义(包括默认值)，模板数据只能引用已定义的变量或应用类型DefaultConfig中的配置项
+func validateConfigTemplate(m model.ConfigurationTemplate, appType model.ApplicationType) error {
+	fields := make([]v1.FieldError, 0)
+	properties := map[string]interface{}{}
+	if len(m.Variables) > 0 {
+		if t, ok := m.Variables["type"]; ok && t != "object" {
+			fields = append(fields, v1.FieldError{Field: "variables.type", Message: "变量定义的类型应为object"})
+		}
+		var ok bool
+		if properties, ok = schema.AsMap(m.Variables["properties"]); !ok {
+			fields = append(fields, v1.FieldError{Field: "variables.properties", Message: "缺少变量属性定义"})
+		}
+		names := make([]string, 0, len(properties))
+		for name := range properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			field := "variables.properties." + name
+			def, ok := schema.AsMap(properties[name])
+			if !ok {
+				fields = append(fields, v1.FieldError{Field: field, Message: "变量定义应为对象"})
+				continue
+			}
+			if value, ok := def["default"]; ok {
+				fields = append(fields, toFieldErrors(field+".default", schema.Validate(def, value))...)
+			}
+		}
+	}
+	for _, ref := range templateReferences(m.TemplateData) {
+		if _, ok := properties[ref.name]; ok {
+			continue
+		}
+		if _, ok := appType.DefaultConfig[ref.name]; ok {
+			continue
+		}
+		fields = append(fields, v1.FieldError{Field: "templateData." + ref.path, Message: "引用了未定义的变量" + ref.name})
+	}
+	if len(fields) > 0 {
+		return &v1.ValidationError{Err: v1.ErrConfigTemplateInvalid, Fields: fields}
+	}
+	return nil
+}
+
+// renderConfigTemplate 用变量渲染模板数据，并以应用类型的DefaultConfig作为未在模板中出现的配置项的默认值。
+// supplied只能包含模板定义的变量，未提供的变量依次取变量定义的default和DefaultConfig中的同名配置项
+func renderConfigTemplate(m model.ConfigurationTemplate, appType model.ApplicationType, supplied map[string]interface{}) (model.JSONMap, error) {
+	fields := make([]v1.FieldError, 0)
+	properties, _ := schema.AsMap(m.Variables["properties"])
+	values := map[string]interface{}{}
+	names := make([]string, 0, len(supplied))
+	for name := range supplied {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if _, ok := properties[name]; !ok {
+			fields = append(fields, v1.FieldError{Field: "variables." + name, Message: "模板没有定义该变量"})
+			continue
+		}
+		values[name] = supplied[name]
+	}
+	for name, def := range properties {
+		if _, ok := values[name]; ok {
+			continue
+		}
+		if defMap, ok := schema.AsMap(def); ok {
+			if value, ok := defMap["default"]; ok {
+				values[name] = value
+				continue
+			}
+		}
+		if value, ok := appType.DefaultConfig[name]; ok {
+			values[name] = value
+		}
+	}
+	if len(m.Variables) > 0 {
+		fields = append(fields, toFieldErrors("variables", schema.Validate(m.Variables, values))...)
+	}
+	// 引用了非必填且没有取值的变量时渲染为null；模板定义被修改后可能引用了未定义的变量
+	for _, ref := range templateReferences(m.TemplateData) {
+		if _, ok := values[ref.name]; ok {
+			continue
+		}
+		if value, ok := appType.DefaultConfig[ref.name]; ok {
+			values[ref.name] = value
+			continue
+		}
+		if _, ok := properties[ref.name]; !ok {
+			fields = append(fields, v1.FieldError{Field: "templateData." + ref.path, Message: "引用了未定义的变量" + ref.name})
+		}
+	}
+	if len(fields) > 0 {
+		return nil, &v1.ValidationError{Err: v1.ErrConfigVariablesInvalid, Fields: fields}
+	}
+	rendered, err := renderTemplateValue(map[string]interface{}(m.TemplateData), values)
+	if err != nil {
+		return nil, err
+	}
+	data := mergeConfigDefaults(appType.DefaultConfig, rendered.(map[string]interface{}))
+	// 统一为JSON解码后的类型，便于与数据库中的配置比较
+	return toJSONMap(data)
+}
+
+// renderTemplateValue 替换字符串中的变量引用。整个字符串只有一个引用时替换为变量值本身，保留数值、数组等类型；
+// 嵌在文本中的非字符串变量按JSON格式化，没有取值的变量替换为空字符串
+func renderTemplateValue(v interface{}, values map[string]interface{}) (interface{}, error) {
+	switch value := v.(type) {
+	case string:
+		if match := templateVariablePattern.FindStringSubmatch(value); match != nil && match[0] == value {
+			return values[match[1]], nil
+		}
+		var err error
+		out := templateVariablePattern.ReplaceAllStringFunc(value, func(ref string) string {
+			name := templateVariablePattern.FindStringSubmatch(ref)[1]
+			if values[name] == nil {
+				return ""
+			}
+			if s, ok := values[name].(string); ok {
+				return s
+			}
+			raw, e := json.Marshal(values[name])
+			if e != nil {
+				err = e
+			}
+			return string(raw)
+		})
+		return out, err
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(value))
+		for k, item := range value {
+			rendered, err := renderTemplateValue(item, values)
+			if err != nil {
+				return nil, err
+			}
+			out[k] = rendered
+		}
+		return out, nil
+	case model.JSONMap:
+		return renderTemplateValue(map[string]interface{}(value), values)
+	case []interface{}:
+		out := make([]interface{}, len(value))
+		for i, item := range value {
+			rendered, err := renderTemplateValue(item, values)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = rendered
+		}
+		return out, nil
+	}
+	return v, nil
+}
+
+// templateReferences 按字段路径排序列出模板数据中的全部变量引用
+func templateReferences(data model.JSONMap) []templateReference {
+	refs := make([]templateReference, 0)
+	collectTemplateReferences("", map[string]interface{}(data), &refs)
+	sort.SliceStable(refs, func(i, j int) bool {
+		return refs[i].path < refs[j].path
+	})
+	return refs
+}
+
+func collectTemplateReferences(path string, v interface{}, out *[]templateReference) {
+	switch value := v.(type) {
+	case string:
+		for _, match := range templateVariablePattern.FindAllStringSubmatch(value, -1) {
+			*out = append(*out, templateReference{path: path, name: match[1]})
+		}
+	case map[string]interface{}:
+		for k, item := range value {
+			field := k
+			if path != "" {
+				field = path + "." + k
+			}
+			collectTemplateReferences(field, item, out)
+		}
+	case []interface{}:
+		for i, item := range value {
+			collectTemplateReferences(fmt.Sprintf("%s[%d]", path, i), item, out)
+		}
+	}
+}
+
+// mergeConfigDefaults 以defaults为基础深度合并data，两边都是对象时逐项合并，否则以data为准
+func mergeConfigDefaults(defaults, data map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(defaults)+len(data))
+	for k, v := range defaults {
+		out[k] = v
+	}
+	for k, v := range data {
+		base, baseOK := asMap(out[k])
+		nested, ok := asMap(v)
+		if baseOK && ok {
+			out[k] = mergeConfigDefaults(base, nested)
+			continue
+		}
+		out[k] = v
+	}
+	return out
+}
+
+// checkTemplateScope 模板必须属于应用的类型，并适用于应用所在的环境和配置所属的业务类型，适用范围为空表示不限制
+func checkTemplateScope(m model.ConfigurationTemplate, application model.Application, businessType string) error {
+	if m.AppTypeID != application.TypeID {
+		return v1.ErrConfigTemplateMismatch
+	}
+	if len(m.Environments) > 0 && !containsString(m.Environments, application.Environment) {
+		return v1.ErrConfigTemplateMismatch
+	}
+	if len(m.BusinessTypes) > 0 && businessType != "" && !containsString(m.BusinessTypes, businessType) {
+		return v1.ErrConfigTemplateMismatch
+	}
+	return nil
+}
+
+func toConfigTemplateDataItem(m model.ConfigurationTemplate) v1.ConfigTemplateDataItem {
+	item := v1.ConfigTemplateDataItem{
+		ID:            m.ID,
+		TemplateID:    m.TemplateID,
+		Name:          m.Name,
+		AppTypeID:     m.AppTypeID,
+		TemplateData:  m.TemplateData,
+		Variables:     m.Variables,
+		BusinessTypes: m.BusinessTypes,
+		Environments:  m.Environments,
+		Version:       m.Version,
+		Category:      m.Category,
+		IsDefault:     m.IsDefault,
+		IsActive:      m.IsActive,
+		CreatedBy:     m.CreatedBy,
+		UpdatedBy:     m.UpdatedBy,
+		Description:   m.Description,
+		CreatedAt:     m.CreatedAt.Format("2006-01-02 15:04:05"),
+		UpdatedAt:     m.UpdatedAt.Format("2006-01-02 15:04:05"),
+	}
+	if item.BusinessTypes == nil {
+		item.BusinessTypes = make([]string, 0)
+	}
+	if item.Environments == nil {
+		item.Environments = make([]string, 0)
+	}
+	return item
+}
@@ -0,0 +1,1193 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	v1 "nunu-layout-admin/api/v1"
+	"nunu-layout-admin/internal/model"
+	"nunu-layout-admin/internal/repository"
+	"nunu-layout-admin/pkg/envelope"
+	"nunu-layout-admin/pkg/schema"
+	"sort"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type ConfigurationService interface {
+	GetConfigurations(ctx context.Context, req *v1.GetConfigurationsRequest) (*v1.GetConfigurationsResponseData, error)
+	GetConfiguration(ctx context.Context, req *v1.GetConfigurationRequest) (*v1.ConfigurationDataItem, error)
+	ConfigurationCreate(ctx context.Context, req *v1.ConfigurationCreateRequest) (*v1.ConfigurationCreateResponseData, error)
+	ConfigurationUpdate(ctx context.Context, req *v1.ConfigurationUpdateRequest) error
+	ConfigurationDelete(ctx context.Context, configID string) error
+
+	GetRevisions(ctx context.Context, req *v1.GetConfigRevisionsRequest) (*v1.GetConfigRevisionsResponseData, error)
+	GetRevision(ctx context.Context, req *v1.GetConfigRevisionRequest) (*v1.ConfigRevisionDataItem, error)
+	RevisionCreate(ctx context.Context, req *v1.ConfigRevisionCreateRequest) (*v1.ConfigRevisionCreateResponseData, error)
+	RevisionUpdate(ctx context.Context, req *v1.ConfigRevisionUpdateRequest) error
+	RevisionDelete(ctx context.Context, req *v1.ConfigRevisionDeleteRequest) error
+	// RevisionSubmit 草稿提交审核，提交后内容不可再修改
+	RevisionSubmit(ctx context.Context, req *v1.ConfigRevisionActionRequest) error
+	// RevisionReject 驳回待发布或等待生效的版本，退回为草稿
+	RevisionReject(ctx context.Context, req *v1.ConfigRevisionActionRequest) error
+	// RevisionPublish 批准发布，生效时间未到时等待定时任务发布
+	RevisionPublish(ctx context.Context, req *v1.ConfigRevisionActionRequest) (*v1.ConfigRevisionPublishResponseData, error)
+	Rollback(ctx context.Context, req *v1.ConfigRollbackRequest) (*v1.ConfigRollbackResponseData, error)
+	DiffRevisions(ctx context.Context, req *v1.ConfigRevisionDiffRequest) (*v1.ConfigRevisionDiffResponseData, error)
+	// PublishDueRevisions 发布生效时间已到的版本，返回发布的数量
+	PublishDueRevisions(ctx context.Context) (int, error)
+
+	// ConfigurationRender 用模板渲染新配置，生成第一个草稿版本
+	ConfigurationRender(ctx context.Context, req *v1.ConfigurationRenderRequest) (*v1.ConfigurationRenderResponseData, error)
+	// ConfigurationRerender 用模板当前版本重新渲染派生的配置，报告被手工修改过的配置
+	ConfigurationRerender(ctx context.Context, req *v1.ConfigurationRerenderRequest) (*v1.ConfigurationRerenderResponseData, error)
+	// ConfigurationLint 按应用类型当前的ConfigSchema检查该类型的全部配置
+	ConfigurationLint(ctx context.Context, typeName string) (*v1.ConfigurationLintResponseData, error)
+}
+
+func NewConfigurationService(
+	service *Service,
+	keyring *envelope.Keyring,
+	configurationRepository repository.ConfigurationRepository,
+	configTemplateRepository repository.ConfigTemplateRepository,
+	historyRepository repository.HistoryRepository,
+	sensitiveRepository repository.SensitiveRepository,
+) ConfigurationService {
+	return &configurationService{
+		Service:                  service,
+		keyring:                  keyring,
+		configurationRepository:  configurationRepository,
+		configTemplateRepository: configTemplateRepository,
+		historyRepository:        historyRepository,
+		sensitiveRepository:      sensitiveRepository,
+	}
+}
+
+type configurationService struct {
+	*Service
+	keyring                  *envelope.Keyring
+	configurationRepository  repository.ConfigurationRepository
+	configTemplateRepository repository.ConfigTemplateRepository
+	historyRepository        repository.HistoryRepository
+	sensitiveRepository      repository.SensitiveRepository
+}
+
+func (s *configurationService) GetConfigurations(ctx context.Context, req *v1.GetConfigurationsRequest) (*v1.GetConfigurationsResponseData, error) {
+	view, err := newSensitiveView(ctx, s.keyring, s.historyRepository, s.sensitiveRepository, req.Reveal)
+	if err != nil {
+		return nil, err
+	}
+	list, total, err := s.configurationRepository.GetConfigurations(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	data := &v1.GetConfigurationsResponseData{
+		List:  make([]v1.ConfigurationDataItem, 0),
+		Total: total,
+	}
+	for _, m := range list {
+		item, err := toConfigurationDataItemView(m, view)
+		if err != nil {
+			return nil, err
+		}
+		data.List = append(data.List, item)
+	}
+	return data, nil
+}
+
+func (s *configurationService) GetConfiguration(ctx context.Context, req *v1.GetConfigurationRequest) (*v1.ConfigurationDataItem, error) {
+	view, err := newSensitiveView(ctx, s.keyring, s.historyRepository, s.sensitiveRepository, req.Reveal)
+	if err != nil {
+		return nil, err
+	}
+	m, err := s.getConfiguration(ctx, req.ConfigID)
+	if err != nil {
+		return nil, err
+	}
+	item, err := toConfigurationDataItemView(m, view)
+	if err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+func (s *configurationService) ConfigurationCreate(ctx context.Context, req *v1.ConfigurationCreateRequest) (*v1.ConfigurationCreateResponseData, error) {
+	if err := checkConfigTimeRange(req.EffectiveTime, req.ExpireTime); err != nil {
+		return nil, err
+	}
+	if _, err := s.getApplication(ctx, req.ApplicationID); err != nil {
+		return nil, err
+	}
+	configID, err := s.newConfigID(ctx, req.ConfigID)
+	if err != nil {
+		return nil, err
+	}
+	operator := s.operator(ctx)
+	configuration := &model.Configuration{
+		ConfigID:      configID,
+		Name:          req.Name,
+		ApplicationID: req.ApplicationID,
+		BusinessID:    req.BusinessID,
+		ServiceID:     req.ServiceID,
+		TenantID:      req.TenantID,
+		ConfigType:    req.ConfigType,
+		ConfigData:    model.JSONMap{},
+		ConfigFormat:  req.ConfigFormat,
+		Source:        req.Source,
+		TemplateID:    req.TemplateID,
+		Priority:      req.Priority,
+		ConfigGroup:   req.ConfigGroup,
+		Status:        model.ConfigStatusPending,
+		IsEncrypted:   req.IsEncrypted,
+		CreatedBy:     operator,
+		UpdatedBy:     operator,
+		Description:   req.Description,
+		Tags:          toConfigurationTags(req.Tags),
+	}
+	configData, err := s.sealConfigData(ctx, *configuration, req.ConfigData, nil)
+	if err != nil {
+		return nil, err
+	}
+	err = s.tm.Transaction(ctx, func(ctx context.Context) error {
+		return s.createConfiguration(ctx, configuration, &model.ConfigurationRevision{
+			ConfigData:    configData,
+			Comment:       req.Comment,
+			EffectiveTime: req.EffectiveTime,
+			ExpireTime:    req.ExpireTime,
+			CreatedBy:     operator,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &v1.ConfigurationCreateResponseData{ConfigID: configuration.ConfigID, Revision: 1}, nil
+}
+
+func (s *configurationService) ConfigurationUpdate(ctx context.Context, req *v1.ConfigurationUpdateRequest) error {
+	old, err := s.getConfiguration(ctx, req.ConfigID)
+	if err != nil {
+		return err
+	}
+	// 未发布过的配置保持pending
+	status := req.Status
+	if status == "" || old.Status == model.ConfigStatusPending {
+		status = old.Status
+	}
+	return s.tm.Transaction(ctx, func(ctx context.Context) error {
+		err := s.configurationRepository.ConfigurationUpdate(ctx, &model.Configuration{
+			Model:       gorm.Model{ID: old.ID},
+			Name:        req.Name,
+			BusinessID:  req.BusinessID,
+			ServiceID:   req.ServiceID,
+			TenantID:    req.TenantID,
+			Priority:    req.Priority,
+			ConfigGroup: req.ConfigGroup,
+			Status:      status,
+			Description: req.Description,
+			UpdatedBy:   s.operator(ctx),
+		})
+		if err != nil {
+			return err
+		}
+		return s.configurationRepository.ReplaceConfigurationTags(ctx, old.ID, toConfigurationTags(req.Tags))
+	})
+}
+
+func (s *configurationService) ConfigurationDelete(ctx context.Context, configID string) error {
+	old, err := s.getConfiguration(ctx, configID)
+	if err != nil {
+		return err
+	}
+	return s.tm.Transaction(ctx, func(ctx context.Context) error {
+		return s.configurationRepository.ConfigurationDelete(ctx, old.ID)
+	})
+}
+
+func (s *configurationService) GetRevisions(ctx context.Context, req *v1.GetConfigRevisionsRequest) (*v1.GetConfigRevisionsResponseData, error) {
+	view, err := newSensitiveView(ctx, s.keyring, s.historyRepository, s.sensitiveRepository, req.Reveal)
+	if err != nil {
+		return nil, err
+	}
+	configuration, err := s.getConfiguration(ctx, req.ConfigID)
+	if err != nil {
+		return nil, err
+	}
+	list, total, err := s.configurationRepository.GetConfigRevisions(ctx, configuration.ID, req.Status, req.Page, req.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	data := &v1.GetConfigRevisionsResponseData{
+		List:  make([]v1.ConfigRevisionDataItem, 0),
+		Total: total,
+	}
+	for _, m := range list {
+		item, err := toConfigRevisionDataItemView(m, view)
+		if err != nil {
+			return nil, err
+		}
+		data.List = append(data.List, item)
+	}
+	return data, nil
+}
+
+func (s *configurationService) GetRevision(ctx context.Context, req *v1.GetConfigRevisionRequest) (*v1.ConfigRevisionDataItem, error) {
+	view, err := newSensitiveView(ctx, s.keyring, s.historyRepository, s.sensitiveRepository, req.Reveal)
+	if err != nil {
+		return nil, err
+	}
+	configuration, err := s.getConfiguration(ctx, req.ConfigID)
+	if err != nil {
+		return nil, err
+	}
+	m, err := s.getRevision(ctx, configuration.ID, req.Revision)
+	if err != nil {
+		return nil, err
+	}
+	item, err := toConfigRevisionDataItemView(m, view)
+	if err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+func (s *configurationService) RevisionCreate(ctx context.Context, req *v1.ConfigRevisionCreateRequest) (*v1.ConfigRevisionCreateResponseData, error) {
+	if err := checkConfigTimeRange(req.EffectiveTime, req.ExpireTime); err != nil {
+		return nil, err
+	}
+	configuration, err := s.getConfiguration(ctx, req.ConfigID)
+	if err != nil {
+		return nil, err
+	}
+	m := &model.ConfigurationRevision{
+		BaseRevision:  req.BaseRevision,
+		ConfigData:    req.ConfigData,
+		Comment:       req.Comment,
+		EffectiveTime: req.EffectiveTime,
+		ExpireTime:    req.ExpireTime,
+		CreatedBy:     s.operator(ctx),
+	}
+	err = s.tm.Transaction(ctx, func(ctx context.Context) error {
+		return s.createDraftRevision(ctx, configuration, m)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &v1.ConfigRevisionCreateResponseData{Revision: m.Revision}, nil
+}
+
+func (s *configurationService) RevisionUpdate(ctx context.Context, req *v1.ConfigRevisionUpdateRequest) error {
+	if err := checkConfigTimeRange(req.EffectiveTime, req.ExpireTime); err != nil {
+		return err
+	}
+	configuration, m, err := s.getConfigurationRevision(ctx, req.ConfigID, req.Revision)
+	if err != nil {
+		return err
+	}
+	if m.Status != model.ConfigRevisionDraft {
+		return v1.ErrConfigRevisionStatus
+	}
+	configData, err := s.sealConfigData(ctx, configuration, req.ConfigData, m.ConfigData)
+	if err != nil {
+		return err
+	}
+	m.ConfigData = configData
+	m.EffectiveTime = req.EffectiveTime
+	m.ExpireTime = req.ExpireTime
+	m.Comment = req.Comment
+	return s.configurationRepository.ConfigRevisionUpdate(ctx, &m)
+}
+
+func (s *configurationService) RevisionDelete(ctx context.Context, req *v1.ConfigRevisionDeleteRequest) error {
+	_, m, err := s.getConfigurationRevision(ctx, req.ConfigID, req.Revision)
+	if err != nil {
+		return err
+	}
+	if m.Status != model.ConfigRevisionDraft {
+		return v1.ErrConfigRevisionStatus
+	}
+	return s.configurationRepository.ConfigRevisionDelete(ctx, m.ID)
+}
+
+func (s *configurationService) RevisionSubmit(ctx context.Context, req *v1.ConfigRevisionActionRequest) error {
+	_, m, err := s.getConfigurationRevision(ctx, req.ConfigID, req.Revision)
+	if err != nil {
+		return err
+	}
+	if m.Status != model.ConfigRevisionDraft {
+		return v1.ErrConfigRevisionStatus
+	}
+	now := time.Now()
+	m.Status = model.ConfigRevisionPending
+	m.SubmittedBy = s.operator(ctx)
+	m.SubmittedAt = &now
+	return s.configurationRepository.ConfigRevisionUpdate(ctx, &m)
+}
+
+func (s *configurationService) RevisionReject(ctx context.Context, req *v1.ConfigRevisionActionRequest) error {
+	_, m, err := s.getConfigurationRevision(ctx, req.ConfigID, req.Revision)
+	if err != nil {
+		return err
+	}
+	if m.Status != model.ConfigRevisionPending && m.Status != model.ConfigRevisionScheduled {
+		return v1.ErrConfigRevisionStatus
+	}
+	m.Status = model.ConfigRevisionDraft
+	m.SubmittedBy = ""
+	m.SubmittedAt = nil
+	m.ApprovedBy = ""
+	m.ApprovedAt = nil
+	s.logger.WithContext(ctx).Info("reject configuration revision", zap.String("configId", req.ConfigID),
+		zap.Int("revision", req.Revision), zap.String("operator", s.operator(ctx)))
+	return s.configurationRepository.ConfigRevisionUpdate(ctx, &m)
+}
+
+func (s *configurationService) RevisionPublish(ctx context.Context, req *v1.ConfigRevisionActionRequest) (*v1.ConfigRevisionPublishResponseData, error) {
+	configuration, m, err := s.getConfigurationRevision(ctx, req.ConfigID, req.Revision)
+	if err != nil {
+		return nil, err
+	}
+	if m.Status != model.ConfigRevisionPending {
+		return nil, v1.ErrConfigRevisionStatus
+	}
+	now := time.Now()
+	if m.ExpireTime != nil && !m.ExpireTime.After(now) {
+		return nil, v1.ErrConfigTimeRangeInvalid
+	}
+	m.ApprovedBy = s.operator(ctx)
+	m.ApprovedAt = &now
+	if m.EffectiveTime != nil && m.EffectiveTime.After(now) {
+		m.Status = model.ConfigRevisionScheduled
+		if err := s.configurationRepository.ConfigRevisionUpdate(ctx, &m); err != nil {
+			return nil, err
+		}
+		return &v1.ConfigRevisionPublishResponseData{Revision: m.Revision, Status: m.Status}, nil
+	}
+	err = s.tm.Transaction(ctx, func(ctx context.Context) error {
+		return s.activateRevision(ctx, &configuration, &m, now)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &v1.ConfigRevisionPublishResponseData{Revision: m.Revision, Status: m.Status}, nil
+}
+
+func (s *configurationService) Rollback(ctx context.Context, req *v1.ConfigRollbackRequest) (*v1.ConfigRollbackResponseData, error) {
+	configuration, target, err := s.getConfigurationRevision(ctx, req.ConfigID, req.Revision)
+	if err != nil {
+		return nil, err
+	}
+	if target.Status != model.ConfigRevisionPublished && target.Status != model.ConfigRevisionSuperseded {
+		return nil, v1.ErrConfigRevisionRollback
+	}
+	comment := req.Comment
+	if comment == "" {
+		comment = fmt.Sprintf("回滚到版本%d", target.Revision)
+	}
+	operator := s.operator(ctx)
+	now := time.Now()
+	var revision int
+	err = s.tm.Transaction(ctx, func(ctx context.Context) error {
+		published, err := s.configurationRepository.GetPublishedConfigRevision(ctx, configuration.ID)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		latest, err := s.configurationRepository.GetMaxConfigRevision(ctx, configuration.ID)
+		if err != nil {
+			return err
+		}
+		revision = latest + 1
+		// 回滚立即生效且不沿用原版本的生效时间段，内容(包括密文)原样复制
+		m := &model.ConfigurationRevision{
+			ConfigurationID: configuration.ID,
+			ConfigID:        configuration.ConfigID,
+			Revision:        revision,
+			Status:          model.ConfigRevisionPending,
+			ConfigData:      target.ConfigData,
+			ConfigFormat:    target.ConfigFormat,
+			IsEncrypted:     target.IsEncrypted,
+			BaseRevision:    published.Revision,
+			SourceRevision:  target.Revision,
+			Comment:         comment,
+			CreatedBy:       operator,
+			SubmittedBy:     operator,
+			SubmittedAt:     &now,
+			ApprovedBy:      operator,
+			ApprovedAt:      &now,
+		}
+		if err := s.configurationRepository.ConfigRevisionCreate(ctx, m); err != nil {
+			return err
+		}
+		return s.activateRevision(ctx, &configuration, m, now)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &v1.ConfigRollbackResponseData{Revision: revision}, nil
+}
+
+func (s *configurationService) DiffRevisions(ctx context.Context, req *v1.ConfigRevisionDiffRequest) (*v1.ConfigRevisionDiffResponseData, error) {
+	view, err := newSensitiveView(ctx, s.keyring, s.historyRepository, s.sensitiveRepository, req.Reveal)
+	if err != nil {
+		return nil, err
+	}
+	configuration, to, err := s.getConfigurationRevision(ctx, req.ConfigID, req.To)
+	if err != nil {
+		return nil, err
+	}
+	from := model.ConfigurationRevision{Revision: req.From}
+	if from.Revision == 0 {
+		from.Revision = to.BaseRevision
+	}
+	if from.Revision > 0 {
+		if from, err = s.getRevision(ctx, configuration.ID, from.Revision); err != nil {
+			return nil, err
+		}
+	}
+	// 密文每次加密结果不同，按明文比较
+	before, err := openSensitive(s.keyring, from.ConfigData)
+	if err != nil {
+		return nil, err
+	}
+	after, err := openSensitive(s.keyring, to.ConfigData)
+	if err != nil {
+		return nil, err
+	}
+	changed := diffSnapshots(before, after)
+	if !view.reveal {
+		if from.IsEncrypted || to.IsEncrypted {
+			// 整体加密的配置所有字段都是敏感字段，只保留变化的字段名
+			changed = model.JSONMap(maskAny(map[string]interface{}(changed), nil, true).(map[string]interface{}))
+		} else {
+			changed = maskSensitive(changed, view.fields)
+		}
+	}
+	return &v1.ConfigRevisionDiffResponseData{
+		ConfigID:      configuration.ConfigID,
+		From:          from.Revision,
+		To:            to.Revision,
+		ChangedFields: changed,
+	}, nil
+}
+
+func (s *configurationService) PublishDueRevisions(ctx context.Context) (int, error) {
+	list, err := s.configurationRepository.GetDueConfigRevisions(ctx, time.Now())
+	if err != nil {
+		return 0, err
+	}
+	published := 0
+	for _, item := range list {
+		err := s.tm.Transaction(ctx, func(ctx context.Context) error {
+			configuration, err := s.configurationRepository.GetConfiguration(ctx, item.ConfigID)
+			if err != nil {
+				return err
+			}
+			// 重新读取，跳过期间已被驳回的版本
+			m, err := s.configurationRepository.GetConfigRevision(ctx, configuration.ID, item.Revision)
+			if err != nil {
+				return err
+			}
+			if m.Status != model.ConfigRevisionScheduled {
+				return nil
+			}
+			if err := s.activateRevision(ctx, &configuration, &m, time.Now()); err != nil {
+				return err
+			}
+			published++
+			return nil
+		})
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				continue
+			}
+			return published, fmt.Errorf("configuration %s revision %d: %w", item.ConfigID, item.Revision, err)
+		}
+	}
+	return published, nil
+}
+
+func (s *configurationService) ConfigurationRender(ctx context.Context, req *v1.ConfigurationRenderRequest) (*v1.ConfigurationRenderResponseData, error) {
+	application, err := s.getApplication(ctx, req.ApplicationID)
+	if err != nil {
+		return nil, err
+	}
+	var template model.ConfigurationTemplate
+	if req.TemplateID == "" {
+		template, err = s.configTemplateRepository.GetDefaultConfigTemplate(ctx, application.TypeID)
+	} else {
+		template, err = s.configTemplateRepository.GetConfigTemplate(ctx, req.TemplateID)
+	}
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, v1.ErrConfigTemplateNotFound
+		}
+		return nil, err
+	}
+	if !template.IsActive {
+		return nil, v1.ErrConfigTemplateInactive
+	}
+	configData, err := s.renderTemplate(ctx, template, application, req.BusinessID, req.Variables)
+	if err != nil {
+		return nil, err
+	}
+	configID, err := s.newConfigID(ctx, req.ConfigID)
+	if err != nil {
+		return nil, err
+	}
+	operator := s.operator(ctx)
+	configuration := &model.Configuration{
+		ConfigID:      configID,
+		Name:          req.Name,
+		ApplicationID: req.ApplicationID,
+		BusinessID:    req.BusinessID,
+		ServiceID:     req.ServiceID,
+		TenantID:      req.TenantID,
+		ConfigType:    req.ConfigType,
+		ConfigData:    model.JSONMap{},
+		ConfigFormat:  req.ConfigFormat,
+		Source:        model.ConfigSourceTemplate,
+		TemplateID:    template.TemplateID,
+		Priority:      req.Priority,
+		ConfigGroup:   req.ConfigGroup,
+		Status:        model.ConfigStatusPending,
+		IsEncrypted:   req.IsEncrypted,
+		CreatedBy:     operator,
+		UpdatedBy:     operator,
+		Description:   req.Description,
+		Tags:          toConfigurationTags(req.Tags),
+	}
+	if configData, err = s.sealConfigData(ctx, *configuration, configData, nil); err != nil {
+		return nil, err
+	}
+	// 只保存调用方提供的变量，重新渲染时未提供的变量使用模板新版本的默认值
+	variables, err := s.sealConfigFields(ctx, *configuration, req.Variables, nil)
+	if err != nil {
+		return nil, err
+	}
+	comment := req.Comment
+	if comment == "" {
+		comment = fmt.Sprintf("由模板%s(版本%s)渲染", template.TemplateID, template.Version)
+	}
+	now := time.Now()
+	err = s.tm.Transaction(ctx, func(ctx context.Context) error {
+		err := s.createConfiguration(ctx, configuration, &model.ConfigurationRevision{
+			ConfigData: configData,
+			Comment:    comment,
+			CreatedBy:  operator,
+		})
+		if err != nil {
+			return err
+		}
+		return s.configurationRepository.ConfigRenderCreate(ctx, &model.ConfigurationRender{
+			ConfigurationID: configuration.ID,
+			ConfigID:        configuration.ConfigID,
+			TemplateID:      template.TemplateID,
+			TemplateVersion: template.Version,
+			Variables:       variables,
+			Revision:        1,
+			RenderedBy:      operator,
+			RenderedAt:      &now,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &v1.ConfigurationRenderResponseData{
+		ConfigID:        configuration.ConfigID,
+		Revision:        1,
+		TemplateID:      template.TemplateID,
+		TemplateVersion: template.Version,
+	}, nil
+}
+
+func (s *configurationService) ConfigurationRerender(ctx context.Context, req *v1.ConfigurationRerenderRequest) (*v1.ConfigurationRerenderResponseData, error) {
+	template, err := s.configTemplateRepository.GetConfigTemplate(ctx, req.TemplateID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, v1.ErrConfigTemplateNotFound
+		}
+		return nil, err
+	}
+	if !template.IsActive {
+		return nil, v1.ErrConfigTemplateInactive
+	}
+	renders, err := s.configurationRepository.GetConfigRenders(ctx, template.TemplateID, req.ConfigIDs)
+	if err != nil {
+		return nil, err
+	}
+	data := &v1.ConfigurationRerenderResponseData{
+		TemplateID:      template.TemplateID,
+		TemplateVersion: template.Version,
+		Items:           make([]v1.ConfigurationRerenderItem, 0, len(renders)),
+	}
+	operator := s.operator(ctx)
+	for _, render := range renders {
+		item := v1.ConfigurationRerenderItem{
+			ConfigID:        render.ConfigID,
+			PreviousVersion: render.TemplateVersion,
+			DriftedFields:   make([]string, 0),
+		}
+		err := s.tm.Transaction(ctx, func(ctx context.Context) error {
+			return s.rerender(ctx, template, render, req, operator, &item)
+		})
+		if err != nil {
+			var verr *v1.ValidationError
+			switch {
+			case errors.As(err, &verr):
+				item.Status = model.ConfigRenderFailed
+				item.Message = verr.Error()
+				for _, field := range verr.Fields {
+					item.Message += "; " + field.Field + ": " + field.Message
+				}
+			case v1.IsKnownError(err):
+				item.Status = model.ConfigRenderFailed
+				item.Message = err.Error()
+			default:
+				return nil, fmt.Errorf("configuration %s: %w", render.ConfigID, err)
+			}
+		}
+		data.Items = append(data.Items, item)
+	}
+	s.logger.WithContext(ctx).Info("rerender configurations", zap.String("templateId", template.TemplateID),
+		zap.String("version", template.Version), zap.Bool("dryRun", req.DryRun), zap.Int("count", len(data.Items)))
+	return data, nil
+}
+
+// ConfigurationLint 修改应用类型的ConfigSchema后检查已有配置。已发布的内容和尚未发布的版本分别检查，
+// 未发布的版本不合规时需要修改后才能重新提交
+func (s *configurationService) ConfigurationLint(ctx context.Context, typeName string) (*v1.ConfigurationLintResponseData, error) {
+	appType, err := s.configTemplateRepository.GetApplicationTypeByName(ctx, typeName)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, v1.ErrApplicationTypeNotFound
+		}
+		return nil, err
+	}
+	configurations, err := s.configurationRepository.GetConfigurationsByAppType(ctx, appType.ID)
+	if err != nil {
+		return nil, err
+	}
+	data := &v1.ConfigurationLintResponseData{
+		TypeName: appType.TypeName,
+		Total:    len(configurations),
+		Invalid:  make([]v1.ConfigurationLintItem, 0),
+	}
+	ids := make([]uint, 0, len(configurations))
+	byID := make(map[uint]model.Configuration, len(configurations))
+	for _, m := range configurations {
+		ids = append(ids, m.ID)
+		byID[m.ID] = m
+		// 从未发布过的配置没有生效的内容
+		if m.Status == model.ConfigStatusPending {
+			continue
+		}
+		data.Checked++
+		fields, err := lintConfigData(s.keyring, appType.ConfigSchema, m.ConfigData)
+		if err != nil {
+			return nil, fmt.Errorf("configuration %s: %w", m.ConfigID, err)
+		}
+		if len(fields) > 0 {
+			data.Invalid = append(data.Invalid, v1.ConfigurationLintItem{
+				ConfigID:   m.ConfigID,
+				Name:       m.Name,
+				Status:     model.ConfigRevisionPublished,
+				Violations: fields,
+			})
+		}
+	}
+	revisions, err := s.configurationRepository.GetOpenConfigRevisions(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range revisions {
+		data.Checked++
+		fields, err := lintConfigData(s.keyring, appType.ConfigSchema, m.ConfigData)
+		if err != nil {
+			return nil, fmt.Errorf("configuration %s revision %d: %w", m.ConfigID, m.Revision, err)
+		}
+		if len(fields) > 0 {
+			data.Invalid = append(data.Invalid, v1.ConfigurationLintItem{
+				ConfigID:   m.ConfigID,
+				Name:       byID[m.ConfigurationID].Name,
+				Revision:   m.Revision,
+				Status:     m.Status,
+				Violations: fields,
+			})
+		}
+	}
+	return data, nil
+}
+
+// rerender 在事务中重新渲染一个配置。上次渲染的版本已发布过时，先与配置当前发布的内容比较，
+// 不一致说明之后被手工修改过；上次渲染的版本仍是草稿时直接覆盖该草稿
+func (s *configurationService) rerender(ctx context.Context, template model.ConfigurationTemplate, render model.ConfigurationRender, req *v1.ConfigurationRerenderRequest, operator string, item *v1.ConfigurationRerenderItem) error {
+	configuration, err := s.getConfiguration(ctx, render.ConfigID)
+	if err != nil {
+		return err
+	}
+	// 上次渲染的草稿可能已被删除，此时按没有渲染版本处理
+	rendered, err := s.getRevision(ctx, configuration.ID, render.Revision)
+	if err != nil && !errors.Is(err, v1.ErrNotFound) {
+		return err
+	}
+	if rendered.Status == model.ConfigRevisionPending || rendered.Status == model.ConfigRevisionScheduled {
+		item.Status = model.ConfigRenderPending
+		item.Revision = rendered.Revision
+		return nil
+	}
+	current, err := openSensitive(s.keyring, configuration.ConfigData)
+	if err != nil {
+		return err
+	}
+	if rendered.Status == model.ConfigRevisionDraft {
+		if current, err = openSensitive(s.keyring, rendered.ConfigData); err != nil {
+			return err
+		}
+	}
+	if rendered.Status == model.ConfigRevisionPublished || rendered.Status == model.ConfigRevisionSuperseded {
+		renderedData, err := openSensitive(s.keyring, rendered.ConfigData)
+		if err != nil {
+			return err
+		}
+		for field := range diffSnapshots(renderedData, current) {
+			item.DriftedFields = append(item.DriftedFields, field)
+		}
+		sort.Strings(item.DriftedFields)
+		if len(item.DriftedFields) > 0 && !req.Force {
+			item.Status = model.ConfigRenderDrifted
+			return nil
+		}
+	}
+	application, err := s.getApplication(ctx, configuration.ApplicationID)
+	if err != nil {
+		return err
+	}
+	variables, err := openSensitive(s.keyring, render.Variables)
+	if err != nil {
+		return err
+	}
+	configData, err := s.renderTemplate(ctx, template, application, configuration.BusinessID, variables)
+	if err != nil {
+		return err
+	}
+	if sameJSON(configData, current) {
+		item.Status = model.ConfigRenderUnchanged
+		item.Revision = rendered.Revision
+		if req.DryRun || render.TemplateVersion == template.Version {
+			return nil
+		}
+		render.TemplateVersion = template.Version
+		return s.configurationRepository.ConfigRenderUpdate(ctx, &render)
+	}
+	item.Status = model.ConfigRenderRendered
+	if req.DryRun {
+		return nil
+	}
+	comment := fmt.Sprintf("由模板%s(版本%s)重新渲染", template.TemplateID, template.Version)
+	if rendered.Status == model.ConfigRevisionDraft {
+		if rendered.ConfigData, err = s.sealConfigData(ctx, configuration, configData, rendered.ConfigData); err != nil {
+			return err
+		}
+		rendered.Comment = comment
+		if err := s.configurationRepository.ConfigRevisionUpdate(ctx, &rendered); err != nil {
+			return err
+		}
+	} else {
+		rendered = model.ConfigurationRevision{
+			ConfigData: configData,
+			Comment:    comment,
+			CreatedBy:  operator,
+		}
+		if err := s.createDraftRevision(ctx, configuration, &rendered); err != nil {
+			return err
+		}
+	}
+	item.Revision = rendered.Revision
+	now := time.Now()
+	render.TemplateVersion = template.Version
+	render.Revision = rendered.Revision
+	render.RenderedBy = operator
+	render.RenderedAt = &now
+	return s.configurationRepository.ConfigRenderUpdate(ctx, &render)
+}
+
+// renderTemplate 检查模板对应用和业务的适用范围后渲染配置数据(明文)
+func (s *configurationService) renderTemplate(ctx context.Context, template model.ConfigurationTemplate, application model.Application, businessID string, variables map[string]interface{}) (model.JSONMap, error) {
+	var businessType string
+	if businessID != "" && len(template.BusinessTypes) > 0 {
+		business, err := s.configurationRepository.GetBusiness(ctx, businessID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, v1.ErrConfigTemplateMismatch
+			}
+			return nil, err
+		}
+		businessType = business.Type
+	}
+	if err := checkTemplateScope(template, application, businessType); err != nil {
+		return nil, err
+	}
+	appType, err := s.configTemplateRepository.GetApplicationType(ctx, template.AppTypeID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, v1.ErrApplicationTypeNotFound
+		}
+		return nil, err
+	}
+	configData, err := renderConfigTemplate(template, appType, variables)
+	if err != nil {
+		return nil, err
+	}
+	// 渲染时先校验一次，试运行的重新渲染也能报告不符合ConfigSchema的配置
+	if err := validateConfigSchema(appType.ConfigSchema, configData); err != nil {
+		return nil, err
+	}
+	return configData, nil
+}
+
+// activateRevision 在事务中发布版本：原来发布的版本标记为已替换，版本内容写回配置实例
+func (s *configurationService) activateRevision(ctx context.Context, configuration *model.Configuration, m *model.ConfigurationRevision, now time.Time) error {
+	if err := s.configurationRepository.SupersedeConfigRevisions(ctx, configuration.ID); err != nil {
+		return err
+	}
+	m.Status = model.ConfigRevisionPublished
+	m.PublishedAt = &now
+	if err := s.configurationRepository.ConfigRevisionUpdate(ctx, m); err != nil {
+		return err
+	}
+	configuration.ConfigData = m.ConfigData
+	configuration.ConfigFormat = m.ConfigFormat
+	configuration.IsEncrypted = m.IsEncrypted
+	configuration.Status = model.ConfigStatusActive
+	configuration.Version = strconv.Itoa(m.Revision)
+	configuration.UpdatedBy = m.ApprovedBy
+	configuration.AppliedAt = &now
+	configuration.EffectiveTime = m.EffectiveTime
+	configuration.ExpireTime = m.ExpireTime
+	if err := s.configurationRepository.ConfigurationPublish(ctx, configuration); err != nil {
+		return err
+	}
+	s.logger.WithContext(ctx).Info("publish configuration revision", zap.String("configId", configuration.ConfigID),
+		zap.Int("revision", m.Revision), zap.String("approvedBy", m.ApprovedBy))
+	return nil
+}
+
+// createConfiguration 在事务中创建配置及其第一个草稿版本，first的内容应已加密
+func (s *configurationService) createConfiguration(ctx context.Context, configuration *model.Configuration, first *model.ConfigurationRevision) error {
+	if err := s.configurationRepository.ConfigurationCreate(ctx, configuration); err != nil {
+		return err
+	}
+	first.ConfigurationID = configuration.ID
+	first.ConfigID = configuration.ConfigID
+	first.Revision = 1
+	first.Status = model.ConfigRevisionDraft
+	first.ConfigFormat = configuration.ConfigFormat
+	first.IsEncrypted = configuration.IsEncrypted
+	return s.configurationRepository.ConfigRevisionCreate(ctx, first)
+}
+
+// createDraftRevision 在事务中基于m.BaseRevision(为0时基于当前发布的版本)创建草稿，m.ConfigData为明文，
+// 创建后m.Revision为新的版本号
+func (s *configurationService) createDraftRevision(ctx context.Context, configuration model.Configuration, m *model.ConfigurationRevision) error {
+	if err := s.ensureBaselineRevision(ctx, configuration); err != nil {
+		return err
+	}
+	if m.BaseRevision == 0 {
+		published, err := s.configurationRepository.GetPublishedConfigRevision(ctx, configuration.ID)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		m.BaseRevision = published.Revision
+	}
+	// 脱敏占位符和未修改的敏感字段沿用基础版本的值
+	var old model.JSONMap
+	if m.BaseRevision > 0 {
+		baseRevision, err := s.getRevision(ctx, configuration.ID, m.BaseRevision)
+		if err != nil {
+			return err
+		}
+		old = baseRevision.ConfigData
+	}
+	configData, err := s.sealConfigData(ctx, configuration, m.ConfigData, old)
+	if err != nil {
+		return err
+	}
+	latest, err := s.configurationRepository.GetMaxConfigRevision(ctx, configuration.ID)
+	if err != nil {
+		return err
+	}
+	m.ConfigurationID = configuration.ID
+	m.ConfigID = configuration.ConfigID
+	m.Revision = latest + 1
+	m.Status = model.ConfigRevisionDraft
+	m.ConfigData = configData
+	m.ConfigFormat = configuration.ConfigFormat
+	m.IsEncrypted = configuration.IsEncrypted
+	return s.configurationRepository.ConfigRevisionCreate(ctx, m)
+}
+
+// ensureBaselineRevision 版本管理之前已有数据的配置没有任何版本，先把当前内容保存为已发布的第一个版本，以便比较和回滚
+func (s *configurationService) ensureBaselineRevision(ctx context.Context, configuration model.Configuration) error {
+	if configuration.Status == model.ConfigStatusPending {
+		return nil
+	}
+	latest, err := s.configurationRepository.GetMaxConfigRevision(ctx, configuration.ID)
+	if err != nil || latest > 0 {
+		return err
+	}
+	publishedAt := configuration.AppliedAt
+	if publishedAt == nil {
+		publishedAt = &configuration.UpdatedAt
+	}
+	return s.configurationRepository.ConfigRevisionCreate(ctx, &model.ConfigurationRevision{
+		ConfigurationID: configuration.ID,
+		ConfigID:        configuration.ConfigID,
+		Revision:        1,
+		Status:          model.ConfigRevisionPublished,
+		ConfigData:      configuration.ConfigData,
+		ConfigFormat:    configuration.ConfigFormat,
+		IsEncrypted:     configuration.IsEncrypted,
+		Comment:         "初始版本",
+		EffectiveTime:   configuration.EffectiveTime,
+		ExpireTime:      configuration.ExpireTime,
+		CreatedBy:       configuration.CreatedBy,
+		ApprovedBy:      configuration.UpdatedBy,
+		PublishedAt:     publishedAt,
+	})
+}
+
+// sealConfigData 加密配置数据中的敏感字段，并按解密后的明文校验应用类型的ConfigSchema。
+// 更新时传入原数据old，未修改和以脱敏占位符提交的敏感字段保持原密文
+func (s *configurationService) sealConfigData(ctx context.Context, configuration model.Configuration, data, old model.JSONMap) (model.JSONMap, error) {
+	sealed, err := s.sealConfigFields(ctx, configuration, data, old)
+	if err != nil {
+		return nil, err
+	}
+	opened, err := openSensitive(s.keyring, sealed)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.validateConfigData(ctx, configuration.ApplicationID, opened); err != nil {
+		return nil, err
+	}
+	return sealed, nil
+}
+
+// sealConfigFields 加密配置的敏感字段，整体加密的配置加密全部顶层字段。
+// 模板渲染记录中的变量值同样按配置的敏感字段加密
+func (s *configurationService) sealConfigFields(ctx context.Context, configuration model.Configuration, data, old model.JSONMap) (model.JSONMap, error) {
+	configs, err := s.historyRepository.GetAuditConfigs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	fields := sensitiveFields(matchAuditConfig(configs, model.ObjectTypeConfiguration, configurationAuditData(configuration)))
+	if data == nil {
+		data = model.JSONMap{}
+	}
+	sealed, _, err := sealSensitive(s.keyring, data, old, fields, configuration.IsEncrypted)
+	return sealed, err
+}
+
+func (s *configurationService) operator(ctx context.Context) string {
+	return requestOperatorName(ctx, s.historyRepository)
+}
+
+// newConfigID 未指定配置ID时生成一个，指定的配置ID不能已被使用
+func (s *configurationService) newConfigID(ctx context.Context, configID string) (string, error) {
+	if configID == "" {
+		return s.sid.GenString()
+	}
+	_, err := s.configurationRepository.GetConfiguration(ctx, configID)
+	if err == nil {
+		return "", v1.ErrConfigIDAlreadyUse
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", err
+	}
+	return configID, nil
+}
+
+// validateConfigData 按配置所属应用的类型的ConfigSchema校验配置数据(明文)
+func (s *configurationService) validateConfigData(ctx context.Context, applicationID uint, data model.JSONMap) error {
+	application, err := s.getApplication(ctx, applicationID)
+	if err != nil {
+		return err
+	}
+	appType, err := s.configTemplateRepository.GetApplicationType(ctx, application.TypeID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return v1.ErrApplicationTypeNotFound
+		}
+		return err
+	}
+	return validateConfigSchema(appType.ConfigSchema, data)
+}
+
+func (s *configurationService) getApplication(ctx context.Context, id uint) (model.Application, error) {
+	m, err := s.configurationRepository.GetApplication(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return m, v1.ErrApplicationNotFound
+		}
+		return m, err
+	}
+	return m, nil
+}
+
+func (s *configurationService) getConfiguration(ctx context.Context, configID string) (model.Configuration, error) {
+	m, err := s.configurationRepository.GetConfiguration(ctx, configID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return m, v1.ErrNotFound
+		}
+		return m, err
+	}
+	return m, nil
+}
+
+func (s *configurationService) getRevision(ctx context.Context, configurationID uint, revision int) (model.ConfigurationRevision, error) {
+	m, err := s.configurationRepository.GetConfigRevision(ctx, configurationID, revision)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return m, v1.ErrNotFound
+		}
+		return m, err
+	}
+	return m, nil
+}
+
+func (s *configurationService) getConfigurationRevision(ctx context.Context, configID string, revision int) (model.Configuration, model.ConfigurationRevision, error) {
+	configuration, err := s.getConfiguration(ctx, configID)
+	if err != nil {
+		return configuration, model.ConfigurationRevision{}, err
+	}
+	m, err := s.getRevision(ctx, configuration.ID, revision)
+	return configuration, m, err
+}
+
+// validateConfigSchema 按ConfigSchema校验配置数据，字段路径以configData开头
+func validateConfigSchema(configSchema, data model.JSONMap) error {
+	if violations := schema.Validate(configSchema, schema.Normalize(data)); len(violations) > 0 {
+		return &v1.ValidationError{
+			Err:    v1.ErrConfigDataInvalid,
+			Fields: toFieldErrors("configData", violations),
+		}
+	}
+	return nil
+}
+
+// lintConfigData 解密后按ConfigSchema校验配置数据，返回不合规的字段
+func lintConfigData(keyring *envelope.Keyring, configSchema, data model.JSONMap) ([]v1.FieldError, error) {
+	opened, err := openSensitive(keyring, data)
+	if err != nil {
+		return nil, err
+	}
+	var verr *v1.ValidationError
+	if err := validateConfigSchema(configSchema, opened); errors.As(err, &verr) {
+		return verr.Fields, nil
+	}
+	return nil, nil
+}
+
+func checkConfigTimeRange(effectiveTime, expireTime *time.Time) error {
+	if effectiveTime != nil && expireTime != nil && !expireTime.After(*effectiveTime) {
+		return v1.ErrConfigTimeRangeInvalid
+	}
+	return nil
+}
+
+func toConfigurationTags(items []v1.ConfigurationTagItem) []model.ConfigurationTag {
+	tags := make([]model.ConfigurationTag, 0, len(items))
+	for _, item := range items {
+		tags = append(tags, model.ConfigurationTag{Key: item.Key, Value: item.Value})
+	}
+	return tags
+}
+
+// toConfigurationDataItemView 按调用方的权限脱敏或解密配置数据
+func toConfigurationDataItemView(m model.Configuration, view *sensitiveView) (v1.ConfigurationDataItem, error) {
+	item := v1.ConfigurationDataItem{
+		ID:            m.ID,
+		ConfigID:      m.ConfigID,
+		Name:          m.Name,
+		ApplicationID: m.ApplicationID,
+		BusinessID:    m.BusinessID,
+		ServiceID:     m.ServiceID,
+		TenantID:      m.TenantID,
+		ConfigType:    m.ConfigType,
+		ConfigFormat:  m.ConfigFormat,
+		Source:        m.Source,
+		TemplateID:    m.TemplateID,
+		Priority:      m.Priority,
+		ConfigGroup:   m.ConfigGroup,
+		Status:        m.Status,
+		IsEncrypted:   m.IsEncrypted,
+		Version:       m.Version,
+		CreatedBy:     m.CreatedBy,
+		UpdatedBy:     m.UpdatedBy,
+		Description:   m.Description,
+		Tags:          make([]v1.ConfigurationTagItem, 0, len(m.Tags)),
+		CreatedAt:     m.CreatedAt.Format("2006-01-02 15:04:05"),
+		UpdatedAt:     m.UpdatedAt.Format("2006-01-02 15:04:05"),
+	}
+	if m.AppliedAt != nil {
+		item.AppliedAt = m.AppliedAt.Format("2006-01-02 15:04:05")
+	}
+	if m.EffectiveTime != nil {
+		item.EffectiveTime = m.EffectiveTime.Format("2006-01-02 15:04:05")
+	}
+	if m.ExpireTime != nil {
+		item.ExpireTime = m.ExpireTime.Format("2006-01-02 15:04:05")
+	}
+	for _, tag := range m.Tags {
+		item.Tags = append(item.Tags, v1.ConfigurationTagItem{Key: tag.Key, Value: tag.Value})
+	}
+	configData, err := view.apply(m.ConfigData)
+	if err != nil {
+		return item, err
+	}
+	item.ConfigData = configData
+	return item, nil
+}
+
+func toConfigRevisionDataItemView(m model.ConfigurationRevision, view *sensitiveView) (v1.ConfigRevisionDataItem, error) {
+	item := v1.ConfigRevisionDataItem{
+		ID:             m.ID,
+		ConfigID:       m.ConfigID,
+		Revision:       m.Revision,
+		Status:         m.Status,
+		ConfigFormat:   m.ConfigFormat,
+		IsEncrypted:    m.IsEncrypted,
+		BaseRevision:   m.BaseRevision,
+		SourceRevision: m.SourceRevision,
+		Comment:        m.Comment,
+		CreatedBy:      m.CreatedBy,
+		SubmittedBy:    m.SubmittedBy,
+		ApprovedBy:     m.ApprovedBy,
+		CreatedAt:      m.CreatedAt.Format("2006-01-02 15:04:05"),
+		UpdatedAt:      m.UpdatedAt.Format("2006-01-02 15:04:05"),
+	}
+	if m.EffectiveTime != nil {
+		item.EffectiveTime = m.EffectiveTime.Format("2006-01-02 15:04:05")
+	}
+	if m.ExpireTime != nil {
+		item.ExpireTime = m.ExpireTime.Format("2006-01-02 15:04:05")
+	}
+	if m.SubmittedAt != nil {
+		item.SubmittedAt = m.SubmittedAt.Format("2006-01-02 15:04:05")
+	}
+	if m.ApprovedAt != nil {
+		item.ApprovedAt = m.ApprovedAt.Format("2006-01-02 15:04:05")
+	}
+	if m.PublishedAt != nil {
+		item.PublishedAt = m.PublishedAt.Format("2006-01-02 15:04:05")
+	}
+	configData, err := view.apply(m.ConfigData)
+	if err != nil {
+		return item, err
+	}
+	item.ConfigData = configData
+	return item, nil
+}
@@ -0,0 +1,544 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	v1 "nunu-layout-admin/api/v1"
+	"nunu-layout-admin/internal/model"
+	"nunu-layout-admin/internal/repository"
+	"strings"
+	"time"
+)
+
+const (
+	dependencyGraphDefaultDepth = 5
+	// 依赖图快照的有效期，到期前定时任务会重新计算
+	dependencyGraphTTL = 24 * time.Hour
+)
+
+// dependencyGraphRelationTypes 各图类型包含的关系类型，nil表示全部关系
+var dependencyGraphRelationTypes = map[string][]string{
+	model.GraphTypeDependency: {
+		model.UniversalRelationContains, model.UniversalRelationDependsOn, model.UniversalRelationConsumes,
+		model.UniversalRelationUses, model.UniversalRelationRunsOn,
+	},
+	model.GraphTypeTopology: nil,
+	model.GraphTypeDataFlow: {
+		model.UniversalRelationProvides, model.UniversalRelationConsumes, model.UniversalRelationSyncs,
+		model.UniversalRelationReplicates, model.UniversalRelationBacksUp,
+	},
+	model.GraphTypeDeployment: {
+		model.UniversalRelationContains, model.UniversalRelationRunsOn, model.UniversalRelationHosts,
+		model.UniversalRelationDeploys, model.UniversalRelationConfigures,
+	},
+	model.GraphTypeNetworkFlow: {
+		model.UniversalRelationConnectsTo, model.UniversalRelationRoutes, model.UniversalRelationExposes,
+		model.RelationTypeLoadBalances,
+	},
+}
+
+type DependencyGraphService interface {
+	Compute(ctx context.Context, req *v1.DependencyGraphComputeRequest) (*v1.DependencyGraphComputeResponseData, error)
+	// ComputeAll 为rootType的全部对象按graphTypes计算依赖图，返回产生新版本的数量
+	ComputeAll(ctx context.Context, rootType string, graphTypes []string) (int, error)
+	GetDependencyGraph(ctx context.Context, req *v1.GetDependencyGraphRequest) (*v1.DependencyGraphDataItem, error)
+	GetDependencyGraphVersions(ctx context.Context, req *v1.GetDependencyGraphVersionsRequest) (*v1.GetDependencyGraphVersionsResponseData, error)
+	Diff(ctx context.Context, req *v1.DependencyGraphDiffRequest) (*v1.DependencyGraphDiffResponseData, error)
+}
+
+func NewDependencyGraphService(
+	service *Service,
+	dependencyGraphRepository repository.DependencyGraphRepository,
+	relationRepository repository.RelationRepository,
+	impactRepository repository.ImpactRepository,
+) DependencyGraphService {
+	return &dependencyGraphService{
+		Service:                   service,
+		dependencyGraphRepository: dependencyGraphRepository,
+		relationRepository:        relationRepository,
+		impactRepository:          impactRepository,
+	}
+}
+
+type dependencyGraphService struct {
+	*Service
+	dependencyGraphRepository repository.DependencyGraphRepository
+	relationRepository        repository.RelationRepository
+	impactRepository          repository.ImpactRepository
+}
+
+// dependencyGraphData DependencyGraph.GraphData中保存的内容
+type dependencyGraphData struct {
+	Nodes     []v1.GraphNode `json:"nodes"`
+	Edges     []v1.GraphEdge `json:"edges"`
+	Truncated bool           `json:"truncated"`
+	Depth     int            `json:"depth"`
+}
+
+func (s *dependencyGraphService) Compute(ctx context.Context, req *v1.DependencyGraphComputeRequest) (*v1.DependencyGraphComputeResponseData, error) {
+	if req.Depth == 0 {
+		req.Depth = dependencyGraphDefaultDepth
+	}
+	root, err := s.relationRepository.GetObject(ctx, req.RootType, req.RootID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, v1.ErrNotFound
+		}
+		return nil, err
+	}
+	edges, err := s.loadGraphEdges(ctx, &req.DependencyGraphKey)
+	if err != nil {
+		return nil, err
+	}
+	return s.compute(ctx, &req.DependencyGraphKey, root, edges, req.Depth)
+}
+
+func (s *dependencyGraphService) ComputeAll(ctx context.Context, rootType string, graphTypes []string) (int, error) {
+	roots, err := s.relationRepository.GetObjects(ctx, rootType)
+	if err != nil {
+		return 0, err
+	}
+	changed := 0
+	for _, graphType := range graphTypes {
+		if _, ok := dependencyGraphRelationTypes[graphType]; !ok {
+			return changed, fmt.Errorf("unknown graph type %q", graphType)
+		}
+		edges, err := s.loadGraphEdges(ctx, &v1.DependencyGraphKey{GraphType: graphType})
+		if err != nil {
+			return changed, err
+		}
+		for _, root := range roots {
+			key := &v1.DependencyGraphKey{GraphType: graphType, RootType: root.ObjectType, RootID: root.ObjectID}
+			data, err := s.compute(ctx, key, root, edges, dependencyGraphDefaultDepth)
+			if err != nil {
+				return changed, err
+			}
+			if data.Changed {
+				changed++
+			}
+		}
+	}
+	return changed, nil
+}
+
+// compute 从root展开依赖图并与最新版本比较，节点和边有变化时写入新版本，否则只刷新计算时间
+func (s *dependencyGraphService) compute(ctx context.Context, key *v1.DependencyGraphKey, root repository.RelationObject, edges []v1.GraphEdge, depth int) (*v1.DependencyGraphComputeResponseData, error) {
+	started := time.Now()
+	direction := "out"
+	if key.GraphType == model.GraphTypeTopology {
+		direction = "both"
+	}
+	data, maxDepth := expandDependencyGraph(root, edges, direction, depth)
+	if err := fillGraphNodes(ctx, s.relationRepository, nodeIndex(data.Nodes)); err != nil {
+		return nil, err
+	}
+	calcDuration := time.Since(started).Milliseconds()
+	expiresAt := started.Add(dependencyGraphTTL)
+
+	latest, err := s.dependencyGraphRepository.GetDependencyGraph(ctx, key, 0)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if err == nil {
+		previous, err := decodeDependencyGraphData(latest.GraphData)
+		if err == nil && previous.Depth == data.Depth && sameDependencyGraph(previous, data) {
+			if err := s.dependencyGraphRepository.DependencyGraphRefresh(ctx, latest.ID, started, expiresAt, calcDuration); err != nil {
+				return nil, err
+			}
+			latest.CalcTime, latest.ExpiresAt, latest.CalcDuration = started, expiresAt, calcDuration
+			return &v1.DependencyGraphComputeResponseData{DependencyGraphVersionItem: toDependencyGraphVersionItem(latest)}, nil
+		}
+	}
+
+	graphID, err := s.sid.GenString()
+	if err != nil {
+		return nil, err
+	}
+	graphData, err := encodeDependencyGraphData(data)
+	if err != nil {
+		return nil, err
+	}
+	graph := model.DependencyGraph{
+		GraphID:      graphID,
+		GraphType:    key.GraphType,
+		RootType:     root.ObjectType,
+		RootID:       root.ObjectID,
+		GraphData:    graphData,
+		NodeCount:    len(data.Nodes),
+		EdgeCount:    len(data.Edges),
+		MaxDepth:     maxDepth,
+		CalcTime:     started,
+		CalcDuration: calcDuration,
+		Version:      latest.Version + 1,
+		Environment:  key.Environment,
+		TenantID:     key.TenantID,
+		ExpiresAt:    expiresAt,
+		IsActive:     true,
+		Description:  fmt.Sprintf("%s %s:%s", key.GraphType, root.ObjectType, root.ObjectID),
+	}
+	err = s.tm.Transaction(ctx, func(ctx context.Context) error {
+		return s.dependencyGraphRepository.DependencyGraphCreate(ctx, &graph)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &v1.DependencyGraphComputeResponseData{
+		DependencyGraphVersionItem: toDependencyGraphVersionItem(graph),
+		Changed:                    true,
+	}, nil
+}
+
+func (s *dependencyGraphService) GetDependencyGraph(ctx context.Context, req *v1.GetDependencyGraphRequest) (*v1.DependencyGraphDataItem, error) {
+	graph, err := s.getVersion(ctx, &req.DependencyGraphKey, req.Version)
+	if err != nil {
+		return nil, err
+	}
+	data, err := decodeDependencyGraphData(graph.GraphData)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if err := s.dependencyGraphRepository.DependencyGraphTouch(ctx, graph.ID, now); err != nil {
+		return nil, err
+	}
+	graph.AccessCount++
+	return &v1.DependencyGraphDataItem{
+		DependencyGraphVersionItem: toDependencyGraphVersionItem(graph),
+		Nodes:                      data.Nodes,
+		Edges:                      data.Edges,
+		Truncated:                  data.Truncated,
+	}, nil
+}
+
+func (s *dependencyGraphService) GetDependencyGraphVersions(ctx context.Context, req *v1.GetDependencyGraphVersionsRequest) (*v1.GetDependencyGraphVersionsResponseData, error) {
+	list, err := s.dependencyGraphRepository.GetDependencyGraphVersions(ctx, &req.DependencyGraphKey)
+	if err != nil {
+		return nil, err
+	}
+	data := &v1.GetDependencyGraphVersionsResponseData{
+		List: make([]v1.DependencyGraphVersionItem, 0, len(list)),
+	}
+	for _, graph := range list {
+		data.List = append(data.List, toDependencyGraphVersionItem(graph))
+	}
+	return data, nil
+}
+
+// Diff 比较两个版本的节点和边，节点按ID、边按ID和关系类型识别
+func (s *dependencyGraphService) Diff(ctx context.Context, req *v1.DependencyGraphDiffRequest) (*v1.DependencyGraphDiffResponseData, error) {
+	from, err := s.getVersion(ctx, &req.DependencyGraphKey, req.FromVersion)
+	if err != nil {
+		return nil, err
+	}
+	to, err := s.getVersion(ctx, &req.DependencyGraphKey, req.ToVersion)
+	if err != nil {
+		return nil, err
+	}
+	fromData, err := decodeDependencyGraphData(from.GraphData)
+	if err != nil {
+		return nil, err
+	}
+	toData, err := decodeDependencyGraphData(to.GraphData)
+	if err != nil {
+		return nil, err
+	}
+	data := &v1.DependencyGraphDiffResponseData{
+		FromVersion:  from.Version,
+		ToVersion:    to.Version,
+		NodesAdded:   make([]v1.GraphNode, 0),
+		NodesRemoved: make([]v1.GraphNode, 0),
+		EdgesAdded:   make([]v1.GraphEdge, 0),
+		EdgesRemoved: make([]v1.GraphEdge, 0),
+	}
+	fromNodes, toNodes := nodeIndex(fromData.Nodes), nodeIndex(toData.Nodes)
+	for _, node := range toData.Nodes {
+		if _, ok := fromNodes[node.ID]; !ok {
+			data.NodesAdded = append(data.NodesAdded, node)
+		}
+	}
+	for _, node := range fromData.Nodes {
+		if _, ok := toNodes[node.ID]; !ok {
+			data.NodesRemoved = append(data.NodesRemoved, node)
+		}
+	}
+	fromEdges, toEdges := edgeKeys(fromData.Edges), edgeKeys(toData.Edges)
+	for _, edge := range toData.Edges {
+		if _, ok := fromEdges[edgeKey(edge)]; !ok {
+			data.EdgesAdded = append(data.EdgesAdded, edge)
+		}
+	}
+	for _, edge := range fromData.Edges {
+		if _, ok := toEdges[edgeKey(edge)]; !ok {
+			data.EdgesRemoved = append(data.EdgesRemoved, edge)
+		}
+	}
+	return data, nil
+}
+
+func (s *dependencyGraphService) getVersion(ctx context.Context, key *v1.DependencyGraphKey, version int64) (model.DependencyGraph, error) {
+	graph, err := s.dependencyGraphRepository.GetDependencyGraph(ctx, key, version)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return graph, v1.ErrNotFound
+		}
+		return graph, err
+	}
+	return graph, nil
+}
+
+// loadGraphEdges 加载图类型对应的全部边：生效的通用关系(按环境、租户过滤)、资源关系，
+// 以及业务-服务、服务-资源、应用部署、应用依赖、应用配置这些内置关联
+func (s *dependencyGraphService) loadGraphEdges(ctx context.Context, key *v1.DependencyGraphKey) ([]v1.GraphEdge, error) {
+	relationTypes := dependencyGraphRelationTypes[key.GraphType]
+	allowed := func(relationType string) bool {
+		return relationTypes == nil || containsString(relationTypes, relationType)
+	}
+	edges := make([]v1.GraphEdge, 0)
+	now := time.Now()
+	relations, err := s.relationRepository.GetActiveUniversalRelations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, relation := range relations {
+		if (relation.EffectiveTime != nil && relation.EffectiveTime.After(now)) ||
+			(relation.ExpireTime != nil && !relation.ExpireTime.After(now)) {
+			continue
+		}
+		if (key.Environment != "" && relation.Environment != key.Environment) ||
+			(key.TenantID != "" && relation.TenantID != key.TenantID) || !allowed(relation.RelationType) {
+			continue
+		}
+		edges = append(edges, v1.GraphEdge{
+			ID:           relation.RelationID,
+			Source:       graphNodeID(relation.SourceType, relation.SourceID),
+			Target:       graphNodeID(relation.TargetType, relation.TargetID),
+			RelationType: relation.RelationType,
+			Direction:    relation.Direction,
+			Weight:       relation.Weight,
+			Properties:   relation.Properties,
+		})
+	}
+	resourceRelations, err := s.relationRepository.GetAllResourceRelations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, relation := range resourceRelations {
+		if relation.Source.ID == 0 || relation.Target.ID == 0 || !allowed(relation.RelationType) {
+			continue
+		}
+		edges = append(edges, v1.GraphEdge{
+			ID:           resourceRelationKey(relation.ID),
+			Source:       graphNodeID(model.ObjectTypeResource, relation.Source.ResourceID),
+			Target:       graphNodeID(model.ObjectTypeResource, relation.Target.ResourceID),
+			RelationType: relation.RelationType,
+			Direction:    relation.Direction,
+			Weight:       float64(relation.Weight),
+			Properties:   relation.Properties,
+		})
+	}
+
+	builtin := []struct {
+		name         string
+		load         func(ctx context.Context) ([]repository.ObjectLink, error)
+		sourceType   string
+		targetType   string
+		relationType string
+		reverse      bool
+	}{
+		{"business_service", s.impactRepository.GetBusinessServices, model.ObjectTypeBusiness, model.ObjectTypeService, model.UniversalRelationContains, false},
+		{"service_resource", s.impactRepository.GetServiceResources, model.ObjectTypeService, model.ObjectTypeResource, model.UniversalRelationUses, false},
+		// 部署关系查询结果为资源->应用，依赖图中表示为应用runs_on资源
+		{"application_deployment", s.impactRepository.GetApplicationDeployments, model.ObjectTypeResource, model.ObjectTypeApplication, model.UniversalRelationRunsOn, true},
+		{"application_dependency", s.impactRepository.GetApplicationDependencies, model.ObjectTypeApplication, model.ObjectTypeApplication, model.UniversalRelationDependsOn, false},
+		{"application_configuration", s.impactRepository.GetApplicationConfigurations, model.ObjectTypeConfiguration, model.ObjectTypeApplication, model.UniversalRelationConfigures, false},
+	}
+	// 内置关联可能存在重复记录(如同一业务服务的多个角色)，按边ID去重
+	seen := make(map[string]struct{})
+	for _, item := range builtin {
+		if !allowed(item.relationType) {
+			continue
+		}
+		links, err := item.load(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, link := range links {
+			source, target := graphNodeID(item.sourceType, link.SourceID), graphNodeID(item.targetType, link.TargetID)
+			if item.reverse {
+				source, target = target, source
+			}
+			id := fmt.Sprintf("%s:%s:%s", item.name, link.SourceID, link.TargetID)
+			if _, ok := seen[id]; ok {
+				continue
+			}
+			seen[id] = struct{}{}
+			edges = append(edges, v1.GraphEdge{
+				ID:           id,
+				Source:       source,
+				Target:       target,
+				RelationType: item.relationType,
+				Direction:    "forward",
+				Weight:       1,
+			})
+		}
+	}
+	return edges, nil
+}
+
+// expandDependencyGraph 从root按层展开depth跳，返回图数据和实际到达的最大深度
+func expandDependencyGraph(root repository.RelationObject, edges []v1.GraphEdge, direction string, depth int) (dependencyGraphData, int) {
+	out := make(map[string][]int)
+	in := make(map[string][]int)
+	for i, edge := range edges {
+		out[edge.Source] = append(out[edge.Source], i)
+		in[edge.Target] = append(in[edge.Target], i)
+	}
+	rootID := graphNodeID(root.ObjectType, root.ObjectID)
+	data := dependencyGraphData{
+		Nodes: []v1.GraphNode{{ID: rootID, Type: root.ObjectType, ObjectID: root.ObjectID, Name: root.Name, SubType: root.SubType}},
+		Edges: make([]v1.GraphEdge, 0),
+		Depth: depth,
+	}
+	nodes := map[string]int{rootID: 0}
+	added := make(map[int]struct{})
+	frontier := []string{rootID}
+	maxDepth := 0
+	addNode := func(id string, level int) bool {
+		if _, ok := nodes[id]; ok {
+			return true
+		}
+		if len(nodes) >= graphMaxNodes {
+			data.Truncated = true
+			return false
+		}
+		objectType, objectID := splitGraphNodeID(id)
+		nodes[id] = level
+		data.Nodes = append(data.Nodes, v1.GraphNode{ID: id, Type: objectType, ObjectID: objectID, Depth: level})
+		return true
+	}
+	for level := 1; level <= depth && len(frontier) > 0; level++ {
+		next := make([]string, 0)
+		for _, id := range frontier {
+			candidates := out[id]
+			if direction == "both" {
+				candidates = append(append([]int(nil), candidates...), in[id]...)
+			}
+			for _, i := range candidates {
+				if _, ok := added[i]; ok {
+					continue
+				}
+				edge := edges[i]
+				neighbor := edge.Target
+				if neighbor == id {
+					neighbor = edge.Source
+				}
+				_, seen := nodes[neighbor]
+				if !addNode(neighbor, level) {
+					continue
+				}
+				if !seen {
+					next = append(next, neighbor)
+					maxDepth = level
+				}
+				added[i] = struct{}{}
+				data.Edges = append(data.Edges, edge)
+			}
+		}
+		frontier = next
+	}
+	return data, maxDepth
+}
+
+func splitGraphNodeID(id string) (string, string) {
+	objectType, objectID, _ := strings.Cut(id, ":")
+	return objectType, objectID
+}
+
+func nodeIndex(nodes []v1.GraphNode) map[string]*v1.GraphNode {
+	index := make(map[string]*v1.GraphNode, len(nodes))
+	for i := range nodes {
+		index[nodes[i].ID] = &nodes[i]
+	}
+	return index
+}
+
+func edgeKey(edge v1.GraphEdge) string {
+	return edge.ID + "|" + edge.RelationType
+}
+
+func edgeKeys(edges []v1.GraphEdge) map[string]struct{} {
+	keys := make(map[string]struct{}, len(edges))
+	for _, edge := range edges {
+		keys[edgeKey(edge)] = struct{}{}
+	}
+	return keys
+}
+
+// sameDependencyGraph 判断两次计算的节点集合和边集合是否一致
+func sameDependencyGraph(a, b dependencyGraphData) bool {
+	if len(a.Nodes) != len(b.Nodes) || len(a.Edges) != len(b.Edges) || a.Truncated != b.Truncated {
+		return false
+	}
+	nodes := nodeIndex(a.Nodes)
+	for _, node := range b.Nodes {
+		if _, ok := nodes[node.ID]; !ok {
+			return false
+		}
+	}
+	edges := edgeKeys(a.Edges)
+	for _, edge := range b.Edges {
+		if _, ok := edges[edgeKey(edge)]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func encodeDependencyGraphData(data dependencyGraphData) (model.JSONMap, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	m := model.JSONMap{}
+	return m, json.Unmarshal(raw, &m)
+}
+
+func decodeDependencyGraphData(m model.JSONMap) (dependencyGraphData, error) {
+	var data dependencyGraphData
+	raw, err := json.Marshal(m)
+	if err != nil {
+		return data, err
+	}
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return data, err
+	}
+	if data.Nodes == nil {
+		data.Nodes = make([]v1.GraphNode, 0)
+	}
+	if data.Edges == nil {
+		data.Edges = make([]v1.GraphEdge, 0)
+	}
+	return data, nil
+}
+
+func toDependencyGraphVersionItem(m model.DependencyGraph) v1.DependencyGraphVersionItem {
+	return v1.DependencyGraphVersionItem{
+		GraphID:      m.GraphID,
+		GraphType:    m.GraphType,
+		RootType:     m.RootType,
+		RootID:       m.RootID,
+		Environment:  m.Environment,
+		TenantID:     m.TenantID,
+		Version:      m.Version,
+		NodeCount:    m.NodeCount,
+		EdgeCount:    m.EdgeCount,
+		MaxDepth:     m.MaxDepth,
+		CalcTime:     m.CalcTime.Format("2006-01-02 15:04:05"),
+		CalcDuration: m.CalcDuration,
+		ExpiresAt:    m.ExpiresAt.Format("2006-01-02 15:04:05"),
+		AccessCount:  m.AccessCount,
+	}
+}
@@ -0,0 +1,275 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	v1 "nunu-layout-admin/api/v1"
+	"nunu-layout-admin/internal/model"
+	"nunu-layout-admin/internal/repository"
+	"strings"
+	"time"
+)
+
+// 单次展开返回的最大节点数，超出后停止扩展并标记truncated
+const graphMaxNodes = 500
+
+type GraphService interface {
+	Expand(ctx context.Context, req *v1.GraphExpandRequest) (*v1.GraphExpandResponseData, error)
+}
+
+func NewGraphService(
+	service *Service,
+	relationRepository repository.RelationRepository,
+	resourceRepository repository.ResourceRepository,
+	historyRepository repository.HistoryRepository,
+) GraphService {
+	return &graphService{
+		Service:            service,
+		relationRepository: relationRepository,
+		resourceRepository: resourceRepository,
+		historyRepository:  historyRepository,
+	}
+}
+
+type graphService struct {
+	*Service
+	relationRepository repository.RelationRepository
+	resourceRepository repository.ResourceRepository
+	historyRepository  repository.HistoryRepository
+}
+
+func graphNodeID(objectType, objectID string) string {
+	return objectType + ":" + objectID
+}
+
+// Expand 从(type,id)出发按层展开通用关系图，最多depth跳。指定AsOf时按变更历史还原该时刻的关系和资源后展开
+func (s *graphService) Expand(ctx context.Context, req *v1.GraphExpandRequest) (*v1.GraphExpandResponseData, error) {
+	if req.Depth == 0 {
+		req.Depth = 1
+	}
+	if req.Direction == "" {
+		req.Direction = "both"
+	}
+	relationTypes := make([]string, 0, len(req.RelationTypes))
+	for _, t := range req.RelationTypes {
+		for _, item := range strings.Split(t, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				relationTypes = append(relationTypes, item)
+			}
+		}
+	}
+	req.RelationTypes = relationTypes
+
+	root, err := s.getRoot(ctx, req)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, v1.ErrNotFound
+		}
+		return nil, err
+	}
+	now := time.Now()
+	loadRelations := func(frontier map[string][]string) ([]model.UniversalRelation, error) {
+		return s.relationRepository.GetGraphRelations(ctx, req, frontier, now)
+	}
+	if !req.AsOf.IsZero() {
+		relations, err := universalRelationsAt(ctx, s.relationRepository, s.historyRepository, req.AsOf)
+		if err != nil {
+			return nil, err
+		}
+		loadRelations = func(frontier map[string][]string) ([]model.UniversalRelation, error) {
+			return filterGraphRelations(req, relations, frontier, req.AsOf), nil
+		}
+	}
+	data := &v1.GraphExpandResponseData{
+		Nodes: make([]v1.GraphNode, 0),
+		Edges: make([]v1.GraphEdge, 0),
+	}
+	nodes := map[string]*v1.GraphNode{
+		graphNodeID(root.ObjectType, root.ObjectID): {
+			ID:       graphNodeID(root.ObjectType, root.ObjectID),
+			Type:     root.ObjectType,
+			ObjectID: root.ObjectID,
+			Name:     root.Name,
+			SubType:  root.SubType,
+		},
+	}
+	order := []string{graphNodeID(root.ObjectType, root.ObjectID)}
+	edges := make(map[string]struct{})
+	frontier := map[string][]string{root.ObjectType: {root.ObjectID}}
+
+	for depth := 1; depth <= req.Depth && len(frontier) > 0; depth++ {
+		relations, err := loadRelations(frontier)
+		if err != nil {
+			return nil, err
+		}
+		inFrontier := make(map[string]struct{})
+		for objectType, ids := range frontier {
+			for _, id := range ids {
+				inFrontier[graphNodeID(objectType, id)] = struct{}{}
+			}
+		}
+		next := make(map[string][]string)
+		for _, relation := range relations {
+			if _, ok := edges[relation.RelationID]; ok {
+				continue
+			}
+			sourceID := graphNodeID(relation.SourceType, relation.SourceID)
+			targetID := graphNodeID(relation.TargetType, relation.TargetID)
+			for _, end := range graphNeighbors(req.Direction, relation, inFrontier) {
+				id := graphNodeID(end.ObjectType, end.ObjectID)
+				if _, ok := nodes[id]; ok {
+					continue
+				}
+				if len(nodes) >= graphMaxNodes {
+					data.Truncated = true
+					continue
+				}
+				nodes[id] = &v1.GraphNode{ID: id, Type: end.ObjectType, ObjectID: end.ObjectID, Name: end.Name, Depth: depth}
+				order = append(order, id)
+				next[end.ObjectType] = append(next[end.ObjectType], end.ObjectID)
+			}
+			_, hasSource := nodes[sourceID]
+			_, hasTarget := nodes[targetID]
+			if !hasSource || !hasTarget {
+				continue
+			}
+			edges[relation.RelationID] = struct{}{}
+			data.Edges = append(data.Edges, v1.GraphEdge{
+				ID:           relation.RelationID,
+				Source:       sourceID,
+				Target:       targetID,
+				RelationType: relation.RelationType,
+				Direction:    relation.Direction,
+				Weight:       relation.Weight,
+				Properties:   relation.Properties,
+			})
+		}
+		frontier = next
+	}
+
+	if err := fillGraphNodes(ctx, s.relationRepository, nodes); err != nil {
+		return nil, err
+	}
+	if !req.AsOf.IsZero() {
+		if err := s.fillResourceNodesAt(ctx, nodes, req.AsOf); err != nil {
+			return nil, err
+		}
+	}
+	for _, id := range order {
+		data.Nodes = append(data.Nodes, *nodes[id])
+	}
+	return data, nil
+}
+
+// getRoot 查找展开的起点，按时间点查询资源时以当时的资源状态为准(资源可能已被删除)
+func (s *graphService) getRoot(ctx context.Context, req *v1.GraphExpandRequest) (repository.RelationObject, error) {
+	if req.AsOf.IsZero() || req.Type != model.ObjectTypeResource {
+		return s.relationRepository.GetObject(ctx, req.Type, req.ID)
+	}
+	resources, err := resourcesAt(ctx, s.resourceRepository, s.historyRepository, []string{req.ID}, req.AsOf)
+	if err != nil {
+		return repository.RelationObject{}, err
+	}
+	if len(resources) == 0 {
+		return repository.RelationObject{}, gorm.ErrRecordNotFound
+	}
+	return resourceRelationObject(resources[0]), nil
+}
+
+// fillResourceNodesAt 用asOf时刻的资源名称和类型覆盖资源节点
+func (s *graphService) fillResourceNodesAt(ctx context.Context, nodes map[string]*v1.GraphNode, asOf time.Time) error {
+	resourceIDs := make([]string, 0)
+	for _, node := range nodes {
+		if node.Type == model.ObjectTypeResource {
+			resourceIDs = append(resourceIDs, node.ObjectID)
+		}
+	}
+	if len(resourceIDs) == 0 {
+		return nil
+	}
+	resources, err := resourcesAt(ctx, s.resourceRepository, s.historyRepository, resourceIDs, asOf)
+	if err != nil {
+		return err
+	}
+	for _, resource := range resources {
+		if node, ok := nodes[graphNodeID(model.ObjectTypeResource, resource.ResourceID)]; ok {
+			node.Name = resource.Name
+			node.SubType = resource.Type
+		}
+	}
+	return nil
+}
+
+// filterGraphRelations 与GetGraphRelations条件一致的内存过滤，relations需已按优先级排序
+func filterGraphRelations(req *v1.GraphExpandRequest, relations []model.UniversalRelation, objects map[string][]string, now time.Time) []model.UniversalRelation {
+	inObjects := make(map[string]struct{})
+	for objectType, ids := range objects {
+		for _, id := range ids {
+			inObjects[graphNodeID(objectType, id)] = struct{}{}
+		}
+	}
+	list := make([]model.UniversalRelation, 0)
+	for _, relation := range relations {
+		_, fromSource := inObjects[graphNodeID(relation.SourceType, relation.SourceID)]
+		_, fromTarget := inObjects[graphNodeID(relation.TargetType, relation.TargetID)]
+		if !(fromSource && req.Direction != "in") && !(fromTarget && req.Direction != "out") {
+			continue
+		}
+		if relation.EffectiveTime != nil && relation.EffectiveTime.After(now) {
+			continue
+		}
+		if relation.ExpireTime != nil && !relation.ExpireTime.After(now) {
+			continue
+		}
+		if len(req.RelationTypes) > 0 && !containsString(req.RelationTypes, relation.RelationType) {
+			continue
+		}
+		if req.Environment != "" && relation.Environment != req.Environment {
+			continue
+		}
+		if req.TenantID != "" && relation.TenantID != req.TenantID {
+			continue
+		}
+		if !req.IncludeInactive && !relation.IsActive {
+			continue
+		}
+		list = append(list, relation)
+	}
+	return list
+}
+
+// graphNeighbors 返回关系中位于当前层之外、按方向可达的一端
+func graphNeighbors(direction string, relation model.UniversalRelation, inFrontier map[string]struct{}) []repository.RelationObject {
+	neighbors := make([]repository.RelationObject, 0, 2)
+	if _, ok := inFrontier[graphNodeID(relation.SourceType, relation.SourceID)]; ok && direction != "in" {
+		neighbors = append(neighbors, repository.RelationObject{ObjectType: relation.TargetType, ObjectID: relation.TargetID, Name: relation.TargetName})
+	}
+	if _, ok := inFrontier[graphNodeID(relation.TargetType, relation.TargetID)]; ok && direction != "out" {
+		neighbors = append(neighbors, repository.RelationObject{ObjectType: relation.SourceType, ObjectID: relation.SourceID, Name: relation.SourceName})
+	}
+	return neighbors
+}
+
+// fillGraphNodes 批量补全节点的名称和细分类型，关系中的冗余名称可能已过时
+func fillGraphNodes(ctx context.Context, relationRepository repository.RelationRepository, nodes map[string]*v1.GraphNode) error {
+	ids := make(map[string][]string)
+	for _, node := range nodes {
+		if node.Depth > 0 {
+			ids[node.Type] = append(ids[node.Type], node.ObjectID)
+		}
+	}
+	for objectType, objectIDs := range ids {
+		objects, err := relationRepository.GetObjectsByIDs(ctx, objectType, objectIDs)
+		if err != nil {
+			return err
+		}
+		for _, obj := range objects {
+			if node, ok := nodes[graphNodeID(obj.ObjectType, obj.ObjectID)]; ok {
+				node.Name = obj.Name
+				node.SubType = obj.SubType
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,402 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	v1 "nunu-layout-admin/api/v1"
+	"nunu-layout-admin/internal/model"
+	"nunu-layout-admin/internal/repository"
+	"nunu-layout-admin/pkg/envelope"
+	"nunu-layout-admin/pkg/probe"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type HealthCheckService interface {
+	GetProbeResults(ctx context.Context, req *v1.GetApplicationProbeResultsRequest) (*v1.GetApplicationProbeResultsResponseData, error)
+	// ApplicationHealthCheck 立即探测一次并更新健康状态，不受检查间隔限制
+	ApplicationHealthCheck(ctx context.Context, appID string) (*v1.ApplicationProbeResultItem, error)
+	// RunHealthChecks 探测到达检查间隔的运行中实例，返回探测的实例数。依赖关系中关闭了健康检查的实例不探测
+	RunHealthChecks(ctx context.Context) (int, error)
+	// PurgeProbeResults 删除超过cmdb.health_check.retention_days的探测结果
+	PurgeProbeResults(ctx context.Context) (int64, error)
+}
+
+func NewHealthCheckService(
+	service *Service,
+	conf *viper.Viper,
+	keyring *envelope.Keyring,
+	healthCheckRepository repository.HealthCheckRepository,
+	applicationRepository repository.ApplicationRepository,
+	historyRepository repository.HistoryRepository,
+) HealthCheckService {
+	return &healthCheckService{
+		Service:               service,
+		conf:                  conf,
+		keyring:               keyring,
+		healthCheckRepository: healthCheckRepository,
+		applicationRepository: applicationRepository,
+		historyRepository:     historyRepository,
+	}
+}
+
+type healthCheckService struct {
+	*Service
+	conf                  *viper.Viper
+	keyring               *envelope.Keyring
+	healthCheckRepository repository.HealthCheckRepository
+	applicationRepository repository.ApplicationRepository
+	historyRepository     repository.HistoryRepository
+}
+
+// probePlan 一个实例的探测参数和目标地址
+type probePlan struct {
+	application model.Application
+	config      probe.Config
+	host        string
+	port        int
+	err         error
+}
+
+func (p probePlan) target() string {
+	if p.host == "" || p.port <= 0 {
+		return ""
+	}
+	return net.JoinHostPort(p.host, strconv.Itoa(p.port))
+}
+
+func (s *healthCheckService) GetProbeResults(ctx context.Context, req *v1.GetApplicationProbeResultsRequest) (*v1.GetApplicationProbeResultsResponseData, error) {
+	application, err := s.applicationRepository.GetApplication(ctx, req.AppID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, v1.ErrNotFound
+		}
+		return nil, err
+	}
+	list, total, err := s.healthCheckRepository.GetProbeResults(ctx, application.ID, req)
+	if err != nil {
+		return nil, err
+	}
+	data := &v1.GetApplicationProbeResultsResponseData{
+		List:  make([]v1.ApplicationProbeResultItem, 0, len(list)),
+		Total: total,
+	}
+	for _, m := range list {
+		data.List = append(data.List, toProbeResultItem(m))
+	}
+	return data, nil
+}
+
+func (s *healthCheckService) ApplicationHealthCheck(ctx context.Context, appID string) (*v1.ApplicationProbeResultItem, error) {
+	application, err := s.applicationRepository.GetApplication(ctx, appID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, v1.ErrNotFound
+		}
+		return nil, err
+	}
+	config, err := parseHealthCheckConfig(application.ApplicationType)
+	if err != nil {
+		return nil, err
+	}
+	plan, err := s.planProbe(application, config)
+	if err != nil {
+		return nil, err
+	}
+	result := probe.Run(ctx, plan.config, plan.host, plan.port)
+	m, err := s.saveProbeResult(ctx, plan, result, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	item := toProbeResultItem(m)
+	return &item, nil
+}
+
+func (s *healthCheckService) RunHealthChecks(ctx context.Context) (int, error) {
+	ctx = WithChangeSource(ctx, model.ChangeSourceScheduled)
+	now := time.Now()
+	list, err := s.healthCheckRepository.GetProbeApplications(ctx)
+	if err != nil {
+		return 0, err
+	}
+	disabledIDs, err := s.healthCheckRepository.GetHealthCheckDisabledApplicationIDs(ctx)
+	if err != nil {
+		return 0, err
+	}
+	disabled := make(map[uint]bool, len(disabledIDs))
+	for _, id := range disabledIDs {
+		disabled[id] = true
+	}
+	plans := make([]probePlan, 0, len(list))
+	for _, application := range list {
+		if len(application.ApplicationType.HealthCheckConfig) == 0 || disabled[application.ID] {
+			continue
+		}
+		config, err := parseHealthCheckConfig(application.ApplicationType)
+		if err != nil {
+			s.logger.WithContext(ctx).Debug("skip health check", zap.String("appId", application.AppID), zap.Error(err))
+			continue
+		}
+		if application.LastHealthCheck != nil && now.Before(application.LastHealthCheck.Add(config.Interval)) {
+			continue
+		}
+		// 探测前先写入检查时间，耗时较长的一轮未结束时，下一轮或其他任务进程不会重复探测同一实例
+		claimed, err := s.healthCheckRepository.ClaimHealthCheck(ctx, application.ID, application.LastHealthCheck, now)
+		if err != nil {
+			return 0, err
+		}
+		if !claimed {
+			continue
+		}
+		// 无法确定探测地址时记为一次失败的探测，便于在探测结果中发现配置问题
+		plan, err := s.planProbe(application, config)
+		plan.err = err
+		plans = append(plans, plan)
+	}
+
+	results := make([]probe.Result, len(plans))
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, s.concurrency())
+	for i := range plans {
+		if plans[i].err != nil {
+			results[i] = probe.Result{Message: plans[i].err.Error()}
+			continue
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i] = probe.Run(ctx, plans[i].config, plans[i].host, plans[i].port)
+		}(i)
+	}
+	wg.Wait()
+
+	for i := range plans {
+		if _, err := s.saveProbeResult(ctx, plans[i], results[i], now); err != nil {
+			// 探测期间实例已被删除
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				continue
+			}
+			return i, fmt.Errorf("application %s: %w", plans[i].application.AppID, err)
+		}
+	}
+	return len(plans), nil
+}
+
+func (s *healthCheckService) PurgeProbeResults(ctx context.Context) (int64, error) {
+	days := s.conf.GetInt("cmdb.health_check.retention_days")
+	if days <= 0 {
+		days = 7
+	}
+	return s.healthCheckRepository.PurgeProbeResults(ctx, time.Now().AddDate(0, 0, -days))
+}
+
+// planProbe 确定探测地址。地址依次取健康检查配置的host、NetworkConfig的probe_host/host、
+// 非通配的bind_ip和部署资源的ip_address属性；端口见probePort
+func (s *healthCheckService) planProbe(application model.Application, config probe.Config) (probePlan, error) {
+	plan := probePlan{application: application, config: config, host: config.Host}
+	if plan.host == "" {
+		for _, key := range []string{"probe_host", "host"} {
+			if host, ok := application.NetworkConfig[key].(string); ok && host != "" {
+				plan.host = host
+				break
+			}
+		}
+	}
+	if plan.host == "" {
+		if ip, ok := application.NetworkConfig["bind_ip"].(string); ok {
+			if parsed := net.ParseIP(ip); parsed != nil && !parsed.IsUnspecified() {
+				plan.host = ip
+			}
+		}
+	}
+	if plan.host == "" {
+		attributes, err := openSensitive(s.keyring, application.Resource.Attributes)
+		if err != nil {
+			return plan, err
+		}
+		plan.host, _ = attributes["ip_address"].(string)
+	}
+	plan.port = probePort(config, application.ListenPorts)
+	if plan.target() == "" {
+		return plan, v1.ErrHealthCheckTarget
+	}
+	return plan, nil
+}
+
+// saveProbeResult 保存探测结果并按阈值更新实例健康状态，健康状态变化时记录应用变更历史
+func (s *healthCheckService) saveProbeResult(ctx context.Context, plan probePlan, result probe.Result, checkedAt time.Time) (model.ApplicationProbeResult, error) {
+	m := model.ApplicationProbeResult{
+		ApplicationID: plan.application.ID,
+		AppID:         plan.application.AppID,
+		Method:        plan.config.Method,
+		Target:        plan.target(),
+		Success:       result.Success,
+		LatencyMs:     result.Latency.Milliseconds(),
+		Message:       truncateString(result.Message, 500),
+		CheckedAt:     checkedAt,
+	}
+	err := s.tm.Transaction(ctx, func(ctx context.Context) error {
+		// 重新读取，避免覆盖探测期间心跳等写入的内容
+		old, err := s.applicationRepository.GetApplication(ctx, plan.application.AppID)
+		if err != nil {
+			return err
+		}
+		limit := plan.config.Threshold
+		if plan.config.HealthyThreshold > limit {
+			limit = plan.config.HealthyThreshold
+		}
+		outcomes := []bool{result.Success}
+		if limit > 1 {
+			recent, err := s.healthCheckRepository.GetRecentProbeResults(ctx, old.ID, limit-1)
+			if err != nil {
+				return err
+			}
+			for _, item := range recent {
+				outcomes = append(outcomes, item.Success)
+			}
+		}
+		application := old
+		application.HealthStatus = nextHealthStatus(old.HealthStatus, outcomes, plan.config.Threshold, plan.config.HealthyThreshold)
+		application.LastHealthCheck = &checkedAt
+		m.HealthStatus = application.HealthStatus
+		if err := s.healthCheckRepository.ProbeResultCreate(ctx, &m); err != nil {
+			return err
+		}
+		if err := s.healthCheckRepository.ApplicationHealthUpdate(ctx, &application); err != nil {
+			return err
+		}
+		if application.HealthStatus == old.HealthStatus {
+			return nil
+		}
+		reason := fmt.Sprintf("健康检查(%s %s): %s", m.Method, m.Target, m.Message)
+		return recordApplicationHistory(ctx, s.historyRepository, model.ChangeTypeUpdate, &old, &application, reason, "health_check")
+	})
+	return m, err
+}
+
+func (s *healthCheckService) concurrency() int {
+	if n := s.conf.GetInt("cmdb.health_check.concurrency"); n > 0 {
+		return n
+	}
+	return 20
+}
+
+func parseHealthCheckConfig(appType model.ApplicationType) (probe.Config, error) {
+	if len(appType.HealthCheckConfig) == 0 {
+		return probe.Config{}, v1.ErrHealthCheckNotConfigured
+	}
+	config, err := probe.ParseConfig(appType.HealthCheckConfig)
+	if err != nil {
+		return config, &v1.ValidationError{
+			Err:    v1.ErrHealthCheckNotConfigured,
+			Fields: []v1.FieldError{{Field: "healthCheckConfig", Message: err.Error()}},
+		}
+	}
+	return config, nil
+}
+
+// nextHealthStatus 按最近的探测结果(新的在前，含本次)计算健康状态：
+// 连续threshold次失败为unhealthy，连续healthyThreshold次成功为healthy，否则保持原状态
+func nextHealthStatus(current string, outcomes []bool, threshold, healthyThreshold int) string {
+	if leadingOutcomes(outcomes, false) >= threshold {
+		return model.HealthStatusUnhealthy
+	}
+	if leadingOutcomes(outcomes, true) >= healthyThreshold {
+		return model.HealthStatusHealthy
+	}
+	if current == "" {
+		return model.HealthStatusUnknown
+	}
+	return current
+}
+
+func leadingOutcomes(outcomes []bool, success bool) int {
+	n := 0
+	for _, outcome := range outcomes {
+		if outcome != success {
+			break
+		}
+		n++
+	}
+	return n
+}
+
+// probePortNames 未配置端口时优先使用的ListenPorts端口名
+var probePortNames = map[string]string{
+	probe.MethodDNS:   "dns",
+	probe.MethodHTTP:  "http",
+	probe.MethodHTTPS: "https",
+}
+
+// probePort 端口依次取配置的port(端口号或ListenPorts中的端口名)、探测方式对应的端口名、
+// 按名称排序的第一个ListenPorts端口和探测方式的默认端口
+func probePort(config probe.Config, ports model.JSONMap) int {
+	if config.Port != "" {
+		if port, err := strconv.Atoi(config.Port); err == nil {
+			return port
+		}
+		return portValue(ports[config.Port])
+	}
+	if port := portValue(ports[probePortNames[config.Method]]); port > 0 {
+		return port
+	}
+	keys := make([]string, 0, len(ports))
+	for key := range ports {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if port := portValue(ports[key]); port > 0 {
+			return port
+		}
+	}
+	return config.DefaultPort()
+}
+
+// portValue ListenPorts的值可以是端口号、数字字符串或包含port的对象
+func portValue(v interface{}) int {
+	switch value := v.(type) {
+	case float64:
+		return int(value)
+	case int:
+		return value
+	case string:
+		port, _ := strconv.Atoi(value)
+		return port
+	case map[string]interface{}:
+		return portValue(value["port"])
+	}
+	return 0
+}
+
+func truncateString(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
+
+func toProbeResultItem(m model.ApplicationProbeResult) v1.ApplicationProbeResultItem {
+	return v1.ApplicationProbeResultItem{
+		ID:           m.ID,
+		AppID:        m.AppID,
+		Method:       m.Method,
+		Target:       m.Target,
+		Success:      m.Success,
+		LatencyMs:    m.LatencyMs,
+		Message:      m.Message,
+		HealthStatus: m.HealthStatus,
+		CheckedAt:    m.CheckedAt.Format("2006-01-02 15:04:05"),
+	}
+}
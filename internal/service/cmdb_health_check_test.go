@@ -0,0 +1,349 @@
+package service
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	v1 "nunu-layout-admin/api/v1"
+	"nunu-layout-admin/internal/model"
+	"nunu-layout-admin/internal/repository"
+	"nunu-layout-admin/pkg/log"
+	"nunu-layout-admin/pkg/probe"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// fakeTransaction 直接执行fn，测试中的内存仓库不需要事务
+type fakeTransaction struct{}
+
+func (fakeTransaction) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+// fakeHealthCheckStore 内存中的应用实例、探测结果和变更历史，同时实现健康检查用到的几个仓库方法；
+// 未实现的方法调用时会panic
+type fakeHealthCheckStore struct {
+	repository.ApplicationRepository
+	repository.HistoryRepository
+
+	mu           sync.Mutex
+	applications map[string]*model.Application
+	disabled     []uint
+	results      []model.ApplicationProbeResult
+	histories    []*model.ApplicationHistory
+}
+
+func newFakeHealthCheckStore(list ...model.Application) *fakeHealthCheckStore {
+	s := &fakeHealthCheckStore{applications: make(map[string]*model.Application)}
+	for i := range list {
+		m := list[i]
+		m.ID = uint(i + 1)
+		s.applications[m.AppID] = &m
+	}
+	return s
+}
+
+func (s *fakeHealthCheckStore) GetApplication(ctx context.Context, appID string) (model.Application, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if m, ok := s.applications[appID]; ok {
+		return *m, nil
+	}
+	return model.Application{}, gorm.ErrRecordNotFound
+}
+
+func (s *fakeHealthCheckStore) GetProbeApplications(ctx context.Context) ([]model.Application, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var list []model.Application
+	for _, m := range s.applications {
+		if m.Status == model.AppStatusRunning {
+			list = append(list, *m)
+		}
+	}
+	return list, nil
+}
+
+func (s *fakeHealthCheckStore) GetHealthCheckDisabledApplicationIDs(ctx context.Context) ([]uint, error) {
+	return s.disabled, nil
+}
+
+func (s *fakeHealthCheckStore) GetRecentProbeResults(ctx context.Context, applicationID uint, limit int) ([]model.ApplicationProbeResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var list []model.ApplicationProbeResult
+	for i := len(s.results) - 1; i >= 0 && len(list) < limit; i-- {
+		if s.results[i].ApplicationID == applicationID {
+			list = append(list, s.results[i])
+		}
+	}
+	return list, nil
+}
+
+func (s *fakeHealthCheckStore) GetProbeResults(ctx context.Context, applicationID uint, req *v1.GetApplicationProbeResultsRequest) ([]model.ApplicationProbeResult, int64, error) {
+	panic("not implemented")
+}
+
+func (s *fakeHealthCheckStore) ProbeResultCreate(ctx context.Context, m *model.ApplicationProbeResult) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m.ID = uint(len(s.results) + 1)
+	s.results = append(s.results, *m)
+	return nil
+}
+
+func (s *fakeHealthCheckStore) ClaimHealthCheck(ctx context.Context, id uint, last *time.Time, now time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, m := range s.applications {
+		if m.ID != id {
+			continue
+		}
+		if (m.LastHealthCheck == nil) != (last == nil) || (last != nil && !m.LastHealthCheck.Equal(*last)) {
+			return false, nil
+		}
+		m.LastHealthCheck = &now
+		return true, nil
+	}
+	return false, nil
+}
+
+func (s *fakeHealthCheckStore) ApplicationHealthUpdate(ctx context.Context, m *model.Application) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	application, ok := s.applications[m.AppID]
+	if !ok {
+		return gorm.ErrRecordNotFound
+	}
+	application.HealthStatus = m.HealthStatus
+	application.LastHealthCheck = m.LastHealthCheck
+	return nil
+}
+
+func (s *fakeHealthCheckStore) PurgeProbeResults(ctx context.Context, before time.Time) (int64, error) {
+	panic("not implemented")
+}
+
+func (s *fakeHealthCheckStore) GetAuditConfigs(ctx context.Context) ([]model.AuditConfig, error) {
+	return nil, nil
+}
+
+func (s *fakeHealthCheckStore) GetLatestHistoryVersion(ctx context.Context, objectType, objectID string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return int64(len(s.histories)), nil
+}
+
+func (s *fakeHealthCheckStore) HistoryCreate(ctx context.Context, m interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.histories = append(s.histories, m.(*model.ApplicationHistory))
+	return nil
+}
+
+func (s *fakeHealthCheckStore) healthStatus(appID string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.applications[appID].HealthStatus
+}
+
+// setLastHealthCheck 修改最后检查时间，模拟经过了检查间隔
+func (s *fakeHealthCheckStore) setLastHealthCheck(appID string, t time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.applications[appID].LastHealthCheck = &t
+}
+
+func newTestHealthCheckService(store *fakeHealthCheckStore) HealthCheckService {
+	service := &Service{logger: &log.Logger{Logger: zap.NewNop()}, tm: fakeTransaction{}}
+	return NewHealthCheckService(service, viper.New(), nil, store, store, store)
+}
+
+// probedApplication 使用http_get探测srv的运行中实例
+func probedApplication(t *testing.T, appID string, srv *httptest.Server, conf model.JSONMap) model.Application {
+	t.Helper()
+	host, port, err := net.SplitHostPort(srv.Listener.Addr().String())
+	if err != nil {
+		t.Fatalf("split %s: %v", srv.Listener.Addr(), err)
+	}
+	config := model.JSONMap{"method": probe.MethodHTTP, "path": "/health", "timeout": float64(1)}
+	for key, value := range conf {
+		config[key] = value
+	}
+	return model.Application{
+		AppID:           appID,
+		Status:          model.AppStatusRunning,
+		NetworkConfig:   model.JSONMap{"probe_host": host},
+		ListenPorts:     model.JSONMap{"http": port},
+		ApplicationType: model.ApplicationType{HealthCheckConfig: config},
+	}
+}
+
+// toggleServer 返回的服务在healthy为true时响应200，否则响应503
+func toggleServer(t *testing.T) (*httptest.Server, *atomic.Bool) {
+	t.Helper()
+	var healthy atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if healthy.Load() {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &healthy
+}
+
+func TestApplicationHealthCheckThresholds(t *testing.T) {
+	srv, healthy := toggleServer(t)
+	store := newFakeHealthCheckStore(probedApplication(t, "app-001", srv, model.JSONMap{
+		"threshold":         float64(2),
+		"healthy_threshold": float64(2),
+	}))
+	s := newTestHealthCheckService(store)
+
+	steps := []struct {
+		healthy bool
+		want    string
+	}{
+		{true, model.HealthStatusUnknown}, // 连续成功次数未达到healthy_threshold
+		{true, model.HealthStatusHealthy},
+		{false, model.HealthStatusHealthy}, // 连续失败次数未达到threshold
+		{false, model.HealthStatusUnhealthy},
+		{false, model.HealthStatusUnhealthy},
+		{true, model.HealthStatusUnhealthy},
+		{true, model.HealthStatusHealthy},
+	}
+	for i, step := range steps {
+		healthy.Store(step.healthy)
+		item, err := s.ApplicationHealthCheck(context.Background(), "app-001")
+		if err != nil {
+			t.Fatalf("step %d: ApplicationHealthCheck: %v", i, err)
+		}
+		if item.Success != step.healthy {
+			t.Errorf("step %d: success = %v, message %q", i, item.Success, item.Message)
+		}
+		if item.HealthStatus != step.want || store.healthStatus("app-001") != step.want {
+			t.Errorf("step %d: health status = %s (saved %s), want %s", i, item.HealthStatus, store.healthStatus("app-001"), step.want)
+		}
+	}
+	if store.results[2].Message != "unexpected status 503" {
+		t.Errorf("failed probe message = %q, want unexpected status 503", store.results[2].Message)
+	}
+
+	// 只在健康状态变化时记录历史: unknown、healthy、unhealthy、healthy
+	if len(store.histories) != 4 {
+		t.Fatalf("histories = %d, want 4", len(store.histories))
+	}
+	last := store.histories[3]
+	if last.ChangeSource != model.ChangeSourceManual || last.AfterData["health_status"] != model.HealthStatusHealthy {
+		t.Errorf("last history = %s %v", last.ChangeSource, last.AfterData["health_status"])
+	}
+}
+
+func TestRunHealthChecks(t *testing.T) {
+	srv, healthy := toggleServer(t)
+	healthy.Store(false)
+	refused := httptest.NewServer(http.NotFoundHandler())
+	refused.Close()
+	store := newFakeHealthCheckStore(
+		probedApplication(t, "app-001", srv, model.JSONMap{"threshold": float64(1)}),
+		probedApplication(t, "app-002", refused, model.JSONMap{"threshold": float64(1), "interval": float64(60)}),
+		model.Application{AppID: "app-003", Status: model.AppStatusStopped},
+		probedApplication(t, "app-004", srv, model.JSONMap{"threshold": float64(1)}),
+	)
+	// app-004的依赖关系关闭了健康检查
+	store.disabled = []uint{4}
+	s := newTestHealthCheckService(store)
+
+	n, err := s.RunHealthChecks(context.Background())
+	if err != nil || n != 2 {
+		t.Fatalf("RunHealthChecks = %d, %v, want 2", n, err)
+	}
+	for _, appID := range []string{"app-001", "app-002"} {
+		if status := store.healthStatus(appID); status != model.HealthStatusUnhealthy {
+			t.Errorf("%s health status = %s, want unhealthy", appID, status)
+		}
+	}
+	if status := store.healthStatus("app-004"); status != "" {
+		t.Errorf("app-004 health status = %s, want not probed", status)
+	}
+	for _, m := range store.histories {
+		if m.ChangeSource != model.ChangeSourceScheduled {
+			t.Errorf("%s change source = %s, want scheduled", m.ApplicationUUID, m.ChangeSource)
+		}
+	}
+
+	// 未到检查间隔的实例不探测
+	healthy.Store(true)
+	if n, err := s.RunHealthChecks(context.Background()); err != nil || n != 0 {
+		t.Fatalf("RunHealthChecks within interval = %d, %v, want 0", n, err)
+	}
+	store.setLastHealthCheck("app-001", time.Now().Add(-time.Minute))
+	if n, err := s.RunHealthChecks(context.Background()); err != nil || n != 1 {
+		t.Fatalf("RunHealthChecks after interval = %d, %v, want 1", n, err)
+	}
+	if status := store.healthStatus("app-001"); status != model.HealthStatusHealthy {
+		t.Errorf("app-001 health status = %s, want healthy", status)
+	}
+	if status := store.healthStatus("app-002"); status != model.HealthStatusUnhealthy {
+		t.Errorf("app-002 health status = %s, want unhealthy", status)
+	}
+}
+
+func TestNextHealthStatus(t *testing.T) {
+	tests := []struct {
+		current  string
+		outcomes []bool
+		want     string
+	}{
+		{"", []bool{false}, model.HealthStatusUnknown},
+		{"", []bool{false, false, false}, model.HealthStatusUnhealthy},
+		{model.HealthStatusHealthy, []bool{false, false, true}, model.HealthStatusHealthy},
+		{model.HealthStatusUnhealthy, []bool{true, false, false}, model.HealthStatusUnhealthy},
+		{model.HealthStatusUnhealthy, []bool{true, true, false}, model.HealthStatusHealthy},
+		{model.HealthStatusDegraded, []bool{true}, model.HealthStatusDegraded},
+	}
+	for _, tt := range tests {
+		if got := nextHealthStatus(tt.current, tt.outcomes, 3, 2); got != tt.want {
+			t.Errorf("nextHealthStatus(%q, %v) = %s, want %s", tt.current, tt.outcomes, got, tt.want)
+		}
+	}
+}
+
+func TestRunHealthChecksSkipsClaimedApplications(t *testing.T) {
+	arrived := make(chan struct{}, 1)
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		arrived <- struct{}{}
+		<-release
+	}))
+	t.Cleanup(srv.Close)
+	store := newFakeHealthCheckStore(probedApplication(t, "app-001", srv, model.JSONMap{"timeout": float64(5)}))
+	s := newTestHealthCheckService(store)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := s.RunHealthChecks(context.Background())
+		done <- err
+	}()
+	<-arrived
+	// 上一轮的探测尚未结束，实例已被认领
+	if n, err := s.RunHealthChecks(context.Background()); err != nil || n != 0 {
+		t.Errorf("overlapping RunHealthChecks = %d, %v, want 0", n, err)
+	}
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatalf("RunHealthChecks: %v", err)
+	}
+	if len(store.results) != 1 {
+		t.Errorf("probe results = %d, want 1", len(store.results))
+	}
+}
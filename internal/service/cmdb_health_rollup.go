@@ -0,0 +1,495 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	v1 "nunu-layout-admin/api/v1"
+	"nunu-layout-admin/internal/model"
+	"nunu-layout-admin/internal/repository"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// 汇总健康状态的严重程度，unknown不参与比较
+var healthSeverity = map[string]int{
+	model.HealthStatusHealthy:  0,
+	model.HealthStatusDegraded: 1,
+	model.HealthStatusDown:     2,
+}
+
+type HealthRollupService interface {
+	GetServiceHealthRule(ctx context.Context, serviceID string) (*v1.ServiceHealthRuleData, error)
+	// ServiceHealthRuleUpdate 创建或替换服务的健康汇总规则
+	ServiceHealthRuleUpdate(ctx context.Context, req *v1.ServiceHealthRuleUpdateRequest) error
+	ServiceHealthRuleDelete(ctx context.Context, serviceID string) error
+	// GetServiceHealth 返回服务保存的健康状态和按当前成员、依赖重新计算的结果
+	GetServiceHealth(ctx context.Context, serviceID string) (*v1.ServiceHealthResponseData, error)
+	// RollupHealth 由应用实例健康状态汇总服务和业务的健康状态，变化时记录变更历史
+	RollupHealth(ctx context.Context, dryRun bool) (*v1.HealthRollupResponseData, error)
+}
+
+func NewHealthRollupService(
+	service *Service,
+	healthRollupRepository repository.HealthRollupRepository,
+	serviceRepository repository.ServiceRepository,
+	historyRepository repository.HistoryRepository,
+) HealthRollupService {
+	return &healthRollupService{
+		Service:                service,
+		healthRollupRepository: healthRollupRepository,
+		serviceRepository:      serviceRepository,
+		historyRepository:      historyRepository,
+	}
+}
+
+type healthRollupService struct {
+	*Service
+	healthRollupRepository repository.HealthRollupRepository
+	serviceRepository      repository.ServiceRepository
+	historyRepository      repository.HistoryRepository
+}
+
+// healthResult 一个服务或业务的汇总结果
+type healthResult struct {
+	status  string
+	reasons []string
+	total   int
+	healthy int
+}
+
+// healthFinding 一条规则命中的结果
+type healthFinding struct {
+	status string
+	reason string
+}
+
+// healthRollup 一次汇总用到的全部数据和计算结果，服务和业务均以业务唯一标识为键
+type healthRollup struct {
+	services        []model.Service
+	businesses      []model.Business
+	rules           map[uint]model.ServiceHealthRule
+	members         map[string][]repository.HealthMember
+	serviceResults  map[string]healthResult
+	businessResults map[string]healthResult
+}
+
+func (s *healthRollupService) GetServiceHealthRule(ctx context.Context, serviceID string) (*v1.ServiceHealthRuleData, error) {
+	service, err := s.getService(ctx, serviceID)
+	if err != nil {
+		return nil, err
+	}
+	rule, isDefault, err := s.getRule(ctx, service.ID)
+	if err != nil {
+		return nil, err
+	}
+	data := toServiceHealthRuleData(serviceID, rule, isDefault)
+	return &data, nil
+}
+
+func (s *healthRollupService) ServiceHealthRuleUpdate(ctx context.Context, req *v1.ServiceHealthRuleUpdateRequest) error {
+	service, err := s.getService(ctx, req.ServiceID)
+	if err != nil {
+		return err
+	}
+	var fields []v1.FieldError
+	for i, dependency := range req.CriticalDependencies {
+		if dependency == req.ServiceID {
+			fields = append(fields, v1.FieldError{Field: fmt.Sprintf("criticalDependencies[%d]", i), Message: "不能依赖服务自身"})
+			continue
+		}
+		if _, err := s.serviceRepository.GetService(ctx, dependency); err != nil {
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+			fields = append(fields, v1.FieldError{Field: fmt.Sprintf("criticalDependencies[%d]", i), Message: "服务" + dependency + "不存在"})
+		}
+	}
+	if len(fields) > 0 {
+		return &v1.ValidationError{Err: v1.ErrHealthRuleDependency, Fields: fields}
+	}
+	m := model.ServiceHealthRule{
+		ServiceID:            service.ID,
+		Enabled:              req.Enabled == nil || *req.Enabled,
+		DownBelowMembers:     req.DownBelowMembers,
+		MinHealthyMembers:    req.MinHealthyMembers,
+		MinHealthyRatio:      req.MinHealthyRatio,
+		CriticalRoles:        req.CriticalRoles,
+		CriticalDependencies: req.CriticalDependencies,
+		DegradeOnDependency:  req.DegradeOnDependency,
+		Description:          req.Description,
+	}
+	old, err := s.healthRollupRepository.GetServiceHealthRule(ctx, service.ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return s.healthRollupRepository.ServiceHealthRuleCreate(ctx, &m)
+		}
+		return err
+	}
+	m.ID = old.ID
+	return s.healthRollupRepository.ServiceHealthRuleUpdate(ctx, &m)
+}
+
+func (s *healthRollupService) ServiceHealthRuleDelete(ctx context.Context, serviceID string) error {
+	service, err := s.getService(ctx, serviceID)
+	if err != nil {
+		return err
+	}
+	rule, err := s.healthRollupRepository.GetServiceHealthRule(ctx, service.ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return v1.ErrNotFound
+		}
+		return err
+	}
+	return s.healthRollupRepository.ServiceHealthRuleDelete(ctx, rule.ID)
+}
+
+func (s *healthRollupService) GetServiceHealth(ctx context.Context, serviceID string) (*v1.ServiceHealthResponseData, error) {
+	service, err := s.getService(ctx, serviceID)
+	if err != nil {
+		return nil, err
+	}
+	rollup, err := s.compute(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rule, isDefault := rollup.rule(service.ID)
+	result := rollup.serviceResults[serviceID]
+	data := &v1.ServiceHealthResponseData{
+		ServiceID:       serviceID,
+		HealthStatus:    service.HealthStatus,
+		EvaluatedStatus: result.status,
+		Reasons:         result.reasons,
+		Total:           result.total,
+		Healthy:         result.healthy,
+		Members:         make([]v1.ServiceHealthMemberItem, 0, len(rollup.members[serviceID])),
+		Dependencies:    make([]v1.ServiceHealthDependencyItem, 0, len(rule.CriticalDependencies)),
+		Rule:            toServiceHealthRuleData(serviceID, rule, isDefault),
+	}
+	for _, member := range rollup.members[serviceID] {
+		data.Members = append(data.Members, v1.ServiceHealthMemberItem{
+			AppID:        member.AppID,
+			Name:         member.Name,
+			Role:         member.Role,
+			Status:       member.Status,
+			HealthStatus: member.HealthStatus,
+			Healthy:      memberHealthy(member),
+		})
+	}
+	for _, dependency := range rule.CriticalDependencies {
+		data.Dependencies = append(data.Dependencies, v1.ServiceHealthDependencyItem{
+			ServiceID:    dependency,
+			HealthStatus: rollup.serviceResults[dependency].status,
+		})
+	}
+	return data, nil
+}
+
+func (s *healthRollupService) RollupHealth(ctx context.Context, dryRun bool) (*v1.HealthRollupResponseData, error) {
+	rollup, err := s.compute(ctx)
+	if err != nil {
+		return nil, err
+	}
+	data := &v1.HealthRollupResponseData{
+		DryRun:     dryRun,
+		Services:   len(rollup.services),
+		Businesses: len(rollup.businesses),
+		Changes:    make([]v1.HealthRollupChangeItem, 0),
+	}
+	for _, old := range rollup.services {
+		result := rollup.serviceResults[old.ServiceID]
+		if result.status == old.HealthStatus {
+			continue
+		}
+		change := v1.HealthRollupChangeItem{
+			ObjectType: model.ObjectTypeService,
+			ObjectID:   old.ServiceID,
+			Name:       old.Name,
+			FromStatus: old.HealthStatus,
+			ToStatus:   result.status,
+			Reason:     strings.Join(result.reasons, "; "),
+		}
+		data.Changes = append(data.Changes, change)
+		if dryRun {
+			continue
+		}
+		old := old
+		err := s.tm.Transaction(ctx, func(ctx context.Context) error {
+			m := old
+			m.HealthStatus = result.status
+			if err := s.healthRollupRepository.ServiceHealthUpdate(ctx, &m); err != nil {
+				return err
+			}
+			return recordServiceHistory(ctx, s.historyRepository, model.ChangeTypeUpdate, &old, &m, change.Reason, "health_rollup")
+		})
+		if err != nil {
+			return nil, fmt.Errorf("service %s: %w", old.ServiceID, err)
+		}
+	}
+	for _, old := range rollup.businesses {
+		result := rollup.businessResults[old.BusinessID]
+		if result.status == old.HealthStatus {
+			continue
+		}
+		change := v1.HealthRollupChangeItem{
+			ObjectType: model.ObjectTypeBusiness,
+			ObjectID:   old.BusinessID,
+			Name:       old.Name,
+			FromStatus: old.HealthStatus,
+			ToStatus:   result.status,
+			Reason:     strings.Join(result.reasons, "; "),
+		}
+		data.Changes = append(data.Changes, change)
+		if dryRun {
+			continue
+		}
+		old := old
+		err := s.tm.Transaction(ctx, func(ctx context.Context) error {
+			m := old
+			m.HealthStatus = result.status
+			if err := s.healthRollupRepository.BusinessHealthUpdate(ctx, &m); err != nil {
+				return err
+			}
+			return recordBusinessHistory(ctx, s.historyRepository, model.ChangeTypeUpdate, &old, &m, change.Reason, "health_rollup")
+		})
+		if err != nil {
+			return nil, fmt.Errorf("business %s: %w", old.BusinessID, err)
+		}
+	}
+	return data, nil
+}
+
+// compute 读取全部服务、业务和成员并计算汇总结果。服务先只按成员计算，
+// 再按关键依赖反复传播直到不再变化，依赖成环时不会因环本身而判定为不可用
+func (s *healthRollupService) compute(ctx context.Context) (*healthRollup, error) {
+	rollup := &healthRollup{
+		rules:           make(map[uint]model.ServiceHealthRule),
+		members:         make(map[string][]repository.HealthMember),
+		serviceResults:  make(map[string]healthResult),
+		businessResults: make(map[string]healthResult),
+	}
+	var err error
+	if rollup.services, err = s.healthRollupRepository.GetRollupServices(ctx); err != nil {
+		return nil, err
+	}
+	if rollup.businesses, err = s.healthRollupRepository.GetRollupBusinesses(ctx); err != nil {
+		return nil, err
+	}
+	rules, err := s.healthRollupRepository.GetServiceHealthRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, rule := range rules {
+		rollup.rules[rule.ServiceID] = rule
+	}
+	resourceMembers, err := s.healthRollupRepository.GetResourceMembers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	groupMembers, err := s.healthRollupRepository.GetGroupMembers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// 同一实例既部署在服务资源上又是服务应用组成员时只计一次，优先使用组内角色
+	seen := make(map[string]bool)
+	for _, member := range append(groupMembers, resourceMembers...) {
+		key := member.ServiceID + "/" + member.AppID
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		rollup.members[member.ServiceID] = append(rollup.members[member.ServiceID], member)
+	}
+
+	memberFindings := make(map[string][]healthFinding, len(rollup.services))
+	for _, service := range rollup.services {
+		rule, _ := rollup.rule(service.ID)
+		if !rule.Enabled {
+			rollup.serviceResults[service.ServiceID] = healthResult{status: service.HealthStatus, reasons: []string{"未启用自动汇总"}}
+			continue
+		}
+		findings, total, healthy := evaluateMembers(rule, rollup.members[service.ServiceID])
+		memberFindings[service.ServiceID] = findings
+		rollup.serviceResults[service.ServiceID] = resolveHealth(findings, total, healthy)
+	}
+	for pass := 0; pass < len(rollup.services); pass++ {
+		changed := false
+		for _, service := range rollup.services {
+			rule, _ := rollup.rule(service.ID)
+			if !rule.Enabled || len(rule.CriticalDependencies) == 0 {
+				continue
+			}
+			current := rollup.serviceResults[service.ServiceID]
+			findings := append(append([]healthFinding{}, memberFindings[service.ServiceID]...), rollup.dependencyFindings(rule)...)
+			result := resolveHealth(findings, current.total, current.healthy)
+			if result.status != current.status {
+				changed = true
+			}
+			rollup.serviceResults[service.ServiceID] = result
+		}
+		if !changed {
+			break
+		}
+	}
+
+	links, err := s.healthRollupRepository.GetBusinessServices(ctx)
+	if err != nil {
+		return nil, err
+	}
+	businessLinks := make(map[string][]repository.ObjectLink)
+	for _, link := range links {
+		businessLinks[link.SourceID] = append(businessLinks[link.SourceID], link)
+	}
+	for _, business := range rollup.businesses {
+		rollup.businessResults[business.BusinessID] = rollup.evaluateBusiness(businessLinks[business.BusinessID])
+	}
+	return rollup, nil
+}
+
+func (r *healthRollup) rule(serviceID uint) (model.ServiceHealthRule, bool) {
+	if rule, ok := r.rules[serviceID]; ok {
+		return rule, false
+	}
+	return defaultServiceHealthRule(serviceID), true
+}
+
+func (r *healthRollup) dependencyFindings(rule model.ServiceHealthRule) []healthFinding {
+	var findings []healthFinding
+	for _, dependency := range rule.CriticalDependencies {
+		switch r.serviceResults[dependency].status {
+		case model.HealthStatusDown:
+			findings = append(findings, healthFinding{model.HealthStatusDown, "关键依赖服务" + dependency + "不可用"})
+		case model.HealthStatusDegraded:
+			if rule.DegradeOnDependency {
+				findings = append(findings, healthFinding{model.HealthStatusDegraded, "关键依赖服务" + dependency + "降级"})
+			}
+		}
+	}
+	return findings
+}
+
+// evaluateBusiness 重要性为high/critical的服务不可用时业务不可用，其他服务不可用或降级时业务降级
+func (r *healthRollup) evaluateBusiness(links []repository.ObjectLink) healthResult {
+	var findings []healthFinding
+	known := 0
+	for _, link := range links {
+		status := r.serviceResults[link.TargetID].status
+		if _, ok := healthSeverity[status]; !ok {
+			continue
+		}
+		known++
+		switch {
+		case status == model.HealthStatusDown && criticalityRank[link.Criticality] >= criticalityRank["high"]:
+			findings = append(findings, healthFinding{model.HealthStatusDown, fmt.Sprintf("重要性为%s的服务%s不可用", link.Criticality, link.TargetID)})
+		case status == model.HealthStatusDown:
+			findings = append(findings, healthFinding{model.HealthStatusDegraded, "服务" + link.TargetID + "不可用"})
+		case status == model.HealthStatusDegraded:
+			findings = append(findings, healthFinding{model.HealthStatusDegraded, "服务" + link.TargetID + "降级"})
+		}
+	}
+	return resolveHealth(findings, known, known)
+}
+
+// defaultServiceHealthRule 服务没有配置规则时使用：没有健康成员为down，有成员不健康为degraded
+func defaultServiceHealthRule(serviceID uint) model.ServiceHealthRule {
+	return model.ServiceHealthRule{ServiceID: serviceID, Enabled: true, DownBelowMembers: 1, MinHealthyRatio: 1}
+}
+
+// memberHealthy 运行中且没有被健康检查或心跳判定为不健康的实例视为健康成员
+func memberHealthy(m repository.HealthMember) bool {
+	return m.Status == model.AppStatusRunning && m.HealthStatus != model.HealthStatusUnhealthy
+}
+
+// evaluateMembers 按规则检查服务成员，返回命中的结果、成员数和健康成员数
+func evaluateMembers(rule model.ServiceHealthRule, members []repository.HealthMember) ([]healthFinding, int, int) {
+	total := len(members)
+	if total == 0 {
+		return nil, 0, 0
+	}
+	var findings []healthFinding
+	healthy := 0
+	for _, member := range members {
+		if memberHealthy(member) {
+			healthy++
+			continue
+		}
+		if member.Role != "" && containsString(rule.CriticalRoles, member.Role) {
+			findings = append(findings, healthFinding{model.HealthStatusDown, fmt.Sprintf("关键角色%s的成员%s不健康", member.Role, member.AppID)})
+		}
+	}
+	if healthy < rule.DownBelowMembers {
+		findings = append(findings, healthFinding{model.HealthStatusDown, fmt.Sprintf("健康成员%d个，少于%d个", healthy, rule.DownBelowMembers)})
+	}
+	if healthy < rule.MinHealthyMembers {
+		findings = append(findings, healthFinding{model.HealthStatusDegraded, fmt.Sprintf("健康成员%d个，少于%d个", healthy, rule.MinHealthyMembers)})
+	}
+	if ratio := float64(healthy) / float64(total); ratio < rule.MinHealthyRatio {
+		findings = append(findings, healthFinding{model.HealthStatusDegraded, fmt.Sprintf("健康成员占比%.0f%%，低于%.0f%%", ratio*100, rule.MinHealthyRatio*100)})
+	}
+	return findings, total, healthy
+}
+
+// resolveHealth 取命中结果中最严重的状态及其原因，没有命中时有成员为healthy，否则为unknown
+func resolveHealth(findings []healthFinding, total, healthy int) healthResult {
+	result := healthResult{status: model.HealthStatusHealthy, reasons: make([]string, 0), total: total, healthy: healthy}
+	for _, finding := range findings {
+		switch {
+		case healthSeverity[finding.status] > healthSeverity[result.status]:
+			result.status = finding.status
+			result.reasons = []string{finding.reason}
+		case finding.status == result.status && finding.status != model.HealthStatusHealthy:
+			result.reasons = append(result.reasons, finding.reason)
+		}
+	}
+	if result.status == model.HealthStatusHealthy && total == 0 {
+		result.status = model.HealthStatusUnknown
+		result.reasons = append(result.reasons, "没有可汇总的成员")
+	}
+	return result
+}
+
+func (s *healthRollupService) getService(ctx context.Context, serviceID string) (model.Service, error) {
+	m, err := s.serviceRepository.GetService(ctx, serviceID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return m, v1.ErrNotFound
+		}
+		return m, err
+	}
+	return m, nil
+}
+
+func (s *healthRollupService) getRule(ctx context.Context, serviceID uint) (model.ServiceHealthRule, bool, error) {
+	m, err := s.healthRollupRepository.GetServiceHealthRule(ctx, serviceID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return defaultServiceHealthRule(serviceID), true, nil
+		}
+		return m, false, err
+	}
+	return m, false, nil
+}
+
+func toServiceHealthRuleData(serviceID string, m model.ServiceHealthRule, isDefault bool) v1.ServiceHealthRuleData {
+	data := v1.ServiceHealthRuleData{
+		ServiceID:            serviceID,
+		Enabled:              m.Enabled,
+		DownBelowMembers:     m.DownBelowMembers,
+		MinHealthyMembers:    m.MinHealthyMembers,
+		MinHealthyRatio:      m.MinHealthyRatio,
+		CriticalRoles:        m.CriticalRoles,
+		CriticalDependencies: m.CriticalDependencies,
+		DegradeOnDependency:  m.DegradeOnDependency,
+		Description:          m.Description,
+		IsDefault:            isDefault,
+	}
+	if data.CriticalRoles == nil {
+		data.CriticalRoles = make([]string, 0)
+	}
+	if data.CriticalDependencies == nil {
+		data.CriticalDependencies = make([]string, 0)
+	}
+	return data
+}
@@ -0,0 +1,1014 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	v1 "nunu-layout-admin/api/v1"
+	"nunu-layout-admin/internal/model"
+	"nunu-layout-admin/internal/repository"
+	"nunu-layout-admin/pkg/envelope"
+	"nunu-layout-admin/pkg/jwt"
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type HistoryService interface {
+	GetHistories(ctx context.Context, req *v1.GetHistoriesRequest) (*v1.GetHistoriesResponseData, error)
+	Revert(ctx context.Context, req *v1.HistoryRevertRequest) (*v1.HistoryRevertResponseData, error)
+}
+
+func NewHistoryService(
+	service *Service,
+	keyring *envelope.Keyring,
+	historyRepository repository.HistoryRepository,
+	resourceRepository repository.ResourceRepository,
+	resourceTypeRepository repository.ResourceTypeRepository,
+	serviceRepository repository.ServiceRepository,
+	businessRepository repository.BusinessRepository,
+	sensitiveRepository repository.SensitiveRepository,
+) HistoryService {
+	return &historyService{
+		Service:                service,
+		keyring:                keyring,
+		historyRepository:      historyRepository,
+		resourceRepository:     resourceRepository,
+		resourceTypeRepository: resourceTypeRepository,
+		serviceRepository:      serviceRepository,
+		businessRepository:     businessRepository,
+		sensitiveRepository:    sensitiveRepository,
+	}
+}
+
+type historyService struct {
+	*Service
+	keyring                *envelope.Keyring
+	historyRepository      repository.HistoryRepository
+	resourceRepository     repository.ResourceRepository
+	resourceTypeRepository repository.ResourceTypeRepository
+	serviceRepository      repository.ServiceRepository
+	businessRepository     repository.BusinessRepository
+	sensitiveRepository    repository.SensitiveRepository
+}
+
+func (s *historyService) GetHistories(ctx context.Context, req *v1.GetHistoriesRequest) (*v1.GetHistoriesResponseData, error) {
+	view, err := newSensitiveView(ctx, s.keyring, s.historyRepository, s.sensitiveRepository, req.Reveal)
+	if err != nil {
+		return nil, err
+	}
+	list, total, err := s.historyRepository.GetHistories(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	data := &v1.GetHistoriesResponseData{
+		List:  make([]v1.HistoryDataItem, 0, len(list)),
+		Total: total,
+	}
+	for _, record := range list {
+		for _, m := range []*model.JSONMap{&record.BeforeData, &record.AfterData, &record.ChangedFields} {
+			if *m, err = view.apply(*m); err != nil {
+				return nil, err
+			}
+		}
+		data.List = append(data.List, v1.HistoryDataItem{
+			ID:            record.ID,
+			ObjectType:    req.ObjectType,
+			ObjectID:      req.ObjectID,
+			ChangeType:    record.ChangeType,
+			ChangeSource:  record.ChangeSource,
+			ChangeTime:    record.ChangeTime.Format("2006-01-02 15:04:05"),
+			OperatorID:    record.OperatorID,
+			OperatorName:  record.OperatorName,
+			OperatorIP:    record.OperatorIP,
+			BeforeData:    record.BeforeData,
+			AfterData:     record.AfterData,
+			ChangedFields: record.ChangedFields,
+			ChangeReason:  record.ChangeReason,
+			Comment:       record.Comment,
+			Version:       record.Version,
+		})
+	}
+	return data, nil
+}
+
+// Revert 把资源/服务/业务的字段和标签恢复为指定历史版本的AfterData，并记录一条带原因的更新历史。
+// CurrentVersion须为对象当前的最新历史版本，且当前数据与该版本一致，否则视为已被并发修改
+func (s *historyService) Revert(ctx context.Context, req *v1.HistoryRevertRequest) (*v1.HistoryRevertResponseData, error) {
+	target, err := s.historyRepository.GetHistory(ctx, req.ObjectType, req.ObjectID, req.Version)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, v1.ErrNotFound
+		}
+		return nil, err
+	}
+	if target.AfterData == nil {
+		return nil, v1.ErrHistoryVersionInvalid
+	}
+	data := &v1.HistoryRevertResponseData{}
+	err = s.tm.Transaction(ctx, func(ctx context.Context) error {
+		latest, err := s.historyRepository.GetHistory(ctx, req.ObjectType, req.ObjectID, 0)
+		if err != nil {
+			return err
+		}
+		if latest.Version != req.CurrentVersion {
+			return v1.ErrHistoryVersionConflict
+		}
+		comment := fmt.Sprintf("revert to version %d", req.Version)
+		switch req.ObjectType {
+		case model.ObjectTypeResource:
+			err = s.revertResource(ctx, req.ObjectID, latest.AfterData, target.AfterData, req.Reason, comment)
+		case model.ObjectTypeService:
+			err = s.revertService(ctx, req.ObjectID, latest.AfterData, target.AfterData, req.Reason, comment)
+		case model.ObjectTypeBusiness:
+			err = s.revertBusiness(ctx, req.ObjectID, latest.AfterData, target.AfterData, req.Reason, comment)
+		default:
+			err = v1.ErrBadRequest
+		}
+		if err != nil {
+			return err
+		}
+		data.Version, err = s.historyRepository.GetLatestHistoryVersion(ctx, req.ObjectType, req.ObjectID)
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, v1.ErrNotFound
+		}
+		return nil, err
+	}
+	data.Changed = data.Version > req.CurrentVersion
+	return data, nil
+}
+
+func (s *historyService) revertResource(ctx context.Context, resourceID string, latest, target model.JSONMap, reason, comment string) error {
+	old, err := s.resourceRepository.GetResource(ctx, resourceID)
+	if err != nil {
+		return err
+	}
+	current, err := resourceSnapshot(old)
+	if err != nil {
+		return err
+	}
+	// 密文在主密钥轮换后会变化，按明文比较
+	if latest, err = openSensitive(s.keyring, latest); err != nil {
+		return err
+	}
+	if current, err = openSensitive(s.keyring, current); err != nil {
+		return err
+	}
+	if len(diffSnapshots(latest, current)) > 0 {
+		return v1.ErrHistoryVersionConflict
+	}
+	reverted, err := resourceFromSnapshot(target)
+	if err != nil {
+		return err
+	}
+	reverted.Attributes, err = sealResourceAttributes(ctx, s.keyring, s.historyRepository, s.resourceTypeRepository,
+		reverted.Type, reverted.TenantID, reverted.Attributes, old.Attributes)
+	if err != nil {
+		return err
+	}
+	reverted.ID = old.ID
+	if err := s.resourceRepository.ResourceUpdate(ctx, &reverted); err != nil {
+		return err
+	}
+	if err := s.resourceRepository.ReplaceResourceTags(ctx, old.ID, reverted.Tags); err != nil {
+		return err
+	}
+	resource, err := s.resourceRepository.GetResource(ctx, resourceID)
+	if err != nil {
+		return err
+	}
+	return recordResourceHistory(ctx, s.historyRepository, model.ChangeTypeUpdate, &old, &resource, reason, comment)
+}
+
+func (s *historyService) revertService(ctx context.Context, serviceID string, latest, target model.JSONMap, reason, comment string) error {
+	old, err := s.serviceRepository.GetService(ctx, serviceID)
+	if err != nil {
+		return err
+	}
+	current, err := serviceSnapshot(old)
+	if err != nil {
+		return err
+	}
+	if len(diffSnapshots(latest, current)) > 0 {
+		return v1.ErrHistoryVersionConflict
+	}
+	reverted, err := serviceFromSnapshot(target)
+	if err != nil {
+		return err
+	}
+	reverted.ID = old.ID
+	if err := s.serviceRepository.ServiceUpdate(ctx, &reverted); err != nil {
+		return err
+	}
+	if err := s.serviceRepository.ReplaceServiceTags(ctx, old.ID, reverted.Tags); err != nil {
+		return err
+	}
+	m, err := s.serviceRepository.GetService(ctx, serviceID)
+	if err != nil {
+		return err
+	}
+	return recordServiceHistory(ctx, s.historyRepository, model.ChangeTypeUpdate, &old, &m, reason, comment)
+}
+
+func (s *historyService) revertBusiness(ctx context.Context, businessID string, latest, target model.JSONMap, reason, comment string) error {
+	old, err := s.businessRepository.GetBusiness(ctx, businessID)
+	if err != nil {
+		return err
+	}
+	current, err := businessSnapshot(old)
+	if err != nil {
+		return err
+	}
+	if len(diffSnapshots(latest, current)) > 0 {
+		return v1.ErrHistoryVersionConflict
+	}
+	reverted, err := businessFromSnapshot(target)
+	if err != nil {
+		return err
+	}
+	reverted.ID = old.ID
+	if err := s.businessRepository.BusinessUpdate(ctx, &reverted); err != nil {
+		return err
+	}
+	if err := s.businessRepository.ReplaceBusinessTags(ctx, old.ID, reverted.Tags); err != nil {
+		return err
+	}
+	m, err := s.businessRepository.GetBusiness(ctx, businessID)
+	if err != nil {
+		return err
+	}
+	return recordBusinessHistory(ctx, s.historyRepository, model.ChangeTypeUpdate, &old, &m, reason, comment)
+}
+
+type changeSourceCtxKey struct{}
+
+// WithChangeSource 指定ctx中后续写操作记录的变更来源(导入、同步、定时任务等)，HTTP请求默认为api
+func WithChangeSource(ctx context.Context, source string) context.Context {
+	return context.WithValue(ctx, changeSourceCtxKey{}, source)
+}
+
+// historyEntry 一次变更的公共部分，由各对象的record函数填入对应的历史表
+type historyEntry struct {
+	ChangeType    string
+	ChangeSource  string
+	ChangeTime    time.Time
+	OperatorID    string
+	OperatorName  string
+	OperatorIP    string
+	BeforeData    model.JSONMap
+	AfterData     model.JSONMap
+	ChangedFields model.JSONMap
+	Version       int64
+}
+
+// newHistoryEntry 计算字段级差异、版本号，并从请求上下文中取出操作人(JWT)和客户端IP。更新前后没有差异时返回nil，调用方不需要写历史。
+// 历史总是保存完整的变更前后数据，按时间点查询、回滚和快照都依赖它；审计配置只决定是否发送变更通知：
+// 需要启用审计，且更新涉及监控字段，通知中只包含监控字段的变化
+func newHistoryEntry(ctx context.Context, historyRepository repository.HistoryRepository, objectType, objectID, changeType string, before, after model.JSONMap) (*historyEntry, error) {
+	changed := diffSnapshots(before, after)
+	if changeType == model.ChangeTypeUpdate && len(changed) == 0 {
+		return nil, nil
+	}
+	configs, err := historyRepository.GetAuditConfigs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	data := after
+	if data == nil {
+		data = before
+	}
+	config := matchAuditConfig(configs, objectType, data)
+	version, err := historyRepository.GetLatestHistoryVersion(ctx, objectType, objectID)
+	if err != nil {
+		return nil, err
+	}
+	entry := &historyEntry{
+		ChangeType:    changeType,
+		ChangeSource:  model.ChangeSourceManual,
+		ChangeTime:    time.Now(),
+		BeforeData:    before,
+		AfterData:     after,
+		ChangedFields: changed,
+		Version:       version + 1,
+	}
+	if _, ok := ctx.Value(gin.ContextKey).(*gin.Context); ok {
+		entry.ChangeSource = model.ChangeSourceAPI
+	}
+	entry.OperatorID, entry.OperatorName, entry.OperatorIP = requestOperator(ctx, historyRepository)
+	if source, ok := ctx.Value(changeSourceCtxKey{}).(string); ok && source != "" {
+		entry.ChangeSource = source
+	}
+	if config != nil && config.EnableAudit {
+		audited := *entry
+		if changeType == model.ChangeTypeUpdate {
+			audited.ChangedFields = filterMonitoredFields(changed, config.MonitoredFields)
+		}
+		if len(audited.ChangedFields) > 0 || changeType != model.ChangeTypeUpdate {
+			if err := enqueueNotifications(ctx, historyRepository, config, objectType, objectID, data, &audited); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return entry, nil
+}
+
+// requestOperator 从请求上下文中取出操作人(JWT)和客户端IP，非HTTP请求时为空
+func requestOperator(ctx context.Context, historyRepository repository.HistoryRepository) (id, name, ip string) {
+	if ginCtx, ok := ctx.Value(gin.ContextKey).(*gin.Context); ok {
+		ip = ginCtx.ClientIP()
+	}
+	if claims, ok := ctx.Value("claims").(*jwt.MyCustomClaims); ok {
+		id = strconv.FormatUint(uint64(claims.UserId), 10)
+		if operatorName, err := historyRepository.GetOperatorName(ctx, claims.UserId); err == nil {
+			name = operatorName
+		}
+	}
+	return id, name, ip
+}
+
+// requestOperatorName 当前操作人的用户名，取不到时为用户ID
+func requestOperatorName(ctx context.Context, historyRepository repository.HistoryRepository) string {
+	id, name, _ := requestOperator(ctx, historyRepository)
+	if name == "" {
+		return id
+	}
+	return name
+}
+
+// recordResourceHistory 在当前事务中写入资源变更历史，创建时before为nil，删除时after为nil
+func recordResourceHistory(ctx context.Context, historyRepository repository.HistoryRepository, changeType string, before, after *model.Resource, reason, comment string) error {
+	var beforeData, afterData model.JSONMap
+	var err error
+	resource := after
+	if before != nil {
+		resource = before
+		if beforeData, err = resourceSnapshot(*before); err != nil {
+			return err
+		}
+	}
+	if after != nil {
+		if afterData, err = resourceSnapshot(*after); err != nil {
+			return err
+		}
+	}
+	entry, err := newHistoryEntry(ctx, historyRepository, model.ObjectTypeResource, resource.ResourceID, changeType, beforeData, afterData)
+	if err != nil || entry == nil {
+		return err
+	}
+	return historyRepository.HistoryCreate(ctx, &model.ResourceHistory{
+		ResourceID:    resource.ID,
+		ResourceUUID:  resource.ResourceID,
+		ChangeType:    entry.ChangeType,
+		ChangeSource:  entry.ChangeSource,
+		ChangeTime:    entry.ChangeTime,
+		OperatorID:    entry.OperatorID,
+		OperatorName:  entry.OperatorName,
+		OperatorIP:    entry.OperatorIP,
+		BeforeData:    entry.BeforeData,
+		AfterData:     entry.AfterData,
+		ChangedFields: entry.ChangedFields,
+		ChangeReason:  reason,
+		Comment:       comment,
+		Version:       entry.Version,
+	})
+}
+
+// recordServiceHistory 在当前事务中写入服务变更历史
+func recordServiceHistory(ctx context.Context, historyRepository repository.HistoryRepository, changeType string, before, after *model.Service, reason, comment string) error {
+	var beforeData, afterData model.JSONMap
+	var err error
+	service := after
+	if before != nil {
+		service = before
+	}
+	if err := recordHealthTransition(ctx, historyRepository, service, before, after, reason); err != nil {
+		return err
+	}
+	if before != nil {
+		if beforeData, err = serviceSnapshot(*before); err != nil {
+			return err
+		}
+	}
+	if after != nil {
+		if afterData, err = serviceSnapshot(*after); err != nil {
+			return err
+		}
+	}
+	entry, err := newHistoryEntry(ctx, historyRepository, model.ObjectTypeService, service.ServiceID, changeType, beforeData, afterData)
+	if err != nil || entry == nil {
+		return err
+	}
+	return historyRepository.HistoryCreate(ctx, &model.ServiceHistory{
+		ServiceID:     service.ID,
+		ServiceUUID:   service.ServiceID,
+		ChangeType:    entry.ChangeType,
+		ChangeSource:  entry.ChangeSource,
+		ChangeTime:    entry.ChangeTime,
+		OperatorID:    entry.OperatorID,
+		OperatorName:  entry.OperatorName,
+		OperatorIP:    entry.OperatorIP,
+		BeforeData:    entry.BeforeData,
+		AfterData:     entry.AfterData,
+		ChangedFields: entry.ChangedFields,
+		ChangeReason:  reason,
+		Comment:       comment,
+		Version:       entry.Version,
+	})
+}
+
+// recordHealthTransition 服务健康状态变化时写入变化记录，不受审计配置影响，SLA按它计算
+func recordHealthTransition(ctx context.Context, historyRepository repository.HistoryRepository, service, before, after *model.Service, reason string) error {
+	var from, to string
+	if before != nil {
+		from = before.HealthStatus
+	}
+	if after != nil {
+		to = after.HealthStatus
+	}
+	if from == to {
+		return nil
+	}
+	return historyRepository.HealthTransitionCreate(ctx, &model.ServiceHealthTransition{
+		ServiceID:   service.ID,
+		ServiceUUID: service.ServiceID,
+		FromStatus:  from,
+		ToStatus:    to,
+		ChangeTime:  time.Now(),
+		Reason:      reason,
+	})
+}
+
+// recordBusinessHistory 在当前事务中写入业务变更历史
+func recordBusinessHistory(ctx context.Context, historyRepository repository.HistoryRepository, changeType string, before, after *model.Business, reason, comment string) error {
+	var beforeData, afterData model.JSONMap
+	var err error
+	business := after
+	if before != nil {
+		business = before
+		if beforeData, err = businessSnapshot(*before); err != nil {
+			return err
+		}
+	}
+	if after != nil {
+		if afterData, err = businessSnapshot(*after); err != nil {
+			return err
+		}
+	}
+	entry, err := newHistoryEntry(ctx, historyRepository, model.ObjectTypeBusiness, business.BusinessID, changeType, beforeData, afterData)
+	if err != nil || entry == nil {
+		return err
+	}
+	return historyRepository.HistoryCreate(ctx, &model.BusinessHistory{
+		BusinessID:    business.ID,
+		BusinessUUID:  business.BusinessID,
+		ChangeType:    entry.ChangeType,
+		ChangeSource:  entry.ChangeSource,
+		ChangeTime:    entry.ChangeTime,
+		OperatorID:    entry.OperatorID,
+		OperatorName:  entry.OperatorName,
+		OperatorIP:    entry.OperatorIP,
+		BeforeData:    entry.BeforeData,
+		AfterData:     entry.AfterData,
+		ChangedFields: entry.ChangedFields,
+		ChangeReason:  reason,
+		Comment:       comment,
+		Version:       entry.Version,
+	})
+}
+
+// recordApplicationHistory 在当前事务中写入应用实例变更历史，before/after的Resource和ApplicationType需已加载
+func recordApplicationHistory(ctx context.Context, historyRepository repository.HistoryRepository, changeType string, before, after *model.Application, reason, comment string) error {
+	var beforeData, afterData model.JSONMap
+	var err error
+	application := after
+	if before != nil {
+		application = before
+		if beforeData, err = applicationSnapshot(*before); err != nil {
+			return err
+		}
+	}
+	if after != nil {
+		if afterData, err = applicationSnapshot(*after); err != nil {
+			return err
+		}
+	}
+	entry, err := newHistoryEntry(ctx, historyRepository, model.ObjectTypeApplication, application.AppID, changeType, beforeData, afterData)
+	if err != nil || entry == nil {
+		return err
+	}
+	return historyRepository.HistoryCreate(ctx, &model.ApplicationHistory{
+		ApplicationID:   application.ID,
+		ApplicationUUID: application.AppID,
+		ChangeType:      entry.ChangeType,
+		ChangeSource:    entry.ChangeSource,
+		ChangeTime:      entry.ChangeTime,
+		OperatorID:      entry.OperatorID,
+		OperatorName:    entry.OperatorName,
+		OperatorIP:      entry.OperatorIP,
+		BeforeData:      entry.BeforeData,
+		AfterData:       entry.AfterData,
+		ChangedFields:   entry.ChangedFields,
+		ChangeReason:    reason,
+		Comment:         comment,
+		Version:         entry.Version,
+	})
+}
+
+// recordResourceRelationHistory 写入资源关系变更历史，关系的Source/Target需已加载
+func recordResourceRelationHistory(ctx context.Context, historyRepository repository.HistoryRepository, changeType string, before, after *model.ResourceRelation) error {
+	var beforeData, afterData model.JSONMap
+	var err error
+	relation := after
+	if before != nil {
+		relation = before
+		if beforeData, err = resourceRelationSnapshot(*before); err != nil {
+			return err
+		}
+	}
+	if after != nil {
+		if afterData, err = resourceRelationSnapshot(*after); err != nil {
+			return err
+		}
+	}
+	entry, err := newHistoryEntry(ctx, historyRepository, model.HistoryObjectResourceRelation, strconv.FormatUint(uint64(relation.ID), 10), changeType, beforeData, afterData)
+	if err != nil || entry == nil {
+		return err
+	}
+	relationType := relation.RelationType
+	if after != nil {
+		relationType = after.RelationType
+	}
+	return historyRepository.HistoryCreate(ctx, &model.RelationHistory{
+		RelationID:    relation.ID,
+		SourceID:      relation.SourceID,
+		TargetID:      relation.TargetID,
+		RelationType:  relationType,
+		ChangeType:    entry.ChangeType,
+		ChangeSource:  entry.ChangeSource,
+		ChangeTime:    entry.ChangeTime,
+		OperatorID:    entry.OperatorID,
+		OperatorName:  entry.OperatorName,
+		OperatorIP:    entry.OperatorIP,
+		BeforeData:    entry.BeforeData,
+		AfterData:     entry.AfterData,
+		ChangedFields: entry.ChangedFields,
+		Version:       entry.Version,
+	})
+}
+
+// recordUniversalRelationHistory 写入通用关系变更历史
+func recordUniversalRelationHistory(ctx context.Context, historyRepository repository.HistoryRepository, changeType string, before, after *model.UniversalRelation) error {
+	var beforeData, afterData model.JSONMap
+	var err error
+	relation := after
+	if before != nil {
+		relation = before
+		if beforeData, err = universalRelationSnapshot(*before); err != nil {
+			return err
+		}
+	}
+	if after != nil {
+		if afterData, err = universalRelationSnapshot(*after); err != nil {
+			return err
+		}
+	}
+	entry, err := newHistoryEntry(ctx, historyRepository, model.HistoryObjectUniversalRelation, relation.RelationID, changeType, beforeData, afterData)
+	if err != nil || entry == nil {
+		return err
+	}
+	relationType := relation.RelationType
+	if after != nil {
+		relationType = after.RelationType
+	}
+	return historyRepository.HistoryCreate(ctx, &model.UniversalRelationHistory{
+		RelationID:    relation.RelationID,
+		SourceType:    relation.SourceType,
+		SourceID:      relation.SourceID,
+		TargetType:    relation.TargetType,
+		TargetID:      relation.TargetID,
+		RelationType:  relationType,
+		ChangeType:    entry.ChangeType,
+		ChangeSource:  entry.ChangeSource,
+		ChangeTime:    entry.ChangeTime,
+		OperatorID:    entry.OperatorID,
+		OperatorName:  entry.OperatorName,
+		OperatorIP:    entry.OperatorIP,
+		BeforeData:    entry.BeforeData,
+		AfterData:     entry.AfterData,
+		ChangedFields: entry.ChangedFields,
+		Version:       entry.Version,
+	})
+}
+
+// resourceSnapshot 资源的历史快照，标签按key展开为map，便于按字段比较
+func resourceSnapshot(m model.Resource) (model.JSONMap, error) {
+	tags := make(map[string]string, len(m.Tags))
+	for _, tag := range m.Tags {
+		tags[tag.Key] = tag.Value
+	}
+	return toJSONMap(map[string]interface{}{
+		"resource_id":    m.ResourceID,
+		"name":           m.Name,
+		"type":           m.Type,
+		"status":         m.Status,
+		"provider":       m.Provider,
+		"region":         m.Region,
+		"zone":           m.Zone,
+		"tenant_id":      m.TenantID,
+		"business_id":    m.BusinessID,
+		"environment":    m.Environment,
+		"attributes":     m.Attributes,
+		"description":    m.Description,
+		"last_sync_time": m.LastSyncTime,
+		"tags":           tags,
+	})
+}
+
+func serviceSnapshot(m model.Service) (model.JSONMap, error) {
+	tags := make(map[string]string, len(m.Tags))
+	for _, tag := range m.Tags {
+		tags[tag.Key] = tag.Value
+	}
+	return toJSONMap(map[string]interface{}{
+		"service_id":    m.ServiceID,
+		"name":          m.Name,
+		"type":          m.Type,
+		"status":        m.Status,
+		"tenant_id":     m.TenantID,
+		"business_id":   m.BusinessID,
+		"environment":   m.Environment,
+		"configuration": m.Configuration,
+		"endpoints":     m.Endpoints,
+		"health_status": m.HealthStatus,
+		"sla_target":    m.SLATarget,
+		"description":   m.Description,
+		"tags":          tags,
+	})
+}
+
+func businessSnapshot(m model.Business) (model.JSONMap, error) {
+	tags := make(map[string]string, len(m.Tags))
+	for _, tag := range m.Tags {
+		tags[tag.Key] = tag.Value
+	}
+	return toJSONMap(map[string]interface{}{
+		"business_id":   m.BusinessID,
+		"name":          m.Name,
+		"type":          m.Type,
+		"status":        m.Status,
+		"tenant_id":     m.TenantID,
+		"owner_id":      m.OwnerID,
+		"team_id":       m.TeamID,
+		"priority":      m.Priority,
+		"cost_center":   m.CostCenter,
+		"budget":        m.Budget,
+		"health_status": m.HealthStatus,
+		"description":   m.Description,
+		"tags":          tags,
+	})
+}
+
+// applicationSnapshot 应用实例的历史快照，心跳时间等运行时数据变化频繁，不记入历史
+func applicationSnapshot(m model.Application) (model.JSONMap, error) {
+	tags := make(map[string]string, len(m.Tags))
+	for _, tag := range m.Tags {
+		tags[tag.Key] = tag.Value
+	}
+	return toJSONMap(map[string]interface{}{
+		"app_id":          m.AppID,
+		"name":            m.Name,
+		"type_name":       m.ApplicationType.TypeName,
+		"version":         m.Version,
+		"status":          m.Status,
+		"resource_id":     m.Resource.ResourceID,
+		"deployment_type": m.DeploymentType,
+		"working_dir":     m.WorkingDir,
+		"executable_path": m.ExecutablePath,
+		"listen_ports":    m.ListenPorts,
+		"network_config":  m.NetworkConfig,
+		"resource_limits": m.ResourceLimits,
+		"environment":     m.Environment,
+		"tenant_id":       m.TenantID,
+		"start_time":      m.StartTime,
+		"health_status":   m.HealthStatus,
+		"description":     m.Description,
+		"tags":            tags,
+	})
+}
+
+func resourceRelationSnapshot(m model.ResourceRelation) (model.JSONMap, error) {
+	return toJSONMap(map[string]interface{}{
+		"source_resource_id": m.Source.ResourceID,
+		"target_resource_id": m.Target.ResourceID,
+		"relation_type":      m.RelationType,
+		"direction":          m.Direction,
+		"weight":             m.Weight,
+		"properties":         m.Properties,
+		"description":        m.Description,
+	})
+}
+
+func universalRelationSnapshot(m model.UniversalRelation) (model.JSONMap, error) {
+	return toJSONMap(map[string]interface{}{
+		"relation_id":    m.RelationID,
+		"source_type":    m.SourceType,
+		"source_id":      m.SourceID,
+		"target_type":    m.TargetType,
+		"target_id":      m.TargetID,
+		"relation_type":  m.RelationType,
+		"direction":      m.Direction,
+		"weight":         m.Weight,
+		"priority":       m.Priority,
+		"properties":     m.Properties,
+		"effective_time": m.EffectiveTime,
+		"expire_time":    m.ExpireTime,
+		"environment":    m.Environment,
+		"tenant_id":      m.TenantID,
+		"status":         m.Status,
+		"is_active":      m.IsActive,
+		"description":    m.Description,
+	})
+}
+
+// decodeSnapshot 把快照中除tags外的字段解码到dest(快照的键与模型JSON字段一致)，返回按key排序的标签
+func decodeSnapshot(data model.JSONMap, dest interface{}) ([][2]string, error) {
+	fields := make(model.JSONMap, len(data))
+	for key, value := range data {
+		if key != "tags" {
+			fields[key] = value
+		}
+	}
+	raw, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(raw, dest); err != nil {
+		return nil, err
+	}
+	tags, _ := data["tags"].(map[string]interface{})
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	pairs := make([][2]string, 0, len(keys))
+	for _, key := range keys {
+		value, _ := tags[key].(string)
+		pairs = append(pairs, [2]string{key, value})
+	}
+	return pairs, nil
+}
+
+// resourceFromSnapshot 由resourceSnapshot生成的快照还原资源
+func resourceFromSnapshot(data model.JSONMap) (model.Resource, error) {
+	m := model.Resource{}
+	tags, err := decodeSnapshot(data, &m)
+	if err != nil {
+		return m, err
+	}
+	for _, tag := range tags {
+		m.Tags = append(m.Tags, model.ResourceTag{Key: tag[0], Value: tag[1]})
+	}
+	return m, nil
+}
+
+func serviceFromSnapshot(data model.JSONMap) (model.Service, error) {
+	m := model.Service{}
+	tags, err := decodeSnapshot(data, &m)
+	if err != nil {
+		return m, err
+	}
+	for _, tag := range tags {
+		m.Tags = append(m.Tags, model.ServiceTag{Key: tag[0], Value: tag[1]})
+	}
+	return m, nil
+}
+
+func businessFromSnapshot(data model.JSONMap) (model.Business, error) {
+	m := model.Business{}
+	tags, err := decodeSnapshot(data, &m)
+	if err != nil {
+		return m, err
+	}
+	for _, tag := range tags {
+		m.Tags = append(m.Tags, model.BusinessTag{Key: tag[0], Value: tag[1]})
+	}
+	return m, nil
+}
+
+// universalRelationFromSnapshot 由universalRelationSnapshot生成的快照还原通用关系，快照的键与模型JSON字段一致
+func universalRelationFromSnapshot(data model.JSONMap) (model.UniversalRelation, error) {
+	m := model.UniversalRelation{}
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return m, err
+	}
+	return m, json.Unmarshal(raw, &m)
+}
+
+// resourceRelationFromSnapshot 由resourceRelationSnapshot生成的快照还原资源关系，同时返回两端资源的业务标识
+func resourceRelationFromSnapshot(data model.JSONMap) (model.ResourceRelation, string, string, error) {
+	m := model.ResourceRelation{}
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return m, "", "", err
+	}
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return m, "", "", err
+	}
+	sourceID, _ := data["source_resource_id"].(string)
+	targetID, _ := data["target_resource_id"].(string)
+	return m, sourceID, targetID, nil
+}
+
+// historyBaseline 对象在当前表中的记录(含已删除)，用于没有任何变更历史的对象
+type historyBaseline struct {
+	Data      model.JSONMap
+	CreatedAt time.Time
+	DeletedAt gorm.DeletedAt
+}
+
+// historyState 对象在某一时刻的快照，ChangeTime为产生该快照的变更时间，未知时为零值
+type historyState struct {
+	Data       model.JSONMap
+	ChangeTime time.Time
+}
+
+// historyStatesAt 按变更历史还原对象在asOf时刻的快照：取asOf及之前最后一条历史的AfterData，删除则对象不存在；
+// 之前没有历史时，之后第一条历史为创建说明对象尚不存在，否则取其BeforeData；
+// 完全没有历史的对象(历史记录启用前写入)以当前记录为准
+func historyStatesAt(ctx context.Context, historyRepository repository.HistoryRepository, objectType string, objectIDs []string, asOf time.Time, baselines map[string]historyBaseline) (map[string]historyState, error) {
+	last, next, err := historyRepository.GetHistoriesAround(ctx, objectType, objectIDs, asOf)
+	if err != nil {
+		return nil, err
+	}
+	states := make(map[string]historyState)
+	seen := make(map[string]struct{})
+	for _, record := range last {
+		seen[record.ObjectID] = struct{}{}
+		if record.ChangeType != model.ChangeTypeDelete && record.AfterData != nil {
+			states[record.ObjectID] = historyState{Data: record.AfterData, ChangeTime: record.ChangeTime}
+		}
+	}
+	for _, record := range next {
+		if _, ok := seen[record.ObjectID]; ok {
+			continue
+		}
+		seen[record.ObjectID] = struct{}{}
+		if record.ChangeType != model.ChangeTypeCreate && record.BeforeData != nil {
+			states[record.ObjectID] = historyState{Data: record.BeforeData}
+		}
+	}
+	for objectID, baseline := range baselines {
+		if _, ok := seen[objectID]; ok {
+			continue
+		}
+		if baseline.CreatedAt.After(asOf) || (baseline.DeletedAt.Valid && !baseline.DeletedAt.Time.After(asOf)) {
+			continue
+		}
+		states[objectID] = historyState{Data: baseline.Data}
+	}
+	return states, nil
+}
+
+// resourcesAt 还原资源在asOf时刻的状态，resourceIDs为空时还原全部资源，结果按ID倒序
+func resourcesAt(ctx context.Context, resourceRepository repository.ResourceRepository, historyRepository repository.HistoryRepository, resourceIDs []string, asOf time.Time) ([]model.Resource, error) {
+	current, err := resourceRepository.GetResourcesCreatedBefore(ctx, asOf, resourceIDs)
+	if err != nil {
+		return nil, err
+	}
+	baselines := make(map[string]historyBaseline, len(current))
+	byID := make(map[string]model.Resource, len(current))
+	for _, m := range current {
+		data, err := resourceSnapshot(m)
+		if err != nil {
+			return nil, err
+		}
+		baselines[m.ResourceID] = historyBaseline{Data: data, CreatedAt: m.CreatedAt, DeletedAt: m.DeletedAt}
+		byID[m.ResourceID] = m
+	}
+	states, err := historyStatesAt(ctx, historyRepository, model.ObjectTypeResource, resourceIDs, asOf, baselines)
+	if err != nil {
+		return nil, err
+	}
+	list := make([]model.Resource, 0, len(states))
+	for resourceID, state := range states {
+		m, err := resourceFromSnapshot(state.Data)
+		if err != nil {
+			return nil, err
+		}
+		if c, ok := byID[resourceID]; ok {
+			m.ID = c.ID
+			m.CreatedAt = c.CreatedAt
+		}
+		m.ResourceID = resourceID
+		m.UpdatedAt = state.ChangeTime
+		if m.UpdatedAt.IsZero() {
+			m.UpdatedAt = m.CreatedAt
+		}
+		list = append(list, m)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].ID != list[j].ID {
+			return list[i].ID > list[j].ID
+		}
+		return list[i].ResourceID > list[j].ResourceID
+	})
+	return list, nil
+}
+
+// universalRelationsAt 还原全部通用关系在asOf时刻的状态，按优先级倒序、ID正序
+func universalRelationsAt(ctx context.Context, relationRepository repository.RelationRepository, historyRepository repository.HistoryRepository, asOf time.Time) ([]model.UniversalRelation, error) {
+	current, err := relationRepository.GetUniversalRelationsCreatedBefore(ctx, asOf)
+	if err != nil {
+		return nil, err
+	}
+	baselines := make(map[string]historyBaseline, len(current))
+	byID := make(map[string]model.UniversalRelation, len(current))
+	for _, m := range current {
+		data, err := universalRelationSnapshot(m)
+		if err != nil {
+			return nil, err
+		}
+		baselines[m.RelationID] = historyBaseline{Data: data, CreatedAt: m.CreatedAt, DeletedAt: m.DeletedAt}
+		byID[m.RelationID] = m
+	}
+	states, err := historyStatesAt(ctx, historyRepository, model.HistoryObjectUniversalRelation, nil, asOf, baselines)
+	if err != nil {
+		return nil, err
+	}
+	list := make([]model.UniversalRelation, 0, len(states))
+	for relationID, state := range states {
+		m, err := universalRelationFromSnapshot(state.Data)
+		if err != nil {
+			return nil, err
+		}
+		if c, ok := byID[relationID]; ok {
+			m.ID = c.ID
+			m.SourceName = c.SourceName
+			m.TargetName = c.TargetName
+		}
+		m.RelationID = relationID
+		list = append(list, m)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Priority != list[j].Priority {
+			return list[i].Priority > list[j].Priority
+		}
+		return list[i].ID < list[j].ID
+	})
+	return list, nil
+}
+
+// diffSnapshots 比较前后快照，嵌套对象按"a.b"展开，返回 字段 -> {before, after}
+func diffSnapshots(before, after model.JSONMap) model.JSONMap {
+	flatBefore := make(map[string]interface{})
+	flatAfter := make(map[string]interface{})
+	flattenSnapshot("", before, flatBefore)
+	flattenSnapshot("", after, flatAfter)
+	changed := model.JSONMap{}
+	for field, value := range flatAfter {
+		old, ok := flatBefore[field]
+		if !ok || !reflect.DeepEqual(old, value) {
+			changed[field] = map[string]interface{}{"before": old, "after": value}
+		}
+	}
+	for field, old := range flatBefore {
+		if _, ok := flatAfter[field]; !ok {
+			changed[field] = map[string]interface{}{"before": old, "after": nil}
+		}
+	}
+	return changed
+}
+
+func flattenSnapshot(prefix string, data map[string]interface{}, out map[string]interface{}) {
+	for key, value := range data {
+		field := key
+		if prefix != "" {
+			field = prefix + "." + key
+		}
+		if nested, ok := value.(map[string]interface{}); ok {
+			flattenSnapshot(field, nested, out)
+			continue
+		}
+		out[field] = value
+	}
+}
+
+// toJSONMap 经JSON序列化把结构体转换为JSONMap，数值统一为float64，便于比较和存储
+func toJSONMap(v interface{}) (model.JSONMap, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	m := model.JSONMap{}
+	return m, json.Unmarshal(raw, &m)
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"nunu-layout-admin/internal/model"
+	"nunu-layout-admin/internal/repository"
+	"testing"
+)
+
+// fakeAuditHistoryRepository 返回固定的审计配置并记录写入的通知，未实现的方法调用时会panic
+type fakeAuditHistoryRepository struct {
+	repository.HistoryRepository
+	configs       []model.AuditConfig
+	notifications []model.NotificationDelivery
+}
+
+func (r *fakeAuditHistoryRepository) GetAuditConfigs(ctx context.Context) ([]model.AuditConfig, error) {
+	return r.configs, nil
+}
+
+func (r *fakeAuditHistoryRepository) GetLatestHistoryVersion(ctx context.Context, objectType, objectID string) (int64, error) {
+	return 3, nil
+}
+
+func (r *fakeAuditHistoryRepository) NotificationCreate(ctx context.Context, list []model.NotificationDelivery) error {
+	r.notifications = append(r.notifications, list...)
+	return nil
+}
+
+func TestNewHistoryEntryKeepsUnmonitoredChanges(t *testing.T) {
+	config := model.AuditConfig{
+		ResourceType:    "*",
+		EnableAudit:     true,
+		IsActive:        true,
+		MonitoredFields: model.JSONMap{"common": []interface{}{"name"}},
+		NotificationConfig: model.JSONMap{
+			"webhook": map[string]interface{}{"enabled": true, "url": "http://127.0.0.1/hook"},
+		},
+	}
+	before := model.JSONMap{"type": "server", "name": "web-01", "region": "cn-north-1"}
+
+	tests := []struct {
+		name          string
+		enableAudit   bool
+		after         model.JSONMap
+		changed       []string
+		notifyChanged []string
+	}{
+		{
+			name:        "unmonitored field",
+			enableAudit: true,
+			after:       model.JSONMap{"type": "server", "name": "web-01", "region": "cn-east-1"},
+			changed:     []string{"region"},
+		},
+		{
+			name:          "monitored field",
+			enableAudit:   true,
+			after:         model.JSONMap{"type": "server", "name": "web-02", "region": "cn-east-1"},
+			changed:       []string{"name", "region"},
+			notifyChanged: []string{"name"},
+		},
+		{
+			name:    "audit disabled",
+			after:   model.JSONMap{"type": "server", "name": "web-02", "region": "cn-north-1"},
+			changed: []string{"name"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := config
+			c.EnableAudit = tt.enableAudit
+			repo := &fakeAuditHistoryRepository{configs: []model.AuditConfig{c}}
+			entry, err := newHistoryEntry(context.Background(), repo, model.ObjectTypeResource, "res-001", model.ChangeTypeUpdate, before, tt.after)
+			if err != nil {
+				t.Fatalf("newHistoryEntry: %v", err)
+			}
+			if entry == nil {
+				t.Fatal("newHistoryEntry returned nil, want an entry with the full change")
+			}
+			if entry.Version != 4 || entry.AfterData["region"] != tt.after["region"] {
+				t.Errorf("entry version %d, after %v", entry.Version, entry.AfterData)
+			}
+			if len(entry.ChangedFields) != len(tt.changed) {
+				t.Errorf("changed fields = %v, want %v", entry.ChangedFields, tt.changed)
+			}
+			for _, field := range tt.changed {
+				if _, ok := entry.ChangedFields[field]; !ok {
+					t.Errorf("changed fields = %v, missing %s", entry.ChangedFields, field)
+				}
+			}
+
+			if len(tt.notifyChanged) == 0 {
+				if len(repo.notifications) != 0 {
+					t.Errorf("notifications = %d, want none", len(repo.notifications))
+				}
+				return
+			}
+			if len(repo.notifications) != 1 {
+				t.Fatalf("notifications = %d, want 1", len(repo.notifications))
+			}
+			notified, _ := repo.notifications[0].Payload["changed_fields"].(model.JSONMap)
+			if len(notified) != len(tt.notifyChanged) {
+				t.Errorf("notified changed fields = %v, want %v", notified, tt.notifyChanged)
+			}
+			for _, field := range tt.notifyChanged {
+				if _, ok := notified[field]; !ok {
+					t.Errorf("notified changed fields = %v, missing %s", notified, field)
+				}
+			}
+		})
+	}
+
+	// 没有差异时不写历史
+	repo := &fakeAuditHistoryRepository{configs: []model.AuditConfig{config}}
+	if entry, err := newHistoryEntry(context.Background(), repo, model.ObjectTypeResource, "res-001", model.ChangeTypeUpdate, before, before); err != nil || entry != nil {
+		t.Errorf("newHistoryEntry without changes = %v, %v, want nil", entry, err)
+	}
+}
@@ -0,0 +1,345 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	v1 "nunu-layout-admin/api/v1"
+	"nunu-layout-admin/internal/model"
+	"nunu-layout-admin/internal/repository"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 参与影响分析的关系类型，true表示故障沿source->target传播(A hosts B: A故障影响B)，
+// false表示沿target->source传播(A runs_on B: B故障影响A)
+var impactRelationTypes = map[string]bool{
+	model.UniversalRelationHosts:     true,
+	model.UniversalRelationServes:    true,
+	model.UniversalRelationRunsOn:    false,
+	model.UniversalRelationDependsOn: false,
+}
+
+// 受影响对象的返回顺序
+var impactObjectOrder = map[string]int{
+	model.ObjectTypeResource:      0,
+	model.ObjectTypeApplication:   1,
+	model.ObjectTypeConfiguration: 2,
+	model.ObjectTypeService:       3,
+	model.ObjectTypeBusiness:      4,
+}
+
+// 服务重要性级别排序，用于取一个服务在多个业务中的最高重要性
+var criticalityRank = map[string]int{
+	"low":      1,
+	"medium":   2,
+	"high":     3,
+	"critical": 4,
+}
+
+const (
+	ImpactDown     = "down"     // 强依赖，随之不可用
+	ImpactDegraded = "degraded" // 仅经由非必需依赖受到影响
+)
+
+type ImpactService interface {
+	GetImpact(ctx context.Context, req *v1.GetImpactRequest) (*v1.GetImpactResponseData, error)
+}
+
+func NewImpactService(
+	service *Service,
+	impactRepository repository.ImpactRepository,
+	relationRepository repository.RelationRepository,
+) ImpactService {
+	return &impactService{
+		Service:            service,
+		impactRepository:   impactRepository,
+		relationRepository: relationRepository,
+	}
+}
+
+type impactService struct {
+	*Service
+	impactRepository   repository.ImpactRepository
+	relationRepository repository.RelationRepository
+}
+
+// impactEdge 故障传播边，From故障会影响To；Relation/Reverse用于还原原始关系的方向
+type impactEdge struct {
+	From        string
+	To          string
+	Relation    string
+	Reverse     bool
+	Required    bool
+	Criticality string
+}
+
+type impactVisit struct {
+	parent string
+	edge   impactEdge
+	impact string
+}
+
+func (s *impactService) GetImpact(ctx context.Context, req *v1.GetImpactRequest) (*v1.GetImpactResponseData, error) {
+	if req.MaxDepth == 0 {
+		req.MaxDepth = 10
+	}
+	root, err := s.relationRepository.GetObject(ctx, model.ObjectTypeResource, req.ResourceID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, v1.ErrNotFound
+		}
+		return nil, err
+	}
+	objects := make(map[string]repository.RelationObject)
+	for _, objectType := range complianceObjectTypes {
+		list, err := s.relationRepository.GetObjects(ctx, objectType)
+		if err != nil {
+			return nil, err
+		}
+		for _, obj := range list {
+			objects[graphNodeID(obj.ObjectType, obj.ObjectID)] = obj
+		}
+	}
+	adjacency, err := s.buildImpactGraph(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rootID := graphNodeID(root.ObjectType, root.ObjectID)
+	visits := map[string]*impactVisit{rootID: {}}
+	// 先只沿必需依赖传播得到"不可用"的对象，再沿全部边传播得到"降级"的对象
+	s.walkImpact(rootID, adjacency, visits, req.MaxDepth, true)
+	s.walkImpact(rootID, adjacency, visits, req.MaxDepth, false)
+
+	businesses, err := s.impactRepository.GetBusinesses(ctx)
+	if err != nil {
+		return nil, err
+	}
+	priorities := make(map[string]int, len(businesses))
+	for _, b := range businesses {
+		priorities[b.BusinessID] = b.Priority
+	}
+
+	data := &v1.GetImpactResponseData{
+		ObjectType: root.ObjectType,
+		ObjectID:   root.ObjectID,
+		Name:       root.Name,
+		Summary:    make(map[string]int),
+		Affected:   make([]v1.ImpactItem, 0),
+	}
+	for id, visit := range visits {
+		if id == rootID {
+			continue
+		}
+		obj := lookupObject(objects, id)
+		item := v1.ImpactItem{
+			ObjectType: obj.ObjectType,
+			ObjectID:   obj.ObjectID,
+			Name:       obj.Name,
+			SubType:    obj.SubType,
+			Impact:     visit.impact,
+		}
+		switch obj.ObjectType {
+		case model.ObjectTypeService:
+			item.Criticality = highestCriticality(adjacency[id])
+		case model.ObjectTypeBusiness:
+			incoming := make([]impactEdge, 0)
+			for from := range visits {
+				for _, edge := range adjacency[from] {
+					if edge.To == id {
+						incoming = append(incoming, edge)
+					}
+				}
+			}
+			item.Criticality = highestCriticality(incoming)
+			item.Priority = priorities[obj.ObjectID]
+		}
+		item.Path, item.Explanation = impactPath(id, visits, objects)
+		item.Depth = len(item.Path) - 1
+		data.Summary[obj.ObjectType]++
+		data.Affected = append(data.Affected, item)
+	}
+	sort.Slice(data.Affected, func(i, j int) bool {
+		a, b := data.Affected[i], data.Affected[j]
+		if impactObjectOrder[a.ObjectType] != impactObjectOrder[b.ObjectType] {
+			return impactObjectOrder[a.ObjectType] < impactObjectOrder[b.ObjectType]
+		}
+		if a.Depth != b.Depth {
+			return a.Depth < b.Depth
+		}
+		return a.ObjectID < b.ObjectID
+	})
+	return data, nil
+}
+
+// buildImpactGraph 汇总通用关系、资源关系、应用部署/依赖、服务资源、业务服务、应用配置，
+// 生成按故障传播方向的邻接表
+func (s *impactService) buildImpactGraph(ctx context.Context) (map[string][]impactEdge, error) {
+	adjacency := make(map[string][]impactEdge)
+	add := func(edge impactEdge) {
+		adjacency[edge.From] = append(adjacency[edge.From], edge)
+	}
+	addRelation := func(source, target, relationType string) {
+		forward, ok := impactRelationTypes[relationType]
+		if !ok {
+			return
+		}
+		if forward {
+			add(impactEdge{From: source, To: target, Relation: relationType, Required: true})
+		} else {
+			add(impactEdge{From: target, To: source, Relation: relationType, Reverse: true, Required: true})
+		}
+	}
+
+	now := time.Now()
+	relations, err := s.relationRepository.GetActiveUniversalRelations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, relation := range relations {
+		if (relation.EffectiveTime != nil && relation.EffectiveTime.After(now)) ||
+			(relation.ExpireTime != nil && !relation.ExpireTime.After(now)) {
+			continue
+		}
+		addRelation(graphNodeID(relation.SourceType, relation.SourceID), graphNodeID(relation.TargetType, relation.TargetID), relation.RelationType)
+	}
+	resourceRelations, err := s.relationRepository.GetAllResourceRelations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, relation := range resourceRelations {
+		addRelation(graphNodeID(model.ObjectTypeResource, relation.Source.ResourceID),
+			graphNodeID(model.ObjectTypeResource, relation.Target.ResourceID), relation.RelationType)
+	}
+
+	deployments, err := s.impactRepository.GetApplicationDeployments(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, link := range deployments {
+		add(impactEdge{From: graphNodeID(model.ObjectTypeResource, link.SourceID), To: graphNodeID(model.ObjectTypeApplication, link.TargetID),
+			Relation: model.UniversalRelationRunsOn, Reverse: true, Required: true})
+	}
+	dependencies, err := s.impactRepository.GetApplicationDependencies(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, link := range dependencies {
+		add(impactEdge{From: graphNodeID(model.ObjectTypeApplication, link.TargetID), To: graphNodeID(model.ObjectTypeApplication, link.SourceID),
+			Relation: model.UniversalRelationDependsOn, Reverse: true, Required: link.IsRequired})
+	}
+	serviceResources, err := s.impactRepository.GetServiceResources(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, link := range serviceResources {
+		add(impactEdge{From: graphNodeID(model.ObjectTypeResource, link.TargetID), To: graphNodeID(model.ObjectTypeService, link.SourceID),
+			Relation: model.UniversalRelationUses, Reverse: true, Required: true})
+	}
+	businessServices, err := s.impactRepository.GetBusinessServices(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, link := range businessServices {
+		add(impactEdge{From: graphNodeID(model.ObjectTypeService, link.TargetID), To: graphNodeID(model.ObjectTypeBusiness, link.SourceID),
+			Relation: model.UniversalRelationServes, Required: true, Criticality: link.Criticality})
+	}
+	configurations, err := s.impactRepository.GetApplicationConfigurations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, link := range configurations {
+		add(impactEdge{From: graphNodeID(model.ObjectTypeApplication, link.TargetID), To: graphNodeID(model.ObjectTypeConfiguration, link.SourceID),
+			Relation: model.UniversalRelationAppliesTo, Reverse: true, Required: true})
+	}
+	return adjacency, nil
+}
+
+// walkImpact 广度优先遍历，requiredOnly时只走必需依赖；已访问的对象保留第一次(最短)的路径
+func (s *impactService) walkImpact(rootID string, adjacency map[string][]impactEdge, visits map[string]*impactVisit, maxDepth int, requiredOnly bool) {
+	impact := ImpactDegraded
+	if requiredOnly {
+		impact = ImpactDown
+	}
+	frontier := []string{rootID}
+	seen := map[string]struct{}{rootID: {}}
+	for depth := 1; depth <= maxDepth && len(frontier) > 0; depth++ {
+		next := make([]string, 0)
+		for _, from := range frontier {
+			for _, edge := range adjacency[from] {
+				if requiredOnly && !edge.Required {
+					continue
+				}
+				if _, ok := seen[edge.To]; ok {
+					continue
+				}
+				seen[edge.To] = struct{}{}
+				next = append(next, edge.To)
+				if _, ok := visits[edge.To]; !ok {
+					visits[edge.To] = &impactVisit{parent: from, edge: edge, impact: impact}
+				}
+			}
+		}
+		frontier = next
+	}
+}
+
+// impactPath 从根对象到id的传播路径及可读说明，反向边表示原始关系由下游指向上游
+func impactPath(id string, visits map[string]*impactVisit, objects map[string]repository.RelationObject) ([]v1.ImpactPathStep, string) {
+	chain := make([]string, 0)
+	for cur := id; ; {
+		chain = append([]string{cur}, chain...)
+		visit := visits[cur]
+		if visit.parent == "" {
+			break
+		}
+		cur = visit.parent
+	}
+	steps := make([]v1.ImpactPathStep, 0, len(chain))
+	var sb strings.Builder
+	for i, nodeID := range chain {
+		obj := lookupObject(objects, nodeID)
+		step := v1.ImpactPathStep{ObjectType: obj.ObjectType, ObjectID: obj.ObjectID, Name: obj.Name}
+		name := obj.Name
+		if name == "" {
+			name = obj.ObjectID
+		}
+		if i > 0 {
+			edge := visits[nodeID].edge
+			step.Relation = edge.Relation
+			step.Reverse = edge.Reverse
+			if edge.Reverse {
+				sb.WriteString(" <-[" + edge.Relation + "]- ")
+			} else {
+				sb.WriteString(" -[" + edge.Relation + "]-> ")
+			}
+		}
+		sb.WriteString(name)
+		steps = append(steps, step)
+	}
+	return steps, sb.String()
+}
+
+func lookupObject(objects map[string]repository.RelationObject, id string) repository.RelationObject {
+	if obj, ok := objects[id]; ok {
+		return obj
+	}
+	objectType, objectID, _ := strings.Cut(id, ":")
+	return repository.RelationObject{ObjectType: objectType, ObjectID: objectID}
+}
+
+// highestCriticality 取一组业务服务关联中的最高重要性级别
+func highestCriticality(edges []impactEdge) string {
+	highest := ""
+	for _, edge := range edges {
+		if edge.Criticality == "" {
+			continue
+		}
+		if highest == "" || criticalityRank[edge.Criticality] > criticalityRank[highest] {
+			highest = edge.Criticality
+		}
+	}
+	return highest
+}
@@ -0,0 +1,339 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	v1 "nunu-layout-admin/api/v1"
+	"nunu-layout-admin/internal/model"
+	"nunu-layout-admin/internal/repository"
+	"nunu-layout-admin/pkg/notify"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	notificationEvent     = "cmdb.change"
+	notificationBatchSize = 100
+	// notificationLease 发送中的通知在此时间内不会被其他进程重复发送
+	notificationLease = 5 * time.Minute
+)
+
+type NotificationService interface {
+	GetDeliveries(ctx context.Context, req *v1.GetNotificationDeliveriesRequest) (*v1.GetNotificationDeliveriesResponseData, error)
+	// RetryDelivery 把发送失败的通知重新放回待发送队列
+	RetryDelivery(ctx context.Context, req *v1.NotificationRetryRequest) error
+	// DispatchNotifications 发送到期的通知，失败的按指数退避重试，返回成功和失败的条数
+	DispatchNotifications(ctx context.Context) (int, int, error)
+}
+
+func NewNotificationService(
+	service *Service,
+	conf *viper.Viper,
+	notificationRepository repository.NotificationRepository,
+) NotificationService {
+	timeout := conf.GetDuration("cmdb.notification.timeout")
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	return &notificationService{
+		Service:                service,
+		conf:                   conf,
+		notificationRepository: notificationRepository,
+		client:                 &http.Client{Timeout: timeout},
+	}
+}
+
+type notificationService struct {
+	*Service
+	conf                   *viper.Viper
+	notificationRepository repository.NotificationRepository
+	client                 *http.Client
+}
+
+// notificationConfig AuditConfig.NotificationConfig的结构，environments为空时不按环境过滤
+type notificationConfig struct {
+	Environments []string `json:"environments"`
+	Email        struct {
+		Enabled    bool     `json:"enabled"`
+		Recipients []string `json:"recipients"`
+	} `json:"email"`
+	Webhook struct {
+		Enabled bool   `json:"enabled"`
+		URL     string `json:"url"`
+		Secret  string `json:"secret"`
+	} `json:"webhook"`
+}
+
+func parseNotificationConfig(data model.JSONMap) (notificationConfig, error) {
+	config := notificationConfig{}
+	if len(data) == 0 {
+		return config, nil
+	}
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return config, err
+	}
+	return config, json.Unmarshal(raw, &config)
+}
+
+// enqueueNotifications 按审计配置的通知设置为一次已记录的变更写入待发送通知，由定时任务异步发送
+func enqueueNotifications(ctx context.Context, historyRepository repository.HistoryRepository, config *model.AuditConfig, objectType, objectID string, data model.JSONMap, entry *historyEntry) error {
+	nc, err := parseNotificationConfig(config.NotificationConfig)
+	if err != nil {
+		return err
+	}
+	if len(nc.Environments) > 0 {
+		environment, _ := data["environment"].(string)
+		matched := false
+		for _, e := range nc.Environments {
+			if e == environment {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return nil
+		}
+	}
+	payload := model.JSONMap{
+		"event":          notificationEvent,
+		"object_type":    objectType,
+		"object_id":      objectID,
+		"name":           data["name"],
+		"tenant_id":      data["tenant_id"],
+		"environment":    data["environment"],
+		"change_type":    entry.ChangeType,
+		"change_source":  entry.ChangeSource,
+		"change_time":    entry.ChangeTime.Format(time.RFC3339),
+		"operator_id":    entry.OperatorID,
+		"operator_name":  entry.OperatorName,
+		"operator_ip":    entry.OperatorIP,
+		"version":        entry.Version,
+		"changed_fields": maskSensitive(entry.ChangedFields, sensitiveFields(config)),
+	}
+	newDelivery := func(channel, target string) model.NotificationDelivery {
+		return model.NotificationDelivery{
+			ObjectType:     objectType,
+			ObjectID:       objectID,
+			ChangeType:     entry.ChangeType,
+			ChangeTime:     entry.ChangeTime,
+			HistoryVersion: entry.Version,
+			AuditConfigID:  config.ID,
+			Channel:        channel,
+			Target:         target,
+			Payload:        payload,
+			Status:         model.NotificationStatusPending,
+		}
+	}
+	list := make([]model.NotificationDelivery, 0, 2)
+	if nc.Email.Enabled && len(nc.Email.Recipients) > 0 {
+		list = append(list, newDelivery(model.NotificationChannelEmail, strings.Join(nc.Email.Recipients, ",")))
+	}
+	if nc.Webhook.Enabled && nc.Webhook.URL != "" {
+		list = append(list, newDelivery(model.NotificationChannelWebhook, nc.Webhook.URL))
+	}
+	return historyRepository.NotificationCreate(ctx, list)
+}
+
+func (s *notificationService) GetDeliveries(ctx context.Context, req *v1.GetNotificationDeliveriesRequest) (*v1.GetNotificationDeliveriesResponseData, error) {
+	list, total, err := s.notificationRepository.GetDeliveries(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	data := &v1.GetNotificationDeliveriesResponseData{
+		List:  make([]v1.NotificationDeliveryDataItem, 0),
+		Total: total,
+	}
+	for _, m := range list {
+		data.List = append(data.List, toNotificationDeliveryDataItem(m))
+	}
+	return data, nil
+}
+
+func (s *notificationService) RetryDelivery(ctx context.Context, req *v1.NotificationRetryRequest) error {
+	m, err := s.notificationRepository.GetDelivery(ctx, req.ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return v1.ErrNotFound
+		}
+		return err
+	}
+	if m.Status != model.NotificationStatusFailed {
+		return v1.ErrNotificationNotRetryable
+	}
+	m.Status = model.NotificationStatusPending
+	m.Attempts = 0
+	m.NextRetryAt = nil
+	return s.notificationRepository.DeliveryUpdate(ctx, &m)
+}
+
+func (s *notificationService) DispatchNotifications(ctx context.Context) (int, int, error) {
+	list, err := s.notificationRepository.GetDueDeliveries(ctx, time.Now(), notificationBatchSize)
+	if err != nil {
+		return 0, 0, err
+	}
+	sent, failed := 0, 0
+	for _, m := range list {
+		ok, err := s.notificationRepository.DeliveryClaim(ctx, m.ID, m.Attempts, time.Now().Add(notificationLease))
+		if err != nil {
+			return sent, failed, err
+		}
+		if !ok {
+			continue
+		}
+		m.Attempts++
+		sendErr := s.send(ctx, m)
+		now := time.Now()
+		switch {
+		case sendErr == nil:
+			m.Status = model.NotificationStatusSent
+			m.SentAt = &now
+			m.NextRetryAt = nil
+			m.LastError = ""
+			sent++
+		case m.Attempts >= s.maxAttempts():
+			m.Status = model.NotificationStatusFailed
+			m.NextRetryAt = nil
+			m.LastError = sendErr.Error()
+			failed++
+		default:
+			next := now.Add(s.retryDelay(m.Attempts))
+			m.Status = model.NotificationStatusPending
+			m.NextRetryAt = &next
+			m.LastError = sendErr.Error()
+			failed++
+		}
+		if sendErr != nil {
+			s.logger.WithContext(ctx).Warn("send notification error", zap.Uint("id", m.ID), zap.String("channel", m.Channel),
+				zap.Int("attempts", m.Attempts), zap.Error(sendErr))
+		}
+		if err := s.notificationRepository.DeliveryUpdate(ctx, &m); err != nil {
+			return sent, failed, err
+		}
+	}
+	return sent, failed, nil
+}
+
+func (s *notificationService) send(ctx context.Context, m model.NotificationDelivery) error {
+	switch m.Channel {
+	case model.NotificationChannelEmail:
+		subject, body := renderNotificationMail(m.Payload)
+		return notify.SendMail(notify.SMTPConfig{
+			Host:     s.conf.GetString("cmdb.notification.smtp.host"),
+			Port:     s.conf.GetInt("cmdb.notification.smtp.port"),
+			Username: s.conf.GetString("cmdb.notification.smtp.username"),
+			Password: s.conf.GetString("cmdb.notification.smtp.password"),
+			From:     s.conf.GetString("cmdb.notification.smtp.from"),
+		}, strings.Split(m.Target, ","), subject, body)
+	case model.NotificationChannelWebhook:
+		body, err := json.Marshal(m.Payload)
+		if err != nil {
+			return err
+		}
+		return notify.PostWebhook(ctx, s.client, m.Target, s.webhookSecret(ctx, m.AuditConfigID), notificationEvent,
+			strconv.FormatUint(uint64(m.ID), 10), body)
+	}
+	return fmt.Errorf("unknown notification channel %q", m.Channel)
+}
+
+// webhookSecret 优先使用审计配置中的webhook.secret，其次使用全局配置
+func (s *notificationService) webhookSecret(ctx context.Context, auditConfigID uint) string {
+	if config, err := s.notificationRepository.GetAuditConfig(ctx, auditConfigID); err == nil {
+		if nc, err := parseNotificationConfig(config.NotificationConfig); err == nil && nc.Webhook.Secret != "" {
+			return nc.Webhook.Secret
+		}
+	}
+	return s.conf.GetString("cmdb.notification.webhook_secret")
+}
+
+func (s *notificationService) maxAttempts() int {
+	if n := s.conf.GetInt("cmdb.notification.max_attempts"); n > 0 {
+		return n
+	}
+	return 5
+}
+
+// retryDelay 第n次失败后的等待时间，从retry_interval开始翻倍，最长1小时
+func (s *notificationService) retryDelay(attempts int) time.Duration {
+	delay := s.conf.GetDuration("cmdb.notification.retry_interval")
+	if delay <= 0 {
+		delay = 30 * time.Second
+	}
+	for i := 1; i < attempts && delay < time.Hour; i++ {
+		delay *= 2
+	}
+	if delay > time.Hour {
+		delay = time.Hour
+	}
+	return delay
+}
+
+// renderNotificationMail 生成变更通知邮件的标题和正文
+func renderNotificationMail(payload model.JSONMap) (string, string) {
+	get := func(key string) string {
+		if v, ok := payload[key]; ok && v != nil {
+			return fmt.Sprint(v)
+		}
+		return ""
+	}
+	subject := fmt.Sprintf("[CMDB] %s %s %s", get("object_type"), get("object_id"), get("change_type"))
+	var body strings.Builder
+	fmt.Fprintf(&body, "对象: %s %s %s\n", get("object_type"), get("object_id"), get("name"))
+	fmt.Fprintf(&body, "变更: %s (来源 %s, 版本 %s)\n", get("change_type"), get("change_source"), get("version"))
+	fmt.Fprintf(&body, "时间: %s\n", get("change_time"))
+	fmt.Fprintf(&body, "操作人: %s %s %s\n", get("operator_name"), get("operator_id"), get("operator_ip"))
+	if tenant, env := get("tenant_id"), get("environment"); tenant != "" || env != "" {
+		fmt.Fprintf(&body, "租户/环境: %s / %s\n", tenant, env)
+	}
+	if changed, ok := payload["changed_fields"].(map[string]interface{}); ok && len(changed) > 0 {
+		fields := make([]string, 0, len(changed))
+		for field := range changed {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields)
+		body.WriteString("变更字段:\n")
+		for _, field := range fields {
+			before, after := interface{}(nil), interface{}(nil)
+			if pair, ok := changed[field].(map[string]interface{}); ok {
+				before, after = pair["before"], pair["after"]
+			}
+			fmt.Fprintf(&body, "  %s: %v -> %v\n", field, before, after)
+		}
+	}
+	return subject, body.String()
+}
+
+func toNotificationDeliveryDataItem(m model.NotificationDelivery) v1.NotificationDeliveryDataItem {
+	item := v1.NotificationDeliveryDataItem{
+		ID:             m.ID,
+		ObjectType:     m.ObjectType,
+		ObjectID:       m.ObjectID,
+		ChangeType:     m.ChangeType,
+		ChangeTime:     m.ChangeTime.Format("2006-01-02 15:04:05"),
+		HistoryVersion: m.HistoryVersion,
+		AuditConfigID:  m.AuditConfigID,
+		Channel:        m.Channel,
+		Target:         m.Target,
+		Payload:        m.Payload,
+		Status:         m.Status,
+		Attempts:       m.Attempts,
+		LastError:      m.LastError,
+		CreatedAt:      m.CreatedAt.Format("2006-01-02 15:04:05"),
+	}
+	if m.NextRetryAt != nil {
+		item.NextRetryAt = m.NextRetryAt.Format("2006-01-02 15:04:05")
+	}
+	if m.SentAt != nil {
+		item.SentAt = m.SentAt.Format("2006-01-02 15:04:05")
+	}
+	return item
+}
@@ -0,0 +1,292 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	v1 "nunu-layout-admin/api/v1"
+	"nunu-layout-admin/internal/model"
+	"nunu-layout-admin/pkg/log"
+	"nunu-layout-admin/pkg/notify"
+	"nunu-layout-admin/pkg/notify/notifytest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// fakeNotificationRepository 内存中的投递记录，行为与数据库实现一致
+type fakeNotificationRepository struct {
+	mu         sync.Mutex
+	deliveries map[uint]*model.NotificationDelivery
+	configs    map[uint]model.AuditConfig
+}
+
+func newFakeNotificationRepository(list ...model.NotificationDelivery) *fakeNotificationRepository {
+	r := &fakeNotificationRepository{deliveries: make(map[uint]*model.NotificationDelivery), configs: make(map[uint]model.AuditConfig)}
+	for i := range list {
+		m := list[i]
+		m.ID = uint(i + 1)
+		if m.Status == "" {
+			m.Status = model.NotificationStatusPending
+		}
+		r.deliveries[m.ID] = &m
+	}
+	return r
+}
+
+func (r *fakeNotificationRepository) get(id uint) model.NotificationDelivery {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return *r.deliveries[id]
+}
+
+// makeDue 把等待重试的通知提前到现在，模拟重试时间已到
+func (r *fakeNotificationRepository) makeDue(id uint) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	past := time.Now().Add(-time.Second)
+	r.deliveries[id].NextRetryAt = &past
+}
+
+func (r *fakeNotificationRepository) GetDeliveries(ctx context.Context, req *v1.GetNotificationDeliveriesRequest) ([]model.NotificationDelivery, int64, error) {
+	return nil, 0, errors.New("not implemented")
+}
+
+func (r *fakeNotificationRepository) GetDelivery(ctx context.Context, id uint) (model.NotificationDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if m, ok := r.deliveries[id]; ok {
+		return *m, nil
+	}
+	return model.NotificationDelivery{}, gorm.ErrRecordNotFound
+}
+
+func (r *fakeNotificationRepository) GetDueDeliveries(ctx context.Context, now time.Time, limit int) ([]model.NotificationDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var list []model.NotificationDelivery
+	for _, m := range r.deliveries {
+		if m.Status == model.NotificationStatusPending && (m.NextRetryAt == nil || !m.NextRetryAt.After(now)) {
+			list = append(list, *m)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	if len(list) > limit {
+		list = list[:limit]
+	}
+	return list, nil
+}
+
+func (r *fakeNotificationRepository) DeliveryClaim(ctx context.Context, id uint, attempts int, leaseUntil time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	m, ok := r.deliveries[id]
+	if !ok || m.Status != model.NotificationStatusPending || m.Attempts != attempts {
+		return false, nil
+	}
+	m.Attempts++
+	m.NextRetryAt = &leaseUntil
+	return true, nil
+}
+
+func (r *fakeNotificationRepository) DeliveryUpdate(ctx context.Context, m *model.NotificationDelivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.deliveries[m.ID]
+	if !ok {
+		return gorm.ErrRecordNotFound
+	}
+	stored.Status = m.Status
+	stored.Attempts = m.Attempts
+	stored.NextRetryAt = m.NextRetryAt
+	stored.LastError = m.LastError
+	stored.SentAt = m.SentAt
+	return nil
+}
+
+func (r *fakeNotificationRepository) GetAuditConfig(ctx context.Context, id uint) (model.AuditConfig, error) {
+	if m, ok := r.configs[id]; ok {
+		return m, nil
+	}
+	return model.AuditConfig{}, gorm.ErrRecordNotFound
+}
+
+func newTestNotificationService(conf *viper.Viper, repo *fakeNotificationRepository) NotificationService {
+	service := &Service{logger: &log.Logger{Logger: zap.NewNop()}}
+	return NewNotificationService(service, conf, repo)
+}
+
+func webhookDelivery(url string) model.NotificationDelivery {
+	return model.NotificationDelivery{
+		ObjectType:    model.ObjectTypeResource,
+		ObjectID:      "res-001",
+		ChangeType:    model.ChangeTypeUpdate,
+		ChangeTime:    time.Now(),
+		AuditConfigID: 7,
+		Channel:       model.NotificationChannelWebhook,
+		Target:        url,
+		Payload:       model.JSONMap{"event": notificationEvent, "object_id": "res-001"},
+	}
+}
+
+func TestDispatchNotificationsWebhookSigned(t *testing.T) {
+	type received struct {
+		header http.Header
+		body   []byte
+	}
+	requests := make(chan received, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests <- received{r.Header.Clone(), body}
+	}))
+	defer srv.Close()
+
+	conf := viper.New()
+	conf.Set("cmdb.notification.webhook_secret", "global")
+	repo := newFakeNotificationRepository(webhookDelivery(srv.URL))
+	repo.configs[7] = model.AuditConfig{NotificationConfig: model.JSONMap{
+		"webhook": map[string]interface{}{"enabled": true, "url": srv.URL, "secret": "per-config"},
+	}}
+
+	sent, failed, err := newTestNotificationService(conf, repo).DispatchNotifications(context.Background())
+	if err != nil || sent != 1 || failed != 0 {
+		t.Fatalf("DispatchNotifications = %d, %d, %v; want 1, 0, nil", sent, failed, err)
+	}
+	req := <-requests
+	want := notify.Sign("per-config", req.header.Get(notify.HeaderTimestamp), req.body)
+	if got := req.header.Get(notify.HeaderSignature); got != want {
+		t.Errorf("signature = %q, want %q (audit config secret)", got, want)
+	}
+	if got := req.header.Get(notify.HeaderDelivery); got != "1" {
+		t.Errorf("delivery header = %q, want 1", got)
+	}
+
+	m := repo.get(1)
+	if m.Status != model.NotificationStatusSent || m.Attempts != 1 || m.SentAt == nil || m.NextRetryAt != nil || m.LastError != "" {
+		t.Errorf("delivery = status %s, attempts %d, sent_at %v, next_retry_at %v, last_error %q",
+			m.Status, m.Attempts, m.SentAt, m.NextRetryAt, m.LastError)
+	}
+}
+
+func TestDispatchNotificationsRetryBackoff(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	conf := viper.New()
+	conf.Set("cmdb.notification.retry_interval", "1m")
+	conf.Set("cmdb.notification.max_attempts", 3)
+	repo := newFakeNotificationRepository(webhookDelivery(srv.URL))
+	svc := newTestNotificationService(conf, repo)
+
+	for attempt, delay := range []time.Duration{time.Minute, 2 * time.Minute} {
+		start := time.Now()
+		sent, failed, err := svc.DispatchNotifications(context.Background())
+		if err != nil || sent != 0 || failed != 1 {
+			t.Fatalf("attempt %d: DispatchNotifications = %d, %d, %v", attempt+1, sent, failed, err)
+		}
+		m := repo.get(1)
+		if m.Status != model.NotificationStatusPending || m.Attempts != attempt+1 {
+			t.Fatalf("attempt %d: status %s, attempts %d", attempt+1, m.Status, m.Attempts)
+		}
+		if !strings.Contains(m.LastError, "503") {
+			t.Errorf("attempt %d: last_error = %q", attempt+1, m.LastError)
+		}
+		if m.NextRetryAt == nil || m.NextRetryAt.Before(start.Add(delay)) || m.NextRetryAt.After(time.Now().Add(delay)) {
+			t.Errorf("attempt %d: next_retry_at = %v, want about now+%s", attempt+1, m.NextRetryAt, delay)
+		}
+		// 未到重试时间时不会再次发送
+		if sent, failed, _ := svc.DispatchNotifications(context.Background()); sent+failed != 0 {
+			t.Fatalf("attempt %d: delivery sent again before next_retry_at", attempt+1)
+		}
+		repo.makeDue(1)
+	}
+
+	if _, failed, err := svc.DispatchNotifications(context.Background()); err != nil || failed != 1 {
+		t.Fatalf("last attempt: failed %d, err %v", failed, err)
+	}
+	m := repo.get(1)
+	if m.Status != model.NotificationStatusFailed || m.Attempts != 3 || m.NextRetryAt != nil || m.SentAt != nil {
+		t.Errorf("delivery = status %s, attempts %d, next_retry_at %v, sent_at %v", m.Status, m.Attempts, m.NextRetryAt, m.SentAt)
+	}
+}
+
+func TestDispatchNotificationsTimeout(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	conf := viper.New()
+	conf.Set("cmdb.notification.timeout", "50ms")
+	repo := newFakeNotificationRepository(webhookDelivery(srv.URL))
+
+	sent, failed, err := newTestNotificationService(conf, repo).DispatchNotifications(context.Background())
+	if err != nil || sent != 0 || failed != 1 {
+		t.Fatalf("DispatchNotifications = %d, %d, %v; want 0, 1, nil", sent, failed, err)
+	}
+	m := repo.get(1)
+	if m.Status != model.NotificationStatusPending || m.Attempts != 1 || m.NextRetryAt == nil || m.LastError == "" {
+		t.Errorf("delivery = status %s, attempts %d, next_retry_at %v, last_error %q", m.Status, m.Attempts, m.NextRetryAt, m.LastError)
+	}
+}
+
+func TestDispatchNotificationsEmail(t *testing.T) {
+	srv := notifytest.NewSMTPServer(t, "blocked@example.com")
+	conf := viper.New()
+	conf.Set("cmdb.notification.smtp.host", srv.Host)
+	conf.Set("cmdb.notification.smtp.port", srv.Port)
+	conf.Set("cmdb.notification.smtp.from", "cmdb@example.com")
+	payload := model.JSONMap{
+		"object_type": model.ObjectTypeResource,
+		"object_id":   "res-001",
+		"change_type": model.ChangeTypeUpdate,
+		"changed_fields": map[string]interface{}{
+			"status": map[string]interface{}{"before": "active", "after": "inactive"},
+		},
+	}
+	repo := newFakeNotificationRepository(
+		model.NotificationDelivery{Channel: model.NotificationChannelEmail, Target: "ops@example.com,dev@example.com", Payload: payload},
+		model.NotificationDelivery{Channel: model.NotificationChannelEmail, Target: "blocked@example.com", Payload: payload},
+	)
+
+	sent, failed, err := newTestNotificationService(conf, repo).DispatchNotifications(context.Background())
+	if err != nil || sent != 1 || failed != 1 {
+		t.Fatalf("DispatchNotifications = %d, %d, %v; want 1, 1, nil", sent, failed, err)
+	}
+	messages := srv.Messages()
+	if len(messages) != 1 || strings.Join(messages[0].To, ",") != "ops@example.com,dev@example.com" {
+		t.Fatalf("messages = %+v", messages)
+	}
+	if !strings.Contains(messages[0].Data, "status: active -> inactive") {
+		t.Errorf("mail body does not list changed fields:\n%s", messages[0].Data)
+	}
+	if m := repo.get(1); m.Status != model.NotificationStatusSent || m.SentAt == nil {
+		t.Errorf("delivery 1 = status %s, sent_at %v", m.Status, m.SentAt)
+	}
+	if m := repo.get(2); m.Status != model.NotificationStatusPending || !strings.Contains(m.LastError, "550") {
+		t.Errorf("delivery 2 = status %s, last_error %q", m.Status, m.LastError)
+	}
+}
+
+func TestNotificationRetryDelay(t *testing.T) {
+	conf := viper.New()
+	conf.Set("cmdb.notification.retry_interval", "10m")
+	s := newTestNotificationService(conf, newFakeNotificationRepository()).(*notificationService)
+	for attempts, want := range map[int]time.Duration{1: 10 * time.Minute, 2: 20 * time.Minute, 3: 40 * time.Minute, 4: time.Hour, 10: time.Hour} {
+		if got := s.retryDelay(attempts); got != want {
+			t.Errorf("retryDelay(%d) = %s, want %s", attempts, got, want)
+		}
+	}
+}
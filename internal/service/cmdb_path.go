@@ -0,0 +1,487 @@
+package service
+
+import (
+	"container/heap"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	v1 "nunu-layout-admin/api/v1"
+	"nunu-layout-admin/internal/model"
+	"nunu-layout-admin/internal/repository"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// 路径缓存的有效期，过期记录由任务服务定期清理
+	pathCacheTTL = 30 * time.Minute
+	// all模式下最多返回的路径条数
+	pathMaxResults   = 100
+	pathDefaultDepth = 4
+)
+
+type PathService interface {
+	GetRelationPaths(ctx context.Context, req *v1.GetRelationPathsRequest) (*v1.GetRelationPathsResponseData, error)
+}
+
+func NewPathService(
+	service *Service,
+	pathRepository repository.PathRepository,
+	relationRepository repository.RelationRepository,
+	resourceRepository repository.ResourceRepository,
+) PathService {
+	return &pathService{
+		Service:            service,
+		pathRepository:     pathRepository,
+		relationRepository: relationRepository,
+		resourceRepository: resourceRepository,
+	}
+}
+
+type pathService struct {
+	*Service
+	pathRepository     repository.PathRepository
+	relationRepository repository.RelationRepository
+	resourceRepository repository.ResourceRepository
+}
+
+// relationPathsData 缓存表中保存的路径数据，Relations为路径上经过的全部关系，用于关系变更时判断是否失效
+type relationPathsData struct {
+	Paths     []v1.RelationPathItem `json:"paths"`
+	Relations []string              `json:"relations"`
+}
+
+// pathEdge 路径图中的一条可通行的边，To为图节点ID(type:id)
+type pathEdge struct {
+	Key          string
+	RelationType string
+	To           string
+	Weight       float64
+}
+
+// GetRelationPaths 计算两个对象之间的路径。shortest按关系权重求最短路径，all返回maxDepth跳以内的全部简单路径。
+// 两端均为资源的shortest/out查询缓存在RelationCache，其余缓存在RelationPath
+func (s *pathService) GetRelationPaths(ctx context.Context, req *v1.GetRelationPathsRequest) (*v1.GetRelationPathsResponseData, error) {
+	if req.Mode == "" {
+		req.Mode = "shortest"
+	}
+	if req.Direction == "" {
+		req.Direction = "out"
+	}
+	if req.MaxDepth == 0 {
+		req.MaxDepth = pathDefaultDepth
+	}
+	if req.Mode == "shortest" {
+		// 最短路径不受跳数限制，统一后保证缓存键一致
+		req.MaxDepth = 0
+	}
+	if req.StartType == req.EndType && req.StartID == req.EndID {
+		return nil, v1.ErrBadRequest
+	}
+	for _, obj := range [][2]string{{req.StartType, req.StartID}, {req.EndType, req.EndID}} {
+		if _, err := s.relationRepository.GetObject(ctx, obj[0], obj[1]); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, v1.ErrNotFound
+			}
+			return nil, err
+		}
+	}
+
+	now := time.Now()
+	resourceCache := req.StartType == model.ObjectTypeResource && req.EndType == model.ObjectTypeResource &&
+		req.Mode == "shortest" && req.Direction == "out"
+	var sourceResource, targetResource model.Resource
+	pathID := relationPathID(req)
+	if resourceCache {
+		var err error
+		if sourceResource, err = s.resourceRepository.GetResource(ctx, req.StartID); err != nil {
+			return nil, err
+		}
+		if targetResource, err = s.resourceRepository.GetResource(ctx, req.EndID); err != nil {
+			return nil, err
+		}
+	}
+
+	if !req.Refresh {
+		data, err := s.getCachedPaths(ctx, req, resourceCache, sourceResource.ID, targetResource.ID, pathID, now)
+		if err != nil {
+			return nil, err
+		}
+		if data != nil {
+			return data, nil
+		}
+	}
+
+	graph, err := s.loadPathGraph(ctx, req.Direction, now)
+	if err != nil {
+		return nil, err
+	}
+	start := graphNodeID(req.StartType, req.StartID)
+	end := graphNodeID(req.EndType, req.EndID)
+	var paths []v1.RelationPathItem
+	if req.Mode == "shortest" {
+		paths = shortestRelationPath(graph, start, end)
+	} else {
+		paths = allRelationPaths(graph, start, end, req.MaxDepth)
+	}
+	stored := relationPathsData{Paths: paths, Relations: make([]string, 0)}
+	seen := make(map[string]struct{})
+	for _, path := range paths {
+		for _, step := range path.Steps {
+			if _, ok := seen[step.Relation]; !ok {
+				seen[step.Relation] = struct{}{}
+				stored.Relations = append(stored.Relations, step.Relation)
+			}
+		}
+	}
+	var best v1.RelationPathItem
+	if len(paths) > 0 {
+		best = paths[0]
+	}
+	expiresAt := now.Add(pathCacheTTL)
+
+	err = s.tm.Transaction(ctx, func(ctx context.Context) error {
+		if resourceCache {
+			raw, err := json.Marshal(stored)
+			if err != nil {
+				return err
+			}
+			return s.pathRepository.RelationCacheSave(ctx, &model.RelationCache{
+				SourceID:      sourceResource.ID,
+				TargetID:      targetResource.ID,
+				Path:          string(raw),
+				PathLength:    best.Length,
+				PathWeight:    best.Weight,
+				RelationChain: best.RelationChain,
+				CacheTime:     now,
+				ExpiresAt:     expiresAt,
+				LastAccess:    now,
+			})
+		}
+		pathData, err := encodeRelationPathsData(stored)
+		if err != nil {
+			return err
+		}
+		pathData["direction"] = req.Direction
+		pathData["maxDepth"] = req.MaxDepth
+		return s.pathRepository.RelationPathSave(ctx, &model.RelationPath{
+			PathID:        pathID,
+			StartType:     req.StartType,
+			StartID:       req.StartID,
+			EndType:       req.EndType,
+			EndID:         req.EndID,
+			PathType:      req.Mode,
+			PathLength:    best.Length,
+			PathWeight:    best.Weight,
+			PathData:      pathData,
+			RelationChain: best.RelationChain,
+			CacheTime:     now,
+			ExpiresAt:     expiresAt,
+			LastAccess:    now,
+			IsActive:      true,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &v1.GetRelationPathsResponseData{
+		Mode:      req.Mode,
+		Paths:     paths,
+		CacheTime: now.Format("2006-01-02 15:04:05"),
+		ExpiresAt: expiresAt.Format("2006-01-02 15:04:05"),
+	}, nil
+}
+
+// getCachedPaths 读取未过期的缓存并累加访问次数，未命中时返回nil
+func (s *pathService) getCachedPaths(ctx context.Context, req *v1.GetRelationPathsRequest, resourceCache bool, sourceID, targetID uint, pathID string, now time.Time) (*v1.GetRelationPathsResponseData, error) {
+	var stored relationPathsData
+	var cacheTime, expiresAt time.Time
+	var accessCount int
+	if resourceCache {
+		cache, err := s.pathRepository.GetRelationCache(ctx, sourceID, targetID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, nil
+			}
+			return nil, err
+		}
+		if !cache.ExpiresAt.After(now) {
+			return nil, nil
+		}
+		if err := json.Unmarshal([]byte(cache.Path), &stored); err != nil {
+			return nil, nil
+		}
+		if err := s.pathRepository.RelationCacheTouch(ctx, cache.ID, now); err != nil {
+			return nil, err
+		}
+		cacheTime, expiresAt, accessCount = cache.CacheTime, cache.ExpiresAt, cache.AccessCount+1
+	} else {
+		path, err := s.pathRepository.GetRelationPath(ctx, pathID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, nil
+			}
+			return nil, err
+		}
+		if !path.IsActive || !path.ExpiresAt.After(now) {
+			return nil, nil
+		}
+		if stored, err = decodeRelationPathsData(path.PathData); err != nil {
+			return nil, nil
+		}
+		if err := s.pathRepository.RelationPathTouch(ctx, path.ID, now); err != nil {
+			return nil, err
+		}
+		cacheTime, expiresAt, accessCount = path.CacheTime, path.ExpiresAt, path.AccessCount+1
+	}
+	if stored.Paths == nil {
+		stored.Paths = make([]v1.RelationPathItem, 0)
+	}
+	return &v1.GetRelationPathsResponseData{
+		Mode:        req.Mode,
+		Paths:       stored.Paths,
+		Cached:      true,
+		CacheTime:   cacheTime.Format("2006-01-02 15:04:05"),
+		ExpiresAt:   expiresAt.Format("2006-01-02 15:04:05"),
+		AccessCount: accessCount,
+	}, nil
+}
+
+// loadPathGraph 加载当前生效的通用关系和资源关系，构造邻接表。
+// out方向只沿关系方向通行(双向关系除外)，both方向忽略关系方向
+func (s *pathService) loadPathGraph(ctx context.Context, direction string, now time.Time) (map[string][]pathEdge, error) {
+	graph := make(map[string][]pathEdge)
+	addEdge := func(key, relationType, relationDirection, source, target string, weight float64) {
+		if weight < 0 {
+			weight = 0
+		}
+		graph[source] = append(graph[source], pathEdge{Key: key, RelationType: relationType, To: target, Weight: weight})
+		if direction == "both" || relationDirection == "bidirectional" {
+			graph[target] = append(graph[target], pathEdge{Key: key, RelationType: relationType, To: source, Weight: weight})
+		}
+	}
+	relations, err := s.relationRepository.GetActiveUniversalRelations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, relation := range relations {
+		if (relation.EffectiveTime != nil && relation.EffectiveTime.After(now)) ||
+			(relation.ExpireTime != nil && !relation.ExpireTime.After(now)) {
+			continue
+		}
+		addEdge(universalRelationKey(relation.RelationID), relation.RelationType, relation.Direction,
+			graphNodeID(relation.SourceType, relation.SourceID), graphNodeID(relation.TargetType, relation.TargetID), relation.Weight)
+	}
+	resourceRelations, err := s.relationRepository.GetAllResourceRelations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, relation := range resourceRelations {
+		if relation.Source.ID == 0 || relation.Target.ID == 0 {
+			continue
+		}
+		addEdge(resourceRelationKey(relation.ID), relation.RelationType, relation.Direction,
+			graphNodeID(model.ObjectTypeResource, relation.Source.ResourceID), graphNodeID(model.ObjectTypeResource, relation.Target.ResourceID), float64(relation.Weight))
+	}
+	return graph, nil
+}
+
+type pathQueueItem struct {
+	node   string
+	weight float64
+	hops   int
+}
+
+type pathQueue []pathQueueItem
+
+func (q pathQueue) Len() int { return len(q) }
+func (q pathQueue) Less(i, j int) bool {
+	if q[i].weight != q[j].weight {
+		return q[i].weight < q[j].weight
+	}
+	return q[i].hops < q[j].hops
+}
+func (q pathQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *pathQueue) Push(x interface{}) { *q = append(*q, x.(pathQueueItem)) }
+func (q *pathQueue) Pop() interface{} {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
+
+// shortestRelationPath 按权重求start到end的最短路径(Dijkstra)，权重相同时取跳数少的
+func shortestRelationPath(graph map[string][]pathEdge, start, end string) []v1.RelationPathItem {
+	type visit struct {
+		weight float64
+		hops   int
+		from   string
+		step   v1.RelationPathStep
+	}
+	best := map[string]visit{start: {}}
+	done := make(map[string]bool)
+	queue := &pathQueue{{node: start}}
+	for queue.Len() > 0 {
+		item := heap.Pop(queue).(pathQueueItem)
+		if done[item.node] {
+			continue
+		}
+		done[item.node] = true
+		if item.node == end {
+			break
+		}
+		for _, edge := range graph[item.node] {
+			if done[edge.To] {
+				continue
+			}
+			weight, hops := item.weight+edge.Weight, item.hops+1
+			if current, ok := best[edge.To]; ok && (current.weight < weight || (current.weight == weight && current.hops <= hops)) {
+				continue
+			}
+			best[edge.To] = visit{
+				weight: weight,
+				hops:   hops,
+				from:   item.node,
+				step: v1.RelationPathStep{
+					Relation:     edge.Key,
+					RelationType: edge.RelationType,
+					From:         item.node,
+					To:           edge.To,
+					Weight:       edge.Weight,
+				},
+			}
+			heap.Push(queue, pathQueueItem{node: edge.To, weight: weight, hops: hops})
+		}
+	}
+	if !done[end] {
+		return make([]v1.RelationPathItem, 0)
+	}
+	steps := make([]v1.RelationPathStep, 0)
+	for node := end; node != start; node = best[node].from {
+		steps = append([]v1.RelationPathStep{best[node].step}, steps...)
+	}
+	return []v1.RelationPathItem{newRelationPathItem(start, steps)}
+}
+
+// allRelationPaths 深度优先枚举start到end不超过maxDepth跳的全部简单路径，按权重、跳数排序
+func allRelationPaths(graph map[string][]pathEdge, start, end string, maxDepth int) []v1.RelationPathItem {
+	paths := make([]v1.RelationPathItem, 0)
+	visited := map[string]bool{start: true}
+	steps := make([]v1.RelationPathStep, 0, maxDepth)
+	var walk func(node string)
+	walk = func(node string) {
+		if len(paths) >= pathMaxResults {
+			return
+		}
+		if node == end {
+			paths = append(paths, newRelationPathItem(start, append([]v1.RelationPathStep(nil), steps...)))
+			return
+		}
+		if len(steps) >= maxDepth {
+			return
+		}
+		for _, edge := range graph[node] {
+			if visited[edge.To] {
+				continue
+			}
+			visited[edge.To] = true
+			steps = append(steps, v1.RelationPathStep{
+				Relation:     edge.Key,
+				RelationType: edge.RelationType,
+				From:         node,
+				To:           edge.To,
+				Weight:       edge.Weight,
+			})
+			walk(edge.To)
+			steps = steps[:len(steps)-1]
+			visited[edge.To] = false
+		}
+	}
+	walk(start)
+	sort.SliceStable(paths, func(i, j int) bool {
+		if paths[i].Weight != paths[j].Weight {
+			return paths[i].Weight < paths[j].Weight
+		}
+		return paths[i].Length < paths[j].Length
+	})
+	return paths
+}
+
+func newRelationPathItem(start string, steps []v1.RelationPathStep) v1.RelationPathItem {
+	item := v1.RelationPathItem{
+		Nodes:  []string{start},
+		Steps:  steps,
+		Length: len(steps),
+	}
+	chain := make([]string, 0, len(steps))
+	for _, step := range steps {
+		item.Nodes = append(item.Nodes, step.To)
+		item.Weight += step.Weight
+		chain = append(chain, step.RelationType)
+	}
+	item.RelationChain = strings.Join(chain, "->")
+	return item
+}
+
+// relationPathID 由查询条件生成RelationPath的唯一标识
+func relationPathID(req *v1.GetRelationPathsRequest) string {
+	sum := sha1.Sum([]byte(fmt.Sprintf("%s|%s|%d|%s|%s|%s|%s",
+		req.Mode, req.Direction, req.MaxDepth, req.StartType, req.StartID, req.EndType, req.EndID)))
+	return hex.EncodeToString(sum[:])
+}
+
+func encodeRelationPathsData(data relationPathsData) (model.JSONMap, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	m := model.JSONMap{}
+	return m, json.Unmarshal(raw, &m)
+}
+
+func decodeRelationPathsData(m model.JSONMap) (relationPathsData, error) {
+	var data relationPathsData
+	raw, err := json.Marshal(m)
+	if err != nil {
+		return data, err
+	}
+	return data, json.Unmarshal(raw, &data)
+}
+
+// invalidateRelationPaths 删除经过relationKey的路径缓存，relationKey为空时清空全部路径缓存
+func invalidateRelationPaths(ctx context.Context, pathRepository repository.PathRepository, relationKey string) error {
+	if relationKey == "" {
+		return pathRepository.PathsDeleteAll(ctx)
+	}
+	paths, err := pathRepository.GetRelationPaths(ctx)
+	if err != nil {
+		return err
+	}
+	pathIDs := make([]uint, 0)
+	for _, path := range paths {
+		data, err := decodeRelationPathsData(path.PathData)
+		if err != nil || containsString(data.Relations, relationKey) {
+			pathIDs = append(pathIDs, path.ID)
+		}
+	}
+	if err := pathRepository.RelationPathsDelete(ctx, pathIDs); err != nil {
+		return err
+	}
+	caches, err := pathRepository.GetRelationCaches(ctx)
+	if err != nil {
+		return err
+	}
+	cacheIDs := make([]uint, 0)
+	for _, cache := range caches {
+		var data relationPathsData
+		if err := json.Unmarshal([]byte(cache.Path), &data); err != nil || containsString(data.Relations, relationKey) {
+			cacheIDs = append(cacheIDs, cache.ID)
+		}
+	}
+	return pathRepository.RelationCachesDelete(ctx, cacheIDs)
+}
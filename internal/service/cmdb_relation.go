@@ -0,0 +1,563 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	v1 "nunu-layout-admin/api/v1"
+	"nunu-layout-admin/internal/model"
+	"nunu-layout-admin/internal/repository"
+	"nunu-layout-admin/pkg/schema"
+)
+
+type RelationService interface {
+	GetUniversalRelations(ctx context.Context, req *v1.GetUniversalRelationsRequest) (*v1.GetUniversalRelationsResponseData, error)
+	GetUniversalRelation(ctx context.Context, relationID string) (*v1.UniversalRelationDataItem, error)
+	UniversalRelationCreate(ctx context.Context, req *v1.UniversalRelationCreateRequest) error
+	UniversalRelationUpdate(ctx context.Context, req *v1.UniversalRelationUpdateRequest) error
+	UniversalRelationDelete(ctx context.Context, relationID string) error
+
+	GetResourceRelations(ctx context.Context, resourceID string) (*v1.GetResourceRelationsResponseData, error)
+	ResourceRelationCreate(ctx context.Context, req *v1.ResourceRelationCreateRequest) error
+	ResourceRelationUpdate(ctx context.Context, req *v1.ResourceRelationUpdateRequest) error
+	ResourceRelationDelete(ctx context.Context, id uint) error
+}
+
+func NewRelationService(
+	service *Service,
+	relationRepository repository.RelationRepository,
+	relationRuleRepository repository.RelationRuleRepository,
+	resourceRepository repository.ResourceRepository,
+	resourceTypeRepository repository.ResourceTypeRepository,
+	pathRepository repository.PathRepository,
+	historyRepository repository.HistoryRepository,
+) RelationService {
+	return &relationService{
+		Service:                service,
+		relationRepository:     relationRepository,
+		relationRuleRepository: relationRuleRepository,
+		resourceRepository:     resourceRepository,
+		resourceTypeRepository: resourceTypeRepository,
+		pathRepository:         pathRepository,
+		historyRepository:      historyRepository,
+	}
+}
+
+type relationService struct {
+	*Service
+	relationRepository     repository.RelationRepository
+	relationRuleRepository repository.RelationRuleRepository
+	resourceRepository     repository.ResourceRepository
+	resourceTypeRepository repository.ResourceTypeRepository
+	pathRepository         repository.PathRepository
+	historyRepository      repository.HistoryRepository
+}
+
+// relationEdge 某个对象的一条出边，Key用于在更新时排除关系自身
+type relationEdge struct {
+	Key          string
+	RelationType string
+	Target       repository.RelationObject
+}
+
+func universalRelationKey(relationID string) string {
+	return "universal:" + relationID
+}
+
+func resourceRelationKey(id uint) string {
+	return fmt.Sprintf("resource:%d", id)
+}
+
+func (s *relationService) GetUniversalRelations(ctx context.Context, req *v1.GetUniversalRelationsRequest) (*v1.GetUniversalRelationsResponseData, error) {
+	list, total, err := s.relationRepository.GetUniversalRelations(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	data := &v1.GetUniversalRelationsResponseData{
+		List:  make([]v1.UniversalRelationDataItem, 0),
+		Total: total,
+	}
+	for _, relation := range list {
+		data.List = append(data.List, toUniversalRelationDataItem(relation))
+	}
+	return data, nil
+}
+
+func (s *relationService) GetUniversalRelation(ctx context.Context, relationID string) (*v1.UniversalRelationDataItem, error) {
+	relation, err := s.relationRepository.GetUniversalRelation(ctx, relationID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, v1.ErrNotFound
+		}
+		return nil, err
+	}
+	item := toUniversalRelationDataItem(relation)
+	return &item, nil
+}
+
+func (s *relationService) UniversalRelationCreate(ctx context.Context, req *v1.UniversalRelationCreateRequest) error {
+	if req.RelationID == "" {
+		id, err := s.sid.GenString()
+		if err != nil {
+			return err
+		}
+		req.RelationID = id
+	}
+	_, err := s.relationRepository.GetUniversalRelation(ctx, req.RelationID)
+	if err == nil {
+		return v1.ErrRelationAlreadyExists
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	source, err := s.getObject(ctx, req.SourceType, req.SourceID)
+	if err != nil {
+		return err
+	}
+	target, err := s.getObject(ctx, req.TargetType, req.TargetID)
+	if err != nil {
+		return err
+	}
+	if req.Direction == "" {
+		req.Direction = "forward"
+	}
+	if req.Weight == 0 {
+		req.Weight = 1
+	}
+	if req.Priority == 0 {
+		req.Priority = 1
+	}
+	return s.tm.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.checkRelation(ctx, source, target, req.RelationType, req.Properties, ""); err != nil {
+			return err
+		}
+		relation := &model.UniversalRelation{
+			RelationID:   req.RelationID,
+			SourceType:   source.ObjectType,
+			SourceID:     source.ObjectID,
+			SourceName:   source.Name,
+			TargetType:   target.ObjectType,
+			TargetID:     target.ObjectID,
+			TargetName:   target.Name,
+			RelationType: req.RelationType,
+			Direction:    req.Direction,
+			Weight:       req.Weight,
+			Priority:     req.Priority,
+			Properties:   req.Properties,
+			Environment:  req.Environment,
+			TenantID:     req.TenantID,
+			Status:       "active",
+			IsActive:     true,
+			Description:  req.Description,
+		}
+		if err := s.relationRepository.UniversalRelationCreate(ctx, relation); err != nil {
+			return err
+		}
+		if err := recordUniversalRelationHistory(ctx, s.historyRepository, model.ChangeTypeCreate, nil, relation); err != nil {
+			return err
+		}
+		// 新增关系可能产生更短或新的路径，清空全部路径缓存
+		return invalidateRelationPaths(ctx, s.pathRepository, "")
+	})
+}
+
+func (s *relationService) UniversalRelationUpdate(ctx context.Context, req *v1.UniversalRelationUpdateRequest) error {
+	old, err := s.relationRepository.GetUniversalRelation(ctx, req.RelationID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return v1.ErrNotFound
+		}
+		return err
+	}
+	source, err := s.getObject(ctx, old.SourceType, old.SourceID)
+	if err != nil {
+		return err
+	}
+	target, err := s.getObject(ctx, old.TargetType, old.TargetID)
+	if err != nil {
+		return err
+	}
+	if req.Weight == 0 {
+		req.Weight = 1
+	}
+	if req.Priority == 0 {
+		req.Priority = 1
+	}
+	// 权重降低或方向变化可能让不经过该关系的缓存路径不再最优，此时清空全部缓存
+	invalidateKey := universalRelationKey(old.RelationID)
+	if req.Weight < old.Weight || req.Direction != old.Direction {
+		invalidateKey = ""
+	}
+	return s.tm.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.checkRelation(ctx, source, target, req.RelationType, req.Properties, universalRelationKey(old.RelationID)); err != nil {
+			return err
+		}
+		err := s.relationRepository.UniversalRelationUpdate(ctx, &model.UniversalRelation{
+			Model:        gorm.Model{ID: old.ID},
+			RelationType: req.RelationType,
+			Direction:    req.Direction,
+			Weight:       req.Weight,
+			Priority:     req.Priority,
+			Properties:   req.Properties,
+			Status:       req.Status,
+			Description:  req.Description,
+		})
+		if err != nil {
+			return err
+		}
+		relation, err := s.relationRepository.GetUniversalRelation(ctx, old.RelationID)
+		if err != nil {
+			return err
+		}
+		if err := recordUniversalRelationHistory(ctx, s.historyRepository, model.ChangeTypeUpdate, &old, &relation); err != nil {
+			return err
+		}
+		return invalidateRelationPaths(ctx, s.pathRepository, invalidateKey)
+	})
+}
+
+func (s *relationService) UniversalRelationDelete(ctx context.Context, relationID string) error {
+	old, err := s.relationRepository.GetUniversalRelation(ctx, relationID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return v1.ErrNotFound
+		}
+		return err
+	}
+	return s.tm.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.relationRepository.UniversalRelationDelete(ctx, old.ID); err != nil {
+			return err
+		}
+		if err := recordUniversalRelationHistory(ctx, s.historyRepository, model.ChangeTypeDelete, &old, nil); err != nil {
+			return err
+		}
+		return invalidateRelationPaths(ctx, s.pathRepository, universalRelationKey(old.RelationID))
+	})
+}
+
+func (s *relationService) GetResourceRelations(ctx context.Context, resourceID string) (*v1.GetResourceRelationsResponseData, error) {
+	resource, err := s.resourceRepository.GetResource(ctx, resourceID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, v1.ErrNotFound
+		}
+		return nil, err
+	}
+	list, err := s.relationRepository.GetResourceRelations(ctx, resource.ID)
+	if err != nil {
+		return nil, err
+	}
+	data := &v1.GetResourceRelationsResponseData{
+		List: make([]v1.ResourceRelationDataItem, 0, len(list)),
+	}
+	for _, relation := range list {
+		data.List = append(data.List, toResourceRelationDataItem(relation))
+	}
+	return data, nil
+}
+
+func (s *relationService) ResourceRelationCreate(ctx context.Context, req *v1.ResourceRelationCreateRequest) error {
+	source, err := s.getObject(ctx, model.ObjectTypeResource, req.SourceResourceID)
+	if err != nil {
+		return err
+	}
+	target, err := s.getObject(ctx, model.ObjectTypeResource, req.TargetResourceID)
+	if err != nil {
+		return err
+	}
+	sourceResource, err := s.resourceRepository.GetResource(ctx, req.SourceResourceID)
+	if err != nil {
+		return err
+	}
+	targetResource, err := s.resourceRepository.GetResource(ctx, req.TargetResourceID)
+	if err != nil {
+		return err
+	}
+	if req.Direction == "" {
+		req.Direction = "forward"
+	}
+	if req.Weight == 0 {
+		req.Weight = 1
+	}
+	return s.tm.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.checkRelation(ctx, source, target, req.RelationType, req.Properties, ""); err != nil {
+			return err
+		}
+		relation := &model.ResourceRelation{
+			SourceID:     sourceResource.ID,
+			TargetID:     targetResource.ID,
+			RelationType: req.RelationType,
+			Direction:    req.Direction,
+			Weight:       req.Weight,
+			Properties:   req.Properties,
+			Description:  req.Description,
+		}
+		if err := s.relationRepository.ResourceRelationCreate(ctx, relation); err != nil {
+			return err
+		}
+		relation.Source = sourceResource
+		relation.Target = targetResource
+		if err := recordResourceRelationHistory(ctx, s.historyRepository, model.ChangeTypeCreate, nil, relation); err != nil {
+			return err
+		}
+		return invalidateRelationPaths(ctx, s.pathRepository, "")
+	})
+}
+
+func (s *relationService) ResourceRelationUpdate(ctx context.Context, req *v1.ResourceRelationUpdateRequest) error {
+	old, err := s.relationRepository.GetResourceRelation(ctx, req.ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return v1.ErrNotFound
+		}
+		return err
+	}
+	source := resourceRelationObject(old.Source)
+	target := resourceRelationObject(old.Target)
+	if req.Weight == 0 {
+		req.Weight = 1
+	}
+	invalidateKey := resourceRelationKey(old.ID)
+	if req.Weight < old.Weight || req.Direction != old.Direction {
+		invalidateKey = ""
+	}
+	return s.tm.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.checkRelation(ctx, source, target, req.RelationType, req.Properties, resourceRelationKey(old.ID)); err != nil {
+			return err
+		}
+		err := s.relationRepository.ResourceRelationUpdate(ctx, &model.ResourceRelation{
+			Model:        gorm.Model{ID: old.ID},
+			RelationType: req.RelationType,
+			Direction:    req.Direction,
+			Weight:       req.Weight,
+			Properties:   req.Properties,
+			Description:  req.Description,
+		})
+		if err != nil {
+			return err
+		}
+		relation, err := s.relationRepository.GetResourceRelation(ctx, old.ID)
+		if err != nil {
+			return err
+		}
+		if err := recordResourceRelationHistory(ctx, s.historyRepository, model.ChangeTypeUpdate, &old, &relation); err != nil {
+			return err
+		}
+		return invalidateRelationPaths(ctx, s.pathRepository, invalidateKey)
+	})
+}
+
+func (s *relationService) ResourceRelationDelete(ctx context.Context, id uint) error {
+	old, err := s.relationRepository.GetResourceRelation(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return v1.ErrNotFound
+		}
+		return err
+	}
+	return s.tm.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.relationRepository.ResourceRelationDelete(ctx, old.ID); err != nil {
+			return err
+		}
+		if err := recordResourceRelationHistory(ctx, s.historyRepository, model.ChangeTypeDelete, &old, nil); err != nil {
+			return err
+		}
+		return invalidateRelationPaths(ctx, s.pathRepository, resourceRelationKey(old.ID))
+	})
+}
+
+func (s *relationService) getObject(ctx context.Context, objectType, objectID string) (repository.RelationObject, error) {
+	obj, err := s.relationRepository.GetObject(ctx, objectType, objectID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return obj, v1.ErrRelationObjectNotFound
+		}
+		return obj, err
+	}
+	return obj, nil
+}
+
+// checkRelation 按资源类型的AllowedRelations和关系规则校验一条从source到target的关系。
+// 源对象没有任何规则约束时只校验资源类型；存在规则时，必须命中允许该关系类型的规则，
+// 且不能超过规则的最大连接数。excludeKey为更新时被替换的关系自身。
+// 必须在写入关系的事务中调用：先锁定源对象，使同一源对象的重复检查、连接数统计和写入串行执行
+func (s *relationService) checkRelation(ctx context.Context, source, target repository.RelationObject, relationType string, properties map[string]interface{}, excludeKey string) error {
+	if source.ObjectType == target.ObjectType && source.ObjectID == target.ObjectID {
+		return v1.ErrRelationNotAllowed
+	}
+	if err := s.relationRepository.LockObject(ctx, source.ObjectType, source.ObjectID); err != nil {
+		return err
+	}
+	if source.ObjectType == model.ObjectTypeResource {
+		resourceType, err := s.resourceTypeRepository.GetResourceType(ctx, source.SubType)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if len(resourceType.AllowedRelations) > 0 && !containsString(resourceType.AllowedRelations, relationType) {
+			return v1.ErrRelationNotAllowed
+		}
+	}
+	edges, err := s.outgoingEdges(ctx, source, excludeKey)
+	if err != nil {
+		return err
+	}
+	for _, edge := range edges {
+		if edge.RelationType == relationType && edge.Target.ObjectType == target.ObjectType && edge.Target.ObjectID == target.ObjectID {
+			return v1.ErrRelationAlreadyExists
+		}
+	}
+
+	rules, err := s.relationRuleRepository.GetActiveRelationRules(ctx)
+	if err != nil {
+		return err
+	}
+	constrained := false
+	matched := make([]model.RelationRule, 0)
+	for _, rule := range rules {
+		if !ruleMatchesObject(rule.SourceType, source) {
+			continue
+		}
+		constrained = true
+		if ruleMatchesObject(rule.TargetType, target) && containsString(rule.AllowedRelations, relationType) {
+			matched = append(matched, rule)
+		}
+	}
+	if !constrained {
+		return nil
+	}
+	if len(matched) == 0 {
+		return v1.ErrRelationNotAllowed
+	}
+	for _, rule := range matched {
+		if len(rule.ValidationRules) > 0 {
+			if properties == nil {
+				properties = map[string]interface{}{}
+			}
+			if violations := schema.Validate(rule.ValidationRules, properties); len(violations) > 0 {
+				return &v1.ValidationError{
+					Err:    v1.ErrRelationPropertiesInvalid,
+					Fields: toFieldErrors("properties", violations),
+				}
+			}
+		}
+		if rule.MaxConnections > 0 && countRuleConnections(rule, edges) >= rule.MaxConnections {
+			return v1.ErrRelationMaxConnections
+		}
+	}
+	return nil
+}
+
+// outgoingEdges 返回对象在通用关系表和资源关系表中的全部出边
+func (s *relationService) outgoingEdges(ctx context.Context, source repository.RelationObject, excludeKey string) ([]relationEdge, error) {
+	edges := make([]relationEdge, 0)
+	relations, err := s.relationRepository.GetUniversalRelationsBySource(ctx, source.ObjectType, source.ObjectID)
+	if err != nil {
+		return nil, err
+	}
+	for _, relation := range relations {
+		key := universalRelationKey(relation.RelationID)
+		if key == excludeKey {
+			continue
+		}
+		target, err := s.relationRepository.GetObject(ctx, relation.TargetType, relation.TargetID)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		edges = append(edges, relationEdge{Key: key, RelationType: relation.RelationType, Target: target})
+	}
+	if source.ObjectType != model.ObjectTypeResource {
+		return edges, nil
+	}
+	resource, err := s.resourceRepository.GetResource(ctx, source.ObjectID)
+	if err != nil {
+		return nil, err
+	}
+	resourceRelations, err := s.relationRepository.GetResourceRelationsBySource(ctx, resource.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, relation := range resourceRelations {
+		key := resourceRelationKey(relation.ID)
+		if key == excludeKey {
+			continue
+		}
+		edges = append(edges, relationEdge{Key: key, RelationType: relation.RelationType, Target: resourceRelationObject(relation.Target)})
+	}
+	return edges, nil
+}
+
+// ruleMatchesObject 规则中的对象类型可以是"*"、对象大类(resource/application...)或细分类型(server/cdn_node...)
+func ruleMatchesObject(ruleType string, obj repository.RelationObject) bool {
+	return ruleType == "*" || ruleType == obj.ObjectType || (obj.SubType != "" && ruleType == obj.SubType)
+}
+
+// countRuleConnections 统计出边中受该规则约束的连接数
+func countRuleConnections(rule model.RelationRule, edges []relationEdge) int {
+	count := 0
+	for _, edge := range edges {
+		if ruleMatchesObject(rule.TargetType, edge.Target) && containsString(rule.AllowedRelations, edge.RelationType) {
+			count++
+		}
+	}
+	return count
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+func resourceRelationObject(m model.Resource) repository.RelationObject {
+	return repository.RelationObject{
+		ObjectType: model.ObjectTypeResource,
+		ObjectID:   m.ResourceID,
+		Name:       m.Name,
+		SubType:    m.Type,
+	}
+}
+
+func toUniversalRelationDataItem(m model.UniversalRelation) v1.UniversalRelationDataItem {
+	return v1.UniversalRelationDataItem{
+		ID:           m.ID,
+		RelationID:   m.RelationID,
+		SourceType:   m.SourceType,
+		SourceID:     m.SourceID,
+		SourceName:   m.SourceName,
+		TargetType:   m.TargetType,
+		TargetID:     m.TargetID,
+		TargetName:   m.TargetName,
+		RelationType: m.RelationType,
+		Direction:    m.Direction,
+		Weight:       m.Weight,
+		Priority:     m.Priority,
+		Properties:   m.Properties,
+		Environment:  m.Environment,
+		TenantID:     m.TenantID,
+		Status:       m.Status,
+		Description:  m.Description,
+		CreatedAt:    m.CreatedAt.Format("2006-01-02 15:04:05"),
+		UpdatedAt:    m.UpdatedAt.Format("2006-01-02 15:04:05"),
+	}
+}
+
+func toResourceRelationDataItem(m model.ResourceRelation) v1.ResourceRelationDataItem {
+	return v1.ResourceRelationDataItem{
+		ID:               m.ID,
+		SourceResourceID: m.Source.ResourceID,
+		SourceName:       m.Source.Name,
+		TargetResourceID: m.Target.ResourceID,
+		TargetName:       m.Target.Name,
+		RelationType:     m.RelationType,
+		Direction:        m.Direction,
+		Weight:           m.Weight,
+		Properties:       m.Properties,
+		Description:      m.Description,
+		CreatedAt:        m.CreatedAt.Format("2006-01-02 15:04:05"),
+		UpdatedAt:        m.UpdatedAt.Format("2006-01-02 15:04:05"),
+	}
+}
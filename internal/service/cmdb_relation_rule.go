@@ -0,0 +1,246 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	v1 "nunu-layout-admin/api/v1"
+	"nunu-layout-admin/internal/model"
+	"nunu-layout-admin/internal/repository"
+)
+
+type RelationRuleService interface {
+	GetRelationRules(ctx context.Context, req *v1.GetRelationRulesRequest) (*v1.GetRelationRulesResponseData, error)
+	RelationRuleCreate(ctx context.Context, req *v1.RelationRuleCreateRequest) error
+	RelationRuleUpdate(ctx context.Context, req *v1.RelationRuleUpdateRequest) error
+	RelationRuleDelete(ctx context.Context, ruleID string) error
+	GetRelationCompliance(ctx context.Context) (*v1.GetRelationComplianceResponseData, error)
+}
+
+func NewRelationRuleService(
+	service *Service,
+	relationRuleRepository repository.RelationRuleRepository,
+	relationRepository repository.RelationRepository,
+) RelationRuleService {
+	return &relationRuleService{
+		Service:                service,
+		relationRuleRepository: relationRuleRepository,
+		relationRepository:     relationRepository,
+	}
+}
+
+type relationRuleService struct {
+	*Service
+	relationRuleRepository repository.RelationRuleRepository
+	relationRepository     repository.RelationRepository
+}
+
+// 参与合规检查的对象类型
+var complianceObjectTypes = []string{
+	model.ObjectTypeResource,
+	model.ObjectTypeApplication,
+	model.ObjectTypeConfiguration,
+	model.ObjectTypeService,
+	model.ObjectTypeBusiness,
+}
+
+func (s *relationRuleService) GetRelationRules(ctx context.Context, req *v1.GetRelationRulesRequest) (*v1.GetRelationRulesResponseData, error) {
+	list, err := s.relationRuleRepository.GetRelationRules(ctx, req.SourceType, req.TargetType)
+	if err != nil {
+		return nil, err
+	}
+	data := &v1.GetRelationRulesResponseData{
+		List: make([]v1.RelationRuleDataItem, 0, len(list)),
+	}
+	for _, rule := range list {
+		data.List = append(data.List, toRelationRuleDataItem(rule))
+	}
+	return data, nil
+}
+
+func (s *relationRuleService) RelationRuleCreate(ctx context.Context, req *v1.RelationRuleCreateRequest) error {
+	if req.MaxConnections > 0 && req.MinConnections > req.MaxConnections {
+		return v1.ErrBadRequest
+	}
+	if req.RuleID == "" {
+		id, err := s.sid.GenString()
+		if err != nil {
+			return err
+		}
+		req.RuleID = id
+	}
+	_, err := s.relationRuleRepository.GetRelationRule(ctx, req.RuleID)
+	if err == nil {
+		return v1.ErrRelationRuleIDAlreadyUse
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return s.relationRuleRepository.RelationRuleCreate(ctx, &model.RelationRule{
+		RuleID:           req.RuleID,
+		RuleName:         req.RuleName,
+		SourceType:       req.SourceType,
+		TargetType:       req.TargetType,
+		AllowedRelations: req.AllowedRelations,
+		IsRequired:       req.IsRequired,
+		MaxConnections:   req.MaxConnections,
+		MinConnections:   req.MinConnections,
+		ValidationRules:  req.ValidationRules,
+		Priority:         req.Priority,
+		IsActive:         true,
+		Description:      req.Description,
+	})
+}
+
+func (s *relationRuleService) RelationRuleUpdate(ctx context.Context, req *v1.RelationRuleUpdateRequest) error {
+	if req.MaxConnections > 0 && req.MinConnections > req.MaxConnections {
+		return v1.ErrBadRequest
+	}
+	old, err := s.relationRuleRepository.GetRelationRule(ctx, req.RuleID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return v1.ErrNotFound
+		}
+		return err
+	}
+	return s.relationRuleRepository.RelationRuleUpdate(ctx, &model.RelationRule{
+		Model:            gorm.Model{ID: old.ID},
+		RuleName:         req.RuleName,
+		SourceType:       req.SourceType,
+		TargetType:       req.TargetType,
+		AllowedRelations: req.AllowedRelations,
+		IsRequired:       req.IsRequired,
+		MaxConnections:   req.MaxConnections,
+		MinConnections:   req.MinConnections,
+		ValidationRules:  req.ValidationRules,
+		Priority:         req.Priority,
+		IsActive:         req.IsActive,
+		Description:      req.Description,
+	})
+}
+
+func (s *relationRuleService) RelationRuleDelete(ctx context.Context, ruleID string) error {
+	old, err := s.relationRuleRepository.GetRelationRule(ctx, ruleID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return v1.ErrNotFound
+		}
+		return err
+	}
+	return s.relationRuleRepository.RelationRuleDelete(ctx, old.ID)
+}
+
+// GetRelationCompliance 按启用的规则检查全部对象，列出连接数低于MinConnections(IsRequired视为至少1条)
+// 或高于MaxConnections的对象
+func (s *relationRuleService) GetRelationCompliance(ctx context.Context) (*v1.GetRelationComplianceResponseData, error) {
+	rules, err := s.relationRuleRepository.GetActiveRelationRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+	objects := make(map[string]repository.RelationObject)
+	keys := make([]string, 0)
+	for _, objectType := range complianceObjectTypes {
+		list, err := s.relationRepository.GetObjects(ctx, objectType)
+		if err != nil {
+			return nil, err
+		}
+		for _, obj := range list {
+			key := obj.ObjectType + ":" + obj.ObjectID
+			objects[key] = obj
+			keys = append(keys, key)
+		}
+	}
+
+	edges := make(map[string][]relationEdge)
+	relations, err := s.relationRepository.GetActiveUniversalRelations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, relation := range relations {
+		target, ok := objects[relation.TargetType+":"+relation.TargetID]
+		if !ok {
+			target = repository.RelationObject{ObjectType: relation.TargetType, ObjectID: relation.TargetID}
+		}
+		sourceKey := relation.SourceType + ":" + relation.SourceID
+		edges[sourceKey] = append(edges[sourceKey], relationEdge{RelationType: relation.RelationType, Target: target})
+	}
+	resourceRelations, err := s.relationRepository.GetAllResourceRelations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, relation := range resourceRelations {
+		sourceKey := model.ObjectTypeResource + ":" + relation.Source.ResourceID
+		edges[sourceKey] = append(edges[sourceKey], relationEdge{RelationType: relation.RelationType, Target: resourceRelationObject(relation.Target)})
+	}
+
+	data := &v1.GetRelationComplianceResponseData{
+		Violations: make([]v1.RelationComplianceItem, 0),
+	}
+	checked := make(map[string]struct{})
+	for _, rule := range rules {
+		min := rule.MinConnections
+		if rule.IsRequired && min < 1 {
+			min = 1
+		}
+		if min == 0 && rule.MaxConnections == 0 {
+			continue
+		}
+		for _, key := range keys {
+			obj := objects[key]
+			if !ruleMatchesObject(rule.SourceType, obj) {
+				continue
+			}
+			checked[key] = struct{}{}
+			count := countRuleConnections(rule, edges[key])
+			message := ""
+			switch {
+			case count < min:
+				message = "连接数少于最小连接数"
+				if count == 0 && rule.IsRequired {
+					message = "缺少必需的关系"
+				}
+			case rule.MaxConnections > 0 && count > rule.MaxConnections:
+				message = "连接数超过最大连接数"
+			default:
+				continue
+			}
+			data.Violations = append(data.Violations, v1.RelationComplianceItem{
+				RuleID:         rule.RuleID,
+				RuleName:       rule.RuleName,
+				ObjectType:     obj.ObjectType,
+				ObjectID:       obj.ObjectID,
+				ObjectName:     obj.Name,
+				Connections:    count,
+				MinConnections: min,
+				MaxConnections: rule.MaxConnections,
+				Message:        message,
+			})
+		}
+	}
+	data.Checked = len(checked)
+	return data, nil
+}
+
+func toRelationRuleDataItem(m model.RelationRule) v1.RelationRuleDataItem {
+	item := v1.RelationRuleDataItem{
+		ID:               m.ID,
+		RuleID:           m.RuleID,
+		RuleName:         m.RuleName,
+		SourceType:       m.SourceType,
+		TargetType:       m.TargetType,
+		AllowedRelations: m.AllowedRelations,
+		IsRequired:       m.IsRequired,
+		MaxConnections:   m.MaxConnections,
+		MinConnections:   m.MinConnections,
+		ValidationRules:  m.ValidationRules,
+		Priority:         m.Priority,
+		IsActive:         m.IsActive,
+		Description:      m.Description,
+		CreatedAt:        m.CreatedAt.Format("2006-01-02 15:04:05"),
+		UpdatedAt:        m.UpdatedAt.Format("2006-01-02 15:04:05"),
+	}
+	if item.AllowedRelations == nil {
+		item.AllowedRelations = make([]string, 0)
+	}
+	return item
+}
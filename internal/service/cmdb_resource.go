@@ -0,0 +1,372 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	v1 "nunu-layout-admin/api/v1"
+	"nunu-layout-admin/internal/model"
+	"nunu-layout-admin/internal/repository"
+	"nunu-layout-admin/pkg/envelope"
+	"nunu-layout-admin/pkg/schema"
+	"strings"
+)
+
+type ResourceService interface {
+	GetResources(ctx context.Context, req *v1.GetResourcesRequest) (*v1.GetResourcesResponseData, error)
+	GetResource(ctx context.Context, req *v1.GetResourceRequest) (*v1.ResourceDataItem, error)
+	ResourceCreate(ctx context.Context, req *v1.ResourceCreateRequest) error
+	ResourceUpdate(ctx context.Context, req *v1.ResourceUpdateRequest) error
+	ResourceDelete(ctx context.Context, resourceID string) error
+}
+
+func NewResourceService(
+	service *Service,
+	keyring *envelope.Keyring,
+	resourceRepository repository.ResourceRepository,
+	resourceTypeRepository repository.ResourceTypeRepository,
+	historyRepository repository.HistoryRepository,
+	sensitiveRepository repository.SensitiveRepository,
+) ResourceService {
+	return &resourceService{
+		Service:                service,
+		keyring:                keyring,
+		resourceRepository:     resourceRepository,
+		resourceTypeRepository: resourceTypeRepository,
+		historyRepository:      historyRepository,
+		sensitiveRepository:    sensitiveRepository,
+	}
+}
+
+type resourceService struct {
+	*Service
+	keyring                *envelope.Keyring
+	resourceRepository     repository.ResourceRepository
+	resourceTypeRepository repository.ResourceTypeRepository
+	historyRepository      repository.HistoryRepository
+	sensitiveRepository    repository.SensitiveRepository
+}
+
+func (s *resourceService) GetResources(ctx context.Context, req *v1.GetResourcesRequest) (*v1.GetResourcesResponseData, error) {
+	view, err := newSensitiveView(ctx, s.keyring, s.historyRepository, s.sensitiveRepository, req.Reveal)
+	if err != nil {
+		return nil, err
+	}
+	if !req.AsOf.IsZero() {
+		return s.getResourcesAt(ctx, req, view)
+	}
+	list, total, err := s.resourceRepository.GetResources(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	data := &v1.GetResourcesResponseData{
+		List:  make([]v1.ResourceDataItem, 0),
+		Total: total,
+	}
+	for _, resource := range list {
+		item, err := toResourceDataItemView(resource, view)
+		if err != nil {
+			return nil, err
+		}
+		data.List = append(data.List, item)
+	}
+	return data, nil
+}
+
+// getResourcesAt 按变更历史还原asOf时刻的资源后再过滤、分页
+func (s *resourceService) getResourcesAt(ctx context.Context, req *v1.GetResourcesRequest, view *sensitiveView) (*v1.GetResourcesResponseData, error) {
+	resources, err := resourcesAt(ctx, s.resourceRepository, s.historyRepository, nil, req.AsOf)
+	if err != nil {
+		return nil, err
+	}
+	matched := make([]model.Resource, 0, len(resources))
+	for _, resource := range resources {
+		if matchResourceFilter(req, resource) {
+			matched = append(matched, resource)
+		}
+	}
+	data := &v1.GetResourcesResponseData{
+		List:  make([]v1.ResourceDataItem, 0),
+		Total: int64(len(matched)),
+	}
+	start := (req.Page - 1) * req.PageSize
+	if start < 0 {
+		start = 0
+	}
+	for i := start; i < len(matched) && i < start+req.PageSize; i++ {
+		item, err := toResourceDataItemView(matched[i], view)
+		if err != nil {
+			return nil, err
+		}
+		data.List = append(data.List, item)
+	}
+	return data, nil
+}
+
+func (s *resourceService) GetResource(ctx context.Context, req *v1.GetResourceRequest) (*v1.ResourceDataItem, error) {
+	view, err := newSensitiveView(ctx, s.keyring, s.historyRepository, s.sensitiveRepository, req.Reveal)
+	if err != nil {
+		return nil, err
+	}
+	if !req.AsOf.IsZero() {
+		resources, err := resourcesAt(ctx, s.resourceRepository, s.historyRepository, []string{req.ResourceID}, req.AsOf)
+		if err != nil {
+			return nil, err
+		}
+		if len(resources) == 0 {
+			return nil, v1.ErrNotFound
+		}
+		item, err := toResourceDataItemView(resources[0], view)
+		if err != nil {
+			return nil, err
+		}
+		return &item, nil
+	}
+	resource, err := s.resourceRepository.GetResource(ctx, req.ResourceID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, v1.ErrNotFound
+		}
+		return nil, err
+	}
+	item, err := toResourceDataItemView(resource, view)
+	if err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+func (s *resourceService) ResourceCreate(ctx context.Context, req *v1.ResourceCreateRequest) error {
+	if req.ResourceID == "" {
+		id, err := s.sid.GenString()
+		if err != nil {
+			return err
+		}
+		req.ResourceID = id
+	}
+	if req.Status == "" {
+		req.Status = model.ResourceStatusActive
+	}
+	attributes, err := sealResourceAttributes(ctx, s.keyring, s.historyRepository, s.resourceTypeRepository, req.Type, req.TenantID, req.Attributes, nil)
+	if err != nil {
+		return err
+	}
+	exists, err := s.resourceRepository.ResourceIDExists(ctx, req.ResourceID)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return v1.ErrResourceIDAlreadyUse
+	}
+	return s.tm.Transaction(ctx, func(ctx context.Context) error {
+		resource := &model.Resource{
+			ResourceID:  req.ResourceID,
+			Name:        req.Name,
+			Type:        req.Type,
+			Status:      req.Status,
+			Provider:    req.Provider,
+			Region:      req.Region,
+			Zone:        req.Zone,
+			TenantID:    req.TenantID,
+			BusinessID:  req.BusinessID,
+			Environment: req.Environment,
+			Attributes:  attributes,
+			Description: req.Description,
+			Tags:        toResourceTags(req.Tags),
+		}
+		if err := s.resourceRepository.ResourceCreate(ctx, resource); err != nil {
+			return err
+		}
+		return recordResourceHistory(ctx, s.historyRepository, model.ChangeTypeCreate, nil, resource, "", "")
+	})
+}
+
+func (s *resourceService) ResourceUpdate(ctx context.Context, req *v1.ResourceUpdateRequest) error {
+	old, err := s.resourceRepository.GetResource(ctx, req.ResourceID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return v1.ErrNotFound
+		}
+		return err
+	}
+	attributes, err := sealResourceAttributes(ctx, s.keyring, s.historyRepository, s.resourceTypeRepository, req.Type, req.TenantID, req.Attributes, old.Attributes)
+	if err != nil {
+		return err
+	}
+	return s.tm.Transaction(ctx, func(ctx context.Context) error {
+		err := s.resourceRepository.ResourceUpdate(ctx, &model.Resource{
+			Model:        gorm.Model{ID: old.ID},
+			Name:         req.Name,
+			Type:         req.Type,
+			Status:       req.Status,
+			Provider:     req.Provider,
+			Region:       req.Region,
+			Zone:         req.Zone,
+			TenantID:     req.TenantID,
+			BusinessID:   req.BusinessID,
+			Environment:  req.Environment,
+			Attributes:   attributes,
+			Description:  req.Description,
+			LastSyncTime: old.LastSyncTime,
+		})
+		if err != nil {
+			return err
+		}
+		if err := s.resourceRepository.ReplaceResourceTags(ctx, old.ID, toResourceTags(req.Tags)); err != nil {
+			return err
+		}
+		resource, err := s.resourceRepository.GetResource(ctx, old.ResourceID)
+		if err != nil {
+			return err
+		}
+		return recordResourceHistory(ctx, s.historyRepository, model.ChangeTypeUpdate, &old, &resource, "", "")
+	})
+}
+
+func (s *resourceService) ResourceDelete(ctx context.Context, resourceID string) error {
+	old, err := s.resourceRepository.GetResource(ctx, resourceID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return v1.ErrNotFound
+		}
+		return err
+	}
+	return s.tm.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.resourceRepository.ResourceDelete(ctx, old.ID); err != nil {
+			return err
+		}
+		return recordResourceHistory(ctx, s.historyRepository, model.ChangeTypeDelete, &old, nil, "", "")
+	})
+}
+
+// validateResourceAttributes 校验资源类型是否可用，并按其AttributeSchema校验扩展属性
+func validateResourceAttributes(ctx context.Context, resourceTypeRepository repository.ResourceTypeRepository, typeName string, attributes map[string]interface{}) error {
+	resourceType, err := resourceTypeRepository.GetResourceType(ctx, typeName)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return v1.ErrResourceTypeNotFound
+		}
+		return err
+	}
+	if !resourceType.IsActive {
+		return v1.ErrResourceTypeInactive
+	}
+	if attributes == nil {
+		attributes = map[string]interface{}{}
+	}
+	if violations := schema.Validate(resourceType.AttributeSchema, attributes); len(violations) > 0 {
+		return &v1.ValidationError{
+			Err:    v1.ErrResourceAttributesInvalid,
+			Fields: toFieldErrors("attributes", violations),
+		}
+	}
+	return nil
+}
+
+// sealResourceAttributes 加密扩展属性中的敏感字段，并按解密后的明文校验AttributeSchema。
+// 更新时传入原属性old，未修改和以脱敏占位符提交的敏感字段保持原密文
+func sealResourceAttributes(ctx context.Context, keyring *envelope.Keyring, historyRepository repository.HistoryRepository, resourceTypeRepository repository.ResourceTypeRepository, typeName, tenantID string, attributes, old model.JSONMap) (model.JSONMap, error) {
+	fields, err := resourceSensitiveFields(ctx, historyRepository, model.Resource{Type: typeName, TenantID: tenantID})
+	if err != nil {
+		return nil, err
+	}
+	sealed, _, err := sealSensitive(keyring, attributes, old, fields, false)
+	if err != nil {
+		return nil, err
+	}
+	opened, err := openSensitive(keyring, sealed)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateResourceAttributes(ctx, resourceTypeRepository, typeName, opened); err != nil {
+		return nil, err
+	}
+	return sealed, nil
+}
+
+// toFieldErrors 将schema校验结果转换为接口返回的字段错误，字段路径统一加上prefix
+func toFieldErrors(prefix string, violations []schema.Violation) []v1.FieldError {
+	fields := make([]v1.FieldError, 0, len(violations))
+	for _, v := range violations {
+		field := prefix
+		switch {
+		case v.Field == "$":
+		case strings.HasPrefix(v.Field, "["):
+			field += v.Field
+		default:
+			field += "." + v.Field
+		}
+		fields = append(fields, v1.FieldError{Field: field, Message: v.Message})
+	}
+	return fields
+}
+
+// matchResourceFilter 与资源列表查询条件一致的内存过滤
+func matchResourceFilter(req *v1.GetResourcesRequest, m model.Resource) bool {
+	if req.Name != "" && !strings.Contains(m.Name, req.Name) {
+		return false
+	}
+	conditions := [][2]string{
+		{req.Type, m.Type},
+		{req.Status, m.Status},
+		{req.Provider, m.Provider},
+		{req.Region, m.Region},
+		{req.Zone, m.Zone},
+		{req.TenantID, m.TenantID},
+		{req.BusinessID, m.BusinessID},
+		{req.Environment, m.Environment},
+	}
+	for _, c := range conditions {
+		if c[0] != "" && c[0] != c[1] {
+			return false
+		}
+	}
+	return true
+}
+
+func toResourceTags(items []v1.ResourceTagItem) []model.ResourceTag {
+	tags := make([]model.ResourceTag, 0, len(items))
+	for _, item := range items {
+		tags = append(tags, model.ResourceTag{Key: item.Key, Value: item.Value})
+	}
+	return tags
+}
+
+func toResourceDataItem(m model.Resource) v1.ResourceDataItem {
+	item := v1.ResourceDataItem{
+		ID:          m.ID,
+		ResourceID:  m.ResourceID,
+		Name:        m.Name,
+		Type:        m.Type,
+		Status:      m.Status,
+		Provider:    m.Provider,
+		Region:      m.Region,
+		Zone:        m.Zone,
+		TenantID:    m.TenantID,
+		BusinessID:  m.BusinessID,
+		Environment: m.Environment,
+		Attributes:  m.Attributes,
+		Description: m.Description,
+		Tags:        make([]v1.ResourceTagItem, 0, len(m.Tags)),
+		CreatedAt:   m.CreatedAt.Format("2006-01-02 15:04:05"),
+		UpdatedAt:   m.UpdatedAt.Format("2006-01-02 15:04:05"),
+	}
+	if m.LastSyncTime != nil {
+		item.LastSyncTime = m.LastSyncTime.Format("2006-01-02 15:04:05")
+	}
+	for _, tag := range m.Tags {
+		item.Tags = append(item.Tags, v1.ResourceTagItem{Key: tag.Key, Value: tag.Value})
+	}
+	return item
+}
+
+// toResourceDataItemView 按调用方的权限脱敏或解密扩展属性
+func toResourceDataItemView(m model.Resource, view *sensitiveView) (v1.ResourceDataItem, error) {
+	item := toResourceDataItem(m)
+	attributes, err := view.apply(m.Attributes)
+	if err != nil {
+		return item, err
+	}
+	item.Attributes = attributes
+	return item, nil
+}
@@ -0,0 +1,436 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	v1 "nunu-layout-admin/api/v1"
+	"nunu-layout-admin/internal/model"
+	"nunu-layout-admin/internal/repository"
+	"nunu-layout-admin/pkg/envelope"
+	"nunu-layout-admin/pkg/schema"
+	"reflect"
+	"strings"
+)
+
+type ResourceTypeService interface {
+	GetResourceTypes(ctx context.Context, req *v1.GetResourceTypesRequest) (*v1.GetResourceTypesResponseData, error)
+	ResourceTypeCreate(ctx context.Context, req *v1.ResourceTypeCreateRequest) error
+	ResourceTypeUpdate(ctx context.Context, req *v1.ResourceTypeUpdateRequest) (*v1.ResourceTypeUpdateResponseData, error)
+	UpdateResourceTypeStatus(ctx context.Context, req *v1.ResourceTypeStatusRequest) error
+	GetResourceTypeSchemas(ctx context.Context, typeName string) (*v1.GetResourceTypeSchemasResponseData, error)
+	GetResourceTypeCompliance(ctx context.Context, typeName string) (*v1.GetResourceTypeComplianceResponseData, error)
+	ResourceTypeMigrate(ctx context.Context, req *v1.ResourceTypeMigrateRequest) (*v1.ResourceTypeMigrateResponseData, error)
+}
+
+func NewResourceTypeService(
+	service *Service,
+	keyring *envelope.Keyring,
+	resourceTypeRepository repository.ResourceTypeRepository,
+	resourceRepository repository.ResourceRepository,
+	historyRepository repository.HistoryRepository,
+) ResourceTypeService {
+	return &resourceTypeService{
+		Service:                service,
+		keyring:                keyring,
+		resourceTypeRepository: resourceTypeRepository,
+		resourceRepository:     resourceRepository,
+		historyRepository:      historyRepository,
+	}
+}
+
+type resourceTypeService struct {
+	*Service
+	keyring                *envelope.Keyring
+	resourceTypeRepository repository.ResourceTypeRepository
+	resourceRepository     repository.ResourceRepository
+	historyRepository      repository.HistoryRepository
+}
+
+func (s *resourceTypeService) GetResourceTypes(ctx context.Context, req *v1.GetResourceTypesRequest) (*v1.GetResourceTypesResponseData, error) {
+	list, err := s.resourceTypeRepository.GetResourceTypes(ctx, req.Category, req.IncludeInactive)
+	if err != nil {
+		return nil, err
+	}
+	data := &v1.GetResourceTypesResponseData{
+		List: make([]v1.ResourceTypeDataItem, 0, len(list)),
+	}
+	for _, t := range list {
+		data.List = append(data.List, toResourceTypeDataItem(t))
+	}
+	return data, nil
+}
+
+func (s *resourceTypeService) ResourceTypeCreate(ctx context.Context, req *v1.ResourceTypeCreateRequest) error {
+	_, err := s.resourceTypeRepository.GetResourceType(ctx, req.TypeName)
+	if err == nil {
+		return v1.ErrResourceTypeAlreadyUse
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return s.tm.Transaction(ctx, func(ctx context.Context) error {
+		err := s.resourceTypeRepository.ResourceTypeCreate(ctx, &model.ResourceType{
+			TypeName:         req.TypeName,
+			DisplayName:      req.DisplayName,
+			Category:         req.Category,
+			Icon:             req.Icon,
+			Color:            req.Color,
+			AttributeSchema:  req.AttributeSchema,
+			SchemaVersion:    1,
+			AllowedRelations: req.AllowedRelations,
+			Description:      req.Description,
+			IsActive:         true,
+		})
+		if err != nil {
+			return err
+		}
+		return s.resourceTypeRepository.ResourceTypeSchemaCreate(ctx, &model.ResourceTypeSchema{
+			TypeName:        req.TypeName,
+			Version:         1,
+			AttributeSchema: req.AttributeSchema,
+			Compatible:      true,
+		})
+	})
+}
+
+// ResourceTypeUpdate 更新资源类型。AttributeSchema发生变化时生成新的schema版本并保留旧版本，
+// 返回变更明细以及在新schema下不再合规的存量资源
+func (s *resourceTypeService) ResourceTypeUpdate(ctx context.Context, req *v1.ResourceTypeUpdateRequest) (*v1.ResourceTypeUpdateResponseData, error) {
+	old, err := s.resourceTypeRepository.GetResourceType(ctx, req.TypeName)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, v1.ErrNotFound
+		}
+		return nil, err
+	}
+	data := &v1.ResourceTypeUpdateResponseData{
+		SchemaVersion: old.SchemaVersion,
+		Compatible:    true,
+		Changes:       make([]v1.SchemaChangeItem, 0),
+		NonCompliant:  make([]v1.NonCompliantResourceItem, 0),
+	}
+	data.SchemaChanged = !reflect.DeepEqual(schema.Normalize(old.AttributeSchema), schema.Normalize(req.AttributeSchema))
+	var changes []schema.Change
+	if data.SchemaChanged {
+		data.Compatible, changes = schema.Compare(old.AttributeSchema, req.AttributeSchema)
+		data.SchemaVersion = old.SchemaVersion + 1
+		data.Changes = toSchemaChangeItems(changes)
+	}
+	err = s.tm.Transaction(ctx, func(ctx context.Context) error {
+		err := s.resourceTypeRepository.ResourceTypeUpdate(ctx, &model.ResourceType{
+			Model:            gorm.Model{ID: old.ID},
+			DisplayName:      req.DisplayName,
+			Category:         req.Category,
+			Icon:             req.Icon,
+			Color:            req.Color,
+			AttributeSchema:  req.AttributeSchema,
+			SchemaVersion:    data.SchemaVersion,
+			AllowedRelations: req.AllowedRelations,
+			Description:      req.Description,
+		})
+		if err != nil || !data.SchemaChanged {
+			return err
+		}
+		// 早于版本表创建的类型没有旧版本记录，先补录以保留旧schema
+		versions, err := s.resourceTypeRepository.GetResourceTypeSchemas(ctx, old.TypeName)
+		if err != nil {
+			return err
+		}
+		if len(versions) == 0 || versions[0].Version != old.SchemaVersion {
+			err = s.resourceTypeRepository.ResourceTypeSchemaCreate(ctx, &model.ResourceTypeSchema{
+				TypeName:        old.TypeName,
+				Version:         old.SchemaVersion,
+				AttributeSchema: old.AttributeSchema,
+				Compatible:      true,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		records := make([]model.SchemaChangeRecord, 0, len(changes))
+		for _, c := range changes {
+			records = append(records, model.SchemaChangeRecord{Field: c.Field, Message: c.Message, Compatible: c.Compatible})
+		}
+		return s.resourceTypeRepository.ResourceTypeSchemaCreate(ctx, &model.ResourceTypeSchema{
+			TypeName:        old.TypeName,
+			Version:         data.SchemaVersion,
+			AttributeSchema: req.AttributeSchema,
+			Compatible:      data.Compatible,
+			Changes:         records,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	if data.SchemaChanged {
+		resources, err := s.resourceRepository.GetResourcesByType(ctx, old.TypeName)
+		if err != nil {
+			return nil, err
+		}
+		if data.NonCompliant, err = checkCompliance(s.keyring, req.AttributeSchema, resources); err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+func (s *resourceTypeService) UpdateResourceTypeStatus(ctx context.Context, req *v1.ResourceTypeStatusRequest) error {
+	if _, err := s.resourceTypeRepository.GetResourceType(ctx, req.TypeName); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return v1.ErrNotFound
+		}
+		return err
+	}
+	return s.resourceTypeRepository.UpdateResourceTypeStatus(ctx, req.TypeName, req.IsActive)
+}
+
+func (s *resourceTypeService) GetResourceTypeSchemas(ctx context.Context, typeName string) (*v1.GetResourceTypeSchemasResponseData, error) {
+	if _, err := s.resourceTypeRepository.GetResourceType(ctx, typeName); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, v1.ErrNotFound
+		}
+		return nil, err
+	}
+	list, err := s.resourceTypeRepository.GetResourceTypeSchemas(ctx, typeName)
+	if err != nil {
+		return nil, err
+	}
+	data := &v1.GetResourceTypeSchemasResponseData{
+		List: make([]v1.ResourceTypeSchemaDataItem, 0, len(list)),
+	}
+	for _, m := range list {
+		item := v1.ResourceTypeSchemaDataItem{
+			Version:         m.Version,
+			AttributeSchema: m.AttributeSchema,
+			Compatible:      m.Compatible,
+			Changes:         make([]v1.SchemaChangeItem, 0, len(m.Changes)),
+			CreatedAt:       m.CreatedAt.Format("2006-01-02 15:04:05"),
+		}
+		for _, c := range m.Changes {
+			item.Changes = append(item.Changes, v1.SchemaChangeItem{Field: c.Field, Message: c.Message, Compatible: c.Compatible})
+		}
+		data.List = append(data.List, item)
+	}
+	return data, nil
+}
+
+func (s *resourceTypeService) GetResourceTypeCompliance(ctx context.Context, typeName string) (*v1.GetResourceTypeComplianceResponseData, error) {
+	resourceType, err := s.resourceTypeRepository.GetResourceType(ctx, typeName)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, v1.ErrNotFound
+		}
+		return nil, err
+	}
+	resources, err := s.resourceRepository.GetResourcesByType(ctx, typeName)
+	if err != nil {
+		return nil, err
+	}
+	nonCompliant, err := checkCompliance(s.keyring, resourceType.AttributeSchema, resources)
+	if err != nil {
+		return nil, err
+	}
+	return &v1.GetResourceTypeComplianceResponseData{
+		SchemaVersion: resourceType.SchemaVersion,
+		Total:         len(resources),
+		NonCompliant:  nonCompliant,
+	}, nil
+}
+
+// ResourceTypeMigrate 对该类型下全部资源的扩展属性依次执行迁移操作(rename/default/drop)。
+// dryRun时只计算结果不落库，否则在同一事务内写入。rename的目标属性已存在时列入conflicts，
+// 非dryRun时拒绝整个迁移，避免丢失数据
+func (s *resourceTypeService) ResourceTypeMigrate(ctx context.Context, req *v1.ResourceTypeMigrateRequest) (*v1.ResourceTypeMigrateResponseData, error) {
+	for _, op := range req.Operations {
+		if op.Op == "rename" && (op.To == "" || op.To == op.Field) {
+			return nil, v1.ErrResourceMigrationInvalid
+		}
+		if op.Op == "default" && op.Value == nil {
+			return nil, v1.ErrResourceMigrationInvalid
+		}
+	}
+	resourceType, err := s.resourceTypeRepository.GetResourceType(ctx, req.TypeName)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, v1.ErrNotFound
+		}
+		return nil, err
+	}
+	resources, err := s.resourceRepository.GetResourcesByType(ctx, req.TypeName)
+	if err != nil {
+		return nil, err
+	}
+	data := &v1.ResourceTypeMigrateResponseData{
+		DryRun:    req.DryRun,
+		Total:     len(resources),
+		Affected:  make([]string, 0),
+		Conflicts: make([]v1.AttributeMigrationConflictItem, 0),
+	}
+	conflicts := make(map[int][]string)
+	// 按明文迁移，写入前重新加密敏感字段
+	migrated := make([]model.Resource, 0)
+	originals := make([]model.Resource, 0)
+	for i := range resources {
+		original := resources[i]
+		opened, err := openSensitive(s.keyring, resources[i].Attributes)
+		if err != nil {
+			return nil, err
+		}
+		attributes, changed, conflicted := migrateAttributes(opened, req.Operations)
+		for _, j := range conflicted {
+			op := req.Operations[j]
+			conflicts[j] = append(conflicts[j], resources[i].ResourceID)
+			data.Conflicts = append(data.Conflicts, v1.AttributeMigrationConflictItem{
+				ResourceID: resources[i].ResourceID,
+				Field:      op.Field,
+				To:         op.To,
+			})
+		}
+		resources[i].Attributes = attributes
+		if changed {
+			data.Affected = append(data.Affected, resources[i].ResourceID)
+			migrated = append(migrated, resources[i])
+			originals = append(originals, original)
+		}
+	}
+	if data.NonCompliant, err = checkCompliance(s.keyring, resourceType.AttributeSchema, resources); err != nil {
+		return nil, err
+	}
+	if req.DryRun {
+		return data, nil
+	}
+	if len(conflicts) > 0 {
+		fields := make([]v1.FieldError, 0, len(conflicts))
+		for j := range req.Operations {
+			if ids, ok := conflicts[j]; ok {
+				fields = append(fields, v1.FieldError{
+					Field:   fmt.Sprintf("operations[%d].to", j),
+					Message: fmt.Sprintf("资源%s已有属性%s", strings.Join(ids, ","), req.Operations[j].To),
+				})
+			}
+		}
+		return nil, &v1.ValidationError{Err: v1.ErrResourceMigrationConflict, Fields: fields}
+	}
+	if len(migrated) == 0 {
+		return data, nil
+	}
+	err = s.tm.Transaction(ctx, func(ctx context.Context) error {
+		for i, resource := range migrated {
+			fields, err := resourceSensitiveFields(ctx, s.historyRepository, resource)
+			if err != nil {
+				return err
+			}
+			if migrated[i].Attributes, _, err = sealSensitive(s.keyring, resource.Attributes, originals[i].Attributes, fields, false); err != nil {
+				return err
+			}
+			if err := s.resourceRepository.ResourceAttributesUpdate(ctx, resource.ID, migrated[i].Attributes); err != nil {
+				return err
+			}
+			err = recordResourceHistory(ctx, s.historyRepository, model.ChangeTypeUpdate, &originals[i], &migrated[i], "", "attribute migration")
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// migrateAttributes 返回迁移后的属性副本、是否发生了变化，以及目标属性已存在的rename操作下标；
+// 冲突的rename不执行，两个属性都保留
+func migrateAttributes(attributes model.JSONMap, operations []v1.AttributeMigrationOperation) (model.JSONMap, bool, []int) {
+	out := make(model.JSONMap, len(attributes))
+	for k, v := range attributes {
+		out[k] = v
+	}
+	changed := false
+	var conflicts []int
+	for i, op := range operations {
+		value, exists := out[op.Field]
+		switch op.Op {
+		case "rename":
+			if !exists {
+				continue
+			}
+			if _, taken := out[op.To]; taken {
+				conflicts = append(conflicts, i)
+				continue
+			}
+			out[op.To] = value
+			delete(out, op.Field)
+			changed = true
+		case "default":
+			if exists && value != nil {
+				continue
+			}
+			out[op.Field] = op.Value
+			changed = true
+		case "drop":
+			if !exists {
+				continue
+			}
+			delete(out, op.Field)
+			changed = true
+		}
+	}
+	return out, changed, conflicts
+}
+
+// checkCompliance 按解密后的扩展属性检查资源是否符合AttributeSchema
+func checkCompliance(keyring *envelope.Keyring, attributeSchema map[string]interface{}, resources []model.Resource) ([]v1.NonCompliantResourceItem, error) {
+	items := make([]v1.NonCompliantResourceItem, 0)
+	for _, resource := range resources {
+		attributes, err := openSensitive(keyring, resource.Attributes)
+		if err != nil {
+			return nil, err
+		}
+		if attributes == nil {
+			attributes = model.JSONMap{}
+		}
+		violations := schema.Validate(attributeSchema, schema.Normalize(attributes))
+		if len(violations) == 0 {
+			continue
+		}
+		items = append(items, v1.NonCompliantResourceItem{
+			ResourceID: resource.ResourceID,
+			Name:       resource.Name,
+			Violations: toFieldErrors("attributes", violations),
+		})
+	}
+	return items, nil
+}
+
+func toSchemaChangeItems(changes []schema.Change) []v1.SchemaChangeItem {
+	items := make([]v1.SchemaChangeItem, 0, len(changes))
+	for _, c := range changes {
+		items = append(items, v1.SchemaChangeItem{Field: c.Field, Message: c.Message, Compatible: c.Compatible})
+	}
+	return items
+}
+
+func toResourceTypeDataItem(m model.ResourceType) v1.ResourceTypeDataItem {
+	item := v1.ResourceTypeDataItem{
+		ID:               m.ID,
+		TypeName:         m.TypeName,
+		DisplayName:      m.DisplayName,
+		Category:         m.Category,
+		Icon:             m.Icon,
+		Color:            m.Color,
+		AttributeSchema:  m.AttributeSchema,
+		SchemaVersion:    m.SchemaVersion,
+		AllowedRelations: m.AllowedRelations,
+		Description:      m.Description,
+		IsActive:         m.IsActive,
+		CreatedAt:        m.CreatedAt.Format("2006-01-02 15:04:05"),
+		UpdatedAt:        m.UpdatedAt.Format("2006-01-02 15:04:05"),
+	}
+	if item.AllowedRelations == nil {
+		item.AllowedRelations = make([]string, 0)
+	}
+	return item
+}
@@ -0,0 +1,61 @@
+package service
+
+import (
+	v1 "nunu-layout-admin/api/v1"
+	"nunu-layout-admin/internal/model"
+	"reflect"
+	"testing"
+)
+
+func TestMigrateAttributes(t *testing.T) {
+	tests := []struct {
+		name       string
+		attributes model.JSONMap
+		operations []v1.AttributeMigrationOperation
+		want       model.JSONMap
+		changed    bool
+		conflicts  []int
+	}{
+		{
+			name:       "rename",
+			attributes: model.JSONMap{"memory": float64(16)},
+			operations: []v1.AttributeMigrationOperation{{Op: "rename", Field: "memory", To: "memory_gb"}},
+			want:       model.JSONMap{"memory_gb": float64(16)},
+			changed:    true,
+		},
+		{
+			name:       "rename target exists",
+			attributes: model.JSONMap{"memory": float64(16), "memory_gb": float64(32)},
+			operations: []v1.AttributeMigrationOperation{
+				{Op: "drop", Field: "legacy"},
+				{Op: "rename", Field: "memory", To: "memory_gb"},
+			},
+			want:      model.JSONMap{"memory": float64(16), "memory_gb": float64(32)},
+			conflicts: []int{1},
+		},
+		{
+			name:       "rename missing field",
+			attributes: model.JSONMap{"memory_gb": float64(32)},
+			operations: []v1.AttributeMigrationOperation{{Op: "rename", Field: "memory", To: "memory_gb"}},
+			want:       model.JSONMap{"memory_gb": float64(32)},
+		},
+		{
+			name:       "default and drop",
+			attributes: model.JSONMap{"os": nil, "legacy": "x"},
+			operations: []v1.AttributeMigrationOperation{
+				{Op: "default", Field: "os", Value: "linux"},
+				{Op: "drop", Field: "legacy"},
+			},
+			want:    model.JSONMap{"os": "linux"},
+			changed: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, changed, conflicts := migrateAttributes(tt.attributes, tt.operations)
+			if !reflect.DeepEqual(got, tt.want) || changed != tt.changed || !reflect.DeepEqual(conflicts, tt.conflicts) {
+				t.Errorf("migrateAttributes = %v, %v, %v; want %v, %v, %v", got, changed, conflicts, tt.want, tt.changed, tt.conflicts)
+			}
+		})
+	}
+}
@@ -0,0 +1,840 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	v1 "nunu-layout-admin/api/v1"
+	"nunu-layout-admin/internal/model"
+	"nunu-layout-admin/internal/repository"
+	"nunu-layout-admin/pkg/envelope"
+	"nunu-layout-admin/pkg/jwt"
+	"strings"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	// sensitiveMask 敏感字段脱敏后的占位符，更新时传回占位符表示保持原值
+	sensitiveMask = "******"
+	// sensitiveGroup 审计配置MonitoredFields中列出敏感字段的分组
+	sensitiveGroup = "sensitive"
+
+	sensitiveRotateBatchSize = 200
+)
+
+type SensitiveService interface {
+	// Reveal 返回资源或配置的敏感字段明文
+	Reveal(ctx context.Context, req *v1.SensitiveRevealRequest) (*v1.SensitiveRevealResponseData, error)
+	// RotateKeys 用当前主密钥重新加密已有密文的数据密钥，并加密尚未加密的敏感字段
+	RotateKeys(ctx context.Context) (*v1.SensitiveRotateResponseData, error)
+}
+
+func NewSensitiveService(
+	service *Service,
+	keyring *envelope.Keyring,
+	sensitiveRepository repository.SensitiveRepository,
+	resourceRepository repository.ResourceRepository,
+	historyRepository repository.HistoryRepository,
+) SensitiveService {
+	return &sensitiveService{
+		Service:             service,
+		keyring:             keyring,
+		sensitiveRepository: sensitiveRepository,
+		resourceRepository:  resourceRepository,
+		historyRepository:   historyRepository,
+	}
+}
+
+type sensitiveService struct {
+	*Service
+	keyring             *envelope.Keyring
+	sensitiveRepository repository.SensitiveRepository
+	resourceRepository  repository.ResourceRepository
+	historyRepository   repository.HistoryRepository
+}
+
+func (s *sensitiveService) Reveal(ctx context.Context, req *v1.SensitiveRevealRequest) (*v1.SensitiveRevealResponseData, error) {
+	configs, err := s.historyRepository.GetAuditConfigs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var data model.JSONMap
+	var fields []string
+	switch req.ObjectType {
+	case model.ObjectTypeResource:
+		resource, err := s.resourceRepository.GetResource(ctx, req.ObjectID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, v1.ErrNotFound
+			}
+			return nil, err
+		}
+		data = model.JSONMap{"attributes": map[string]interface{}(resource.Attributes)}
+		fields = sensitiveFields(matchAuditConfig(configs, model.ObjectTypeResource, resourceAuditData(resource)))
+	case model.ObjectTypeConfiguration:
+		configuration, err := s.sensitiveRepository.GetConfiguration(ctx, req.ObjectID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, v1.ErrNotFound
+			}
+			return nil, err
+		}
+		data = model.JSONMap{"config_data": map[string]interface{}(configuration.ConfigData)}
+		fields = sensitiveFields(matchAuditConfig(configs, model.ObjectTypeConfiguration, configurationAuditData(configuration)))
+	default:
+		return nil, v1.ErrBadRequest
+	}
+	revealed := make(map[string]interface{})
+	if err := collectSensitive(s.keyring, "", data, fields, revealed); err != nil {
+		return nil, err
+	}
+	operatorID, operatorName, operatorIP := requestOperator(ctx, s.historyRepository)
+	s.logger.WithContext(ctx).Info("reveal sensitive fields", zap.String("objectType", req.ObjectType),
+		zap.String("objectId", req.ObjectID), zap.Int("count", len(revealed)),
+		zap.String("operatorId", operatorID), zap.String("operatorName", operatorName), zap.String("operatorIp", operatorIP))
+	return &v1.SensitiveRevealResponseData{
+		ObjectType: req.ObjectType,
+		ObjectID:   req.ObjectID,
+		Fields:     revealed,
+	}, nil
+}
+
+func (s *sensitiveService) RotateKeys(ctx context.Context) (*v1.SensitiveRotateResponseData, error) {
+	if !s.keyring.Enabled() {
+		return nil, v1.ErrSensitiveKeyNotConfigured
+	}
+	configs, err := s.historyRepository.GetAuditConfigs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// 同一密文在对象和历史中各有一份，缓存重新加密的结果使两处保持一致
+	r := &sensitiveRotator{keyring: s.keyring, rewrapped: make(map[string]string)}
+	filter := repository.SensitiveRotateFilter{
+		CurrentKey:  s.keyring.CurrentKeyID(),
+		RetiredKeys: s.keyring.RetiredKeyIDs(),
+	}
+	data := &v1.SensitiveRotateResponseData{
+		CurrentKey: s.keyring.CurrentKeyID(),
+		Items:      make([]v1.SensitiveRotateItem, 0),
+	}
+
+	item := v1.SensitiveRotateItem{ObjectType: model.ObjectTypeResource}
+	var afterID uint
+	for {
+		list, err := s.sensitiveRepository.GetResourcesAfter(ctx, filter, afterID, sensitiveRotateBatchSize)
+		if err != nil {
+			return nil, err
+		}
+		var updates []func(ctx context.Context) error
+		for _, m := range list {
+			afterID = m.ID
+			item.Scanned++
+			fields := sensitiveFields(matchAuditConfig(configs, model.ObjectTypeResource, resourceAuditData(m)))
+			attributes, changed, err := r.rotate(m.Attributes, fields, false)
+			if err != nil {
+				s.rotateFailed(ctx, &item, "resource "+m.ResourceID, err)
+				continue
+			}
+			if changed {
+				id := m.ID
+				updates = append(updates, func(ctx context.Context) error {
+					return s.sensitiveRepository.ResourceAttributesUpdate(ctx, id, attributes)
+				})
+			}
+		}
+		s.saveRotated(ctx, &item, updates)
+		if len(list) < sensitiveRotateBatchSize {
+			break
+		}
+	}
+	data.Items = append(data.Items, item)
+
+	item = v1.SensitiveRotateItem{ObjectType: model.ObjectTypeConfiguration}
+	afterID = 0
+	for {
+		list, err := s.sensitiveRepository.GetConfigurationsAfter(ctx, filter, afterID, sensitiveRotateBatchSize)
+		if err != nil {
+			return nil, err
+		}
+		var updates []func(ctx context.Context) error
+		for _, m := range list {
+			afterID = m.ID
+			item.Scanned++
+			fields := sensitiveFields(matchAuditConfig(configs, model.ObjectTypeConfiguration, configurationAuditData(m)))
+			configData, changed, err := r.rotate(m.ConfigData, fields, m.IsEncrypted)
+			if err != nil {
+				s.rotateFailed(ctx, &item, "configuration "+m.ConfigID, err)
+				continue
+			}
+			if changed {
+				id := m.ID
+				updates = append(updates, func(ctx context.Context) error {
+					return s.sensitiveRepository.ConfigurationDataUpdate(ctx, id, configData)
+				})
+			}
+		}
+		s.saveRotated(ctx, &item, updates)
+		if len(list) < sensitiveRotateBatchSize {
+			break
+		}
+	}
+	data.Items = append(data.Items, item)
+
+	// 配置版本和渲染记录按所属配置匹配审计配置
+	configurations := make(map[uint]model.Configuration)
+	item = v1.SensitiveRotateItem{ObjectType: model.ObjectTypeConfiguration + "_revision"}
+	afterID = 0
+	for {
+		list, err := s.sensitiveRepository.GetConfigRevisionsAfter(ctx, filter, afterID, sensitiveRotateBatchSize)
+		if err != nil {
+			return nil, err
+		}
+		ids := make([]uint, 0, len(list))
+		for _, m := range list {
+			ids = append(ids, m.ConfigurationID)
+		}
+		if err := s.loadConfigurations(ctx, configurations, ids); err != nil {
+			return nil, err
+		}
+		var updates []func(ctx context.Context) error
+		for _, m := range list {
+			afterID = m.ID
+			item.Scanned++
+			fields := sensitiveFields(matchAuditConfig(configs, model.ObjectTypeConfiguration, configurationAuditData(configurations[m.ConfigurationID])))
+			configData, changed, err := r.rotate(m.ConfigData, fields, m.IsEncrypted)
+			if err != nil {
+				s.rotateFailed(ctx, &item, fmt.Sprintf("configuration %s revision %d", m.ConfigID, m.Revision), err)
+				continue
+			}
+			if changed {
+				id := m.ID
+				updates = append(updates, func(ctx context.Context) error {
+					return s.sensitiveRepository.ConfigRevisionDataUpdate(ctx, id, configData)
+				})
+			}
+		}
+		s.saveRotated(ctx, &item, updates)
+		if len(list) < sensitiveRotateBatchSize {
+			break
+		}
+	}
+	data.Items = append(data.Items, item)
+
+	item = v1.SensitiveRotateItem{ObjectType: model.ObjectTypeConfiguration + "_render"}
+	afterID = 0
+	for {
+		list, err := s.sensitiveRepository.GetConfigRendersAfter(ctx, filter, afterID, sensitiveRotateBatchSize)
+		if err != nil {
+			return nil, err
+		}
+		ids := make([]uint, 0, len(list))
+		for _, m := range list {
+			ids = append(ids, m.ConfigurationID)
+		}
+		if err := s.loadConfigurations(ctx, configurations, ids); err != nil {
+			return nil, err
+		}
+		var updates []func(ctx context.Context) error
+		for _, m := range list {
+			afterID = m.ID
+			item.Scanned++
+			configuration := configurations[m.ConfigurationID]
+			fields := sensitiveFields(matchAuditConfig(configs, model.ObjectTypeConfiguration, configurationAuditData(configuration)))
+			variables, changed, err := r.rotate(m.Variables, fields, configuration.IsEncrypted)
+			if err != nil {
+				s.rotateFailed(ctx, &item, "configuration "+m.ConfigID+" render", err)
+				continue
+			}
+			if changed {
+				id := m.ID
+				updates = append(updates, func(ctx context.Context) error {
+					return s.sensitiveRepository.ConfigRenderVariablesUpdate(ctx, id, variables)
+				})
+			}
+		}
+		s.saveRotated(ctx, &item, updates)
+		if len(list) < sensitiveRotateBatchSize {
+			break
+		}
+	}
+	data.Items = append(data.Items, item)
+
+	for _, objectType := range auditObjectTypes {
+		item = v1.SensitiveRotateItem{ObjectType: objectType + "_history"}
+		afterID = 0
+		for {
+			list, err := s.sensitiveRepository.GetHistoriesAfter(ctx, filter, objectType, afterID, sensitiveRotateBatchSize)
+			if err != nil {
+				return nil, err
+			}
+			var updates []func(ctx context.Context) error
+			for i := range list {
+				m := &list[i]
+				afterID = m.ID
+				item.Scanned++
+				changed, err := r.rotateHistory(configs, objectType, m)
+				if err != nil {
+					s.rotateFailed(ctx, &item, fmt.Sprintf("%s history %d", objectType, m.ID), err)
+					continue
+				}
+				if changed {
+					updates = append(updates, func(ctx context.Context) error {
+						return s.sensitiveRepository.HistoryDataUpdate(ctx, objectType, m)
+					})
+				}
+			}
+			s.saveRotated(ctx, &item, updates)
+			if len(list) < sensitiveRotateBatchSize {
+				break
+			}
+		}
+		data.Items = append(data.Items, item)
+	}
+	s.logger.WithContext(ctx).Info("rotate sensitive data keys", zap.String("currentKey", data.CurrentKey), zap.Any("items", data.Items))
+	return data, nil
+}
+
+// loadConfigurations 补充读取缓存中还没有的配置，只保留匹配审计配置需要的字段
+func (s *sensitiveService) loadConfigurations(ctx context.Context, configurations map[uint]model.Configuration, ids []uint) error {
+	missing := make([]uint, 0, len(ids))
+	for _, id := range ids {
+		if _, ok := configurations[id]; !ok {
+			missing = append(missing, id)
+		}
+	}
+	list, err := s.sensitiveRepository.GetConfigurationsByIDs(ctx, missing)
+	if err != nil {
+		return err
+	}
+	for _, m := range list {
+		configurations[m.ID] = model.Configuration{TenantID: m.TenantID, IsEncrypted: m.IsEncrypted}
+	}
+	return nil
+}
+
+// rotateFailed 记录无法轮换的记录，跳过后继续处理其余记录
+func (s *sensitiveService) rotateFailed(ctx context.Context, item *v1.SensitiveRotateItem, object string, err error) {
+	item.Failed++
+	s.logger.WithContext(ctx).Error("rotate sensitive data failed", zap.String("object", object), zap.Error(err))
+}
+
+// saveRotated 在一个事务中保存一批轮换结果，保存失败时整批回滚并计为失败，重新执行时会再次处理这些记录
+func (s *sensitiveService) saveRotated(ctx context.Context, item *v1.SensitiveRotateItem, updates []func(ctx context.Context) error) {
+	if len(updates) == 0 {
+		return
+	}
+	err := s.tm.Transaction(ctx, func(ctx context.Context) error {
+		for _, update := range updates {
+			if err := update(ctx); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		item.Failed += len(updates)
+		s.logger.WithContext(ctx).Error("save rotated sensitive data failed", zap.String("objectType", item.ObjectType),
+			zap.Int("count", len(updates)), zap.Error(err))
+		return
+	}
+	item.Updated += len(updates)
+}
+
+// sensitiveRotator 主密钥轮换：加密尚未加密的敏感字段，并把其他主密钥加密的数据密钥改用当前主密钥加密
+type sensitiveRotator struct {
+	keyring   *envelope.Keyring
+	rewrapped map[string]string
+}
+
+// rotate 返回轮换后的数据以及是否有变化
+func (r *sensitiveRotator) rotate(data model.JSONMap, fields []string, all bool) (model.JSONMap, bool, error) {
+	if data == nil {
+		return nil, false, nil
+	}
+	sealed, _, err := sealSensitive(r.keyring, data, data, fields, all)
+	if err != nil {
+		return nil, false, err
+	}
+	out, err := r.rewrap(map[string]interface{}(sealed))
+	if err != nil {
+		return nil, false, err
+	}
+	result := model.JSONMap(out.(map[string]interface{}))
+	return result, !sameJSON(data, result), nil
+}
+
+// rotateHistory 加密历史快照中尚未加密的敏感字段并重新加密数据密钥。字段差异中的敏感字段分别加密前后的值
+func (r *sensitiveRotator) rotateHistory(configs []model.AuditConfig, objectType string, m *repository.HistoryRecord) (bool, error) {
+	data := m.AfterData
+	if data == nil {
+		data = m.BeforeData
+	}
+	fields := sensitiveFields(matchAuditConfig(configs, objectType, data))
+	changed := false
+	for _, target := range []*model.JSONMap{&m.BeforeData, &m.AfterData} {
+		if *target == nil {
+			continue
+		}
+		// 历史快照中只有资源的attributes是需要加密的扩展属性
+		snapshot := *target
+		if objectType == model.ObjectTypeResource {
+			if attributes, ok := asMap(snapshot["attributes"]); ok {
+				sealed, _, err := sealSensitive(r.keyring, attributes, attributes, fields, false)
+				if err != nil {
+					return false, err
+				}
+				snapshot = copyJSONMap(snapshot)
+				snapshot["attributes"] = map[string]interface{}(sealed)
+			}
+		}
+		out, err := r.rewrap(map[string]interface{}(snapshot))
+		if err != nil {
+			return false, err
+		}
+		result := model.JSONMap(out.(map[string]interface{}))
+		if !sameJSON(*target, result) {
+			*target, changed = result, true
+		}
+	}
+	if m.ChangedFields != nil {
+		changedFields := copyJSONMap(m.ChangedFields)
+		if objectType == model.ObjectTypeResource {
+			for path, value := range changedFields {
+				diff, ok := asMap(value)
+				if !ok || !strings.HasPrefix(path, "attributes.") || !isSensitiveKey(path, fields) {
+					continue
+				}
+				sealed := make(map[string]interface{}, len(diff))
+				for k, v := range diff {
+					sv, err := sealValue(r.keyring, v, v, true)
+					if err != nil {
+						return false, err
+					}
+					sealed[k] = sv
+				}
+				changedFields[path] = sealed
+			}
+		}
+		out, err := r.rewrap(map[string]interface{}(changedFields))
+		if err != nil {
+			return false, err
+		}
+		result := model.JSONMap(out.(map[string]interface{}))
+		if !sameJSON(m.ChangedFields, result) {
+			m.ChangedFields, changed = result, true
+		}
+	}
+	return changed, nil
+}
+
+func (r *sensitiveRotator) rewrap(v interface{}) (interface{}, error) {
+	switch value := v.(type) {
+	case string:
+		if !envelope.IsSealed(value) {
+			return value, nil
+		}
+		if rewrapped, ok := r.rewrapped[value]; ok {
+			return rewrapped, nil
+		}
+		rewrapped, _, err := r.keyring.Rewrap(value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", v1.ErrSensitiveDecryptFailed, err)
+		}
+		r.rewrapped[value] = rewrapped
+		return rewrapped, nil
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(value))
+		for k, item := range value {
+			rewrapped, err := r.rewrap(item)
+			if err != nil {
+				return nil, err
+			}
+			out[k] = rewrapped
+		}
+		return out, nil
+	case model.JSONMap:
+		return r.rewrap(map[string]interface{}(value))
+	case []interface{}:
+		out := make([]interface{}, len(value))
+		for i, item := range value {
+			rewrapped, err := r.rewrap(item)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = rewrapped
+		}
+		return out, nil
+	}
+	return v, nil
+}
+
+// sensitiveView 接口返回时处理敏感字段：默认脱敏，调用方请求查看明文且有权限时解密
+type sensitiveView struct {
+	keyring *envelope.Keyring
+	fields  []string
+	reveal  bool
+}
+
+// newSensitiveView 没有查看明文权限却请求reveal时返回ErrForbidden。脱敏按全部启用的审计配置中的敏感字段进行
+func newSensitiveView(ctx context.Context, keyring *envelope.Keyring, historyRepository repository.HistoryRepository, sensitiveRepository repository.SensitiveRepository, reveal bool) (*sensitiveView, error) {
+	if reveal {
+		var uid uint
+		if claims, ok := ctx.Value("claims").(*jwt.MyCustomClaims); ok {
+			uid = claims.UserId
+		}
+		allowed, err := sensitiveRepository.CanReveal(ctx, uid)
+		if err != nil {
+			return nil, err
+		}
+		if !allowed {
+			return nil, v1.ErrForbidden
+		}
+	}
+	configs, err := historyRepository.GetAuditConfigs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	fields := make([]string, 0)
+	for i := range configs {
+		fields = append(fields, sensitiveFields(&configs[i])...)
+	}
+	return &sensitiveView{keyring: keyring, fields: fields, reveal: reveal}, nil
+}
+
+// apply 返回处理后的副本，data本身不变
+func (v *sensitiveView) apply(data model.JSONMap) (model.JSONMap, error) {
+	if data == nil {
+		return nil, nil
+	}
+	if v.reveal {
+		return openSensitive(v.keyring, data)
+	}
+	return maskSensitive(data, v.fields), nil
+}
+
+// sensitiveFields 审计配置sensitive分组中的字段名
+func sensitiveFields(config *model.AuditConfig) []string {
+	fields := make([]string, 0)
+	if config == nil {
+		return fields
+	}
+	list, _ := config.MonitoredFields[sensitiveGroup].([]interface{})
+	for _, field := range list {
+		if name, ok := field.(string); ok && name != "" {
+			fields = append(fields, name)
+		}
+	}
+	return fields
+}
+
+// isSensitiveKey key为字段名或展开后的字段路径(attributes.ip_address)，按最后一段匹配敏感字段
+func isSensitiveKey(key string, fields []string) bool {
+	if i := strings.LastIndex(key, "."); i >= 0 {
+		key = key[i+1:]
+	}
+	for _, field := range fields {
+		if key == field {
+			return true
+		}
+	}
+	return false
+}
+
+// resourceAuditData 匹配审计配置用到的资源字段
+func resourceAuditData(m model.Resource) model.JSONMap {
+	return model.JSONMap{"type": m.Type, "tenant_id": m.TenantID}
+}
+
+func configurationAuditData(m model.Configuration) model.JSONMap {
+	return model.JSONMap{"tenant_id": m.TenantID}
+}
+
+// resourceSensitiveFields 资源匹配到的审计配置中的敏感字段
+func resourceSensitiveFields(ctx context.Context, historyRepository repository.HistoryRepository, m model.Resource) ([]string, error) {
+	configs, err := historyRepository.GetAuditConfigs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return sensitiveFields(matchAuditConfig(configs, model.ObjectTypeResource, resourceAuditData(m))), nil
+}
+
+// sealSensitive 返回data的副本，其中任意层级的敏感字段加密保存，all为true时加密全部顶层字段。
+// 值为脱敏占位符时沿用old中的原值，明文与old中的原值相同时沿用原密文，避免未修改的字段每次保存都产生差异；
+// 未配置主密钥时敏感字段以明文保存。第二个返回值表示结果是否包含密文
+func sealSensitive(keyring *envelope.Keyring, data, old model.JSONMap, fields []string, all bool) (model.JSONMap, bool, error) {
+	if data == nil {
+		return nil, false, nil
+	}
+	out, err := sealMap(keyring, data, old, fields, all)
+	if err != nil {
+		return nil, false, err
+	}
+	result := model.JSONMap(out)
+	return result, containsSealed(result), nil
+}
+
+func sealMap(keyring *envelope.Keyring, data, old map[string]interface{}, fields []string, all bool) (map[string]interface{}, error) {
+	out := make(map[string]interface{}, len(data))
+	for key, value := range data {
+		oldValue, hasOld := old[key]
+		if all || isSensitiveKey(key, fields) {
+			sealed, err := sealValue(keyring, value, oldValue, hasOld)
+			if err != nil {
+				return nil, err
+			}
+			out[key] = sealed
+			continue
+		}
+		nested, err := sealNested(keyring, value, oldValue, fields)
+		if err != nil {
+			return nil, err
+		}
+		out[key] = nested
+	}
+	return out, nil
+}
+
+// sealNested 继续在嵌套对象和数组中查找敏感字段，数组元素按下标对应原值
+func sealNested(keyring *envelope.Keyring, value, oldValue interface{}, fields []string) (interface{}, error) {
+	if nested, ok := asMap(value); ok {
+		oldNested, _ := asMap(oldValue)
+		return sealMap(keyring, nested, oldNested, fields, false)
+	}
+	list, ok := value.([]interface{})
+	if !ok {
+		return value, nil
+	}
+	oldList, _ := oldValue.([]interface{})
+	out := make([]interface{}, len(list))
+	for i, item := range list {
+		var oldItem interface{}
+		if i < len(oldList) {
+			oldItem = oldList[i]
+		}
+		sealed, err := sealNested(keyring, item, oldItem, fields)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = sealed
+	}
+	return out, nil
+}
+
+// sealValue 加密一个敏感字段的值，值按JSON序列化后加密以保留类型
+func sealValue(keyring *envelope.Keyring, value, oldValue interface{}, hasOld bool) (interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+	if s, ok := value.(string); ok && s == sensitiveMask && hasOld {
+		return oldValue, nil
+	}
+	plain := value
+	if s, ok := value.(string); ok && envelope.IsSealed(s) {
+		opened, err := openValue(keyring, s)
+		if err != nil {
+			return nil, err
+		}
+		plain = opened
+	}
+	if !keyring.Enabled() {
+		return plain, nil
+	}
+	raw, err := json.Marshal(plain)
+	if err != nil {
+		return nil, err
+	}
+	if s, ok := oldValue.(string); ok && envelope.IsSealed(s) {
+		if previous, err := openValue(keyring, s); err == nil {
+			if prevRaw, err := json.Marshal(previous); err == nil && bytes.Equal(prevRaw, raw) {
+				return s, nil
+			}
+		}
+	}
+	return keyring.Seal(raw)
+}
+
+func openValue(keyring *envelope.Keyring, s string) (interface{}, error) {
+	raw, err := keyring.Open(s)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", v1.ErrSensitiveDecryptFailed, err)
+	}
+	var value interface{}
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return nil, fmt.Errorf("%w: %v", v1.ErrSensitiveDecryptFailed, err)
+	}
+	return value, nil
+}
+
+// openSensitive 返回解密全部密文后的副本
+func openSensitive(keyring *envelope.Keyring, data model.JSONMap) (model.JSONMap, error) {
+	if data == nil {
+		return nil, nil
+	}
+	out, err := openAny(keyring, map[string]interface{}(data))
+	if err != nil {
+		return nil, err
+	}
+	return model.JSONMap(out.(map[string]interface{})), nil
+}
+
+func openAny(keyring *envelope.Keyring, v interface{}) (interface{}, error) {
+	switch value := v.(type) {
+	case string:
+		if envelope.IsSealed(value) {
+			return openValue(keyring, value)
+		}
+	case model.JSONMap:
+		return openAny(keyring, map[string]interface{}(value))
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(value))
+		for k, item := range value {
+			opened, err := openAny(keyring, item)
+			if err != nil {
+				return nil, err
+			}
+			out[k] = opened
+		}
+		return out, nil
+	case []interface{}:
+		out := make([]interface{}, len(value))
+		for i, item := range value {
+			opened, err := openAny(keyring, item)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = opened
+		}
+		return out, nil
+	}
+	return v, nil
+}
+
+// maskSensitive 返回脱敏后的副本：敏感字段(包括字段差异中以敏感字段结尾的路径)的值和全部密文替换为占位符，nil保持不变
+func maskSensitive(data model.JSONMap, fields []string) model.JSONMap {
+	if data == nil {
+		return nil
+	}
+	return model.JSONMap(maskAny(map[string]interface{}(data), fields, false).(map[string]interface{}))
+}
+
+func maskAny(v interface{}, fields []string, sensitive bool) interface{} {
+	switch value := v.(type) {
+	case nil:
+		return nil
+	case model.JSONMap:
+		return maskAny(map[string]interface{}(value), fields, sensitive)
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(value))
+		for k, item := range value {
+			out[k] = maskAny(item, fields, sensitive || isSensitiveKey(k, fields))
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(value))
+		for i, item := range value {
+			out[i] = maskAny(item, fields, sensitive)
+		}
+		return out
+	case string:
+		if sensitive || envelope.IsSealed(value) {
+			return sensitiveMask
+		}
+		return value
+	}
+	if sensitive {
+		return sensitiveMask
+	}
+	return v
+}
+
+// collectSensitive 收集密文和敏感字段的明文，键为字段路径
+func collectSensitive(keyring *envelope.Keyring, prefix string, v interface{}, fields []string, out map[string]interface{}) error {
+	switch value := v.(type) {
+	case string:
+		if envelope.IsSealed(value) {
+			opened, err := openValue(keyring, value)
+			if err != nil {
+				return err
+			}
+			out[prefix] = opened
+		}
+		return nil
+	case model.JSONMap:
+		return collectSensitive(keyring, prefix, map[string]interface{}(value), fields, out)
+	case map[string]interface{}:
+		for k, item := range value {
+			path := k
+			if prefix != "" {
+				path = prefix + "." + k
+			}
+			if isSensitiveKey(k, fields) {
+				opened, err := openAny(keyring, item)
+				if err != nil {
+					return err
+				}
+				out[path] = opened
+				continue
+			}
+			if err := collectSensitive(keyring, path, item, fields, out); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for i, item := range value {
+			if err := collectSensitive(keyring, fmt.Sprintf("%s[%d]", prefix, i), item, fields, out); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func containsSealed(v interface{}) bool {
+	switch value := v.(type) {
+	case string:
+		return envelope.IsSealed(value)
+	case model.JSONMap:
+		return containsSealed(map[string]interface{}(value))
+	case map[string]interface{}:
+		for _, item := range value {
+			if containsSealed(item) {
+				return true
+			}
+		}
+	case []interface{}:
+		for _, item := range value {
+			if containsSealed(item) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func asMap(v interface{}) (map[string]interface{}, bool) {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		return value, true
+	case model.JSONMap:
+		return value, true
+	}
+	return nil, false
+}
+
+func copyJSONMap(data model.JSONMap) model.JSONMap {
+	out := make(model.JSONMap, len(data))
+	for k, v := range data {
+		out[k] = v
+	}
+	return out
+}
+
+func sameJSON(a, b interface{}) bool {
+	rawA, errA := json.Marshal(a)
+	rawB, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(rawA, rawB)
+}
@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"nunu-layout-admin/internal/model"
+	"nunu-layout-admin/internal/repository"
+	"nunu-layout-admin/pkg/envelope"
+	"nunu-layout-admin/pkg/log"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// countingTransaction 记录开启的事务数
+type countingTransaction struct {
+	count int
+}
+
+func (t *countingTransaction) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	t.count++
+	return fn(ctx)
+}
+
+// fakeSensitiveRepository 内存中的资源和配置，配置保存始终失败；未实现的方法调用时会panic
+type fakeSensitiveRepository struct {
+	repository.SensitiveRepository
+	filter         repository.SensitiveRotateFilter
+	resources      []model.Resource
+	configurations []model.Configuration
+	saved          map[uint]model.JSONMap
+}
+
+func (r *fakeSensitiveRepository) GetResourcesAfter(ctx context.Context, filter repository.SensitiveRotateFilter, afterID uint, limit int) ([]model.Resource, error) {
+	r.filter = filter
+	if afterID > 0 {
+		return nil, nil
+	}
+	return r.resources, nil
+}
+
+func (r *fakeSensitiveRepository) GetConfigurationsAfter(ctx context.Context, filter repository.SensitiveRotateFilter, afterID uint, limit int) ([]model.Configuration, error) {
+	if afterID > 0 {
+		return nil, nil
+	}
+	return r.configurations, nil
+}
+
+func (r *fakeSensitiveRepository) GetConfigRevisionsAfter(ctx context.Context, filter repository.SensitiveRotateFilter, afterID uint, limit int) ([]model.ConfigurationRevision, error) {
+	return nil, nil
+}
+
+func (r *fakeSensitiveRepository) GetConfigRendersAfter(ctx context.Context, filter repository.SensitiveRotateFilter, afterID uint, limit int) ([]model.ConfigurationRender, error) {
+	return nil, nil
+}
+
+func (r *fakeSensitiveRepository) GetHistoriesAfter(ctx context.Context, filter repository.SensitiveRotateFilter, objectType string, afterID uint, limit int) ([]repository.HistoryRecord, error) {
+	return nil, nil
+}
+
+func (r *fakeSensitiveRepository) GetConfigurationsByIDs(ctx context.Context, ids []uint) ([]model.Configuration, error) {
+	return nil, nil
+}
+
+func (r *fakeSensitiveRepository) ResourceAttributesUpdate(ctx context.Context, id uint, attributes model.JSONMap) error {
+	r.saved[id] = attributes
+	return nil
+}
+
+func (r *fakeSensitiveRepository) ConfigurationDataUpdate(ctx context.Context, id uint, data model.JSONMap) error {
+	return errors.New("database is locked")
+}
+
+func newTestKeyring(t *testing.T, current string, ids ...string) *envelope.Keyring {
+	t.Helper()
+	conf := viper.New()
+	keys := make(map[string]interface{})
+	for i, id := range ids {
+		keys[id] = base64.StdEncoding.EncodeToString([]byte(strings.Repeat(string(rune('a'+i)), 32)))
+	}
+	conf.Set("security.encryption.master_keys", keys)
+	conf.Set("security.encryption.current_key", current)
+	return envelope.NewKeyring(conf)
+}
+
+func TestRotateKeys(t *testing.T) {
+	old := newTestKeyring(t, "k1", "k1")
+	sealed, err := old.Seal([]byte(`"s3cret"`))
+	if err != nil {
+		t.Fatalf("Seal: %v", err)
+	}
+	unknown := newTestKeyring(t, "k9", "k9")
+	lost, err := unknown.Seal([]byte(`"lost"`))
+	if err != nil {
+		t.Fatalf("Seal: %v", err)
+	}
+
+	repo := &fakeSensitiveRepository{
+		resources: []model.Resource{
+			{Model: gorm.Model{ID: 1}, ResourceID: "res-001", Attributes: model.JSONMap{"password": sealed}},
+			{Model: gorm.Model{ID: 2}, ResourceID: "res-002", Attributes: model.JSONMap{"password": lost}},
+		},
+		configurations: []model.Configuration{
+			{Model: gorm.Model{ID: 1}, ConfigID: "cfg-001", ConfigData: model.JSONMap{"token": sealed}},
+		},
+		saved: make(map[uint]model.JSONMap),
+	}
+	tm := &countingTransaction{}
+	s := &sensitiveService{
+		Service:             &Service{logger: &log.Logger{Logger: zap.NewNop()}, tm: tm},
+		keyring:             newTestKeyring(t, "k2", "k1", "k2"),
+		sensitiveRepository: repo,
+		historyRepository:   &fakeAuditHistoryRepository{},
+	}
+	data, err := s.RotateKeys(context.Background())
+	if err != nil {
+		t.Fatalf("RotateKeys: %v", err)
+	}
+	if repo.filter.CurrentKey != "k2" || len(repo.filter.RetiredKeys) != 1 || repo.filter.RetiredKeys[0] != "k1" {
+		t.Errorf("filter = %+v, want current k2 and retired [k1]", repo.filter)
+	}
+	// 资源和配置各有一批需要保存
+	if tm.count != 2 {
+		t.Errorf("transactions = %d, want 2", tm.count)
+	}
+
+	resource, configuration := data.Items[0], data.Items[1]
+	if resource.Scanned != 2 || resource.Updated != 1 || resource.Failed != 1 {
+		t.Errorf("resource item = %+v, want scanned 2, updated 1, failed 1", resource)
+	}
+	if configuration.Scanned != 1 || configuration.Updated != 0 || configuration.Failed != 1 {
+		t.Errorf("configuration item = %+v, want scanned 1, updated 0, failed 1", configuration)
+	}
+	password, _ := repo.saved[1]["password"].(string)
+	if !strings.HasPrefix(password, envelope.Prefix+"k2:") {
+		t.Errorf("rotated password = %q, want sealed with k2", password)
+	}
+	if _, ok := repo.saved[2]; ok {
+		t.Error("resource sealed with an unknown key was saved")
+	}
+}
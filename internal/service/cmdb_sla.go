@@ -0,0 +1,412 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	v1 "nunu-layout-admin/api/v1"
+	"nunu-layout-admin/internal/model"
+	"nunu-layout-admin/internal/repository"
+	"sort"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// SLA统计周期
+const (
+	SLAPeriodDay   = "day"
+	SLAPeriodWeek  = "week"
+	SLAPeriodMonth = "month"
+)
+
+type SLAService interface {
+	// GetSLAReport 读取已保存的SLA统计，低于目标的服务排在前面
+	GetSLAReport(ctx context.Context, req *v1.SLAReportRequest) (*v1.SLAReportResponseData, error)
+	// CalculateSLA 立即计算并保存指定窗口的SLA统计
+	CalculateSLA(ctx context.Context, req *v1.SLACalculateRequest) (*v1.SLAReportResponseData, error)
+	// CalculateCurrentSLA 计算当前的日、周、月窗口，上一个窗口尚未在结束后计算过时一并计算，返回计算的窗口数
+	CalculateCurrentSLA(ctx context.Context) (int, error)
+}
+
+func NewSLAService(
+	service *Service,
+	conf *viper.Viper,
+	slaRepository repository.SLARepository,
+) SLAService {
+	return &slaService{
+		Service:       service,
+		conf:          conf,
+		slaRepository: slaRepository,
+	}
+}
+
+type slaService struct {
+	*Service
+	conf          *viper.Viper
+	slaRepository repository.SLARepository
+}
+
+// slaWindow 统计窗口[start, end)，date为日/周窗口的开始日期或月窗口的年月
+type slaWindow struct {
+	period string
+	date   string
+	start  time.Time
+	end    time.Time
+}
+
+// slaTransition 服务健康状态的一次变化
+type slaTransition struct {
+	at     time.Time
+	status string
+	reason string
+}
+
+// slaStatistics 保存在DataStatistics.Statistics中的SLA明细
+type slaStatistics struct {
+	ServiceID       string      `json:"service_id"`
+	Name            string      `json:"name"`
+	SLATarget       float64     `json:"sla_target"`
+	Availability    *float64    `json:"availability"`
+	BelowTarget     bool        `json:"below_target"`
+	UptimeSeconds   int64       `json:"uptime_seconds"`
+	DowntimeSeconds int64       `json:"downtime_seconds"`
+	DegradedSeconds int64       `json:"degraded_seconds"`
+	UnknownSeconds  int64       `json:"unknown_seconds"`
+	WindowStart     time.Time   `json:"window_start"`
+	WindowEnd       time.Time   `json:"window_end"`
+	Outages         []slaOutage `json:"outages"`
+}
+
+type slaOutage struct {
+	Start   time.Time `json:"start"`
+	End     time.Time `json:"end"`
+	Reason  string    `json:"reason"`
+	Ongoing bool      `json:"ongoing"`
+}
+
+func (s *slaService) GetSLAReport(ctx context.Context, req *v1.SLAReportRequest) (*v1.SLAReportResponseData, error) {
+	window, err := s.requestWindow(req.Period, req.Date)
+	if err != nil {
+		return nil, err
+	}
+	list, err := s.slaRepository.GetSLAStatistics(ctx, window.period, window.date, req.ServiceID)
+	if err != nil {
+		return nil, err
+	}
+	data := newSLAReport(window)
+	for _, m := range list {
+		var stat slaStatistics
+		raw, err := json.Marshal(m.Statistics)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(raw, &stat); err != nil {
+			return nil, err
+		}
+		if req.BelowTargetOnly && !stat.BelowTarget {
+			continue
+		}
+		data.add(stat, m.CalcTime)
+	}
+	data.sort()
+	return data.SLAReportResponseData, nil
+}
+
+func (s *slaService) CalculateSLA(ctx context.Context, req *v1.SLACalculateRequest) (*v1.SLAReportResponseData, error) {
+	window, err := s.requestWindow(req.Period, req.Date)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	stats, err := s.calculate(ctx, window, now)
+	if err != nil {
+		return nil, err
+	}
+	data := newSLAReport(window)
+	for _, stat := range stats {
+		data.add(stat, now)
+	}
+	data.sort()
+	return data.SLAReportResponseData, nil
+}
+
+func (s *slaService) CalculateCurrentSLA(ctx context.Context) (int, error) {
+	now := time.Now()
+	loc := s.location()
+	count := 0
+	for _, period := range []string{SLAPeriodDay, SLAPeriodWeek, SLAPeriodMonth} {
+		current := newSLAWindow(period, now.In(loc))
+		previous := newSLAWindow(period, current.start.Add(-time.Nanosecond))
+		list, err := s.slaRepository.GetSLAStatistics(ctx, previous.period, previous.date, "")
+		if err != nil {
+			return count, err
+		}
+		if len(list) == 0 || list[0].CalcTime.Before(previous.end) {
+			if _, err := s.calculate(ctx, previous, now); err != nil {
+				return count, err
+			}
+			count++
+		}
+		if _, err := s.calculate(ctx, current, now); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
+
+// calculate 按服务健康状态的变化记录计算窗口内各服务的可用率并保存，窗口未结束时计算到now
+func (s *slaService) calculate(ctx context.Context, window slaWindow, now time.Time) ([]slaStatistics, error) {
+	if !window.start.Before(now) {
+		return nil, v1.ErrSLAWindowNotStarted
+	}
+	until := window.end
+	if now.Before(until) {
+		until = now
+	}
+	services, err := s.slaRepository.GetSLAServices(ctx)
+	if err != nil {
+		return nil, err
+	}
+	last, err := s.slaRepository.GetLastHealthTransitions(ctx, window.start)
+	if err != nil {
+		return nil, err
+	}
+	between, err := s.slaRepository.GetHealthTransitions(ctx, window.start, until)
+	if err != nil {
+		return nil, err
+	}
+	initial := make(map[string]slaTransition, len(last))
+	for _, m := range last {
+		initial[m.ServiceUUID] = toSLATransition(m)
+	}
+	transitions := make(map[string][]slaTransition)
+	for _, m := range between {
+		transitions[m.ServiceUUID] = append(transitions[m.ServiceUUID], toSLATransition(m))
+	}
+
+	existing, err := s.slaRepository.GetSLAStatistics(ctx, window.period, window.date, "")
+	if err != nil {
+		return nil, err
+	}
+	stored := make(map[string]model.DataStatistics, len(existing))
+	for _, m := range existing {
+		stored[m.Dimension] = m
+	}
+	stats := make([]slaStatistics, 0, len(services))
+	for _, service := range services {
+		started := time.Now()
+		stat := measureSLA(initial[service.ServiceID], transitions[service.ServiceID], window.start, until, until.Before(window.end))
+		stat.ServiceID = service.ServiceID
+		stat.Name = service.Name
+		stat.SLATarget = service.SLATarget
+		stat.WindowStart = window.start
+		stat.WindowEnd = window.end
+		stat.BelowTarget = stat.Availability != nil && service.SLATarget > 0 && *stat.Availability < service.SLATarget
+		stats = append(stats, stat)
+
+		statistics, err := toJSONMap(stat)
+		if err != nil {
+			return nil, err
+		}
+		m := model.DataStatistics{
+			StatType:      model.StatTypePerformance,
+			Dimension:     repository.SLADimensionPrefix + service.ServiceID,
+			Period:        window.period,
+			Date:          window.date,
+			TenantID:      service.TenantID,
+			BusinessID:    service.BusinessID,
+			TotalCount:    stat.UptimeSeconds + stat.DowntimeSeconds,
+			ActiveCount:   stat.UptimeSeconds,
+			InactiveCount: stat.DowntimeSeconds,
+			Statistics:    statistics,
+			CalcTime:      now,
+			CalcDuration:  time.Since(started).Milliseconds(),
+			DataSource:    "cmdb_service_health_transitions",
+			IsLatest:      true,
+			Version:       1,
+			Description:   "服务SLA可用率",
+		}
+		if old, ok := stored[m.Dimension]; ok {
+			m.ID = old.ID
+			m.Version = old.Version + 1
+			err = s.slaRepository.StatisticsUpdate(ctx, &m)
+		} else {
+			err = s.slaRepository.StatisticsCreate(ctx, &m)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return stats, nil
+}
+
+// requestWindow 按请求的周期和日期确定窗口，日期为空时为当前窗口
+func (s *slaService) requestWindow(period, date string) (slaWindow, error) {
+	loc := s.location()
+	at := time.Now().In(loc)
+	if date != "" {
+		t, err := time.ParseInLocation("2006-01-02", date, loc)
+		if err != nil {
+			return slaWindow{}, v1.ErrBadRequest
+		}
+		at = t
+	}
+	return newSLAWindow(period, at), nil
+}
+
+// location 日、周、月窗口按cmdb.sla.timezone划分，默认为服务器本地时区
+func (s *slaService) location() *time.Location {
+	name := s.conf.GetString("cmdb.sla.timezone")
+	if name == "" {
+		return time.Local
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return time.Local
+	}
+	return loc
+}
+
+// newSLAWindow 返回包含t的窗口，周从周一开始
+func newSLAWindow(period string, t time.Time) slaWindow {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	switch period {
+	case SLAPeriodWeek:
+		start := day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+		return slaWindow{period: period, date: start.Format("2006-01-02"), start: start, end: start.AddDate(0, 0, 7)}
+	case SLAPeriodMonth:
+		start := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+		return slaWindow{period: period, date: start.Format("2006-01"), start: start, end: start.AddDate(0, 1, 0)}
+	}
+	return slaWindow{period: SLAPeriodDay, date: day.Format("2006-01-02"), start: day, end: day.AddDate(0, 0, 1)}
+}
+
+// measureSLA 从窗口开始时的状态initial起按状态变化累计[start, until)内各状态的时长。down计为不可用，
+// healthy和degraded计为正常，其他状态(unknown、服务不存在或没有变化记录)不参与可用率计算
+func measureSLA(initial slaTransition, transitions []slaTransition, start, until time.Time, ongoing bool) slaStatistics {
+	status := initial.status
+	stat := slaStatistics{Outages: make([]slaOutage, 0)}
+	var up, down, degraded, unknown time.Duration
+	var outage *slaOutage
+	from := start
+	accumulate := func(to time.Time) {
+		if !to.After(from) {
+			return
+		}
+		switch status {
+		case model.HealthStatusDown:
+			down += to.Sub(from)
+		case model.HealthStatusHealthy:
+			up += to.Sub(from)
+		case model.HealthStatusDegraded:
+			up += to.Sub(from)
+			degraded += to.Sub(from)
+		default:
+			unknown += to.Sub(from)
+		}
+		from = to
+	}
+	if status == model.HealthStatusDown {
+		outage = &slaOutage{Start: start, Reason: initial.reason}
+	}
+	for _, transition := range transitions {
+		at := transition.at
+		if at.Before(start) {
+			at = start
+		}
+		if at.After(until) {
+			at = until
+		}
+		accumulate(at)
+		if transition.status == status {
+			continue
+		}
+		if status == model.HealthStatusDown {
+			outage.End = at
+			stat.Outages = append(stat.Outages, *outage)
+			outage = nil
+		}
+		status = transition.status
+		if status == model.HealthStatusDown {
+			outage = &slaOutage{Start: at, Reason: transition.reason}
+		}
+	}
+	accumulate(until)
+	if outage != nil {
+		outage.End = until
+		outage.Ongoing = ongoing
+		stat.Outages = append(stat.Outages, *outage)
+	}
+	stat.UptimeSeconds = int64(up.Seconds())
+	stat.DowntimeSeconds = int64(down.Seconds())
+	stat.DegradedSeconds = int64(degraded.Seconds())
+	stat.UnknownSeconds = int64(unknown.Seconds())
+	if measured := up + down; measured > 0 {
+		availability := float64(up) / float64(measured)
+		stat.Availability = &availability
+	}
+	return stat
+}
+
+func toSLATransition(m model.ServiceHealthTransition) slaTransition {
+	return slaTransition{at: m.ChangeTime, status: m.ToStatus, reason: m.Reason}
+}
+
+type slaReport struct {
+	*v1.SLAReportResponseData
+}
+
+func newSLAReport(window slaWindow) slaReport {
+	return slaReport{&v1.SLAReportResponseData{
+		Period:      window.period,
+		Date:        window.date,
+		WindowStart: window.start,
+		WindowEnd:   window.end,
+		Items:       make([]v1.SLAReportItem, 0),
+	}}
+}
+
+func (r slaReport) add(stat slaStatistics, calcTime time.Time) {
+	item := v1.SLAReportItem{
+		ServiceID:       stat.ServiceID,
+		Name:            stat.Name,
+		SLATarget:       stat.SLATarget,
+		Availability:    stat.Availability,
+		BelowTarget:     stat.BelowTarget,
+		UptimeSeconds:   stat.UptimeSeconds,
+		DowntimeSeconds: stat.DowntimeSeconds,
+		DegradedSeconds: stat.DegradedSeconds,
+		UnknownSeconds:  stat.UnknownSeconds,
+		Outages:         make([]v1.SLAOutageItem, 0, len(stat.Outages)),
+		CalcTime:        calcTime,
+	}
+	for _, outage := range stat.Outages {
+		item.Outages = append(item.Outages, v1.SLAOutageItem{
+			Start:           outage.Start,
+			End:             outage.End,
+			DurationSeconds: int64(outage.End.Sub(outage.Start).Seconds()),
+			Reason:          outage.Reason,
+			Ongoing:         outage.Ongoing,
+		})
+	}
+	r.Total++
+	if item.BelowTarget {
+		r.BelowTarget++
+	}
+	r.Items = append(r.Items, item)
+}
+
+// sort 低于目标的服务在前，按可用率从低到高
+func (r slaReport) sort() {
+	sort.SliceStable(r.Items, func(i, j int) bool {
+		a, b := r.Items[i], r.Items[j]
+		if a.BelowTarget != b.BelowTarget {
+			return a.BelowTarget
+		}
+		if a.BelowTarget && *a.Availability != *b.Availability {
+			return *a.Availability < *b.Availability
+		}
+		return false
+	})
+}
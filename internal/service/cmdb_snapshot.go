@@ -0,0 +1,1184 @@
+package service
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"io"
+	v1 "nunu-layout-admin/api/v1"
+	"nunu-layout-admin/internal/model"
+	"nunu-layout-admin/internal/repository"
+	"nunu-layout-admin/pkg/envelope"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+type SnapshotService interface {
+	CreateSnapshot(ctx context.Context, req *v1.SnapshotCreateRequest) (*v1.SnapshotDataItem, error)
+	GetSnapshots(ctx context.Context, req *v1.GetSnapshotsRequest) (*v1.GetSnapshotsResponseData, error)
+	VerifySnapshot(ctx context.Context, req *v1.SnapshotVerifyRequest) (*v1.SnapshotVerifyResponseData, error)
+	// PurgeExpiredSnapshots 删除已过期快照的文件和记录，返回删除的快照数
+	PurgeExpiredSnapshots(ctx context.Context) (int, error)
+	DiffSnapshots(ctx context.Context, req *v1.SnapshotDiffRequest) (*v1.SnapshotDiffResponseData, error)
+	RestoreSnapshot(ctx context.Context, req *v1.SnapshotRestoreRequest) (*v1.SnapshotRestoreResponseData, error)
+}
+
+func NewSnapshotService(
+	service *Service,
+	conf *viper.Viper,
+	keyring *envelope.Keyring,
+	snapshotRepository repository.SnapshotRepository,
+	historyRepository repository.HistoryRepository,
+	resourceRepository repository.ResourceRepository,
+	serviceRepository repository.ServiceRepository,
+	businessRepository repository.BusinessRepository,
+	relationRepository repository.RelationRepository,
+	pathRepository repository.PathRepository,
+	sensitiveRepository repository.SensitiveRepository,
+) SnapshotService {
+	return &snapshotService{
+		Service:             service,
+		conf:                conf,
+		keyring:             keyring,
+		snapshotRepository:  snapshotRepository,
+		historyRepository:   historyRepository,
+		resourceRepository:  resourceRepository,
+		serviceRepository:   serviceRepository,
+		businessRepository:  businessRepository,
+		relationRepository:  relationRepository,
+		pathRepository:      pathRepository,
+		sensitiveRepository: sensitiveRepository,
+	}
+}
+
+type snapshotService struct {
+	*Service
+	conf                *viper.Viper
+	keyring             *envelope.Keyring
+	snapshotRepository  repository.SnapshotRepository
+	historyRepository   repository.HistoryRepository
+	resourceRepository  repository.ResourceRepository
+	serviceRepository   repository.ServiceRepository
+	businessRepository  repository.BusinessRepository
+	relationRepository  repository.RelationRepository
+	pathRepository      repository.PathRepository
+	sensitiveRepository repository.SensitiveRepository
+}
+
+// snapshotFile 快照文件的内容(gzip压缩的JSON)。全量快照保存范围内对象的完整数据，
+// 对象数据与变更历史的快照格式一致；增量快照只保存基准快照之后的变更历史
+type snapshotFile struct {
+	SnapshotID         string           `json:"snapshot_id"`
+	SnapshotType       string           `json:"snapshot_type"`
+	SnapshotTime       time.Time        `json:"snapshot_time"`
+	BaseSnapshotID     string           `json:"base_snapshot_id,omitempty"`
+	BaseSnapshotTime   *time.Time       `json:"base_snapshot_time,omitempty"`
+	TenantID           string           `json:"tenant_id"`
+	BusinessID         string           `json:"business_id"`
+	Resources          []model.JSONMap  `json:"resources,omitempty"`
+	Services           []model.JSONMap  `json:"services,omitempty"`
+	Businesses         []model.JSONMap  `json:"businesses,omitempty"`
+	ResourceRelations  []model.JSONMap  `json:"resource_relations,omitempty"`
+	UniversalRelations []model.JSONMap  `json:"universal_relations,omitempty"`
+	Changes            []snapshotChange `json:"changes,omitempty"`
+}
+
+type snapshotChange struct {
+	ObjectType string        `json:"object_type"`
+	ObjectID   string        `json:"object_id"`
+	ChangeType string        `json:"change_type"`
+	ChangeTime time.Time     `json:"change_time"`
+	Version    int64         `json:"version"`
+	BeforeData model.JSONMap `json:"before_data"`
+	AfterData  model.JSONMap `json:"after_data"`
+}
+
+// CreateSnapshot 生成快照文件并记录数量、大小和SHA-256校验和，写文件失败时快照标记为failed
+func (s *snapshotService) CreateSnapshot(ctx context.Context, req *v1.SnapshotCreateRequest) (*v1.SnapshotDataItem, error) {
+	id, err := s.sid.GenString()
+	if err != nil {
+		return nil, err
+	}
+	m := &model.ResourceSnapshot{
+		SnapshotID:   "snap-" + id,
+		SnapshotTime: time.Now(),
+		SnapshotType: req.SnapshotType,
+		TenantID:     req.TenantID,
+		BusinessID:   req.BusinessID,
+		Status:       model.SnapshotStatusCreating,
+		Description:  req.Description,
+	}
+	m.CreatorID, m.CreatorName, _ = requestOperator(ctx, s.historyRepository)
+	retentionDays := req.RetentionDays
+	if retentionDays == 0 {
+		retentionDays = s.conf.GetInt("cmdb.snapshot.retention_days")
+	}
+	if retentionDays > 0 {
+		expiresAt := m.SnapshotTime.AddDate(0, 0, retentionDays)
+		m.ExpiresAt = &expiresAt
+	}
+
+	var base model.ResourceSnapshot
+	if m.SnapshotType == model.SnapshotTypeIncremental {
+		base, err = s.snapshotRepository.GetLatestFullSnapshot(ctx, m.TenantID, m.BusinessID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, v1.ErrSnapshotBaseNotFound
+			}
+			return nil, err
+		}
+		m.BaseSnapshotID = base.SnapshotID
+	}
+	if err := s.snapshotRepository.SnapshotCreate(ctx, m); err != nil {
+		return nil, err
+	}
+
+	file := &snapshotFile{
+		SnapshotID:     m.SnapshotID,
+		SnapshotType:   m.SnapshotType,
+		SnapshotTime:   m.SnapshotTime,
+		BaseSnapshotID: m.BaseSnapshotID,
+		TenantID:       m.TenantID,
+		BusinessID:     m.BusinessID,
+	}
+	if m.SnapshotType == model.SnapshotTypeIncremental {
+		file.BaseSnapshotTime = &base.SnapshotTime
+		err = s.buildIncremental(ctx, m, base.SnapshotTime, file)
+	} else {
+		err = s.buildFull(ctx, m, file)
+	}
+	if err == nil {
+		m.StoragePath = filepath.Join(s.snapshotDir(), m.SnapshotID+".json.gz")
+		m.FileSize, m.Checksum, err = writeSnapshotFile(m.StoragePath, file)
+	}
+	if err != nil {
+		m.Status = model.SnapshotStatusFailed
+		if updateErr := s.snapshotRepository.SnapshotUpdate(ctx, m); updateErr != nil {
+			s.logger.WithContext(ctx).Error("SnapshotUpdate error", zap.Error(updateErr))
+		}
+		return nil, err
+	}
+	m.Status = model.SnapshotStatusCompleted
+	if err := s.snapshotRepository.SnapshotUpdate(ctx, m); err != nil {
+		return nil, err
+	}
+	item := toSnapshotDataItem(*m)
+	return &item, nil
+}
+
+// buildFull 读取范围内的资源、服务、业务，以及至少一端在范围内的资源关系和通用关系
+func (s *snapshotService) buildFull(ctx context.Context, m *model.ResourceSnapshot, file *snapshotFile) error {
+	resources, err := s.snapshotRepository.GetScopedResources(ctx, m.TenantID, m.BusinessID)
+	if err != nil {
+		return err
+	}
+	services, err := s.snapshotRepository.GetScopedServices(ctx, m.TenantID, m.BusinessID)
+	if err != nil {
+		return err
+	}
+	businesses, err := s.snapshotRepository.GetScopedBusinesses(ctx, m.TenantID, m.BusinessID)
+	if err != nil {
+		return err
+	}
+	// 全局快照不限制关系的端点
+	var resourceIDs []uint
+	var objects map[string][]string
+	if m.TenantID != "" || m.BusinessID != "" {
+		resourceIDs = make([]uint, 0, len(resources))
+		objects = make(map[string][]string)
+		for _, resource := range resources {
+			resourceIDs = append(resourceIDs, resource.ID)
+			objects[model.ObjectTypeResource] = append(objects[model.ObjectTypeResource], resource.ResourceID)
+		}
+		for _, service := range services {
+			objects[model.ObjectTypeService] = append(objects[model.ObjectTypeService], service.ServiceID)
+		}
+		for _, business := range businesses {
+			objects[model.ObjectTypeBusiness] = append(objects[model.ObjectTypeBusiness], business.BusinessID)
+		}
+	}
+	resourceRelations, err := s.snapshotRepository.GetScopedResourceRelations(ctx, resourceIDs)
+	if err != nil {
+		return err
+	}
+	universalRelations, err := s.snapshotRepository.GetScopedUniversalRelations(ctx, objects)
+	if err != nil {
+		return err
+	}
+
+	for _, resource := range resources {
+		data, err := resourceSnapshot(resource)
+		if err != nil {
+			return err
+		}
+		file.Resources = append(file.Resources, data)
+	}
+	for _, service := range services {
+		data, err := serviceSnapshot(service)
+		if err != nil {
+			return err
+		}
+		file.Services = append(file.Services, data)
+	}
+	for _, business := range businesses {
+		data, err := businessSnapshot(business)
+		if err != nil {
+			return err
+		}
+		file.Businesses = append(file.Businesses, data)
+	}
+	for _, relation := range resourceRelations {
+		data, err := resourceRelationSnapshot(relation)
+		if err != nil {
+			return err
+		}
+		file.ResourceRelations = append(file.ResourceRelations, data)
+	}
+	for _, relation := range universalRelations {
+		data, err := universalRelationSnapshot(relation)
+		if err != nil {
+			return err
+		}
+		file.UniversalRelations = append(file.UniversalRelations, data)
+	}
+	m.ResourceCount = len(resources)
+	m.ServiceCount = len(services)
+	m.BusinessCount = len(businesses)
+	m.RelationCount = len(resourceRelations) + len(universalRelations)
+	return nil
+}
+
+// buildIncremental 收集(since, 快照时间]之间的变更历史。限定范围时，资源/服务/业务按变更前后数据的
+// tenant_id/business_id过滤，关系按端点是否在范围内(当前范围内或有范围内变更的对象)过滤；数量为有变更的对象数
+func (s *snapshotService) buildIncremental(ctx context.Context, m *model.ResourceSnapshot, since time.Time, file *snapshotFile) error {
+	scoped := m.TenantID != "" || m.BusinessID != ""
+	inScope := map[string]map[string]struct{}{
+		model.ObjectTypeResource: {},
+		model.ObjectTypeService:  {},
+		model.ObjectTypeBusiness: {},
+	}
+	if scoped {
+		resources, err := s.snapshotRepository.GetScopedResources(ctx, m.TenantID, m.BusinessID)
+		if err != nil {
+			return err
+		}
+		for _, resource := range resources {
+			inScope[model.ObjectTypeResource][resource.ResourceID] = struct{}{}
+		}
+		services, err := s.snapshotRepository.GetScopedServices(ctx, m.TenantID, m.BusinessID)
+		if err != nil {
+			return err
+		}
+		for _, service := range services {
+			inScope[model.ObjectTypeService][service.ServiceID] = struct{}{}
+		}
+		businesses, err := s.snapshotRepository.GetScopedBusinesses(ctx, m.TenantID, m.BusinessID)
+		if err != nil {
+			return err
+		}
+		for _, business := range businesses {
+			inScope[model.ObjectTypeBusiness][business.BusinessID] = struct{}{}
+		}
+	}
+	matchObject := func(data model.JSONMap) bool {
+		if data == nil {
+			return false
+		}
+		if m.TenantID != "" && data["tenant_id"] != m.TenantID {
+			return false
+		}
+		return m.BusinessID == "" || data["business_id"] == m.BusinessID
+	}
+	hasEndpoint := func(data model.JSONMap, typeKey, idKey string) bool {
+		if data == nil {
+			return false
+		}
+		objectType := model.ObjectTypeResource
+		if typeKey != "" {
+			objectType, _ = data[typeKey].(string)
+		}
+		id, _ := data[idKey].(string)
+		_, ok := inScope[objectType][id]
+		return ok
+	}
+
+	changed := make(map[string]map[string]struct{})
+	objectTypes := []string{model.ObjectTypeResource, model.ObjectTypeService, model.ObjectTypeBusiness,
+		model.HistoryObjectResourceRelation, model.HistoryObjectUniversalRelation}
+	for _, objectType := range objectTypes {
+		records, err := s.historyRepository.GetHistoriesBetween(ctx, objectType, since, m.SnapshotTime)
+		if err != nil {
+			return err
+		}
+		for _, record := range records {
+			if scoped {
+				var match bool
+				switch objectType {
+				case model.HistoryObjectResourceRelation:
+					match = hasEndpoint(record.BeforeData, "", "source_resource_id") || hasEndpoint(record.BeforeData, "", "target_resource_id") ||
+						hasEndpoint(record.AfterData, "", "source_resource_id") || hasEndpoint(record.AfterData, "", "target_resource_id")
+				case model.HistoryObjectUniversalRelation:
+					match = hasEndpoint(record.BeforeData, "source_type", "source_id") || hasEndpoint(record.BeforeData, "target_type", "target_id") ||
+						hasEndpoint(record.AfterData, "source_type", "source_id") || hasEndpoint(record.AfterData, "target_type", "target_id")
+				default:
+					match = matchObject(record.BeforeData) || matchObject(record.AfterData)
+					if match {
+						// 已删除或移出范围的对象仍需要带上与之相连的关系变更
+						inScope[objectType][record.ObjectID] = struct{}{}
+					}
+				}
+				if !match {
+					continue
+				}
+			}
+			if changed[objectType] == nil {
+				changed[objectType] = make(map[string]struct{})
+			}
+			changed[objectType][record.ObjectID] = struct{}{}
+			file.Changes = append(file.Changes, snapshotChange{
+				ObjectType: objectType,
+				ObjectID:   record.ObjectID,
+				ChangeType: record.ChangeType,
+				ChangeTime: record.ChangeTime,
+				Version:    record.Version,
+				BeforeData: record.BeforeData,
+				AfterData:  record.AfterData,
+			})
+		}
+	}
+	m.ResourceCount = len(changed[model.ObjectTypeResource])
+	m.ServiceCount = len(changed[model.ObjectTypeService])
+	m.BusinessCount = len(changed[model.ObjectTypeBusiness])
+	m.RelationCount = len(changed[model.HistoryObjectResourceRelation]) + len(changed[model.HistoryObjectUniversalRelation])
+	return nil
+}
+
+func (s *snapshotService) GetSnapshots(ctx context.Context, req *v1.GetSnapshotsRequest) (*v1.GetSnapshotsResponseData, error) {
+	list, total, err := s.snapshotRepository.GetSnapshots(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	data := &v1.GetSnapshotsResponseData{
+		List:  make([]v1.SnapshotDataItem, 0),
+		Total: total,
+	}
+	for _, snapshot := range list {
+		data.List = append(data.List, toSnapshotDataItem(snapshot))
+	}
+	return data, nil
+}
+
+// VerifySnapshot 重新计算快照文件的SHA-256，与记录的校验和、文件大小一致时有效，文件缺失视为无效
+func (s *snapshotService) VerifySnapshot(ctx context.Context, req *v1.SnapshotVerifyRequest) (*v1.SnapshotVerifyResponseData, error) {
+	m, err := s.snapshotRepository.GetSnapshot(ctx, req.SnapshotID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, v1.ErrNotFound
+		}
+		return nil, err
+	}
+	if m.Status != model.SnapshotStatusCompleted {
+		return nil, v1.ErrSnapshotNotCompleted
+	}
+	size, checksum, err := fileChecksum(m.StoragePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &v1.SnapshotVerifyResponseData{}, nil
+		}
+		return nil, err
+	}
+	return &v1.SnapshotVerifyResponseData{
+		Valid:    checksum == m.Checksum && size == m.FileSize,
+		Checksum: checksum,
+		FileSize: size,
+	}, nil
+}
+
+func (s *snapshotService) PurgeExpiredSnapshots(ctx context.Context) (int, error) {
+	list, err := s.snapshotRepository.GetExpiredSnapshots(ctx, time.Now())
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for _, m := range list {
+		if m.StoragePath != "" {
+			if err := os.Remove(m.StoragePath); err != nil && !os.IsNotExist(err) {
+				s.logger.WithContext(ctx).Error("remove snapshot file error", zap.String("path", m.StoragePath), zap.Error(err))
+				continue
+			}
+		}
+		if err := s.snapshotRepository.SnapshotDelete(ctx, m.ID); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
+
+func (s *snapshotService) snapshotDir() string {
+	if dir := s.conf.GetString("cmdb.snapshot.dir"); dir != "" {
+		return dir
+	}
+	return "storage/snapshots"
+}
+
+// writeSnapshotFile 先写临时文件再重命名，避免留下不完整的快照文件；校验和按压缩后的文件计算
+func writeSnapshotFile(path string, content *snapshotFile) (int64, string, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return 0, "", err
+	}
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return 0, "", err
+	}
+	defer os.Remove(tmp)
+	hash := sha256.New()
+	gz := gzip.NewWriter(io.MultiWriter(f, hash))
+	if err := json.NewEncoder(gz).Encode(content); err != nil {
+		f.Close()
+		return 0, "", err
+	}
+	if err := gz.Close(); err != nil {
+		f.Close()
+		return 0, "", err
+	}
+	if err := f.Close(); err != nil {
+		return 0, "", err
+	}
+	info, err := os.Stat(tmp)
+	if err != nil {
+		return 0, "", err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return 0, "", err
+	}
+	return info.Size(), hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func fileChecksum(path string) (int64, string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, "", err
+	}
+	defer f.Close()
+	hash := sha256.New()
+	size, err := io.Copy(hash, f)
+	if err != nil {
+		return 0, "", err
+	}
+	return size, hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func toSnapshotDataItem(m model.ResourceSnapshot) v1.SnapshotDataItem {
+	item := v1.SnapshotDataItem{
+		ID:             m.ID,
+		SnapshotID:     m.SnapshotID,
+		SnapshotTime:   m.SnapshotTime.Format("2006-01-02 15:04:05"),
+		SnapshotType:   m.SnapshotType,
+		BaseSnapshotID: m.BaseSnapshotID,
+		TenantID:       m.TenantID,
+		BusinessID:     m.BusinessID,
+		ResourceCount:  m.ResourceCount,
+		ServiceCount:   m.ServiceCount,
+		BusinessCount:  m.BusinessCount,
+		RelationCount:  m.RelationCount,
+		Status:         m.Status,
+		StoragePath:    m.StoragePath,
+		FileSize:       m.FileSize,
+		Checksum:       m.Checksum,
+		CreatorID:      m.CreatorID,
+		CreatorName:    m.CreatorName,
+		Description:    m.Description,
+		CreatedAt:      m.CreatedAt.Format("2006-01-02 15:04:05"),
+	}
+	if m.ExpiresAt != nil {
+		item.ExpiresAt = m.ExpiresAt.Format("2006-01-02 15:04:05")
+	}
+	return item
+}
+
+// snapshotObjectTypes 快照中的对象类型，按恢复时写入的顺序排列(关系依赖两端对象)
+var snapshotObjectTypes = []string{
+	model.ObjectTypeBusiness,
+	model.ObjectTypeService,
+	model.ObjectTypeResource,
+	model.HistoryObjectResourceRelation,
+	model.HistoryObjectUniversalRelation,
+}
+
+func isSnapshotEdgeType(objectType string) bool {
+	return objectType == model.HistoryObjectResourceRelation || objectType == model.HistoryObjectUniversalRelation
+}
+
+// snapshotState 快照还原出的CMDB状态，对象类型 -> 对象键 -> 快照数据
+type snapshotState map[string]map[string]model.JSONMap
+
+func newSnapshotState() snapshotState {
+	st := make(snapshotState, len(snapshotObjectTypes))
+	for _, objectType := range snapshotObjectTypes {
+		st[objectType] = make(map[string]model.JSONMap)
+	}
+	return st
+}
+
+func stateFromFile(file *snapshotFile) snapshotState {
+	st := newSnapshotState()
+	lists := map[string][]model.JSONMap{
+		model.ObjectTypeResource:             file.Resources,
+		model.ObjectTypeService:              file.Services,
+		model.ObjectTypeBusiness:             file.Businesses,
+		model.HistoryObjectResourceRelation:  file.ResourceRelations,
+		model.HistoryObjectUniversalRelation: file.UniversalRelations,
+	}
+	for objectType, list := range lists {
+		for _, data := range list {
+			st[objectType][snapshotKey(objectType, data)] = data
+		}
+	}
+	return st
+}
+
+// apply 按顺序重放变更历史：先移除变更前的键，再写入变更后的数据(资源关系的键随端点变化)
+func (st snapshotState) apply(changes []snapshotChange) {
+	for _, change := range changes {
+		objects, ok := st[change.ObjectType]
+		if !ok {
+			continue
+		}
+		if change.BeforeData != nil {
+			delete(objects, snapshotKey(change.ObjectType, change.BeforeData))
+		}
+		if change.AfterData != nil {
+			objects[snapshotKey(change.ObjectType, change.AfterData)] = change.AfterData
+		}
+	}
+}
+
+// scoped 按租户/业务过滤对象，关系保留至少一端在过滤后对象中的部分
+func (st snapshotState) scoped(tenantID, businessID string) snapshotState {
+	if tenantID == "" && businessID == "" {
+		return st
+	}
+	result := newSnapshotState()
+	endpoints := make(map[string]struct{})
+	for _, objectType := range snapshotObjectTypes {
+		if isSnapshotEdgeType(objectType) {
+			continue
+		}
+		for key, data := range st[objectType] {
+			if tenantID != "" && data["tenant_id"] != tenantID {
+				continue
+			}
+			if businessID != "" && data["business_id"] != businessID {
+				continue
+			}
+			result[objectType][key] = data
+			endpoints[graphNodeID(objectType, key)] = struct{}{}
+		}
+	}
+	hasEndpoint := func(objectType string, id interface{}) bool {
+		key, _ := id.(string)
+		_, ok := endpoints[graphNodeID(objectType, key)]
+		return ok
+	}
+	for key, data := range st[model.HistoryObjectResourceRelation] {
+		if hasEndpoint(model.ObjectTypeResource, data["source_resource_id"]) || hasEndpoint(model.ObjectTypeResource, data["target_resource_id"]) {
+			result[model.HistoryObjectResourceRelation][key] = data
+		}
+	}
+	for key, data := range st[model.HistoryObjectUniversalRelation] {
+		sourceType, _ := data["source_type"].(string)
+		targetType, _ := data["target_type"].(string)
+		if hasEndpoint(sourceType, data["source_id"]) || hasEndpoint(targetType, data["target_id"]) {
+			result[model.HistoryObjectUniversalRelation][key] = data
+		}
+	}
+	return result
+}
+
+// snapshotKey 对象按业务唯一标识区分；资源关系没有稳定的业务标识，按"源资源|关系类型|目标资源"区分
+func snapshotKey(objectType string, data model.JSONMap) string {
+	switch objectType {
+	case model.ObjectTypeResource:
+		return fmt.Sprint(data["resource_id"])
+	case model.ObjectTypeService:
+		return fmt.Sprint(data["service_id"])
+	case model.ObjectTypeBusiness:
+		return fmt.Sprint(data["business_id"])
+	case model.HistoryObjectResourceRelation:
+		return fmt.Sprintf("%v|%v|%v", data["source_resource_id"], data["relation_type"], data["target_resource_id"])
+	case model.HistoryObjectUniversalRelation:
+		return fmt.Sprint(data["relation_id"])
+	}
+	return ""
+}
+
+// loadSnapshotState 校验快照文件后还原其状态，增量快照在基准全量快照上重放变更
+func (s *snapshotService) loadSnapshotState(ctx context.Context, m model.ResourceSnapshot) (snapshotState, error) {
+	if m.Status != model.SnapshotStatusCompleted {
+		return nil, v1.ErrSnapshotNotCompleted
+	}
+	file, err := readSnapshotFile(m.StoragePath, m.Checksum)
+	if err != nil {
+		return nil, err
+	}
+	if m.SnapshotType != model.SnapshotTypeIncremental {
+		return stateFromFile(file).scoped(m.TenantID, m.BusinessID), nil
+	}
+	base, err := s.snapshotRepository.GetSnapshot(ctx, m.BaseSnapshotID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, v1.ErrSnapshotBaseNotFound
+		}
+		return nil, err
+	}
+	st, err := s.loadSnapshotState(ctx, base)
+	if err != nil {
+		return nil, err
+	}
+	st.apply(file.Changes)
+	return st.scoped(m.TenantID, m.BusinessID), nil
+}
+
+// liveState 当前数据在指定范围内的状态，与全量快照的内容一致
+func (s *snapshotService) liveState(ctx context.Context, tenantID, businessID string) (snapshotState, error) {
+	file := &snapshotFile{}
+	if err := s.buildFull(ctx, &model.ResourceSnapshot{TenantID: tenantID, BusinessID: businessID}, file); err != nil {
+		return nil, err
+	}
+	return stateFromFile(file), nil
+}
+
+func (s *snapshotService) getCompletedSnapshot(ctx context.Context, snapshotID string) (model.ResourceSnapshot, error) {
+	m, err := s.snapshotRepository.GetSnapshot(ctx, snapshotID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return m, v1.ErrNotFound
+		}
+		return m, err
+	}
+	if m.Status != model.SnapshotStatusCompleted {
+		return m, v1.ErrSnapshotNotCompleted
+	}
+	return m, nil
+}
+
+// DiffSnapshots 对比两个快照，未指定ToSnapshotID时与FromSnapshot范围内的当前数据对比
+func (s *snapshotService) DiffSnapshots(ctx context.Context, req *v1.SnapshotDiffRequest) (*v1.SnapshotDiffResponseData, error) {
+	view, err := newSensitiveView(ctx, s.keyring, s.historyRepository, s.sensitiveRepository, req.Reveal)
+	if err != nil {
+		return nil, err
+	}
+	from, err := s.getCompletedSnapshot(ctx, req.FromSnapshotID)
+	if err != nil {
+		return nil, err
+	}
+	fromState, err := s.loadSnapshotState(ctx, from)
+	if err != nil {
+		return nil, err
+	}
+	var toState snapshotState
+	if req.ToSnapshotID == "" {
+		toState, err = s.liveState(ctx, from.TenantID, from.BusinessID)
+	} else {
+		var to model.ResourceSnapshot
+		if to, err = s.getCompletedSnapshot(ctx, req.ToSnapshotID); err == nil {
+			toState, err = s.loadSnapshotState(ctx, to)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	data := &v1.SnapshotDiffResponseData{
+		FromSnapshotID: req.FromSnapshotID,
+		ToSnapshotID:   req.ToSnapshotID,
+		Objects:        newSnapshotDiffGroup(),
+		Edges:          newSnapshotDiffGroup(),
+	}
+	for _, objectType := range snapshotObjectTypes {
+		group := &data.Objects
+		if isSnapshotEdgeType(objectType) {
+			group = &data.Edges
+		}
+		for _, key := range sortedStateKeys(fromState[objectType], toState[objectType]) {
+			before, inFrom := fromState[objectType][key]
+			after, inTo := toState[objectType][key]
+			// 密文在主密钥轮换后会变化，按明文比较
+			if before, err = openSensitive(s.keyring, before); err != nil {
+				return nil, err
+			}
+			if after, err = openSensitive(s.keyring, after); err != nil {
+				return nil, err
+			}
+			item := v1.SnapshotDiffItem{ObjectType: objectType, Key: key}
+			if item.Before, err = view.apply(before); err != nil {
+				return nil, err
+			}
+			if item.After, err = view.apply(after); err != nil {
+				return nil, err
+			}
+			switch {
+			case !inFrom:
+				group.Added = append(group.Added, item)
+			case !inTo:
+				group.Removed = append(group.Removed, item)
+			default:
+				if changed := diffSnapshots(before, after); len(changed) > 0 {
+					if item.ChangedFields, err = view.apply(changed); err != nil {
+						return nil, err
+					}
+					group.Changed = append(group.Changed, item)
+				}
+			}
+		}
+	}
+	return data, nil
+}
+
+func newSnapshotDiffGroup() v1.SnapshotDiffGroup {
+	return v1.SnapshotDiffGroup{
+		Added:   make([]v1.SnapshotDiffItem, 0),
+		Removed: make([]v1.SnapshotDiffItem, 0),
+		Changed: make([]v1.SnapshotDiffItem, 0),
+	}
+}
+
+func sortedStateKeys(states ...map[string]model.JSONMap) []string {
+	seen := make(map[string]struct{})
+	keys := make([]string, 0)
+	for _, state := range states {
+		for key := range state {
+			if _, ok := seen[key]; !ok {
+				seen[key] = struct{}{}
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// RestoreSnapshot 在一个事务中把快照重放到数据库：范围内快照有而当前没有的对象重新创建(软删除的记录直接恢复)，
+// 两边都有的按快照覆盖，当前有而快照没有的删除。可按租户/业务/对象类型只恢复一部分，变更历史来源记为import
+func (s *snapshotService) RestoreSnapshot(ctx context.Context, req *v1.SnapshotRestoreRequest) (*v1.SnapshotRestoreResponseData, error) {
+	m, err := s.getCompletedSnapshot(ctx, req.SnapshotID)
+	if err != nil {
+		return nil, err
+	}
+	tenantID, businessID := m.TenantID, m.BusinessID
+	if req.TenantID != "" {
+		if tenantID != "" && tenantID != req.TenantID {
+			return nil, v1.ErrSnapshotScopeMismatch
+		}
+		tenantID = req.TenantID
+	}
+	if req.BusinessID != "" {
+		if businessID != "" && businessID != req.BusinessID {
+			return nil, v1.ErrSnapshotScopeMismatch
+		}
+		businessID = req.BusinessID
+	}
+	objectTypes := req.ObjectTypes
+	if len(objectTypes) == 0 {
+		objectTypes = snapshotObjectTypes
+	}
+	target, err := s.loadSnapshotState(ctx, m)
+	if err != nil {
+		return nil, err
+	}
+	target = target.scoped(tenantID, businessID)
+
+	r := &snapshotRestorer{
+		snapshotService: s,
+		reason:          req.Reason,
+		comment:         "restore from snapshot " + m.SnapshotID,
+		data:            &v1.SnapshotRestoreResponseData{},
+	}
+	ctx = WithChangeSource(ctx, model.ChangeSourceImport)
+	err = s.tm.Transaction(ctx, func(ctx context.Context) error {
+		live, err := s.liveState(ctx, tenantID, businessID)
+		if err != nil {
+			return err
+		}
+		if err := r.loadResourceRelations(ctx); err != nil {
+			return err
+		}
+		// 先删除多余的关系，再写对象，最后写关系，保证关系两端的对象已经存在
+		for _, objectType := range snapshotObjectTypes {
+			if !isSnapshotEdgeType(objectType) || !containsString(objectTypes, objectType) {
+				continue
+			}
+			for _, key := range sortedStateKeys(live[objectType]) {
+				if _, ok := target[objectType][key]; !ok {
+					if err := r.restore(ctx, objectType, live[objectType][key], nil); err != nil {
+						return err
+					}
+				}
+			}
+		}
+		for _, edges := range []bool{false, true} {
+			for _, objectType := range snapshotObjectTypes {
+				if isSnapshotEdgeType(objectType) != edges || !containsString(objectTypes, objectType) {
+					continue
+				}
+				for _, key := range sortedStateKeys(live[objectType], target[objectType]) {
+					before, after := live[objectType][key], target[objectType][key]
+					if edges && after == nil {
+						continue
+					}
+					if before != nil && after != nil && len(diffSnapshots(before, after)) == 0 {
+						continue
+					}
+					if err := r.restore(ctx, objectType, before, after); err != nil {
+						return err
+					}
+				}
+			}
+		}
+		if r.edgesChanged {
+			return invalidateRelationPaths(ctx, s.pathRepository, "")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r.data, nil
+}
+
+// errSnapshotRestoreSkipped 对象无法恢复(如关系的端点不存在)，计入跳过数量而不中止恢复
+var errSnapshotRestoreSkipped = errors.New("snapshot restore skipped")
+
+// snapshotRestorer 一次恢复过程中的状态，before/after为nil分别表示创建和删除
+type snapshotRestorer struct {
+	*snapshotService
+	reason            string
+	comment           string
+	data              *v1.SnapshotRestoreResponseData
+	resourceRelations map[string]model.ResourceRelation
+	edgesChanged      bool
+}
+
+func (r *snapshotRestorer) loadResourceRelations(ctx context.Context) error {
+	list, err := r.snapshotRepository.GetScopedResourceRelations(ctx, nil)
+	if err != nil {
+		return err
+	}
+	r.resourceRelations = make(map[string]model.ResourceRelation, len(list))
+	for _, relation := range list {
+		data, err := resourceRelationSnapshot(relation)
+		if err != nil {
+			return err
+		}
+		r.resourceRelations[snapshotKey(model.HistoryObjectResourceRelation, data)] = relation
+	}
+	return nil
+}
+
+func (r *snapshotRestorer) restore(ctx context.Context, objectType string, before, after model.JSONMap) error {
+	var err error
+	switch objectType {
+	case model.ObjectTypeResource:
+		err = r.restoreResource(ctx, before, after)
+	case model.ObjectTypeService:
+		err = r.restoreService(ctx, before, after)
+	case model.ObjectTypeBusiness:
+		err = r.restoreBusiness(ctx, before, after)
+	case model.HistoryObjectResourceRelation:
+		err = r.restoreResourceRelation(ctx, before, after)
+	case model.HistoryObjectUniversalRelation:
+		err = r.restoreUniversalRelation(ctx, before, after)
+	}
+	if errors.Is(err, errSnapshotRestoreSkipped) {
+		r.data.Skipped++
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if isSnapshotEdgeType(objectType) {
+		r.edgesChanged = true
+	}
+	switch {
+	case before == nil:
+		r.data.Created++
+	case after == nil:
+		r.data.Deleted++
+	default:
+		r.data.Updated++
+	}
+	return nil
+}
+
+func (r *snapshotRestorer) restoreResource(ctx context.Context, before, after model.JSONMap) error {
+	if after == nil {
+		old, err := r.resourceRepository.GetResource(ctx, snapshotKey(model.ObjectTypeResource, before))
+		if err != nil {
+			return err
+		}
+		if err := r.resourceRepository.ResourceDelete(ctx, old.ID); err != nil {
+			return err
+		}
+		return recordResourceHistory(ctx, r.historyRepository, model.ChangeTypeDelete, &old, nil, r.reason, r.comment)
+	}
+	m, err := resourceFromSnapshot(after)
+	if err != nil {
+		return err
+	}
+	tags := m.Tags
+	m.Tags = nil
+	var old *model.Resource
+	if before != nil {
+		current, err := r.resourceRepository.GetResource(ctx, m.ResourceID)
+		if err != nil {
+			return err
+		}
+		old, m.ID = &current, current.ID
+	} else if m.ID, err = r.snapshotRepository.UndeleteByKey(ctx, &model.Resource{}, "resource_id", m.ResourceID); err != nil {
+		return err
+	}
+	if m.ID > 0 {
+		err = r.resourceRepository.ResourceUpdate(ctx, &m)
+	} else {
+		err = r.resourceRepository.ResourceCreate(ctx, &m)
+	}
+	if err != nil {
+		return err
+	}
+	if err := r.resourceRepository.ReplaceResourceTags(ctx, m.ID, tags); err != nil {
+		return err
+	}
+	resource, err := r.resourceRepository.GetResource(ctx, m.ResourceID)
+	if err != nil {
+		return err
+	}
+	changeType := model.ChangeTypeUpdate
+	if old == nil {
+		changeType = model.ChangeTypeCreate
+	}
+	return recordResourceHistory(ctx, r.historyRepository, changeType, old, &resource, r.reason, r.comment)
+}
+
+func (r *snapshotRestorer) restoreService(ctx context.Context, before, after model.JSONMap) error {
+	if after == nil {
+		old, err := r.serviceRepository.GetService(ctx, snapshotKey(model.ObjectTypeService, before))
+		if err != nil {
+			return err
+		}
+		if err := r.serviceRepository.ServiceDelete(ctx, old.ID); err != nil {
+			return err
+		}
+		return recordServiceHistory(ctx, r.historyRepository, model.ChangeTypeDelete, &old, nil, r.reason, r.comment)
+	}
+	m, err := serviceFromSnapshot(after)
+	if err != nil {
+		return err
+	}
+	tags := m.Tags
+	m.Tags = nil
+	var old *model.Service
+	if before != nil {
+		current, err := r.serviceRepository.GetService(ctx, m.ServiceID)
+		if err != nil {
+			return err
+		}
+		old, m.ID = &current, current.ID
+	} else if m.ID, err = r.snapshotRepository.UndeleteByKey(ctx, &model.Service{}, "service_id", m.ServiceID); err != nil {
+		return err
+	}
+	if m.ID > 0 {
+		err = r.serviceRepository.ServiceUpdate(ctx, &m)
+	} else {
+		err = r.serviceRepository.ServiceCreate(ctx, &m)
+	}
+	if err != nil {
+		return err
+	}
+	if err := r.serviceRepository.ReplaceServiceTags(ctx, m.ID, tags); err != nil {
+		return err
+	}
+	service, err := r.serviceRepository.GetService(ctx, m.ServiceID)
+	if err != nil {
+		return err
+	}
+	changeType := model.ChangeTypeUpdate
+	if old == nil {
+		changeType = model.ChangeTypeCreate
+	}
+	return recordServiceHistory(ctx, r.historyRepository, changeType, old, &service, r.reason, r.comment)
+}
+
+func (r *snapshotRestorer) restoreBusiness(ctx context.Context, before, after model.JSONMap) error {
+	if after == nil {
+		old, err := r.businessRepository.GetBusiness(ctx, snapshotKey(model.ObjectTypeBusiness, before))
+		if err != nil {
+			return err
+		}
+		if err := r.businessRepository.BusinessDelete(ctx, old.ID); err != nil {
+			return err
+		}
+		return recordBusinessHistory(ctx, r.historyRepository, model.ChangeTypeDelete, &old, nil, r.reason, r.comment)
+	}
+	m, err := businessFromSnapshot(after)
+	if err != nil {
+		return err
+	}
+	tags := m.Tags
+	m.Tags = nil
+	var old *model.Business
+	if before != nil {
+		current, err := r.businessRepository.GetBusiness(ctx, m.BusinessID)
+		if err != nil {
+			return err
+		}
+		old, m.ID = &current, current.ID
+	} else if m.ID, err = r.snapshotRepository.UndeleteByKey(ctx, &model.Business{}, "business_id", m.BusinessID); err != nil {
+		return err
+	}
+	if m.ID > 0 {
+		err = r.businessRepository.BusinessUpdate(ctx, &m)
+	} else {
+		err = r.businessRepository.BusinessCreate(ctx, &m)
+	}
+	if err != nil {
+		return err
+	}
+	if err := r.businessRepository.ReplaceBusinessTags(ctx, m.ID, tags); err != nil {
+		return err
+	}
+	business, err := r.businessRepository.GetBusiness(ctx, m.BusinessID)
+	if err != nil {
+		return err
+	}
+	changeType := model.ChangeTypeUpdate
+	if old == nil {
+		changeType = model.ChangeTypeCreate
+	}
+	return recordBusinessHistory(ctx, r.historyRepository, changeType, old, &business, r.reason, r.comment)
+}
+
+// restoreResourceRelation 端点资源在当前数据中不存在时跳过该关系
+func (r *snapshotRestorer) restoreResourceRelation(ctx context.Context, before, after model.JSONMap) error {
+	if after == nil {
+		old := r.resourceRelations[snapshotKey(model.HistoryObjectResourceRelation, before)]
+		if err := r.relationRepository.ResourceRelationDelete(ctx, old.ID); err != nil {
+			return err
+		}
+		return recordResourceRelationHistory(ctx, r.historyRepository, model.ChangeTypeDelete, &old, nil)
+	}
+	m, sourceID, targetID, err := resourceRelationFromSnapshot(after)
+	if err != nil {
+		return err
+	}
+	if before != nil {
+		old := r.resourceRelations[snapshotKey(model.HistoryObjectResourceRelation, before)]
+		m.ID, m.SourceID, m.TargetID, m.Source, m.Target = old.ID, old.SourceID, old.TargetID, old.Source, old.Target
+		if err := r.relationRepository.ResourceRelationUpdate(ctx, &m); err != nil {
+			return err
+		}
+		return recordResourceRelationHistory(ctx, r.historyRepository, model.ChangeTypeUpdate, &old, &m)
+	}
+	if m.Source, err = r.resourceRepository.GetResource(ctx, sourceID); err == nil {
+		m.Target, err = r.resourceRepository.GetResource(ctx, targetID)
+	}
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errSnapshotRestoreSkipped
+		}
+		return err
+	}
+	m.SourceID, m.TargetID = m.Source.ID, m.Target.ID
+	if err := r.relationRepository.ResourceRelationCreate(ctx, &m); err != nil {
+		return err
+	}
+	return recordResourceRelationHistory(ctx, r.historyRepository, model.ChangeTypeCreate, nil, &m)
+}
+
+func (r *snapshotRestorer) restoreUniversalRelation(ctx context.Context, before, after model.JSONMap) error {
+	if after == nil {
+		old, err := r.relationRepository.GetUniversalRelation(ctx, snapshotKey(model.HistoryObjectUniversalRelation, before))
+		if err != nil {
+			return err
+		}
+		if err := r.relationRepository.UniversalRelationDelete(ctx, old.ID); err != nil {
+			return err
+		}
+		return recordUniversalRelationHistory(ctx, r.historyRepository, model.ChangeTypeDelete, &old, nil)
+	}
+	m, err := universalRelationFromSnapshot(after)
+	if err != nil {
+		return err
+	}
+	// 快照中不保存冗余的端点名称，按当前对象补全
+	for _, end := range []struct {
+		objectType, objectID string
+		name                 *string
+	}{{m.SourceType, m.SourceID, &m.SourceName}, {m.TargetType, m.TargetID, &m.TargetName}} {
+		obj, err := r.relationRepository.GetObject(ctx, end.objectType, end.objectID)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		*end.name = obj.Name
+	}
+	var old *model.UniversalRelation
+	if before != nil {
+		current, err := r.relationRepository.GetUniversalRelation(ctx, m.RelationID)
+		if err != nil {
+			return err
+		}
+		old, m.ID = &current, current.ID
+	} else if m.ID, err = r.snapshotRepository.UndeleteByKey(ctx, &model.UniversalRelation{}, "relation_id", m.RelationID); err != nil {
+		return err
+	}
+	if m.ID > 0 {
+		err = r.relationRepository.UniversalRelationUpdateAll(ctx, &m)
+	} else {
+		err = r.relationRepository.UniversalRelationCreate(ctx, &m)
+	}
+	if err != nil {
+		return err
+	}
+	relation, err := r.relationRepository.GetUniversalRelation(ctx, m.RelationID)
+	if err != nil {
+		return err
+	}
+	changeType := model.ChangeTypeUpdate
+	if old == nil {
+		changeType = model.ChangeTypeCreate
+	}
+	return recordUniversalRelationHistory(ctx, r.historyRepository, changeType, old, &relation)
+}
+
+// readSnapshotFile 校验SHA-256后解压读取快照文件
+func readSnapshotFile(path, checksum string) (*snapshotFile, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, v1.ErrSnapshotChecksumMismatch
+		}
+		return nil, err
+	}
+	sum := sha256.Sum256(raw)
+	if hex.EncodeToString(sum[:]) != checksum {
+		return nil, v1.ErrSnapshotChecksumMismatch
+	}
+	gz, err := gzip.NewReader(bytes.NewReader(raw))
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+	file := &snapshotFile{}
+	return file, json.NewDecoder(gz).Decode(file)
+}
@@ -0,0 +1,38 @@
+package task
+
+import (
+	"context"
+	"go.uber.org/zap"
+	"nunu-layout-admin/internal/service"
+)
+
+type ApplicationTask interface {
+	CheckHeartbeats(ctx context.Context) error
+}
+
+func NewApplicationTask(
+	task *Task,
+	applicationService service.ApplicationService,
+) ApplicationTask {
+	return &applicationTask{
+		applicationService: applicationService,
+		Task:               task,
+	}
+}
+
+type applicationTask struct {
+	applicationService service.ApplicationService
+	*Task
+}
+
+// CheckHeartbeats 把心跳超时的应用实例标记为故障或不健康
+func (t applicationTask) CheckHeartbeats(ctx context.Context) error {
+	marked, err := t.applicationService.CheckHeartbeats(ctx)
+	if err != nil {
+		return err
+	}
+	if marked > 0 {
+		t.logger.Info("CheckHeartbeats", zap.Int("marked", marked))
+	}
+	return nil
+}
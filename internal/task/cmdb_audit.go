@@ -0,0 +1,36 @@
+package task
+
+import (
+	"context"
+	"go.uber.org/zap"
+	"nunu-layout-admin/internal/service"
+)
+
+type AuditTask interface {
+	PurgeExpiredHistories(ctx context.Context) error
+}
+
+func NewAuditTask(
+	task *Task,
+	auditService service.AuditService,
+) AuditTask {
+	return &auditTask{
+		auditService: auditService,
+		Task:         task,
+	}
+}
+
+type auditTask struct {
+	auditService service.AuditService
+	*Task
+}
+
+// PurgeExpiredHistories 按审计配置的保留天数清理(或归档)过期的变更历史
+func (t auditTask) PurgeExpiredHistories(ctx context.Context) error {
+	count, err := t.auditService.PurgeExpiredHistories(ctx)
+	if err != nil {
+		return err
+	}
+	t.logger.Info("PurgeExpiredHistories", zap.Int("count", count))
+	return nil
+}
@@ -0,0 +1,38 @@
+package task
+
+import (
+	"context"
+	"go.uber.org/zap"
+	"nunu-layout-admin/internal/service"
+)
+
+type ConfigurationTask interface {
+	PublishDueRevisions(ctx context.Context) error
+}
+
+func NewConfigurationTask(
+	task *Task,
+	configurationService service.ConfigurationService,
+) ConfigurationTask {
+	return &configurationTask{
+		configurationService: configurationService,
+		Task:                 task,
+	}
+}
+
+type configurationTask struct {
+	configurationService service.ConfigurationService
+	*Task
+}
+
+// PublishDueRevisions 发布生效时间已到的配置版本
+func (t configurationTask) PublishDueRevisions(ctx context.Context) error {
+	published, err := t.configurationService.PublishDueRevisions(ctx)
+	if err != nil {
+		return err
+	}
+	if published > 0 {
+		t.logger.Info("PublishDueRevisions", zap.Int("published", published))
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package task
+
+import (
+	"context"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+	"nunu-layout-admin/internal/model"
+	"nunu-layout-admin/internal/service"
+)
+
+type DependencyGraphTask interface {
+	ComputeDependencyGraphs(ctx context.Context) error
+}
+
+func NewDependencyGraphTask(
+	task *Task,
+	conf *viper.Viper,
+	dependencyGraphService service.DependencyGraphService,
+) DependencyGraphTask {
+	return &dependencyGraphTask{
+		conf:                   conf,
+		dependencyGraphService: dependencyGraphService,
+		Task:                   task,
+	}
+}
+
+type dependencyGraphTask struct {
+	conf                   *viper.Viper
+	dependencyGraphService service.DependencyGraphService
+	*Task
+}
+
+// ComputeDependencyGraphs 按配置的根对象类型和图类型重新计算依赖图，默认为每个业务计算依赖图
+func (t dependencyGraphTask) ComputeDependencyGraphs(ctx context.Context) error {
+	rootTypes := t.conf.GetStringSlice("cmdb.dependency_graph.root_types")
+	if len(rootTypes) == 0 {
+		rootTypes = []string{model.ObjectTypeBusiness}
+	}
+	graphTypes := t.conf.GetStringSlice("cmdb.dependency_graph.graph_types")
+	if len(graphTypes) == 0 {
+		graphTypes = []string{model.GraphTypeDependency}
+	}
+	for _, rootType := range rootTypes {
+		changed, err := t.dependencyGraphService.ComputeAll(ctx, rootType, graphTypes)
+		if err != nil {
+			return err
+		}
+		t.logger.Info("ComputeDependencyGraphs", zap.String("root_type", rootType), zap.Int("changed", changed))
+	}
+	return nil
+}
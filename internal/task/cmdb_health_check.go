@@ -0,0 +1,45 @@
+package task
+
+import (
+	"context"
+	"go.uber.org/zap"
+	"nunu-layout-admin/internal/service"
+)
+
+type HealthCheckTask interface {
+	RunHealthChecks(ctx context.Context) error
+	PurgeProbeResults(ctx context.Context) error
+}
+
+func NewHealthCheckTask(
+	task *Task,
+	healthCheckService service.HealthCheckService,
+) HealthCheckTask {
+	return &healthCheckTask{
+		healthCheckService: healthCheckService,
+		Task:               task,
+	}
+}
+
+type healthCheckTask struct {
+	healthCheckService service.HealthCheckService
+	*Task
+}
+
+// RunHealthChecks 探测到达检查间隔的应用实例
+func (t healthCheckTask) RunHealthChecks(ctx context.Context) error {
+	_, err := t.healthCheckService.RunHealthChecks(ctx)
+	return err
+}
+
+// PurgeProbeResults 清理过期的探测结果
+func (t healthCheckTask) PurgeProbeResults(ctx context.Context) error {
+	purged, err := t.healthCheckService.PurgeProbeResults(ctx)
+	if err != nil {
+		return err
+	}
+	if purged > 0 {
+		t.logger.Info("PurgeProbeResults", zap.Int64("purged", purged))
+	}
+	return nil
+}
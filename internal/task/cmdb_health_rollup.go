@@ -0,0 +1,39 @@
+package task
+
+import (
+	"context"
+	"go.uber.org/zap"
+	"nunu-layout-admin/internal/model"
+	"nunu-layout-admin/internal/service"
+)
+
+type HealthRollupTask interface {
+	RollupHealth(ctx context.Context) error
+}
+
+func NewHealthRollupTask(
+	task *Task,
+	healthRollupService service.HealthRollupService,
+) HealthRollupTask {
+	return &healthRollupTask{
+		healthRollupService: healthRollupService,
+		Task:                task,
+	}
+}
+
+type healthRollupTask struct {
+	healthRollupService service.HealthRollupService
+	*Task
+}
+
+// RollupHealth 汇总服务和业务的健康状态
+func (t healthRollupTask) RollupHealth(ctx context.Context) error {
+	data, err := t.healthRollupService.RollupHealth(service.WithChangeSource(ctx, model.ChangeSourceScheduled), false)
+	if err != nil {
+		return err
+	}
+	if len(data.Changes) > 0 {
+		t.logger.Info("RollupHealth", zap.Int("changes", len(data.Changes)))
+	}
+	return nil
+}
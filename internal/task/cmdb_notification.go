@@ -0,0 +1,38 @@
+package task
+
+import (
+	"context"
+	"go.uber.org/zap"
+	"nunu-layout-admin/internal/service"
+)
+
+type NotificationTask interface {
+	DispatchNotifications(ctx context.Context) error
+}
+
+func NewNotificationTask(
+	task *Task,
+	notificationService service.NotificationService,
+) NotificationTask {
+	return &notificationTask{
+		notificationService: notificationService,
+		Task:                task,
+	}
+}
+
+type notificationTask struct {
+	notificationService service.NotificationService
+	*Task
+}
+
+// DispatchNotifications 发送到期的变更通知
+func (t notificationTask) DispatchNotifications(ctx context.Context) error {
+	sent, failed, err := t.notificationService.DispatchNotifications(ctx)
+	if err != nil {
+		return err
+	}
+	if sent > 0 || failed > 0 {
+		t.logger.Info("DispatchNotifications", zap.Int("sent", sent), zap.Int("failed", failed))
+	}
+	return nil
+}
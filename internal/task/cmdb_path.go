@@ -0,0 +1,37 @@
+package task
+
+import (
+	"context"
+	"go.uber.org/zap"
+	"nunu-layout-admin/internal/repository"
+	"time"
+)
+
+type PathTask interface {
+	PurgeExpiredPaths(ctx context.Context) error
+}
+
+func NewPathTask(
+	task *Task,
+	pathRepo repository.PathRepository,
+) PathTask {
+	return &pathTask{
+		pathRepo: pathRepo,
+		Task:     task,
+	}
+}
+
+type pathTask struct {
+	pathRepo repository.PathRepository
+	*Task
+}
+
+// PurgeExpiredPaths 清理已过期的RelationPath/RelationCache缓存
+func (t pathTask) PurgeExpiredPaths(ctx context.Context) error {
+	count, err := t.pathRepo.PathsDeleteExpired(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+	t.logger.Info("PurgeExpiredPaths", zap.Int64("count", count))
+	return nil
+}
@@ -0,0 +1,36 @@
+package task
+
+import (
+	"context"
+	"go.uber.org/zap"
+	"nunu-layout-admin/internal/service"
+)
+
+type SLATask interface {
+	CalculateSLA(ctx context.Context) error
+}
+
+func NewSLATask(
+	task *Task,
+	slaService service.SLAService,
+) SLATask {
+	return &slaTask{
+		slaService: slaService,
+		Task:       task,
+	}
+}
+
+type slaTask struct {
+	slaService service.SLAService
+	*Task
+}
+
+// CalculateSLA 计算当前日、周、月窗口的服务SLA
+func (t slaTask) CalculateSLA(ctx context.Context) error {
+	windows, err := t.slaService.CalculateCurrentSLA(ctx)
+	if err != nil {
+		return err
+	}
+	t.logger.Info("CalculateSLA", zap.Int("windows", windows))
+	return nil
+}
@@ -0,0 +1,36 @@
+package task
+
+import (
+	"context"
+	"go.uber.org/zap"
+	"nunu-layout-admin/internal/service"
+)
+
+type SnapshotTask interface {
+	PurgeExpiredSnapshots(ctx context.Context) error
+}
+
+func NewSnapshotTask(
+	task *Task,
+	snapshotService service.SnapshotService,
+) SnapshotTask {
+	return &snapshotTask{
+		snapshotService: snapshotService,
+		Task:            task,
+	}
+}
+
+type snapshotTask struct {
+	snapshotService service.SnapshotService
+	*Task
+}
+
+// PurgeExpiredSnapshots 删除已过期的快照文件及记录
+func (t snapshotTask) PurgeExpiredSnapshots(ctx context.Context) error {
+	count, err := t.snapshotService.PurgeExpiredSnapshots(ctx)
+	if err != nil {
+		return err
+	}
+	t.logger.Info("PurgeExpiredSnapshots", zap.Int("count", count))
+	return nil
+}
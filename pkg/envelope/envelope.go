@@ -0,0 +1,202 @@
+package envelope
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// Prefix 密文的固定前缀，完整格式为 enc:v1:<主密钥ID>:<加密后的数据密钥>:<加密后的数据>
+const Prefix = "enc:v1:"
+
+var (
+	ErrNoMasterKey   = errors.New("envelope: master key is not configured")
+	ErrUnknownKey    = errors.New("envelope: unknown master key")
+	ErrMalformedData = errors.New("envelope: malformed ciphertext")
+)
+
+// Keyring 信封加密：每个值使用随机生成的数据密钥(AES-256-GCM)加密，数据密钥再由主密钥加密后与密文一起保存。
+// 轮换主密钥时只需用新主密钥重新加密数据密钥，不需要重新加密数据
+type Keyring struct {
+	current string
+	keys    map[string][]byte
+}
+
+// EnvPrefix 主密钥的环境变量前缀，配置文件中密钥值为空时从 EnvPrefix+大写的密钥ID 读取(如 CMDB_MASTER_KEY_K1)
+const EnvPrefix = "CMDB_MASTER_KEY_"
+
+// NewKeyring 从 security.encryption 读取主密钥(base64编码的32字节)，密钥值不应写入配置文件，留空并通过环境变量提供。
+// 没有主密钥时不启用加密，但env为prod时拒绝启动
+func NewKeyring(conf *viper.Viper) *Keyring {
+	keys := make(map[string][]byte)
+	for id, value := range conf.GetStringMapString("security.encryption.master_keys") {
+		if value == "" {
+			value = os.Getenv(EnvPrefix + strings.ToUpper(id))
+		}
+		if value == "" {
+			continue
+		}
+		key, err := base64.StdEncoding.DecodeString(value)
+		if err != nil || len(key) != 32 {
+			panic(fmt.Sprintf("invalid master key %q: must be 32 bytes encoded in base64", id))
+		}
+		if id == "" || strings.Contains(id, ":") {
+			panic(fmt.Sprintf("invalid master key id %q", id))
+		}
+		keys[id] = key
+	}
+	current := conf.GetString("security.encryption.current_key")
+	// viper读取map时键统一为小写
+	current = strings.ToLower(current)
+	if len(keys) == 0 && conf.GetString("env") == "prod" {
+		panic(fmt.Sprintf("master key is not configured: set %s%s", EnvPrefix, strings.ToUpper(current)))
+	}
+	if len(keys) > 0 {
+		if _, ok := keys[current]; !ok {
+			panic(fmt.Sprintf("current master key %q is not configured", current))
+		}
+	}
+	return &Keyring{current: current, keys: keys}
+}
+
+// Enabled 是否配置了主密钥
+func (k *Keyring) Enabled() bool {
+	return len(k.keys) > 0
+}
+
+// CurrentKeyID 新数据使用的主密钥ID
+func (k *Keyring) CurrentKeyID() string {
+	return k.current
+}
+
+// RetiredKeyIDs 除当前主密钥外仍可用于解密的主密钥ID，按ID排序
+func (k *Keyring) RetiredKeyIDs() []string {
+	ids := make([]string, 0, len(k.keys))
+	for id := range k.keys {
+		if id != k.current {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// IsSealed 判断值是否为本包生成的密文
+func IsSealed(s string) bool {
+	return strings.HasPrefix(s, Prefix)
+}
+
+// Seal 使用新的数据密钥加密plaintext，并用当前主密钥加密数据密钥
+func (k *Keyring) Seal(plaintext []byte) (string, error) {
+	if !k.Enabled() {
+		return "", ErrNoMasterKey
+	}
+	dek := make([]byte, 32)
+	if _, err := rand.Read(dek); err != nil {
+		return "", err
+	}
+	wrapped, err := gcmSeal(k.keys[k.current], dek)
+	if err != nil {
+		return "", err
+	}
+	data, err := gcmSeal(dek, plaintext)
+	if err != nil {
+		return "", err
+	}
+	return Prefix + k.current + ":" + base64.RawURLEncoding.EncodeToString(wrapped) + ":" +
+		base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// Open 解密Seal生成的密文
+func (k *Keyring) Open(s string) ([]byte, error) {
+	_, dek, data, err := k.unwrap(s)
+	if err != nil {
+		return nil, err
+	}
+	return gcmOpen(dek, data)
+}
+
+// Rewrap 用当前主密钥重新加密数据密钥，密文已经使用当前主密钥时原样返回且changed为false
+func (k *Keyring) Rewrap(s string) (string, bool, error) {
+	id, dek, data, err := k.unwrap(s)
+	if err != nil {
+		return "", false, err
+	}
+	if id == k.current {
+		return s, false, nil
+	}
+	wrapped, err := gcmSeal(k.keys[k.current], dek)
+	if err != nil {
+		return "", false, err
+	}
+	return Prefix + k.current + ":" + base64.RawURLEncoding.EncodeToString(wrapped) + ":" +
+		base64.RawURLEncoding.EncodeToString(data), true, nil
+}
+
+// unwrap 解析密文并解密出数据密钥
+func (k *Keyring) unwrap(s string) (string, []byte, []byte, error) {
+	if !IsSealed(s) {
+		return "", nil, nil, ErrMalformedData
+	}
+	parts := strings.Split(strings.TrimPrefix(s, Prefix), ":")
+	if len(parts) != 3 {
+		return "", nil, nil, ErrMalformedData
+	}
+	key, ok := k.keys[parts[0]]
+	if !ok {
+		return "", nil, nil, fmt.Errorf("%w: %s", ErrUnknownKey, parts[0])
+	}
+	wrapped, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", nil, nil, ErrMalformedData
+	}
+	data, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", nil, nil, ErrMalformedData
+	}
+	dek, err := gcmOpen(key, wrapped)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	return parts[0], dek, data, nil
+}
+
+// gcmSeal 返回 nonce + 密文
+func gcmSeal(key, plaintext []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func gcmOpen(key, data []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < gcm.NonceSize() {
+		return nil, ErrMalformedData
+	}
+	return gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,124 @@
+package notify
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"nunu-layout-admin/pkg/notify/notifytest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPostWebhookSignature(t *testing.T) {
+	body := []byte(`{"event":"cmdb.change"}`)
+	var got *http.Request
+	var gotBody []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		gotBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	if err := PostWebhook(context.Background(), srv.Client(), srv.URL, "s3cret", "cmdb.change", "42", body); err != nil {
+		t.Fatalf("PostWebhook: %v", err)
+	}
+	if string(gotBody) != string(body) {
+		t.Errorf("body = %s, want %s", gotBody, body)
+	}
+	if got.Header.Get(HeaderEvent) != "cmdb.change" || got.Header.Get(HeaderDelivery) != "42" {
+		t.Errorf("event/delivery headers = %q/%q", got.Header.Get(HeaderEvent), got.Header.Get(HeaderDelivery))
+	}
+	timestamp := got.Header.Get(HeaderTimestamp)
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	mac.Write([]byte(timestamp + "." + string(body)))
+	want := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	if sig := got.Header.Get(HeaderSignature); sig != want {
+		t.Errorf("signature = %q, want %q", sig, want)
+	}
+}
+
+func TestPostWebhookWithoutSecret(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if sig := r.Header.Get(HeaderSignature); sig != "" {
+			t.Errorf("unexpected signature %q", sig)
+		}
+	}))
+	defer srv.Close()
+
+	if err := PostWebhook(context.Background(), srv.Client(), srv.URL, "", "cmdb.change", "1", []byte("{}")); err != nil {
+		t.Fatalf("PostWebhook: %v", err)
+	}
+}
+
+func TestPostWebhookServerError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "boom", http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	err := PostWebhook(context.Background(), srv.Client(), srv.URL, "", "cmdb.change", "1", []byte("{}"))
+	if err == nil || !strings.Contains(err.Error(), "502") || !strings.Contains(err.Error(), "boom") {
+		t.Fatalf("err = %v, want 502 with response body", err)
+	}
+}
+
+func TestPostWebhookTimeout(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	client := &http.Client{Timeout: 50 * time.Millisecond}
+	if err := PostWebhook(context.Background(), client, srv.URL, "", "cmdb.change", "1", []byte("{}")); err == nil {
+		t.Fatal("expected timeout error")
+	}
+}
+
+func TestSendMail(t *testing.T) {
+	srv := notifytest.NewSMTPServer(t)
+	conf := SMTPConfig{Host: srv.Host, Port: srv.Port, From: "cmdb@example.com"}
+
+	if err := SendMail(conf, []string{"ops@example.com", "dev@example.com"}, "资源变更", "line1\nline2"); err != nil {
+		t.Fatalf("SendMail: %v", err)
+	}
+	messages := srv.Messages()
+	if len(messages) != 1 {
+		t.Fatalf("got %d messages, want 1", len(messages))
+	}
+	msg := messages[0]
+	if msg.From != "cmdb@example.com" || strings.Join(msg.To, ",") != "ops@example.com,dev@example.com" {
+		t.Errorf("envelope = %s -> %v", msg.From, msg.To)
+	}
+	if !strings.Contains(msg.Data, "Subject: =?UTF-8?b?") {
+		t.Errorf("subject is not encoded:\n%s", msg.Data)
+	}
+	if !strings.Contains(msg.Data, "\r\n\r\nline1\r\nline2") {
+		t.Errorf("body not found:\n%s", msg.Data)
+	}
+}
+
+func TestSendMailRejected(t *testing.T) {
+	srv := notifytest.NewSMTPServer(t, "nobody@example.com")
+	conf := SMTPConfig{Host: srv.Host, Port: srv.Port, From: "cmdb@example.com"}
+
+	if err := SendMail(conf, []string{"nobody@example.com"}, "s", "b"); err == nil {
+		t.Fatal("expected error for rejected recipient")
+	}
+	if n := len(srv.Messages()); n != 0 {
+		t.Errorf("got %d messages, want 0", n)
+	}
+}
+
+func TestSendMailNotConfigured(t *testing.T) {
+	if err := SendMail(SMTPConfig{}, []string{"ops@example.com"}, "s", "b"); err == nil {
+		t.Fatal("expected error without smtp host")
+	}
+}
@@ -0,0 +1,127 @@
+// Package notifytest 提供测试用的本地SMTP服务，记录收到的邮件
+package notifytest
+
+import (
+	"bufio"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// Message 服务收到的一封邮件
+type Message struct {
+	From string
+	To   []string
+	Data string
+}
+
+// SMTPServer 只实现smtp.SendMail用到的命令，不支持STARTTLS和AUTH
+type SMTPServer struct {
+	Host string
+	Port int
+
+	reject   []string
+	listener net.Listener
+	mu       sync.Mutex
+	messages []Message
+}
+
+// NewSMTPServer 在127.0.0.1的随机端口启动服务，测试结束时关闭；对reject中的收件人返回550
+func NewSMTPServer(t testing.TB, reject ...string) *SMTPServer {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	addr := l.Addr().(*net.TCPAddr)
+	s := &SMTPServer{Host: addr.IP.String(), Port: addr.Port, reject: reject, listener: l}
+	go s.serve()
+	t.Cleanup(func() { _ = l.Close() })
+	return s
+}
+
+// Messages 返回已收到的邮件
+func (s *SMTPServer) Messages() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Message(nil), s.messages...)
+}
+
+func (s *SMTPServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *SMTPServer) handle(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(code int, text string) {
+		_, _ = conn.Write([]byte(strconv.Itoa(code) + " " + text + "\r\n"))
+	}
+	reply(220, "notifytest ESMTP")
+	var msg Message
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		cmd := strings.ToUpper(line)
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			reply(250, "notifytest")
+		case strings.HasPrefix(cmd, "MAIL FROM:"):
+			msg = Message{From: strings.Trim(line[len("MAIL FROM:"):], "<> ")}
+			reply(250, "OK")
+		case strings.HasPrefix(cmd, "RCPT TO:"):
+			rcpt := strings.Trim(line[len("RCPT TO:"):], "<> ")
+			if s.rejected(rcpt) {
+				reply(550, "mailbox unavailable")
+				continue
+			}
+			msg.To = append(msg.To, rcpt)
+			reply(250, "OK")
+		case cmd == "DATA":
+			reply(354, "end data with <CR><LF>.<CR><LF>")
+			var data strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				data.WriteString(strings.TrimPrefix(l, "."))
+			}
+			msg.Data = data.String()
+			s.mu.Lock()
+			s.messages = append(s.messages, msg)
+			s.mu.Unlock()
+			reply(250, "OK")
+		case cmd == "RSET", cmd == "NOOP":
+			reply(250, "OK")
+		case cmd == "QUIT":
+			reply(221, "bye")
+			return
+		default:
+			reply(502, "command not implemented")
+		}
+	}
+}
+
+func (s *SMTPServer) rejected(rcpt string) bool {
+	for _, r := range s.reject {
+		if r == rcpt {
+			return true
+		}
+	}
+	return false
+}
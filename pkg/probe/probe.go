@@ -0,0 +1,272 @@
+package probe
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 探测方式，对应ApplicationType.HealthCheckConfig的method
+const (
+	MethodTCP   = "tcp_connect"
+	MethodHTTP  = "http_get"
+	MethodHTTPS = "https_get"
+	MethodDNS   = "dns_query"
+)
+
+// Config 从HealthCheckConfig解析出的探测参数，timeout和interval单位为秒
+type Config struct {
+	Method string
+	// Port 为ListenPorts中的端口名或端口号，为空时取ListenPorts中的第一个端口(按名称排序)
+	Port             string
+	Host             string
+	Interval         time.Duration
+	Timeout          time.Duration
+	Threshold        int // 连续失败多少次标记为不健康
+	HealthyThreshold int // 连续成功多少次恢复为健康
+
+	Path               string
+	ExpectedStatus     []int
+	InsecureSkipVerify bool
+
+	Query        string
+	QueryType    string
+	ExpectAnswer bool
+}
+
+// ParseConfig 解析健康检查配置并填充默认值
+func ParseConfig(conf map[string]interface{}) (Config, error) {
+	c := Config{
+		Method:           stringValue(conf["method"]),
+		Port:             stringValue(conf["port"]),
+		Host:             stringValue(conf["host"]),
+		Interval:         seconds(conf["interval"], 30*time.Second),
+		Timeout:          seconds(conf["timeout"], 5*time.Second),
+		Threshold:        intValue(conf["threshold"], 3),
+		HealthyThreshold: intValue(conf["healthy_threshold"], 1),
+		Path:             stringValue(conf["path"]),
+		Query:            stringValue(conf["query"]),
+		QueryType:        strings.ToUpper(stringValue(conf["query_type"])),
+	}
+	c.InsecureSkipVerify, _ = conf["insecure_skip_verify"].(bool)
+	c.ExpectAnswer, _ = conf["expect_answer"].(bool)
+	if items, ok := conf["expected_status"].([]interface{}); ok {
+		for _, item := range items {
+			if code := intValue(item, 0); code > 0 {
+				c.ExpectedStatus = append(c.ExpectedStatus, code)
+			}
+		}
+	}
+	switch c.Method {
+	case MethodTCP, MethodHTTP, MethodHTTPS:
+	case MethodDNS:
+		if c.Query == "" {
+			return c, fmt.Errorf("dns_query requires query")
+		}
+		if c.QueryType == "" {
+			c.QueryType = "A"
+		}
+		if c.QueryType != "A" && c.QueryType != "AAAA" && c.QueryType != "TXT" {
+			return c, fmt.Errorf("unsupported query_type %s", c.QueryType)
+		}
+	case "":
+		return c, fmt.Errorf("method is required")
+	default:
+		return c, fmt.Errorf("unsupported method %s", c.Method)
+	}
+	if c.Path == "" {
+		c.Path = "/"
+	}
+	return c, nil
+}
+
+// DefaultPort 没有配置端口且ListenPorts为空时使用的端口
+func (c Config) DefaultPort() int {
+	switch c.Method {
+	case MethodDNS:
+		return 53
+	case MethodHTTP:
+		return 80
+	case MethodHTTPS:
+		return 443
+	}
+	return 0
+}
+
+// Result 一次探测的结果
+type Result struct {
+	Success bool
+	Latency time.Duration
+	Message string
+}
+
+// Run 对host:port执行一次探测，超时由c.Timeout控制
+func Run(ctx context.Context, c Config, host string, port int) Result {
+	ctx, cancel := context.WithTimeout(ctx, c.Timeout)
+	defer cancel()
+	addr := net.JoinHostPort(host, strconv.Itoa(port))
+	start := time.Now()
+	var message string
+	var err error
+	switch c.Method {
+	case MethodTCP:
+		message, err = probeTCP(ctx, addr)
+	case MethodHTTP, MethodHTTPS:
+		message, err = probeHTTP(ctx, c, addr)
+	case MethodDNS:
+		message, err = probeDNS(ctx, c, addr)
+	default:
+		err = fmt.Errorf("unsupported method %s", c.Method)
+	}
+	result := Result{Success: err == nil, Latency: time.Since(start), Message: message}
+	if err != nil {
+		result.Message = err.Error()
+	}
+	return result
+}
+
+func probeTCP(ctx context.Context, addr string) (string, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return "", err
+	}
+	_ = conn.Close()
+	return "connected", nil
+}
+
+func probeHTTP(ctx context.Context, c Config, addr string) (string, error) {
+	scheme := "http"
+	if c.Method == MethodHTTPS {
+		scheme = "https"
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, scheme+"://"+addr+c.Path, nil)
+	if err != nil {
+		return "", err
+	}
+	client := &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig:   &tls.Config{InsecureSkipVerify: c.InsecureSkipVerify},
+			DisableKeepAlives: true,
+		},
+		// 不跟随重定向，3xx本身即为服务正常的响应
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	_ = resp.Body.Close()
+	message := "HTTP " + strconv.Itoa(resp.StatusCode)
+	if len(c.ExpectedStatus) > 0 {
+		for _, code := range c.ExpectedStatus {
+			if resp.StatusCode == code {
+				return message, nil
+			}
+		}
+		return "", fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	if resp.StatusCode >= 400 {
+		return "", fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return message, nil
+}
+
+// probeDNS 向addr发送查询。服务器返回NXDOMAIN或空应答也说明服务正常，除非配置了expect_answer
+func probeDNS(ctx context.Context, c Config, addr string) (string, error) {
+	resolver := &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, addr)
+		},
+	}
+	query := c.Query
+	if !strings.HasSuffix(query, ".") {
+		query += "."
+	}
+	var answers int
+	var err error
+	switch c.QueryType {
+	case "AAAA":
+		var ips []net.IP
+		ips, err = resolver.LookupIP(ctx, "ip6", query)
+		answers = len(ips)
+	case "TXT":
+		var txts []string
+		txts, err = resolver.LookupTXT(ctx, query)
+		answers = len(txts)
+	default:
+		var ips []net.IP
+		ips, err = resolver.LookupIP(ctx, "ip4", query)
+		answers = len(ips)
+	}
+	var dnsErr *net.DNSError
+	if err != nil && !(errors.As(err, &dnsErr) && dnsErr.IsNotFound) {
+		return "", err
+	}
+	if answers == 0 {
+		if c.ExpectAnswer {
+			return "", fmt.Errorf("no %s record for %s", c.QueryType, c.Query)
+		}
+		return "no answer", nil
+	}
+	return strconv.Itoa(answers) + " answers", nil
+}
+
+func stringValue(v interface{}) string {
+	switch value := v.(type) {
+	case string:
+		return value
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	case int:
+		return strconv.Itoa(value)
+	}
+	return ""
+}
+
+func intValue(v interface{}, def int) int {
+	switch value := v.(type) {
+	case float64:
+		if value > 0 {
+			return int(math.Round(value))
+		}
+	case int:
+		if value > 0 {
+			return value
+		}
+	case string:
+		if n, err := strconv.Atoi(value); err == nil && n > 0 {
+			return n
+		}
+	}
+	return def
+}
+
+func seconds(v interface{}, def time.Duration) time.Duration {
+	switch value := v.(type) {
+	case float64:
+		if value > 0 {
+			return time.Duration(value * float64(time.Second))
+		}
+	case int:
+		if value > 0 {
+			return time.Duration(value) * time.Second
+		}
+	case string:
+		if d, err := time.ParseDuration(value); err == nil && d > 0 {
+			return d
+		}
+	}
+	return def
+}
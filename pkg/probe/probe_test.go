@@ -0,0 +1,339 @@
+package probe
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// hostPort 拆分本地监听地址，供Run使用
+func hostPort(t *testing.T, addr string) (string, int) {
+	t.Helper()
+	host, p, err := net.SplitHostPort(addr)
+	if err != nil {
+		t.Fatalf("split %s: %v", addr, err)
+	}
+	port, _ := strconv.Atoi(p)
+	return host, port
+}
+
+// closedPort 返回一个刚释放的本地端口，连接会被拒绝
+func closedPort(t *testing.T) int {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	_, port := hostPort(t, l.Addr().String())
+	_ = l.Close()
+	return port
+}
+
+func TestRunTCP(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer l.Close()
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			_ = conn.Close()
+		}
+	}()
+	c := Config{Method: MethodTCP, Timeout: time.Second}
+	host, port := hostPort(t, l.Addr().String())
+	if r := Run(context.Background(), c, host, port); !r.Success || r.Message != "connected" {
+		t.Errorf("Run = %+v, want success", r)
+	}
+}
+
+func TestRunTCPRefused(t *testing.T) {
+	c := Config{Method: MethodTCP, Timeout: time.Second}
+	r := Run(context.Background(), c, "127.0.0.1", closedPort(t))
+	if r.Success {
+		t.Fatalf("Run = %+v, want failure", r)
+	}
+	if !strings.Contains(r.Message, "refused") {
+		t.Errorf("message = %q, want connection refused", r.Message)
+	}
+}
+
+func TestRunHTTP(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/health":
+			w.WriteHeader(http.StatusOK)
+		case "/redirect":
+			http.Redirect(w, r, "/missing", http.StatusFound)
+		case "/teapot":
+			w.WriteHeader(http.StatusTeapot)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	host, port := hostPort(t, srv.Listener.Addr().String())
+
+	tests := []struct {
+		name     string
+		path     string
+		expected []int
+		success  bool
+		message  string
+	}{
+		{name: "ok", path: "/health", success: true, message: "HTTP 200"},
+		{name: "redirect not followed", path: "/redirect", success: true, message: "HTTP 302"},
+		{name: "client error", path: "/missing", message: "unexpected status 404"},
+		{name: "expected status", path: "/teapot", expected: []int{418}, success: true, message: "HTTP 418"},
+		{name: "expected status mismatch", path: "/health", expected: []int{204, 301}, message: "unexpected status 200"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := Config{Method: MethodHTTP, Timeout: time.Second, Path: tt.path, ExpectedStatus: tt.expected}
+			r := Run(context.Background(), c, host, port)
+			if r.Success != tt.success || r.Message != tt.message {
+				t.Errorf("Run = %+v, want success=%v message=%q", r, tt.success, tt.message)
+			}
+		})
+	}
+}
+
+func TestRunHTTPS(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	host, port := hostPort(t, srv.Listener.Addr().String())
+
+	c := Config{Method: MethodHTTPS, Timeout: time.Second, Path: "/"}
+	if r := Run(context.Background(), c, host, port); r.Success {
+		t.Errorf("Run with self-signed certificate = %+v, want failure", r)
+	}
+	c.InsecureSkipVerify = true
+	if r := Run(context.Background(), c, host, port); !r.Success {
+		t.Errorf("Run with insecure_skip_verify = %+v, want success", r)
+	}
+}
+
+func TestRunHTTPRefused(t *testing.T) {
+	c := Config{Method: MethodHTTP, Timeout: time.Second, Path: "/"}
+	if r := Run(context.Background(), c, "127.0.0.1", closedPort(t)); r.Success || !strings.Contains(r.Message, "refused") {
+		t.Errorf("Run = %+v, want connection refused", r)
+	}
+}
+
+func TestRunHTTPTimeout(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+	host, port := hostPort(t, srv.Listener.Addr().String())
+
+	c := Config{Method: MethodHTTP, Timeout: 100 * time.Millisecond, Path: "/"}
+	start := time.Now()
+	r := Run(context.Background(), c, host, port)
+	if r.Success || !strings.Contains(r.Message, "deadline exceeded") {
+		t.Errorf("Run = %+v, want timeout", r)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Run took %s, want about %s", elapsed, c.Timeout)
+	}
+}
+
+// dnsServer 本地UDP DNS桩：records中的名称(带结尾的点)返回对应的A/TXT记录，其余返回NXDOMAIN；
+// silent为true时不回复任何查询
+type dnsServer struct {
+	conn    net.PacketConn
+	records map[string][]string
+	silent  bool
+}
+
+func newDNSServer(t *testing.T, records map[string][]string, silent bool) *dnsServer {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen udp: %v", err)
+	}
+	s := &dnsServer{conn: conn, records: records, silent: silent}
+	t.Cleanup(func() { _ = conn.Close() })
+	go s.serve()
+	return s
+}
+
+func (s *dnsServer) serve() {
+	buf := make([]byte, 1500)
+	for {
+		n, addr, err := s.conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		if s.silent {
+			continue
+		}
+		if resp := s.answer(buf[:n]); resp != nil {
+			_, _ = s.conn.WriteTo(resp, addr)
+		}
+	}
+}
+
+const (
+	dnsTypeA    = 1
+	dnsTypeTXT  = 16
+	dnsTypeAAAA = 28
+)
+
+// answer 按RFC 1035手工构造应答，只处理单个问题的查询
+func (s *dnsServer) answer(query []byte) []byte {
+	if len(query) < 12 {
+		return nil
+	}
+	// 读取问题中的名称
+	var labels []string
+	i := 12
+	for i < len(query) && query[i] != 0 {
+		size := int(query[i])
+		if i+1+size > len(query) {
+			return nil
+		}
+		labels = append(labels, string(query[i+1:i+1+size]))
+		i += 1 + size
+	}
+	end := i + 5 // 结尾的0、qtype和qclass
+	if end > len(query) {
+		return nil
+	}
+	name := strings.ToLower(strings.Join(labels, ".")) + "."
+	qtype := binary.BigEndian.Uint16(query[i+1:])
+
+	values, found := s.records[name]
+	var answers [][]byte
+	for _, value := range values {
+		var rdata []byte
+		var rtype uint16
+		if ip := net.ParseIP(value); ip != nil {
+			if ip4 := ip.To4(); ip4 != nil {
+				rtype, rdata = dnsTypeA, ip4
+			} else {
+				rtype, rdata = dnsTypeAAAA, ip.To16()
+			}
+		} else {
+			rtype, rdata = dnsTypeTXT, append([]byte{byte(len(value))}, value...)
+		}
+		if rtype != qtype {
+			continue
+		}
+		rr := []byte{0xc0, 12} // 指向问题中的名称
+		rr = binary.BigEndian.AppendUint16(rr, rtype)
+		rr = binary.BigEndian.AppendUint16(rr, 1) // IN
+		rr = binary.BigEndian.AppendUint32(rr, 60)
+		rr = binary.BigEndian.AppendUint16(rr, uint16(len(rdata)))
+		answers = append(answers, append(rr, rdata...))
+	}
+
+	resp := append([]byte{}, query[:2]...) // ID
+	flags := uint16(0x8180)                // QR、RD、RA
+	if !found {
+		flags |= 3 // NXDOMAIN
+	}
+	resp = binary.BigEndian.AppendUint16(resp, flags)
+	resp = binary.BigEndian.AppendUint16(resp, 1)
+	resp = binary.BigEndian.AppendUint16(resp, uint16(len(answers)))
+	resp = binary.BigEndian.AppendUint16(resp, 0)
+	resp = binary.BigEndian.AppendUint16(resp, 0)
+	resp = append(resp, query[12:end]...)
+	for _, rr := range answers {
+		resp = append(resp, rr...)
+	}
+	return resp
+}
+
+func TestRunDNS(t *testing.T) {
+	srv := newDNSServer(t, map[string][]string{
+		"app.example.com.":   {"10.0.0.1", "10.0.0.2", "fd00::1", "v=spf1 -all"},
+		"empty.example.com.": nil,
+	}, false)
+	host, port := hostPort(t, srv.conn.LocalAddr().String())
+
+	tests := []struct {
+		name         string
+		query        string
+		queryType    string
+		expectAnswer bool
+		success      bool
+		message      string
+	}{
+		{name: "A", query: "app.example.com", queryType: "A", success: true, message: "2 answers"},
+		{name: "AAAA", query: "app.example.com", queryType: "AAAA", success: true, message: "1 answers"},
+		{name: "TXT", query: "app.example.com.", queryType: "TXT", success: true, message: "1 answers"},
+		{name: "NXDOMAIN", query: "missing.example.com", queryType: "A", success: true, message: "no answer"},
+		{name: "NXDOMAIN expect answer", query: "missing.example.com", queryType: "A", expectAnswer: true, message: "no A record for missing.example.com"},
+		{name: "no data expect answer", query: "empty.example.com", queryType: "A", expectAnswer: true, message: "no A record for empty.example.com"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := Config{Method: MethodDNS, Timeout: time.Second, Query: tt.query, QueryType: tt.queryType, ExpectAnswer: tt.expectAnswer}
+			r := Run(context.Background(), c, host, port)
+			if r.Success != tt.success || r.Message != tt.message {
+				t.Errorf("Run = %+v, want success=%v message=%q", r, tt.success, tt.message)
+			}
+		})
+	}
+}
+
+func TestRunDNSTimeout(t *testing.T) {
+	srv := newDNSServer(t, nil, true)
+	host, port := hostPort(t, srv.conn.LocalAddr().String())
+
+	c := Config{Method: MethodDNS, Timeout: 200 * time.Millisecond, Query: "app.example.com", QueryType: "A"}
+	start := time.Now()
+	r := Run(context.Background(), c, host, port)
+	if r.Success {
+		t.Fatalf("Run = %+v, want failure", r)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Run took %s, want about %s", elapsed, c.Timeout)
+	}
+}
+
+func TestParseConfig(t *testing.T) {
+	c, err := ParseConfig(map[string]interface{}{
+		"method":            "http_get",
+		"timeout":           float64(2),
+		"threshold":         float64(5),
+		"healthy_threshold": "2",
+		"expected_status":   []interface{}{float64(200), float64(204)},
+	})
+	if err != nil {
+		t.Fatalf("ParseConfig: %v", err)
+	}
+	if c.Timeout != 2*time.Second || c.Interval != 30*time.Second || c.Threshold != 5 || c.HealthyThreshold != 2 || c.Path != "/" {
+		t.Errorf("ParseConfig = %+v", c)
+	}
+	if len(c.ExpectedStatus) != 2 || c.ExpectedStatus[0] != 200 || c.ExpectedStatus[1] != 204 {
+		t.Errorf("ExpectedStatus = %v, want [200 204]", c.ExpectedStatus)
+	}
+
+	for _, conf := range []map[string]interface{}{
+		{},
+		{"method": "icmp"},
+		{"method": "dns_query"},
+		{"method": "dns_query", "query": "example.com", "query_type": "MX"},
+	} {
+		if _, err := ParseConfig(conf); err == nil {
+			t.Errorf("ParseConfig(%v) succeeded, want error", conf)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// Change 两个schema版本之间的一项差异
+type Change struct {
+	Field      string `json:"field"`
+	Message    string `json:"message"`
+	Compatible bool   `json:"compatible"`
+}
+
+// Compare 比较新旧schema，返回全部差异以及新schema是否向后兼容(旧数据在新schema下依然合法)
+func Compare(oldSchema, newSchema map[string]interface{}) (bool, []Change) {
+	changes := make([]Change, 0)
+	compare(Normalize(oldSchema), Normalize(newSchema), "", &changes)
+	compatible := true
+	for _, c := range changes {
+		if !c.Compatible {
+			compatible = false
+			break
+		}
+	}
+	return compatible, changes
+}
+
+// Normalize 通过JSON编解码把任意值转换为标准的JSON类型(map[string]interface{}/[]interface{}/float64...)
+func Normalize(v interface{}) map[string]interface{} {
+	out := map[string]interface{}{}
+	if v == nil {
+		return out
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return out
+	}
+	_ = json.Unmarshal(b, &out)
+	return out
+}
+
+func compare(oldSchema, newSchema map[string]interface{}, path string, out *[]Change) {
+	field := path
+	if field == "" {
+		field = "$"
+	}
+	if !reflect.DeepEqual(oldSchema["type"], newSchema["type"]) && newSchema["type"] != nil {
+		*out = append(*out, Change{Field: field, Message: fmt.Sprintf("类型由%v变更为%v", oldSchema["type"], newSchema["type"])})
+	}
+	if !reflect.DeepEqual(oldSchema["format"], newSchema["format"]) && newSchema["format"] != nil {
+		*out = append(*out, Change{Field: field, Message: fmt.Sprintf("格式由%v变更为%v", oldSchema["format"], newSchema["format"])})
+	}
+	if !reflect.DeepEqual(oldSchema["pattern"], newSchema["pattern"]) && newSchema["pattern"] != nil {
+		*out = append(*out, Change{Field: field, Message: fmt.Sprintf("正则约束变更为%v", newSchema["pattern"])})
+	}
+	compareEnum(oldSchema, newSchema, field, out)
+	compareBound(oldSchema, newSchema, field, "minimum", true, out)
+	compareBound(oldSchema, newSchema, field, "maximum", false, out)
+	compareBound(oldSchema, newSchema, field, "minLength", true, out)
+	compareBound(oldSchema, newSchema, field, "maxLength", false, out)
+	compareBound(oldSchema, newSchema, field, "minItems", true, out)
+	compareBound(oldSchema, newSchema, field, "maxItems", false, out)
+
+	oldRequired, newRequired := toSet(oldSchema["required"]), toSet(newSchema["required"])
+	for name := range newRequired {
+		if _, ok := oldRequired[name]; !ok {
+			*out = append(*out, Change{Field: join(path, name), Message: "新增必填字段"})
+		}
+	}
+	for name := range oldRequired {
+		if _, ok := newRequired[name]; !ok {
+			*out = append(*out, Change{Field: join(path, name), Message: "取消必填", Compatible: true})
+		}
+	}
+	if additional, ok := newSchema["additionalProperties"].(bool); ok && !additional {
+		if old, ok := oldSchema["additionalProperties"].(bool); !ok || old {
+			*out = append(*out, Change{Field: field, Message: "不再允许未定义字段"})
+		}
+	}
+
+	oldProps, _ := AsMap(oldSchema["properties"])
+	newProps, _ := AsMap(newSchema["properties"])
+	names := make([]string, 0, len(oldProps)+len(newProps))
+	for name := range oldProps {
+		names = append(names, name)
+	}
+	for name := range newProps {
+		if _, ok := oldProps[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		oldProp, inOld := AsMap(oldProps[name])
+		newProp, inNew := AsMap(newProps[name])
+		switch {
+		case inOld && !inNew:
+			*out = append(*out, Change{Field: join(path, name), Message: "删除字段定义"})
+		case !inOld && inNew:
+			*out = append(*out, Change{Field: join(path, name), Message: "新增字段定义", Compatible: true})
+		default:
+			compare(oldProp, newProp, join(path, name), out)
+		}
+	}
+	if oldItems, ok := AsMap(oldSchema["items"]); ok {
+		if newItems, ok := AsMap(newSchema["items"]); ok {
+			compare(oldItems, newItems, path+"[]", out)
+		}
+	}
+}
+
+func compareEnum(oldSchema, newSchema map[string]interface{}, field string, out *[]Change) {
+	newEnum, ok := AsSlice(newSchema["enum"])
+	if !ok {
+		if _, hadEnum := AsSlice(oldSchema["enum"]); hadEnum {
+			*out = append(*out, Change{Field: field, Message: "取消枚举约束", Compatible: true})
+		}
+		return
+	}
+	oldEnum, hadEnum := AsSlice(oldSchema["enum"])
+	if !hadEnum {
+		*out = append(*out, Change{Field: field, Message: "新增枚举约束"})
+		return
+	}
+	for _, o := range oldEnum {
+		found := false
+		for _, n := range newEnum {
+			if equal(o, n) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			*out = append(*out, Change{Field: field, Message: fmt.Sprintf("枚举值%v被移除", o)})
+		}
+	}
+}
+
+// compareBound 比较数值边界，lower为true表示下界(变大即收紧)，否则为上界(变小即收紧)
+func compareBound(oldSchema, newSchema map[string]interface{}, field, key string, lower bool, out *[]Change) {
+	newValue, hasNew := AsNumber(newSchema[key])
+	if !hasNew {
+		return
+	}
+	oldValue, hasOld := AsNumber(oldSchema[key])
+	if hasOld && oldValue == newValue {
+		return
+	}
+	tightened := !hasOld || (lower && newValue > oldValue) || (!lower && newValue < oldValue)
+	*out = append(*out, Change{Field: field, Message: fmt.Sprintf("%s变更为%v", key, newValue), Compatible: !tightened})
+}
+
+func toSet(v interface{}) map[string]struct{} {
+	set := map[string]struct{}{}
+	items, _ := AsSlice(v)
+	for _, item := range items {
+		set[fmt.Sprint(item)] = struct{}{}
+	}
+	return set
+}
@@ -0,0 +1,292 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Violation 一条字段级校验失败信息
+type Violation struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+var hostnameRegexp = regexp.MustCompile(`^([a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)(\.[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)*$`)
+
+// Validate 按JSON-Schema子集校验data，返回全部校验失败的字段。
+// 支持的关键字: type/properties/required/enum/format/minimum/maximum/
+// minLength/maxLength/pattern/items/minItems/maxItems/additionalProperties
+func Validate(schema map[string]interface{}, data interface{}) []Violation {
+	violations := make([]Violation, 0)
+	validate(schema, data, "", &violations)
+	return violations
+}
+
+func validate(schema map[string]interface{}, value interface{}, path string, out *[]Violation) {
+	if len(schema) == 0 {
+		return
+	}
+	field := path
+	if field == "" {
+		field = "$"
+	}
+	if t, ok := schema["type"].(string); ok && value != nil {
+		if !matchType(t, value) {
+			*out = append(*out, Violation{Field: field, Message: fmt.Sprintf("类型应为%s", t)})
+			return
+		}
+	}
+	if enum, ok := AsSlice(schema["enum"]); ok && value != nil {
+		matched := false
+		for _, e := range enum {
+			if equal(e, value) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			options := make([]string, 0, len(enum))
+			for _, e := range enum {
+				options = append(options, fmt.Sprint(e))
+			}
+			*out = append(*out, Violation{Field: field, Message: "取值必须为以下之一: " + strings.Join(options, ", ")})
+		}
+	}
+	if s, ok := value.(string); ok {
+		validateString(schema, s, field, out)
+	}
+	if n, ok := AsNumber(value); ok {
+		if min, ok := AsNumber(schema["minimum"]); ok && n < min {
+			*out = append(*out, Violation{Field: field, Message: fmt.Sprintf("不能小于%v", min)})
+		}
+		if max, ok := AsNumber(schema["maximum"]); ok && n > max {
+			*out = append(*out, Violation{Field: field, Message: fmt.Sprintf("不能大于%v", max)})
+		}
+	}
+	if items, ok := AsSlice(value); ok {
+		if min, ok := AsNumber(schema["minItems"]); ok && float64(len(items)) < min {
+			*out = append(*out, Violation{Field: field, Message: fmt.Sprintf("元素个数不能少于%v", min)})
+		}
+		if max, ok := AsNumber(schema["maxItems"]); ok && float64(len(items)) > max {
+			*out = append(*out, Violation{Field: field, Message: fmt.Sprintf("元素个数不能多于%v", max)})
+		}
+		if itemSchema, ok := AsMap(schema["items"]); ok {
+			for i, item := range items {
+				validate(itemSchema, item, fmt.Sprintf("%s[%d]", path, i), out)
+			}
+		}
+	}
+	if obj, ok := AsMap(value); ok {
+		validateObject(schema, obj, path, out)
+	}
+}
+
+func validateObject(schema map[string]interface{}, obj map[string]interface{}, path string, out *[]Violation) {
+	properties, _ := AsMap(schema["properties"])
+	if required, ok := AsSlice(schema["required"]); ok {
+		for _, r := range required {
+			name := fmt.Sprint(r)
+			if v, exists := obj[name]; !exists || v == nil {
+				*out = append(*out, Violation{Field: join(path, name), Message: "缺少必填字段"})
+			}
+		}
+	}
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		propSchema, ok := AsMap(properties[k])
+		if !ok {
+			if additional, isBool := schema["additionalProperties"].(bool); isBool && !additional {
+				*out = append(*out, Violation{Field: join(path, k), Message: "不允许的字段"})
+			} else if additionalSchema, isMap := AsMap(schema["additionalProperties"]); isMap {
+				validate(additionalSchema, obj[k], join(path, k), out)
+			}
+			continue
+		}
+		validate(propSchema, obj[k], join(path, k), out)
+	}
+}
+
+func validateString(schema map[string]interface{}, s string, field string, out *[]Violation) {
+	length := float64(len([]rune(s)))
+	if min, ok := AsNumber(schema["minLength"]); ok && length < min {
+		*out = append(*out, Violation{Field: field, Message: fmt.Sprintf("长度不能少于%v", min)})
+	}
+	if max, ok := AsNumber(schema["maxLength"]); ok && length > max {
+		*out = append(*out, Violation{Field: field, Message: fmt.Sprintf("长度不能超过%v", max)})
+	}
+	if pattern, ok := schema["pattern"].(string); ok {
+		re, err := regexp.Compile(pattern)
+		if err != nil || !re.MatchString(s) {
+			*out = append(*out, Violation{Field: field, Message: "不匹配格式" + pattern})
+		}
+	}
+	if format, ok := schema["format"].(string); ok && !matchFormat(format, s) {
+		*out = append(*out, Violation{Field: field, Message: "格式应为" + format})
+	}
+}
+
+func matchType(t string, value interface{}) bool {
+	switch t {
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "integer":
+		n, ok := AsNumber(value)
+		return ok && n == math.Trunc(n)
+	case "number":
+		_, ok := AsNumber(value)
+		return ok
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "array":
+		_, ok := AsSlice(value)
+		return ok
+	case "object":
+		_, ok := AsMap(value)
+		return ok
+	case "null":
+		return value == nil
+	}
+	// 未知类型不做限制
+	return true
+}
+
+func matchFormat(format string, s string) bool {
+	switch format {
+	case "ipv4":
+		ip := net.ParseIP(s)
+		return ip != nil && ip.To4() != nil && !strings.Contains(s, ":")
+	case "ipv6":
+		ip := net.ParseIP(s)
+		return ip != nil && strings.Contains(s, ":")
+	case "ip":
+		return net.ParseIP(s) != nil
+	case "cidr":
+		_, _, err := net.ParseCIDR(s)
+		return err == nil
+	case "mac":
+		_, err := net.ParseMAC(s)
+		return err == nil
+	case "email":
+		_, err := mail.ParseAddress(s)
+		return err == nil
+	case "hostname":
+		return len(s) <= 253 && hostnameRegexp.MatchString(s)
+	case "uri", "url":
+		u, err := url.Parse(s)
+		return err == nil && u.Scheme != "" && u.Host != ""
+	case "date-time":
+		_, err := time.Parse(time.RFC3339, s)
+		return err == nil
+	case "date":
+		_, err := time.Parse("2006-01-02", s)
+		return err == nil
+	}
+	return true
+}
+
+// AsMap 将任意键为字符串的map(包括具名类型如model.JSONMap)转换为map[string]interface{}
+func AsMap(v interface{}) (map[string]interface{}, bool) {
+	if v == nil {
+		return nil, false
+	}
+	if m, ok := v.(map[string]interface{}); ok {
+		return m, true
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Map || rv.Type().Key().Kind() != reflect.String {
+		return nil, false
+	}
+	m := make(map[string]interface{}, rv.Len())
+	iter := rv.MapRange()
+	for iter.Next() {
+		m[iter.Key().String()] = iter.Value().Interface()
+	}
+	return m, true
+}
+
+// AsSlice 将任意切片/数组转换为[]interface{}
+func AsSlice(v interface{}) ([]interface{}, bool) {
+	if v == nil {
+		return nil, false
+	}
+	if s, ok := v.([]interface{}); ok {
+		return s, true
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, false
+	}
+	if rv.Type().Elem().Kind() == reflect.Uint8 {
+		// []byte按字符串处理
+		return nil, false
+	}
+	s := make([]interface{}, rv.Len())
+	for i := 0; i < rv.Len(); i++ {
+		s[i] = rv.Index(i).Interface()
+	}
+	return s, true
+}
+
+// AsNumber 将JSON数字或Go数值类型转换为float64
+func AsNumber(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int8:
+		return float64(n), true
+	case int16:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint:
+		return float64(n), true
+	case uint8:
+		return float64(n), true
+	case uint16:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func equal(a, b interface{}) bool {
+	if na, ok := AsNumber(a); ok {
+		nb, ok := AsNumber(b)
+		return ok && na == nb
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func join(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}